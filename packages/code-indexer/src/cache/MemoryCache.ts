@@ -2,10 +2,15 @@
  * In-memory cache implementation
  */
 
-import type { SymbolCache, IndexedSymbol } from "../engine/types.ts";
+import type {
+  SymbolCache,
+  IndexedSymbol,
+  CachedReference,
+} from "../engine/types.ts";
 
 export class MemoryCache implements SymbolCache {
   private cache: Map<string, IndexedSymbol[]> = new Map();
+  private references: Map<string, CachedReference[]> = new Map();
 
   async get(filePath: string): Promise<IndexedSymbol[] | null> {
     return this.cache.get(filePath) || null;
@@ -13,9 +18,27 @@ export class MemoryCache implements SymbolCache {
 
   async set(filePath: string, symbols: IndexedSymbol[]): Promise<void> {
     this.cache.set(filePath, symbols);
+    this.references.clear();
   }
 
   async clear(): Promise<void> {
     this.cache.clear();
+    this.references.clear();
+  }
+
+  async remove(filePath: string): Promise<void> {
+    this.cache.delete(filePath);
+    this.references.clear();
+  }
+
+  async setReferences(
+    namePath: string,
+    references: CachedReference[],
+  ): Promise<void> {
+    this.references.set(namePath, references);
+  }
+
+  async getReferences(namePath: string): Promise<CachedReference[]> {
+    return this.references.get(namePath) ?? [];
   }
 }
@@ -0,0 +1,185 @@
+import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import {
+  cpSync,
+  mkdtempSync,
+  readFileSync,
+  rmSync,
+  utimesSync,
+  writeFileSync,
+} from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import { SymbolKind } from "vscode-languageserver-types";
+import { SQLiteCache } from "./SQLiteCache.ts";
+import type { IndexedSymbol } from "../engine/types.ts";
+import { getContentHash } from "../engine/contentHash.ts";
+
+describe("SQLiteCache persistence", () => {
+  let rootPath: string;
+  let filePath: string;
+  let cache: SQLiteCache;
+
+  const createSymbols = (): IndexedSymbol[] => [
+    {
+      name: "processUsers",
+      kind: SymbolKind.Function,
+      location: {
+        uri: pathToFileURL(filePath).toString(),
+        range: {
+          start: { line: 0, character: 0 },
+          end: { line: 2, character: 1 },
+        },
+      },
+    },
+  ];
+
+  beforeEach(() => {
+    rootPath = mkdtempSync(join(tmpdir(), "lsmcp-sqlite-cache-"));
+    filePath = join(rootPath, "users.ts");
+    writeFileSync(
+      filePath,
+      "export function processUsers() {\n  return [];\n}\n",
+    );
+    cache = new SQLiteCache(rootPath);
+  });
+
+  afterEach(() => {
+    cache.close();
+    rmSync(rootPath, { recursive: true, force: true });
+  });
+
+  it("should persist content hash with file info", async () => {
+    await cache.set(filePath, createSymbols(), { contentHash: "abc123" });
+
+    const info = await cache.getFileInfo(filePath);
+    expect(info?.contentHash).toBe("abc123");
+    expect(info?.lastModified).toBeGreaterThan(0);
+  });
+
+  it("should survive reopening the database", async () => {
+    await cache.set(filePath, createSymbols(), { contentHash: "abc123" });
+    await cache.setMetadata("lastGitHash", "deadbeef");
+    cache.close();
+
+    cache = new SQLiteCache(rootPath);
+    const symbols = await cache.get(filePath);
+    expect(symbols?.map((s) => s.name)).toEqual(["processUsers"]);
+    expect(await cache.getAllFiles()).toEqual([filePath]);
+    expect(await cache.getMetadata("lastGitHash")).toBe("deadbeef");
+  });
+
+  it("should remove file entries and their references", async () => {
+    await cache.set(filePath, createSymbols(), { contentHash: "abc123" });
+    await cache.setReferences("processUsers", [
+      {
+        namePath: "processUsers",
+        filePath: "users.ts",
+        startLine: 0,
+        startCharacter: 16,
+        endLine: 0,
+        endCharacter: 28,
+      },
+    ]);
+    expect(await cache.getReferences("processUsers")).toHaveLength(1);
+
+    await cache.remove(filePath);
+
+    expect(await cache.getFileInfo(filePath)).toBeNull();
+    expect(await cache.getReferences("processUsers")).toHaveLength(0);
+  });
+
+  it("should drop cached references when a file is indexed again", async () => {
+    await cache.set(filePath, createSymbols(), { contentHash: "abc123" });
+    await cache.setReferences("users.ts:0:16:declaration", [
+      {
+        namePath: "users.ts:0:16:declaration",
+        filePath: "users.ts",
+        startLine: 0,
+        startCharacter: 16,
+        endLine: 0,
+        endCharacter: 28,
+      },
+    ]);
+    expect(
+      await cache.getReferences("users.ts:0:16:declaration"),
+    ).toHaveLength(1);
+
+    await cache.set(filePath, createSymbols(), { contentHash: "def456" });
+
+    expect(
+      await cache.getReferences("users.ts:0:16:declaration"),
+    ).toHaveLength(0);
+  });
+
+  it("should store embeddings by embedder and content hash", async () => {
+    await cache.set(filePath, createSymbols(), { contentHash: "abc123" });
+    await cache.setEmbeddings(filePath, "local-4", {
+      contentHash: "abc123",
+      names: ["processUsers", "helper"],
+      docs: ["Process every user", null],
+      vectors: [
+        Float32Array.from([1, 0, 0, 0]),
+        Float32Array.from([0, 0.5, 0.5, 0]),
+      ],
+    });
+    cache.close();
+    cache = new SQLiteCache(rootPath);
+
+    const stored = await cache.getEmbeddings(filePath, "local-4", "abc123");
+    expect(stored?.names).toEqual(["processUsers", "helper"]);
+    expect(stored?.docs).toEqual(["Process every user", null]);
+    expect(stored?.vectors.map((vector) => Array.from(vector))).toEqual([
+      [1, 0, 0, 0],
+      [0, 0.5, 0.5, 0],
+    ]);
+    expect(await cache.getEmbeddings(filePath, "local-4", "def456")).toBeNull();
+    expect(await cache.getEmbeddings(filePath, "local-8", "abc123")).toBeNull();
+
+    await cache.remove(filePath);
+    expect(await cache.getEmbeddings(filePath, "local-4", "abc123")).toBeNull();
+  });
+
+  it("should keep entries of touched but unchanged files", async () => {
+    const contentHash = getContentHash(readFileSync(filePath, "utf-8"));
+    await cache.set(filePath, createSymbols(), { contentHash });
+    // A fresh checkout gives every file a new mtime
+    const later = new Date(Date.now() + 60_000);
+    utimesSync(filePath, later, later);
+
+    expect((await cache.get(filePath))?.map((s) => s.name)).toEqual([
+      "processUsers",
+    ]);
+
+    writeFileSync(filePath, "export function renamed() {}\n");
+    const evenLater = new Date(Date.now() + 120_000);
+    utimesSync(filePath, evenLater, evenLater);
+    expect(await cache.get(filePath)).toBeNull();
+  });
+
+  it("should adopt an index built under another root", async () => {
+    await cache.set(filePath, createSymbols(), { contentHash: "abc123" });
+    await cache.setMetadata("lastGitHash", "deadbeef");
+    cache.close();
+
+    const movedRoot = mkdtempSync(join(tmpdir(), "lsmcp-sqlite-moved-"));
+    try {
+      cpSync(rootPath, movedRoot, {
+        recursive: true,
+        preserveTimestamps: true,
+      });
+      cache = new SQLiteCache(movedRoot);
+
+      const movedFile = join(movedRoot, "users.ts");
+      expect(await cache.getAllFiles()).toEqual([movedFile]);
+      expect((await cache.get(movedFile))?.[0].location.uri).toBe(
+        pathToFileURL(movedFile).toString(),
+      );
+      expect(await cache.getMetadata("lastGitHash")).toBe("deadbeef");
+    } finally {
+      cache.close();
+      cache = new SQLiteCache(rootPath);
+      rmSync(movedRoot, { recursive: true, force: true });
+    }
+  });
+});
@@ -2,13 +2,20 @@
  * SQLite cache implementation using SymbolCacheManager
  */
 
-import type { SymbolCache, IndexedSymbol } from "../engine/types.ts";
+import type {
+  SymbolCache,
+  IndexedSymbol,
+  CachedFileMetadata,
+  CachedReference,
+  CachedEmbeddings,
+} from "../engine/types.ts";
 import { SymbolCacheManager } from "./SymbolCacheManager.ts";
 import type { SymbolEntry } from "../symbolIndex.ts";
 import { relative, join } from "path";
 import { statSync } from "fs";
 import { pathToFileURL } from "url";
-import { debugLogWithPrefix } from "../../../../src/utils/debugLog.ts";
+import { debugLogWithPrefix } from "../utils/logging.ts";
+import { getFileContentHash } from "../engine/contentHash.ts";
 
 export class SQLiteCache implements SymbolCache {
   private manager: SymbolCacheManager;
@@ -44,9 +51,17 @@ export class SQLiteCache implements SymbolCache {
       // Check if cache is still valid
       const cacheTime = cachedSymbols[0].lastModified;
       if (stats.mtimeMs > cacheTime) {
-        // File has been modified, invalidate cache
-        this.manager.invalidateFile(relativePath);
-        return null;
+        // A fresh checkout touches every file; trust the content hash
+        const storedHash = this.manager.getFileInfo(relativePath)?.contentHash;
+        if (
+          !storedHash ||
+          storedHash !== (await getFileContentHash(filePath))
+        ) {
+          // File has been modified, invalidate cache
+          this.manager.invalidateFile(relativePath);
+          return null;
+        }
+        this.manager.touchFile(relativePath, stats.mtimeMs);
       }
 
       // Convert cached symbols to IndexedSymbol format
@@ -56,7 +71,11 @@ export class SQLiteCache implements SymbolCache {
     }
   }
 
-  async set(filePath: string, symbols: IndexedSymbol[]): Promise<void> {
+  async set(
+    filePath: string,
+    symbols: IndexedSymbol[],
+    metadata?: { contentHash?: string },
+  ): Promise<void> {
     const relativePath = relative(this.rootPath, filePath);
     const stats = statSync(filePath);
     const lastModified = stats.mtimeMs;
@@ -65,12 +84,73 @@ export class SQLiteCache implements SymbolCache {
     const symbolEntries = this.convertIndexedToSymbolEntries(symbols);
 
     await this.manager.cacheSymbols(relativePath, symbolEntries, lastModified);
+    this.manager.setFileInfo(relativePath, lastModified, metadata?.contentHash);
+    // The file changed, so references found before may be gone or missing
+    this.manager.clearReferences();
   }
 
   async clear(): Promise<void> {
     this.manager.clearCache();
   }
 
+  /**
+   * Remove a file and its references from the cache
+   */
+  async remove(filePath: string): Promise<void> {
+    this.manager.invalidateFile(relative(this.rootPath, filePath));
+  }
+
+  async getMetadata(key: string): Promise<string | null> {
+    return this.manager.getMetadata(key);
+  }
+
+  async setMetadata(key: string, value: string): Promise<void> {
+    this.manager.setMetadata(key, value);
+  }
+
+  /**
+   * Store references to a symbol under a key naming it ("Parent/child", or
+   * where it was looked up); they are dropped when any file changes
+   */
+  async setReferences(
+    namePath: string,
+    references: CachedReference[],
+  ): Promise<void> {
+    this.manager.cacheReferences(namePath, references);
+  }
+
+  async getReferences(namePath: string): Promise<CachedReference[]> {
+    return this.manager.getReferences(namePath);
+  }
+
+  /**
+   * Store the embeddings of a file's symbols; they are found again for as
+   * long as the file keeps the content hash they were made from
+   */
+  async setEmbeddings(
+    filePath: string,
+    embedderId: string,
+    embeddings: CachedEmbeddings,
+  ): Promise<void> {
+    this.manager.cacheEmbeddings(
+      relative(this.rootPath, filePath),
+      embedderId,
+      embeddings,
+    );
+  }
+
+  async getEmbeddings(
+    filePath: string,
+    embedderId: string,
+    contentHash: string,
+  ): Promise<CachedEmbeddings | null> {
+    return this.manager.getEmbeddings(
+      relative(this.rootPath, filePath),
+      embedderId,
+      contentHash,
+    );
+  }
+
   private convertIndexedToSymbolEntries(
     symbols: IndexedSymbol[],
   ): SymbolEntry[] {
@@ -201,11 +281,20 @@ export class SQLiteCache implements SymbolCache {
   }
   
   /**
-   * Get cached file info with last modified time
+   * Get cached file info with last modified time and content hash
    */
-  async getFileInfo(filePath: string): Promise<{ lastModified: number } | null> {
+  async getFileInfo(filePath: string): Promise<CachedFileMetadata | null> {
     try {
       const relativePath = relative(this.rootPath, filePath);
+      const fileInfo = this.manager.getFileInfo(relativePath);
+      if (fileInfo) {
+        return {
+          lastModified: fileInfo.lastModified,
+          contentHash: fileInfo.contentHash,
+        };
+      }
+
+      // Fall back to symbol rows written before file metadata existed
       const cachedSymbols = this.manager.getSymbolsByFile(relativePath);
       
       if (cachedSymbols.length === 0) {
@@ -2,8 +2,11 @@ import { DatabaseSync, type StatementSync } from "node:sqlite";
 import { join } from "node:path";
 import { mkdirSync, existsSync } from "node:fs";
 import type { SymbolEntry } from "../symbolIndex.ts";
+import type { CachedEmbeddings, CachedReference } from "../engine/types.ts";
 import { SYMBOL_CACHE_SCHEMA_VERSION } from "@internal/types";
-import { debugLogWithPrefix } from "../../../../src/utils/debugLog.ts";
+import { debugLogWithPrefix } from "../utils/logging.ts";
+
+export type { CachedEmbeddings, CachedReference };
 
 // Define CachedSymbol type locally
 export interface CachedSymbol {
@@ -20,6 +23,24 @@ export interface CachedSymbol {
   projectRoot: string;
 }
 
+/**
+ * Per-file indexing metadata persisted alongside symbols
+ */
+export interface CachedFileInfo {
+  filePath: string;
+  lastModified: number;
+  contentHash?: string;
+  indexedAt: number;
+}
+
+const CACHE_TABLES = [
+  "symbols",
+  "files",
+  "symbol_references",
+  "symbol_embeddings",
+  "index_metadata",
+];
+
 export class SymbolCacheManager {
   private db: DatabaseSync;
   private insertStmt: StatementSync;
@@ -41,6 +62,7 @@ export class SymbolCacheManager {
     // Initialize database
     this.db = new DatabaseSync(dbPath);
     this.initializeDatabase();
+    this.adoptRelocatedRows();
 
     // Prepare statements
     this.insertStmt = this.db.prepare(`
@@ -102,7 +124,13 @@ export class SymbolCacheManager {
 
       // Drop existing tables if schema is outdated
       if (currentVersion > 0) {
-        this.db.exec(`DROP TABLE IF EXISTS symbols;`);
+        this.db.exec(`
+          DROP TABLE IF EXISTS symbols;
+          DROP TABLE IF EXISTS files;
+          DROP TABLE IF EXISTS symbol_references;
+          DROP TABLE IF EXISTS symbol_embeddings;
+          DROP TABLE IF EXISTS index_metadata;
+        `);
       }
 
       // Create symbols table with new schema
@@ -131,6 +159,7 @@ export class SymbolCacheManager {
         CREATE INDEX IF NOT EXISTS idx_symbols_project 
         ON symbols(projectRoot);
       `);
+      this.createAuxiliaryTables();
 
       // Update schema version
       this.db.exec(`
@@ -164,9 +193,62 @@ export class SymbolCacheManager {
         CREATE INDEX IF NOT EXISTS idx_symbols_project 
         ON symbols(projectRoot);
       `);
+      this.createAuxiliaryTables();
     }
   }
 
+  /**
+   * Create tables for file metadata, references, embeddings and
+   * index-level metadata
+   */
+  private createAuxiliaryTables(): void {
+    this.db.exec(`
+      CREATE TABLE IF NOT EXISTS files (
+        filePath TEXT NOT NULL,
+        projectRoot TEXT NOT NULL,
+        lastModified INTEGER NOT NULL,
+        contentHash TEXT,
+        indexedAt INTEGER NOT NULL,
+        PRIMARY KEY (filePath, projectRoot)
+      );
+
+      CREATE TABLE IF NOT EXISTS symbol_references (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        namePath TEXT NOT NULL,
+        filePath TEXT NOT NULL,
+        startLine INTEGER NOT NULL,
+        startCharacter INTEGER NOT NULL,
+        endLine INTEGER NOT NULL,
+        endCharacter INTEGER NOT NULL,
+        projectRoot TEXT NOT NULL
+      );
+
+      CREATE INDEX IF NOT EXISTS idx_references_name 
+      ON symbol_references(namePath, projectRoot);
+
+      CREATE INDEX IF NOT EXISTS idx_references_file 
+      ON symbol_references(filePath, projectRoot);
+
+      CREATE TABLE IF NOT EXISTS symbol_embeddings (
+        filePath TEXT NOT NULL,
+        projectRoot TEXT NOT NULL,
+        embedderId TEXT NOT NULL,
+        contentHash TEXT NOT NULL,
+        names TEXT NOT NULL,
+        docs TEXT NOT NULL,
+        vectors BLOB NOT NULL,
+        PRIMARY KEY (filePath, projectRoot, embedderId)
+      );
+
+      CREATE TABLE IF NOT EXISTS index_metadata (
+        key TEXT NOT NULL,
+        projectRoot TEXT NOT NULL,
+        value TEXT NOT NULL,
+        PRIMARY KEY (key, projectRoot)
+      );
+    `);
+  }
+
   cacheSymbols(
     filePath: string,
     symbols: SymbolEntry[],
@@ -253,15 +335,263 @@ export class SymbolCacheManager {
 
   invalidateFile(filePath: string): void {
     this.deleteByFileStmt.run(filePath, this.rootPath);
+    this.db
+      .prepare("DELETE FROM files WHERE filePath = ? AND projectRoot = ?")
+      .run(filePath, this.rootPath);
+    this.db
+      .prepare(
+        "DELETE FROM symbol_embeddings WHERE filePath = ? AND projectRoot = ?",
+      )
+      .run(filePath, this.rootPath);
+    // A changed file can add or drop references to any symbol
+    this.clearReferences();
+  }
+
+  /**
+   * Move rows recorded under another root to this one
+   * The database lives inside the project, so rows for a single different
+   * root mean the project was moved or the index was built elsewhere (e.g.
+   * in CI) and copied in. File paths are relative and stay valid.
+   */
+  private adoptRelocatedRows(): void {
+    const roots = this.db
+      .prepare(
+        `SELECT DISTINCT projectRoot FROM symbols
+        UNION
+        SELECT DISTINCT projectRoot FROM files`,
+      )
+      .all() as { projectRoot: string }[];
+    if (roots.length !== 1 || roots[0].projectRoot === this.rootPath) {
+      return;
+    }
+    const previousRoot = roots[0].projectRoot;
+    debugLogWithPrefix(
+      "SymbolCache",
+      `Adopting index built at ${previousRoot} for ${this.rootPath}`,
+    );
+    this.db.exec("BEGIN TRANSACTION");
+    try {
+      for (const table of CACHE_TABLES) {
+        this.db
+          .prepare(`UPDATE ${table} SET projectRoot = ? WHERE projectRoot = ?`)
+          .run(this.rootPath, previousRoot);
+      }
+      this.db.exec("COMMIT");
+    } catch (error) {
+      this.db.exec("ROLLBACK");
+      throw error;
+    }
+  }
+
+  /**
+   * Record a new mtime for a file whose content did not change
+   */
+  touchFile(filePath: string, lastModified: number): void {
+    for (const table of ["symbols", "files"]) {
+      this.db
+        .prepare(
+          `UPDATE ${table} SET lastModified = ? WHERE filePath = ? AND projectRoot = ?`,
+        )
+        .run(lastModified, filePath, this.rootPath);
+    }
   }
 
   clearCache(): void {
-    this.db.exec("DELETE FROM symbols WHERE projectRoot = ?");
+    for (const table of CACHE_TABLES) {
+      this.db
+        .prepare(`DELETE FROM ${table} WHERE projectRoot = ?`)
+        .run(this.rootPath);
+    }
+  }
+
+  /**
+   * Record mtime and content hash for an indexed file
+   */
+  setFileInfo(
+    filePath: string,
+    lastModified: number,
+    contentHash?: string,
+  ): void {
+    this.db
+      .prepare(
+        `INSERT OR REPLACE INTO files (
+          filePath, projectRoot, lastModified, contentHash, indexedAt
+        ) VALUES (?, ?, ?, ?, ?)`,
+      )
+      .run(
+        filePath,
+        this.rootPath,
+        lastModified,
+        contentHash ?? null,
+        Date.now(),
+      );
+  }
+
+  getFileInfo(filePath: string): CachedFileInfo | null {
+    const row = this.db
+      .prepare(
+        "SELECT filePath, lastModified, contentHash, indexedAt FROM files WHERE filePath = ? AND projectRoot = ?",
+      )
+      .get(filePath, this.rootPath) as
+      | (Omit<CachedFileInfo, "contentHash"> & { contentHash: string | null })
+      | undefined;
+
+    if (!row) {
+      return null;
+    }
+
+    return {
+      filePath: row.filePath,
+      lastModified: row.lastModified,
+      contentHash: row.contentHash ?? undefined,
+      indexedAt: row.indexedAt,
+    };
+  }
+
+  /**
+   * Replace the cached references to a symbol
+   */
+  cacheReferences(namePath: string, references: CachedReference[]): void {
+    this.db.exec("BEGIN TRANSACTION");
+
+    try {
+      this.db
+        .prepare(
+          "DELETE FROM symbol_references WHERE namePath = ? AND projectRoot = ?",
+        )
+        .run(namePath, this.rootPath);
+
+      const insert = this.db.prepare(`
+        INSERT INTO symbol_references (
+          namePath, filePath, startLine, startCharacter,
+          endLine, endCharacter, projectRoot
+        ) VALUES (?, ?, ?, ?, ?, ?, ?)
+      `);
+
+      for (const ref of references) {
+        insert.run(
+          namePath,
+          ref.filePath,
+          ref.startLine,
+          ref.startCharacter,
+          ref.endLine,
+          ref.endCharacter,
+          this.rootPath,
+        );
+      }
+
+      this.db.exec("COMMIT");
+    } catch (error) {
+      this.db.exec("ROLLBACK");
+      throw error;
+    }
+  }
+
+  getReferences(namePath: string): CachedReference[] {
+    return this.db
+      .prepare(
+        `SELECT namePath, filePath, startLine, startCharacter, endLine, endCharacter
+        FROM symbol_references
+        WHERE namePath = ? AND projectRoot = ?
+        ORDER BY filePath, startLine, startCharacter`,
+      )
+      .all(namePath, this.rootPath) as unknown as CachedReference[];
+  }
+
+  /**
+   * Drop every cached reference of this root
+   */
+  clearReferences(): void {
     this.db
-      .prepare("DELETE FROM symbols WHERE projectRoot = ?")
+      .prepare("DELETE FROM symbol_references WHERE projectRoot = ?")
       .run(this.rootPath);
   }
 
+  /**
+   * Replace the embeddings of a file's symbols made by an embedder
+   * Vectors are stored as one blob of float32 values, one vector after the
+   * other.
+   */
+  cacheEmbeddings(
+    filePath: string,
+    embedderId: string,
+    embeddings: CachedEmbeddings,
+  ): void {
+    const dimensions = embeddings.vectors[0]?.length ?? 0;
+    const vectors = new Float32Array(embeddings.vectors.length * dimensions);
+    embeddings.vectors.forEach((vector, i) => {
+      vectors.set(vector, i * dimensions);
+    });
+    this.db
+      .prepare(
+        `INSERT OR REPLACE INTO symbol_embeddings (
+          filePath, projectRoot, embedderId, contentHash, names, docs, vectors
+        ) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+      )
+      .run(
+        filePath,
+        this.rootPath,
+        embedderId,
+        embeddings.contentHash,
+        JSON.stringify(embeddings.names),
+        JSON.stringify(embeddings.docs),
+        new Uint8Array(vectors.buffer),
+      );
+  }
+
+  /**
+   * Embeddings of a file made by an embedder, or null when none were stored
+   * for this content
+   */
+  getEmbeddings(
+    filePath: string,
+    embedderId: string,
+    contentHash: string,
+  ): CachedEmbeddings | null {
+    const row = this.db
+      .prepare(
+        `SELECT names, docs, vectors FROM symbol_embeddings
+        WHERE filePath = ? AND projectRoot = ? AND embedderId = ?
+        AND contentHash = ?`,
+      )
+      .get(filePath, this.rootPath, embedderId, contentHash) as
+      | { names: string; docs: string; vectors: Uint8Array }
+      | undefined;
+    if (!row) {
+      return null;
+    }
+
+    const names = JSON.parse(row.names) as string[];
+    // slice() copies the blob into a buffer of its own, aligned for floats
+    const values = new Float32Array(row.vectors.slice().buffer);
+    const dimensions = names.length > 0 ? values.length / names.length : 0;
+    return {
+      contentHash,
+      names,
+      docs: JSON.parse(row.docs) as (string | null)[],
+      vectors: names.map((_, i) =>
+        values.slice(i * dimensions, (i + 1) * dimensions),
+      ),
+    };
+  }
+
+  setMetadata(key: string, value: string): void {
+    this.db
+      .prepare(
+        "INSERT OR REPLACE INTO index_metadata (key, projectRoot, value) VALUES (?, ?, ?)",
+      )
+      .run(key, this.rootPath, value);
+  }
+
+  getMetadata(key: string): string | null {
+    const row = this.db
+      .prepare(
+        "SELECT value FROM index_metadata WHERE key = ? AND projectRoot = ?",
+      )
+      .get(key, this.rootPath) as { value: string } | undefined;
+    return row?.value ?? null;
+  }
+
   getStats(): { totalSymbols: number; totalFiles: number } {
     const symbolCount = this.db
       .prepare("SELECT COUNT(*) as count FROM symbols WHERE projectRoot = ?")
@@ -281,8 +611,12 @@ export class SymbolCacheManager {
   
   getAllFiles(): string[] {
     const rows = this.db
-      .prepare("SELECT DISTINCT filePath FROM symbols WHERE projectRoot = ?")
-      .all(this.rootPath) as { filePath: string }[];
+      .prepare(
+        `SELECT filePath FROM symbols WHERE projectRoot = ?
+        UNION
+        SELECT filePath FROM files WHERE projectRoot = ?`,
+      )
+      .all(this.rootPath, this.rootPath) as { filePath: string }[];
     
     return rows.map(row => row.filePath);
   }
@@ -0,0 +1,163 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import { mkdtempSync, rmSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { IndexWatcher, isGitHeadChange } from "./IndexWatcher.ts";
+import type { SymbolIndex } from "./SymbolIndex.ts";
+
+function createFakeIndex(totalFiles = 1) {
+  return {
+    indexFile: vi.fn().mockResolvedValue(undefined),
+    removeFile: vi.fn(),
+    getStats: vi.fn().mockReturnValue({ totalFiles }),
+    updateIncremental: vi
+      .fn()
+      .mockResolvedValue({ updated: [], removed: [], errors: [] }),
+  };
+}
+
+describe("IndexWatcher", () => {
+  let rootPath: string;
+
+  beforeEach(() => {
+    rootPath = mkdtempSync(join(tmpdir(), "lsmcp-watcher-"));
+  });
+
+  afterEach(() => {
+    rmSync(rootPath, { recursive: true, force: true });
+  });
+
+  describe("shouldTrack", () => {
+    it("should ignore VCS, cache and dependency directories", () => {
+      const watcher = new IndexWatcher(
+        rootPath,
+        createFakeIndex() as unknown as SymbolIndex,
+      );
+
+      expect(watcher.shouldTrack(".git/HEAD")).toBe(false);
+      expect(watcher.shouldTrack(".lsmcp/cache/symbols.db")).toBe(false);
+      expect(watcher.shouldTrack("node_modules/foo/index.ts")).toBe(false);
+      expect(watcher.shouldTrack("src/index.ts")).toBe(true);
+    });
+
+    it("should respect configured patterns", () => {
+      const watcher = new IndexWatcher(
+        rootPath,
+        createFakeIndex() as unknown as SymbolIndex,
+        { patterns: ["**/*.go"], ignorePatterns: ["**/vendor/**"] },
+      );
+
+      expect(watcher.shouldTrack("cmd/main.go")).toBe(true);
+      expect(watcher.shouldTrack("vendor/lib/lib.go")).toBe(false);
+      expect(watcher.shouldTrack("README.md")).toBe(false);
+    });
+
+    it("should skip ignored files and reload rules when they change", () => {
+      writeFileSync(join(rootPath, ".gitignore"), "build/\n");
+      const watcher = new IndexWatcher(
+        rootPath,
+        createFakeIndex() as unknown as SymbolIndex,
+      );
+
+      expect(watcher.shouldTrack("build/out.ts")).toBe(false);
+      expect(watcher.shouldTrack("gen/types.ts")).toBe(true);
+
+      writeFileSync(join(rootPath, ".lsmcpignore"), "gen/\n");
+      (watcher as any).handleChange(".lsmcpignore");
+      watcher.stop();
+
+      expect(watcher.shouldTrack("gen/types.ts")).toBe(false);
+    });
+  });
+
+  describe("flush", () => {
+    it("should re-index existing files and remove deleted ones", async () => {
+      writeFileSync(join(rootPath, "a.ts"), "export const a = 1;\n");
+      const index = createFakeIndex();
+      const watcher = new IndexWatcher(
+        rootPath,
+        index as unknown as SymbolIndex,
+      );
+
+      (watcher as any).pending.add("a.ts");
+      (watcher as any).pending.add("deleted.ts");
+      const result = await watcher.flush();
+
+      expect(result).toEqual({ updated: ["a.ts"], removed: ["deleted.ts"] });
+      expect(index.indexFile).toHaveBeenCalledWith("a.ts");
+      expect(index.removeFile).toHaveBeenCalledWith("deleted.ts");
+    });
+
+    it("should skip updates until the index has been built", async () => {
+      const index = createFakeIndex(0);
+      const watcher = new IndexWatcher(
+        rootPath,
+        index as unknown as SymbolIndex,
+      );
+
+      (watcher as any).pending.add("a.ts");
+      const result = await watcher.flush();
+
+      expect(result).toEqual({ updated: [], removed: [] });
+      expect(index.indexFile).not.toHaveBeenCalled();
+    });
+
+    it("should be settled only while running with no pending changes", async () => {
+      writeFileSync(join(rootPath, "a.ts"), "export const a = 1;\n");
+      const watcher = new IndexWatcher(
+        rootPath,
+        createFakeIndex() as unknown as SymbolIndex,
+      );
+      expect(watcher.isSettled()).toBe(false);
+
+      watcher.start();
+      expect(watcher.isSettled()).toBe(true);
+
+      (watcher as any).pending.add("a.ts");
+      expect(watcher.isSettled()).toBe(false);
+
+      await watcher.flush();
+      expect(watcher.isSettled()).toBe(true);
+      watcher.stop();
+    });
+  });
+
+  describe("HEAD changes", () => {
+    it("should recognize HEAD and branch ref updates", () => {
+      expect(isGitHeadChange(".git/HEAD")).toBe(true);
+      expect(isGitHeadChange(".git/refs/heads/feature/login")).toBe(true);
+      expect(isGitHeadChange(".git/packed-refs")).toBe(true);
+      expect(isGitHeadChange(".git/refs/heads/main.lock")).toBe(false);
+      expect(isGitHeadChange(".git/index")).toBe(false);
+      expect(isGitHeadChange("src/HEAD")).toBe(false);
+    });
+
+    it("should re-index the files that differ after a branch switch", async () => {
+      writeFileSync(join(rootPath, "b.ts"), "export const b = 1;\n");
+      const index = createFakeIndex();
+      index.updateIncremental.mockResolvedValue({
+        updated: ["a.ts"],
+        removed: ["old.ts"],
+        errors: [],
+      });
+      const watcher = new IndexWatcher(
+        rootPath,
+        index as unknown as SymbolIndex,
+      );
+
+      (watcher as any).handleChange(".git/HEAD");
+      (watcher as any).handleChange("a.ts");
+      (watcher as any).handleChange("b.ts");
+      const result = await watcher.flush();
+      watcher.stop();
+
+      expect(index.updateIncremental).toHaveBeenCalledTimes(1);
+      expect(index.indexFile).toHaveBeenCalledTimes(1);
+      expect(index.indexFile).toHaveBeenCalledWith("b.ts");
+      expect(result).toEqual({
+        updated: ["a.ts", "b.ts"],
+        removed: ["old.ts"],
+      });
+    });
+  });
+});
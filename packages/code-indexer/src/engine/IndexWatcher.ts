@@ -0,0 +1,268 @@
+/**
+ * File system watcher that keeps a SymbolIndex in sync with on-disk changes
+ * (editor saves, git pull, code generators) outside of MCP tool edits
+ *
+ * When git moves HEAD (switch, rebase, commit, pull) the files that differ
+ * between the old and the new commit are re-indexed through
+ * SymbolIndex.updateIncremental rather than one by one as their writes
+ * arrive, so none is missed and the index does not serve the old branch.
+ */
+
+import { EventEmitter } from "events";
+import { watch, statSync, type FSWatcher } from "fs";
+import { relative, resolve, sep } from "path";
+import { minimatch } from "minimatch";
+import type { SymbolIndex } from "./SymbolIndex.ts";
+import { IgnoreRules, isIgnoreFile } from "./ignoreRules.ts";
+import { debugLogWithPrefix } from "../utils/logging.ts";
+
+export interface IndexWatcherOptions {
+  /** Glob patterns (relative to root) of files to keep indexed */
+  patterns?: string[];
+  /** Additional glob patterns to ignore */
+  ignorePatterns?: string[];
+  /** Debounce delay before re-indexing changed files (ms) */
+  delay?: number;
+}
+
+export interface IndexWatcherEvent {
+  updated: string[];
+  removed: string[];
+}
+
+// Directories that never contain indexable sources
+const ALWAYS_IGNORED = ["**/.git/**", "**/.lsmcp/**", "**/node_modules/**"];
+
+/**
+ * Whether a change to a path (relative to root) means git moved HEAD:
+ * HEAD itself on a switch, a branch ref on commit, rebase or pull
+ */
+export function isGitHeadChange(relativePath: string): boolean {
+  const normalized = relativePath.split(sep).join("/");
+  if (normalized === ".git/HEAD" || normalized === ".git/packed-refs") {
+    return true;
+  }
+  return (
+    normalized.startsWith(".git/refs/heads/") && !normalized.endsWith(".lock")
+  );
+}
+
+export class IndexWatcher extends EventEmitter {
+  private watcher: FSWatcher | null = null;
+  private pending = new Set<string>();
+  private headMoved = false;
+  private timer: NodeJS.Timeout | null = null;
+  private flushing: Promise<void> | null = null;
+  private readonly patterns: string[];
+  private readonly ignorePatterns: string[];
+  private readonly delay: number;
+  private ignoreRules: IgnoreRules;
+
+  constructor(
+    private rootPath: string,
+    private index: SymbolIndex,
+    options: IndexWatcherOptions = {},
+  ) {
+    super();
+    this.patterns = options.patterns ?? [];
+    this.ignorePatterns = [
+      ...ALWAYS_IGNORED,
+      ...(options.ignorePatterns ?? []),
+    ];
+    this.delay = options.delay ?? 500;
+    this.ignoreRules = new IgnoreRules(rootPath);
+  }
+
+  /**
+   * Start watching the root directory recursively
+   * Returns false if the platform does not support recursive watching
+   */
+  start(): boolean {
+    if (this.watcher) {
+      return true;
+    }
+
+    try {
+      this.watcher = watch(
+        this.rootPath,
+        { recursive: true, persistent: false },
+        (_eventType, filename) => {
+          if (filename) {
+            this.handleChange(filename.toString());
+          }
+        },
+      );
+      this.watcher.on("error", (error) => {
+        debugLogWithPrefix("IndexWatcher", `Watcher error: ${error.message}`);
+        this.emit("watchError", error);
+      });
+      debugLogWithPrefix("IndexWatcher", `Watching ${this.rootPath}`);
+      return true;
+    } catch (error) {
+      debugLogWithPrefix(
+        "IndexWatcher",
+        `Failed to start watcher: ${error instanceof Error ? error.message : String(error)}`,
+      );
+      this.watcher = null;
+      return false;
+    }
+  }
+
+  /**
+   * Stop watching and drop pending changes
+   */
+  stop(): void {
+    if (this.timer) {
+      clearTimeout(this.timer);
+      this.timer = null;
+    }
+    this.pending.clear();
+    this.headMoved = false;
+    this.watcher?.close();
+    this.watcher = null;
+  }
+
+  isRunning(): boolean {
+    return this.watcher !== null;
+  }
+
+  /**
+   * Whether the index has caught up with every change seen so far
+   */
+  isSettled(): boolean {
+    return (
+      this.isRunning() &&
+      this.pending.size === 0 &&
+      !this.headMoved &&
+      this.flushing === null
+    );
+  }
+
+  /**
+   * Check whether a path (relative to root) should trigger re-indexing
+   */
+  shouldTrack(relativePath: string): boolean {
+    const normalized = relativePath.split(sep).join("/");
+    const options = { dot: true };
+
+    if (this.ignorePatterns.some((p) => minimatch(normalized, p, options))) {
+      return false;
+    }
+
+    // .gitignore, .git/info/exclude and .lsmcpignore
+    if (this.ignoreRules.isIgnored(normalized)) {
+      return false;
+    }
+
+    if (this.patterns.length === 0) {
+      return true;
+    }
+
+    return this.patterns.some((p) => minimatch(normalized, p, options));
+  }
+
+  /**
+   * Re-index all pending files immediately
+   */
+  async flush(): Promise<IndexWatcherEvent> {
+    if (this.timer) {
+      clearTimeout(this.timer);
+      this.timer = null;
+    }
+
+    // Serialize flushes so a file is never indexed twice concurrently
+    if (this.flushing) {
+      await this.flushing;
+    }
+
+    let files = Array.from(this.pending);
+    this.pending.clear();
+    const headMoved = this.headMoved;
+    this.headMoved = false;
+
+    const result: IndexWatcherEvent = { updated: [], removed: [] };
+    if (files.length === 0 && !headMoved) {
+      return result;
+    }
+
+    // Nothing to keep in sync until the index has been built once;
+    // the first query performs a full index anyway
+    if (this.index.getStats().totalFiles === 0) {
+      return result;
+    }
+
+    const run = async () => {
+      if (headMoved) {
+        const update = await this.index.updateIncremental();
+        result.updated.push(...update.updated);
+        result.removed.push(...update.removed);
+        for (const error of update.errors) {
+          debugLogWithPrefix("IndexWatcher", `HEAD update: ${error}`);
+        }
+        // Files the update covered need no second pass
+        const covered = new Set([...update.updated, ...update.removed]);
+        files = files.filter((file) => !covered.has(file));
+      }
+
+      for (const file of files) {
+        const absolutePath = resolve(this.rootPath, file);
+        let isFile: boolean;
+        try {
+          isFile = statSync(absolutePath).isFile();
+        } catch {
+          this.index.removeFile(file);
+          result.removed.push(file);
+          continue;
+        }
+        if (isFile) {
+          await this.index.indexFile(file);
+          result.updated.push(file);
+        }
+      }
+    };
+
+    this.flushing = run();
+    try {
+      await this.flushing;
+    } finally {
+      this.flushing = null;
+    }
+
+    debugLogWithPrefix(
+      "IndexWatcher",
+      `Re-indexed ${result.updated.length} files, removed ${result.removed.length}`,
+    );
+    this.emit("reindexed", result);
+    return result;
+  }
+
+  private handleChange(filename: string): void {
+    const relativePath = relative(
+      this.rootPath,
+      resolve(this.rootPath, filename),
+    );
+    if (isIgnoreFile(relativePath)) {
+      this.ignoreRules = new IgnoreRules(this.rootPath);
+    }
+    if (isGitHeadChange(relativePath)) {
+      this.headMoved = true;
+    } else if (this.shouldTrack(relativePath)) {
+      this.pending.add(relativePath);
+    } else {
+      return;
+    }
+
+    if (this.timer) {
+      clearTimeout(this.timer);
+    }
+    this.timer = setTimeout(() => {
+      this.timer = null;
+      this.flush().catch((error) => {
+        debugLogWithPrefix(
+          "IndexWatcher",
+          `Failed to re-index: ${error instanceof Error ? error.message : String(error)}`,
+        );
+      });
+    }, this.delay);
+  }
+}
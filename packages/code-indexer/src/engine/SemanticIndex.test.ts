@@ -0,0 +1,191 @@
+import { describe, it, expect, vi } from "vitest";
+import { SymbolKind } from "vscode-languageserver-types";
+import { SemanticIndex, symbolEmbeddingText } from "./SemanticIndex.ts";
+import { createLocalEmbedder } from "./embeddings.ts";
+import type { CachedEmbeddings, IndexedSymbol } from "./types.ts";
+
+function symbol(
+  name: string,
+  kind: SymbolKind,
+  file: string,
+  line = 0,
+): IndexedSymbol {
+  return {
+    name,
+    kind,
+    location: {
+      uri: `file:///project/${file}`,
+      range: {
+        start: { line, character: 0 },
+        end: { line, character: name.length },
+      },
+    },
+  };
+}
+
+const FILES: Record<string, string> = {
+  "/project/src/check.ts": [
+    "/** Reject strings that are not e-mail addresses */",
+    "export function checkAddr(value: string): boolean {",
+  ].join("\n"),
+  "/project/src/config.ts": "export function loadConfig() {}",
+};
+
+describe("SemanticIndex", () => {
+  const embedder = createLocalEmbedder();
+
+  it("should rank symbols by their name and doc comment", async () => {
+    const index = new SemanticIndex(embedder.id, {
+      readText: async (path) => FILES[path],
+    });
+    await index.update(
+      [
+        symbol("checkAddr", SymbolKind.Function, "src/check.ts", 1),
+        symbol("loadConfig", SymbolKind.Function, "src/config.ts"),
+      ],
+      embedder,
+    );
+
+    const matches = index.search(
+      await embedder.embedQuery("validate email address"),
+      { limit: 10 },
+    );
+
+    expect(matches[0].symbol.name).toBe("checkAddr");
+    expect(matches[0].doc).toBe(
+      "Reject strings that are not e-mail addresses",
+    );
+    expect(matches.map((match) => match.symbol.name)).not.toContain(
+      "loadConfig",
+    );
+  });
+
+  it("should only embed files again after they were invalidated", async () => {
+    const readText = vi.fn(async (path: string) => FILES[path]);
+    const index = new SemanticIndex(embedder.id, { readText });
+    const symbols = [
+      symbol("checkAddr", SymbolKind.Function, "src/check.ts", 1),
+      symbol("loadConfig", SymbolKind.Function, "src/config.ts"),
+    ];
+
+    await index.update(symbols, embedder);
+    await index.update(symbols, embedder);
+    expect(readText).toHaveBeenCalledTimes(2);
+
+    index.invalidate("file:///project/src/check.ts");
+    await index.update(symbols, embedder);
+    expect(readText).toHaveBeenCalledTimes(3);
+
+    await index.update(symbols.slice(1), embedder);
+    expect(index.size).toBe(1);
+  });
+
+  it("should reuse stored vectors while the content hash is unchanged", async () => {
+    const stored = new Map<string, CachedEmbeddings>();
+    const hashes: Record<string, string> = {
+      "file:///project/src/check.ts": "hash-1",
+    };
+    const store = {
+      getContentHash: (uri: string) => hashes[uri],
+      getCachedEmbeddings: async (
+        uri: string,
+        embedderId: string,
+        contentHash: string,
+      ) => {
+        const entry = stored.get(`${uri} ${embedderId}`);
+        return entry?.contentHash === contentHash ? entry : null;
+      },
+      cacheEmbeddings: async (
+        uri: string,
+        embedderId: string,
+        embeddings: CachedEmbeddings,
+      ) => {
+        stored.set(`${uri} ${embedderId}`, embeddings);
+      },
+    };
+    const readText = vi.fn(async (path: string) => FILES[path]);
+    const symbols = [
+      symbol("checkAddr", SymbolKind.Function, "src/check.ts", 1),
+    ];
+
+    await new SemanticIndex(embedder.id, { readText, store }).update(
+      symbols,
+      embedder,
+    );
+    const [entry] = stored.values();
+    expect(entry.docs).toEqual([
+      "Reject strings that are not e-mail addresses",
+    ]);
+
+    // A new process finds the vectors and the doc without reading the file
+    const restarted = new SemanticIndex(embedder.id, { readText, store });
+    await restarted.update(symbols, embedder);
+    expect(readText).toHaveBeenCalledTimes(1);
+    const [match] = restarted.search(await embedder.embedQuery("email"), {
+      limit: 1,
+    });
+    expect(match.doc).toBe("Reject strings that are not e-mail addresses");
+
+    hashes["file:///project/src/check.ts"] = "hash-2";
+    await new SemanticIndex(embedder.id, { readText, store }).update(
+      symbols,
+      embedder,
+    );
+    expect(readText).toHaveBeenCalledTimes(2);
+  });
+
+  it("should apply the filter and the limit", async () => {
+    const index = new SemanticIndex(embedder.id, {
+      readText: async () => "",
+    });
+    await index.update(
+      [
+        symbol("sendEmail", SymbolKind.Function, "a.ts"),
+        symbol("EmailSender", SymbolKind.Class, "b.ts"),
+        symbol("emailQueue", SymbolKind.Variable, "c.ts"),
+      ],
+      embedder,
+    );
+    const query = await embedder.embedQuery("email");
+
+    const classes = index.search(query, {
+      limit: 10,
+      filter: (s) => s.kind === SymbolKind.Class,
+    });
+    expect(classes.map((match) => match.symbol.name)).toEqual(["EmailSender"]);
+    expect(index.search(query, { limit: 2 })).toHaveLength(2);
+  });
+
+  it("should embed names alone when the file cannot be read", async () => {
+    const index = new SemanticIndex(embedder.id, {
+      readText: async () => {
+        throw new Error("ENOENT");
+      },
+    });
+    await index.update(
+      [symbol("sendEmail", SymbolKind.Function, "gone.ts")],
+      embedder,
+    );
+
+    const [match] = index.search(await embedder.embedQuery("send email"), {
+      limit: 1,
+    });
+    expect(match.symbol.name).toBe("sendEmail");
+    expect(match.doc).toBeUndefined();
+  });
+});
+
+describe("symbolEmbeddingText", () => {
+  it("should join name, container, kind, detail and doc", () => {
+    expect(
+      symbolEmbeddingText(
+        {
+          ...symbol("close", SymbolKind.Method, "client.ts"),
+          containerName: "Client",
+          detail: "(force: boolean) => void",
+        },
+        "Close the connection",
+      ),
+    ).toBe("close Client Method (force: boolean) => void Close the connection");
+  });
+});
@@ -0,0 +1,239 @@
+/**
+ * Embeddings of indexed symbols for semantic search
+ *
+ * Each symbol is embedded from its name, container, kind, detail and doc
+ * comment. Vectors live in memory next to the SymbolIndex they were built
+ * from; files are embedded on the first search after they were (re)indexed.
+ * With an EmbeddingStore, vectors outlive the process: a file whose content
+ * hash is unchanged is not embedded again.
+ */
+
+import { readFile } from "fs/promises";
+import { fileURLToPath } from "url";
+import { getSymbolKindName } from "@internal/types";
+import type { CachedEmbeddings, IndexedSymbol } from "./types.ts";
+import { cosineSimilarity, type Embedder } from "./embeddings.ts";
+import { extractDocComment } from "./docComments.ts";
+import { debugLogWithPrefix } from "../utils/logging.ts";
+
+// Scores at or below this come from hash collisions rather than shared words
+const MIN_SCORE = 0.05;
+
+export interface SemanticMatch {
+  symbol: IndexedSymbol;
+  /** Cosine similarity to the query, up to 1 */
+  score: number;
+  doc?: string;
+}
+
+interface EmbeddedSymbol {
+  symbol: IndexedSymbol;
+  doc?: string;
+  vector: Float32Array;
+}
+
+/**
+ * Persistent vectors by file URI, embedder id and content hash
+ * SymbolIndex stores them in its cache (the SQLite index of the project).
+ */
+export interface EmbeddingStore {
+  /** Hash of the indexed content of a file, if known */
+  getContentHash(uri: string): string | undefined;
+  getCachedEmbeddings(
+    uri: string,
+    embedderId: string,
+    contentHash: string,
+  ): Promise<CachedEmbeddings | null>;
+  cacheEmbeddings(
+    uri: string,
+    embedderId: string,
+    embeddings: CachedEmbeddings,
+  ): Promise<void>;
+}
+
+export interface SemanticIndexOptions {
+  readText?: (path: string) => Promise<string>;
+  store?: EmbeddingStore;
+}
+
+/** Text a symbol is embedded from */
+export function symbolEmbeddingText(
+  symbol: IndexedSymbol,
+  doc?: string,
+): string {
+  return [
+    symbol.name,
+    symbol.containerName,
+    getSymbolKindName(symbol.kind),
+    symbol.detail,
+    doc,
+  ]
+    .filter(Boolean)
+    .join(" ");
+}
+
+export class SemanticIndex {
+  // Embedded symbols by file URI
+  private files = new Map<string, EmbeddedSymbol[]>();
+
+  private readonly readText: (path: string) => Promise<string>;
+  private readonly store?: EmbeddingStore;
+
+  constructor(
+    readonly embedderId: string,
+    options: SemanticIndexOptions = {},
+  ) {
+    this.readText = options.readText ?? ((path) => readFile(path, "utf-8"));
+    this.store = options.store;
+  }
+
+  /** Drop the vectors of a file, or of every file when `uri` is missing */
+  invalidate(uri?: string): void {
+    if (uri) {
+      this.files.delete(uri);
+    } else {
+      this.files.clear();
+    }
+  }
+
+  /** Number of files with vectors */
+  get size(): number {
+    return this.files.size;
+  }
+
+  /**
+   * Embed the files that have no vectors yet and drop the files that are
+   * no longer indexed
+   * @param symbols every symbol of the index, nested ones included
+   */
+  async update(symbols: IndexedSymbol[], embedder: Embedder): Promise<void> {
+    const byFile = new Map<string, IndexedSymbol[]>();
+    for (const symbol of symbols) {
+      const uri = symbol.location.uri;
+      byFile.set(uri, [...(byFile.get(uri) ?? []), symbol]);
+    }
+    for (const uri of this.files.keys()) {
+      if (!byFile.has(uri)) {
+        this.files.delete(uri);
+      }
+    }
+
+    for (const [uri, fileSymbols] of byFile) {
+      if (this.files.has(uri)) {
+        continue;
+      }
+      const contentHash = this.store?.getContentHash(uri);
+      const stored = contentHash
+        ? await this.loadStored(uri, contentHash, fileSymbols)
+        : undefined;
+      if (stored) {
+        this.files.set(uri, stored);
+        continue;
+      }
+
+      let lines: string[] = [];
+      try {
+        lines = (await this.readText(fileURLToPath(uri))).split("\n");
+      } catch (error) {
+        // Embed names alone when the file is gone or unreadable
+        debugLogWithPrefix("SemanticIndex", `Failed to read ${uri}: ${error}`);
+      }
+      const docs = fileSymbols.map(
+        (symbol) =>
+          extractDocComment(lines, symbol.location.range.start.line) ||
+          undefined,
+      );
+      const vectors = await embedder.embedDocuments(
+        fileSymbols.map((symbol, i) => symbolEmbeddingText(symbol, docs[i])),
+      );
+      this.files.set(
+        uri,
+        fileSymbols.map((symbol, i) => ({
+          symbol,
+          doc: docs[i],
+          vector: vectors[i],
+        })),
+      );
+      if (contentHash) {
+        await this.saveStored(uri, {
+          contentHash,
+          names: fileSymbols.map((symbol) => symbol.name),
+          docs: docs.map((doc) => doc ?? null),
+          vectors,
+        });
+      }
+    }
+  }
+
+  /**
+   * Stored vectors of a file, if they were made for the same symbols
+   */
+  private async loadStored(
+    uri: string,
+    contentHash: string,
+    fileSymbols: IndexedSymbol[],
+  ): Promise<EmbeddedSymbol[] | undefined> {
+    let stored: CachedEmbeddings | null;
+    try {
+      stored = await this.store!.getCachedEmbeddings(
+        uri,
+        this.embedderId,
+        contentHash,
+      );
+    } catch (error) {
+      debugLogWithPrefix(
+        "SemanticIndex",
+        `Failed to load embeddings of ${uri}: ${error}`,
+      );
+      return undefined;
+    }
+    // The same content can be indexed into other symbols by another server
+    if (
+      !stored ||
+      stored.names.length !== fileSymbols.length ||
+      stored.names.some((name, i) => name !== fileSymbols[i].name)
+    ) {
+      return undefined;
+    }
+    const { docs, vectors } = stored;
+    return fileSymbols.map((symbol, i) => ({
+      symbol,
+      doc: docs[i] ?? undefined,
+      vector: vectors[i],
+    }));
+  }
+
+  private async saveStored(
+    uri: string,
+    embeddings: CachedEmbeddings,
+  ): Promise<void> {
+    try {
+      await this.store!.cacheEmbeddings(uri, this.embedderId, embeddings);
+    } catch (error) {
+      debugLogWithPrefix(
+        "SemanticIndex",
+        `Failed to store embeddings of ${uri}: ${error}`,
+      );
+    }
+  }
+
+  /** Symbols most similar to a query vector, best first */
+  search(
+    query: Float32Array,
+    options: { limit: number; filter?: (symbol: IndexedSymbol) => boolean },
+  ): SemanticMatch[] {
+    const matches: SemanticMatch[] = [];
+    for (const entries of this.files.values()) {
+      for (const { symbol, doc, vector } of entries) {
+        if (options.filter && !options.filter(symbol)) {
+          continue;
+        }
+        const score = cosineSimilarity(query, vector);
+        if (score > MIN_SCORE) {
+          matches.push({ symbol, score, doc });
+        }
+      }
+    }
+    return matches.sort((a, b) => b.score - a.score).slice(0, options.limit);
+  }
+}
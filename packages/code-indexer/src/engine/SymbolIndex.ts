@@ -4,8 +4,9 @@
  */
 
 import { EventEmitter } from "events";
-import { pathToFileURL } from "url";
-import { resolve } from "path";
+import { fileURLToPath, pathToFileURL } from "url";
+import { extname, relative, resolve } from "path";
+import { minimatch } from "minimatch";
 import type {
   IndexedSymbol,
   FileSymbols,
@@ -14,18 +15,39 @@ import type {
   SymbolProvider,
   FileSystem,
   SymbolCache,
+  CachedReference,
+  CachedEmbeddings,
   IndexEvent,
+  IndexingRunStats,
 } from "./types.ts";
 import { SymbolKind } from "vscode-languageserver-types";
 import {
   getGitHashAsync,
   getModifiedFilesAsync,
   getFileGitHash,
+  getTrackedFilesAsync,
   getUntrackedFilesAsync,
 } from "../utils/gitUtils.ts";
 import { ContentHashDiffChecker, type FileDiffChecker } from "./fileDiffDetector.ts";
+import { fuzzyScore, symbolRelevance } from "./fuzzyMatch.ts";
+import { IgnoreRules } from "./ignoreRules.ts";
+import { runWorkerPool } from "./workerPool.ts";
 import { shouldExcludeSymbol, type IndexConfig } from "../config/config.ts";
-import { debugLogWithPrefix } from "../../../../src/utils/debugLog.ts";
+import { debugLogWithPrefix, indexerLog } from "../utils/logging.ts";
+
+const log = indexerLog("SymbolIndex");
+
+const LAST_GIT_HASH_KEY = "lastGitHash";
+const LAST_INDEXED_AT_KEY = "lastIndexedAt";
+const LAST_INCREMENTAL_AT_KEY = "lastIncrementalAt";
+
+const DEFAULT_WORKERS = 5;
+
+// Extensions picked up as new files when config.files names no patterns
+const DEFAULT_EXTENSIONS = [".ts", ".tsx", ".js", ".jsx", ".mjs", ".mts"];
+
+/** What indexFile did with a file */
+export type IndexFileOutcome = "indexed" | "reused" | "empty" | "failed";
 
 export class SymbolIndex extends EventEmitter {
   private fileIndex: Map<string, FileSymbols> = new Map();
@@ -40,6 +62,8 @@ export class SymbolIndex extends EventEmitter {
   };
   private config?: IndexConfig;
   private diffChecker: FileDiffChecker;
+  // Latest indexing error by file URI; cleared when the file indexes fine
+  private fileErrors: Map<string, string> = new Map();
 
   constructor(
     private rootPath: string,
@@ -55,12 +79,13 @@ export class SymbolIndex extends EventEmitter {
   /**
    * Index a single file
    */
-  async indexFile(filePath: string): Promise<void> {
+  async indexFile(filePath: string): Promise<IndexFileOutcome> {
     const absolutePath = resolve(this.rootPath, filePath);
     const uri = pathToFileURL(absolutePath).toString();
     const startTime = Date.now();
 
     try {
+      this.fileErrors.delete(uri);
       // Read file content first (we need it for content hash)
       const content = await this.fileSystem.readFile(absolutePath);
       
@@ -76,7 +101,7 @@ export class SymbolIndex extends EventEmitter {
           symbolCount: existingFile!.symbols.length,
           fromCache: true,
         } as IndexEvent);
-        return;
+        return "reused";
       }
       
       const contentHash = diffResult.contentHash;
@@ -92,7 +117,7 @@ export class SymbolIndex extends EventEmitter {
             symbolCount: cachedSymbols.length,
             fromCache: true,
           } as IndexEvent);
-          return;
+          return "reused";
         }
       }
 
@@ -100,7 +125,7 @@ export class SymbolIndex extends EventEmitter {
       const rawSymbols = await this.symbolProvider.getDocumentSymbols(uri);
 
       if (!rawSymbols || rawSymbols.length === 0) {
-        return;
+        return "empty";
       }
 
       // Convert symbols
@@ -117,7 +142,7 @@ export class SymbolIndex extends EventEmitter {
 
       // Update cache
       if (this.cache) {
-        await this.cache.set(absolutePath, symbols);
+        await this.cache.set(absolutePath, symbols, { contentHash });
       }
 
       // Update stats
@@ -131,12 +156,19 @@ export class SymbolIndex extends EventEmitter {
         symbolCount: symbols.length,
         fromCache: false,
       } as IndexEvent);
+      return "indexed";
     } catch (error) {
+      log.debug(`Failed to index ${uri}`, { error });
+      this.fileErrors.set(
+        uri,
+        error instanceof Error ? error.message : String(error),
+      );
       this.emit("indexError", {
         type: "indexError",
         uri,
         error: error instanceof Error ? error : new Error(String(error)),
       } as IndexEvent);
+      return "failed";
     }
   }
 
@@ -159,8 +191,19 @@ export class SymbolIndex extends EventEmitter {
     await this.loadIndexFromCache();
   }
   
+  /**
+   * Ignore rules as they are on disk now, plus the config's ignorePatterns
+   */
+  private loadIgnoreRules(): IgnoreRules {
+    return new IgnoreRules(this.rootPath, {
+      patterns: this.config?.ignorePatterns,
+    });
+  }
+
   /**
    * Load existing index from cache
+   * Restores content hashes and the last git hash so that only files
+   * changed since the previous run are re-indexed
    */
   async loadIndexFromCache(): Promise<void> {
     if (!this.cache) {
@@ -169,7 +212,7 @@ export class SymbolIndex extends EventEmitter {
     
     try {
       // Get all cached files
-      const cachedFiles = await (this.cache as any).getAllFiles?.();
+      const cachedFiles = await this.cache.getAllFiles?.();
       
       if (!cachedFiles || cachedFiles.length === 0) {
         debugLogWithPrefix("SymbolIndex", "No cached files found");
@@ -181,20 +224,50 @@ export class SymbolIndex extends EventEmitter {
         `Loading ${cachedFiles.length} files from cache`
       );
       
+      let removedCount = 0;
+
       // Load symbols for each cached file
       for (const filePath of cachedFiles) {
+        // Drop entries for files deleted while the server was not running
+        if (!(await this.fileSystem.exists(filePath))) {
+          await this.cache.remove?.(filePath);
+          removedCount++;
+          continue;
+        }
+
         const cachedSymbols = await this.cache.get(filePath);
         if (cachedSymbols && cachedSymbols.length > 0) {
           const uri = pathToFileURL(filePath).toString();
+          const fileInfo = await this.cache.getFileInfo?.(filePath);
           // Store without re-caching
-          this.storeSymbols(uri, cachedSymbols, undefined, undefined);
+          this.storeSymbols(
+            uri,
+            cachedSymbols,
+            undefined,
+            fileInfo?.contentHash,
+          );
         }
       }
-      
+
+      const lastGitHash = await this.cache.getMetadata?.(LAST_GIT_HASH_KEY);
+      if (lastGitHash) {
+        this.stats.lastGitHash = lastGitHash;
+      }
+      const lastIndexedAt = await this.cache.getMetadata?.(LAST_INDEXED_AT_KEY);
+      if (lastIndexedAt) {
+        this.stats.lastIndexedAt = new Date(lastIndexedAt);
+      }
+      const lastIncrementalAt = await this.cache.getMetadata?.(
+        LAST_INCREMENTAL_AT_KEY,
+      );
+      if (lastIncrementalAt) {
+        this.stats.lastIncrementalAt = new Date(lastIncrementalAt);
+      }
+
       this.updateStats();
       debugLogWithPrefix(
         "SymbolIndex",
-        `Loaded ${this.stats.totalFiles} files, ${this.stats.totalSymbols} symbols from cache`
+        `Loaded ${this.stats.totalFiles} files, ${this.stats.totalSymbols} symbols from cache (${removedCount} deleted files dropped)`
       );
     } catch (error) {
       debugLogWithPrefix(
@@ -206,20 +279,38 @@ export class SymbolIndex extends EventEmitter {
 
   /**
    * Index multiple files
+   * Files are spread over a pool of `concurrency` workers (default: the
+   * config's indexConcurrency); no new file is started while the heap is
+   * above maxHeapMB (default: the config's memoryLimit).
    */
   async indexFiles(
     filePaths: string[],
-    concurrency = 5,
+    concurrency?: number,
     options?: {
-      onProgress?: (progress: { current: number; total: number }) => void;
+      onProgress?: (progress: {
+        current: number;
+        total: number;
+        file?: string;
+      }) => void;
       skipFailures?: boolean;
+      maxHeapMB?: number;
     },
-  ): Promise<void> {
+  ): Promise<IndexingRunStats> {
     // Load configuration if not already loaded
     if (!this.config) {
       await this.initialize();
     }
 
+    const ignoreRules = this.loadIgnoreRules();
+    const included = filePaths.filter((file) => !ignoreRules.isIgnored(file));
+    if (included.length < filePaths.length) {
+      debugLogWithPrefix(
+        "SymbolIndex",
+        `Skipping ${filePaths.length - included.length} ignored files`,
+      );
+      filePaths = included;
+    }
+
     this.emit("indexingStarted", {
       type: "indexingStarted",
       fileCount: filePaths.length,
@@ -227,19 +318,24 @@ export class SymbolIndex extends EventEmitter {
 
     const startTime = Date.now();
     const totalFiles = filePaths.length;
-    let processedFiles = 0;
-    let failedFiles = 0;
-
-    // Process in chunks
-    for (let i = 0; i < filePaths.length; i += concurrency) {
-      const chunk = filePaths.slice(i, i + concurrency);
-
-      const promises = chunk.map(async (file) => {
+    const counts: Record<IndexFileOutcome, number> = {
+      indexed: 0,
+      reused: 0,
+      empty: 0,
+      failed: 0,
+    };
+    let done = 0;
+    const workers =
+      concurrency ?? this.config?.settings?.indexConcurrency ?? DEFAULT_WORKERS;
+    const maxHeapMB = options?.maxHeapMB ?? this.config?.settings?.memoryLimit;
+
+    const pool = await runWorkerPool(
+      filePaths,
+      async (file) => {
         try {
-          await this.indexFile(file);
-          processedFiles++;
+          counts[await this.indexFile(file)]++;
         } catch (error) {
-          failedFiles++;
+          counts.failed++;
           debugLogWithPrefix(
             "SymbolIndex",
             `Failed to index ${file}: ${error instanceof Error ? error.message : String(error)}`,
@@ -248,38 +344,50 @@ export class SymbolIndex extends EventEmitter {
             throw error;
           }
         }
-      });
-
-      await Promise.all(promises);
-
-      // Report progress
-      if (options?.onProgress) {
-        options.onProgress({ current: processedFiles, total: totalFiles });
-      }
-
-      // Add a small delay between batches to prevent overwhelming the system
-      if (i + concurrency < filePaths.length) {
-        await new Promise((resolve) => setTimeout(resolve, 20));
-      }
-    }
+        done++;
+        options?.onProgress?.({ current: done, total: totalFiles, file });
+      },
+      {
+        workers,
+        maxHeapBytes: maxHeapMB ? maxHeapMB * 1024 * 1024 : undefined,
+      },
+    );
 
     const duration = Date.now() - startTime;
+    const run: IndexingRunStats = {
+      files: totalFiles,
+      ...counts,
+      duration,
+      filesPerSecond: Math.round((totalFiles * 1000) / Math.max(duration, 1)),
+      workers: pool.workers,
+      throttled: pool.throttled,
+      peakHeapMB: Math.round(pool.peakHeapBytes / 1024 / 1024),
+    };
+    this.stats.lastRun = run;
+    this.stats.lastIndexedAt = new Date();
+    await this.persistMetadata(
+      LAST_INDEXED_AT_KEY,
+      this.stats.lastIndexedAt.toISOString(),
+    );
 
     // Save current git hash
     const gitHashResult = await getGitHashAsync(this.rootPath);
     if (gitHashResult.isOk()) {
       this.stats.lastGitHash = gitHashResult.value;
+      await this.persistGitHash(gitHashResult.value);
     }
 
-    debugLogWithPrefix(
-      "SymbolIndex",
-      `Indexed ${processedFiles}/${totalFiles} files in ${duration}ms (${failedFiles} failures)`,
+    log.info(
+      `Indexed ${totalFiles} files in ${duration}ms with ${run.workers} workers (${run.filesPerSecond} files/s, ${run.reused} reused, ${run.failed} failures)`,
+      { ...run },
     );
 
     this.emit("indexingCompleted", {
       type: "indexingCompleted",
       duration,
+      stats: run,
     } as IndexEvent);
+    return run;
   }
 
   /**
@@ -289,6 +397,7 @@ export class SymbolIndex extends EventEmitter {
     const absolutePath = resolve(this.rootPath, filePath);
     const uri = pathToFileURL(absolutePath).toString();
 
+    this.fileErrors.delete(uri);
     const fileSymbols = this.fileIndex.get(uri);
     if (!fileSymbols) return;
 
@@ -297,6 +406,7 @@ export class SymbolIndex extends EventEmitter {
 
     // Remove from file index
     this.fileIndex.delete(uri);
+    void this.cache?.remove?.(absolutePath);
 
     this.updateStats();
     this.emit("fileRemoved", {
@@ -316,12 +426,12 @@ export class SymbolIndex extends EventEmitter {
       fileUris = new Set(this.fileIndex.keys());
     }
 
-    // Filter by name (partial match)
+    // Filter by name (fuzzy match)
     if (query.name) {
       const nameUris = new Set<string>();
-      // Check all symbol names for partial match
+      // Check all symbol names for a fuzzy match
       for (const [symbolName, uris] of this.symbolIndex) {
-        if (symbolName.includes(query.name)) {
+        if (fuzzyScore(query.name, symbolName) !== null) {
           uris.forEach((uri) => nameUris.add(uri));
         }
       }
@@ -405,9 +515,31 @@ export class SymbolIndex extends EventEmitter {
       results.push(...matchingSymbols);
     }
 
+    if (query.name) {
+      return this.rankSymbols(results, query.name);
+    }
     return results;
   }
 
+  /**
+   * Sort name query results by relevance, best first
+   */
+  private rankSymbols(symbols: IndexedSymbol[], name: string): IndexedSymbol[] {
+    return symbols
+      .map((symbol) => {
+        const relativePath = relative(
+          this.rootPath,
+          fileURLToPath(symbol.location.uri),
+        );
+        return {
+          symbol,
+          score: symbolRelevance(name, symbol, relativePath) ?? 0,
+        };
+      })
+      .sort((a, b) => b.score - a.score)
+      .map(({ symbol }) => symbol);
+  }
+
   /**
    * Get index statistics
    */
@@ -415,6 +547,82 @@ export class SymbolIndex extends EventEmitter {
     return { ...this.stats };
   }
 
+  /**
+   * Symbol counts per kind for every indexed file, nested symbols included
+   * (paths relative to the root)
+   */
+  getSymbolCounts(): Array<{ file: string; byKind: Map<SymbolKind, number> }> {
+    const tally = (
+      symbols: IndexedSymbol[],
+      byKind: Map<SymbolKind, number>,
+    ): void => {
+      for (const symbol of symbols) {
+        byKind.set(symbol.kind, (byKind.get(symbol.kind) ?? 0) + 1);
+        if (symbol.children) {
+          tally(symbol.children, byKind);
+        }
+      }
+    };
+
+    return Array.from(this.fileIndex.values(), (fileSymbols) => {
+      const byKind = new Map<SymbolKind, number>();
+      tally(fileSymbols.symbols, byKind);
+      return {
+        file: relative(this.rootPath, fileURLToPath(fileSymbols.uri)),
+        byKind,
+      };
+    });
+  }
+
+  /**
+   * Files whose latest indexing failed, with the error (paths relative to
+   * the root)
+   */
+  getIndexErrors(): Array<{ file: string; error: string }> {
+    return Array.from(this.fileErrors, ([uri, error]) => ({
+      file: relative(this.rootPath, fileURLToPath(uri)),
+      error,
+    })).sort((a, b) => a.file.localeCompare(b.file));
+  }
+
+  /**
+   * Indexed files whose content changed since they were indexed, and ones
+   * that no longer exist (paths relative to the root)
+   * Reads every indexed file to compare content hashes.
+   */
+  async findStaleFiles(): Promise<{ modified: string[]; deleted: string[] }> {
+    const modified: string[] = [];
+    const deleted: string[] = [];
+    await runWorkerPool(
+      Array.from(this.fileIndex.values()),
+      async (fileSymbols) => {
+        const absolutePath = fileURLToPath(fileSymbols.uri);
+        const file = relative(this.rootPath, absolutePath);
+        let content: string;
+        try {
+          content = await this.fileSystem.readFile(absolutePath);
+        } catch {
+          deleted.push(file);
+          return;
+        }
+        if (this.diffChecker.checkFile(content, fileSymbols).hasChanged) {
+          modified.push(file);
+        }
+      },
+      { workers: this.config?.settings?.indexConcurrency ?? DEFAULT_WORKERS },
+    );
+    return { modified: modified.sort(), deleted: deleted.sort() };
+  }
+
+  /**
+   * Get indexed files as paths relative to the root
+   */
+  getIndexedFiles(): string[] {
+    return Array.from(this.fileIndex.keys())
+      .map((uri) => relative(this.rootPath, fileURLToPath(uri)))
+      .sort();
+  }
+
   /**
    * Clear the index
    */
@@ -423,6 +631,7 @@ export class SymbolIndex extends EventEmitter {
     this.symbolIndex.clear();
     this.kindIndex.clear();
     this.containerIndex.clear();
+    this.fileErrors.clear();
     this.stats = {
       totalFiles: 0,
       totalSymbols: 0,
@@ -459,7 +668,11 @@ export class SymbolIndex extends EventEmitter {
    */
   async updateIncremental(options?: {
     batchSize?: number;
-    onProgress?: (progress: { current: number; total: number }) => void;
+    onProgress?: (progress: {
+      current: number;
+      total: number;
+      file?: string;
+    }) => void;
   }): Promise<{
     updated: string[];
     removed: string[];
@@ -519,7 +732,30 @@ export class SymbolIndex extends EventEmitter {
       lastHash,
     );
 
-    if (modifiedFilesResult.isErr()) {
+    let modifiedFiles: string[];
+    if (modifiedFilesResult.isOk()) {
+      modifiedFiles = modifiedFilesResult.value;
+    } else if (modifiedFilesResult.error.type === "HASH_NOT_FOUND") {
+      // The indexed commit is gone (history rewritten and pruned, or a
+      // cache from another clone): compare every tracked and indexed file
+      // by content hash instead of rebuilding the index
+      const trackedFilesResult = await getTrackedFilesAsync(this.rootPath);
+      if (trackedFilesResult.isErr()) {
+        return {
+          updated: [],
+          removed: [],
+          errors: [trackedFilesResult.error.message],
+        };
+      }
+      modifiedFiles = [
+        ...new Set([...trackedFilesResult.value, ...this.getIndexedFiles()]),
+      ];
+      log.info("Indexed commit not found, reconciling by content", {
+        lastHash,
+        currentHash,
+        files: modifiedFiles.length,
+      });
+    } else {
       debugLogWithPrefix(
         "SymbolIndex",
         `Error getting modified files: ${modifiedFilesResult.error.message}`,
@@ -531,12 +767,18 @@ export class SymbolIndex extends EventEmitter {
         errors: [modifiedFilesResult.error.message],
       };
     }
-
-    const modifiedFiles = modifiedFilesResult.value;
     debugLogWithPrefix(
       "SymbolIndex",
       `Found ${modifiedFiles.length} modified files`,
     );
+    if (lastHash !== currentHash) {
+      // Branch switch, rebase, pull or commit since the last update
+      log.info("HEAD moved, re-indexing files that differ", {
+        from: lastHash,
+        to: currentHash,
+        files: modifiedFiles.length,
+      });
+    }
 
     debugLogWithPrefix("SymbolIndex", "Getting untracked files");
     const untrackedFilesResult = await getUntrackedFilesAsync(this.rootPath);
@@ -557,47 +799,47 @@ export class SymbolIndex extends EventEmitter {
       `Found ${untrackedFiles.length} untracked files`,
     );
 
-    // Filter both modified and untracked files to only include TypeScript/JavaScript files
-    const supportedExtensions = [".ts", ".tsx", ".js", ".jsx", ".mjs", ".mts"];
-
-    const isSupported = (file: string): boolean => {
-      return supportedExtensions.some((ext) => file.endsWith(ext));
-    };
-
-    const tsModifiedFiles = modifiedFiles.filter(isSupported);
-    const tsUntrackedFiles = untrackedFiles.filter(isSupported);
+    // Filter both modified and untracked files to the ones the index covers
+    const isIndexable = this.createIndexableFilter();
+    const indexableModifiedFiles = modifiedFiles.filter(isIndexable);
+    const indexableUntrackedFiles = untrackedFiles.filter(isIndexable);
 
     debugLogWithPrefix(
       "SymbolIndex",
-      `Filtered to ${tsModifiedFiles.length} modified TS/JS files`,
+      `Filtered to ${indexableModifiedFiles.length} modified indexable files`,
     );
     debugLogWithPrefix(
       "SymbolIndex",
-      `Filtered to ${tsUntrackedFiles.length} untracked TS/JS files`,
+      `Filtered to ${indexableUntrackedFiles.length} untracked indexable files`,
     );
 
-    // Combine modified and untracked files
-    const allFiles = [...new Set([...tsModifiedFiles, ...tsUntrackedFiles])];
+    // Combine modified and untracked files, leaving out ignored ones
+    const ignoreRules = this.loadIgnoreRules();
+    const allFiles = [
+      ...new Set([...indexableModifiedFiles, ...indexableUntrackedFiles]),
+    ].filter((file) => !ignoreRules.isIgnored(file));
     const totalFiles = allFiles.length;
 
     const updated: string[] = [];
     const removed: string[] = [];
     const errors: string[] = [];
 
-    // Process files in batches to avoid memory issues
-    // Use more efficient batch processing with memory management
-    let processedCount = 0;
-
-    for (let i = 0; i < allFiles.length; i += batchSize) {
-      const batch = allFiles.slice(i, Math.min(i + batchSize, allFiles.length));
-
-      // Report progress before processing batch
-      if (options?.onProgress) {
-        options.onProgress({ current: processedCount, total: totalFiles });
+    // Files indexed before an ignore rule covered them leave the index
+    for (const uri of Array.from(this.fileIndex.keys())) {
+      const file = relative(this.rootPath, fileURLToPath(uri));
+      if (ignoreRules.isIgnored(file)) {
+        this.removeFile(file);
+        removed.push(file);
       }
+    }
 
-      // Process batch concurrently with controlled memory usage
-      const batchPromises = batch.map(async (file) => {
+    // Re-index on the worker pool, under the same memory bound as indexFiles
+    let processedCount = 0;
+    const maxHeapMB = this.config?.settings?.memoryLimit;
+
+    await runWorkerPool(
+      allFiles,
+      async (file) => {
         const absolutePath = resolve(this.rootPath, file);
 
         try {
@@ -605,51 +847,30 @@ export class SymbolIndex extends EventEmitter {
           if (await this.fileSystem.exists(absolutePath)) {
             // Re-index the file
             await this.indexFile(file);
-            return { type: "updated" as const, file };
+            updated.push(file);
           } else {
             // File was deleted
             this.removeFile(file);
-            return { type: "removed" as const, file };
+            removed.push(file);
           }
         } catch (error) {
-          return {
-            type: "error" as const,
-            file,
-            error: `${file}: ${error instanceof Error ? error.message : String(error)}`,
-          };
-        }
-      });
-
-      const batchResults = await Promise.all(batchPromises);
-
-      // Collect results immediately to free memory
-      for (const result of batchResults) {
-        switch (result.type) {
-          case "updated":
-            updated.push(result.file);
-            break;
-          case "removed":
-            removed.push(result.file);
-            break;
-          case "error":
-            errors.push(result.error);
-            break;
+          errors.push(
+            `${file}: ${error instanceof Error ? error.message : String(error)}`,
+          );
         }
-      }
-
-      processedCount += batch.length;
-
-      // For very large batches, add a small yield to prevent blocking
-      // Only add delay for very large sets of files
-      if (allFiles.length > 1000 && i + batchSize < allFiles.length) {
-        await new Promise((resolve) => setTimeout(resolve, 10));
-      }
 
-      // Clear references to help garbage collection for large batches
-      if (processedCount % 500 === 0 && global.gc) {
-        global.gc();
-      }
-    }
+        processedCount++;
+        options?.onProgress?.({
+          current: processedCount,
+          total: totalFiles,
+          file,
+        });
+      },
+      {
+        workers: batchSize,
+        maxHeapBytes: maxHeapMB ? maxHeapMB * 1024 * 1024 : undefined,
+      },
+    );
 
     // Final progress report
     if (options?.onProgress) {
@@ -659,6 +880,12 @@ export class SymbolIndex extends EventEmitter {
     // Update git hash
     this.stats.lastGitHash = currentHash;
     this.stats.lastUpdated = new Date();
+    this.stats.lastIncrementalAt = this.stats.lastUpdated;
+    await this.persistGitHash(currentHash);
+    await this.persistMetadata(
+      LAST_INCREMENTAL_AT_KEY,
+      this.stats.lastIncrementalAt.toISOString(),
+    );
 
     debugLogWithPrefix(
       "SymbolIndex",
@@ -668,6 +895,80 @@ export class SymbolIndex extends EventEmitter {
     return { updated, removed, errors };
   }
 
+  /**
+   * Whether a changed file (relative to root) belongs in the index: files
+   * already indexed always do, so deletions and renames are picked up;
+   * others must match config.files, or without patterns have the extension
+   * of an indexed file or a TypeScript/JavaScript one
+   */
+  private createIndexableFilter(): (file: string) => boolean {
+    const indexed = new Set(this.getIndexedFiles());
+    const patterns = this.config?.files ?? [];
+    const extensions = new Set([
+      ...DEFAULT_EXTENSIONS,
+      ...Array.from(indexed, (file) => extname(file)).filter(Boolean),
+    ]);
+
+    return (file) => {
+      if (indexed.has(file)) {
+        return true;
+      }
+      if (patterns.length > 0) {
+        return patterns.some((p) => minimatch(file, p, { dot: true }));
+      }
+      return extensions.has(extname(file));
+    };
+  }
+
+  /**
+   * References stored in the cache under a key; the cache drops them all
+   * when a file is indexed again or removed
+   */
+  async getCachedReferences(key: string): Promise<CachedReference[]> {
+    return (await this.cache?.getReferences?.(key)) ?? [];
+  }
+
+  async cacheReferences(
+    key: string,
+    references: CachedReference[],
+  ): Promise<void> {
+    await this.cache?.setReferences?.(key, references);
+  }
+
+  /** Content hash of an indexed file, when it was computed */
+  getContentHash(uri: string): string | undefined {
+    return this.fileIndex.get(uri)?.contentHash;
+  }
+
+  /**
+   * Embeddings of a file stored in the cache for the given content
+   */
+  async getCachedEmbeddings(
+    uri: string,
+    embedderId: string,
+    contentHash: string,
+  ): Promise<CachedEmbeddings | null> {
+    return (
+      (await this.cache?.getEmbeddings?.(
+        fileURLToPath(uri),
+        embedderId,
+        contentHash,
+      )) ?? null
+    );
+  }
+
+  async cacheEmbeddings(
+    uri: string,
+    embedderId: string,
+    embeddings: CachedEmbeddings,
+  ): Promise<void> {
+    await this.cache?.setEmbeddings?.(
+      fileURLToPath(uri),
+      embedderId,
+      embeddings,
+    );
+  }
+
   /**
    * Check if a file needs re-indexing
    */
@@ -704,12 +1005,35 @@ export class SymbolIndex extends EventEmitter {
 
   // Private methods
 
+  private async persistGitHash(hash: string | undefined): Promise<void> {
+    if (hash) {
+      await this.persistMetadata(LAST_GIT_HASH_KEY, hash);
+    }
+  }
+
+  private async persistMetadata(key: string, value: string): Promise<void> {
+    if (!this.cache?.setMetadata) {
+      return;
+    }
+    try {
+      await this.cache.setMetadata(key, value);
+    } catch (error) {
+      debugLogWithPrefix(
+        "SymbolIndex",
+        `Failed to persist ${key}: ${error instanceof Error ? error.message : String(error)}`,
+      );
+    }
+  }
+
   private storeSymbols(
     uri: string,
     symbols: IndexedSymbol[],
     gitHash?: string,
     contentHash?: string,
   ): void {
+    // Tag symbols with their workspace root (also for cached entries)
+    this.tagRoot(symbols);
+
     // Store in file index
     this.fileIndex.set(uri, {
       uri,
@@ -724,6 +1048,15 @@ export class SymbolIndex extends EventEmitter {
     this.updateIndices(symbols, uri);
   }
 
+  private tagRoot(symbols: IndexedSymbol[]): void {
+    for (const symbol of symbols) {
+      symbol.root = this.rootPath;
+      if (symbol.children) {
+        this.tagRoot(symbol.children);
+      }
+    }
+  }
+
   private updateIndices(symbols: IndexedSymbol[], uri: string): void {
     const processSymbol = (symbol: IndexedSymbol, containerName?: string) => {
       // Update name index
@@ -801,11 +1134,8 @@ export class SymbolIndex extends EventEmitter {
     const processSymbol = (symbol: IndexedSymbol, containerName?: string) => {
       let matches = true;
 
-      // Check name (case-insensitive)
-      if (
-        query.name &&
-        !symbol.name.toLowerCase().includes(query.name.toLowerCase())
-      ) {
+      // Check name (case-insensitive fuzzy match)
+      if (query.name && fuzzyScore(query.name, symbol.name) === null) {
         matches = false;
       }
 
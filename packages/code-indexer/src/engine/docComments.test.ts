@@ -0,0 +1,57 @@
+import { describe, it, expect } from "vitest";
+import { extractDocComment } from "./docComments.ts";
+
+describe("extractDocComment", () => {
+  it("should read a JSDoc block above the declaration", () => {
+    const lines = [
+      "import { z } from 'zod';",
+      "",
+      "/**",
+      " * Check that a string looks like an e-mail address",
+      " * @param value the address",
+      " */",
+      "export function isValidEmail(value: string): boolean {",
+    ];
+
+    expect(extractDocComment(lines, 6)).toBe(
+      "Check that a string looks like an e-mail address @param value the address",
+    );
+  });
+
+  it("should skip decorators and attributes between comment and declaration", () => {
+    const lines = [
+      "/// Parses the configuration file",
+      "#[derive(Debug)]",
+      "pub struct Config {",
+    ];
+
+    expect(extractDocComment(lines, 2)).toBe("Parses the configuration file");
+  });
+
+  it("should read a comment at the start of the symbol range", () => {
+    const lines = [
+      "// Dial connects to the address",
+      "func Dial(addr string) (*Conn, error) {",
+    ];
+
+    expect(extractDocComment(lines, 0)).toBe("Dial connects to the address");
+  });
+
+  it("should read a Python docstring after the declaration", () => {
+    const lines = [
+      "def send_mail(to):",
+      '    """Send an e-mail.',
+      "",
+      '    Retries twice."""',
+      "    pass",
+    ];
+
+    expect(extractDocComment(lines, 0)).toBe("Send an e-mail. Retries twice.");
+  });
+
+  it("should ignore comments separated by a blank line", () => {
+    const lines = ["// Copyright 2026", "", "func main() {"];
+
+    expect(extractDocComment(lines, 2)).toBe("");
+  });
+});
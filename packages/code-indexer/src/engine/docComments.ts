@@ -0,0 +1,85 @@
+/**
+ * Doc comments of declarations, read from source text
+ *
+ * Servers differ on whether a symbol range covers its doc comment, so the
+ * comment is looked for both at the start of the range and right above it.
+ * Python docstrings follow the declaration line instead.
+ */
+
+// Most doc comment lines kept; the first lines carry the summary
+const MAX_DOC_LINES = 20;
+
+const COMMENT_LINE = /^(\/\/|\/\*|\*|--|#(?!\[|!|include|define|if))/;
+
+// Decorators and attributes between a doc comment and its declaration
+const ATTRIBUTE_LINE = /^(@\w|#\[|\[\w)/;
+
+const DOCSTRING_START = /^[rRuU]?("""|''')/;
+
+function stripCommentMarkers(line: string): string {
+  return line
+    .trim()
+    .replace(/^(\/\/[/!]?|\/\*\*?|\*\/|\*|--|#+)\s?/, "")
+    .replace(/\s*\*\/$/, "")
+    .trim();
+}
+
+function readDocstring(lines: string[], start: number): string[] {
+  const first = lines[start]?.trim() ?? "";
+  const quote = DOCSTRING_START.exec(first)?.[1];
+  if (!quote) {
+    return [];
+  }
+  const body = first.slice(first.indexOf(quote) + 3);
+  if (body.includes(quote)) {
+    return [body.slice(0, body.indexOf(quote))];
+  }
+  const result = [body];
+  for (let i = start + 1; i < lines.length; i++) {
+    const line = lines[i].trim();
+    if (line.includes(quote)) {
+      result.push(line.slice(0, line.indexOf(quote)));
+      break;
+    }
+    result.push(line);
+  }
+  return result;
+}
+
+/**
+ * Text of the doc comment of the declaration starting at `line` (0-based),
+ * without comment markers; empty when there is none
+ */
+export function extractDocComment(lines: string[], line: number): string {
+  const isComment = (index: number) =>
+    COMMENT_LINE.test(lines[index]?.trim() ?? "");
+  let comment: string[] = [];
+
+  if (isComment(line)) {
+    // The range starts with the comment
+    for (let i = line; i < lines.length && isComment(i); i++) {
+      comment.push(lines[i]);
+    }
+  } else {
+    let i = line - 1;
+    while (i >= 0 && ATTRIBUTE_LINE.test(lines[i].trim())) {
+      i--;
+    }
+    for (; i >= 0 && isComment(i); i--) {
+      comment.unshift(lines[i]);
+    }
+  }
+
+  if (comment.length === 0) {
+    // Python: the docstring is the first statement of the body
+    comment = readDocstring(lines, line + 1);
+  } else {
+    comment = comment.map(stripCommentMarkers);
+  }
+
+  return comment
+    .map((text) => text.trim())
+    .filter((text) => text.length > 0)
+    .slice(0, MAX_DOC_LINES)
+    .join(" ");
+}
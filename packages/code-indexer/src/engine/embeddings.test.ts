@@ -0,0 +1,134 @@
+import { describe, it, expect, vi, afterEach } from "vitest";
+import {
+  cosineSimilarity,
+  createHttpEmbedder,
+  createLocalEmbedder,
+  embedText,
+  tokenizeText,
+} from "./embeddings.ts";
+
+describe("tokenizeText", () => {
+  it("should split identifiers into stemmed words", () => {
+    expect(tokenizeText("validateEmailAddresses")).toEqual([
+      "validat",
+      "email",
+      "address",
+    ]);
+    expect(tokenizeText("parse_URL HTTPServer")).toEqual([
+      "pars",
+      "url",
+      "http",
+      "serv",
+    ]);
+  });
+
+  it("should give word forms the same stem and drop stop words", () => {
+    expect(tokenizeText("validates validating validation")).toEqual([
+      "validat",
+      "validat",
+      "validat",
+    ]);
+    expect(tokenizeText("the function that parses a config")).toEqual([
+      "function",
+      "pars",
+      "config",
+    ]);
+  });
+
+  it("should expand common abbreviations", () => {
+    expect(tokenizeText("checkAddr cfg")).toEqual([
+      "check",
+      "address",
+      "config",
+    ]);
+  });
+});
+
+describe("embedText", () => {
+  it("should rank related symbols above unrelated ones", () => {
+    const query = embedText("function that validates email addresses");
+    const score = (text: string) => cosineSimilarity(query, embedText(text));
+
+    expect(score("isValidEmail Function")).toBeGreaterThan(
+      score("parseConfig Function Read the config file"),
+    );
+    expect(score("checkAddr Function")).toBeGreaterThan(
+      score("scheduleRetry Function Queue a job to run again"),
+    );
+  });
+
+  it("should return unit vectors, and a zero vector for no words", () => {
+    const vector = embedText("validateEmail", 64);
+    const zero = embedText("the of", 64);
+
+    expect(vector).toHaveLength(64);
+    expect(cosineSimilarity(vector, vector)).toBeCloseTo(1);
+    expect(zero.every((value) => value === 0)).toBe(true);
+    expect(cosineSimilarity(vector, zero)).toBe(0);
+  });
+});
+
+describe("createLocalEmbedder", () => {
+  it("should embed documents and queries alike", async () => {
+    const embedder = createLocalEmbedder(128);
+    const [document] = await embedder.embedDocuments(["sendEmail"]);
+    const query = await embedder.embedQuery("send email");
+
+    expect(embedder.id).toBe("local-128");
+    expect(cosineSimilarity(document, query)).toBeCloseTo(1);
+  });
+});
+
+describe("createHttpEmbedder", () => {
+  afterEach(() => {
+    vi.unstubAllGlobals();
+  });
+
+  it("should post batches to the endpoint and keep the order", async () => {
+    const fetchMock = vi.fn(async (_url: string, init: RequestInit) => {
+      const { input } = JSON.parse(String(init.body)) as { input: string[] };
+      const data = input.map((text, index) => ({
+        index,
+        embedding: [text.length, index],
+      }));
+      return new Response(JSON.stringify({ data: data.reverse() }));
+    });
+    vi.stubGlobal("fetch", fetchMock);
+    const embedder = createHttpEmbedder({
+      url: "http://localhost:11434/v1/embeddings",
+      model: "nomic-embed-text",
+      apiKey: "secret",
+      batchSize: 2,
+    });
+
+    const vectors = await embedder.embedDocuments(["a", "bb", "ccc"]);
+
+    expect(embedder.id).toBe("http-nomic-embed-text");
+    expect(vectors.map((vector) => Array.from(vector))).toEqual([
+      [1, 0],
+      [2, 1],
+      [3, 0],
+    ]);
+    expect(fetchMock).toHaveBeenCalledTimes(2);
+    const [url, init] = fetchMock.mock.calls[0];
+    expect(url).toBe("http://localhost:11434/v1/embeddings");
+    expect(init.headers).toMatchObject({ Authorization: "Bearer secret" });
+    expect(JSON.parse(String(init.body))).toEqual({
+      model: "nomic-embed-text",
+      input: ["a", "bb"],
+    });
+  });
+
+  it("should fail when the endpoint does", async () => {
+    vi.stubGlobal(
+      "fetch",
+      vi.fn(async () => new Response("unavailable", { status: 503 })),
+    );
+    const embedder = createHttpEmbedder({
+      url: "http://localhost:11434/v1/embeddings",
+      model: "nomic-embed-text",
+    });
+
+    await expect(embedder.embedQuery("email")).rejects.toThrow(/503/);
+  });
+});
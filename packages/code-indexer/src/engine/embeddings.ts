@@ -0,0 +1,271 @@
+/**
+ * Text embeddings for semantic symbol search
+ *
+ * An Embedder turns symbol descriptions and queries into vectors whose
+ * cosine similarity tells how related they are. The local embedder needs no
+ * model: it splits identifiers into words, stems them and hashes the words
+ * and their character trigrams into a fixed number of dimensions, so
+ * "validates email addresses" finds `validateEmailAddress` and `isValidEmail`.
+ * The HTTP embedder asks an embedding model behind an OpenAI-compatible
+ * endpoint instead; other providers implement Embedder the same way.
+ */
+
+export interface Embedder {
+  /** Vectors of embedders with the same id are comparable */
+  id: string;
+  embedDocuments(texts: string[]): Promise<Float32Array[]>;
+  embedQuery(text: string): Promise<Float32Array>;
+}
+
+const DEFAULT_DIMENSIONS = 512;
+
+// Texts sent to an embeddings endpoint per request
+const DEFAULT_HTTP_BATCH_SIZE = 64;
+
+// Trigrams catch abbreviations and word forms the stemmer misses
+const TRIGRAM_WEIGHT = 0.3;
+
+const STOP_WORDS = new Set([
+  "a",
+  "all",
+  "an",
+  "and",
+  "any",
+  "are",
+  "as",
+  "at",
+  "be",
+  "by",
+  "can",
+  "do",
+  "does",
+  "for",
+  "from",
+  "how",
+  "i",
+  "in",
+  "into",
+  "is",
+  "it",
+  "its",
+  "of",
+  "on",
+  "or",
+  "so",
+  "some",
+  "that",
+  "the",
+  "then",
+  "this",
+  "to",
+  "was",
+  "what",
+  "where",
+  "which",
+  "who",
+  "with",
+]);
+
+// Abbreviations common in identifiers, by the word they stand for
+const ABBREVIATIONS: Record<string, string> = {
+  addr: "address",
+  arg: "argument",
+  auth: "authenticate",
+  buf: "buffer",
+  cfg: "config",
+  conf: "config",
+  conn: "connection",
+  ctx: "context",
+  db: "database",
+  dir: "directory",
+  env: "environment",
+  err: "error",
+  fn: "function",
+  func: "function",
+  idx: "index",
+  init: "initialize",
+  msg: "message",
+  num: "number",
+  pwd: "password",
+  req: "request",
+  res: "response",
+  resp: "response",
+  str: "string",
+  tmp: "temporary",
+  usr: "user",
+  val: "value",
+};
+
+/** Strip plural and common verb suffixes so word forms share a stem */
+function stem(word: string): string {
+  if (word.length <= 3) {
+    return word;
+  }
+  let result = word;
+  if (result.endsWith("sses")) {
+    result = result.slice(0, -2);
+  } else if (result.endsWith("ies") && result.length > 4) {
+    result = `${result.slice(0, -3)}y`;
+  } else if (result.endsWith("s") && !/(ss|us|is)$/.test(result)) {
+    result = result.slice(0, -1);
+  }
+  if (result.endsWith("ation") && result.length > 7) {
+    result = `${result.slice(0, -5)}at`;
+  } else {
+    for (const suffix of ["ing", "ed", "er"]) {
+      if (result.endsWith(suffix) && result.length - suffix.length >= 3) {
+        result = result.slice(0, -suffix.length);
+        break;
+      }
+    }
+  }
+  if (result.endsWith("e") && result.length > 3) {
+    result = result.slice(0, -1);
+  }
+  return result;
+}
+
+/**
+ * Split text and identifiers (camelCase, PascalCase, snake_case, kebab-case)
+ * into stemmed lowercase words, without stop words
+ */
+export function tokenizeText(text: string): string[] {
+  return text
+    .replace(/([a-z0-9])([A-Z])/g, "$1 $2")
+    .replace(/([A-Z]+)([A-Z][a-z])/g, "$1 $2")
+    .toLowerCase()
+    .split(/[^a-z0-9]+/)
+    .filter((word) => word.length > 0 && !STOP_WORDS.has(word))
+    .map((word) => stem(ABBREVIATIONS[word] ?? word));
+}
+
+/** 32-bit FNV-1a */
+function hash(text: string): number {
+  let h = 0x811c9dc5;
+  for (let i = 0; i < text.length; i++) {
+    h ^= text.charCodeAt(i);
+    h = Math.imul(h, 0x01000193);
+  }
+  return h >>> 0;
+}
+
+function addFeature(vector: Float32Array, feature: string, weight: number) {
+  const h = hash(feature);
+  // The top bit picks the sign so that collisions cancel out on average
+  vector[h % vector.length] += h & 0x80000000 ? -weight : weight;
+}
+
+function normalize(vector: Float32Array): Float32Array {
+  let norm = 0;
+  for (const value of vector) {
+    norm += value * value;
+  }
+  norm = Math.sqrt(norm);
+  if (norm > 0) {
+    for (let i = 0; i < vector.length; i++) {
+      vector[i] /= norm;
+    }
+  }
+  return vector;
+}
+
+/** Hashed bag of words and trigrams, L2-normalized */
+export function embedText(
+  text: string,
+  dimensions = DEFAULT_DIMENSIONS,
+): Float32Array {
+  const vector = new Float32Array(dimensions);
+  for (const word of tokenizeText(text)) {
+    addFeature(vector, word, 1);
+    const padded = `#${word}#`;
+    for (let i = 0; i + 3 <= padded.length; i++) {
+      addFeature(vector, `#3${padded.slice(i, i + 3)}`, TRIGRAM_WEIGHT);
+    }
+  }
+  return normalize(vector);
+}
+
+/**
+ * Embedder without a model, see embedText
+ */
+export function createLocalEmbedder(
+  dimensions = DEFAULT_DIMENSIONS,
+): Embedder {
+  return {
+    id: `local-${dimensions}`,
+    embedDocuments: async (texts) =>
+      texts.map((text) => embedText(text, dimensions)),
+    embedQuery: async (text) => embedText(text, dimensions),
+  };
+}
+
+export interface HttpEmbedderOptions {
+  /** e.g. https://api.openai.com/v1/embeddings or http://localhost:11434/v1/embeddings */
+  url: string;
+  model: string;
+  /** Sent as a bearer token */
+  apiKey?: string;
+  batchSize?: number;
+}
+
+/**
+ * Embedder backed by an OpenAI-compatible embeddings endpoint (OpenAI,
+ * Ollama, LM Studio, llama.cpp server, ...)
+ */
+export function createHttpEmbedder(options: HttpEmbedderOptions): Embedder {
+  const batchSize = options.batchSize ?? DEFAULT_HTTP_BATCH_SIZE;
+
+  const embed = async (input: string[]): Promise<Float32Array[]> => {
+    const response = await fetch(options.url, {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+        ...(options.apiKey
+          ? { Authorization: `Bearer ${options.apiKey}` }
+          : {}),
+      },
+      body: JSON.stringify({ model: options.model, input }),
+    });
+    if (!response.ok) {
+      throw new Error(
+        `Embeddings endpoint ${options.url} returned ${response.status}`,
+      );
+    }
+    const body = (await response.json()) as {
+      data?: { index: number; embedding: number[] }[];
+    };
+    if (body.data?.length !== input.length) {
+      throw new Error(
+        `Embeddings endpoint ${options.url} returned ${body.data?.length ?? 0} vectors for ${input.length} texts`,
+      );
+    }
+    return [...body.data]
+      .sort((a, b) => a.index - b.index)
+      .map(({ embedding }) => Float32Array.from(embedding));
+  };
+
+  return {
+    id: `http-${options.model}`,
+    embedDocuments: async (texts) => {
+      const vectors: Float32Array[] = [];
+      for (let i = 0; i < texts.length; i += batchSize) {
+        vectors.push(...(await embed(texts.slice(i, i + batchSize))));
+      }
+      return vectors;
+    },
+    embedQuery: async (text) => (await embed([text]))[0],
+  };
+}
+
+/** Cosine similarity of two vectors of the same length */
+export function cosineSimilarity(a: Float32Array, b: Float32Array): number {
+  let dot = 0;
+  let normA = 0;
+  let normB = 0;
+  for (let i = 0; i < a.length; i++) {
+    dot += a[i] * b[i];
+    normA += a[i] * a[i];
+    normB += b[i] * b[i];
+  }
+  return normA > 0 && normB > 0 ? dot / Math.sqrt(normA * normB) : 0;
+}
@@ -0,0 +1,297 @@
+/**
+ * fzf-style fuzzy matching and relevance ranking for symbol names
+ */
+
+import { SymbolKind } from "vscode-languageserver-types";
+import type { IndexedSymbol } from "./types.ts";
+
+const SCORE_MATCH = 16;
+const SCORE_GAP_START = -3;
+const SCORE_GAP_EXTENSION = -1;
+const BONUS_BOUNDARY = 8;
+const BONUS_CAMEL = 7;
+const BONUS_CONSECUTIVE = 4;
+const BONUS_FIRST_CHAR_MULTIPLIER = 2;
+const BONUS_PREFIX = 24;
+const BONUS_EXACT = 48;
+
+function isLower(char: string): boolean {
+  return char >= "a" && char <= "z";
+}
+
+function isUpper(char: string): boolean {
+  return char >= "A" && char <= "Z";
+}
+
+function isDigit(char: string): boolean {
+  return char >= "0" && char <= "9";
+}
+
+function isAlphaNumeric(char: string): boolean {
+  return isLower(char) || isUpper(char) || isDigit(char);
+}
+
+/**
+ * Bonus for matching the character at `index`, based on the character before it
+ */
+function positionBonus(candidate: string, index: number): number {
+  if (index === 0) {
+    return BONUS_BOUNDARY;
+  }
+  const prev = candidate[index - 1];
+  const current = candidate[index];
+  if (!isAlphaNumeric(prev)) {
+    return BONUS_BOUNDARY;
+  }
+  if (
+    (isLower(prev) && isUpper(current)) ||
+    (!isDigit(prev) && isDigit(current))
+  ) {
+    return BONUS_CAMEL;
+  }
+  return 0;
+}
+
+/**
+ * Score how well `query` matches `candidate` as a case-insensitive
+ * subsequence. Returns null when the query characters do not all appear in
+ * order. Higher is better; word and camelCase boundaries, consecutive runs
+ * and prefixes are rewarded, gaps are penalized.
+ *
+ * Like fzf's v1 algorithm, the match is found by a forward scan and then
+ * tightened by scanning backwards from its end, so "prcUsrs" prefers the
+ * shortest window containing the whole query.
+ */
+export function fuzzyScore(query: string, candidate: string): number | null {
+  if (query.length === 0) {
+    return 0;
+  }
+  const q = query.toLowerCase();
+  const c = candidate.toLowerCase();
+
+  // Forward scan to find where the match ends
+  let qi = 0;
+  let end = -1;
+  for (let ci = 0; ci < c.length; ci++) {
+    if (c[ci] === q[qi]) {
+      qi++;
+      if (qi === q.length) {
+        end = ci;
+        break;
+      }
+    }
+  }
+  if (end === -1) {
+    return null;
+  }
+
+  // Backward scan to find the latest start
+  qi = q.length - 1;
+  let start = end;
+  for (let ci = end; ci >= 0; ci--) {
+    if (c[ci] === q[qi]) {
+      qi--;
+      if (qi < 0) {
+        start = ci;
+        break;
+      }
+    }
+  }
+
+  // Score the window left to right
+  let score = 0;
+  let inGap = false;
+  let consecutive = 0;
+  let firstBonus = 0;
+  qi = 0;
+  for (let ci = start; ci <= end && qi < q.length; ci++) {
+    if (c[ci] === q[qi]) {
+      let bonus = positionBonus(candidate, ci);
+      if (consecutive === 0) {
+        firstBonus = bonus;
+      } else {
+        // Consecutive runs keep at least the bonus of the chunk they started
+        if (bonus >= BONUS_CAMEL) {
+          firstBonus = bonus;
+        }
+        bonus = Math.max(bonus, firstBonus, BONUS_CONSECUTIVE);
+      }
+      if (qi === 0) {
+        bonus *= BONUS_FIRST_CHAR_MULTIPLIER;
+      }
+      if (query[qi] === candidate[ci]) {
+        score += 1;
+      }
+      score += SCORE_MATCH + bonus;
+      consecutive++;
+      inGap = false;
+      qi++;
+    } else {
+      score += inGap ? SCORE_GAP_EXTENSION : SCORE_GAP_START;
+      inGap = true;
+      consecutive = 0;
+      firstBonus = 0;
+    }
+  }
+
+  // Leading characters before the match cost a little
+  score -= Math.min(start, 8);
+
+  if (c === q) {
+    score += BONUS_EXACT;
+  } else if (c.startsWith(q)) {
+    score += BONUS_PREFIX;
+  }
+
+  return score;
+}
+
+/** Declarations people usually search for rank above locals and members */
+const KIND_BONUS: Partial<Record<SymbolKind, number>> = {
+  [SymbolKind.Class]: 12,
+  [SymbolKind.Interface]: 12,
+  [SymbolKind.Struct]: 12,
+  [SymbolKind.Enum]: 10,
+  [SymbolKind.Function]: 10,
+  [SymbolKind.Method]: 8,
+  [SymbolKind.Module]: 6,
+  [SymbolKind.Namespace]: 6,
+  [SymbolKind.TypeParameter]: -4,
+  [SymbolKind.Variable]: -2,
+  [SymbolKind.Field]: -2,
+};
+
+const BONUS_TOP_LEVEL = 6;
+const PENALTY_PRIVATE = 8;
+const PENALTY_PER_DIRECTORY = 2;
+
+/**
+ * Whether a symbol looks like part of the public surface: top-level, not
+ * underscore/# private, and capitalized when nested (Go's export rule)
+ */
+export function isExportedSymbol(
+  symbol: Pick<IndexedSymbol, "name" | "containerName">,
+): boolean {
+  if (symbol.name.startsWith("_") || symbol.name.startsWith("#")) {
+    return false;
+  }
+  return !symbol.containerName || isUpper(symbol.name[0] ?? "");
+}
+
+/**
+ * Relevance of a symbol for a name query, or null if the name does not match.
+ * Combines the fuzzy score with the symbol kind, exported-ness and how deep
+ * the file sits below the project root.
+ */
+export function symbolRelevance(
+  query: string,
+  symbol: IndexedSymbol,
+  relativePath: string,
+): number | null {
+  const score = fuzzyScore(query, symbol.name);
+  if (score === null) {
+    return null;
+  }
+
+  const depth = relativePath.split(/[\\/]/).length - 1;
+  return (
+    score +
+    (KIND_BONUS[symbol.kind] ?? 0) +
+    (symbol.containerName ? 0 : BONUS_TOP_LEVEL) -
+    (isExportedSymbol(symbol) ? 0 : PENALTY_PRIVATE) -
+    Math.min(depth, 5) * PENALTY_PER_DIRECTORY
+  );
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  describe("fuzzyScore", () => {
+    it("should match subsequences case-insensitively", () => {
+      expect(fuzzyScore("prcUsrs", "processUsers")).not.toBeNull();
+      expect(fuzzyScore("PROCESS", "processUsers")).not.toBeNull();
+      expect(fuzzyScore("usp", "processUsers")).toBeNull();
+    });
+
+    it("should treat an empty query as a match", () => {
+      expect(fuzzyScore("", "anything")).toBe(0);
+    });
+
+    it("should rank exact matches above prefixes above subsequences", () => {
+      const exact = fuzzyScore("user", "user")!;
+      const prefix = fuzzyScore("user", "userName")!;
+      const scattered = fuzzyScore("user", "uniqueServer")!;
+      expect(exact).toBeGreaterThan(prefix);
+      expect(prefix).toBeGreaterThan(scattered);
+    });
+
+    it("should prefer camelCase boundaries over scattered matches", () => {
+      const names = [
+        "parseRecordsUsingRules",
+        "printCurrentUserStatus",
+        "processUsers",
+      ];
+      const ranked = [...names].sort(
+        (a, b) => fuzzyScore("prcUsrs", b)! - fuzzyScore("prcUsrs", a)!,
+      );
+      expect(ranked[0]).toBe("processUsers");
+    });
+
+    it("should reward word boundaries after separators", () => {
+      expect(fuzzyScore("gu", "get_user")!).toBeGreaterThan(
+        fuzzyScore("gu", "gradual")!,
+      );
+    });
+  });
+
+  describe("symbolRelevance", () => {
+    const symbol = (
+      name: string,
+      kind: SymbolKind,
+      containerName?: string,
+    ): IndexedSymbol => ({
+      name,
+      kind,
+      containerName,
+      location: {
+        uri: "file:///root/a.ts",
+        range: {
+          start: { line: 0, character: 0 },
+          end: { line: 0, character: name.length },
+        },
+      },
+    });
+
+    it("should return null when the name does not match", () => {
+      expect(
+        symbolRelevance("zzz", symbol("run", SymbolKind.Function), "a.ts"),
+      ).toBeNull();
+    });
+
+    it("should prefer functions over variables with the same name", () => {
+      const fn = symbolRelevance(
+        "config",
+        symbol("config", SymbolKind.Function),
+        "a.ts",
+      )!;
+      const variable = symbolRelevance(
+        "config",
+        symbol("config", SymbolKind.Variable),
+        "a.ts",
+      )!;
+      expect(fn).toBeGreaterThan(variable);
+    });
+
+    it("should prefer exported and shallow symbols", () => {
+      const run = symbol("Run", SymbolKind.Method, "Server");
+      const hidden = symbol("run", SymbolKind.Method, "Server");
+      expect(symbolRelevance("run", run, "a.go")!).toBeGreaterThan(
+        symbolRelevance("run", hidden, "a.go")!,
+      );
+      expect(symbolRelevance("run", run, "a.go")!).toBeGreaterThan(
+        symbolRelevance("run", run, "internal/deep/pkg/a.go")!,
+      );
+    });
+  });
+}
@@ -0,0 +1,144 @@
+import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { dirname, join } from "path";
+import {
+  IgnoreRules,
+  isIgnoreFile,
+  parseIgnorePattern,
+} from "./ignoreRules.ts";
+
+describe("parseIgnorePattern", () => {
+  const parse = (line: string) =>
+    parseIgnorePattern(line, "/root", ".gitignore");
+
+  it("should skip blank lines and comments", () => {
+    expect(parse("")).toBeUndefined();
+    expect(parse("   ")).toBeUndefined();
+    expect(parse("# build")).toBeUndefined();
+  });
+
+  it("should parse negation, escapes, anchors and directory rules", () => {
+    expect(parse("!keep.ts")).toMatchObject({
+      negated: true,
+      glob: "**/keep.ts",
+    });
+    expect(parse("\\#file")).toMatchObject({
+      negated: false,
+      glob: "**/#file",
+    });
+    expect(parse("/dist/")).toMatchObject({
+      directoryOnly: true,
+      glob: "dist",
+    });
+    expect(parse("docs/*.md  ")).toMatchObject({
+      pattern: "docs/*.md",
+      glob: "docs/*.md",
+    });
+  });
+});
+
+describe("IgnoreRules", () => {
+  let rootPath: string;
+
+  function write(path: string, content: string): void {
+    mkdirSync(dirname(join(rootPath, path)), { recursive: true });
+    writeFileSync(join(rootPath, path), content);
+  }
+
+  beforeEach(() => {
+    rootPath = mkdtempSync(join(tmpdir(), "lsmcp-ignore-"));
+  });
+
+  afterEach(() => {
+    rmSync(rootPath, { recursive: true, force: true });
+  });
+
+  it("should apply .gitignore files from the root down", () => {
+    write(".gitignore", "# output\nbuild/\n*.log\n");
+    write("packages/app/.gitignore", "*.gen.ts\n/local.ts\n");
+    const rules = new IgnoreRules(rootPath);
+
+    expect(rules.isIgnored("build/index.ts")).toBe(true);
+    expect(rules.isIgnored("src/build.ts")).toBe(false);
+    expect(rules.isIgnored("logs/debug.log")).toBe(true);
+    expect(rules.isIgnored("packages/app/src/api.gen.ts")).toBe(true);
+    expect(rules.isIgnored("packages/lib/api.gen.ts")).toBe(false);
+    expect(rules.isIgnored("packages/app/local.ts")).toBe(true);
+    expect(rules.isIgnored("packages/app/src/local.ts")).toBe(false);
+    expect(rules.match("packages/app/src/api.gen.ts").rule).toMatchObject({
+      source: "packages/app/.gitignore",
+      line: 1,
+    });
+  });
+
+  it("should let .lsmcpignore add rules and re-include git-ignored files", () => {
+    write(".gitignore", "generated/\n");
+    write(".lsmcpignore", "!generated/\nfixtures/\n");
+    const rules = new IgnoreRules(rootPath);
+
+    expect(rules.isIgnored("generated/schema.ts")).toBe(false);
+    expect(rules.isIgnored("fixtures/sample.ts")).toBe(true);
+  });
+
+  it("should not re-include files below an ignored directory", () => {
+    write(".gitignore", "vendor/\n!vendor/keep.ts\n");
+    const rules = new IgnoreRules(rootPath);
+
+    expect(rules.match("vendor/keep.ts")).toMatchObject({
+      ignored: true,
+      rule: { pattern: "vendor/" },
+    });
+  });
+
+  it("should read .git/info/exclude", () => {
+    write(".git/info/exclude", "*.local.ts\n");
+    const rules = new IgnoreRules(rootPath);
+
+    expect(rules.match("src/config.local.ts")).toMatchObject({
+      ignored: true,
+      rule: { source: ".git/info/exclude", line: 1 },
+    });
+    expect(rules.isIgnored(".git/HEAD")).toBe(true);
+  });
+
+  it("should always apply config and built-in patterns", () => {
+    write(".lsmcpignore", "!.lsmcp/\n");
+    const rules = new IgnoreRules(rootPath, { patterns: ["**/dist/**"] });
+
+    expect(rules.isIgnored(".lsmcp/cache/symbols.db")).toBe(true);
+    expect(rules.match("packages/a/dist/index.js").rule).toMatchObject({
+      source: "ignorePatterns",
+    });
+    expect(rules.isIgnored(join(rootPath, "src/index.ts"))).toBe(false);
+    expect(rules.isIgnored("../elsewhere/build.log")).toBe(false);
+  });
+
+  it("should list rules by source in order of precedence", () => {
+    write(".gitignore", "build/\n");
+    write("build/.gitignore", "*.ts\n");
+    write("src/.lsmcpignore", "*.test.ts\n");
+    const rules = new IgnoreRules(rootPath, { patterns: ["**/dist/**"] });
+
+    const sources = rules.listSources().map(({ source, rules }) => ({
+      source,
+      patterns: rules.map((rule) => rule.pattern),
+    }));
+
+    expect(sources).toEqual([
+      { source: ".gitignore", patterns: ["build/"] },
+      { source: "src/.lsmcpignore", patterns: ["*.test.ts"] },
+      { source: "built-in", patterns: [".git/", ".lsmcp/"] },
+      { source: "ignorePatterns", patterns: ["**/dist/**"] },
+    ]);
+  });
+});
+
+describe("isIgnoreFile", () => {
+  it("should recognize ignore files anywhere in the tree", () => {
+    expect(isIgnoreFile(".gitignore")).toBe(true);
+    expect(isIgnoreFile("packages/app/.lsmcpignore")).toBe(true);
+    expect(isIgnoreFile(".git/info/exclude")).toBe(true);
+    expect(isIgnoreFile("src/gitignore.ts")).toBe(false);
+  });
+});
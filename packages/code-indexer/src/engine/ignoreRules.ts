@@ -0,0 +1,306 @@
+/**
+ * Ignore rules deciding which files are left out of the index
+ *
+ * A file is ignored when git would ignore it (.gitignore files from the
+ * repository root down and .git/info/exclude), when a .lsmcpignore says so,
+ * or when it matches the config's ignorePatterns. .lsmcpignore uses gitignore
+ * syntax and is read after the .gitignore of the same directory, so a
+ * `!generated/` in it indexes a directory git ignores. Ignore files are read
+ * on first use for the directories of the paths asked about.
+ */
+
+import { existsSync, readdirSync, readFileSync } from "fs";
+import { dirname, isAbsolute, join, relative, resolve, sep } from "path";
+import { Minimatch } from "minimatch";
+
+export const LSMCP_IGNORE_FILE = ".lsmcpignore";
+
+const IGNORE_FILES = [".gitignore", LSMCP_IGNORE_FILE];
+
+// Never indexed, whatever the ignore files say
+const BUILT_IN_PATTERNS = [".git/", ".lsmcp/"];
+
+export interface IgnoreRule {
+  /** The pattern as written */
+  pattern: string;
+  /** Ignore file relative to root, "ignorePatterns" or "built-in" */
+  source: string;
+  /** 1-based line in the source file */
+  line?: number;
+  negated: boolean;
+  directoryOnly: boolean;
+  /** Directory the pattern is relative to */
+  base: string;
+  /** Glob matched against paths relative to base */
+  glob: string;
+}
+
+export interface IgnoreMatch {
+  ignored: boolean;
+  /** The last rule that matched, which decided the outcome */
+  rule?: IgnoreRule;
+}
+
+export interface IgnoreSource {
+  source: string;
+  rules: IgnoreRule[];
+}
+
+/**
+ * Parse one line of a gitignore-style file
+ * Returns undefined for blank lines and comments
+ */
+export function parseIgnorePattern(
+  text: string,
+  base: string,
+  source: string,
+  line?: number,
+): IgnoreRule | undefined {
+  // Trailing spaces are dropped unless escaped with a backslash
+  const pattern = text.replace(/(?<!\\)\s+$/, "");
+  if (!pattern || pattern.startsWith("#")) {
+    return undefined;
+  }
+
+  const negated = pattern.startsWith("!");
+  let body = negated ? pattern.slice(1) : pattern;
+  if (body.startsWith("\\#") || body.startsWith("\\!")) {
+    body = body.slice(1);
+  }
+  const directoryOnly = body.endsWith("/");
+  body = body.replace(/\/+$/, "");
+  if (!body) {
+    return undefined;
+  }
+
+  // A slash at the start or in the middle anchors the pattern to its
+  // directory; otherwise it matches at any depth below it
+  const anchored = body.includes("/");
+  body = body.replace(/^\/+/, "");
+  return {
+    pattern,
+    source,
+    line,
+    negated,
+    directoryOnly,
+    base,
+    glob: anchored ? body : `**/${body}`,
+  };
+}
+
+const matchers = new WeakMap<IgnoreRule, Minimatch>();
+
+function matchesRule(
+  rule: IgnoreRule,
+  absolutePath: string,
+  isDirectory: boolean,
+): boolean {
+  if (rule.directoryOnly && !isDirectory) {
+    return false;
+  }
+  const path = relative(rule.base, absolutePath).split(sep).join("/");
+  if (!path || path.startsWith("../")) {
+    return false;
+  }
+  let matcher = matchers.get(rule);
+  if (!matcher) {
+    matcher = new Minimatch(rule.glob, { dot: true });
+    matchers.set(rule, matcher);
+  }
+  return matcher.match(path);
+}
+
+/**
+ * The closest directory at or above the given one that has a .git
+ */
+function findGitRoot(directory: string): string | undefined {
+  let current = directory;
+  while (true) {
+    if (existsSync(join(current, ".git"))) {
+      return current;
+    }
+    const parent = dirname(current);
+    if (parent === current) {
+      return undefined;
+    }
+    current = parent;
+  }
+}
+
+export class IgnoreRules {
+  private readonly rootPath: string;
+  private readonly gitRoot: string | undefined;
+  private readonly excludeRules: IgnoreRule[];
+  private readonly alwaysRules: IgnoreRule[];
+  // Rules of the ignore files in each directory, and all rules in effect there
+  private readonly ownRules = new Map<string, IgnoreRule[]>();
+  private readonly effectiveRules = new Map<string, IgnoreRule[]>();
+
+  constructor(rootPath: string, options: { patterns?: string[] } = {}) {
+    this.rootPath = resolve(rootPath);
+    this.gitRoot = findGitRoot(this.rootPath);
+
+    this.excludeRules = this.gitRoot
+      ? this.readRules(join(this.gitRoot, ".git/info/exclude"), this.gitRoot)
+      : [];
+
+    this.alwaysRules = [
+      ...BUILT_IN_PATTERNS.map(
+        (pattern) => parseIgnorePattern(pattern, this.rootPath, "built-in")!,
+      ),
+      // Config patterns are plain globs relative to the root
+      ...(options.patterns ?? []).map((pattern) => ({
+        pattern,
+        source: "ignorePatterns",
+        negated: false,
+        directoryOnly: false,
+        base: this.rootPath,
+        glob: pattern,
+      })),
+    ];
+  }
+
+  /**
+   * Whether a path (relative to root or absolute) is left out of the index
+   * Paths outside the root are never ignored
+   */
+  isIgnored(path: string, isDirectory = false): boolean {
+    return this.match(path, isDirectory).ignored;
+  }
+
+  /**
+   * Decide whether a path is ignored, and by which rule
+   */
+  match(path: string, isDirectory = false): IgnoreMatch {
+    const absolutePath = resolve(this.rootPath, path);
+    const relativePath = relative(this.rootPath, absolutePath);
+    if (
+      !relativePath ||
+      relativePath.startsWith("..") ||
+      isAbsolute(relativePath)
+    ) {
+      return { ignored: false };
+    }
+
+    const segments = relativePath.split(sep);
+    let current = this.rootPath;
+    let result: IgnoreMatch = { ignored: false };
+    for (let i = 0; i < segments.length; i++) {
+      current = join(current, segments[i]);
+      result = this.matchEntry(current, i < segments.length - 1 || isDirectory);
+      // As in git, nothing below an ignored directory can be re-included
+      if (result.ignored) {
+        break;
+      }
+    }
+    return result;
+  }
+
+  /**
+   * Every rule in effect below the root, grouped by source in order of
+   * precedence (later sources override earlier ones)
+   * Walks the directories that are not ignored to find nested ignore files.
+   */
+  listSources(): IgnoreSource[] {
+    const walk = (directory: string) => {
+      this.rulesIn(directory);
+      let entries: string[];
+      try {
+        entries = readdirSync(directory, { withFileTypes: true })
+          .filter((entry) => entry.isDirectory())
+          .map((entry) => entry.name);
+      } catch {
+        return;
+      }
+      for (const name of entries) {
+        const child = join(directory, name);
+        if (!this.matchEntry(child, true).ignored) {
+          walk(child);
+        }
+      }
+    };
+    walk(this.rootPath);
+
+    const directories = [...this.ownRules.keys()].sort();
+    const rules = [
+      ...this.excludeRules,
+      ...directories.flatMap((directory) => this.ownRules.get(directory)!),
+      ...this.alwaysRules,
+    ];
+    const sources = new Map<string, IgnoreRule[]>();
+    for (const rule of rules) {
+      const list = sources.get(rule.source) ?? [];
+      list.push(rule);
+      sources.set(rule.source, list);
+    }
+    return [...sources].map(([source, rules]) => ({ source, rules }));
+  }
+
+  private matchEntry(absolutePath: string, isDirectory: boolean): IgnoreMatch {
+    for (const rule of this.alwaysRules) {
+      if (matchesRule(rule, absolutePath, isDirectory)) {
+        return { ignored: true, rule };
+      }
+    }
+    // The last matching rule wins, so deeper files override shallower ones
+    let result: IgnoreMatch = { ignored: false };
+    for (const rule of this.rulesIn(dirname(absolutePath))) {
+      if (matchesRule(rule, absolutePath, isDirectory)) {
+        result = { ignored: !rule.negated, rule };
+      }
+    }
+    return result;
+  }
+
+  /**
+   * Rules applying to the entries of a directory: info/exclude, then the
+   * ignore files from the repository root (or our root) down to it
+   */
+  private rulesIn(directory: string): IgnoreRule[] {
+    const cached = this.effectiveRules.get(directory);
+    if (cached) {
+      return cached;
+    }
+    const top = this.gitRoot ?? this.rootPath;
+    const parent = dirname(directory);
+    const inherited =
+      directory === top || parent === directory
+        ? this.excludeRules
+        : this.rulesIn(parent);
+
+    const own = IGNORE_FILES.flatMap((name) =>
+      this.readRules(join(directory, name), directory),
+    );
+    if (own.length > 0) {
+      this.ownRules.set(directory, own);
+    }
+    const rules = own.length > 0 ? [...inherited, ...own] : inherited;
+    this.effectiveRules.set(directory, rules);
+    return rules;
+  }
+
+  private readRules(file: string, base: string): IgnoreRule[] {
+    let text: string;
+    try {
+      text = readFileSync(file, "utf-8");
+    } catch {
+      return [];
+    }
+    const source = relative(this.rootPath, file).split(sep).join("/");
+    return text
+      .split(/\r?\n/)
+      .flatMap(
+        (line, index) =>
+          parseIgnorePattern(line, base, source, index + 1) ?? [],
+      );
+  }
+}
+
+/** Whether an ignore file changed, so rules read before are stale */
+export function isIgnoreFile(relativePath: string): boolean {
+  const normalized = relativePath.split(sep).join("/");
+  const name = normalized.slice(normalized.lastIndexOf("/") + 1);
+  return (
+    IGNORE_FILES.includes(name) || normalized.endsWith(".git/info/exclude")
+  );
+}
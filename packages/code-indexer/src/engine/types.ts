@@ -15,6 +15,8 @@ export interface IndexedSymbol {
   deprecated?: boolean;
   detail?: string;
   children?: IndexedSymbol[];
+  /** Workspace root of the index that holds the symbol */
+  root?: string;
 }
 
 /**
@@ -49,6 +51,33 @@ export interface IndexStats {
   indexingTime: number;
   lastUpdated: Date;
   lastGitHash?: string;
+  /** When indexFiles last completed */
+  lastIndexedAt?: Date;
+  /** When updateIncremental last completed */
+  lastIncrementalAt?: Date;
+  /** Throughput of the latest indexFiles run */
+  lastRun?: IndexingRunStats;
+}
+
+/**
+ * Throughput of one indexing run
+ */
+export interface IndexingRunStats {
+  files: number;
+  /** Files parsed by the symbol provider */
+  indexed: number;
+  /** Files whose content was unchanged or whose symbols were cached */
+  reused: number;
+  /** Files the provider returned no symbols for */
+  empty: number;
+  failed: number;
+  /** Wall-clock milliseconds */
+  duration: number;
+  filesPerSecond: number;
+  workers: number;
+  /** Times a worker waited for memory below the limit */
+  throttled: number;
+  peakHeapMB: number;
 }
 
 /**
@@ -63,13 +92,73 @@ export interface SymbolProvider {
  */
 export type { FileSystemApi as FileSystem } from "@internal/types";
 
+/**
+ * Per-file metadata stored by persistent caches
+ */
+export interface CachedFileMetadata {
+  lastModified: number;
+  contentHash?: string;
+}
+
+/**
+ * A reference to a symbol persisted in the cache
+ */
+export interface CachedReference {
+  /** Key the references are stored under */
+  namePath: string;
+  /** Relative to the cache root */
+  filePath: string;
+  startLine: number;
+  startCharacter: number;
+  endLine: number;
+  endCharacter: number;
+}
+
+/**
+ * Embeddings of the symbols of one file persisted in the cache
+ */
+export interface CachedEmbeddings {
+  /** Hash of the file content the symbols were read from */
+  contentHash: string;
+  /** Symbol names, in the order of `docs` and `vectors` */
+  names: string[];
+  docs: (string | null)[];
+  vectors: Float32Array[];
+}
+
 /**
  * Cache interface
  */
 export interface SymbolCache {
   get(filePath: string): Promise<IndexedSymbol[] | null>;
-  set(filePath: string, symbols: IndexedSymbol[]): Promise<void>;
+  set(
+    filePath: string,
+    symbols: IndexedSymbol[],
+    metadata?: { contentHash?: string },
+  ): Promise<void>;
   clear(): Promise<void>;
+
+  // Optional persistence hooks (implemented by SQLiteCache)
+  getAllFiles?(): Promise<string[]>;
+  getFileInfo?(filePath: string): Promise<CachedFileMetadata | null>;
+  remove?(filePath: string): Promise<void>;
+  getMetadata?(key: string): Promise<string | null>;
+  setMetadata?(key: string, value: string): Promise<void>;
+  setReferences?(
+    namePath: string,
+    references: CachedReference[],
+  ): Promise<void>;
+  getReferences?(namePath: string): Promise<CachedReference[]>;
+  getEmbeddings?(
+    filePath: string,
+    embedderId: string,
+    contentHash: string,
+  ): Promise<CachedEmbeddings | null>;
+  setEmbeddings?(
+    filePath: string,
+    embedderId: string,
+    embeddings: CachedEmbeddings,
+  ): Promise<void>;
 }
 
 /**
@@ -85,4 +174,4 @@ export type IndexEvent =
   | { type: "fileRemoved"; uri: string }
   | { type: "indexError"; uri: string; error: Error }
   | { type: "indexingStarted"; fileCount: number }
-  | { type: "indexingCompleted"; duration: number };
+  | { type: "indexingCompleted"; duration: number; stats?: IndexingRunStats };
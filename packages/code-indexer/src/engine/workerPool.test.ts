@@ -0,0 +1,80 @@
+import { describe, it, expect } from "vitest";
+import { runWorkerPool } from "./workerPool.ts";
+
+describe("runWorkerPool", () => {
+  it("should keep workers busy while another waits on a slow item", async () => {
+    let releaseSlow!: () => void;
+    const slow = new Promise<void>((resolve) => (releaseSlow = resolve));
+    const finished: string[] = [];
+    let active = 0;
+    let peak = 0;
+
+    const stats = await runWorkerPool(
+      ["slow", "a", "b", "c", "d"],
+      async (item) => {
+        active++;
+        peak = Math.max(peak, active);
+        await (item === "slow" ? slow : Promise.resolve());
+        finished.push(item);
+        active--;
+        if (item === "d") {
+          releaseSlow();
+        }
+      },
+      { workers: 2 },
+    );
+
+    expect(finished).toEqual(["a", "b", "c", "d", "slow"]);
+    expect(peak).toBe(2);
+    expect(stats.workers).toBe(2);
+  });
+
+  it("should start no new task while the heap is above the limit", async () => {
+    let active = 0;
+    let peak = 0;
+    let done = 0;
+
+    const stats = await runWorkerPool(
+      [1, 2, 3, 4, 5, 6],
+      async () => {
+        active++;
+        peak = Math.max(peak, active);
+        await new Promise((resolve) => setTimeout(resolve, 1));
+        active--;
+        done++;
+      },
+      { workers: 3, maxHeapBytes: 100, heapUsed: () => 200 },
+    );
+
+    expect(done).toBe(6);
+    expect(peak).toBe(1);
+    expect(stats.throttled).toBeGreaterThan(0);
+    expect(stats.peakHeapBytes).toBe(200);
+  });
+
+  it("should stop after the first failure and throw it", async () => {
+    const started: number[] = [];
+
+    await expect(
+      runWorkerPool(
+        [1, 2, 3, 4],
+        async (item) => {
+          started.push(item);
+          if (item === 2) {
+            throw new Error("boom");
+          }
+        },
+        { workers: 1 },
+      ),
+    ).rejects.toThrow("boom");
+    expect(started).toEqual([1, 2]);
+  });
+
+  it("should start no more workers than there are items", async () => {
+    const stats = await runWorkerPool([1], async () => {}, { workers: 8 });
+    const empty = await runWorkerPool([], async () => {}, { workers: 8 });
+
+    expect(stats.workers).toBe(1);
+    expect(empty.workers).toBe(0);
+  });
+});
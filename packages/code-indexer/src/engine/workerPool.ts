@@ -0,0 +1,84 @@
+/**
+ * Bounded pool of async workers for indexing
+ *
+ * Runs a task for every item with at most `workers` tasks in flight. Each
+ * worker pulls the next item as soon as its task settles, so one slow file
+ * no longer holds up a whole batch. With maxHeapBytes, a worker that finds
+ * the heap above the limit waits for the other workers' tasks to finish
+ * before it starts another one; a worker left on its own carries on, so
+ * the pool always makes progress.
+ */
+
+export interface WorkerPoolOptions {
+  workers: number;
+  /** No new task starts while the heap is above this many bytes */
+  maxHeapBytes?: number;
+  /** Heap usage in bytes (default: process.memoryUsage().heapUsed) */
+  heapUsed?: () => number;
+}
+
+export interface WorkerPoolStats {
+  /** Workers started; never more than there are items */
+  workers: number;
+  /** Times a worker waited because the heap was above the limit */
+  throttled: number;
+  peakHeapBytes: number;
+}
+
+/**
+ * Run the task for every item; the first task that throws stops the pool
+ * from starting new ones, and its error is thrown once the others settled
+ */
+export async function runWorkerPool<T>(
+  items: readonly T[],
+  task: (item: T) => Promise<void>,
+  options: WorkerPoolOptions,
+): Promise<WorkerPoolStats> {
+  const heapUsed = options.heapUsed ?? (() => process.memoryUsage().heapUsed);
+  const stats: WorkerPoolStats = {
+    workers: Math.min(Math.max(1, Math.floor(options.workers)), items.length),
+    throttled: 0,
+    peakHeapBytes: heapUsed(),
+  };
+  // Settled-safe promises of the tasks in flight
+  const active = new Set<Promise<void>>();
+  let next = 0;
+  let failure: { error: unknown } | undefined;
+
+  const overLimit = (): boolean => {
+    const used = heapUsed();
+    stats.peakHeapBytes = Math.max(stats.peakHeapBytes, used);
+    return options.maxHeapBytes !== undefined && used > options.maxHeapBytes;
+  };
+
+  const worker = async () => {
+    while (next < items.length && !failure) {
+      if (overLimit() && active.size > 0) {
+        stats.throttled++;
+        global.gc?.();
+        await Promise.race(active);
+        continue;
+      }
+
+      const running = task(items[next++]);
+      const settled = running.then(
+        () => {},
+        () => {},
+      );
+      active.add(settled);
+      try {
+        await running;
+      } catch (error) {
+        failure ??= { error };
+      } finally {
+        active.delete(settled);
+      }
+    }
+  };
+
+  await Promise.all(Array.from({ length: stats.workers }, worker));
+  if (failure) {
+    throw failure.error;
+  }
+  return stats;
+}
@@ -10,9 +10,12 @@ export type {
   FileSymbols,
   SymbolQuery,
   IndexStats,
+  IndexingRunStats,
   SymbolProvider,
   FileSystem,
   SymbolCache,
+  CachedReference,
+  CachedEmbeddings,
   IndexEvent,
 } from "./engine/types.ts";
 
@@ -27,9 +30,53 @@ export {
 // Filesystem adapter
 export { NodeFileSystem } from "./engine/NodeFileSystem.ts";
 
+// File system watcher
+export {
+  IndexWatcher,
+  type IndexWatcherOptions,
+  type IndexWatcherEvent,
+} from "./engine/IndexWatcher.ts";
+
+// Worker pool used for indexing
+export {
+  runWorkerPool,
+  type WorkerPoolOptions,
+  type WorkerPoolStats,
+} from "./engine/workerPool.ts";
+
+// Ignore rules (.gitignore, .git/info/exclude, .lsmcpignore)
+export {
+  IgnoreRules,
+  LSMCP_IGNORE_FILE,
+  parseIgnorePattern,
+  type IgnoreRule,
+  type IgnoreMatch,
+  type IgnoreSource,
+} from "./engine/ignoreRules.ts";
+
+// Semantic search over symbol embeddings
+export {
+  createHttpEmbedder,
+  createLocalEmbedder,
+  cosineSimilarity,
+  embedText,
+  tokenizeText,
+  type Embedder,
+  type HttpEmbedderOptions,
+} from "./engine/embeddings.ts";
+export {
+  SemanticIndex,
+  symbolEmbeddingText,
+  type EmbeddingStore,
+  type SemanticIndexOptions,
+  type SemanticMatch,
+} from "./engine/SemanticIndex.ts";
+export { extractDocComment } from "./engine/docComments.ts";
+
 // Cache implementations
 export { MemoryCache } from "./cache/MemoryCache.ts";
 export { SQLiteCache } from "./cache/SQLiteCache.ts";
+export { getFileContentHash } from "./engine/contentHash.ts";
 
 // Cache integration helpers
 export {
@@ -48,9 +95,22 @@ export {
   indexFiles,
   querySymbols,
   getIndexStats,
+  getIndexedFiles,
+  getSymbolCounts,
+  getIndexErrors,
+  findStaleFiles,
+  semanticSearchSymbols,
   updateIndexIncremental,
+  startIndexWatcher,
+  stopIndexWatcher,
+  onIndexChange,
+  findReferencesCached,
+} from "./mcp/IndexerAdapter.ts";
+export type {
+  IndexerDeps,
+  IndexProgressCallback,
+  IndexChange,
 } from "./mcp/IndexerAdapter.ts";
-export type { IndexerDeps } from "./mcp/IndexerAdapter.ts";
 
 // Engine helpers and config
 // Symbol kind utilities are now re-exported from @internal/types
@@ -61,6 +121,11 @@ export {
   parseSymbolKind,
 } from "@internal/types";
 export { getAdapterDefaultPattern } from "./engine/adapterDefaults.ts";
+export {
+  fuzzyScore,
+  isExportedSymbol,
+  symbolRelevance,
+} from "./engine/fuzzyMatch.ts";
 export {
   shouldExcludeSymbol,
   type IndexConfig,
@@ -69,6 +134,11 @@ export { loadIndexConfig } from "./config/configLoader.ts";
 
 // Utilities
 export { markFileModified } from "./utils/autoIndex.ts";
+export {
+  getChangedFilesBetweenAsync,
+  getFileAtRevisionAsync,
+  type GitError,
+} from "./utils/gitUtils.ts";
 
 /**
  * Indexer facade (stateful; used by some tools and reports)
@@ -6,14 +6,28 @@ import { SymbolIndex } from "../engine/SymbolIndex.ts";
 import { NodeFileSystem } from "../engine/NodeFileSystem.ts";
 import { SQLiteCache } from "../cache/SQLiteCache.ts";
 import { MemoryCache } from "../cache/MemoryCache.ts";
+import {
+  IndexWatcher,
+  type IndexWatcherOptions,
+} from "../engine/IndexWatcher.ts";
+import { SemanticIndex, type SemanticMatch } from "../engine/SemanticIndex.ts";
+import type { Embedder } from "../engine/embeddings.ts";
 import { createLSPSymbolProvider } from "@internal/lsp-client";
+import type { SymbolKind } from "vscode-languageserver-types";
 import { fileURLToPath } from "url";
 import { readFile } from "fs/promises";
-import type { IndexedSymbol, SymbolQuery } from "../engine/types.ts";
-import {
-  debugLogWithPrefix,
-  errorLog,
-} from "../../../../src/utils/debugLog.ts";
+import type {
+  CachedReference,
+  IndexEvent,
+  IndexedSymbol,
+  IndexingRunStats,
+  IndexStats,
+  SymbolQuery,
+} from "../engine/types.ts";
+import { errorLog } from "../../../../src/utils/debugLog.ts";
+import { debugLogWithPrefix } from "../utils/logging.ts";
+import { metrics } from "../../../../src/utils/metrics.ts";
+import { withSpan } from "../../../../src/utils/tracing.ts";
 
 /**
  * Dependencies for indexer facade.
@@ -24,11 +38,89 @@ export type IndexerDeps = {
   fileSystem?: any;
   fs?: any; // alias for compatibility
   symbolProvider?: any;
+  /** Keep the index in memory instead of .lsmcp/cache */
+  readOnly?: boolean;
 };
 
 // Global index instances by root path
 const indexInstances = new Map<string, SymbolIndex>();
 
+metrics.gauge("lsmcp_index_files", "Files in the symbol index", () =>
+  [...indexInstances].map(([root, index]) => ({
+    labels: { root },
+    value: index.getStats().totalFiles,
+  })),
+);
+metrics.gauge("lsmcp_index_symbols", "Symbols in the symbol index", () =>
+  [...indexInstances].map(([root, index]) => ({
+    labels: { root },
+    value: index.getStats().totalSymbols,
+  })),
+);
+metrics.gauge(
+  "lsmcp_index_files_per_second",
+  "Throughput of the latest indexing run",
+  () =>
+    [...indexInstances].flatMap(([root, index]) => {
+      const run = index.getStats().lastRun;
+      return run ? [{ labels: { root }, value: run.filesPerSecond }] : [];
+    }),
+);
+
+// File system watchers by root path
+const watcherInstances = new Map<string, IndexWatcher>();
+
+// Symbol embeddings by root path, built on the first semantic search
+const semanticIndexes = new Map<string, SemanticIndex>();
+
+/**
+ * A change to the symbols of an index: one file, or the whole index when
+ * `uri` is missing
+ */
+export interface IndexChange {
+  root: string;
+  uri?: string;
+}
+
+const changeListeners = new Set<(change: IndexChange) => void>();
+
+// Symbol changes seen per root path
+const changeCounts = new Map<string, number>();
+
+function emitIndexChange(change: IndexChange): void {
+  changeCounts.set(change.root, (changeCounts.get(change.root) ?? 0) + 1);
+  semanticIndexes.get(change.root)?.invalidate(change.uri);
+  for (const listener of changeListeners) {
+    listener(change);
+  }
+}
+
+/**
+ * Listen for symbol changes in every index, current and future.
+ * Returns a function that stops listening.
+ */
+export function onIndexChange(
+  listener: (change: IndexChange) => void,
+): () => void {
+  changeListeners.add(listener);
+  return () => changeListeners.delete(listener);
+}
+
+function trackIndexChanges(rootPath: string, index: SymbolIndex): void {
+  index.on("fileIndexed", (event: Extract<IndexEvent, { type: "fileIndexed" }>) => {
+    // Cache hits leave the symbols as they were
+    if (!event.fromCache) {
+      emitIndexChange({ root: rootPath, uri: event.uri });
+    }
+  });
+  index.on(
+    "fileRemoved",
+    (event: Extract<IndexEvent, { type: "fileRemoved" }>) =>
+      emitIndexChange({ root: rootPath, uri: event.uri }),
+  );
+  index.on("cleared", () => emitIndexChange({ root: rootPath }));
+}
+
 /**
  * Get or create a symbol index for a root path
  */
@@ -66,9 +158,10 @@ export function getOrCreateIndex(
     fileSystem = new NodeFileSystem();
   }
 
-  // Use in-memory cache during vitest to avoid FS permissions under /test roots
+  // Use in-memory cache during vitest to avoid FS permissions under /test roots,
+  // and in read-only mode
   const cache =
-    process.env.VITEST === "true" || process.env.VITEST
+    process.env.VITEST === "true" || process.env.VITEST || context?.readOnly
       ? new MemoryCache()
       : new SQLiteCache(rootPath);
 
@@ -121,6 +214,7 @@ export function getOrCreateIndex(
 
   // Store instance
   indexInstances.set(rootPath, index);
+  trackIndexChanges(rootPath, index);
 
   return index;
 }
@@ -129,6 +223,8 @@ export function getOrCreateIndex(
  * Clear index for a root path
  */
 export function clearIndex(rootPath: string): void {
+  semanticIndexes.delete(rootPath);
+  stopIndexWatcher(rootPath);
   const index = indexInstances.get(rootPath);
   if (index) {
     index.clear();
@@ -141,6 +237,8 @@ export function clearIndex(rootPath: string): void {
  * Force clear index including cache
  */
 export async function forceClearIndex(rootPath: string): Promise<void> {
+  semanticIndexes.delete(rootPath);
+  stopIndexWatcher(rootPath);
   const index = indexInstances.get(rootPath);
   if (index) {
     await index.forceClear();
@@ -149,19 +247,31 @@ export async function forceClearIndex(rootPath: string): Promise<void> {
   }
 }
 
+export type IndexProgressCallback = (progress: {
+  current: number;
+  total: number;
+  file?: string;
+}) => void;
+
 /**
  * Index files using the new implementation
  */
 export async function indexFiles(
   rootPath: string,
   filePaths: string[],
-  options?: { concurrency?: number; context?: IndexerDeps },
+  options?: {
+    concurrency?: number;
+    context?: IndexerDeps;
+    onProgress?: IndexProgressCallback;
+  },
 ): Promise<{
   success: boolean;
   totalFiles: number;
   totalSymbols: number;
   duration: number;
   errors: Array<{ file: string; error: string }>;
+  /** Throughput of this run */
+  run?: IndexingRunStats;
 }> {
   const index = getOrCreateIndex(rootPath, options?.context);
   if (!index) {
@@ -192,7 +302,14 @@ export async function indexFiles(
 
   try {
     // Index files
-    await index.indexFiles(filePaths, options?.concurrency);
+    const run = await withSpan(
+      "index indexFiles",
+      { "index.root": rootPath, "index.files": filePaths.length },
+      () =>
+        index.indexFiles(filePaths, options?.concurrency, {
+          onProgress: options?.onProgress,
+        }),
+    );
 
     // Get stats
     const stats = index.getStats();
@@ -203,6 +320,7 @@ export async function indexFiles(
       totalSymbols: stats.totalSymbols,
       duration: Date.now() - startTime,
       errors,
+      run,
     };
   } finally {
     index.off("indexError", errorHandler);
@@ -221,13 +339,21 @@ export function querySymbols(
     return [];
   }
 
-  return index.querySymbols(query);
+  return withSpan(
+    "index querySymbols",
+    { "index.root": rootPath, "index.query": query.name },
+    (span) => {
+      const symbols = index.querySymbols(query);
+      span?.setAttribute("index.results", symbols.length);
+      return symbols;
+    },
+  );
 }
 
 /**
  * Get index statistics
  */
-export function getIndexStats(rootPath: string) {
+export function getIndexStats(rootPath: string): IndexStats {
   const index = indexInstances.get(rootPath);
   if (!index) {
     return {
@@ -241,12 +367,97 @@ export function getIndexStats(rootPath: string) {
   return index.getStats();
 }
 
+/**
+ * Get files currently in the index (relative to root)
+ */
+export function getIndexedFiles(rootPath: string): string[] {
+  return indexInstances.get(rootPath)?.getIndexedFiles() ?? [];
+}
+
+/**
+ * Symbol counts per kind for each indexed file (relative to root)
+ */
+export function getSymbolCounts(
+  rootPath: string,
+): Array<{ file: string; byKind: Map<SymbolKind, number> }> {
+  return indexInstances.get(rootPath)?.getSymbolCounts() ?? [];
+}
+
+/**
+ * Files whose latest indexing failed, with the error
+ */
+export function getIndexErrors(
+  rootPath: string,
+): Array<{ file: string; error: string }> {
+  return indexInstances.get(rootPath)?.getIndexErrors() ?? [];
+}
+
+/**
+ * Indexed files that changed or were deleted since they were indexed
+ */
+export async function findStaleFiles(
+  rootPath: string,
+): Promise<{ modified: string[]; deleted: string[] }> {
+  const index = indexInstances.get(rootPath);
+  if (!index) {
+    return { modified: [], deleted: [] };
+  }
+  return index.findStaleFiles();
+}
+
+/**
+ * Symbols of the index most similar in meaning to a query, by embeddings
+ * of their names and doc comments. Files are embedded on the first search
+ * after they were indexed, unless the index cache holds vectors of the
+ * same embedder for their current content; switching to an embedder with
+ * another id embeds everything again.
+ */
+export async function semanticSearchSymbols(
+  rootPath: string,
+  query: string,
+  options: {
+    embedder: Embedder;
+    limit: number;
+    filter?: (symbol: IndexedSymbol) => boolean;
+  },
+): Promise<SemanticMatch[]> {
+  const index = indexInstances.get(rootPath);
+  if (!index) {
+    return [];
+  }
+
+  const existing = semanticIndexes.get(rootPath);
+  const semantic =
+    existing?.embedderId === options.embedder.id
+      ? existing
+      : new SemanticIndex(options.embedder.id, { store: index });
+  semanticIndexes.set(rootPath, semantic);
+
+  return withSpan(
+    "index semanticSearch",
+    { "index.root": rootPath, "index.query": query },
+    async (span) => {
+      await semantic.update(
+        index.querySymbols({ includeChildren: true }),
+        options.embedder,
+      );
+      const matches = semantic.search(
+        await options.embedder.embedQuery(query),
+        { limit: options.limit, filter: options.filter },
+      );
+      span?.setAttribute("index.results", matches.length);
+      return matches;
+    },
+  );
+}
+
 /**
  * Update index incrementally
  */
 export async function updateIndexIncremental(
   rootPath: string,
   context?: IndexerDeps,
+  options?: { onProgress?: IndexProgressCallback },
 ): Promise<{
   success: boolean;
   updated: string[];
@@ -266,7 +477,9 @@ export async function updateIndexIncremental(
   }
 
   try {
-    const result = await index.updateIncremental();
+    const result = await index.updateIncremental({
+      onProgress: options?.onProgress,
+    });
     return {
       success: true,
       ...result,
@@ -280,3 +493,74 @@ export async function updateIndexIncremental(
     };
   }
 }
+
+/**
+ * Start watching the file system so the index follows on-disk changes
+ */
+export function startIndexWatcher(
+  rootPath: string,
+  context?: IndexerDeps,
+  options?: IndexWatcherOptions,
+): IndexWatcher | null {
+  const existing = watcherInstances.get(rootPath);
+  if (existing) {
+    return existing;
+  }
+
+  const index = getOrCreateIndex(rootPath, context);
+  if (!index) {
+    return null;
+  }
+
+  const watcher = new IndexWatcher(rootPath, index, options);
+  if (!watcher.start()) {
+    return null;
+  }
+
+  watcherInstances.set(rootPath, watcher);
+  return watcher;
+}
+
+/**
+ * Stop the file system watcher for a root path
+ */
+export function stopIndexWatcher(rootPath: string): void {
+  const watcher = watcherInstances.get(rootPath);
+  if (watcher) {
+    watcher.stop();
+    watcherInstances.delete(rootPath);
+  }
+}
+
+/**
+ * Find references through the index cache: a lookup stored under the same
+ * key is reused until a file is indexed again or removed. The cache is only
+ * trusted while a file system watcher keeps the index in sync with the
+ * disk; otherwise, and for lookups the index changed during, `find` runs
+ * without storing its result. Lookups that found nothing are not cached.
+ */
+export async function findReferencesCached(
+  rootPath: string,
+  key: string,
+  find: () => Promise<CachedReference[]>,
+): Promise<CachedReference[]> {
+  const index = indexInstances.get(rootPath);
+  const watcher = watcherInstances.get(rootPath);
+  const current = () =>
+    !!watcher?.isSettled() && (index?.getStats().totalFiles ?? 0) > 0;
+  if (!index || !current()) {
+    return find();
+  }
+
+  const cached = await index.getCachedReferences(key);
+  if (cached.length > 0) {
+    return cached;
+  }
+
+  const changes = changeCounts.get(rootPath);
+  const references = await find();
+  if (current() && changeCounts.get(rootPath) === changes) {
+    await index.cacheReferences(key, references);
+  }
+  return references;
+}
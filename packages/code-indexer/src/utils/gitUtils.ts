@@ -7,7 +7,7 @@ import { spawn } from "child_process";
 import { existsSync } from "fs";
 import { join, relative } from "path";
 import { Result, ok, err } from "neverthrow";
-import { debugLogWithPrefix } from "../../../../src/utils/debugLog.ts";
+import { debugLogWithPrefix } from "./logging.ts";
 
 // Error types
 export type GitError =
@@ -184,19 +184,28 @@ export async function getModifiedFilesAsync(
   }
 
   try {
-    // Get all modified files in parallel
+    // Get all modified files in parallel. Renames are reported as a
+    // deletion plus an addition so that the old path leaves the index.
     const [committedChanges, stagedChanges, unstagedChanges] =
       await Promise.all([
         // Files changed between commits
         executeGitCommand(
           "git",
-          ["diff", "--name-only", sinceHash, "HEAD"],
+          ["diff", "--name-only", "--no-renames", sinceHash, "HEAD"],
           rootPath,
         ),
         // Staged changes
-        executeGitCommand("git", ["diff", "--name-only", "--cached"], rootPath),
+        executeGitCommand(
+          "git",
+          ["diff", "--name-only", "--no-renames", "--cached"],
+          rootPath,
+        ),
         // Unstaged changes
-        executeGitCommand("git", ["diff", "--name-only"], rootPath),
+        executeGitCommand(
+          "git",
+          ["diff", "--name-only", "--no-renames"],
+          rootPath,
+        ),
       ]);
 
     // Combine all changes and deduplicate
@@ -293,6 +302,191 @@ export async function getUntrackedFilesAsync(
   }
 }
 
+/**
+ * Get list of tracked files in the working tree (async)
+ */
+export async function getTrackedFilesAsync(
+  rootPath: string,
+): Promise<Result<string[], GitError>> {
+  try {
+    const output = await executeGitCommand("git", ["ls-files"], rootPath);
+
+    const files = output
+      .split("\n")
+      .filter((file) => file.length > 0)
+      .map((file) => file.trim());
+
+    debugLogWithPrefix("gitUtils", `Found ${files.length} tracked files`);
+    return ok(files);
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+    debugLogWithPrefix("gitUtils", `getTrackedFiles error: ${message}`);
+
+    if (message.includes("timed out")) {
+      return err({
+        type: "TIMEOUT",
+        message: `Git command timed out`,
+        timeout: 30000,
+      });
+    }
+
+    return err({
+      type: "COMMAND_FAILED",
+      message,
+      command: "git ls-files",
+    });
+  }
+}
+
+/**
+ * Check a revision (commit, branch, tag, HEAD~2) before passing it to git
+ */
+async function checkRevision(
+  rootPath: string,
+  revision: string,
+): Promise<Result<void, GitError>> {
+  // Never let a revision be read as an option
+  if (!revision || revision.startsWith("-")) {
+    return err({
+      type: "INVALID_HASH",
+      message: `Invalid revision: ${revision}`,
+    });
+  }
+  if (!(await checkHashExists(rootPath, revision))) {
+    return err({
+      type: "HASH_NOT_FOUND",
+      message: `Revision not found: ${revision}`,
+      hash: revision,
+    });
+  }
+  return ok(undefined);
+}
+
+/**
+ * Get list of files that differ between two revisions, or between a
+ * revision and the working tree (including untracked files) when `to` is
+ * omitted (async). Paths are relative to rootPath; files outside of it are
+ * left out.
+ */
+export async function getChangedFilesBetweenAsync(
+  rootPath: string,
+  from: string,
+  to?: string,
+): Promise<Result<string[], GitError>> {
+  for (const revision of to === undefined ? [from] : [from, to]) {
+    const checked = await checkRevision(rootPath, revision);
+    if (checked.isErr()) {
+      return err(checked.error);
+    }
+  }
+
+  try {
+    const output = await executeGitCommand(
+      "git",
+      [
+        "diff",
+        "--name-only",
+        "--no-renames",
+        "--relative",
+        from,
+        ...(to === undefined ? [] : [to]),
+        "--",
+      ],
+      rootPath,
+    );
+    const files = new Set(
+      output
+        .split("\n")
+        .filter((file) => file.length > 0)
+        .map((file) => file.trim()),
+    );
+
+    if (to === undefined) {
+      const untracked = await getUntrackedFilesAsync(rootPath);
+      if (untracked.isErr()) {
+        return err(untracked.error);
+      }
+      untracked.value.forEach((file) => files.add(file));
+    }
+
+    debugLogWithPrefix(
+      "gitUtils",
+      `Found ${files.size} files changed between ${from} and ${to ?? "working tree"}`,
+    );
+    return ok(Array.from(files));
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+    debugLogWithPrefix("gitUtils", `getChangedFilesBetween error: ${message}`);
+
+    if (message.includes("timed out")) {
+      return err({
+        type: "TIMEOUT",
+        message: `Git command timed out`,
+        timeout: 30000,
+      });
+    }
+
+    return err({
+      type: "COMMAND_FAILED",
+      message,
+      command: "git diff",
+    });
+  }
+}
+
+/**
+ * Get the content of a file (relative to rootPath) at a revision, or null
+ * if the file does not exist there (async)
+ */
+export async function getFileAtRevisionAsync(
+  rootPath: string,
+  revision: string,
+  filePath: string,
+): Promise<Result<string | null, GitError>> {
+  if (revision.startsWith("-")) {
+    return err({
+      type: "INVALID_HASH",
+      message: `Invalid revision: ${revision}`,
+    });
+  }
+
+  try {
+    // "./" makes the path relative to rootPath instead of the repository
+    const output = await executeGitCommand(
+      "git",
+      ["show", `${revision}:./${filePath}`],
+      rootPath,
+    );
+    return ok(output);
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+
+    // Added or deleted between the revisions
+    if (
+      message.includes("does not exist in") ||
+      message.includes("exists on disk, but not in")
+    ) {
+      return ok(null);
+    }
+
+    debugLogWithPrefix("gitUtils", `getFileAtRevision error: ${message}`);
+
+    if (message.includes("timed out")) {
+      return err({
+        type: "TIMEOUT",
+        message: `Git command timed out`,
+        timeout: 30000,
+      });
+    }
+
+    return err({
+      type: "COMMAND_FAILED",
+      message,
+      command: "git show",
+    });
+  }
+}
+
 /**
  * Get file's last commit hash (async)
  */
@@ -1,7 +1,10 @@
 import { describe, it, expect, vi, beforeEach } from "vitest";
 import {
+  getChangedFilesBetweenAsync,
+  getFileAtRevisionAsync,
   getGitHashAsync,
   getModifiedFilesAsync,
+  getTrackedFilesAsync,
   getUntrackedFilesAsync,
 } from "./gitUtils.ts";
 import { spawn } from "child_process";
@@ -20,6 +23,25 @@ class MockProcess extends EventEmitter {
   }
 }
 
+/**
+ * Answer each spawned git command with the next output in turn
+ */
+function mockGitOutputs(
+  outputs: Array<{ stdout?: string; stderr?: string; code?: number }>,
+) {
+  const queue = [...outputs];
+  vi.mocked(spawn).mockImplementation(() => {
+    const proc = new MockProcess();
+    const { stdout = "", stderr = "", code = 0 } = queue.shift() ?? {};
+    setTimeout(() => {
+      if (stdout) proc.stdout.emit("data", Buffer.from(stdout));
+      if (stderr) proc.stderr.emit("data", Buffer.from(stderr));
+      proc.emit("close", code);
+    }, 0);
+    return proc as any;
+  });
+}
+
 describe("gitUtils (async)", () => {
   beforeEach(() => {
     vi.clearAllMocks();
@@ -162,6 +184,31 @@ describe("gitUtils (async)", () => {
       expect(result._unsafeUnwrapErr().type).toBe("INVALID_HASH");
     });
 
+    it("should report both paths of a renamed file", async () => {
+      const procs = Array.from({ length: 4 }, () => new MockProcess());
+      let callCount = 0;
+      vi.mocked(spawn).mockImplementation(() => procs[callCount++] as any);
+
+      const resultPromise = getModifiedFilesAsync("/test/path", "abc123def");
+
+      setTimeout(() => {
+        procs[0].emit("close", 0);
+      }, 10);
+      setTimeout(() => {
+        procs[1].stdout.emit("data", Buffer.from("src/old.ts\nsrc/new.ts\n"));
+        for (const proc of procs.slice(1)) {
+          proc.emit("close", 0);
+        }
+      }, 20);
+
+      const result = await resultPromise;
+
+      expect(result._unsafeUnwrap()).toEqual(["src/old.ts", "src/new.ts"]);
+      for (const call of vi.mocked(spawn).mock.calls.slice(1)) {
+        expect(call[1]).toContain("--no-renames");
+      }
+    });
+
     it("should detect non-existent hash", async () => {
       const mockProc = new MockProcess();
       vi.mocked(spawn).mockReturnValue(mockProc as any);
@@ -245,4 +292,107 @@ describe("gitUtils (async)", () => {
       expect(files.length).toBe(10000);
     });
   });
+
+  describe("getTrackedFilesAsync", () => {
+    it("should return list of tracked files", async () => {
+      const mockProc = new MockProcess();
+      vi.mocked(spawn).mockReturnValue(mockProc as any);
+
+      const resultPromise = getTrackedFilesAsync("/test/path");
+
+      setTimeout(() => {
+        mockProc.stdout.emit("data", Buffer.from("go.mod\ncmd/main.go\n"));
+        mockProc.emit("close", 0);
+      }, 10);
+
+      const result = await resultPromise;
+
+      expect(result._unsafeUnwrap()).toEqual(["go.mod", "cmd/main.go"]);
+      expect(vi.mocked(spawn).mock.calls[0][1]).toEqual(["ls-files"]);
+    });
+  });
+
+  describe("getChangedFilesBetweenAsync", () => {
+    it("should add untracked files when comparing with the working tree", async () => {
+      mockGitOutputs([
+        {},
+        { stdout: "pkg/client.go\npkg/old.go\n" },
+        { stdout: "pkg/new.go\n" },
+      ]);
+
+      const result = await getChangedFilesBetweenAsync("/test/path", "main");
+
+      expect(result._unsafeUnwrap()).toEqual([
+        "pkg/client.go",
+        "pkg/old.go",
+        "pkg/new.go",
+      ]);
+      expect(vi.mocked(spawn).mock.calls[1][1]).toEqual([
+        "diff",
+        "--name-only",
+        "--no-renames",
+        "--relative",
+        "main",
+        "--",
+      ]);
+    });
+
+    it("should reject unknown revisions and ones that look like options", async () => {
+      mockGitOutputs([
+        {},
+        { stderr: "fatal: Not a valid object name", code: 1 },
+      ]);
+
+      const missing = await getChangedFilesBetweenAsync(
+        "/test/path",
+        "main",
+        "no-such-branch",
+      );
+      const option = await getChangedFilesBetweenAsync(
+        "/test/path",
+        "--output=x",
+      );
+
+      expect(missing._unsafeUnwrapErr()).toMatchObject({
+        type: "HASH_NOT_FOUND",
+        hash: "no-such-branch",
+      });
+      expect(option._unsafeUnwrapErr().type).toBe("INVALID_HASH");
+    });
+  });
+
+  describe("getFileAtRevisionAsync", () => {
+    it("should read the file relative to the root at the revision", async () => {
+      mockGitOutputs([{ stdout: "package pkg\n" }]);
+
+      const result = await getFileAtRevisionAsync(
+        "/test/path",
+        "main",
+        "pkg/client.go",
+      );
+
+      expect(result._unsafeUnwrap()).toBe("package pkg\n");
+      expect(vi.mocked(spawn).mock.calls[0][1]).toEqual([
+        "show",
+        "main:./pkg/client.go",
+      ]);
+    });
+
+    it("should return null for files missing at the revision", async () => {
+      mockGitOutputs([
+        {
+          stderr: "fatal: path 'pkg/new.go' does not exist in 'main'",
+          code: 128,
+        },
+      ]);
+
+      const result = await getFileAtRevisionAsync(
+        "/test/path",
+        "main",
+        "pkg/new.go",
+      );
+
+      expect(result._unsafeUnwrap()).toBeNull();
+    });
+  });
 });
@@ -0,0 +1,19 @@
+/**
+ * Indexer logging: the `indexer` scope of the structured logger
+ * Enable with `--log-level indexer=debug` (or LSMCP_DEBUG=1).
+ */
+
+import {
+  createLogger,
+  writeLog,
+} from "../../../../src/utils/structuredLog.ts";
+
+/**
+ * Debug record for an indexer component (e.g. "SymbolIndex")
+ */
+export function debugLogWithPrefix(prefix: string, ...args: unknown[]): void {
+  writeLog("debug", "indexer", prefix, args);
+}
+
+export const indexerLog = (component: string) =>
+  createLogger("indexer", component);
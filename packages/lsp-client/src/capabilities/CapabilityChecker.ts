@@ -179,19 +179,29 @@ export function createToolCapabilityMap(): Map<
 > {
   const map = new Map<string, (keyof ServerCapabilities)[]>();
 
-  // LSP tool requirements
-  map.set("get_hover", ["hoverProvider"]);
-  map.set("find_references", ["referencesProvider"]);
-  map.set("get_definitions", ["definitionProvider"]);
-  map.set("get_diagnostics", ["diagnosticProvider"]);
-  map.set("get_all_diagnostics", ["diagnosticProvider"]);
-  map.set("get_document_symbols", ["documentSymbolProvider"]);
-  map.set("get_completion", ["completionProvider"]);
-  map.set("get_signature_help", ["signatureHelpProvider"]);
-  map.set("format_document", ["documentFormattingProvider"]);
-  map.set("get_workspace_symbols", ["workspaceSymbolProvider"]);
-  map.set("get_code_actions", ["codeActionProvider"]);
-  map.set("rename_symbol", ["renameProvider"]);
+  // LSP tool requirements, keyed by the registered MCP tool names
+  map.set("lsp_get_hover", ["hoverProvider"]);
+  map.set("lsp_find_references", ["referencesProvider"]);
+  map.set("lsp_get_definitions", ["definitionProvider"]);
+  map.set("lsp_get_diagnostics", ["diagnosticProvider"]);
+  map.set("lsp_get_all_diagnostics", ["diagnosticProvider"]);
+  map.set("lsp_get_document_symbols", ["documentSymbolProvider"]);
+  map.set("lsp_get_completion", ["completionProvider"]);
+  map.set("lsp_get_signature_help", ["signatureHelpProvider"]);
+  map.set("lsp_get_inlay_hints", ["inlayHintProvider"]);
+  map.set("lsp_format_document", ["documentFormattingProvider"]);
+  map.set("lsp_format_range", ["documentRangeFormattingProvider"]);
+  map.set("lsp_get_workspace_symbols", ["workspaceSymbolProvider"]);
+  map.set("lsp_get_code_actions", ["codeActionProvider"]);
+  map.set("lsp_apply_code_action", ["codeActionProvider"]);
+  map.set("lsp_organize_imports", ["codeActionProvider"]);
+  map.set("lsp_rename_symbol", ["renameProvider"]);
+  map.set("lsp_get_incoming_calls", ["callHierarchyProvider"]);
+  map.set("lsp_get_outgoing_calls", ["callHierarchyProvider"]);
+  map.set("lsp_get_call_graph", ["callHierarchyProvider"]);
+  map.set("lsp_get_type_hierarchy", ["typeHierarchyProvider"]);
+  map.set("lsp_execute_command", ["executeCommandProvider"]);
+  map.set("lsp_move_symbol_to_file", ["documentSymbolProvider"]);
 
   // Some tools might work with either of multiple capabilities
   // (These need special handling)
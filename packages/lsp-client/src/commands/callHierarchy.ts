@@ -0,0 +1,154 @@
+import type {
+  CallHierarchyIncomingCall,
+  CallHierarchyItem,
+  CallHierarchyOutgoingCall,
+} from "../protocol/types/index.ts";
+import type {
+  CallHierarchyCallsParams,
+  IncomingCallsResult,
+  LSPCommand,
+  OutgoingCallsResult,
+  PrepareCallHierarchyResult,
+  TextDocumentPositionParams,
+} from "./types.ts";
+
+export function createPrepareCallHierarchyCommand(): LSPCommand<
+  TextDocumentPositionParams,
+  CallHierarchyItem[]
+> {
+  return {
+    method: "textDocument/prepareCallHierarchy",
+
+    buildParams(input: TextDocumentPositionParams) {
+      return {
+        textDocument: { uri: input.uri },
+        position: input.position,
+      };
+    },
+
+    processResponse(
+      response: PrepareCallHierarchyResult,
+    ): CallHierarchyItem[] {
+      return response ?? [];
+    },
+  };
+}
+
+export function createIncomingCallsCommand(): LSPCommand<
+  CallHierarchyCallsParams,
+  CallHierarchyIncomingCall[]
+> {
+  return {
+    method: "callHierarchy/incomingCalls",
+
+    buildParams(input: CallHierarchyCallsParams) {
+      return { item: input.item };
+    },
+
+    processResponse(
+      response: IncomingCallsResult,
+    ): CallHierarchyIncomingCall[] {
+      return response ?? [];
+    },
+  };
+}
+
+export function createOutgoingCallsCommand(): LSPCommand<
+  CallHierarchyCallsParams,
+  CallHierarchyOutgoingCall[]
+> {
+  return {
+    method: "callHierarchy/outgoingCalls",
+
+    buildParams(input: CallHierarchyCallsParams) {
+      return { item: input.item };
+    },
+
+    processResponse(
+      response: OutgoingCallsResult,
+    ): CallHierarchyOutgoingCall[] {
+      return response ?? [];
+    },
+  };
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  const item: CallHierarchyItem = {
+    name: "processUsers",
+    kind: 12,
+    uri: "file:///test.ts",
+    range: {
+      start: { line: 4, character: 0 },
+      end: { line: 10, character: 1 },
+    },
+    selectionRange: {
+      start: { line: 4, character: 9 },
+      end: { line: 4, character: 21 },
+    },
+  };
+
+  describe("PrepareCallHierarchyCommand", () => {
+    const command = createPrepareCallHierarchyCommand();
+
+    it("should build correct parameters", () => {
+      const params = command.buildParams({
+        uri: "file:///test.ts",
+        position: { line: 4, character: 9 },
+      });
+
+      expect(params).toEqual({
+        textDocument: { uri: "file:///test.ts" },
+        position: { line: 4, character: 9 },
+      });
+    });
+
+    it("should return empty array for null response", () => {
+      expect(command.processResponse(null)).toEqual([]);
+    });
+
+    it("should return items as is", () => {
+      expect(command.processResponse([item])).toEqual([item]);
+    });
+  });
+
+  describe("IncomingCallsCommand", () => {
+    const command = createIncomingCallsCommand();
+
+    it("should pass the item through", () => {
+      expect(command.buildParams({ item })).toEqual({ item });
+    });
+
+    it("should return empty array for null response", () => {
+      expect(command.processResponse(null)).toEqual([]);
+    });
+
+    it("should return calls as is", () => {
+      const calls: CallHierarchyIncomingCall[] = [
+        { from: item, fromRanges: [item.selectionRange] },
+      ];
+      expect(command.processResponse(calls)).toEqual(calls);
+    });
+  });
+
+  describe("OutgoingCallsCommand", () => {
+    const command = createOutgoingCallsCommand();
+
+    it("should pass the item through", () => {
+      expect(command.buildParams({ item })).toEqual({ item });
+    });
+
+    it("should return empty array for null response", () => {
+      expect(command.processResponse(null)).toEqual([]);
+    });
+
+    it("should return calls as is", () => {
+      const calls: CallHierarchyOutgoingCall[] = [
+        { to: item, fromRanges: [item.selectionRange] },
+      ];
+      expect(command.processResponse(calls)).toEqual(calls);
+    });
+  });
+}
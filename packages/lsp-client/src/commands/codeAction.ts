@@ -18,6 +18,7 @@ export function createCodeActionCommand(): LSPCommand<
         range: input.range,
         context: {
           diagnostics: input.diagnostics ?? [],
+          ...(input.only && input.only.length > 0 ? { only: input.only } : {}),
         },
       };
     },
@@ -28,6 +29,24 @@ export function createCodeActionCommand(): LSPCommand<
   };
 }
 
+export function createResolveCodeActionCommand(): LSPCommand<
+  CodeAction,
+  CodeAction
+> {
+  return {
+    method: "codeAction/resolve",
+
+    buildParams(input: CodeAction) {
+      return input;
+    },
+
+    processResponse(response: unknown): CodeAction {
+      // Servers may return null when nothing needs resolving
+      return (response as CodeAction) || ({} as CodeAction);
+    },
+  };
+}
+
 // In-source tests using Vitest
 if (import.meta.vitest) {
   const { describe, it, expect } = import.meta.vitest;
@@ -88,6 +107,24 @@ if (import.meta.vitest) {
           },
         });
       });
+
+      it("should include requested kinds in context.only", () => {
+        const range = {
+          start: { line: 1, character: 0 },
+          end: { line: 1, character: 0 },
+        };
+
+        const params = command.buildParams({
+          uri: "file:///test.ts",
+          range,
+          only: ["quickfix"],
+        });
+
+        expect(params.context).toEqual({
+          diagnostics: [],
+          only: ["quickfix"],
+        });
+      });
     });
 
     describe("processResponse", () => {
@@ -164,4 +201,26 @@ if (import.meta.vitest) {
       });
     });
   });
+
+  describe("ResolveCodeActionCommand", () => {
+    const command = createResolveCodeActionCommand();
+
+    it("should send the code action as params", () => {
+      const action: CodeAction = {
+        title: "Extract function",
+        kind: "refactor.extract",
+        data: { id: 1 },
+      };
+      expect(command.method).toBe("codeAction/resolve");
+      expect(command.buildParams(action)).toBe(action);
+    });
+
+    it("should return the resolved action", () => {
+      const resolved: CodeAction = {
+        title: "Extract function",
+        edit: { changes: {} },
+      };
+      expect(command.processResponse(resolved)).toEqual(resolved);
+    });
+  });
 }
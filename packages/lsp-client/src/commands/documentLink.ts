@@ -0,0 +1,57 @@
+import type { DocumentLink } from "@internal/types";
+import type {
+  DocumentLinkResult,
+  LSPCommand,
+  TextDocumentParams,
+} from "./types.ts";
+
+export function createDocumentLinkCommand(): LSPCommand<
+  TextDocumentParams,
+  DocumentLink[]
+> {
+  return {
+    method: "textDocument/documentLink",
+
+    buildParams(input: TextDocumentParams) {
+      return {
+        textDocument: { uri: input.uri },
+      };
+    },
+
+    processResponse(response: DocumentLinkResult): DocumentLink[] {
+      return response ?? [];
+    },
+  };
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  describe("DocumentLinkCommand", () => {
+    const command = createDocumentLinkCommand();
+
+    it("should build correct parameters", () => {
+      expect(command.buildParams({ uri: "file:///main.c" })).toEqual({
+        textDocument: { uri: "file:///main.c" },
+      });
+    });
+
+    it("should handle null response", () => {
+      expect(command.processResponse(null)).toEqual([]);
+    });
+
+    it("should pass through links", () => {
+      const links: DocumentLink[] = [
+        {
+          range: {
+            start: { line: 0, character: 10 },
+            end: { line: 0, character: 17 },
+          },
+          target: "file:///util.h",
+        },
+      ];
+      expect(command.processResponse(links)).toEqual(links);
+    });
+  });
+}
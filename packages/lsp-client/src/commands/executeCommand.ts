@@ -0,0 +1,64 @@
+import type { LSPCommand } from "./types.ts";
+
+export interface ExecuteCommandInput {
+  command: string;
+  arguments?: unknown[];
+}
+
+export function createExecuteCommandCommand(): LSPCommand<
+  ExecuteCommandInput,
+  unknown
+> {
+  return {
+    method: "workspace/executeCommand",
+
+    buildParams(input: ExecuteCommandInput) {
+      return {
+        command: input.command,
+        ...(input.arguments ? { arguments: input.arguments } : {}),
+      };
+    },
+
+    processResponse(response: unknown): unknown {
+      return response ?? null;
+    },
+  };
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  describe("ExecuteCommandCommand", () => {
+    const command = createExecuteCommandCommand();
+
+    describe("buildParams", () => {
+      it("should build parameters with arguments", () => {
+        const params = command.buildParams({
+          command: "gopls.tidy",
+          arguments: [{ URIs: ["file:///go.mod"] }],
+        });
+
+        expect(params).toEqual({
+          command: "gopls.tidy",
+          arguments: [{ URIs: ["file:///go.mod"] }],
+        });
+      });
+
+      it("should omit arguments when not provided", () => {
+        const params = command.buildParams({ command: "rust-analyzer.reload" });
+        expect(params).toEqual({ command: "rust-analyzer.reload" });
+      });
+    });
+
+    describe("processResponse", () => {
+      it("should normalize undefined to null", () => {
+        expect(command.processResponse(undefined)).toBeNull();
+      });
+
+      it("should pass through results", () => {
+        expect(command.processResponse({ ok: true })).toEqual({ ok: true });
+      });
+    });
+  });
+}
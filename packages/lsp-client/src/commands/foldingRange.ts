@@ -0,0 +1,49 @@
+import type { FoldingRange } from "@internal/types";
+import type {
+  FoldingRangeResult,
+  LSPCommand,
+  TextDocumentParams,
+} from "./types.ts";
+
+export function createFoldingRangeCommand(): LSPCommand<
+  TextDocumentParams,
+  FoldingRange[]
+> {
+  return {
+    method: "textDocument/foldingRange",
+
+    buildParams(input: TextDocumentParams) {
+      return {
+        textDocument: { uri: input.uri },
+      };
+    },
+
+    processResponse(response: FoldingRangeResult): FoldingRange[] {
+      return response ?? [];
+    },
+  };
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  describe("FoldingRangeCommand", () => {
+    const command = createFoldingRangeCommand();
+
+    it("should build correct parameters", () => {
+      expect(command.buildParams({ uri: "file:///main.go" })).toEqual({
+        textDocument: { uri: "file:///main.go" },
+      });
+    });
+
+    it("should handle null response", () => {
+      expect(command.processResponse(null)).toEqual([]);
+    });
+
+    it("should pass through ranges", () => {
+      const ranges: FoldingRange[] = [{ startLine: 2, endLine: 5 }];
+      expect(command.processResponse(ranges)).toEqual(ranges);
+    });
+  });
+}
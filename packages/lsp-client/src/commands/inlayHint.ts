@@ -0,0 +1,60 @@
+import type { InlayHint } from "@internal/types";
+import type { InlayHintParams, InlayHintResult, LSPCommand } from "./types.ts";
+
+export function createInlayHintCommand(): LSPCommand<
+  InlayHintParams,
+  InlayHint[]
+> {
+  return {
+    method: "textDocument/inlayHint",
+
+    buildParams(input: InlayHintParams) {
+      return {
+        textDocument: { uri: input.uri },
+        range: input.range,
+      };
+    },
+
+    processResponse(response: InlayHintResult): InlayHint[] {
+      return response ?? [];
+    },
+  };
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  describe("InlayHintCommand", () => {
+    const command = createInlayHintCommand();
+
+    describe("buildParams", () => {
+      it("should build correct parameters", () => {
+        const range = {
+          start: { line: 0, character: 0 },
+          end: { line: 10, character: 0 },
+        };
+
+        expect(command.buildParams({ uri: "file:///main.rs", range })).toEqual(
+          {
+            textDocument: { uri: "file:///main.rs" },
+            range,
+          },
+        );
+      });
+    });
+
+    describe("processResponse", () => {
+      it("should handle null response", () => {
+        expect(command.processResponse(null)).toEqual([]);
+      });
+
+      it("should return hints as is", () => {
+        const hints: InlayHint[] = [
+          { position: { line: 1, character: 9 }, label: ": i32", kind: 1 },
+        ];
+        expect(command.processResponse(hints)).toEqual(hints);
+      });
+    });
+  });
+}
@@ -15,6 +15,9 @@ export function createReferencesCommand(): LSPCommand<
         context: {
           includeDeclaration: input.includeDeclaration ?? true,
         },
+        ...(input.workDoneToken !== undefined
+          ? { workDoneToken: input.workDoneToken }
+          : {}),
       };
     },
 
@@ -32,6 +35,16 @@ if (import.meta.vitest) {
     const command = createReferencesCommand();
 
     describe("buildParams", () => {
+      it("should pass through a work done progress token", () => {
+        const params = command.buildParams({
+          uri: "file:///test.ts",
+          position: { line: 1, character: 2 },
+          workDoneToken: "refs-1",
+        });
+
+        expect(params.workDoneToken).toBe("refs-1");
+      });
+
       it("should build correct parameters with default includeDeclaration", () => {
         const params = command.buildParams({
           uri: "file:///test.ts",
@@ -0,0 +1,192 @@
+import type { SemanticToken, SemanticTokensLegend } from "@internal/types";
+import type {
+  LSPCommand,
+  SemanticTokensResult,
+  TextDocumentParams,
+} from "./types.ts";
+
+/** Token types defined by the LSP specification (3.17) */
+export const STANDARD_TOKEN_TYPES = [
+  "namespace",
+  "type",
+  "class",
+  "enum",
+  "interface",
+  "struct",
+  "typeParameter",
+  "parameter",
+  "variable",
+  "property",
+  "enumMember",
+  "event",
+  "function",
+  "method",
+  "macro",
+  "keyword",
+  "modifier",
+  "comment",
+  "string",
+  "number",
+  "regexp",
+  "operator",
+  "decorator",
+];
+
+/** Token modifiers defined by the LSP specification (3.17) */
+export const STANDARD_TOKEN_MODIFIERS = [
+  "declaration",
+  "definition",
+  "readonly",
+  "static",
+  "deprecated",
+  "abstract",
+  "async",
+  "modification",
+  "documentation",
+  "defaultLibrary",
+];
+
+export function createSemanticTokensCommand(): LSPCommand<
+  TextDocumentParams,
+  SemanticTokensResult
+> {
+  return {
+    method: "textDocument/semanticTokens/full",
+
+    buildParams(input: TextDocumentParams) {
+      return {
+        textDocument: { uri: input.uri },
+      };
+    },
+
+    processResponse(response: SemanticTokensResult): SemanticTokensResult {
+      return response ?? null;
+    },
+  };
+}
+
+/**
+ * Decode the relative integer encoding of semantic tokens into absolute
+ * positions with type and modifier names from the server legend
+ */
+export function decodeSemanticTokens(
+  data: number[],
+  legend: SemanticTokensLegend,
+): SemanticToken[] {
+  const tokens: SemanticToken[] = [];
+  let line = 0;
+  let character = 0;
+
+  for (let i = 0; i + 4 < data.length; i += 5) {
+    const deltaLine = data[i];
+    const deltaStart = data[i + 1];
+    const modifierBits = data[i + 4];
+    line += deltaLine;
+    character = deltaLine === 0 ? character + deltaStart : deltaStart;
+
+    const modifiers: string[] = [];
+    for (let bit = 0; bit < legend.tokenModifiers.length; bit++) {
+      if (modifierBits & (1 << bit)) {
+        modifiers.push(legend.tokenModifiers[bit]);
+      }
+    }
+
+    tokens.push({
+      line,
+      character,
+      length: data[i + 2],
+      type: legend.tokenTypes[data[i + 3]] ?? "unknown",
+      modifiers,
+    });
+  }
+
+  return tokens;
+}
+
+/**
+ * Whether a token introduces a symbol (as opposed to referencing one)
+ */
+export function isDeclarationToken(token: SemanticToken): boolean {
+  return (
+    token.modifiers.includes("declaration") ||
+    token.modifiers.includes("definition")
+  );
+}
+
+/**
+ * Whether a token is a comment (including doc comments)
+ */
+export function isCommentToken(token: SemanticToken): boolean {
+  return token.type === "comment";
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  const legend: SemanticTokensLegend = {
+    tokenTypes: ["function", "parameter", "comment", "type"],
+    tokenModifiers: ["declaration", "readonly"],
+  };
+
+  describe("SemanticTokensCommand", () => {
+    const command = createSemanticTokensCommand();
+
+    it("should build correct parameters", () => {
+      expect(command.buildParams({ uri: "file:///main.go" })).toEqual({
+        textDocument: { uri: "file:///main.go" },
+      });
+    });
+
+    it("should normalize missing responses", () => {
+      expect(command.processResponse(undefined as any)).toBeNull();
+      expect(command.processResponse({ data: [0, 0, 1, 0, 0] })).toEqual({
+        data: [0, 0, 1, 0, 0],
+      });
+    });
+  });
+
+  describe("decodeSemanticTokens", () => {
+    it("should decode relative positions and modifiers", () => {
+      // // doc          -> comment at 0:0
+      // func Add(a int) -> function decl at 1:5, parameter decl at 1:9,
+      //                    type at 1:11
+      const data = [0, 0, 6, 2, 0, 1, 5, 3, 0, 1, 0, 4, 1, 1, 3, 0, 2, 3, 3, 0];
+
+      expect(decodeSemanticTokens(data, legend)).toEqual([
+        { line: 0, character: 0, length: 6, type: "comment", modifiers: [] },
+        {
+          line: 1,
+          character: 5,
+          length: 3,
+          type: "function",
+          modifiers: ["declaration"],
+        },
+        {
+          line: 1,
+          character: 9,
+          length: 1,
+          type: "parameter",
+          modifiers: ["declaration", "readonly"],
+        },
+        { line: 1, character: 11, length: 3, type: "type", modifiers: [] },
+      ]);
+    });
+
+    it("should ignore trailing incomplete entries", () => {
+      expect(decodeSemanticTokens([0, 0, 1, 0], legend)).toEqual([]);
+    });
+  });
+
+  describe("token helpers", () => {
+    it("should classify declarations and comments", () => {
+      const [comment, fn, , type] = decodeSemanticTokens(
+        [0, 0, 6, 2, 0, 1, 5, 3, 0, 1, 0, 4, 1, 1, 3, 0, 2, 3, 3, 0],
+        legend,
+      );
+      expect(isCommentToken(comment)).toBe(true);
+      expect(isDeclarationToken(fn)).toBe(true);
+      expect(isDeclarationToken(type)).toBe(false);
+    });
+  });
+}
@@ -0,0 +1,124 @@
+import type { TypeHierarchyItem } from "../protocol/types/index.ts";
+import type {
+  LSPCommand,
+  TextDocumentPositionParams,
+  TypeHierarchyParams,
+  TypeHierarchyResult,
+} from "./types.ts";
+
+export function createPrepareTypeHierarchyCommand(): LSPCommand<
+  TextDocumentPositionParams,
+  TypeHierarchyItem[]
+> {
+  return {
+    method: "textDocument/prepareTypeHierarchy",
+
+    buildParams(input: TextDocumentPositionParams) {
+      return {
+        textDocument: { uri: input.uri },
+        position: input.position,
+      };
+    },
+
+    processResponse(response: TypeHierarchyResult): TypeHierarchyItem[] {
+      return response ?? [];
+    },
+  };
+}
+
+export function createSupertypesCommand(): LSPCommand<
+  TypeHierarchyParams,
+  TypeHierarchyItem[]
+> {
+  return {
+    method: "typeHierarchy/supertypes",
+
+    buildParams(input: TypeHierarchyParams) {
+      return { item: input.item };
+    },
+
+    processResponse(response: TypeHierarchyResult): TypeHierarchyItem[] {
+      return response ?? [];
+    },
+  };
+}
+
+export function createSubtypesCommand(): LSPCommand<
+  TypeHierarchyParams,
+  TypeHierarchyItem[]
+> {
+  return {
+    method: "typeHierarchy/subtypes",
+
+    buildParams(input: TypeHierarchyParams) {
+      return { item: input.item };
+    },
+
+    processResponse(response: TypeHierarchyResult): TypeHierarchyItem[] {
+      return response ?? [];
+    },
+  };
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  const item: TypeHierarchyItem = {
+    name: "Reader",
+    kind: 11,
+    uri: "file:///test.go",
+    range: {
+      start: { line: 2, character: 0 },
+      end: { line: 4, character: 1 },
+    },
+    selectionRange: {
+      start: { line: 2, character: 5 },
+      end: { line: 2, character: 11 },
+    },
+  };
+
+  describe("PrepareTypeHierarchyCommand", () => {
+    const command = createPrepareTypeHierarchyCommand();
+
+    it("should build correct parameters", () => {
+      const params = command.buildParams({
+        uri: "file:///test.go",
+        position: { line: 2, character: 5 },
+      });
+
+      expect(params).toEqual({
+        textDocument: { uri: "file:///test.go" },
+        position: { line: 2, character: 5 },
+      });
+    });
+
+    it("should return empty array for null response", () => {
+      expect(command.processResponse(null)).toEqual([]);
+    });
+  });
+
+  describe("SupertypesCommand", () => {
+    const command = createSupertypesCommand();
+
+    it("should pass the item through", () => {
+      expect(command.buildParams({ item })).toEqual({ item });
+    });
+
+    it("should return items as is", () => {
+      expect(command.processResponse([item])).toEqual([item]);
+    });
+  });
+
+  describe("SubtypesCommand", () => {
+    const command = createSubtypesCommand();
+
+    it("should use the subtypes method", () => {
+      expect(command.method).toBe("typeHierarchy/subtypes");
+    });
+
+    it("should return empty array for null response", () => {
+      expect(command.processResponse(null)).toEqual([]);
+    });
+  });
+}
@@ -7,18 +7,28 @@ import type {
   Command,
   CompletionItem,
   Diagnostic,
+  DocumentLink,
   DocumentSymbol,
+  FoldingRange,
   FormattingOptions,
+  InlayHint,
   Location,
   LocationLink,
   MarkupContent,
   Position,
   Range,
+  SemanticTokens,
   SignatureHelp,
   SymbolInformation,
   TextEdit,
   WorkspaceEdit,
 } from "@internal/types";
+import type {
+  CallHierarchyIncomingCall,
+  CallHierarchyItem,
+  CallHierarchyOutgoingCall,
+  TypeHierarchyItem,
+} from "../protocol/types/index.ts";
 
 /**
  * Base interface for all LSP commands
@@ -61,6 +71,7 @@ export interface TextDocumentParams {
 
 export interface ReferenceParams extends TextDocumentPositionParams {
   includeDeclaration?: boolean;
+  workDoneToken?: string | number;
 }
 
 export interface CompletionParams extends TextDocumentPositionParams {
@@ -71,6 +82,12 @@ export interface CodeActionParams {
   uri: string;
   range: Range;
   diagnostics?: Diagnostic[];
+  only?: string[];
+}
+
+export interface InlayHintParams {
+  uri: string;
+  range: Range;
 }
 
 export interface FormattingParams {
@@ -86,6 +103,14 @@ export interface RenameParams extends TextDocumentPositionParams {
   newName: string;
 }
 
+export interface CallHierarchyCallsParams {
+  item: CallHierarchyItem;
+}
+
+export interface TypeHierarchyParams {
+  item: TypeHierarchyItem;
+}
+
 /**
  * Response type helpers
  */
@@ -110,6 +135,14 @@ export type CodeActionResult = (Command | CodeAction)[] | null;
 export type FormattingResult = TextEdit[] | null;
 export type SignatureHelpResult = SignatureHelp | null;
 export type RenameResult = WorkspaceEdit | null;
+export type PrepareCallHierarchyResult = CallHierarchyItem[] | null;
+export type IncomingCallsResult = CallHierarchyIncomingCall[] | null;
+export type OutgoingCallsResult = CallHierarchyOutgoingCall[] | null;
+export type TypeHierarchyResult = TypeHierarchyItem[] | null;
+export type SemanticTokensResult = SemanticTokens | null;
+export type InlayHintResult = InlayHint[] | null;
+export type DocumentLinkResult = DocumentLink[] | null;
+export type FoldingRangeResult = FoldingRange[] | null;
 
 /**
  * Utility function to convert LocationLink to Location
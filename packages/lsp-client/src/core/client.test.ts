@@ -0,0 +1,219 @@
+import { describe, it, expect, afterEach } from "vitest";
+import {
+  ErrorCodes,
+  frame,
+  MockLanguageServer,
+  reply,
+} from "@internal/mock-lsp-server";
+import { createLSPClient, type InternalLSPClient } from "./client.ts";
+
+const uri = "file:///project/main.go";
+
+const range = {
+  start: { line: 1, character: 5 },
+  end: { line: 1, character: 9 },
+};
+
+const clients: InternalLSPClient[] = [];
+
+function createClient(
+  server: MockLanguageServer,
+  options: {
+    requestPolicies?: Record<string, { timeout?: number }>;
+    initializationOptions?: Record<string, unknown>;
+  } = {},
+): InternalLSPClient {
+  const client = createLSPClient({
+    process: server.process,
+    rootPath: "/project",
+    languageId: "go",
+    serverCharacteristics: {
+      readinessCheckTimeout: 1,
+      requestPolicies: options.requestPolicies,
+    },
+    initializationOptions: options.initializationOptions,
+  });
+  clients.push(client);
+  return client;
+}
+
+afterEach(async () => {
+  await Promise.all(clients.splice(0).map((client) => client.stop()));
+});
+
+describe("LSP client against the mock server", () => {
+  it("should initialize with the server's capabilities and shut it down", async () => {
+    const server = new MockLanguageServer({
+      capabilities: { hoverProvider: true },
+    });
+    const client = createClient(server);
+    const exited = new Promise((resolve) =>
+      server.process.once("exit", resolve),
+    );
+
+    await client.start();
+    expect(client.supportsFeature("hover")).toBe(true);
+    expect(client.supportsFeature("rename")).toBe(false);
+    expect(server.messages("initialize")[0].params).toMatchObject({
+      rootUri: "file:///project",
+    });
+    expect(server.messages("initialized")).toHaveLength(1);
+
+    await client.stop();
+    expect(await exited).toBe(0);
+    expect(server.messages("shutdown")).toHaveLength(1);
+  });
+
+  it("should ask about the document it opened", async () => {
+    const server = new MockLanguageServer().respond(
+      "textDocument/hover",
+      (params, mock) => {
+        const lines = mock.documents.get(params.textDocument.uri)!.split("\n");
+        return { contents: lines[params.position.line].trim(), range };
+      },
+    );
+    const client = createClient(server);
+    await client.start();
+
+    client.openDocument(uri, "package main\nfunc main() {}\n", "go");
+    const hover = await client.getHover(uri, { line: 1, character: 6 });
+
+    expect(hover).toEqual({
+      contents: { kind: "markdown", value: "func main() {}" },
+      range,
+    });
+  });
+
+  it("should reject with the error the server answers with", async () => {
+    const server = new MockLanguageServer();
+    const client = createClient(server);
+    await client.start();
+
+    const error = await client
+      .sendRequest("textDocument/typeDefinition", {})
+      .catch((e) => e);
+
+    expect(error.message).toBe("Unhandled method textDocument/typeDefinition");
+    expect(error.code).toBe(ErrorCodes.MethodNotFound);
+  });
+
+  it("should time out slow and unanswered requests", async () => {
+    const server = new MockLanguageServer({
+      responses: {
+        "textDocument/hover": null,
+        "textDocument/definition": reply.none(),
+      },
+      delays: { "textDocument/hover": 500 },
+    });
+    const client = createClient(server, {
+      requestPolicies: { "*": { timeout: 50 } },
+    });
+    await client.start();
+
+    await expect(
+      client.getHover(uri, { line: 0, character: 0 }),
+    ).rejects.toThrow("LSP request timeout: textDocument/hover");
+    await expect(
+      client.sendRequest("textDocument/definition", {}),
+    ).rejects.toThrow("LSP request timeout: textDocument/definition");
+  });
+
+  it("should skip malformed messages and keep reading", async () => {
+    const server = new MockLanguageServer().respond(
+      "textDocument/hover",
+      (_params, mock) => {
+        mock.writeRaw(frame("{not json"));
+        mock.writeRaw("Content-Type: text/plain\r\n\r\n");
+        return { contents: "ok" };
+      },
+    );
+    const client = createClient(server);
+    await client.start();
+
+    const hover = await client.getHover(uri, { line: 0, character: 0 });
+
+    expect(hover?.contents).toEqual({ kind: "markdown", value: "ok" });
+  });
+
+  it("should fail pending requests when the server crashes", async () => {
+    const server = new MockLanguageServer({
+      responses: { "textDocument/references": reply.none() },
+    });
+    const client = createClient(server);
+    const exits: unknown[] = [];
+    client.on("serverExit", (event) => exits.push(event));
+    await client.start();
+
+    const pending = client.sendRequest("textDocument/references", {});
+    server.crash(2);
+
+    await expect(pending).rejects.toThrow(
+      "LSP server exited (code 2) before responding",
+    );
+    expect(exits).toEqual([{ code: 2, signal: null }]);
+  });
+
+  it("should include stderr when the server exits during initialize", async () => {
+    const server = new MockLanguageServer().respond(
+      "initialize",
+      (_params, mock) => {
+        mock.crash(1, "go: cannot find main module\n");
+        return reply.none();
+      },
+    );
+    const client = createClient(server);
+
+    await expect(client.start()).rejects.toThrow(
+      /exited unexpectedly with code 1[\s\S]*go: cannot find main module/,
+    );
+  });
+
+  it("should pass on published diagnostics that have a range", async () => {
+    const server = new MockLanguageServer();
+    const client = createClient(server);
+    await client.start();
+    const published = new Promise<any>((resolve) =>
+      client.on("diagnostics", resolve),
+    );
+
+    server.publishDiagnostics(uri, [
+      { range, message: "declared and not used: x", severity: 1 },
+      { message: "no range" },
+    ]);
+
+    expect(await published).toEqual({
+      uri,
+      diagnostics: [
+        { range, message: "declared and not used: x", severity: 1 },
+      ],
+    });
+  });
+
+  it("should answer workspace/configuration from initializationOptions", async () => {
+    const server = new MockLanguageServer();
+    const client = createClient(server, {
+      initializationOptions: { gopls: { staticcheck: true } },
+    });
+    await client.start();
+
+    expect(
+      await server.request("workspace/configuration", {
+        items: [{ section: "gopls" }, { section: "unknown" }],
+      }),
+    ).toEqual([{ staticcheck: true }, {}]);
+  });
+
+  it("should answer gopls with flat initializationOptions", async () => {
+    const server = new MockLanguageServer();
+    const client = createClient(server, {
+      initializationOptions: { staticcheck: true, gofumpt: false },
+    });
+    await client.start();
+
+    expect(
+      await server.request("workspace/configuration", {
+        items: [{ section: "gopls" }],
+      }),
+    ).toEqual([{ staticcheck: true, gofumpt: false }]);
+  });
+});
@@ -20,17 +20,42 @@ import type {
   FormattingOptions,
   PublishDiagnosticsParams,
   ServerCapabilities,
+  ServerStatusParams,
+  DidChangeWorkspaceFoldersParams,
+  FileRename,
+  RenameFilesParams,
+  CallHierarchyItem,
+  CallHierarchyIncomingCall,
+  CallHierarchyOutgoingCall,
+  TypeHierarchyItem,
+  DiagnosticResult,
 } from "../protocol/types/index.ts";
+import type {
+  DocumentLink,
+  FoldingRange,
+  InlayHint,
+  SemanticToken,
+} from "@internal/types";
 import type { LSPClientConfig } from "./state.ts";
 import { createInitialState } from "./state.ts";
 import { ConnectionHandler } from "./connection.ts";
 import { LifecycleManager } from "./lifecycle.ts";
-import { DocumentManager } from "../managers/document-manager.ts";
+import {
+  DocumentManager,
+  documentSyncKind,
+  type DocumentSnapshot,
+} from "../managers/document-manager.ts";
+import { TextDocumentSyncKind } from "../protocol/types/index.ts";
 import { DiagnosticsManager } from "../managers/diagnostics.ts";
 import { createFeatureCommands } from "../utils/features.ts";
+import { decodeSemanticTokens } from "../commands/semanticTokens.ts";
+import type { SemanticTokensResult } from "../commands/types.ts";
 import { applyWorkspaceEditManually } from "../managers/workspace.ts";
 import { getLanguageIdFromPath } from "../utils/language.ts";
 import { debug } from "../utils/debug.ts";
+import { ResultCache, type CacheScope } from "../utils/resultCache.ts";
+import { toWorkspaceFolder } from "../utils/helpers.ts";
+import { withSpan } from "../../../../src/utils/tracing.ts";
 import type { IFileSystem, IServerCharacteristics } from "../interfaces.ts";
 import type { ChildProcess } from "child_process";
 
@@ -49,11 +74,31 @@ export interface InternalLSPClient {
   // Document management
   openDocument(uri: string, text: string, languageId?: string): void;
   closeDocument(uri: string): void;
-  updateDocument(uri: string, text: string, version: number): void;
+  /** Send new content; the version is the next one unless given */
+  updateDocument(uri: string, text: string, version?: number): void;
+  saveDocument(uri: string, text?: string): void;
+  /** A file changed outside the client, e.g. on disk; drops cached results */
+  fileChanged(uri: string): void;
   isDocumentOpen(uri: string): boolean;
+  /** Open documents with their current content */
+  getOpenDocuments(): DocumentSnapshot[];
+  getServerStatus(): ServerStatusParams | undefined;
+  getWorkspaceFolders(): string[];
+  changeWorkspaceFolders(added: string[], removed?: string[]): void;
+  /** Edits (import updates) the server wants applied before files move */
+  willRenameFiles(files: FileRename[]): Promise<WorkspaceEdit | null>;
+  didRenameFiles(files: FileRename[]): void;
 
   // LSP features
-  findReferences(uri: string, position: Position): Promise<Location[]>;
+  findReferences(
+    uri: string,
+    position: Position,
+    options?: {
+      workDoneToken?: string | number;
+      signal?: AbortSignal;
+      includeDeclaration?: boolean;
+    },
+  ): Promise<Location[]>;
   getDefinition(
     uri: string,
     position: Position,
@@ -61,21 +106,31 @@ export interface InternalLSPClient {
   getHover(uri: string, position: Position): Promise<Hover | null>;
   getDiagnostics(uri: string): Diagnostic[];
   pullDiagnostics(uri: string): Promise<Diagnostic[]>;
+  pullWorkspaceDiagnostics(): Promise<DiagnosticResult[]>;
   getDocumentSymbols(
     uri: string,
   ): Promise<DocumentSymbol[] | SymbolInformation[]>;
-  getWorkspaceSymbols(query: string): Promise<SymbolInformation[]>;
+  getWorkspaceSymbols(
+    query: string,
+    options?: { signal?: AbortSignal },
+  ): Promise<SymbolInformation[]>;
   getCompletion(uri: string, position: Position): Promise<CompletionItem[]>;
   resolveCompletionItem(item: CompletionItem): Promise<CompletionItem>;
   getSignatureHelp(
     uri: string,
     position: Position,
   ): Promise<SignatureHelp | null>;
+  getSemanticTokens(uri: string): Promise<SemanticToken[]>;
+  getInlayHints(uri: string, range: Range): Promise<InlayHint[]>;
+  getDocumentLinks(uri: string): Promise<DocumentLink[]>;
+  getFoldingRanges(uri: string): Promise<FoldingRange[]>;
   getCodeActions(
     uri: string,
     range: Range,
-    context?: { diagnostics?: Diagnostic[] },
+    context?: { diagnostics?: Diagnostic[]; only?: string[] },
   ): Promise<(Command | CodeAction)[]>;
+  resolveCodeAction(action: CodeAction): Promise<CodeAction>;
+  executeCommand(command: string, args?: unknown[]): Promise<unknown>;
   formatDocument(uri: string, options: FormattingOptions): Promise<TextEdit[]>;
   formatRange(
     uri: string,
@@ -92,6 +147,25 @@ export interface InternalLSPClient {
     edit: WorkspaceEdit,
     label?: string,
   ): Promise<{ applied: boolean; failureReason?: string }>;
+  prepareCallHierarchy(
+    uri: string,
+    position: Position,
+    options?: { signal?: AbortSignal },
+  ): Promise<CallHierarchyItem[]>;
+  getIncomingCalls(
+    item: CallHierarchyItem,
+    options?: { signal?: AbortSignal },
+  ): Promise<CallHierarchyIncomingCall[]>;
+  getOutgoingCalls(
+    item: CallHierarchyItem,
+    options?: { signal?: AbortSignal },
+  ): Promise<CallHierarchyOutgoingCall[]>;
+  prepareTypeHierarchy(
+    uri: string,
+    position: Position,
+  ): Promise<TypeHierarchyItem[]>;
+  getSupertypes(item: TypeHierarchyItem): Promise<TypeHierarchyItem[]>;
+  getSubtypes(item: TypeHierarchyItem): Promise<TypeHierarchyItem[]>;
 
   // Advanced features
   sendRequest<T = unknown>(method: string, params?: unknown): Promise<T>;
@@ -99,7 +173,9 @@ export interface InternalLSPClient {
     event: "diagnostics",
     listener: (params: PublishDiagnosticsParams) => void,
   ): void;
+  on(event: string, listener: (...args: any[]) => void): void;
   emit(event: string, ...args: unknown[]): boolean;
+  off(event: string, listener: (...args: any[]) => void): void;
   waitForDiagnostics(fileUri: string, timeout?: number): Promise<Diagnostic[]>;
   getDiagnosticSupport(): {
     pushDiagnostics: boolean;
@@ -113,7 +189,37 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
   const state = createInitialState(config);
   const connection = new ConnectionHandler(state);
   const lifecycle = new LifecycleManager(state, connection, config);
-  const documentManager = new DocumentManager();
+  const documentManager = new DocumentManager({
+    debounce: config.serverCharacteristics?.documentUpdateDebounce,
+    maxOpenDocuments: config.serverCharacteristics?.maxOpenDocuments,
+  });
+  state.documentText = (uri) => documentManager.getDocumentText(uri);
+  state.flushDocuments = () => documentManager.flush();
+  state.touchDocument = (uri) =>
+    documentManager.touch(uri, connection.sendNotification.bind(connection));
+  // Callers waiting on the same document share one wait
+  const diagnosticsWaits = new Map<string, Promise<Diagnostic[]>>();
+  const resultCache = new ResultCache(
+    config.serverCharacteristics?.resultCacheSize,
+  );
+  // Keyed by the text the server will answer for, so pending edits go first
+  const cached = <T>(
+    method: string,
+    uri: string,
+    position: Position | undefined,
+    scope: CacheScope,
+    fetch: () => Promise<T>,
+  ): Promise<T> => {
+    documentManager.flush(uri);
+    return resultCache.getOrFetch(
+      method,
+      uri,
+      documentManager.getDocumentText(uri),
+      position,
+      scope,
+      fetch,
+    );
+  };
   const diagnosticsManager = new DiagnosticsManager(state.eventEmitter);
   const commands = createFeatureCommands();
 
@@ -148,12 +254,30 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
           return !!caps.workspaceSymbolProvider;
         case "codeAction":
           return !!caps.codeActionProvider;
+        case "codeActionResolve":
+          return !!caps.codeActionProvider?.resolveProvider;
+        case "executeCommand":
+          return !!caps.executeCommandProvider;
         case "formatting":
           return !!caps.documentFormattingProvider;
         case "rangeFormatting":
           return !!caps.documentRangeFormattingProvider;
         case "signatureHelp":
           return !!caps.signatureHelpProvider;
+        case "semanticTokens":
+          return !!caps.semanticTokensProvider?.full;
+        case "inlayHint":
+          return !!caps.inlayHintProvider;
+        case "documentLink":
+          return !!caps.documentLinkProvider;
+        case "foldingRange":
+          return !!caps.foldingRangeProvider;
+        case "callHierarchy":
+          return !!caps.callHierarchyProvider;
+        case "typeHierarchy":
+          return !!caps.typeHierarchyProvider;
+        case "workspaceFolders":
+          return !!caps.workspace?.workspaceFolders?.supported;
         case "diagnostics":
           return true; // Usually always supported
         default:
@@ -165,11 +289,13 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
     openDocument(uri: string, text: string, languageId?: string): void {
       const actualLanguageId =
         languageId || getLanguageIdFromPath(uri) || state.languageId;
-      documentManager.openDocument(
-        uri,
-        text,
-        connection.sendNotification.bind(connection),
-        actualLanguageId,
+      withSpan("lsp openDocument", { "lsp.document": uri }, () =>
+        documentManager.openDocument(
+          uri,
+          text,
+          connection.sendNotification.bind(connection),
+          actualLanguageId,
+        ),
       );
     },
 
@@ -179,31 +305,116 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
         connection.sendNotification.bind(connection),
       );
       diagnosticsManager.clearDiagnostics(uri);
+      resultCache.invalidate(uri);
     },
 
-    updateDocument(uri: string, text: string, version: number): void {
+    updateDocument(uri: string, text: string, version?: number): void {
+      // supportsIncrementalSync: false forces full text for servers that
+      // mis-apply ranges
+      const syncKind = documentSyncKind(state.serverCapabilities);
       documentManager.updateDocument(
         uri,
         text,
         connection.sendNotification.bind(connection),
         version,
+        syncKind === TextDocumentSyncKind.Incremental &&
+          state.serverCharacteristics?.supportsIncrementalSync === false
+          ? TextDocumentSyncKind.Full
+          : syncKind,
+      );
+      resultCache.contentChanged(uri);
+    },
+
+    saveDocument(uri: string, text?: string): void {
+      documentManager.saveDocument(
+        uri,
+        connection.sendNotification.bind(connection),
+        text,
       );
+      resultCache.contentChanged(uri);
+    },
+
+    fileChanged(uri: string): void {
+      resultCache.contentChanged(uri);
     },
 
     isDocumentOpen(uri: string): boolean {
       return documentManager.isDocumentOpen(uri);
     },
 
+    getOpenDocuments: () => documentManager.getDocumentSnapshots(),
+
+    getServerStatus: () => state.serverStatus,
+
+    getWorkspaceFolders: () => [...state.workspaceFolders],
+
+    changeWorkspaceFolders(added: string[], removed: string[] = []): void {
+      const newFolders = added.filter(
+        (folder) => !state.workspaceFolders.includes(folder),
+      );
+      const oldFolders = removed.filter(
+        (folder) =>
+          folder !== state.rootPath && state.workspaceFolders.includes(folder),
+      );
+      if (newFolders.length === 0 && oldFolders.length === 0) {
+        return;
+      }
+      state.workspaceFolders = [
+        ...state.workspaceFolders.filter((f) => !oldFolders.includes(f)),
+        ...newFolders,
+      ];
+      const params: DidChangeWorkspaceFoldersParams = {
+        event: {
+          added: newFolders.map(toWorkspaceFolder),
+          removed: oldFolders.map(toWorkspaceFolder),
+        },
+      };
+      connection.sendNotification(
+        "workspace/didChangeWorkspaceFolders",
+        params,
+      );
+    },
+
+    async willRenameFiles(files: FileRename[]): Promise<WorkspaceEdit | null> {
+      if (!state.serverCapabilities?.workspace?.fileOperations?.willRename) {
+        return null;
+      }
+      const params: RenameFilesParams = { files };
+      return await connection.sendRequest<WorkspaceEdit | null>(
+        "workspace/willRenameFiles",
+        params,
+      );
+    },
+
+    didRenameFiles(files: FileRename[]): void {
+      if (!state.serverCapabilities?.workspace?.fileOperations?.didRename) {
+        return;
+      }
+      const params: RenameFilesParams = { files };
+      connection.sendNotification("workspace/didRenameFiles", params);
+    },
+
     // LSP features - delegated to feature modules
-    async findReferences(uri: string, position: Position): Promise<Location[]> {
+    async findReferences(
+      uri: string,
+      position: Position,
+      options?: {
+        workDoneToken?: string | number;
+        signal?: AbortSignal;
+        includeDeclaration?: boolean;
+      },
+    ): Promise<Location[]> {
       const params = commands.references.buildParams({
         uri,
         position,
-        includeDeclaration: true,
+        includeDeclaration: options?.includeDeclaration ?? true,
+        workDoneToken: options?.workDoneToken,
       });
       const result = await connection.sendRequest<Location[] | null>(
         commands.references.method,
         params,
+        undefined,
+        options?.signal,
       );
       return commands.references.processResponse(result);
     },
@@ -212,29 +423,45 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
       uri: string,
       position: Position,
     ): Promise<Location | Location[] | LocationLink[]> {
-      const params = commands.definition.buildParams({ uri, position });
-      debug(
-        "[lspClient] Sending textDocument/definition request:",
-        JSON.stringify(params, null, 2),
-      );
-      const result = await connection.sendRequest(
+      return cached(
         commands.definition.method,
-        params,
-      );
-      debug(
-        "[lspClient] Received definition response:",
-        JSON.stringify(result, null, 2),
+        uri,
+        position,
+        "document",
+        async () => {
+          const params = commands.definition.buildParams({ uri, position });
+          debug(
+            "[lspClient] Sending textDocument/definition request:",
+            JSON.stringify(params, null, 2),
+          );
+          const result = await connection.sendRequest(
+            commands.definition.method,
+            params,
+          );
+          debug(
+            "[lspClient] Received definition response:",
+            JSON.stringify(result, null, 2),
+          );
+          return commands.definition.processResponse(result);
+        },
       );
-      return commands.definition.processResponse(result);
     },
 
     async getHover(uri: string, position: Position): Promise<Hover | null> {
-      const params = commands.hover.buildParams({ uri, position });
-      const result = await connection.sendRequest(
+      return cached(
         commands.hover.method,
-        params,
+        uri,
+        position,
+        "workspace",
+        async () => {
+          const params = commands.hover.buildParams({ uri, position });
+          const result = await connection.sendRequest(
+            commands.hover.method,
+            params,
+          );
+          return commands.hover.processResponse(result);
+        },
       );
-      return commands.hover.processResponse(result);
     },
 
     getDiagnostics(uri: string): Diagnostic[] {
@@ -245,6 +472,14 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
       return diagnosticsManager.pullDiagnostics(
         uri,
         connection.sendRequest.bind(connection),
+        state.serverCapabilities?.diagnosticProvider?.identifier,
+      );
+    },
+
+    async pullWorkspaceDiagnostics(): Promise<DiagnosticResult[]> {
+      return diagnosticsManager.pullWorkspaceDiagnostics(
+        connection.sendRequest.bind(connection),
+        state.serverCapabilities?.diagnosticProvider?.identifier,
       );
     },
 
@@ -252,12 +487,20 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
       uri: string,
     ): Promise<DocumentSymbol[] | SymbolInformation[]> {
       try {
-        const params = commands.documentSymbols.buildParams({ uri });
-        const result = await connection.sendRequest(
+        return await cached(
           commands.documentSymbols.method,
-          params,
+          uri,
+          undefined,
+          "document",
+          async () => {
+            const params = commands.documentSymbols.buildParams({ uri });
+            const result = await connection.sendRequest(
+              commands.documentSymbols.method,
+              params,
+            );
+            return commands.documentSymbols.processResponse(result);
+          },
         );
-        return commands.documentSymbols.processResponse(result);
       } catch (error: unknown) {
         const errorMessage =
           error instanceof Error ? error.message : String(error);
@@ -274,11 +517,16 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
       }
     },
 
-    async getWorkspaceSymbols(query: string): Promise<SymbolInformation[]> {
+    async getWorkspaceSymbols(
+      query: string,
+      options?: { signal?: AbortSignal },
+    ): Promise<SymbolInformation[]> {
       const params = { query };
       const result = await connection.sendRequest<SymbolInformation[] | null>(
         "workspace/symbol",
         params,
+        undefined,
+        options?.signal,
       );
       return result ?? [];
     },
@@ -316,15 +564,57 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
       return commands.signatureHelp.processResponse(result);
     },
 
+    async getSemanticTokens(uri: string): Promise<SemanticToken[]> {
+      const legend = state.serverCapabilities?.semanticTokensProvider?.legend;
+      if (!legend) {
+        throw new Error("LSP server does not support semantic tokens");
+      }
+      const params = commands.semanticTokens.buildParams({ uri });
+      const result = await connection.sendRequest<SemanticTokensResult>(
+        commands.semanticTokens.method,
+        params,
+      );
+      const tokens = commands.semanticTokens.processResponse(result);
+      return tokens ? decodeSemanticTokens(tokens.data, legend) : [];
+    },
+
+    async getInlayHints(uri: string, range: Range): Promise<InlayHint[]> {
+      const params = commands.inlayHint.buildParams({ uri, range });
+      const result = await connection.sendRequest<InlayHint[] | null>(
+        commands.inlayHint.method,
+        params,
+      );
+      return commands.inlayHint.processResponse(result);
+    },
+
+    async getDocumentLinks(uri: string): Promise<DocumentLink[]> {
+      const params = commands.documentLink.buildParams({ uri });
+      const result = await connection.sendRequest<DocumentLink[] | null>(
+        commands.documentLink.method,
+        params,
+      );
+      return commands.documentLink.processResponse(result);
+    },
+
+    async getFoldingRanges(uri: string): Promise<FoldingRange[]> {
+      const params = commands.foldingRange.buildParams({ uri });
+      const result = await connection.sendRequest<FoldingRange[] | null>(
+        commands.foldingRange.method,
+        params,
+      );
+      return commands.foldingRange.processResponse(result);
+    },
+
     async getCodeActions(
       uri: string,
       range: Range,
-      context?: { diagnostics?: Diagnostic[] },
+      context?: { diagnostics?: Diagnostic[]; only?: string[] },
     ): Promise<(Command | CodeAction)[]> {
       const params = commands.codeAction.buildParams({
         uri,
         range,
         diagnostics: context?.diagnostics,
+        only: context?.only,
       });
       const result = await connection.sendRequest(
         commands.codeAction.method,
@@ -333,6 +623,28 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
       return commands.codeAction.processResponse(result);
     },
 
+    async resolveCodeAction(action: CodeAction): Promise<CodeAction> {
+      const params = commands.resolveCodeAction.buildParams(action);
+      const result = await connection.sendRequest(
+        commands.resolveCodeAction.method,
+        params,
+      );
+      const resolved = commands.resolveCodeAction.processResponse(result);
+      return { ...action, ...resolved };
+    },
+
+    async executeCommand(command: string, args?: unknown[]): Promise<unknown> {
+      const params = commands.executeCommand.buildParams({
+        command,
+        arguments: args,
+      });
+      const result = await connection.sendRequest(
+        commands.executeCommand.method,
+        params,
+      );
+      return commands.executeCommand.processResponse(result);
+    },
+
     async formatDocument(
       uri: string,
       options: FormattingOptions,
@@ -441,13 +753,91 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
       }
     },
 
+    async prepareCallHierarchy(
+      uri: string,
+      position: Position,
+      options?: { signal?: AbortSignal },
+    ): Promise<CallHierarchyItem[]> {
+      const params = commands.prepareCallHierarchy.buildParams({
+        uri,
+        position,
+      });
+      const result = await connection.sendRequest(
+        commands.prepareCallHierarchy.method,
+        params,
+        undefined,
+        options?.signal,
+      );
+      return commands.prepareCallHierarchy.processResponse(result);
+    },
+
+    async getIncomingCalls(
+      item: CallHierarchyItem,
+      options?: { signal?: AbortSignal },
+    ): Promise<CallHierarchyIncomingCall[]> {
+      const params = commands.incomingCalls.buildParams({ item });
+      const result = await connection.sendRequest(
+        commands.incomingCalls.method,
+        params,
+        undefined,
+        options?.signal,
+      );
+      return commands.incomingCalls.processResponse(result);
+    },
+
+    async getOutgoingCalls(
+      item: CallHierarchyItem,
+      options?: { signal?: AbortSignal },
+    ): Promise<CallHierarchyOutgoingCall[]> {
+      const params = commands.outgoingCalls.buildParams({ item });
+      const result = await connection.sendRequest(
+        commands.outgoingCalls.method,
+        params,
+        undefined,
+        options?.signal,
+      );
+      return commands.outgoingCalls.processResponse(result);
+    },
+
+    async prepareTypeHierarchy(
+      uri: string,
+      position: Position,
+    ): Promise<TypeHierarchyItem[]> {
+      const params = commands.prepareTypeHierarchy.buildParams({
+        uri,
+        position,
+      });
+      const result = await connection.sendRequest(
+        commands.prepareTypeHierarchy.method,
+        params,
+      );
+      return commands.prepareTypeHierarchy.processResponse(result);
+    },
+
+    async getSupertypes(
+      item: TypeHierarchyItem,
+    ): Promise<TypeHierarchyItem[]> {
+      const params = commands.supertypes.buildParams({ item });
+      const result = await connection.sendRequest(
+        commands.supertypes.method,
+        params,
+      );
+      return commands.supertypes.processResponse(result);
+    },
+
+    async getSubtypes(item: TypeHierarchyItem): Promise<TypeHierarchyItem[]> {
+      const params = commands.subtypes.buildParams({ item });
+      const result = await connection.sendRequest(
+        commands.subtypes.method,
+        params,
+      );
+      return commands.subtypes.processResponse(result);
+    },
+
     // Advanced features
     sendRequest: connection.sendRequest.bind(connection),
 
-    on(
-      event: "diagnostics",
-      listener: (params: PublishDiagnosticsParams) => void,
-    ): void {
+    on(event: string, listener: (...args: any[]) => void): void {
       state.eventEmitter.on(event, listener);
     },
 
@@ -455,11 +845,25 @@ export function createLSPClient(config: LSPClientConfig): InternalLSPClient {
       return state.eventEmitter.emit(event, ...args);
     },
 
+    off(event: string, listener: (...args: any[]) => void): void {
+      state.eventEmitter.off(event, listener);
+    },
+
     waitForDiagnostics(
       fileUri: string,
       timeout: number = 2000,
     ): Promise<Diagnostic[]> {
-      return diagnosticsManager.waitForDiagnostics(fileUri, timeout);
+      const existing = diagnosticsWaits.get(fileUri);
+      if (existing) {
+        return existing;
+      }
+      // Diagnostics for debounced edits only come after they are sent
+      const wait = diagnosticsManager
+        .waitForDiagnostics(fileUri, timeout)
+        .finally(() => diagnosticsWaits.delete(fileUri));
+      diagnosticsWaits.set(fileUri, wait);
+      documentManager.flush(fileUri);
+      return wait;
     },
 
     getDiagnosticSupport(): {
@@ -0,0 +1,171 @@
+import { describe, expect } from "vitest";
+import { fuzz, type Random } from "../../../../tests/helpers/fuzz.ts";
+import type { LSPMessage } from "../protocol/types/index.ts";
+import { ConnectionHandler } from "./connection.ts";
+import { createInitialState, type LSPProcessState } from "./state.ts";
+
+/** A connection collecting the frames it writes and the messages it reads */
+function createConnection() {
+  const written: Buffer[] = [];
+  const process = {
+    stdin: {
+      write: (data: string) => {
+        written.push(Buffer.from(data));
+        return true;
+      },
+    },
+  };
+  const state = createInitialState({
+    process: process as any,
+    rootPath: "/tmp",
+  });
+  const received: LSPMessage[] = [];
+  state.eventEmitter.on("message", (message: LSPMessage) =>
+    received.push(message),
+  );
+  return { connection: new ConnectionHandler(state), state, written, received };
+}
+
+function feed(
+  connection: ConnectionHandler,
+  state: LSPProcessState,
+  chunks: Buffer[],
+): void {
+  for (const chunk of chunks) {
+    state.buffer = Buffer.concat([state.buffer, chunk]);
+    connection.processBuffer();
+  }
+}
+
+function frame(body: Buffer | string): Buffer {
+  const bytes = typeof body === "string" ? Buffer.from(body) : body;
+  return Buffer.concat([
+    Buffer.from(`Content-Length: ${bytes.length}\r\n\r\n`),
+    bytes,
+  ]);
+}
+
+const NEXT = { jsonrpc: "2.0", method: "test/next" };
+
+/** Valid JSON that is not a message the client can handle */
+const UNEXPECTED_BODIES = [
+  "null",
+  "42",
+  '"text"',
+  "[]",
+  "{}",
+  '{"jsonrpc":"2.0"}',
+  '{"jsonrpc":"2.0","id":99,"result":null}',
+  '{"jsonrpc":"2.0","id":null,"error":{"code":-32700}}',
+  '{"jsonrpc":"2.0","method":null}',
+  '{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///a.go","diagnostics":5}}',
+  '{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///a.go","diagnostics":[null,{"range":null},{}]}}',
+  '{"jsonrpc":"2.0","id":1,"method":"workspace/configuration","params":{"items":"all"}}',
+  '{"jsonrpc":"2.0","id":2,"method":"workspace/configuration","params":{}}',
+  '{"jsonrpc":"2.0","method":"$/progress","params":7}',
+  '{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":"x"}}',
+];
+
+function randomParams(random: Random): Record<string, unknown> {
+  return {
+    text: random.text(40),
+    count: random.int(-1000, 1000),
+    items: Array.from({ length: random.int(0, 3) }, () => random.text(8)),
+  };
+}
+
+describe("LSP message framing", () => {
+  fuzz("should read back what the writer framed in any chunking", (random) => {
+    const writer = createConnection();
+    const reader = createConnection();
+    const sent = Array.from({ length: random.int(1, 5) }, () => ({
+      jsonrpc: "2.0",
+      method: `test/${random.int(0, 9)}`,
+      params: randomParams(random),
+    }));
+
+    for (const { method, params } of sent) {
+      writer.connection.sendNotification(method, params);
+    }
+    feed(
+      reader.connection,
+      reader.state,
+      random.chunks(Buffer.concat(writer.written)),
+    );
+
+    expect(reader.received).toEqual(sent);
+    expect(reader.state.buffer.length).toBe(0);
+    expect(reader.state.contentLength).toBe(-1);
+  });
+
+  fuzz("should decode invalid UTF-8 the same in any chunking", (random) => {
+    const { connection, state, received } = createConnection();
+    // Anything but the quote and backslash may appear raw in a JSON string
+    const bytes = Buffer.from(
+      Array.from({ length: random.int(0, 24) }, () => {
+        const byte = random.int(0x20, 0xff);
+        return byte === 0x22 || byte === 0x5c ? 0x20 : byte;
+      }),
+    );
+    const body = Buffer.concat([
+      Buffer.from('{"jsonrpc":"2.0","method":"test/bytes","params":{"text":"'),
+      bytes,
+      Buffer.from('"}}'),
+    ]);
+
+    feed(
+      connection,
+      state,
+      random.chunks(Buffer.concat([frame(body), frame(JSON.stringify(NEXT))])),
+    );
+
+    expect(received).toEqual([
+      {
+        jsonrpc: "2.0",
+        method: "test/bytes",
+        params: { text: bytes.toString("utf-8") },
+      },
+      NEXT,
+    ]);
+  });
+
+  fuzz("should read on after bodies it cannot handle", (random) => {
+    const { connection, state, received } = createConnection();
+    const bodies = Array.from({ length: random.int(1, 4) }, () =>
+      random.bool()
+        ? frame(random.pick(UNEXPECTED_BODIES))
+        : frame(random.bytes(random.int(0, 32))),
+    );
+
+    expect(() =>
+      feed(
+        connection,
+        state,
+        random.chunks(Buffer.concat([...bodies, frame(JSON.stringify(NEXT))])),
+      ),
+    ).not.toThrow();
+    expect(received.at(-1)).toEqual(NEXT);
+    expect(state.buffer.length).toBe(0);
+  });
+
+  fuzz("should never throw on arbitrary bytes", (random) => {
+    const { connection, state } = createConnection();
+    const pieces = Array.from({ length: random.int(1, 8) }, () => {
+      switch (random.int(0, 3)) {
+        case 0:
+          return Buffer.from(`Content-Length: ${random.int(0, 64)}\r\n\r\n`);
+        case 1:
+          return Buffer.from(random.pick(["\r\n\r\n", "\r\n", "\n\n"]));
+        case 2:
+          return Buffer.from(random.pick(UNEXPECTED_BODIES));
+        default:
+          return random.bytes(random.int(0, 32));
+      }
+    });
+
+    expect(() =>
+      feed(connection, state, random.chunks(Buffer.concat(pieces))),
+    ).not.toThrow();
+    expect(state.contentLength).toBeGreaterThanOrEqual(-1);
+  });
+});
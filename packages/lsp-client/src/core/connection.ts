@@ -13,8 +13,81 @@ import {
   isLSPNotification,
   isLSPRequest,
 } from "../protocol/types/index.ts";
+import type {
+  ApplyWorkspaceEditParams,
+  ServerStatusParams,
+} from "../protocol/types/index.ts";
 import type { LSPProcessState } from "./state.ts";
+import { applyWorkspaceEditManually } from "../managers/workspace.ts";
 import { debug } from "../utils/debug.ts";
+import { lspLogger } from "../utils/lsp-logger.ts";
+import { metrics } from "../../../../src/utils/metrics.ts";
+import { SpanKind, withSpan } from "../../../../src/utils/tracing.ts";
+import { toWorkspaceFolder } from "../utils/helpers.ts";
+import {
+  convertPositions,
+  readDocumentText,
+  requestDocumentUri,
+} from "../utils/positionEncoding.ts";
+import {
+  backoffDelay,
+  isTransientError,
+  resolveRequestPolicy,
+} from "../utils/requestPolicy.ts";
+
+const requestLog = lspLogger("request");
+const requestDuration = metrics.histogram(
+  "lsmcp_lsp_request_duration_seconds",
+  "Language server request latency by method and status",
+);
+
+function createCancelledError(method: string): Error {
+  const error = new Error(`LSP request cancelled: ${method}`);
+  error.name = "AbortError";
+  return error;
+}
+
+function waitForRetry(
+  delay: number,
+  signal: AbortSignal | undefined,
+  method: string,
+): Promise<void> {
+  return new Promise((resolve, reject) => {
+    if (signal?.aborted) {
+      reject(createCancelledError(method));
+      return;
+    }
+    const onAbort = () => {
+      clearTimeout(timer);
+      reject(createCancelledError(method));
+    };
+    const timer = setTimeout(() => {
+      signal?.removeEventListener("abort", onAbort);
+      resolve();
+    }, delay);
+    signal?.addEventListener("abort", onAbort, { once: true });
+  });
+}
+
+/**
+ * Look up a dotted configuration section (e.g. "python.analysis")
+ */
+function getConfigurationSection(
+  options: Record<string, unknown> | undefined,
+  section: string | undefined,
+): unknown {
+  if (!options || !section) {
+    return undefined;
+  }
+  let current: unknown = options;
+  for (const key of section.split(".")) {
+    if (!current || typeof current !== "object") {
+      return undefined;
+    }
+    current = (current as Record<string, unknown>)[key];
+  }
+  return current;
+}
 
 export class ConnectionHandler {
   constructor(private state: LSPProcessState) {}
@@ -28,16 +101,16 @@ export class ConnectionHandler {
           return;
         }
 
-        const header = this.state.buffer.substring(0, headerEnd);
+        const header = this.state.buffer.subarray(0, headerEnd).toString();
         const contentLengthMatch = header.match(/Content-Length: (\d+)/);
         if (!contentLengthMatch) {
           debug("Invalid LSP header:", header);
-          this.state.buffer = this.state.buffer.substring(headerEnd + 4);
+          this.state.buffer = this.state.buffer.subarray(headerEnd + 4);
           continue;
         }
 
         this.state.contentLength = parseInt(contentLengthMatch[1], 10);
-        this.state.buffer = this.state.buffer.substring(headerEnd + 4);
+        this.state.buffer = this.state.buffer.subarray(headerEnd + 4);
       }
 
       if (this.state.buffer.length < this.state.contentLength) {
@@ -45,11 +118,12 @@ export class ConnectionHandler {
         return;
       }
 
-      const messageBody = this.state.buffer.substring(
-        0,
-        this.state.contentLength,
-      );
-      this.state.buffer = this.state.buffer.substring(this.state.contentLength);
+      // Decode only complete messages so multi-byte characters split
+      // across chunks survive
+      const messageBody = this.state.buffer
+        .subarray(0, this.state.contentLength)
+        .toString("utf-8");
+      this.state.buffer = this.state.buffer.subarray(this.state.contentLength);
       this.state.contentLength = -1;
 
       try {
@@ -61,6 +135,31 @@ export class ConnectionHandler {
     }
   }
 
+  private documentText(uri: string): string | undefined {
+    return this.state.documentText?.(uri) ?? readDocumentText(uri);
+  }
+
+  /** Positions from tools (UTF-16) in the server's encoding */
+  private toServer<T>(params: T): T {
+    return convertPositions(
+      params,
+      "toServer",
+      this.state.positionEncoding,
+      (uri) => this.documentText(uri),
+    );
+  }
+
+  /** Positions from the server in UTF-16 */
+  private fromServer<T>(value: T, uri?: string): T {
+    return convertPositions(
+      value,
+      "fromServer",
+      this.state.positionEncoding,
+      (docUri) => this.documentText(docUri),
+      uri,
+    );
+  }
+
   private handleMessage(message: LSPMessage): void {
     debug(
       "[LSP message]",
@@ -86,9 +185,15 @@ export class ConnectionHandler {
         clearTimeout(handler.timer);
       }
       this.state.responseHandlers.delete(message.id);
+      // Any answer shows the server is alive (see requestTimeout)
+      this.state.eventEmitter.emit("response", message.id);
 
       if (message.error) {
-        handler.reject(new Error(message.error.message));
+        handler.reject(
+          Object.assign(new Error(message.error.message), {
+            code: message.error.code,
+          }),
+        );
       } else {
         handler.resolve(message.result);
       }
@@ -100,6 +205,10 @@ export class ConnectionHandler {
   private handleNotificationOrRequest(
     message: LSPNotification | LSPRequest,
   ): void {
+    if (message.params) {
+      message = { ...message, params: this.fromServer(message.params) };
+    }
+
     // Handle diagnostics notification
     if (
       message.method === "textDocument/publishDiagnostics" &&
@@ -133,14 +242,103 @@ export class ConnectionHandler {
             unstable: true,
           };
         }
-        return {};
+        // rust-analyzer re-reads its settings from here; answering {} would
+        // reset the initializationOptions to server defaults
+        if (item.section === "rust-analyzer") {
+          return this.state.initializationOptions ?? {};
+        }
+        // gopls takes the same flat settings as in initializationOptions;
+        // configs may also nest them under "gopls"
+        if (item.section === "gopls") {
+          const options = this.state.initializationOptions;
+          return getConfigurationSection(options, "gopls") ?? options ?? {};
+        }
+        // Servers like pyright take settings only from here ("python",
+        // "python.analysis"); answer with that part of initializationOptions
+        return (
+          getConfigurationSection(
+            this.state.initializationOptions,
+            item.section,
+          ) ?? {}
+        );
       });
       this.sendResponse((message as LSPRequest).id, configurations);
     }
 
+    // Handle workspace/workspaceFolders request
+    if (
+      isLSPRequest(message) &&
+      message.method === "workspace/workspaceFolders"
+    ) {
+      this.sendResponse(
+        (message as LSPRequest).id,
+        this.state.workspaceFolders.map(toWorkspaceFolder),
+      );
+    }
+
+    // Handle rust-analyzer server status (enabled via experimental capability)
+    if (message.method === "experimental/serverStatus" && message.params) {
+      this.state.serverStatus =
+        message.params as unknown as ServerStatusParams;
+      this.state.eventEmitter.emit("serverStatus", message.params);
+    }
+
+    // Handle server messages (forwarded to the MCP client as log messages)
+    if (message.method === "window/logMessage" && message.params) {
+      this.state.eventEmitter.emit("logMessage", message.params);
+    }
+    if (message.method === "window/showMessage" && message.params) {
+      this.state.eventEmitter.emit("showMessage", message.params);
+    }
+    if (
+      isLSPRequest(message) &&
+      message.method === "window/showMessageRequest"
+    ) {
+      // Nobody can pick one of the actions; report it like showMessage
+      if (message.params) {
+        this.state.eventEmitter.emit("showMessage", message.params);
+      }
+      this.sendResponse((message as LSPRequest).id, null);
+    }
+
+    // Handle work done progress ($/progress reports and token creation)
+    if (message.method === "$/progress" && message.params) {
+      this.state.eventEmitter.emit("progress", message.params);
+    }
+    if (
+      isLSPRequest(message) &&
+      message.method === "window/workDoneProgress/create"
+    ) {
+      this.sendResponse((message as LSPRequest).id, null);
+    }
+
+    // Handle workspace/applyEdit request (e.g. from workspace/executeCommand)
+    if (
+      isLSPRequest(message) &&
+      message.method === "workspace/applyEdit" &&
+      message.params
+    ) {
+      void this.handleApplyEdit(message as LSPRequest);
+    }
+
     this.state.eventEmitter.emit("message", message);
   }
 
+  private async handleApplyEdit(request: LSPRequest): Promise<void> {
+    const params = request.params as ApplyWorkspaceEditParams;
+    try {
+      await applyWorkspaceEditManually(params.edit, this.state.fileSystemApi);
+      this.state.eventEmitter.emit("workspaceEditApplied", params);
+      this.sendResponse(request.id, { applied: true });
+    } catch (error) {
+      debug("Failed to apply workspace edit from server:", error);
+      this.sendResponse(request.id, {
+        applied: false,
+        failureReason: error instanceof Error ? error.message : String(error),
+      });
+    }
+  }
+
   sendMessage(message: LSPMessage): void {
     if (!this.state.process) {
       throw new Error("LSP server not started");
@@ -150,26 +348,139 @@ export class ConnectionHandler {
     this.state.process.stdin?.write(header + content);
   }
 
+  /**
+   * Send a request and wait for its response.
+   * Aborting the signal sends $/cancelRequest and rejects immediately.
+   * Without an explicit timeout the method's request policy applies, and
+   * transient failures are retried with exponential backoff.
+   */
   sendRequest<T = unknown>(
     method: string,
     params?: unknown,
-    timeout: number = 30000,
+    timeout?: number,
+    signal?: AbortSignal,
+  ): Promise<T> {
+    return withSpan(
+      `lsp ${method}`,
+      {
+        "rpc.system": "jsonrpc",
+        "rpc.method": method,
+        "lsp.document": requestDocumentUri(params),
+      },
+      () => this.sendRequestWithRetries<T>(method, params, timeout, signal),
+      SpanKind.CLIENT,
+    );
+  }
+
+  private async sendRequestWithRetries<T>(
+    method: string,
+    params: unknown,
+    timeout: number | undefined,
+    signal: AbortSignal | undefined,
+  ): Promise<T> {
+    const policy = resolveRequestPolicy(
+      method,
+      this.state.serverCharacteristics?.requestPolicies,
+    );
+    const started = Date.now();
+    for (let attempt = 0; ; attempt++) {
+      try {
+        const result = await this.sendRequestOnce<T>(
+          method,
+          params,
+          timeout ?? policy.timeout,
+          signal,
+        );
+        requestLog.debug(method, {
+          duration: Date.now() - started,
+          attempts: attempt + 1,
+        });
+        requestDuration.observeSince({ method, status: "ok" }, started);
+        return result;
+      } catch (error) {
+        if (attempt >= policy.retries || !isTransientError(error)) {
+          // Cancellation is the caller's choice, not a failure
+          const level =
+            (error as Error).name === "AbortError" ? "debug" : "warn";
+          requestLog[level](`${method} failed`, {
+            duration: Date.now() - started,
+            attempts: attempt + 1,
+            error: (error as Error).message,
+          });
+          requestDuration.observeSince(
+            { method, status: level === "debug" ? "cancelled" : "error" },
+            started,
+          );
+          throw error;
+        }
+        const delay = backoffDelay(policy, attempt);
+        debug(
+          `[LSP request] Retrying ${method} in ${delay}ms (${attempt + 1}/${policy.retries}): ${(error as Error).message}`,
+        );
+        await waitForRetry(delay, signal, method);
+      }
+    }
+  }
+
+  private sendRequestOnce<T>(
+    method: string,
+    params: unknown,
+    timeout: number,
+    signal: AbortSignal | undefined,
   ): Promise<T> {
     return new Promise((resolve, reject) => {
+      if (signal?.aborted) {
+        reject(createCancelledError(method));
+        return;
+      }
+      // Requests must see the latest text, including debounced edits
+      this.state.flushDocuments?.();
+      const uri = requestDocumentUri(params);
+      if (uri) {
+        this.state.touchDocument?.(uri);
+      }
+
       const id = ++this.state.messageId;
       const request: LSPRequest = {
         jsonrpc: "2.0",
         id,
         method,
-        params: params as Record<string, unknown>,
+        params: this.toServer(params) as Record<string, unknown>,
+      };
+
+      const onAbort = () => {
+        const handler = this.state.responseHandlers.get(id);
+        if (!handler) {
+          return;
+        }
+        clearTimeout(handler.timer);
+        this.state.responseHandlers.delete(id);
+        debug(`[LSP request] Cancelling #${id} ${method}`);
+        this.sendNotification("$/cancelRequest", { id });
+        reject(createCancelledError(method));
       };
 
       const timer = setTimeout(() => {
         this.state.responseHandlers.delete(id);
+        signal?.removeEventListener("abort", onAbort);
+        // Nobody waits for the answer any more, and a retry asks again
+        this.sendNotification("$/cancelRequest", { id });
+        this.state.eventEmitter.emit("requestTimeout", { method, timeout });
         reject(new Error(`LSP request timeout: ${method}`));
       }, timeout);
 
-      this.state.responseHandlers.set(id, { resolve, reject, timer });
+      this.state.responseHandlers.set(id, {
+        resolve: (value) => {
+          signal?.removeEventListener("abort", onAbort);
+          resolve(this.fromServer(value, uri));
+        },
+        reject: (error) => {
+          signal?.removeEventListener("abort", onAbort);
+          reject(error);
+        },
+        timer,
+      });
+      signal?.addEventListener("abort", onAbort, { once: true });
       this.sendMessage(request);
     });
   }
@@ -178,7 +489,7 @@ export class ConnectionHandler {
     const notification: LSPNotification = {
       jsonrpc: "2.0",
       method,
-      params: params as Record<string, unknown>,
+      params: this.toServer(params) as Record<string, unknown>,
     };
     this.sendMessage(notification);
   }
@@ -192,3 +503,247 @@ export class ConnectionHandler {
     this.sendMessage(response);
   }
 }
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect, vi } = import.meta.vitest;
+  const { createInitialState } = await import("./state.ts");
+
+  function createConnection() {
+    const written: LSPMessage[] = [];
+    const process = {
+      stdin: {
+        write: vi.fn((data: string) => {
+          const body = data.slice(data.indexOf("\r\n\r\n") + 4);
+          written.push(JSON.parse(body));
+        }),
+      },
+    };
+    const state = createInitialState({
+      process: process as any,
+      rootPath: "/tmp",
+    });
+    return { connection: new ConnectionHandler(state), state, written };
+  }
+
+  function receive(
+    connection: ConnectionHandler,
+    state: LSPProcessState,
+    message: unknown,
+  ) {
+    const content = JSON.stringify(message);
+    state.buffer = Buffer.concat([
+      state.buffer,
+      Buffer.from(
+        `Content-Length: ${Buffer.byteLength(content)}\r\n\r\n${content}`,
+      ),
+    ]);
+    connection.processBuffer();
+  }
+
+  describe("ConnectionHandler.sendRequest cancellation", () => {
+    it("should send $/cancelRequest and reject when aborted", async () => {
+      const { connection, state, written } = createConnection();
+      const controller = new AbortController();
+
+      const pending = connection.sendRequest(
+        "textDocument/references",
+        {},
+        30000,
+        controller.signal,
+      );
+      controller.abort();
+
+      await expect(pending).rejects.toThrow(
+        "LSP request cancelled: textDocument/references",
+      );
+      expect(written[1]).toEqual({
+        jsonrpc: "2.0",
+        method: "$/cancelRequest",
+        params: { id: 1 },
+      });
+      expect(state.responseHandlers.size).toBe(0);
+
+      // A late response from the server is ignored
+      receive(connection, state, { jsonrpc: "2.0", id: 1, result: [] });
+    });
+
+    it("should not send a request when already aborted", async () => {
+      const { connection, written } = createConnection();
+      const controller = new AbortController();
+      controller.abort();
+
+      const pending = connection.sendRequest(
+        "workspace/symbol",
+        {},
+        30000,
+        controller.signal,
+      );
+
+      await expect(pending).rejects.toThrow("cancelled");
+      expect(written).toHaveLength(0);
+    });
+
+    it("should resolve normally when not aborted", async () => {
+      const { connection, state, written } = createConnection();
+      const controller = new AbortController();
+
+      const pending = connection.sendRequest(
+        "textDocument/hover",
+        {},
+        30000,
+        controller.signal,
+      );
+      receive(connection, state, { jsonrpc: "2.0", id: 1, result: "ok" });
+
+      await expect(pending).resolves.toBe("ok");
+      controller.abort();
+      expect(written).toHaveLength(1);
+    });
+  });
+
+  describe("ConnectionHandler health events", () => {
+    it("should emit requestTimeout and response", async () => {
+      const { connection, state } = createConnection();
+      const timeouts: unknown[] = [];
+      const responses: unknown[] = [];
+      state.eventEmitter.on("requestTimeout", (e) => timeouts.push(e));
+      state.eventEmitter.on("response", (id) => responses.push(id));
+
+      const slow = connection.sendRequest("textDocument/references", {}, 1);
+      await expect(slow).rejects.toThrow("LSP request timeout");
+      const fast = connection.sendRequest("textDocument/hover", {});
+      receive(connection, state, { jsonrpc: "2.0", id: 2, result: null });
+      await fast;
+
+      expect(timeouts).toEqual([
+        { method: "textDocument/references", timeout: 1 },
+      ]);
+      expect(responses).toEqual([2]);
+    });
+  });
+
+  describe("ConnectionHandler request policies", () => {
+    it("should retry ContentModified with backoff", async () => {
+      vi.useFakeTimers();
+      try {
+        const { connection, state, written } = createConnection();
+        state.serverCharacteristics = {
+          requestPolicies: { "*": { retries: 2, backoff: 100 } },
+        };
+
+        const pending = connection.sendRequest("textDocument/definition", {});
+        receive(connection, state, {
+          jsonrpc: "2.0",
+          id: 1,
+          error: { code: -32801, message: "content modified" },
+        });
+        await vi.advanceTimersByTimeAsync(100);
+        receive(connection, state, { jsonrpc: "2.0", id: 2, result: [] });
+
+        await expect(pending).resolves.toEqual([]);
+        expect(written.map((m) => (m as LSPRequest).id)).toEqual([1, 2]);
+      } finally {
+        vi.useRealTimers();
+      }
+    });
+
+    it("should cancel a timed out request before retrying it", async () => {
+      vi.useFakeTimers();
+      try {
+        const { connection, state, written } = createConnection();
+        state.serverCharacteristics = {
+          requestPolicies: { "*": { timeout: 50, retries: 1, backoff: 10 } },
+        };
+
+        const pending = connection.sendRequest("textDocument/definition", {});
+        await vi.advanceTimersByTimeAsync(60);
+        receive(connection, state, { jsonrpc: "2.0", id: 2, result: [] });
+
+        await expect(pending).resolves.toEqual([]);
+        expect(
+          written.map((m) => (m as LSPRequest).id ?? (m as LSPRequest).method),
+        ).toEqual([1, "$/cancelRequest", 2]);
+        expect(written[1]).toMatchObject({ params: { id: 1 } });
+      } finally {
+        vi.useRealTimers();
+      }
+    });
+
+    it("should not retry other errors", async () => {
+      const { connection, state } = createConnection();
+      state.serverCharacteristics = { requestPolicies: { "*": { retries: 2 } } };
+
+      const pending = connection.sendRequest("textDocument/definition", {});
+      receive(connection, state, {
+        jsonrpc: "2.0",
+        id: 1,
+        error: { code: -32602, message: "invalid params" },
+      });
+
+      await expect(pending).rejects.toMatchObject({
+        message: "invalid params",
+        code: -32602,
+      });
+    });
+  });
+
+  describe("ConnectionHandler non-ASCII messages", () => {
+    it("should decode messages split inside a multi-byte character", async () => {
+      const { connection, state } = createConnection();
+      const pending = connection.sendRequest("textDocument/hover", {});
+      const content = JSON.stringify({
+        jsonrpc: "2.0",
+        id: 1,
+        result: "漢字😀",
+      });
+      const bytes = Buffer.from(
+        `Content-Length: ${Buffer.byteLength(content)}\r\n\r\n${content}`,
+      );
+
+      // Split in the middle of the 3-byte "漢"
+      const split = bytes.indexOf(Buffer.from("漢")) + 1;
+      state.buffer = bytes.subarray(0, split);
+      connection.processBuffer();
+      state.buffer = Buffer.concat([state.buffer, bytes.subarray(split)]);
+      connection.processBuffer();
+
+      await expect(pending).resolves.toBe("漢字😀");
+    });
+
+    it("should convert positions when the server negotiated utf-8", async () => {
+      const { connection, state, written } = createConnection();
+      state.positionEncoding = "utf-8";
+      state.documentText = () => 'const s = "漢"; foo();';
+
+      const pending = connection.sendRequest("textDocument/definition", {
+        textDocument: { uri: "file:///a.ts" },
+        position: { line: 0, character: 15 },
+      });
+      receive(connection, state, {
+        jsonrpc: "2.0",
+        id: 1,
+        result: [
+          {
+            uri: "file:///a.ts",
+            range: {
+              start: { line: 0, character: 17 },
+              end: { line: 0, character: 20 },
+            },
+          },
+        ],
+      });
+
+      expect((written[0] as any).params.position.character).toBe(17);
+      expect((await pending) as any).toEqual([
+        {
+          uri: "file:///a.ts",
+          range: {
+            start: { line: 0, character: 15 },
+            end: { line: 0, character: 18 },
+          },
+        },
+      ]);
+    });
+  });
+}
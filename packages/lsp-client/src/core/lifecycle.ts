@@ -6,13 +6,31 @@ import type {
   InitializeParams,
   InitializeResult,
   ServerCapabilities,
+  ServerStatusParams,
 } from "../protocol/types/index.ts";
 import type { LSPProcessState, LSPClientConfig } from "./state.ts";
 import type { ConnectionHandler } from "./connection.ts";
 import { debug, formatError } from "../utils/debug.ts";
-import { getServerCharacteristics } from "../utils/helpers.ts";
+import {
+  getServerCharacteristics,
+  toWorkspaceFolder,
+} from "../utils/helpers.ts";
+import {
+  STANDARD_TOKEN_MODIFIERS,
+  STANDARD_TOKEN_TYPES,
+} from "../commands/semanticTokens.ts";
+import {
+  SUPPORTED_POSITION_ENCODINGS,
+  negotiatedEncoding,
+} from "../utils/positionEncoding.ts";
+
+// Upper bound for waiting on workspace loading during initialize
+const QUIESCENT_TIMEOUT = 30_000;
 
 export class LifecycleManager {
+  // Set by stop() so an intentional exit is not reported as a crash
+  private stopping = false;
+
   constructor(
     private state: LSPProcessState,
     private connection: ConnectionHandler,
@@ -34,6 +52,14 @@ export class LifecycleManager {
 
     // Store server capabilities
     this.state.serverCapabilities = initResult.capabilities;
+    this.state.positionEncoding = negotiatedEncoding(
+      initResult.capabilities.positionEncoding,
+    );
+    if (this.state.positionEncoding !== "utf-16") {
+      debug(
+        `[lspClient] Server uses ${this.state.positionEncoding} positions; converting`,
+      );
+    }
 
     // Send initialized notification
     this.connection.sendNotification("initialized", {});
@@ -52,12 +78,7 @@ export class LifecycleManager {
       locale: "en",
       rootPath: this.state.rootPath,
       rootUri: `file://${this.state.rootPath}`,
-      workspaceFolders: [
-        {
-          uri: `file://${this.state.rootPath}`,
-          name: this.state.rootPath.split("/").pop() || "workspace",
-        },
-      ],
+      workspaceFolders: this.state.workspaceFolders.map(toWorkspaceFolder),
       capabilities: {
         textDocument: {
           synchronization: {
@@ -69,6 +90,10 @@ export class LifecycleManager {
           publishDiagnostics: {
             relatedInformation: true,
           },
+          diagnostic: {
+            dynamicRegistration: false,
+            relatedDocumentSupport: false,
+          },
           definition: {
             linkSupport: true,
           },
@@ -81,13 +106,94 @@ export class LifecycleManager {
               snippetSupport: true,
             },
           },
+          signatureHelp: {
+            signatureInformation: {
+              documentationFormat: ["markdown", "plaintext"],
+              parameterInformation: {
+                labelOffsetSupport: true,
+              },
+              activeParameterSupport: true,
+            },
+          },
+          semanticTokens: {
+            dynamicRegistration: false,
+            requests: { full: true },
+            tokenTypes: STANDARD_TOKEN_TYPES,
+            tokenModifiers: STANDARD_TOKEN_MODIFIERS,
+            formats: ["relative"],
+            overlappingTokenSupport: false,
+            multilineTokenSupport: false,
+          },
+          inlayHint: {
+            dynamicRegistration: false,
+          },
+          documentLink: {
+            dynamicRegistration: false,
+            tooltipSupport: false,
+          },
+          foldingRange: {
+            dynamicRegistration: false,
+            lineFoldingOnly: true,
+          },
+          codeAction: {
+            dynamicRegistration: false,
+            codeActionLiteralSupport: {
+              codeActionKind: {
+                valueSet: [
+                  "",
+                  "quickfix",
+                  "refactor",
+                  "refactor.extract",
+                  "refactor.inline",
+                  "refactor.rewrite",
+                  "source",
+                  "source.organizeImports",
+                  "source.fixAll",
+                ],
+              },
+            },
+            isPreferredSupport: true,
+            disabledSupport: true,
+            dataSupport: true,
+            resolveSupport: {
+              properties: ["edit"],
+            },
+          },
           documentSymbol: {
             hierarchicalDocumentSymbolSupport: true,
           },
+          callHierarchy: {
+            dynamicRegistration: false,
+          },
+          typeHierarchy: {
+            dynamicRegistration: false,
+          },
         },
         workspace: {
           workspaceFolders: true,
           configuration: true,
+          applyEdit: true,
+          workspaceEdit: {
+            documentChanges: true,
+          },
+          executeCommand: {
+            dynamicRegistration: false,
+          },
+          fileOperations: {
+            dynamicRegistration: false,
+            willRename: true,
+            didRename: true,
+          },
+        },
+        window: {
+          workDoneProgress: true,
+        },
+        general: {
+          positionEncodings: SUPPORTED_POSITION_ENCODINGS,
+        },
+        experimental: {
+          // rust-analyzer: report workspace loading via experimental/serverStatus
+          serverStatusNotification: true,
         },
       },
       initializationOptions: this.config.initializationOptions,
@@ -104,6 +210,31 @@ export class LifecycleManager {
     await new Promise((resolve) =>
       setTimeout(resolve, characteristics.readinessCheckTimeout),
     );
+
+    // Servers reporting status (rust-analyzer) tell us when loading is done
+    if (this.state.serverStatus && !this.state.serverStatus.quiescent) {
+      await this.waitForQuiescent(QUIESCENT_TIMEOUT);
+    }
+  }
+
+  private waitForQuiescent(timeout: number): Promise<void> {
+    return new Promise((resolve) => {
+      const onStatus = (status: ServerStatusParams) => {
+        if (status.quiescent) {
+          done();
+        }
+      };
+      const done = () => {
+        clearTimeout(timer);
+        this.state.eventEmitter.off("serverStatus", onStatus);
+        resolve();
+      };
+      const timer = setTimeout(() => {
+        debug("[lspClient] Server still loading, continuing anyway");
+        done();
+      }, timeout);
+      this.state.eventEmitter.on("serverStatus", onStatus);
+    });
   }
 
   async start(): Promise<void> {
@@ -139,7 +270,7 @@ export class LifecycleManager {
     });
 
     this.state.process.stdout?.on("data", (data: Buffer) => {
-      this.state.buffer += data.toString();
+      this.state.buffer = Buffer.concat([this.state.buffer, data]);
       this.connection.processBuffer();
     });
 
@@ -164,11 +295,21 @@ export class LifecycleManager {
       this.state.process?.removeAllListeners("exit");
       this.state.process?.removeAllListeners("error");
 
-      this.state.process?.on("exit", (code) => {
+      this.state.process?.on("exit", (code, signal) => {
         this.state.process = null;
         if (code !== 0 && code !== null) {
           debug(`[LSP] Server exited with code ${code}`);
         }
+        // Nothing will answer pending requests; fail them now instead of
+        // letting each one run into its timeout
+        this.rejectPendingRequests(
+          new Error(
+            `LSP server exited (${signal ?? `code ${code}`}) before responding`,
+          ),
+        );
+        if (!this.stopping) {
+          this.state.eventEmitter.emit("serverExit", { code, signal });
+        }
       });
 
       this.state.process?.on("error", (error) => {
@@ -192,7 +333,16 @@ export class LifecycleManager {
     }
   }
 
+  private rejectPendingRequests(error: Error): void {
+    for (const handler of this.state.responseHandlers.values()) {
+      clearTimeout(handler.timer);
+      handler.reject(error);
+    }
+    this.state.responseHandlers.clear();
+  }
+
   async stop(): Promise<void> {
+    this.stopping = true;
     if (this.state.process) {
       // Send shutdown request
       try {
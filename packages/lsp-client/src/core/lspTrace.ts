@@ -0,0 +1,298 @@
+/**
+ * Capture and replay the JSON-RPC traffic with a language server
+ *
+ * With `--trace-lsp <file>` every message between lsmcp and the server is
+ * appended to a JSON lines file. A captured trace can be played back by
+ * `createReplayProcess`, which stands in for the server process: each message
+ * the client sends is matched against the next recorded one and the server
+ * messages recorded after it are sent back. Request ids are remapped, so a
+ * client that numbers its requests differently still gets its responses.
+ */
+
+import { EventEmitter } from "events";
+import { appendFileSync, readFileSync } from "fs";
+import type { ChildProcess } from "child_process";
+import { FrameReader, frame, type MessageRewriter } from "./messageRewriter.ts";
+import { lspError } from "../utils/lsp-logger.ts";
+
+export interface TraceEntry {
+  time: string;
+  /** Server the message belongs to when several share one trace file */
+  server?: string;
+  /** "send" is client to server, "receive" server to client */
+  direction: "send" | "receive";
+  message: TraceMessage;
+}
+
+interface TraceMessage {
+  id?: number | string | null;
+  method?: string;
+  [key: string]: unknown;
+}
+
+/**
+ * Record every message passing through a rewriter, unchanged
+ * Pass the result to rewriteProcessMessages to trace a server process.
+ */
+export function createTraceRecorder(
+  file: string,
+  server?: string,
+): MessageRewriter {
+  let failed = false;
+  const record = (direction: TraceEntry["direction"], content: string) => {
+    if (failed) {
+      return content;
+    }
+    let message: TraceMessage;
+    try {
+      message = JSON.parse(content);
+    } catch {
+      message = { unparsed: content };
+    }
+    const entry: TraceEntry = {
+      time: new Date().toISOString(),
+      server,
+      direction,
+      message,
+    };
+    try {
+      appendFileSync(file, JSON.stringify(entry) + "\n");
+    } catch (error) {
+      // Tracing must never break the session
+      failed = true;
+      lspError(`Cannot write LSP trace to ${file}:`, error);
+    }
+    return content;
+  };
+  return {
+    toServer: (content) => record("send", content),
+    fromServer: (content) => record("receive", content),
+  };
+}
+
+/**
+ * Parse a JSON lines trace, optionally keeping one server's messages
+ */
+export function parseTrace(text: string, server?: string): TraceEntry[] {
+  return text
+    .split("\n")
+    .filter((line) => line.trim())
+    .map((line) => JSON.parse(line) as TraceEntry)
+    .filter((entry) => server === undefined || entry.server === server);
+}
+
+export function loadTrace(file: string, server?: string): TraceEntry[] {
+  return parseTrace(readFileSync(file, "utf-8"), server);
+}
+
+export interface ReplayOptions {
+  /** Called for client messages the trace has no counterpart for */
+  onUnexpected?: (message: TraceMessage) => void;
+}
+
+function isResponse(message: TraceMessage): boolean {
+  return message.method === undefined && message.id !== undefined;
+}
+
+function matches(recorded: TraceMessage, sent: TraceMessage): boolean {
+  if (sent.method !== undefined) {
+    return recorded.method === sent.method;
+  }
+  // Responses to server requests keep the server's ids
+  return isResponse(recorded) && recorded.id === sent.id;
+}
+
+/**
+ * Stand-in for a server process that answers from a captured trace
+ * Messages are answered as soon as the client sends them; recorded timing
+ * is not reproduced. Requests missing from the trace get an error response
+ * instead of hanging until the client times out.
+ */
+export function createReplayProcess(
+  entries: TraceEntry[],
+  options: ReplayOptions = {},
+): ChildProcess {
+  const reader = new FrameReader();
+  const stdout = new EventEmitter();
+  const process = new EventEmitter() as EventEmitter & Record<string, any>;
+  // Recorded request id -> id the client used this time
+  const requestIds = new Map<unknown, unknown>();
+  let cursor = 0;
+
+  const emit = (message: TraceMessage) => {
+    const content = JSON.stringify(message);
+    setImmediate(() => stdout.emit("data", Buffer.from(frame(content))));
+  };
+
+  const release = (entry: TraceEntry) => {
+    const message = entry.message;
+    if (!isResponse(message)) {
+      emit(message);
+    } else if (requestIds.has(message.id)) {
+      emit({ ...message, id: requestIds.get(message.id) as number });
+    }
+    // Responses to requests the client did not repeat are dropped
+  };
+
+  const handle = (sent: TraceMessage) => {
+    let index = cursor;
+    while (
+      index < entries.length &&
+      !(
+        entries[index].direction === "send" &&
+        matches(entries[index].message, sent)
+      )
+    ) {
+      index++;
+    }
+    if (index === entries.length) {
+      options.onUnexpected?.(sent);
+      if (sent.method !== undefined && sent.id !== undefined) {
+        emit({
+          jsonrpc: "2.0",
+          id: sent.id,
+          error: {
+            code: -32603,
+            message: `No recorded response for ${sent.method}`,
+          },
+        });
+      }
+      return;
+    }
+
+    const recorded = entries[index].message;
+    if (sent.method !== undefined && sent.id !== undefined) {
+      requestIds.set(recorded.id, sent.id);
+    }
+    // Server messages between skipped entries and up to the next client
+    // message belong to what the client has sent so far
+    for (const entry of entries.slice(cursor, index)) {
+      if (entry.direction === "receive") {
+        release(entry);
+      }
+    }
+    cursor = index + 1;
+    while (cursor < entries.length && entries[cursor].direction === "receive") {
+      release(entries[cursor++]);
+    }
+    if (sent.method === "exit") {
+      setImmediate(() => process.emit("exit", 0, null));
+    }
+  };
+
+  process.stdin = {
+    write: (data: string | Buffer) => {
+      for (const content of reader.push(data)) {
+        handle(JSON.parse(content));
+      }
+      return true;
+    },
+  };
+  process.stdout = stdout;
+  process.stderr = null;
+  process.pid = undefined;
+  process.killed = false;
+  process.kill = () => {
+    process.killed = true;
+    setImmediate(() => process.emit("exit", null, "SIGTERM"));
+    return true;
+  };
+  return process as unknown as ChildProcess;
+}
+
+if (import.meta.vitest) {
+  const { describe, it, expect, vi } = import.meta.vitest;
+  const { mkdtempSync } = await import("fs");
+  const { tmpdir } = await import("os");
+  const { join } = await import("path");
+  const { PassThrough } = await import("stream");
+  const { rewriteProcessMessages } = await import("./messageRewriter.ts");
+  const { ConnectionHandler } = await import("./connection.ts");
+  const { createInitialState } = await import("./state.ts");
+
+  const entry = (
+    direction: TraceEntry["direction"],
+    message: TraceMessage,
+  ): TraceEntry => ({ time: "2025-01-01T00:00:00.000Z", direction, message });
+
+  function connect(process: ChildProcess) {
+    const state = createInitialState({ process, rootPath: "/tmp" });
+    const connection = new ConnectionHandler(state);
+    process.stdout!.on("data", (data: Buffer) => {
+      state.buffer = Buffer.concat([state.buffer, data]);
+      connection.processBuffer();
+    });
+    return { connection, state };
+  }
+
+  describe("createTraceRecorder", () => {
+    it("should record both directions with the server name", () => {
+      const file = join(mkdtempSync(join(tmpdir(), "lsp-trace-")), "t.jsonl");
+      const child = new EventEmitter() as EventEmitter & Record<string, any>;
+      child.stdin = new PassThrough();
+      child.stdout = new PassThrough();
+      const process = rewriteProcessMessages(
+        child as unknown as ChildProcess,
+        createTraceRecorder(file, "tsgo"),
+      );
+
+      process.stdin!.write(
+        frame('{"jsonrpc":"2.0","id":1,"method":"shutdown"}'),
+      );
+      child.stdout.emit("data", Buffer.from(frame('{"id":1,"result":null}')));
+
+      const trace = loadTrace(file, "tsgo");
+      expect(trace.map((e) => [e.direction, e.message])).toEqual([
+        ["send", { jsonrpc: "2.0", id: 1, method: "shutdown" }],
+        ["receive", { id: 1, result: null }],
+      ]);
+      expect(loadTrace(file, "gopls")).toEqual([]);
+    });
+  });
+
+  describe("createReplayProcess", () => {
+    const trace = [
+      entry("send", { jsonrpc: "2.0", id: 1, method: "initialize" }),
+      entry("receive", {
+        jsonrpc: "2.0",
+        id: 1,
+        result: { capabilities: { hoverProvider: true } },
+      }),
+      entry("send", { jsonrpc: "2.0", method: "initialized" }),
+      entry("send", { jsonrpc: "2.0", id: 2, method: "textDocument/hover" }),
+      entry("receive", {
+        jsonrpc: "2.0",
+        id: 2,
+        result: { contents: "const a: 1" },
+      }),
+    ];
+
+    it("should answer requests with remapped ids", async () => {
+      const { connection, state } = connect(createReplayProcess(trace));
+      // The live client numbers its requests differently
+      state.messageId = 40;
+
+      await expect(connection.sendRequest("initialize", {})).resolves.toEqual({
+        capabilities: { hoverProvider: true },
+      });
+      connection.sendNotification("initialized", {});
+      await expect(
+        connection.sendRequest("textDocument/hover", {}),
+      ).resolves.toEqual({ contents: "const a: 1" });
+    });
+
+    it("should reject requests missing from the trace", async () => {
+      const onUnexpected = vi.fn();
+      const { connection } = connect(
+        createReplayProcess(trace, { onUnexpected }),
+      );
+
+      await expect(
+        connection.sendRequest("textDocument/references", {}),
+      ).rejects.toThrow("No recorded response for textDocument/references");
+      expect(onUnexpected).toHaveBeenCalledWith(
+        expect.objectContaining({ method: "textDocument/references" }),
+      );
+    });
+  });
+}
@@ -0,0 +1,148 @@
+/**
+ * Rewrite LSP messages between the client and a server process
+ *
+ * Used when the server sees a different file system than lsmcp, e.g. a server
+ * running in a container with the workspace bind-mounted elsewhere. Messages
+ * are reframed after rewriting because their byte length changes.
+ */
+
+import { EventEmitter } from "events";
+import type { ChildProcess } from "child_process";
+
+export interface MessageRewriter {
+  /** Rewrite the JSON content of a message sent to the server */
+  toServer(content: string): string;
+  /** Rewrite the JSON content of a message received from the server */
+  fromServer(content: string): string;
+}
+
+/**
+ * Split a byte stream into LSP message contents
+ */
+export class FrameReader {
+  private buffer = Buffer.alloc(0);
+
+  push(chunk: Buffer | string): string[] {
+    this.buffer = Buffer.concat([
+      this.buffer,
+      typeof chunk === "string" ? Buffer.from(chunk, "utf-8") : chunk,
+    ]);
+    const messages: string[] = [];
+    for (;;) {
+      const headerEnd = this.buffer.indexOf("\r\n\r\n");
+      if (headerEnd === -1) {
+        break;
+      }
+      const header = this.buffer.subarray(0, headerEnd).toString("ascii");
+      const match = header.match(/Content-Length: *(\d+)/i);
+      if (!match) {
+        // Not a header we understand; drop it rather than stall the stream
+        this.buffer = this.buffer.subarray(headerEnd + 4);
+        continue;
+      }
+      const start = headerEnd + 4;
+      const end = start + Number(match[1]);
+      if (this.buffer.length < end) {
+        break;
+      }
+      messages.push(this.buffer.subarray(start, end).toString("utf-8"));
+      this.buffer = this.buffer.subarray(end);
+    }
+    return messages;
+  }
+}
+
+export function frame(content: string): string {
+  return `Content-Length: ${Buffer.byteLength(content, "utf-8")}\r\n\r\n${content}`;
+}
+
+/**
+ * Wrap a server process so every message passes through a rewriter
+ * The result can be passed to the LSP client in place of the process.
+ */
+export function rewriteProcessMessages(
+  child: ChildProcess,
+  rewriter: MessageRewriter,
+): ChildProcess {
+  const outgoing = new FrameReader();
+  const incoming = new FrameReader();
+  const stdout = new EventEmitter();
+  const process = new EventEmitter() as EventEmitter & Record<string, any>;
+
+  process.stdin = {
+    write: (data: string | Buffer) => {
+      for (const content of outgoing.push(data)) {
+        child.stdin?.write(frame(rewriter.toServer(content)));
+      }
+      return true;
+    },
+  };
+  process.stdout = stdout;
+  process.stderr = child.stderr;
+  process.kill = (signal?: NodeJS.Signals) => child.kill(signal);
+  Object.defineProperty(process, "pid", { get: () => child.pid });
+  Object.defineProperty(process, "killed", { get: () => child.killed });
+
+  child.stdout?.on("data", (data: Buffer) => {
+    for (const content of incoming.push(data)) {
+      stdout.emit("data", Buffer.from(frame(rewriter.fromServer(content))));
+    }
+  });
+  child.on("exit", (code, signal) => process.emit("exit", code, signal));
+  child.on("error", (error) => process.emit("error", error));
+  return process as unknown as ChildProcess;
+}
+
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+  const { PassThrough } = await import("stream");
+
+  function fakeChild() {
+    const child = new EventEmitter() as EventEmitter & Record<string, any>;
+    child.stdin = new PassThrough();
+    child.stdout = new PassThrough();
+    child.stderr = new PassThrough();
+    child.pid = 42;
+    child.killed = false;
+    child.kill = () => true;
+    return child as unknown as ChildProcess;
+  }
+
+  const rewriter: MessageRewriter = {
+    toServer: (content) => content.replaceAll("/home/dev/app", "/workspace"),
+    fromServer: (content) => content.replaceAll("/workspace", "/home/dev/app"),
+  };
+
+  describe("rewriteProcessMessages", () => {
+    it("should rewrite outgoing messages and fix their length", () => {
+      const child = fakeChild();
+      const process = rewriteProcessMessages(child, rewriter);
+      const written: string[] = [];
+      child.stdin!.on("data", (data) => written.push(data.toString()));
+
+      process.stdin!.write(frame('{"uri":"file:///home/dev/app/main.go"}'));
+
+      const content = '{"uri":"file:///workspace/main.go"}';
+      expect(written.join("")).toBe(
+        `Content-Length: ${content.length}\r\n\r\n${content}`,
+      );
+    });
+
+    it("should reassemble split incoming messages before rewriting", () => {
+      const child = fakeChild();
+      const process = rewriteProcessMessages(child, rewriter);
+      const received: string[] = [];
+      process.stdout!.on("data", (data) => received.push(data.toString()));
+
+      const message = frame('{"uri":"file:///workspace/é.go"}');
+      child.stdout!.emit("data", Buffer.from(message.slice(0, 30)));
+      expect(received).toEqual([]);
+      child.stdout!.emit("data", Buffer.from(message.slice(30)));
+
+      expect(received).toEqual([
+        frame('{"uri":"file:///home/dev/app/é.go"}'),
+      ]);
+      expect(process.pid).toBe(42);
+    });
+  });
+}
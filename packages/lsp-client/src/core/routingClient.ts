@@ -0,0 +1,471 @@
+/**
+ * Routing over several language servers in one workspace
+ *
+ * Polyglot repositories (e.g. a Go backend with a TypeScript frontend) need
+ * one server per language, and servers without workspaceFolders support need
+ * one process per workspace root. The routing client looks like a single LSP
+ * client and forwards each document call to the server that owns the file's
+ * extension and root; workspace-wide calls are sent to every server and
+ * merged.
+ */
+
+import { extname, sep } from "path";
+import { fileURLToPath } from "url";
+import type { InternalLSPClient } from "./client.ts";
+
+export interface ClientRoute<T extends InternalLSPClient = InternalLSPClient> {
+  /** Preset or adapter id, for logging and tool output */
+  id: string;
+  client: T;
+  /** File extensions handled by this server (e.g. [".go"]) */
+  extensions: string[];
+  /** Workspace root served by this process (any root when omitted) */
+  root?: string;
+}
+
+interface RoutingMethods<T extends InternalLSPClient> {
+  routes: ClientRoute<T>[];
+  routeFor(uri: string): ClientRoute<T>;
+  /** Add a server, e.g. for a workspace root added at runtime */
+  addRoute(route: ClientRoute<T>): void;
+  /** Remove a server; its client is not stopped */
+  removeRoute(client: T): void;
+  /** Swap in a restarted server for every route served by `previous` */
+  replaceClient(previous: T, next: T): void;
+}
+
+// Methods whose first argument is a document URI
+const URI_METHODS = [
+  "openDocument",
+  "closeDocument",
+  "updateDocument",
+  "saveDocument",
+  "isDocumentOpen",
+  "findReferences",
+  "getDefinition",
+  "getHover",
+  "getDiagnostics",
+  "pullDiagnostics",
+  "getDocumentSymbols",
+  "getCompletion",
+  "getSignatureHelp",
+  "getSemanticTokens",
+  "getInlayHints",
+  "getDocumentLinks",
+  "getFoldingRanges",
+  "getCodeActions",
+  "formatDocument",
+  "formatRange",
+  "prepareRename",
+  "rename",
+  "prepareCallHierarchy",
+  "prepareTypeHierarchy",
+  "waitForDiagnostics",
+] as const;
+
+// Methods taking an item with a uri (call/type hierarchy items)
+const ITEM_METHODS = [
+  "getIncomingCalls",
+  "getOutgoingCalls",
+  "getSupertypes",
+  "getSubtypes",
+] as const;
+
+function uriPath(uri: string): string {
+  try {
+    return uri.startsWith("file:") ? fileURLToPath(uri) : uri;
+  } catch {
+    return uri;
+  }
+}
+
+function isInRoot(path: string, root: string | undefined): boolean {
+  return !root || path === root || path.startsWith(root + sep);
+}
+
+/**
+ * Create a client that dispatches to one of several language servers by file
+ * extension and workspace root. The first route is the primary server: it
+ * handles files no route claims and calls that carry no document.
+ */
+export function createRoutingClient<T extends InternalLSPClient>(
+  initialRoutes: ClientRoute<T>[],
+): T & RoutingMethods<T> {
+  if (initialRoutes.length === 0) {
+    throw new Error("createRoutingClient requires at least one route");
+  }
+  const routes = [...initialRoutes];
+  const [primary] = routes;
+  const clients = () => Array.from(new Set(routes.map((r) => r.client)));
+
+  // A server for the file's extension wins over one that merely covers its
+  // root; among those the most specific root wins, then the earlier route
+  const routeFor = (uri: string): ClientRoute<T> => {
+    const path = uriPath(uri);
+    const extension = extname(path).toLowerCase();
+    const inRoot = routes.filter((route) => isInRoot(path, route.root));
+    const byExtension = inRoot.filter((route) =>
+      route.extensions.some((e) => e.toLowerCase() === extension),
+    );
+    const candidates = byExtension.length > 0 ? byExtension : inRoot;
+    let best: ClientRoute<T> | undefined;
+    for (const route of candidates) {
+      if (!best || (route.root?.length ?? 0) > (best.root?.length ?? 0)) {
+        best = route;
+      }
+    }
+    return best ?? primary;
+  };
+
+  // Completion items and code actions are resolved by the server that
+  // produced them, which is the one that served the last document call
+  let lastClient: T = primary.client;
+  const clientFor = (uri: unknown): T => {
+    lastClient =
+      typeof uri === "string" ? routeFor(uri).client : primary.client;
+    return lastClient;
+  };
+
+  const router = Object.create(primary.client) as T & RoutingMethods<T>;
+  router.routes = routes;
+  router.routeFor = routeFor;
+  // Listeners registered through the router, replayed on servers that join
+  // later (added roots, restarts)
+  const listeners: [string, (...args: any[]) => void][] = [];
+  const attachListeners = (client: T) => {
+    for (const [event, listener] of listeners) {
+      client.on(event as "diagnostics", listener);
+    }
+  };
+
+  router.addRoute = (route) => {
+    if (!clients().includes(route.client)) {
+      attachListeners(route.client);
+    }
+    routes.push(route);
+  };
+  router.replaceClient = (previous, next) => {
+    for (const route of routes) {
+      if (route.client === previous) {
+        route.client = next;
+      }
+    }
+    if (lastClient === previous) {
+      lastClient = next;
+    }
+    // Calls the router does not forward explicitly go to the primary
+    if (primary.client === next) {
+      Object.setPrototypeOf(router, next);
+    }
+    attachListeners(next);
+  };
+  router.removeRoute = (client) => {
+    if (client === primary.client) {
+      throw new Error("The primary server cannot be removed");
+    }
+    for (let i = routes.length - 1; i >= 0; i--) {
+      if (routes[i].client === client) {
+        routes.splice(i, 1);
+      }
+    }
+  };
+
+  for (const method of URI_METHODS) {
+    (router as any)[method] = (uri: string, ...args: unknown[]) =>
+      (clientFor(uri) as any)[method](uri, ...args);
+  }
+  for (const method of ITEM_METHODS) {
+    (router as any)[method] = (item: { uri: string }, ...args: unknown[]) =>
+      (clientFor(item.uri) as any)[method](item, ...args);
+  }
+  router.resolveCompletionItem = (item) =>
+    lastClient.resolveCompletionItem(item);
+  router.resolveCodeAction = (action) => lastClient.resolveCodeAction(action);
+
+  router.start = async () => {
+    await Promise.all(clients().map((client) => client.start()));
+  };
+  router.stop = async () => {
+    await Promise.all(clients().map((client) => client.stop()));
+  };
+  router.isInitialized = () => clients().every((c) => c.isInitialized());
+  // Results of any server may point into the file
+  router.fileChanged = (uri) => {
+    for (const client of clients()) {
+      client.fileChanged(uri);
+    }
+  };
+  router.supportsFeature = (feature) =>
+    clients().some((client) => client.supportsFeature(feature));
+
+  router.getWorkspaceSymbols = async (query, options) => {
+    const results = await Promise.allSettled(
+      clients().map((client) => client.getWorkspaceSymbols(query, options)),
+    );
+    return results.flatMap((result) =>
+      result.status === "fulfilled" ? (result.value ?? []) : [],
+    );
+  };
+  router.pullWorkspaceDiagnostics = async () => {
+    const results = await Promise.allSettled(
+      clients()
+        .filter((client) => client.getDiagnosticSupport().pullDiagnostics)
+        .map((client) => client.pullWorkspaceDiagnostics()),
+    );
+    return results.flatMap((result) =>
+      result.status === "fulfilled" ? result.value : [],
+    );
+  };
+  router.executeCommand = (command, args) => {
+    const owner =
+      clients().find((client) =>
+        client
+          .getServerCapabilities()
+          ?.executeCommandProvider?.commands.includes(command),
+      ) ?? primary.client;
+    return owner.executeCommand(command, args);
+  };
+  // Renames go to the server owning the first file; for directories that is
+  // the server covering their root
+  router.willRenameFiles = (files) =>
+    clientFor(files[0]?.oldUri).willRenameFiles(files);
+  router.didRenameFiles = (files) =>
+    clientFor(files[0]?.oldUri).didRenameFiles(files);
+  router.sendRequest = (<R>(method: string, params?: unknown) => {
+    const uri = (params as { textDocument?: { uri?: string } } | undefined)
+      ?.textDocument?.uri;
+    return clientFor(uri).sendRequest<R>(method, params);
+  }) as T["sendRequest"];
+
+  router.on = ((event: string, listener: (...args: any[]) => void) => {
+    listeners.push([event, listener]);
+    for (const client of clients()) {
+      client.on(event as "diagnostics", listener);
+    }
+  }) as T["on"];
+  router.off = (event, listener) => {
+    const index = listeners.findIndex(
+      ([e, l]) => e === event && l === listener,
+    );
+    if (index !== -1) {
+      listeners.splice(index, 1);
+    }
+    for (const client of clients()) {
+      client.off(event, listener);
+    }
+  };
+
+  router.getDiagnosticSupport = () => {
+    const support = clients().map((client) => client.getDiagnosticSupport());
+    return {
+      pushDiagnostics: support.some((s) => s.pushDiagnostics),
+      pullDiagnostics: support.some((s) => s.pullDiagnostics),
+    };
+  };
+  // Union of capabilities so tools offered by any server stay available;
+  // the primary server's entries take precedence
+  router.getServerCapabilities = () => {
+    const capabilities = clients()
+      .map((client) => client.getServerCapabilities())
+      .filter((c): c is NonNullable<typeof c> => c !== undefined);
+    if (capabilities.length === 0) {
+      return undefined;
+    }
+    return Object.assign({}, ...capabilities.reverse());
+  };
+
+  return router;
+}
+
+/**
+ * File extensions matched by glob patterns such as "**\/*.go" or
+ * "src/**\/*.{ts,tsx}"
+ */
+export function extensionsFromPatterns(patterns: string[]): string[] {
+  const extensions = new Set<string>();
+  for (const pattern of patterns) {
+    const match = pattern.match(/\*\.(\{[^}]+\}|[\w.+-]+)$/);
+    if (!match) continue;
+    const group = match[1];
+    const names = group.startsWith("{")
+      ? group.slice(1, -1).split(",")
+      : [group];
+    for (const name of names) {
+      extensions.add(`.${name.trim()}`);
+    }
+  }
+  return Array.from(extensions);
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect, vi } = import.meta.vitest;
+
+  function createFakeClient(languageId: string, commands: string[] = []) {
+    return {
+      languageId,
+      start: vi.fn().mockResolvedValue(undefined),
+      stop: vi.fn().mockResolvedValue(undefined),
+      isInitialized: vi.fn().mockReturnValue(true),
+      supportsFeature: vi.fn().mockReturnValue(false),
+      openDocument: vi.fn(),
+      getHover: vi.fn().mockResolvedValue({ contents: languageId }),
+      getIncomingCalls: vi.fn().mockResolvedValue([]),
+      getCompletion: vi.fn().mockResolvedValue([]),
+      resolveCompletionItem: vi.fn(async (item) => item),
+      getWorkspaceSymbols: vi
+        .fn()
+        .mockResolvedValue([{ name: `${languageId}Symbol` }]),
+      executeCommand: vi.fn().mockResolvedValue(languageId),
+      sendRequest: vi.fn().mockResolvedValue(languageId),
+      on: vi.fn(),
+      off: vi.fn(),
+      getDiagnosticSupport: vi
+        .fn()
+        .mockReturnValue({ pushDiagnostics: true, pullDiagnostics: false }),
+      getServerCapabilities: vi.fn().mockReturnValue({
+        hoverProvider: true,
+        executeCommandProvider: { commands },
+      }),
+    } as unknown as InternalLSPClient;
+  }
+
+  function createRoutes() {
+    const go = createFakeClient("gopls", ["gopls.tidy"]);
+    const ts = createFakeClient("typescript");
+    const router = createRoutingClient([
+      { id: "gopls", client: go, extensions: [".go"] },
+      { id: "typescript", client: ts, extensions: [".ts", ".tsx"] },
+    ]);
+    return { go, ts, router };
+  }
+
+  describe("createRoutingClient", () => {
+    it("should route document calls by extension", async () => {
+      const { go, ts, router } = createRoutes();
+
+      router.openDocument("file:///app/web/App.tsx", "export {}");
+      await router.getHover("file:///app/main.go", { line: 0, character: 0 });
+
+      expect(ts.openDocument).toHaveBeenCalledWith(
+        "file:///app/web/App.tsx",
+        "export {}",
+      );
+      expect(go.openDocument).not.toHaveBeenCalled();
+      expect(go.getHover).toHaveBeenCalled();
+      expect(router.routeFor("file:///app/README.md").id).toBe("gopls");
+    });
+
+    it("should route hierarchy items and resolve calls", async () => {
+      const { go, ts, router } = createRoutes();
+
+      await router.getIncomingCalls({ uri: "file:///a.ts" } as any);
+      await router.getCompletion("file:///a.ts", { line: 0, character: 0 });
+      await router.resolveCompletionItem({ label: "x" });
+
+      expect(ts.getIncomingCalls).toHaveBeenCalled();
+      expect(ts.resolveCompletionItem).toHaveBeenCalled();
+      expect(go.resolveCompletionItem).not.toHaveBeenCalled();
+    });
+
+    it("should merge workspace symbols from every server", async () => {
+      const { router } = createRoutes();
+
+      const symbols = await router.getWorkspaceSymbols("Symbol");
+
+      expect(symbols.map((s) => s.name)).toEqual([
+        "goplsSymbol",
+        "typescriptSymbol",
+      ]);
+    });
+
+    it("should send commands and requests to the owning server", async () => {
+      const { router } = createRoutes();
+
+      expect(await router.executeCommand("gopls.tidy")).toBe("gopls");
+      expect(
+        await router.sendRequest("textDocument/foldingRange", {
+          textDocument: { uri: "file:///a.ts" },
+        }),
+      ).toBe("typescript");
+    });
+
+    it("should subscribe listeners on every server", () => {
+      const { go, ts, router } = createRoutes();
+      const listener = () => {};
+
+      router.on("diagnostics", listener);
+
+      expect(go.on).toHaveBeenCalledWith("diagnostics", listener);
+      expect(ts.on).toHaveBeenCalledWith("diagnostics", listener);
+    });
+
+    it("should swap in a restarted client with its listeners", async () => {
+      const { go, router } = createRoutes();
+      const listener = () => {};
+      router.on("diagnostics", listener);
+      const restarted = createFakeClient("gopls");
+
+      router.replaceClient(go, restarted);
+      await router.getHover("file:///app/main.go", { line: 0, character: 0 });
+
+      expect(restarted.getHover).toHaveBeenCalled();
+      expect(go.getHover).not.toHaveBeenCalled();
+      expect(restarted.on).toHaveBeenCalledWith("diagnostics", listener);
+      // Calls that are not forwarded reach the new primary
+      expect(router.languageId).toBe("gopls");
+      expect(Object.getPrototypeOf(router)).toBe(restarted);
+    });
+  });
+
+  describe("createRoutingClient with workspace roots", () => {
+    it("should route files to the server of their root", async () => {
+      const main = createFakeClient("gopls");
+      const other = createFakeClient("gopls");
+      const ts = createFakeClient("typescript");
+      const router = createRoutingClient([
+        { id: "gopls", client: main, extensions: [".go"], root: "/work/api" },
+        { id: "typescript", client: ts, extensions: [".ts"] },
+      ]);
+      router.addRoute({
+        id: "gopls",
+        client: other,
+        extensions: [".go"],
+        root: "/work/worker",
+      });
+
+      expect(router.routeFor("file:///work/worker/main.go").client).toBe(
+        other,
+      );
+      expect(router.routeFor("file:///work/api/main.go").client).toBe(main);
+      // Extension match wins over root match
+      expect(router.routeFor("file:///work/worker/web/a.ts").client).toBe(ts);
+      // Files outside every root fall back to the primary server
+      expect(router.routeFor("file:///tmp/x.go").client).toBe(main);
+
+      router.removeRoute(other);
+      expect(router.routeFor("file:///work/worker/main.go").client).toBe(main);
+      expect(() => router.removeRoute(main)).toThrow();
+    });
+
+    it("should not run workspace calls twice for a shared client", async () => {
+      const go = createFakeClient("gopls");
+      const router = createRoutingClient([
+        { id: "gopls", client: go, extensions: [".go"], root: "/a" },
+        { id: "gopls", client: go, extensions: [".go"], root: "/b" },
+      ]);
+
+      await router.getWorkspaceSymbols("x");
+
+      expect(go.getWorkspaceSymbols).toHaveBeenCalledTimes(1);
+    });
+  });
+
+  describe("extensionsFromPatterns", () => {
+    it("should read extensions from glob patterns", () => {
+      expect(
+        extensionsFromPatterns(["**/*.go", "src/**/*.{ts,tsx}", "Makefile"]),
+      ).toEqual([".go", ".ts", ".tsx"]);
+    });
+  });
+}
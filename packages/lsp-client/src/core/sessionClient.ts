@@ -0,0 +1,195 @@
+/**
+ * Session-scoped views over a shared LSP client
+ *
+ * Several MCP sessions can talk to one language server. Each session gets a
+ * client whose open/close calls are reference counted, so one session closing
+ * a document does not pull it away from another session still using it.
+ */
+
+import type { LSPClient } from "@internal/types";
+
+interface SharedDocument {
+  sessions: Set<string>;
+  text: string;
+}
+
+export class SharedDocumentTracker {
+  private documents = new Map<string, SharedDocument>();
+
+  constructor(private client: LSPClient) {}
+
+  open(
+    sessionId: string,
+    uri: string,
+    text: string,
+    languageId?: string,
+  ): void {
+    const document = this.documents.get(uri);
+    if (!document) {
+      this.client.openDocument(uri, text, languageId);
+      this.documents.set(uri, {
+        sessions: new Set([sessionId]),
+        text,
+      });
+      return;
+    }
+
+    document.sessions.add(sessionId);
+    if (document.text !== text) {
+      // The file changed on disk since another session opened it
+      this.update(uri, text);
+    }
+  }
+
+  /**
+   * Without a version the shared client numbers the change itself
+   */
+  update(uri: string, text: string, version?: number): void {
+    const document = this.documents.get(uri);
+    if (!document) {
+      throw new Error(`Document ${uri} is not open`);
+    }
+    document.text = text;
+    this.client.updateDocument(uri, text, version);
+  }
+
+  close(sessionId: string, uri: string): void {
+    const document = this.documents.get(uri);
+    if (!document || !document.sessions.delete(sessionId)) {
+      return;
+    }
+    if (document.sessions.size === 0) {
+      this.documents.delete(uri);
+      this.client.closeDocument(uri);
+    }
+  }
+
+  /**
+   * Release every document held by a session
+   */
+  closeSession(sessionId: string): void {
+    for (const [uri, document] of this.documents) {
+      if (document.sessions.has(sessionId)) {
+        this.close(sessionId, uri);
+      }
+    }
+  }
+
+  isOpen(sessionId: string, uri: string): boolean {
+    return this.documents.get(uri)?.sessions.has(sessionId) ?? false;
+  }
+
+  getOpenDocuments(sessionId?: string): string[] {
+    return Array.from(this.documents.entries())
+      .filter(([, document]) => !sessionId || document.sessions.has(sessionId))
+      .map(([uri]) => uri);
+  }
+}
+
+/**
+ * Create a client for one session that shares the underlying connection.
+ * Everything except document lifecycle is delegated to the shared client.
+ */
+export function createSessionClient<T extends LSPClient>(
+  client: T,
+  tracker: SharedDocumentTracker,
+  sessionId: string,
+): T {
+  const sessionClient = Object.create(client) as T;
+  sessionClient.openDocument = (uri, text, languageId) =>
+    tracker.open(sessionId, uri, text, languageId);
+  sessionClient.updateDocument = (uri, text, version) =>
+    tracker.update(uri, text, version);
+  sessionClient.closeDocument = (uri) => tracker.close(sessionId, uri);
+  sessionClient.isDocumentOpen = (uri) => tracker.isOpen(sessionId, uri);
+  return sessionClient;
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect, vi } = import.meta.vitest;
+
+  function createFakeClient() {
+    return {
+      openDocument: vi.fn(),
+      updateDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      isDocumentOpen: vi.fn(),
+      getHover: vi.fn().mockResolvedValue(null),
+    } as unknown as LSPClient;
+  }
+
+  describe("SharedDocumentTracker", () => {
+    it("should open a document once and close it after the last session", () => {
+      const client = createFakeClient();
+      const tracker = new SharedDocumentTracker(client);
+
+      tracker.open("a", "file:///x.go", "package x");
+      tracker.open("b", "file:///x.go", "package x");
+      expect(client.openDocument).toHaveBeenCalledTimes(1);
+
+      tracker.close("a", "file:///x.go");
+      expect(client.closeDocument).not.toHaveBeenCalled();
+      expect(tracker.isOpen("b", "file:///x.go")).toBe(true);
+
+      tracker.close("b", "file:///x.go");
+      expect(client.closeDocument).toHaveBeenCalledWith("file:///x.go");
+    });
+
+    it("should send didChange when a session opens newer content", () => {
+      const client = createFakeClient();
+      const tracker = new SharedDocumentTracker(client);
+
+      tracker.open("a", "file:///x.go", "package x");
+      tracker.open("b", "file:///x.go", "package y");
+
+      expect(client.updateDocument).toHaveBeenCalledWith(
+        "file:///x.go",
+        "package y",
+        undefined,
+      );
+    });
+
+    it("should release all documents of a closed session", () => {
+      const client = createFakeClient();
+      const tracker = new SharedDocumentTracker(client);
+
+      tracker.open("a", "file:///x.go", "x");
+      tracker.open("a", "file:///y.go", "y");
+      tracker.open("b", "file:///y.go", "y");
+      tracker.closeSession("a");
+
+      expect(client.closeDocument).toHaveBeenCalledTimes(1);
+      expect(client.closeDocument).toHaveBeenCalledWith("file:///x.go");
+      expect(tracker.getOpenDocuments()).toEqual(["file:///y.go"]);
+      expect(tracker.getOpenDocuments("a")).toEqual([]);
+    });
+  });
+
+  describe("createSessionClient", () => {
+    it("should route document calls through the tracker", async () => {
+      const client = createFakeClient();
+      const tracker = new SharedDocumentTracker(client);
+      const first = createSessionClient(client, tracker, "a");
+      const second = createSessionClient(client, tracker, "b");
+
+      first.openDocument("file:///x.go", "x");
+      second.openDocument("file:///x.go", "x");
+      first.updateDocument("file:///x.go", "y", 7);
+      expect(client.updateDocument).toHaveBeenCalledWith(
+        "file:///x.go",
+        "y",
+        7,
+      );
+      first.closeDocument("file:///x.go");
+
+      expect(first.isDocumentOpen("file:///x.go")).toBe(false);
+      expect(second.isDocumentOpen("file:///x.go")).toBe(true);
+      expect(client.closeDocument).not.toHaveBeenCalled();
+
+      // Other methods are shared
+      await second.getHover("file:///x.go", { line: 0, character: 0 });
+      expect(client.getHover).toHaveBeenCalled();
+    });
+  });
+}
@@ -0,0 +1,204 @@
+/**
+ * Socket transport for language servers that are already running
+ *
+ * Servers started by an IDE or running in a container can listen on a TCP
+ * port or a Unix socket (e.g. `gopls -listen=:37374`). The connection is
+ * wrapped so it looks like a spawned process to the LSP client: messages are
+ * written to stdin and read from stdout, and closing the socket is reported as
+ * the process exiting.
+ */
+
+import { EventEmitter } from "events";
+import { connect, type Socket } from "net";
+import type { ChildProcess } from "child_process";
+
+export type ServerAddress =
+  | { kind: "tcp"; host: string; port: number }
+  | { kind: "unix"; path: string };
+
+/**
+ * Parse `tcp://host:port`, `unix:///path/to/socket` or a bare `host:port`
+ */
+export function parseServerAddress(address: string): ServerAddress {
+  if (address.startsWith("unix://")) {
+    const path = address.slice("unix://".length);
+    if (!path) {
+      throw new Error(`Missing socket path in '${address}'`);
+    }
+    return { kind: "unix", path };
+  }
+
+  const hostPort = address.startsWith("tcp://")
+    ? address.slice("tcp://".length)
+    : address;
+  const match = hostPort.match(/^(?:\[([^\]]+)\]|([^:/]*)):(\d+)\/?$/);
+  if (!match) {
+    throw new Error(
+      `Invalid server address '${address}'. Use tcp://host:port or unix:///path/to/socket`,
+    );
+  }
+  const port = Number(match[3]);
+  if (port < 1 || port > 65535) {
+    throw new Error(`Invalid port in '${address}'`);
+  }
+  return { kind: "tcp", host: match[1] ?? (match[2] || "127.0.0.1"), port };
+}
+
+export function formatServerAddress(address: ServerAddress): string {
+  return address.kind === "unix"
+    ? `unix://${address.path}`
+    : `tcp://${address.host}:${address.port}`;
+}
+
+/**
+ * Connect to a listening language server
+ * The result can be passed to the LSP client in place of a spawned process.
+ */
+export function connectToServer(
+  address: string,
+  options: { timeout?: number } = {},
+): Promise<ChildProcess> {
+  const target = parseServerAddress(address);
+  const timeout = options.timeout ?? 10_000;
+
+  return new Promise((resolve, reject) => {
+    const socket =
+      target.kind === "unix"
+        ? connect(target.path)
+        : connect(target.port, target.host);
+    const timer = setTimeout(() => {
+      socket.destroy();
+      reject(
+        new Error(
+          `Timed out connecting to ${formatServerAddress(target)} after ${timeout}ms`,
+        ),
+      );
+    }, timeout);
+
+    socket.once("connect", () => {
+      clearTimeout(timer);
+      socket.off("error", onConnectError);
+      resolve(socketProcess(socket));
+    });
+    const onConnectError = (error: Error) => {
+      clearTimeout(timer);
+      reject(
+        new Error(
+          `Cannot connect to language server at ${formatServerAddress(target)}: ${error.message}`,
+        ),
+      );
+    };
+    socket.once("error", onConnectError);
+  });
+}
+
+/**
+ * Present a connected socket with the parts of the ChildProcess interface
+ * the LSP client uses
+ */
+function socketProcess(socket: Socket): ChildProcess {
+  const process = new EventEmitter() as EventEmitter & {
+    stdin: Socket;
+    stdout: Socket;
+    stderr: null;
+    pid: undefined;
+    killed: boolean;
+    kill: () => boolean;
+  };
+  process.stdin = socket;
+  process.stdout = socket;
+  process.stderr = null;
+  process.pid = undefined;
+  process.killed = false;
+  process.kill = () => {
+    process.killed = true;
+    socket.destroy();
+    return true;
+  };
+
+  socket.on("error", (error) => process.emit("error", error));
+  socket.on("close", (hadError) => {
+    // A server closing the connection is an exit; one we closed is a kill
+    process.emit(
+      "exit",
+      process.killed ? null : hadError ? 1 : 0,
+      process.killed ? "SIGTERM" : null,
+    );
+  });
+  return process as unknown as ChildProcess;
+}
+
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+  const { createServer } = await import("net");
+
+  describe("parseServerAddress", () => {
+    it("should parse tcp and unix addresses", () => {
+      expect(parseServerAddress("tcp://localhost:37374")).toEqual({
+        kind: "tcp",
+        host: "localhost",
+        port: 37374,
+      });
+      expect(parseServerAddress(":9257")).toEqual({
+        kind: "tcp",
+        host: "127.0.0.1",
+        port: 9257,
+      });
+      expect(parseServerAddress("tcp://[::1]:9257")).toEqual({
+        kind: "tcp",
+        host: "::1",
+        port: 9257,
+      });
+      expect(parseServerAddress("unix:///tmp/gopls.sock")).toEqual({
+        kind: "unix",
+        path: "/tmp/gopls.sock",
+      });
+    });
+
+    it("should reject addresses without a port", () => {
+      expect(() => parseServerAddress("tcp://localhost")).toThrow(
+        "Invalid server address",
+      );
+    });
+  });
+
+  describe("connectToServer", () => {
+    it("should exchange data and report the server closing as an exit", async () => {
+      const server = createServer((socket) => {
+        socket.once("data", (data) => {
+          socket.end(`echo ${data}`);
+        });
+      });
+      await new Promise<void>((resolve) =>
+        server.listen(0, "127.0.0.1", resolve),
+      );
+      const { port } = server.address() as { port: number };
+
+      const process = await connectToServer(`tcp://127.0.0.1:${port}`);
+      const received = new Promise<string>((resolve) =>
+        process.stdout!.once("data", (data) => resolve(data.toString())),
+      );
+      const exited = new Promise<unknown[]>((resolve) =>
+        process.once("exit", (...args) => resolve(args)),
+      );
+      process.stdin!.write("ping");
+
+      expect(await received).toBe("echo ping");
+      expect(await exited).toEqual([0, null]);
+      server.close();
+    });
+
+    it("should explain a refused connection", async () => {
+      const server = createServer();
+      await new Promise<void>((resolve) =>
+        server.listen(0, "127.0.0.1", resolve),
+      );
+      const { port } = server.address() as { port: number };
+      await new Promise((resolve) => server.close(resolve));
+
+      await expect(connectToServer(`tcp://127.0.0.1:${port}`)).rejects.toThrow(
+        `Cannot connect to language server at tcp://127.0.0.1:${port}`,
+      );
+    });
+  });
+}
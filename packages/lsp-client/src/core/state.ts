@@ -8,9 +8,14 @@ import type {
   ServerCapabilities,
   Diagnostic,
   DocumentUri,
+  ServerStatusParams,
 } from "../protocol/types/index.ts";
 import type { IFileSystem } from "../interfaces.ts";
 import { nodeFileSystemApi } from "../utils/filesystem.ts";
+import type {
+  DocumentTextSource,
+  PositionEncoding,
+} from "../utils/positionEncoding.ts";
 
 export interface LSPProcessState {
   process: ChildProcess | null;
@@ -23,7 +28,8 @@ export interface LSPProcessState {
       timer?: NodeJS.Timeout;
     }
   >;
-  buffer: string;
+  /** Raw bytes from the server; Content-Length counts bytes, not chars */
+  buffer: Buffer;
   contentLength: number;
   diagnostics: Map<DocumentUri, Diagnostic[]>;
   eventEmitter: EventEmitter;
@@ -32,6 +38,19 @@ export interface LSPProcessState {
   serverCharacteristics?: Record<string, any>;
   fileSystemApi: IFileSystem;
   serverCapabilities?: ServerCapabilities;
+  /** Last experimental/serverStatus report (rust-analyzer) */
+  serverStatus?: ServerStatusParams;
+  initializationOptions?: Record<string, unknown>;
+  /** Absolute paths of the workspace folders (rootPath first) */
+  workspaceFolders: string[];
+  /** Encoding of position characters negotiated in initialize */
+  positionEncoding: PositionEncoding;
+  /** Text of open documents, for converting positions */
+  documentText?: DocumentTextSource;
+  /** Send debounced document edits before a request reaches the server */
+  flushDocuments?: () => void;
+  /** Reopen a document closed by the open-document cap before a request */
+  touchDocument?: (uri: string) => void;
 }
 
 export interface LSPClientConfig {
@@ -43,6 +62,8 @@ export interface LSPClientConfig {
   clientName?: string;
   clientVersion?: string;
   initializationOptions?: Record<string, unknown>;
+  /** Additional workspace roots sent as workspaceFolders */
+  workspaceFolders?: string[];
 }
 
 export function createInitialState(config: LSPClientConfig): LSPProcessState {
@@ -50,7 +71,7 @@ export function createInitialState(config: LSPClientConfig): LSPProcessState {
     process: config.process,
     messageId: 0,
     responseHandlers: new Map(),
-    buffer: "",
+    buffer: Buffer.alloc(0),
     contentLength: -1,
     diagnostics: new Map(),
     eventEmitter: new EventEmitter(),
@@ -58,6 +79,14 @@ export function createInitialState(config: LSPClientConfig): LSPProcessState {
     languageId: config.languageId || "plaintext",
     serverCharacteristics: config.serverCharacteristics,
     fileSystemApi: config.fileSystemApi || createDefaultFileSystemApi(),
+    initializationOptions: config.initializationOptions,
+    workspaceFolders: [
+      config.rootPath,
+      ...(config.workspaceFolders ?? []).filter(
+        (folder) => folder !== config.rootPath,
+      ),
+    ],
+    positionEncoding: "utf-16",
   };
 }
 
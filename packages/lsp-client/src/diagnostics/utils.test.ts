@@ -0,0 +1,61 @@
+import { describe, it, expect, vi } from "vitest";
+import type { LSPClient } from "../protocol/types/index.ts";
+import { waitForDiagnosticsWithRetry } from "./utils.ts";
+
+const uri = "file:///project/main.ts";
+const diagnostic = {
+  range: {
+    start: { line: 0, character: 6 },
+    end: { line: 0, character: 7 },
+  },
+  message: "Type 'string' is not assignable to type 'number'.",
+  severity: 1,
+};
+
+function createClient(pull: () => Promise<unknown>) {
+  return {
+    languageId: "typescript",
+    isDocumentOpen: vi.fn(() => false),
+    openDocument: vi.fn(),
+    getDiagnosticSupport: () => ({
+      pushDiagnostics: true,
+      pullDiagnostics: true,
+    }),
+    pullDiagnostics: vi.fn(pull),
+    waitForDiagnostics: vi.fn(async () => [diagnostic]),
+    getDiagnostics: vi.fn(() => []),
+  };
+}
+
+describe("waitForDiagnosticsWithRetry", () => {
+  it("should answer with pulled diagnostics", async () => {
+    const client = createClient(async () => [diagnostic]);
+
+    const diagnostics = await waitForDiagnosticsWithRetry(
+      client as unknown as LSPClient,
+      uri,
+      "const x: number = '1';\n",
+    );
+
+    expect(diagnostics).toEqual([diagnostic]);
+    expect(client.waitForDiagnostics).not.toHaveBeenCalled();
+  });
+
+  it("should fall back to pushed diagnostics when pulling fails", async () => {
+    const client = createClient(async () => {
+      throw new Error("Method not found");
+    });
+
+    const diagnostics = await waitForDiagnosticsWithRetry(
+      client as unknown as LSPClient,
+      uri,
+      "const x: number = '1';\n",
+    );
+
+    expect(diagnostics).toEqual([diagnostic]);
+    expect(client.waitForDiagnostics).toHaveBeenCalledWith(
+      uri,
+      expect.any(Number),
+    );
+  });
+});
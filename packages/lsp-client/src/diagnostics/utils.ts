@@ -10,6 +10,7 @@ export interface DiagnosticWaitOptions {
   languageSpecific?: {
     moonbit?: { initialWait: number; maxPolls: number; timeout: number };
     deno?: { initialWait: number; maxPolls: number; timeout: number };
+    rust?: { initialWait: number; maxPolls: number; timeout: number };
     default?: { initialWait: number; maxPolls: number; timeout: number };
   };
 }
@@ -43,12 +44,33 @@ export async function waitForDiagnosticsWithRetry(
     client.openDocument(fileUri, fileContent, languageId);
   } else {
     // Update document to ensure fresh content
-    client.updateDocument(fileUri, fileContent, 2);
+    client.updateDocument(fileUri, fileContent);
   }
 
   // Get language-specific settings
   const langSettings = getLanguageSettings(client.languageId, languageSpecific);
   const effectiveTimeout = langSettings.timeout || timeout;
+
+  // rust-analyzer only reports cargo check results after didSave
+  if (isRustLanguage(client.languageId) && client.saveDocument) {
+    const checked = waitForFlycheck(client, effectiveTimeout);
+    client.saveDocument(fileUri);
+    if (await checked) {
+      // Give the server a moment to publish the merged diagnostics
+      await new Promise<void>((resolve) => setTimeout(resolve, 100));
+      return client.getDiagnostics(fileUri) || [];
+    }
+  }
+
+  // Servers with pull diagnostics answer directly; no need to wait for push
+  if (client.pullDiagnostics && supportsPullDiagnostics(client)) {
+    try {
+      return await client.pullDiagnostics(fileUri);
+    } catch {
+      // Fall back to push/polling below
+    }
+  }
+
   const initialWait = options.initialWait || langSettings.initialWait || 0;
   const maxPolls =
     options.maxPolls ||
@@ -103,7 +125,7 @@ export async function waitForDiagnosticsWithRetry(
 
       // Force document update every few polls
       if (poll > 0 && poll % 3 === 0) {
-        client.updateDocument(fileUri, fileContent, poll + 2);
+        client.updateDocument(fileUri, fileContent);
       }
     }
   }
@@ -111,6 +133,61 @@ export async function waitForDiagnosticsWithRetry(
   return diagnostics;
 }
 
+/**
+ * Wait for a rust-analyzer flycheck (cargo check) run to finish.
+ * Resolves false if no check starts shortly (checkOnSave disabled) or the
+ * check does not finish within the timeout.
+ */
+export function waitForFlycheck(
+  client: Pick<LSPClient, "on" | "off">,
+  timeout: number,
+  startTimeout: number = 2000,
+): Promise<boolean> {
+  return new Promise((resolve) => {
+    const handler = (...args: unknown[]) => {
+      const params = args[0] as
+        | { token?: string | number; value?: { kind?: string } }
+        | undefined;
+      if (
+        typeof params?.token !== "string" ||
+        !params.token.startsWith("rustAnalyzer/flycheck")
+      ) {
+        return;
+      }
+      if (params.value?.kind === "begin") {
+        clearTimeout(startTimer);
+      } else if (params.value?.kind === "end") {
+        finish(true);
+      }
+    };
+    const finish = (checked: boolean) => {
+      clearTimeout(startTimer);
+      clearTimeout(timer);
+      client.off?.("progress", handler);
+      resolve(checked);
+    };
+    const startTimer = setTimeout(() => finish(false), startTimeout);
+    const timer = setTimeout(() => finish(false), timeout);
+    client.on("progress", handler);
+  });
+}
+
+/**
+ * Check whether the server advertises textDocument/diagnostic
+ */
+export function supportsPullDiagnostics(client: LSPClient): boolean {
+  try {
+    return client.getDiagnosticSupport?.().pullDiagnostics ?? false;
+  } catch {
+    return false;
+  }
+}
+
+// The client languageId is the preset id when started from lsmcp
+function isRustLanguage(languageId: string | undefined): boolean {
+  return languageId === "rust" || languageId === "rust-analyzer";
+}
+
 /**
  * Get language-specific settings for diagnostics
  */
@@ -132,6 +209,17 @@ function getLanguageSettings(
     return languageSpecific.deno;
   }
 
+  // cargo check on a cold target directory takes a while
+  if (isRustLanguage(languageId)) {
+    return (
+      languageSpecific.rust ?? {
+        initialWait: 500,
+        maxPolls: 100,
+        timeout: 30000,
+      }
+    );
+  }
+
   return languageSpecific.default || defaults;
 }
 
@@ -144,3 +232,35 @@ export function isLargeFile(
 ): boolean {
   return fileContent.split("\n").length > threshold;
 }
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+  const { EventEmitter } = await import("events");
+
+  describe("waitForFlycheck", () => {
+    const flycheck = (kind: string) => ({
+      token: "rustAnalyzer/flycheck/0",
+      value: { kind, title: "cargo check" },
+    });
+
+    it("should resolve true when the check finishes", async () => {
+      const emitter = new EventEmitter();
+      const done = waitForFlycheck(emitter as any, 1000, 100);
+
+      emitter.emit("progress", { token: "other", value: { kind: "end" } });
+      emitter.emit("progress", flycheck("begin"));
+      emitter.emit("progress", flycheck("end"));
+
+      await expect(done).resolves.toBe(true);
+      expect(emitter.listenerCount("progress")).toBe(0);
+    });
+
+    it("should resolve false when no check starts", async () => {
+      const emitter = new EventEmitter();
+      await expect(waitForFlycheck(emitter as any, 1000, 20)).resolves.toBe(
+        false,
+      );
+    });
+  });
+}
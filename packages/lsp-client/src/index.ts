@@ -18,6 +18,39 @@ export type {
   LSPProcessState as LSPClientState,
 } from "./core/state.ts";
 
+export {
+  SharedDocumentTracker,
+  createSessionClient,
+} from "./core/sessionClient.ts";
+
+export {
+  createRoutingClient,
+  extensionsFromPatterns,
+  type ClientRoute,
+} from "./core/routingClient.ts";
+
+export {
+  connectToServer,
+  parseServerAddress,
+  type ServerAddress,
+} from "./core/socketTransport.ts";
+
+export {
+  rewriteProcessMessages,
+  type MessageRewriter,
+} from "./core/messageRewriter.ts";
+
+export {
+  createReplayProcess,
+  createTraceRecorder,
+  loadTrace,
+  parseTrace,
+  type ReplayOptions,
+  type TraceEntry,
+} from "./core/lspTrace.ts";
+
+export type { DocumentSnapshot } from "./managers/document-manager.ts";
+
 // ============================================================================
 // Essential Protocol Types
 // ============================================================================
@@ -40,6 +73,10 @@ export type {
   Command,
   FormattingOptions,
   ServerCapabilities,
+  CallHierarchyItem,
+  CallHierarchyIncomingCall,
+  CallHierarchyOutgoingCall,
+  TypeHierarchyItem,
 } from "./protocol/types/index.ts";
 
 // Export enums and additional types from vscode-languageserver-protocol
@@ -78,15 +115,34 @@ export { resolveLineParameter } from "./utils/container-helpers.ts";
 export type { ErrorContext } from "./utils/container-helpers.ts";
 export { formatError } from "./utils/container-helpers.ts";
 export { loadFileContext } from "./utils/fileContext.ts";
+export {
+  createWorkDoneToken,
+  onWorkDoneProgress,
+} from "./utils/workDoneProgress.ts";
+export type { WorkDoneProgressUpdate } from "./utils/workDoneProgress.ts";
+export {
+  DEFAULT_REQUEST_POLICIES,
+  resolveRequestPolicy,
+} from "./utils/requestPolicy.ts";
+export type {
+  RequestPolicy,
+  RequestPolicies,
+} from "./utils/requestPolicy.ts";
 export { withLSPOperation } from "./client/lspOperations.ts";
 export { createCompletionHandler } from "./commands/completion.ts";
 export { defaultLog as log, LogLevel } from "./utils/logger.ts";
 export {
   waitForDiagnosticsWithRetry,
+  supportsPullDiagnostics,
   isLargeFile,
 } from "./diagnostics/utils.ts";
 export { resolveLineIndexOrThrow } from "./utils/lineResolver.ts";
 export { createAdvancedCompletionHandler } from "./commands/completion.ts";
+export {
+  decodeSemanticTokens,
+  isCommentToken,
+  isDeclarationToken,
+} from "./commands/semanticTokens.ts";
 export {
   createTypescriptLSPClient,
   openDocument,
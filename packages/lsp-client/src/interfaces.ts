@@ -2,6 +2,8 @@
  * Interfaces for dependency injection - following Dependency Inversion Principle
  */
 
+import type { RequestPolicies } from "./utils/requestPolicy.ts";
+
 // Logger interface
 export interface ILogger {
   debug(...args: any[]): void;
@@ -34,6 +36,10 @@ export interface IServerCharacteristics {
   operationTimeout: number;
   supportsIncrementalSync?: boolean;
   supportsPullDiagnostics?: boolean;
+  documentUpdateDebounce?: number;
+  maxOpenDocuments?: number;
+  resultCacheSize?: number;
+  requestPolicies?: RequestPolicies;
 }
 
 export interface IServerCharacteristicsProvider {
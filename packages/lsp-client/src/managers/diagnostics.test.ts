@@ -0,0 +1,78 @@
+import { describe, it, expect, vi } from "vitest";
+import { EventEmitter } from "events";
+import { DiagnosticsManager } from "./diagnostics.ts";
+
+const diagnostic = {
+  range: {
+    start: { line: 0, character: 0 },
+    end: { line: 0, character: 5 },
+  },
+  message: "Type error",
+  severity: 1,
+};
+
+describe("DiagnosticsManager.pullDiagnostics", () => {
+  it("should store full reports and send previousResultId next time", async () => {
+    const manager = new DiagnosticsManager(new EventEmitter());
+    const sendRequest = vi
+      .fn()
+      .mockResolvedValueOnce({
+        kind: "full",
+        resultId: "1",
+        items: [diagnostic],
+      })
+      .mockResolvedValueOnce({ kind: "unchanged", resultId: "1" });
+
+    const first = await manager.pullDiagnostics("file:///a.go", sendRequest);
+    expect(first).toEqual([diagnostic]);
+
+    const second = await manager.pullDiagnostics("file:///a.go", sendRequest);
+    expect(second).toEqual([diagnostic]);
+    expect(sendRequest).toHaveBeenLastCalledWith("textDocument/diagnostic", {
+      textDocument: { uri: "file:///a.go" },
+      previousResultId: "1",
+    });
+  });
+
+  it("should pass the server identifier", async () => {
+    const manager = new DiagnosticsManager(new EventEmitter());
+    const sendRequest = vi.fn().mockResolvedValue({ kind: "full", items: [] });
+
+    await manager.pullDiagnostics("file:///a.rs", sendRequest, "rustc");
+
+    expect(sendRequest).toHaveBeenCalledWith("textDocument/diagnostic", {
+      textDocument: { uri: "file:///a.rs" },
+      identifier: "rustc",
+    });
+  });
+
+  it("should reject when the server rejects the request", async () => {
+    const manager = new DiagnosticsManager(new EventEmitter());
+    manager.handlePublishDiagnostics({
+      uri: "file:///a.ts",
+      diagnostics: [diagnostic],
+    } as any);
+    const sendRequest = vi
+      .fn()
+      .mockRejectedValue(new Error("Method not found"));
+
+    await expect(
+      manager.pullDiagnostics("file:///a.ts", sendRequest),
+    ).rejects.toThrow("Method not found");
+    // Pushed diagnostics stay available for the caller's fallback
+    expect(manager.getDiagnostics("file:///a.ts")).toEqual([diagnostic]);
+  });
+});
+
+describe("DiagnosticsManager.getDiagnosticSupport", () => {
+  it("should detect pull diagnostics from diagnosticProvider", () => {
+    expect(
+      DiagnosticsManager.getDiagnosticSupport({
+        diagnosticProvider: { interFileDependencies: false },
+      } as any).pullDiagnostics,
+    ).toBe(true);
+    expect(DiagnosticsManager.getDiagnosticSupport({}).pullDiagnostics).toBe(
+      false,
+    );
+  });
+});
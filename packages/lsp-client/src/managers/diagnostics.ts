@@ -8,6 +8,8 @@ import type {
   PublishDiagnosticsParams,
   ServerCapabilities,
   DocumentDiagnosticReport,
+  WorkspaceDiagnosticReport,
+  DiagnosticResult,
 } from "../protocol/types/index.ts";
 import { debug } from "../utils/debug.ts";
 
@@ -17,6 +19,8 @@ const debugLog = (message: string, ...args: unknown[]) => {
 
 export class DiagnosticsManager {
   private diagnostics = new Map<string, Diagnostic[]>();
+  // Last resultId per document, sent back as previousResultId on pull
+  private resultIds = new Map<string, string>();
   private eventEmitter: EventEmitter;
 
   constructor(eventEmitter: EventEmitter) {
@@ -48,6 +52,7 @@ export class DiagnosticsManager {
    */
   clearDiagnostics(uri: string): void {
     this.diagnostics.delete(uri);
+    this.resultIds.delete(uri);
   }
 
   /**
@@ -55,6 +60,7 @@ export class DiagnosticsManager {
    */
   clearAllDiagnostics(): void {
     this.diagnostics.clear();
+    this.resultIds.clear();
   }
 
   /**
@@ -113,32 +119,74 @@ export class DiagnosticsManager {
 
   /**
    * Pull diagnostics from the server (LSP 3.17+)
+   * Sends the previous resultId so the server can answer "unchanged".
+   * Throws if the server rejects the request so callers can fall back
    */
   async pullDiagnostics(
     uri: string,
     sendRequest: <T>(method: string, params: unknown) => Promise<T>,
+    identifier?: string,
   ): Promise<Diagnostic[]> {
-    try {
-      const params = {
-        textDocument: { uri },
-      };
+    const previousResultId = this.resultIds.get(uri);
+    const params = {
+      textDocument: { uri },
+      ...(identifier ? { identifier } : {}),
+      ...(previousResultId ? { previousResultId } : {}),
+    };
 
-      const result = await sendRequest<DocumentDiagnosticReport>(
-        "textDocument/diagnostic",
-        params,
-      );
+    const result = await sendRequest<DocumentDiagnosticReport>(
+      "textDocument/diagnostic",
+      params,
+    );
 
-      if (result.kind === "full" && result.items) {
-        // Store the diagnostics
-        this.diagnostics.set(uri, result.items);
-        return result.items;
-      }
+    if (result.resultId) {
+      this.resultIds.set(uri, result.resultId);
+    }
 
-      return [];
-    } catch (error) {
-      debugLog("Pull diagnostics not supported:", error);
-      // Fall back to stored diagnostics
-      return this.getDiagnostics(uri);
+    if (result.kind === "full") {
+      // Store the diagnostics
+      const items = result.items ?? [];
+      this.diagnostics.set(uri, items);
+      return items;
     }
+
+    // "unchanged": the last reported diagnostics are still valid
+    return this.getDiagnostics(uri);
+  }
+
+  /**
+   * Pull diagnostics for the whole workspace (workspace/diagnostic)
+   * Throws if the server rejects the request so callers can fall back
+   */
+  async pullWorkspaceDiagnostics(
+    sendRequest: <T>(method: string, params: unknown) => Promise<T>,
+    identifier?: string,
+  ): Promise<DiagnosticResult[]> {
+    const previousResultIds = Array.from(this.resultIds, ([uri, value]) => ({
+      uri,
+      value,
+    }));
+    const params = {
+      ...(identifier ? { identifier } : {}),
+      previousResultIds,
+    };
+
+    const result = await sendRequest<WorkspaceDiagnosticReport>(
+      "workspace/diagnostic",
+      params,
+    );
+
+    return (result?.items ?? []).map((report) => {
+      if (report.resultId) {
+        this.resultIds.set(report.uri, report.resultId);
+      }
+      if (report.kind === "full") {
+        this.diagnostics.set(report.uri, report.items ?? []);
+      }
+      return {
+        uri: report.uri,
+        diagnostics: this.getDiagnostics(report.uri),
+      };
+    });
   }
 }
@@ -0,0 +1,215 @@
+import { describe, it, expect, vi } from "vitest";
+import {
+  DocumentManager,
+  computeContentChanges,
+  documentSyncKind,
+} from "./document-manager.ts";
+
+function apply(
+  text: string,
+  change: ReturnType<typeof computeContentChanges>[number],
+) {
+  const lines = text.split("\n");
+  const offset = (p: { line: number; character: number }) =>
+    lines.slice(0, p.line).reduce((sum, l) => sum + l.length + 1, 0) +
+    p.character;
+  return (
+    text.slice(0, offset(change.range!.start)) +
+    change.text +
+    text.slice(offset(change.range!.end))
+  );
+}
+
+describe("computeContentChanges", () => {
+  it("should replace only the edited range", () => {
+    const previous = "line 1\nconst a = 1;\nline 3\n";
+    const next = "line 1\nconst abc = 1;\nline 3\n";
+
+    const [change] = computeContentChanges(previous, next);
+
+    expect(change).toEqual({
+      range: {
+        start: { line: 1, character: 7 },
+        end: { line: 1, character: 7 },
+      },
+      text: "bc",
+    });
+    expect(apply(previous, change)).toBe(next);
+  });
+
+  it("should handle inserted and deleted lines", () => {
+    const previous = "a\nb\nc\n";
+
+    for (const next of ["a\nb\nx\ny\nc\n", "a\nc\n", "", "a\nb\nc\nd"]) {
+      const changes = computeContentChanges(previous, next);
+      expect(changes).toHaveLength(1);
+      expect(apply(previous, changes[0])).toBe(next);
+    }
+  });
+
+  it("should not split surrogate pairs or CRLF line breaks", () => {
+    const emoji = computeContentChanges("x😀y", "x😁y")[0];
+    expect(emoji.text).toBe("😁");
+
+    const crlf = computeContentChanges("a\r\nb", "a\nb")[0];
+    expect(crlf.text).toBe("\n");
+    expect(crlf.range).toEqual({
+      start: { line: 0, character: 1 },
+      end: { line: 1, character: 0 },
+    });
+  });
+
+  it("should produce an empty edit for identical text", () => {
+    expect(computeContentChanges("same", "same")).toEqual([
+      {
+        range: {
+          start: { line: 0, character: 4 },
+          end: { line: 0, character: 4 },
+        },
+        text: "",
+      },
+    ]);
+  });
+});
+
+describe("documentSyncKind", () => {
+  it("should read the number and object forms", () => {
+    expect(documentSyncKind({ textDocumentSync: 2 })).toBe(2);
+    expect(documentSyncKind({ textDocumentSync: { change: 1 } })).toBe(1);
+    expect(documentSyncKind({ textDocumentSync: { openClose: true } })).toBe(
+      0,
+    );
+    expect(documentSyncKind(undefined)).toBe(1);
+  });
+});
+
+describe("DocumentManager.updateDocument", () => {
+  it("should send ranges for incremental servers and skip None", () => {
+    const manager = new DocumentManager();
+    const send = vi.fn();
+    manager.openDocument("file:///a.ts", "let a = 1;\n", send, "typescript");
+    send.mockClear();
+
+    manager.updateDocument("file:///a.ts", "let b = 1;\n", send, 2, 2);
+    manager.updateDocument("file:///a.ts", "let c = 1;\n", send, 3, 1);
+    manager.updateDocument("file:///a.ts", "let d = 1;\n", send, 4, 0);
+
+    expect(send.mock.calls.map(([, params]) => params.contentChanges)).toEqual(
+      [
+        [
+          {
+            range: {
+              start: { line: 0, character: 4 },
+              end: { line: 0, character: 5 },
+            },
+            text: "b",
+          },
+        ],
+        [{ text: "let c = 1;\n" }],
+      ],
+    );
+    expect(manager.getDocumentText("file:///a.ts")).toBe("let d = 1;\n");
+  });
+});
+
+describe("DocumentManager debounce", () => {
+  function openDebounced() {
+    const manager = new DocumentManager({ debounce: 100 });
+    const send = vi.fn();
+    manager.openDocument("file:///a.ts", "let a = 1;\n", send, "typescript");
+    send.mockClear();
+    return { manager, send };
+  }
+
+  it("should merge edits within the window into one didChange", () => {
+    vi.useFakeTimers();
+    try {
+      const { manager, send } = openDebounced();
+
+      manager.updateDocument("file:///a.ts", "let b = 1;\n", send, 2, 2);
+      vi.advanceTimersByTime(50);
+      manager.updateDocument("file:///a.ts", "let b = 12;\n", send, 3, 2);
+      expect(send).not.toHaveBeenCalled();
+      expect(manager.getDocumentSnapshots()[0]).toMatchObject({
+        text: "let b = 12;\n",
+        version: 3,
+      });
+
+      vi.advanceTimersByTime(100);
+
+      expect(send).toHaveBeenCalledTimes(1);
+      expect(send.mock.calls[0][1]).toEqual({
+        textDocument: { uri: "file:///a.ts", version: 3 },
+        contentChanges: [
+          {
+            range: {
+              start: { line: 0, character: 4 },
+              end: { line: 0, character: 9 },
+            },
+            text: "b = 12",
+          },
+        ],
+      });
+    } finally {
+      vi.useRealTimers();
+    }
+  });
+
+  it("should flush on save and drop pending edits on close", () => {
+    const { manager, send } = openDebounced();
+
+    manager.updateDocument("file:///a.ts", "let b = 1;\n", send);
+    manager.flush();
+    manager.updateDocument("file:///a.ts", "let c = 1;\n", send);
+    manager.saveDocument("file:///a.ts", send);
+    manager.updateDocument("file:///a.ts", "let d = 1;\n", send);
+    manager.closeDocument("file:///a.ts", send);
+
+    expect(send.mock.calls.map(([method]) => method)).toEqual([
+      "textDocument/didChange",
+      "textDocument/didChange",
+      "textDocument/didSave",
+      "textDocument/didClose",
+    ]);
+    expect(send.mock.calls[1][1].textDocument.version).toBe(3);
+    expect(manager.hasPendingChanges("file:///a.ts")).toBe(false);
+  });
+});
+
+describe("DocumentManager open document cap", () => {
+  it("should close the least recently used documents and reopen them", () => {
+    const manager = new DocumentManager({ maxOpenDocuments: 2 });
+    const send = vi.fn();
+    const events = () =>
+      send.mock.calls.map(
+        ([method, params]) => `${method} ${params.textDocument.uri}`,
+      );
+
+    manager.openDocument("file:///a.ts", "a", send, "typescript");
+    manager.openDocument("file:///b.ts", "b", send, "typescript");
+    manager.updateDocument("file:///a.ts", "a2", send);
+    manager.openDocument("file:///c.ts", "c", send, "typescript");
+
+    expect(events().slice(3)).toEqual([
+      "textDocument/didOpen file:///c.ts",
+      "textDocument/didClose file:///b.ts",
+    ]);
+    expect(manager.isDocumentOpen("file:///b.ts")).toBe(true);
+
+    send.mockClear();
+    manager.touch("file:///b.ts", send);
+
+    expect(events()).toEqual([
+      "textDocument/didOpen file:///b.ts",
+      "textDocument/didClose file:///a.ts",
+    ]);
+    expect(send.mock.calls[0][1].textDocument).toMatchObject({
+      text: "b",
+      version: 1,
+    });
+
+    send.mockClear();
+    manager.closeDocument("file:///a.ts", send);
+    expect(send).not.toHaveBeenCalled();
+  });
+});
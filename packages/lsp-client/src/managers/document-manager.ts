@@ -6,12 +6,149 @@ import type {
   DidOpenTextDocumentParams,
   DidChangeTextDocumentParams,
   DidCloseTextDocumentParams,
+  DidSaveTextDocumentParams,
+  Position,
+  ServerCapabilities,
+  TextDocumentContentChangeEvent,
   VersionedTextDocumentIdentifier,
 } from "../protocol/types/index.ts";
+import { TextDocumentSyncKind } from "../protocol/types/index.ts";
+
+/** Content of an open document, for re-opening it on a new server */
+export interface DocumentSnapshot {
+  uri: string;
+  text: string;
+  languageId: string;
+  version: number;
+}
+
+/**
+ * How the server wants document changes: None, Full or Incremental
+ * Before initialization full text is sent, as servers must accept it.
+ */
+export function documentSyncKind(
+  capabilities: ServerCapabilities | undefined,
+): number {
+  const sync = capabilities?.textDocumentSync;
+  if (!capabilities) {
+    return TextDocumentSyncKind.Full;
+  }
+  if (typeof sync === "number") {
+    return sync;
+  }
+  return sync?.change ?? TextDocumentSyncKind.None;
+}
+
+function positionAt(text: string, offset: number): Position {
+  let line = 0;
+  let lineStart = 0;
+  let newline = text.indexOf("\n");
+  while (newline !== -1 && newline < offset) {
+    line++;
+    lineStart = newline + 1;
+    newline = text.indexOf("\n", lineStart);
+  }
+  return { line, character: offset - lineStart };
+}
+
+const isHighSurrogate = (code: number) => code >= 0xd800 && code <= 0xdbff;
+const isLowSurrogate = (code: number) => code >= 0xdc00 && code <= 0xdfff;
+
+/**
+ * Incremental change turning `previous` into `next`
+ *
+ * The common prefix and suffix are kept, so an edit sends only the lines it
+ * touches; several edits collapse into one range spanning all of them.
+ * Identical texts yield an empty edit, which still bumps the version.
+ */
+export function computeContentChanges(
+  previous: string,
+  next: string,
+): TextDocumentContentChangeEvent[] {
+  const maxPrefix = Math.min(previous.length, next.length);
+  let prefix = 0;
+  while (
+    prefix < maxPrefix &&
+    previous.charCodeAt(prefix) === next.charCodeAt(prefix)
+  ) {
+    prefix++;
+  }
+  // Never split a surrogate pair (or \r\n) between kept and replaced text
+  if (
+    prefix > 0 &&
+    (isHighSurrogate(previous.charCodeAt(prefix - 1)) ||
+      (previous.charCodeAt(prefix - 1) === 13 &&
+        previous.charCodeAt(prefix) === 10))
+  ) {
+    prefix--;
+  }
+
+  const maxSuffix = maxPrefix - prefix;
+  let suffix = 0;
+  while (
+    suffix < maxSuffix &&
+    previous.charCodeAt(previous.length - 1 - suffix) ===
+      next.charCodeAt(next.length - 1 - suffix)
+  ) {
+    suffix++;
+  }
+  const suffixStart = previous.length - suffix;
+  if (
+    suffix > 0 &&
+    (isLowSurrogate(previous.charCodeAt(suffixStart)) ||
+      (previous.charCodeAt(suffixStart) === 10 &&
+        previous.charCodeAt(suffixStart - 1) === 13))
+  ) {
+    suffix--;
+  }
+
+  return [
+    {
+      range: {
+        start: positionAt(previous, prefix),
+        end: positionAt(previous, previous.length - suffix),
+      },
+      text: next.slice(prefix, next.length - suffix),
+    },
+  ];
+}
+
+export interface DocumentManagerOptions {
+  /**
+   * Milliseconds to wait for further edits before sending didChange
+   * Edits within the window are merged into one notification. 0 sends
+   * every edit immediately.
+   */
+  debounce?: number;
+  /**
+   * Most documents kept open in the server
+   * The least recently used ones get didClose and are reopened when next
+   * accessed. Unlimited when unset.
+   */
+  maxOpenDocuments?: number;
+}
+
+interface PendingChange {
+  text: string;
+  version: number;
+  syncKind: number;
+  sendNotification: (method: string, params: unknown) => void;
+  timer: ReturnType<typeof setTimeout>;
+}
 
 export class DocumentManager {
   private openDocuments = new Set<string>();
   private documentVersions = new Map<string, number>();
+  // Text as the server last saw it; pending edits are kept separately
+  private documentContents = new Map<
+    string,
+    { text: string; languageId: string }
+  >();
+  private pendingChanges = new Map<string, PendingChange>();
+  // Open for callers but closed in the server by the LRU cap
+  private coldDocuments = new Set<string>();
+
+  constructor(private options: DocumentManagerOptions = {}) {}
 
   /**
    * Open a document in the LSP server
@@ -23,6 +160,7 @@ export class DocumentManager {
     languageId?: string,
   ): void {
     if (this.openDocuments.has(uri)) {
+      this.touch(uri, sendNotification);
       return; // Already open
     }
 
@@ -38,6 +176,74 @@ export class DocumentManager {
     sendNotification("textDocument/didOpen", params);
     this.openDocuments.add(uri);
     this.documentVersions.set(uri, 1);
+    this.documentContents.set(uri, {
+      text: content,
+      languageId: params.textDocument.languageId,
+    });
+    this.evictColdDocuments(sendNotification);
+  }
+
+  /**
+   * Mark a document as used, reopening it if the LRU cap closed it
+   */
+  touch(
+    uri: string,
+    sendNotification: (method: string, params: unknown) => void,
+  ): void {
+    if (!this.openDocuments.has(uri)) {
+      return;
+    }
+    // Sets iterate in insertion order, so re-adding makes it the newest
+    this.openDocuments.delete(uri);
+    this.openDocuments.add(uri);
+    if (!this.coldDocuments.has(uri)) {
+      return;
+    }
+
+    const content = this.documentContents.get(uri)!;
+    const params: DidOpenTextDocumentParams = {
+      textDocument: {
+        uri,
+        languageId: content.languageId,
+        version: this.documentVersions.get(uri) ?? 1,
+        text: content.text,
+      },
+    };
+    sendNotification("textDocument/didOpen", params);
+    this.coldDocuments.delete(uri);
+    this.evictColdDocuments(sendNotification);
+  }
+
+  /**
+   * Close the least recently used documents in the server above the cap
+   */
+  private evictColdDocuments(
+    sendNotification: (method: string, params: unknown) => void,
+  ): void {
+    const max = this.options.maxOpenDocuments;
+    if (!max || max <= 0) {
+      return;
+    }
+    let resident = this.openDocuments.size - this.coldDocuments.size;
+    for (const uri of this.openDocuments) {
+      if (resident <= max) {
+        break;
+      }
+      if (this.coldDocuments.has(uri)) {
+        continue;
+      }
+      // Unsent edits are kept as the text to reopen with
+      const pending = this.dropPendingChange(uri);
+      const content = this.documentContents.get(uri);
+      if (pending && content) {
+        content.text = pending.text;
+        this.documentVersions.set(uri, pending.version);
+      }
+      const params: DidCloseTextDocumentParams = { textDocument: { uri } };
+      sendNotification("textDocument/didClose", params);
+      this.coldDocuments.add(uri);
+      resident--;
+    }
   }
 
   /**
@@ -55,9 +261,13 @@ export class DocumentManager {
       textDocument: { uri },
     };
 
-    sendNotification("textDocument/didClose", params);
+    this.dropPendingChange(uri);
+    if (!this.coldDocuments.delete(uri)) {
+      sendNotification("textDocument/didClose", params);
+    }
     this.openDocuments.delete(uri);
     this.documentVersions.delete(uri);
+    this.documentContents.delete(uri);
   }
 
   /**
@@ -68,24 +278,126 @@ export class DocumentManager {
     content: string,
     sendNotification: (method: string, params: unknown) => void,
     version?: number,
+    syncKind: number = TextDocumentSyncKind.Full,
   ): void {
     if (!this.openDocuments.has(uri)) {
       throw new Error(`Document ${uri} is not open`);
     }
+    this.touch(uri, sendNotification);
 
-    const currentVersion = this.documentVersions.get(uri) || 1;
+    const pending = this.pendingChanges.get(uri);
+    const currentVersion =
+      pending?.version ?? this.documentVersions.get(uri) ?? 1;
     const newVersion = version ?? currentVersion + 1;
 
-    const params: DidChangeTextDocumentParams = {
-      textDocument: {
-        uri,
-        version: newVersion,
-      } as VersionedTextDocumentIdentifier,
-      contentChanges: [{ text: content }],
+    const debounce = this.options.debounce ?? 0;
+    if (debounce <= 0) {
+      this.sendChange(uri, content, newVersion, syncKind, sendNotification);
+      return;
+    }
+
+    if (pending) {
+      clearTimeout(pending.timer);
+    }
+    const timer = setTimeout(() => this.flush(uri), debounce);
+    timer.unref?.();
+    this.pendingChanges.set(uri, {
+      text: content,
+      version: newVersion,
+      syncKind,
+      sendNotification,
+      timer,
+    });
+  }
+
+  /**
+   * Send pending edits now, for one document or all of them
+   * Called before requests and diagnostics waits so the server sees the
+   * latest text.
+   */
+  flush(uri?: string): void {
+    const uris = uri === undefined ? [...this.pendingChanges.keys()] : [uri];
+    for (const pendingUri of uris) {
+      const pending = this.dropPendingChange(pendingUri);
+      if (pending) {
+        this.sendChange(
+          pendingUri,
+          pending.text,
+          pending.version,
+          pending.syncKind,
+          pending.sendNotification,
+        );
+      }
+    }
+  }
+
+  /**
+   * Whether a document has edits the server has not seen yet
+   */
+  hasPendingChanges(uri: string): boolean {
+    return this.pendingChanges.has(uri);
+  }
+
+  private dropPendingChange(uri: string): PendingChange | undefined {
+    const pending = this.pendingChanges.get(uri);
+    if (pending) {
+      clearTimeout(pending.timer);
+      this.pendingChanges.delete(uri);
+    }
+    return pending;
+  }
+
+  private sendChange(
+    uri: string,
+    content: string,
+    version: number,
+    syncKind: number,
+    sendNotification: (method: string, params: unknown) => void,
+  ): void {
+    const previous = this.documentContents.get(uri)?.text;
+
+    // Ranges refer to the previous text, so send before storing the new one
+    if (syncKind !== TextDocumentSyncKind.None) {
+      const params: DidChangeTextDocumentParams = {
+        textDocument: {
+          uri,
+          version,
+        } as VersionedTextDocumentIdentifier,
+        contentChanges:
+          syncKind === TextDocumentSyncKind.Incremental &&
+          previous !== undefined
+            ? computeContentChanges(previous, content)
+            : [{ text: content }],
+      };
+      sendNotification("textDocument/didChange", params);
+    }
+    this.documentVersions.set(uri, version);
+    const current = this.documentContents.get(uri);
+    if (current) {
+      current.text = content;
+    }
+  }
+
+  /**
+   * Notify the server that a document was saved (triggers on-save checks)
+   */
+  saveDocument(
+    uri: string,
+    sendNotification: (method: string, params: unknown) => void,
+    text?: string,
+  ): void {
+    if (!this.openDocuments.has(uri)) {
+      throw new Error(`Document ${uri} is not open`);
+    }
+    this.touch(uri, sendNotification);
+    this.flush(uri);
+
+    const params: DidSaveTextDocumentParams = {
+      textDocument: { uri },
+      ...(text !== undefined ? { text } : {}),
     };
 
-    sendNotification("textDocument/didChange", params);
-    this.documentVersions.set(uri, newVersion);
+    sendNotification("textDocument/didSave", params);
   }
 
   /**
@@ -102,6 +414,28 @@ export class DocumentManager {
     return Array.from(this.openDocuments);
   }
 
+  /**
+   * Content of an open document as the server last saw it
+   */
+  getDocumentText(uri: string): string | undefined {
+    return this.documentContents.get(uri)?.text;
+  }
+
+  /**
+   * Latest content of every open document, including pending edits
+   */
+  getDocumentSnapshots(): DocumentSnapshot[] {
+    return Array.from(this.documentContents, ([uri, content]) => {
+      const pending = this.pendingChanges.get(uri);
+      return {
+        uri,
+        languageId: content.languageId,
+        text: pending?.text ?? content.text,
+        version: pending?.version ?? this.documentVersions.get(uri) ?? 1,
+      };
+    });
+  }
+
   /**
    * Close all documents
    */
@@ -117,6 +451,8 @@ export class DocumentManager {
    * Get document version
    */
   getDocumentVersion(uri: string): number | undefined {
-    return this.documentVersions.get(uri);
+    return (
+      this.pendingChanges.get(uri)?.version ?? this.documentVersions.get(uri)
+    );
   }
 }
@@ -0,0 +1,64 @@
+import { describe, it, expect } from "vitest";
+import type { IFileSystem } from "../interfaces.ts";
+import { applyWorkspaceEditManually } from "./workspace.ts";
+
+function createMemoryFileSystem(files: Record<string, string>) {
+  return {
+    files,
+    readFile: async (path: string) => files[path],
+    writeFile: async (path: string, data: string) => {
+      files[path] = data;
+    },
+  };
+}
+
+const range = (line: number, start: number, end: number) => ({
+  start: { line, character: start },
+  end: { line, character: end },
+});
+
+describe("applyWorkspaceEditManually", () => {
+  it("should apply edits from changes", async () => {
+    const fs = createMemoryFileSystem({ "/src/a.ts": "const a = 1;\n" });
+
+    await applyWorkspaceEditManually(
+      {
+        changes: {
+          "file:///src/a.ts": [{ range: range(0, 6, 7), newText: "b" }],
+        },
+      },
+      fs as unknown as IFileSystem,
+    );
+
+    expect(fs.files["/src/a.ts"]).toBe("const b = 1;\n");
+  });
+
+  it("should apply text document edits from documentChanges", async () => {
+    const fs = createMemoryFileSystem({ "/src/a.go": "package a\n" });
+
+    await applyWorkspaceEditManually(
+      {
+        documentChanges: [
+          {
+            textDocument: { uri: "file:///src/a.go", version: 1 },
+            edits: [{ range: range(0, 8, 9), newText: "b" }],
+          },
+        ],
+      },
+      fs as unknown as IFileSystem,
+    );
+
+    expect(fs.files["/src/a.go"]).toBe("package b\n");
+  });
+
+  it("should reject resource operations", async () => {
+    const fs = createMemoryFileSystem({});
+
+    await expect(
+      applyWorkspaceEditManually(
+        { documentChanges: [{ kind: "create", uri: "file:///src/new.ts" }] },
+        fs as unknown as IFileSystem,
+      ),
+    ).rejects.toThrow("Unsupported resource operation");
+  });
+});
@@ -2,34 +2,57 @@
  * Workspace edit management
  */
 
-import type { WorkspaceEdit } from "../protocol/types/index.ts";
+import { fileURLToPath } from "url";
+import type { TextEdit, WorkspaceEdit } from "../protocol/types/index.ts";
 import type { IFileSystem } from "../interfaces.ts";
 import { applyTextEdits } from "../utils/textEdits.ts";
 
+async function applyFileEdits(
+  uri: string,
+  edits: TextEdit[],
+  fileSystemApi: IFileSystem,
+): Promise<void> {
+  // Convert file:// URI to file path
+  const filePath = uri.startsWith("file://") ? fileURLToPath(uri) : uri;
+
+  // Read current content
+  const currentContent = await fileSystemApi.readFile(filePath);
+
+  // Apply edits
+  const newContent = applyTextEdits(currentContent, edits);
+
+  // Write back
+  await fileSystemApi.writeFile(filePath, newContent);
+}
+
+/**
+ * Apply text edits from `changes` and `documentChanges`.
+ * Resource operations (create/rename/delete) are not supported here.
+ */
 export async function applyWorkspaceEditManually(
   edit: WorkspaceEdit,
   fileSystemApi: IFileSystem,
 ): Promise<void> {
-  if (!edit.changes) {
-    return;
-  }
-
-  for (const [uri, edits] of Object.entries(edit.changes)) {
+  for (const [uri, edits] of Object.entries(edit.changes ?? {})) {
     if (!edits || edits.length === 0) {
       continue;
     }
+    await applyFileEdits(uri, edits, fileSystemApi);
+  }
 
-    // Convert file:// URI to file path
-    const filePath = uri.startsWith("file://") ? uri.slice(7) : uri;
-
-    // Read current content
-    const currentContent = await fileSystemApi.readFile(filePath);
-
-    // Apply edits
-    const newContent = applyTextEdits(currentContent, edits);
-
-    // Write back
-    await fileSystemApi.writeFile(filePath, newContent);
+  for (const change of edit.documentChanges ?? []) {
+    if (!("textDocument" in change)) {
+      throw new Error(
+        `Unsupported resource operation in workspace edit: ${change.kind}`,
+      );
+    }
+    if (change.edits.length > 0) {
+      await applyFileEdits(
+        change.textDocument.uri,
+        change.edits as TextEdit[],
+        fileSystemApi,
+      );
+    }
   }
 }
 
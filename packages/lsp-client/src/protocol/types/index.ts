@@ -21,4 +21,6 @@ export type {
   LSPClientState,
   HoverContents,
   DocumentDiagnosticReport,
+  WorkspaceDiagnosticReport,
+  DiagnosticResult,
 } from "@internal/types";
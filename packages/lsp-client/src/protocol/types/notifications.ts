@@ -59,6 +59,14 @@ export type WorkDoneProgress =
   | WorkDoneProgressReport
   | WorkDoneProgressEnd;
 
+// rust-analyzer experimental/serverStatus notification
+export interface ServerStatusParams {
+  health: "ok" | "warning" | "error";
+  /** False while the server is still loading the workspace or indexing */
+  quiescent: boolean;
+  message?: string;
+}
+
 // Type guards
 export function isPublishDiagnosticsParams(
   params: any,
@@ -49,6 +49,9 @@ export interface WorkspaceFolder {
 }
 
 export interface ClientCapabilities {
+  general?: {
+    positionEncodings?: string[];
+  };
   textDocument?: {
     synchronization?: {
       dynamicRegistration?: boolean;
@@ -59,6 +62,10 @@ export interface ClientCapabilities {
     publishDiagnostics?: {
       relatedInformation?: boolean;
     };
+    diagnostic?: {
+      dynamicRegistration?: boolean;
+      relatedDocumentSupport?: boolean;
+    };
     definition?: {
       linkSupport?: boolean;
     };
@@ -71,14 +78,75 @@ export interface ClientCapabilities {
         snippetSupport?: boolean;
       };
     };
+    signatureHelp?: {
+      signatureInformation?: {
+        documentationFormat?: string[];
+        parameterInformation?: {
+          labelOffsetSupport?: boolean;
+        };
+        activeParameterSupport?: boolean;
+      };
+    };
+    semanticTokens?: {
+      dynamicRegistration?: boolean;
+      requests: { full?: boolean; range?: boolean };
+      tokenTypes: string[];
+      tokenModifiers: string[];
+      formats: string[];
+      overlappingTokenSupport?: boolean;
+      multilineTokenSupport?: boolean;
+    };
+    inlayHint?: {
+      dynamicRegistration?: boolean;
+    };
+    documentLink?: {
+      dynamicRegistration?: boolean;
+      tooltipSupport?: boolean;
+    };
+    foldingRange?: {
+      dynamicRegistration?: boolean;
+      lineFoldingOnly?: boolean;
+    };
+    codeAction?: {
+      dynamicRegistration?: boolean;
+      codeActionLiteralSupport?: {
+        codeActionKind: {
+          valueSet: string[];
+        };
+      };
+      isPreferredSupport?: boolean;
+      disabledSupport?: boolean;
+      dataSupport?: boolean;
+      resolveSupport?: {
+        properties: string[];
+      };
+    };
     documentSymbol?: {
       hierarchicalDocumentSymbolSupport?: boolean;
     };
+    callHierarchy?: {
+      dynamicRegistration?: boolean;
+    };
+    typeHierarchy?: {
+      dynamicRegistration?: boolean;
+    };
   };
   workspace?: {
     workspaceFolders?: boolean;
     configuration?: boolean;
+    applyEdit?: boolean;
+    workspaceEdit?: {
+      documentChanges?: boolean;
+    };
+    executeCommand?: {
+      dynamicRegistration?: boolean;
+    };
+  };
+  window?: {
+    workDoneProgress?: boolean;
   };
+  /** Non-standard extensions (e.g. rust-analyzer serverStatusNotification) */
+  experimental?: Record<string, unknown>;
 }
 
 export interface InitializeParams {
@@ -101,9 +169,17 @@ export interface DidOpenTextDocumentParams {
 }
 
 export interface TextDocumentContentChangeEvent {
+  /** Replaced range; the whole document when omitted */
+  range?: Range;
   text: string;
 }
 
+export const TextDocumentSyncKind = {
+  None: 0,
+  Full: 1,
+  Incremental: 2,
+} as const;
+
 export interface DidChangeTextDocumentParams {
   textDocument: VersionedTextDocumentIdentifier;
   contentChanges: TextDocumentContentChangeEvent[];
@@ -113,6 +189,28 @@ export interface DidCloseTextDocumentParams {
   textDocument: TextDocumentIdentifier;
 }
 
+export interface DidSaveTextDocumentParams {
+  textDocument: TextDocumentIdentifier;
+  text?: string;
+}
+
+export interface DidChangeWorkspaceFoldersParams {
+  event: {
+    added: WorkspaceFolder[];
+    removed: WorkspaceFolder[];
+  };
+}
+
+// File operation params (willRenameFiles / didRenameFiles)
+export interface FileRename {
+  oldUri: string;
+  newUri: string;
+}
+
+export interface RenameFilesParams {
+  files: FileRename[];
+}
+
 // Code action params
 export interface CodeActionContext {
   diagnostics: Diagnostic[];
@@ -16,10 +16,17 @@ import {
   Command,
   TextEdit,
   WorkspaceEdit,
+  Range,
+  SymbolKind,
+  SymbolTag,
+  DocumentUri,
+  SemanticTokensLegend,
 } from "@internal/types";
 
 // Server capabilities
 export interface ServerCapabilities {
+  /** Negotiated position encoding (LSP 3.17, default "utf-16") */
+  positionEncoding?: string;
   textDocumentSync?:
     | number
     | {
@@ -53,7 +60,21 @@ export interface ServerCapabilities {
     | boolean
     | {
         codeActionKinds?: string[];
+        resolveProvider?: boolean;
       };
+  executeCommandProvider?: {
+    commands: string[];
+  };
+  semanticTokensProvider?: {
+    legend: SemanticTokensLegend;
+    range?: boolean | Record<string, unknown>;
+    full?: boolean | { delta?: boolean };
+  };
+  inlayHintProvider?: boolean | { resolveProvider?: boolean };
+  documentLinkProvider?: { resolveProvider?: boolean };
+  foldingRangeProvider?: boolean | Record<string, unknown>;
+  callHierarchyProvider?: boolean | Record<string, unknown>;
+  typeHierarchyProvider?: boolean | Record<string, unknown>;
   diagnosticProvider?: {
     identifier?: string;
     interFileDependencies?: boolean;
@@ -117,6 +138,40 @@ export type CodeActionResult = (Command | CodeAction)[] | null;
 export type FormattingResult = TextEdit[] | null;
 export type RenameResult = WorkspaceEdit | null;
 
+// Call hierarchy types
+export interface CallHierarchyItem {
+  name: string;
+  kind: SymbolKind;
+  tags?: SymbolTag[];
+  detail?: string;
+  uri: DocumentUri;
+  range: Range;
+  selectionRange: Range;
+  data?: unknown;
+}
+
+export interface CallHierarchyIncomingCall {
+  from: CallHierarchyItem;
+  fromRanges: Range[];
+}
+
+export interface CallHierarchyOutgoingCall {
+  to: CallHierarchyItem;
+  fromRanges: Range[];
+}
+
+// Type hierarchy types
+export interface TypeHierarchyItem {
+  name: string;
+  kind: SymbolKind;
+  tags?: SymbolTag[];
+  detail?: string;
+  uri: DocumentUri;
+  range: Range;
+  selectionRange: Range;
+  data?: unknown;
+}
+
 // Type guard helpers
 export function isLocationLink(obj: any): obj is LocationLink {
   return obj && typeof obj === "object" && "targetUri" in obj;
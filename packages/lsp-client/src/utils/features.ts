@@ -19,8 +19,26 @@ import {
   createPrepareRenameCommand,
   createRenameCommand,
 } from "../commands/rename.ts";
-import { createCodeActionCommand } from "../commands/codeAction.ts";
+import {
+  createCodeActionCommand,
+  createResolveCodeActionCommand,
+} from "../commands/codeAction.ts";
+import { createExecuteCommandCommand } from "../commands/executeCommand.ts";
 import { createSignatureHelpCommand } from "../commands/signatureHelp.ts";
+import { createSemanticTokensCommand } from "../commands/semanticTokens.ts";
+import { createInlayHintCommand } from "../commands/inlayHint.ts";
+import { createDocumentLinkCommand } from "../commands/documentLink.ts";
+import { createFoldingRangeCommand } from "../commands/foldingRange.ts";
+import {
+  createPrepareCallHierarchyCommand,
+  createIncomingCallsCommand,
+  createOutgoingCallsCommand,
+} from "../commands/callHierarchy.ts";
+import {
+  createPrepareTypeHierarchyCommand,
+  createSupertypesCommand,
+  createSubtypesCommand,
+} from "../commands/typeHierarchy.ts";
 
 export interface FeatureCommands {
   definition: ReturnType<typeof createDefinitionCommand>;
@@ -35,7 +53,19 @@ export interface FeatureCommands {
   prepareRename: ReturnType<typeof createPrepareRenameCommand>;
   rename: ReturnType<typeof createRenameCommand>;
   codeAction: ReturnType<typeof createCodeActionCommand>;
+  resolveCodeAction: ReturnType<typeof createResolveCodeActionCommand>;
+  executeCommand: ReturnType<typeof createExecuteCommandCommand>;
   signatureHelp: ReturnType<typeof createSignatureHelpCommand>;
+  semanticTokens: ReturnType<typeof createSemanticTokensCommand>;
+  inlayHint: ReturnType<typeof createInlayHintCommand>;
+  documentLink: ReturnType<typeof createDocumentLinkCommand>;
+  foldingRange: ReturnType<typeof createFoldingRangeCommand>;
+  prepareCallHierarchy: ReturnType<typeof createPrepareCallHierarchyCommand>;
+  incomingCalls: ReturnType<typeof createIncomingCallsCommand>;
+  outgoingCalls: ReturnType<typeof createOutgoingCallsCommand>;
+  prepareTypeHierarchy: ReturnType<typeof createPrepareTypeHierarchyCommand>;
+  supertypes: ReturnType<typeof createSupertypesCommand>;
+  subtypes: ReturnType<typeof createSubtypesCommand>;
 }
 
 export function createFeatureCommands(): FeatureCommands {
@@ -52,6 +82,18 @@ export function createFeatureCommands(): FeatureCommands {
     prepareRename: createPrepareRenameCommand(),
     rename: createRenameCommand(),
     codeAction: createCodeActionCommand(),
+    resolveCodeAction: createResolveCodeActionCommand(),
+    executeCommand: createExecuteCommandCommand(),
     signatureHelp: createSignatureHelpCommand(),
+    semanticTokens: createSemanticTokensCommand(),
+    inlayHint: createInlayHintCommand(),
+    documentLink: createDocumentLinkCommand(),
+    foldingRange: createFoldingRangeCommand(),
+    prepareCallHierarchy: createPrepareCallHierarchyCommand(),
+    incomingCalls: createIncomingCallsCommand(),
+    outgoingCalls: createOutgoingCallsCommand(),
+    prepareTypeHierarchy: createPrepareTypeHierarchyCommand(),
+    supertypes: createSupertypesCommand(),
+    subtypes: createSubtypesCommand(),
   };
 }
@@ -2,6 +2,8 @@
  * General helper utilities
  */
 
+import type { WorkspaceFolder } from "../protocol/types/index.ts";
+
 export interface ServerCharacteristics {
   readinessCheckTimeout: number;
   supportsDidSave?: boolean;
@@ -55,3 +57,13 @@ export function getServerCharacteristics(
 export function isObject(value: unknown): value is Record<string, unknown> {
   return value !== null && typeof value === "object" && !Array.isArray(value);
 }
+
+/**
+ * LSP workspace folder for an absolute directory path
+ */
+export function toWorkspaceFolder(path: string): WorkspaceFolder {
+  return {
+    uri: `file://${path}`,
+    name: path.split("/").pop() || "workspace",
+  };
+}
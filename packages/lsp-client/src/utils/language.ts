@@ -23,7 +23,9 @@ export function getLanguageIdFromPath(filePath: string): string | undefined {
     ".cc": "cpp",
     ".cxx": "cpp",
     ".h": "c",
+    ".hh": "cpp",
     ".hpp": "cpp",
+    ".hxx": "cpp",
     ".cs": "csharp",
     ".fs": "fsharp",
     ".fsx": "fsharp",
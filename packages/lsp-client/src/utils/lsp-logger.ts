@@ -1,15 +1,32 @@
 /**
- * LSP Client Logger - Independent logging system for LSP client
+ * LSP Client Logger - the `lsp` scope of the structured logger
  *
  * This logger is separate from the MCP logger and can be controlled independently.
- * Use LSP_DEBUG=1 environment variable to enable LSP client debug logging.
+ * Use `--log-level lsp=debug` (or LSP_DEBUG=1) to enable LSP client debug logging.
  */
 
+import {
+  createLogger,
+  isLogEnabled,
+  writeLog,
+  type Logger,
+} from "../../../../src/utils/structuredLog.ts";
+
+/**
+ * Structured logger for an LSP client component (scope `lsp:<component>`)
+ *
+ * @example
+ * lspLogger("request").debug("textDocument/hover", { duration: 12 });
+ */
+export function lspLogger(component: string): Logger {
+  return createLogger("lsp", component);
+}
+
 /**
  * Check if LSP debug logging is enabled
  */
 export function isLspDebugEnabled(): boolean {
-  return process.env.LSP_DEBUG === "1" || process.env.LSP_DEBUG === "true";
+  return isLogEnabled("debug", "lsp");
 }
 
 /**
@@ -22,9 +39,7 @@ export function isLspDebugEnabled(): boolean {
  * lspDebug("[LSPClient] Connected to server");
  */
 export function lspDebug(...args: unknown[]): void {
-  if (isLspDebugEnabled()) {
-    console.error("[LSP]", ...args);
-  }
+  writeLog("debug", "lsp", undefined, args);
 }
 
 /**
@@ -38,9 +53,7 @@ export function lspDebug(...args: unknown[]): void {
  * lspDebugWithPrefix("Protocol", "Received response:", data);
  */
 export function lspDebugWithPrefix(prefix: string, ...args: unknown[]): void {
-  if (isLspDebugEnabled()) {
-    console.error(`[LSP:${prefix}]`, ...args);
-  }
+  writeLog("debug", "lsp", prefix, args);
 }
 
 /**
@@ -52,7 +65,7 @@ export function lspDebugWithPrefix(prefix: string, ...args: unknown[]): void {
  * lspError("Failed to connect to LSP server:", error.message);
  */
 export function lspError(...args: unknown[]): void {
-  console.error("[LSP:ERROR]", ...args);
+  writeLog("error", "lsp", undefined, args);
 }
 
 /**
@@ -64,7 +77,7 @@ export function lspError(...args: unknown[]): void {
  * lspWarn("LSP server responded slowly:", responseTime);
  */
 export function lspWarn(...args: unknown[]): void {
-  console.error("[LSP:WARN]", ...args);
+  writeLog("warn", "lsp", undefined, args);
 }
 
 /**
@@ -80,8 +93,8 @@ export function lspConditionalDebug(
   condition: boolean,
   ...args: unknown[]
 ): void {
-  if (condition && isLspDebugEnabled()) {
-    console.error("[LSP]", ...args);
+  if (condition) {
+    writeLog("debug", "lsp", undefined, args);
   }
 }
 
@@ -95,9 +108,10 @@ export function lspConditionalDebug(
  * lspPerformance("initialize", 1234);
  */
 export function lspPerformance(operation: string, duration: number): void {
-  if (isLspDebugEnabled()) {
-    console.error(`[LSP:PERF] ${operation} took ${duration}ms`);
-  }
+  writeLog("debug", "lsp", "PERF", [`${operation} took ${duration}ms`], {
+    operation,
+    duration,
+  });
 }
 
 /**
@@ -110,8 +124,11 @@ export function lspPerformance(operation: string, duration: number): void {
  * lspData("Server capabilities", capabilities);
  */
 export function lspData(label: string, data: unknown): void {
-  if (isLspDebugEnabled()) {
-    console.error(`[LSP:DATA] ${label}:`, JSON.stringify(data, null, 2));
+  if (isLogEnabled("debug", "lsp", "DATA")) {
+    writeLog("debug", "lsp", "DATA", [
+      `${label}:`,
+      JSON.stringify(data, null, 2),
+    ]);
   }
 }
 
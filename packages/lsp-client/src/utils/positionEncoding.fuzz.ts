@@ -0,0 +1,70 @@
+import { describe, expect } from "vitest";
+import { fuzz } from "../../../../tests/helpers/fuzz.ts";
+import {
+  convertPositions,
+  fromEncodedCharacter,
+  toEncodedCharacter,
+  type PositionEncoding,
+} from "./positionEncoding.ts";
+
+const ENCODINGS: PositionEncoding[] = ["utf-8", "utf-32"];
+
+/** UTF-16 offsets in the line that do not split a surrogate pair */
+function boundaries(line: string): number[] {
+  const offsets = [0];
+  for (const char of line) {
+    offsets.push(offsets[offsets.length - 1] + char.length);
+  }
+  return offsets;
+}
+
+describe("position encoding conversion", () => {
+  fuzz("should convert character offsets there and back", (random) => {
+    const line = random.text(30).replace(/[\r\n]/g, "");
+    const encoding = random.pick(ENCODINGS);
+    const character = random.bool(0.9)
+      ? random.pick(boundaries(line))
+      : line.length + random.int(1, 5);
+
+    const encoded = toEncodedCharacter(line, character, encoding);
+
+    expect(fromEncodedCharacter(line, encoded, encoding)).toBe(character);
+  });
+
+  fuzz("should count characters the way the encoding does", (random) => {
+    const line = random.text(30);
+    const encoding = random.pick(ENCODINGS);
+    const encoded = boundaries(line).map((character) =>
+      toEncodedCharacter(line, character, encoding),
+    );
+
+    expect(toEncodedCharacter(line, line.length, "utf-8")).toBe(
+      Buffer.byteLength(line),
+    );
+    expect(toEncodedCharacter(line, line.length, "utf-32")).toBe(
+      [...line].length,
+    );
+    for (let i = 1; i < encoded.length; i++) {
+      expect(encoded[i]).toBeGreaterThan(encoded[i - 1]);
+    }
+  });
+
+  fuzz("should map payload positions back to where they were", (random) => {
+    const uri = "file:///fuzz.ts";
+    const text = random.text(80);
+    const lines = text.split(/\r\n|\r|\n/);
+    const encoding = random.pick(ENCODINGS);
+    const positions = Array.from({ length: random.int(1, 5) }, () => {
+      // Lines past the end of the document stay as they are
+      const line = random.int(0, lines.length);
+      return { line, character: random.pick(boundaries(lines[line] ?? "")) };
+    });
+    const params = { textDocument: { uri }, positions };
+
+    const sent = convertPositions(params, "toServer", encoding, () => text);
+
+    expect(convertPositions(sent, "fromServer", encoding, () => text)).toEqual(
+      params,
+    );
+  });
+});
@@ -0,0 +1,310 @@
+/**
+ * Position encodings (LSP 3.17 positionEncoding)
+ *
+ * Tools address characters with JavaScript string offsets, i.e. UTF-16 code
+ * units, which is the LSP default. Servers that negotiate UTF-8 or UTF-32
+ * count bytes or code points instead, so every position crossing the
+ * connection is translated using the text of its line. On ASCII lines all
+ * encodings agree; on lines with emoji or CJK characters they differ.
+ */
+
+import { readFileSync } from "fs";
+import { fileURLToPath } from "url";
+
+export type PositionEncoding = "utf-8" | "utf-16" | "utf-32";
+
+/** Encodings offered in initialize, in order of preference */
+export const SUPPORTED_POSITION_ENCODINGS: PositionEncoding[] = [
+  "utf-16",
+  "utf-8",
+  "utf-32",
+];
+
+export function negotiatedEncoding(
+  serverEncoding: string | undefined,
+): PositionEncoding {
+  return serverEncoding === "utf-8" || serverEncoding === "utf-32"
+    ? serverEncoding
+    : "utf-16";
+}
+
+function codePointUnits(codePoint: number, encoding: PositionEncoding) {
+  switch (encoding) {
+    case "utf-8":
+      return codePoint < 0x80
+        ? 1
+        : codePoint < 0x800
+          ? 2
+          : codePoint < 0x10000
+            ? 3
+            : 4;
+    case "utf-16":
+      return codePoint < 0x10000 ? 1 : 2;
+    case "utf-32":
+      return 1;
+  }
+}
+
+/**
+ * Convert a UTF-16 character offset in a line to the server's encoding
+ */
+export function toEncodedCharacter(
+  line: string,
+  character: number,
+  encoding: PositionEncoding,
+): number {
+  if (encoding === "utf-16") {
+    return character;
+  }
+  let units = 0;
+  let offset = 0;
+  for (const char of line) {
+    if (offset >= character) {
+      break;
+    }
+    const codePoint = char.codePointAt(0)!;
+    offset += codePointUnits(codePoint, "utf-16");
+    units += codePointUnits(codePoint, encoding);
+  }
+  // Positions past the end of the line stay past the end
+  return units + Math.max(0, character - offset);
+}
+
+/**
+ * Convert a character offset in the server's encoding to UTF-16
+ */
+export function fromEncodedCharacter(
+  line: string,
+  character: number,
+  encoding: PositionEncoding,
+): number {
+  if (encoding === "utf-16") {
+    return character;
+  }
+  let units = 0;
+  let offset = 0;
+  for (const char of line) {
+    if (units >= character) {
+      break;
+    }
+    const codePoint = char.codePointAt(0)!;
+    units += codePointUnits(codePoint, encoding);
+    offset += codePointUnits(codePoint, "utf-16");
+  }
+  return offset + Math.max(0, character - units);
+}
+
+function isPosition(
+  value: Record<string, unknown>,
+): value is { line: number; character: number } {
+  return typeof value.line === "number" && typeof value.character === "number";
+}
+
+/**
+ * Text of open documents, falling back to the file on disk
+ */
+export type DocumentTextSource = (uri: string) => string | undefined;
+
+export function readDocumentText(uri: string): string | undefined {
+  try {
+    return readFileSync(fileURLToPath(uri), "utf-8");
+  } catch {
+    return undefined;
+  }
+}
+
+/**
+ * Translate every position in an LSP payload
+ *
+ * Positions are resolved against the document they belong to: the nearest
+ * `uri`, `textDocument.uri`, `targetUri` or `changes` key, else `uri` (the
+ * document of the request).
+ */
+export function convertPositions<T>(
+  value: T,
+  direction: "toServer" | "fromServer",
+  encoding: PositionEncoding,
+  getText: DocumentTextSource,
+  uri?: string,
+): T {
+  if (encoding === "utf-16") {
+    return value;
+  }
+  const lines = new Map<string, string[] | undefined>();
+  const lineOf = (docUri: string, line: number) => {
+    if (!lines.has(docUri)) {
+      lines.set(docUri, getText(docUri)?.split(/\r\n|\r|\n/));
+    }
+    return lines.get(docUri)?.[line];
+  };
+  const convert =
+    direction === "toServer" ? toEncodedCharacter : fromEncodedCharacter;
+
+  const walk = (node: unknown, docUri: string | undefined): unknown => {
+    if (Array.isArray(node)) {
+      return node.map((item) => walk(item, docUri));
+    }
+    if (!node || typeof node !== "object") {
+      return node;
+    }
+    const object = node as Record<string, unknown>;
+    if (isPosition(object)) {
+      const text = docUri ? lineOf(docUri, object.line) : undefined;
+      return text === undefined
+        ? object
+        : { ...object, character: convert(text, object.character, encoding) };
+    }
+
+    const textDocument = object.textDocument as { uri?: unknown } | undefined;
+    const from = object.from as { uri?: unknown } | undefined;
+    const ownUri =
+      typeof object.uri === "string"
+        ? object.uri
+        : typeof textDocument?.uri === "string"
+          ? textDocument.uri
+          : // Incoming calls: fromRanges are in the caller's document
+            typeof from?.uri === "string"
+            ? from.uri
+            : docUri;
+    const targetUri =
+      typeof object.targetUri === "string" ? object.targetUri : undefined;
+
+    const result: Record<string, unknown> = {};
+    for (const [key, child] of Object.entries(object)) {
+      if (key === "changes" && child && typeof child === "object") {
+        // WorkspaceEdit.changes is keyed by document URI
+        result[key] = Object.fromEntries(
+          Object.entries(child).map(([editUri, edits]) => [
+            editUri,
+            walk(edits, editUri),
+          ]),
+        );
+      } else if (targetUri && key !== "originSelectionRange") {
+        result[key] = walk(child, targetUri);
+      } else {
+        result[key] = walk(child, ownUri);
+      }
+    }
+    return result;
+  };
+
+  return walk(value, uri) as T;
+}
+
+/**
+ * Document a request or notification is about, for positions in its result
+ */
+export function requestDocumentUri(params: unknown): string | undefined {
+  const object = params as
+    | { textDocument?: { uri?: unknown }; item?: { uri?: unknown } }
+    | undefined;
+  const uri = object?.textDocument?.uri ?? object?.item?.uri;
+  return typeof uri === "string" ? uri : undefined;
+}
+
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  // "😀" is 2 UTF-16 units, 4 UTF-8 bytes and 1 code point; "漢" is 1/3/1
+  const line = 'const s = "😀漢"; foo();';
+
+  describe("character conversion", () => {
+    it("should convert offsets after emoji and CJK characters", () => {
+      const foo = line.indexOf("foo");
+
+      expect(toEncodedCharacter(line, foo, "utf-8")).toBe(foo + 2 + 2);
+      expect(toEncodedCharacter(line, foo, "utf-32")).toBe(foo - 1);
+      expect(fromEncodedCharacter(line, foo + 4, "utf-8")).toBe(foo);
+      expect(fromEncodedCharacter(line, foo - 1, "utf-32")).toBe(foo);
+    });
+
+    it("should leave ASCII prefixes and UTF-16 unchanged", () => {
+      expect(toEncodedCharacter(line, 6, "utf-8")).toBe(6);
+      expect(toEncodedCharacter(line, 14, "utf-16")).toBe(14);
+    });
+
+    it("should keep positions past the end of the line", () => {
+      expect(toEncodedCharacter("漢", 3, "utf-8")).toBe(5);
+      expect(fromEncodedCharacter("漢", 5, "utf-8")).toBe(3);
+    });
+  });
+
+  describe("convertPositions", () => {
+    const texts: Record<string, string> = {
+      "file:///a.ts": line,
+      "file:///b.ts": "漢字 bar",
+    };
+    const getText = (uri: string) => texts[uri];
+
+    it("should convert request positions of the request document", () => {
+      const params = {
+        textDocument: { uri: "file:///a.ts" },
+        position: { line: 0, character: line.indexOf("foo") },
+      };
+
+      expect(
+        convertPositions(params, "toServer", "utf-8", getText).position,
+      ).toEqual({ line: 0, character: line.indexOf("foo") + 4 });
+    });
+
+    it("should resolve result positions against their own document", () => {
+      const result = [
+        {
+          uri: "file:///b.ts",
+          range: {
+            start: { line: 0, character: 7 },
+            end: { line: 0, character: 10 },
+          },
+        },
+        {
+          targetUri: "file:///b.ts",
+          targetRange: {
+            start: { line: 0, character: 7 },
+            end: { line: 0, character: 10 },
+          },
+          originSelectionRange: {
+            start: { line: 0, character: 24 },
+            end: { line: 0, character: 27 },
+          },
+        },
+      ];
+
+      const converted = convertPositions(
+        result,
+        "fromServer",
+        "utf-8",
+        getText,
+        "file:///a.ts",
+      );
+
+      expect(converted[0].range).toEqual({
+        start: { line: 0, character: 3 },
+        end: { line: 0, character: 6 },
+      });
+      expect(converted[1].targetRange.start.character).toBe(3);
+      expect(converted[1].originSelectionRange.start.character).toBe(20);
+    });
+
+    it("should use the keys of WorkspaceEdit.changes as documents", () => {
+      const edit = {
+        changes: {
+          "file:///b.ts": [
+            {
+              range: {
+                start: { line: 0, character: 7 },
+                end: { line: 0, character: 10 },
+              },
+              newText: "baz",
+            },
+          ],
+        },
+      };
+
+      expect(
+        convertPositions(edit, "fromServer", "utf-8", getText).changes[
+          "file:///b.ts"
+        ][0].range.start,
+      ).toEqual({ line: 0, character: 3 });
+    });
+  });
+}
@@ -0,0 +1,147 @@
+/**
+ * Per-method timeout and retry policy for LSP requests
+ *
+ * Interactive requests like hover should fail fast, while references or
+ * workspace symbols may need minutes on a cold rust-analyzer. Transient
+ * failures (timeouts, ContentModified, ServerCancelled) are retried with
+ * exponential backoff.
+ */
+
+export interface RequestPolicy {
+  /** Milliseconds to wait for a response */
+  timeout?: number;
+  /** Attempts after the first one on transient failures */
+  retries?: number;
+  /** Delay before the first retry (ms), doubled on every further retry */
+  backoff?: number;
+  /** Upper bound for the retry delay (ms) */
+  maxBackoff?: number;
+}
+
+/** Policies keyed by LSP method; "*" applies to every method */
+export type RequestPolicies = Record<string, RequestPolicy>;
+
+export const DEFAULT_REQUEST_POLICIES: RequestPolicies = {
+  "*": { timeout: 30000, retries: 0, backoff: 200, maxBackoff: 5000 },
+  "textDocument/hover": { timeout: 5000 },
+  "textDocument/signatureHelp": { timeout: 5000 },
+  "textDocument/completion": { timeout: 5000 },
+};
+
+/**
+ * Requests not retried through "*" because repeating them has side effects
+ */
+const NON_IDEMPOTENT_METHODS = new Set([
+  "initialize",
+  "shutdown",
+  "workspace/executeCommand",
+]);
+
+/** LSP error codes meaning "try again" */
+export const ContentModified = -32801;
+export const ServerCancelled = -32802;
+
+export function resolveRequestPolicy(
+  method: string,
+  policies: RequestPolicies = {},
+): Required<RequestPolicy> {
+  const resolved = {
+    ...DEFAULT_REQUEST_POLICIES["*"],
+    ...DEFAULT_REQUEST_POLICIES[method],
+    ...policies["*"],
+    ...policies[method],
+  } as Required<RequestPolicy>;
+  if (
+    NON_IDEMPOTENT_METHODS.has(method) &&
+    policies[method]?.retries === undefined
+  ) {
+    resolved.retries = 0;
+  }
+  return resolved;
+}
+
+/**
+ * Delay before retry number `attempt` (0-based)
+ */
+export function backoffDelay(
+  policy: Required<RequestPolicy>,
+  attempt: number,
+): number {
+  return Math.min(policy.backoff * 2 ** attempt, policy.maxBackoff);
+}
+
+export function isTransientError(error: unknown): boolean {
+  if (!(error instanceof Error) || error.name === "AbortError") {
+    return false;
+  }
+  const code = (error as Error & { code?: unknown }).code;
+  return (
+    code === ContentModified ||
+    code === ServerCancelled ||
+    error.message.startsWith("LSP request timeout")
+  );
+}
+
+if (import.meta.vitest) {
+  const { describe, it, expect } = import.meta.vitest;
+
+  describe("resolveRequestPolicy", () => {
+    it("should layer method policies over the defaults", () => {
+      const policies: RequestPolicies = {
+        "*": { retries: 2 },
+        "textDocument/references": { timeout: 120000 },
+      };
+
+      expect(resolveRequestPolicy("textDocument/references", policies)).toEqual(
+        { timeout: 120000, retries: 2, backoff: 200, maxBackoff: 5000 },
+      );
+      expect(resolveRequestPolicy("textDocument/hover", policies)).toMatchObject(
+        { timeout: 5000, retries: 2 },
+      );
+    });
+
+    it("should not retry non-idempotent requests by default", () => {
+      expect(
+        resolveRequestPolicy("workspace/executeCommand", { "*": { retries: 3 } })
+          .retries,
+      ).toBe(0);
+      expect(
+        resolveRequestPolicy("workspace/executeCommand", {
+          "workspace/executeCommand": { retries: 1 },
+        }).retries,
+      ).toBe(1);
+    });
+  });
+
+  describe("backoffDelay", () => {
+    it("should double the delay up to the maximum", () => {
+      const policy = resolveRequestPolicy("textDocument/definition", {
+        "*": { backoff: 100, maxBackoff: 300 },
+      });
+
+      expect([0, 1, 2, 3].map((n) => backoffDelay(policy, n))).toEqual([
+        100, 200, 300, 300,
+      ]);
+    });
+  });
+
+  describe("isTransientError", () => {
+    it("should accept timeouts and retryable LSP error codes", () => {
+      const modified = Object.assign(new Error("content modified"), {
+        code: ContentModified,
+      });
+      const invalid = Object.assign(new Error("invalid params"), {
+        code: -32602,
+      });
+      const cancelled = new Error("LSP request cancelled: textDocument/hover");
+      cancelled.name = "AbortError";
+
+      expect(isTransientError(modified)).toBe(true);
+      expect(
+        isTransientError(new Error("LSP request timeout: textDocument/hover")),
+      ).toBe(true);
+      expect(isTransientError(invalid)).toBe(false);
+      expect(isTransientError(cancelled)).toBe(false);
+    });
+  });
+}
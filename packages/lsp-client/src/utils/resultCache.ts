@@ -0,0 +1,270 @@
+/**
+ * Cache for read-only LSP queries (hover, definition, document symbols)
+ *
+ * Entries are keyed by method, document, a hash of the document text the
+ * server has and the position, so an edited document never hits an old
+ * entry. Each entry also records the documents it depends on: its own and
+ * every document its result points into (e.g. definition targets). A change
+ * to any of those drops it. Hover text can describe types declared anywhere,
+ * so hovers are dropped on every change to the contents of a file in the
+ * workspace; closing a document only drops the entries depending on it.
+ */
+
+import { createHash } from "crypto";
+import type { Position } from "../protocol/types/index.ts";
+import { metrics } from "../../../../src/utils/metrics.ts";
+
+const cacheLookups = metrics.counter(
+  "lsmcp_result_cache_lookups_total",
+  "Result cache lookups by method and result (hit or miss)",
+);
+
+export type CacheScope = "document" | "workspace";
+
+interface CacheEntry {
+  value: Promise<unknown>;
+  dependencies: Set<string>;
+  scope: CacheScope;
+}
+
+export const DEFAULT_RESULT_CACHE_SIZE = 500;
+
+export function contentHash(text: string): string {
+  return createHash("sha1").update(text).digest("hex");
+}
+
+/**
+ * Document URIs a result refers to
+ */
+export function resultUris(value: unknown): string[] {
+  const uris = new Set<string>();
+  const walk = (node: unknown) => {
+    if (Array.isArray(node)) {
+      node.forEach(walk);
+      return;
+    }
+    if (!node || typeof node !== "object") {
+      return;
+    }
+    for (const [key, child] of Object.entries(node)) {
+      if ((key === "uri" || key === "targetUri") && typeof child === "string") {
+        uris.add(child);
+      } else {
+        walk(child);
+      }
+    }
+  };
+  walk(value);
+  return [...uris];
+}
+
+export class ResultCache {
+  // Maps iterate in insertion order; re-inserting on hits keeps LRU order
+  private entries = new Map<string, CacheEntry>();
+
+  constructor(private maxEntries: number = DEFAULT_RESULT_CACHE_SIZE) {}
+
+  get enabled(): boolean {
+    return this.maxEntries > 0;
+  }
+
+  get size(): number {
+    return this.entries.size;
+  }
+
+  /**
+   * Return the cached result, or run `fetch` and cache it
+   * Concurrent calls with the same key share one request; failures are not
+   * cached.
+   */
+  async getOrFetch<T>(
+    method: string,
+    uri: string,
+    text: string | undefined,
+    position: Position | undefined,
+    scope: CacheScope,
+    fetch: () => Promise<T>,
+  ): Promise<T> {
+    // Without the document text there is nothing to validate against
+    if (!this.enabled || text === undefined) {
+      return fetch();
+    }
+    const key = [
+      method,
+      uri,
+      contentHash(text),
+      position ? `${position.line}:${position.character}` : "",
+    ].join("\0");
+
+    const cached = this.entries.get(key);
+    if (cached) {
+      cacheLookups.inc({ method, result: "hit" });
+      this.entries.delete(key);
+      this.entries.set(key, cached);
+      return cached.value as Promise<T>;
+    }
+    cacheLookups.inc({ method, result: "miss" });
+
+    const entry: CacheEntry = {
+      value: fetch(),
+      dependencies: new Set([uri]),
+      scope,
+    };
+    this.entries.set(key, entry);
+    this.evict();
+    try {
+      const result = await (entry.value as Promise<T>);
+      for (const dependency of resultUris(result)) {
+        entry.dependencies.add(dependency);
+      }
+      return result;
+    } catch (error) {
+      if (this.entries.get(key) === entry) {
+        this.entries.delete(key);
+      }
+      throw error;
+    }
+  }
+
+  /**
+   * Drop entries that depend on a document
+   */
+  invalidate(uri: string): void {
+    for (const [key, entry] of this.entries) {
+      if (entry.dependencies.has(uri)) {
+        this.entries.delete(key);
+      }
+    }
+  }
+
+  /**
+   * Drop entries a change to the contents of a file may have made stale:
+   * those depending on it and workspace-scoped ones
+   */
+  contentChanged(uri: string): void {
+    for (const [key, entry] of this.entries) {
+      if (entry.scope === "workspace" || entry.dependencies.has(uri)) {
+        this.entries.delete(key);
+      }
+    }
+  }
+
+  clear(): void {
+    this.entries.clear();
+  }
+
+  private evict(): void {
+    for (const key of this.entries.keys()) {
+      if (this.entries.size <= this.maxEntries) {
+        break;
+      }
+      this.entries.delete(key);
+    }
+  }
+}
+
+if (import.meta.vitest) {
+  const { describe, it, expect, vi } = import.meta.vitest;
+
+  const position = { line: 1, character: 4 };
+
+  describe("ResultCache", () => {
+    it("should reuse results until the document text changes", async () => {
+      const cache = new ResultCache();
+      const fetch = vi.fn().mockResolvedValue({ contents: "string" });
+      const hover = (text: string) =>
+        cache.getOrFetch(
+          "textDocument/hover",
+          "file:///a.ts",
+          text,
+          position,
+          "workspace",
+          fetch,
+        );
+
+      await hover("const a = 1;");
+      await hover("const a = 1;");
+      expect(fetch).toHaveBeenCalledTimes(1);
+
+      await hover("const a = 2;");
+      expect(fetch).toHaveBeenCalledTimes(2);
+    });
+
+    it("should invalidate definitions via their targets", async () => {
+      const cache = new ResultCache();
+      const fetch = vi.fn().mockResolvedValue([
+        {
+          uri: "file:///b.ts",
+          range: { start: position, end: position },
+        },
+      ]);
+      const definition = () =>
+        cache.getOrFetch(
+          "textDocument/definition",
+          "file:///a.ts",
+          "import { b } from './b';",
+          position,
+          "document",
+          fetch,
+        );
+
+      await definition();
+      cache.invalidate("file:///c.ts");
+      await definition();
+      expect(fetch).toHaveBeenCalledTimes(1);
+
+      cache.invalidate("file:///b.ts");
+      await definition();
+      expect(fetch).toHaveBeenCalledTimes(2);
+    });
+
+    it("should keep other hovers when a document is only closed", async () => {
+      const cache = new ResultCache();
+      const fetch = vi.fn().mockResolvedValue({ contents: "string" });
+      const hover = (uri: string) =>
+        cache.getOrFetch(
+          "textDocument/hover",
+          uri,
+          "const a = 1;",
+          position,
+          "workspace",
+          fetch,
+        );
+
+      await hover("file:///a.ts");
+      await hover("file:///b.ts");
+      cache.invalidate("file:///b.ts");
+      await hover("file:///a.ts");
+      expect(fetch).toHaveBeenCalledTimes(2);
+
+      cache.contentChanged("file:///c.ts");
+      await hover("file:///a.ts");
+      expect(fetch).toHaveBeenCalledTimes(3);
+    });
+
+    it("should not cache failures and should bound its size", async () => {
+      const cache = new ResultCache(2);
+      const failing = vi.fn().mockRejectedValue(new Error("timeout"));
+      const symbols = (uri: string, fetch: () => Promise<unknown>) =>
+        cache.getOrFetch(
+          "textDocument/documentSymbol",
+          uri,
+          "x",
+          undefined,
+          "document",
+          fetch,
+        );
+
+      await expect(symbols("file:///a.ts", failing)).rejects.toThrow();
+      expect(cache.size).toBe(0);
+
+      const ok = vi.fn().mockResolvedValue([]);
+      for (const uri of ["file:///a.ts", "file:///b.ts", "file:///c.ts"]) {
+        await symbols(uri, ok);
+      }
+      expect(cache.size).toBe(2);
+      await symbols("file:///a.ts", ok);
+      expect(ok).toHaveBeenCalledTimes(4);
+    });
+  });
+}
@@ -0,0 +1,56 @@
+import { describe, expect } from "vitest";
+import { fuzz } from "../../../../tests/helpers/fuzz.ts";
+import type { TextEdit } from "../protocol/types/index.ts";
+import {
+  applyTextEdits,
+  offsetToPosition,
+  positionToOffset,
+} from "./textEdits.ts";
+
+describe("offset and position conversion", () => {
+  fuzz("should convert offsets to positions and back", (random) => {
+    const text = random.text(60);
+    const offset = random.int(0, text.length);
+
+    const position = offsetToPosition(text, offset);
+
+    expect(positionToOffset(text, position)).toBe(offset);
+    expect(position.character).toBeLessThanOrEqual(
+      text.split("\n")[position.line].length,
+    );
+  });
+
+  fuzz("should apply edits like splicing their offsets", (random) => {
+    const text = random.text(60);
+    const cuts = Array.from({ length: random.int(0, 8) }, () =>
+      random.int(0, text.length),
+    ).sort((a, b) => a - b);
+
+    // Pairs of cuts are edit ranges; two edits starting at the same offset
+    // have no defined order, so only the first is kept
+    const edits: TextEdit[] = [];
+    let expected = "";
+    let last = 0;
+    for (let i = 0; i + 1 < cuts.length; i += 2) {
+      const [start, end] = [cuts[i], cuts[i + 1]];
+      if (edits.length > 0 && start === cuts[i - 2]) {
+        continue;
+      }
+      const newText = random.text(10);
+      edits.push({
+        range: {
+          start: offsetToPosition(text, start),
+          end: offsetToPosition(text, end),
+        },
+        newText,
+      });
+      expected += text.slice(last, start) + newText;
+      last = end;
+    }
+    expected += text.slice(last);
+
+    expect(applyTextEdits(text, random.bool() ? edits.reverse() : edits)).toBe(
+      expected,
+    );
+  });
+});
@@ -0,0 +1,89 @@
+/**
+ * LSP work done progress ($/progress) helpers
+ */
+
+export type ProgressToken = string | number;
+
+export interface WorkDoneProgressUpdate {
+  kind: "begin" | "report" | "end";
+  title?: string;
+  message?: string;
+  percentage?: number;
+}
+
+interface ProgressEmitter {
+  on(event: string, listener: (...args: any[]) => void): void;
+  off?: (event: string, listener: (...args: any[]) => void) => void;
+}
+
+let nextToken = 0;
+
+/**
+ * Create a unique token to attach to a request as workDoneToken
+ */
+export function createWorkDoneToken(prefix = "lsmcp"): string {
+  nextToken++;
+  return `${prefix}-${process.pid}-${nextToken}`;
+}
+
+/**
+ * Listen for $/progress notifications with the given token.
+ * Returns a function that stops listening.
+ */
+export function onWorkDoneProgress(
+  client: ProgressEmitter,
+  token: ProgressToken,
+  listener: (update: WorkDoneProgressUpdate) => void,
+): () => void {
+  const handler = (params: { token: ProgressToken; value: unknown }) => {
+    const value = params?.value as WorkDoneProgressUpdate | undefined;
+    if (params?.token === token && value && typeof value.kind === "string") {
+      listener(value);
+    }
+  };
+  client.on("progress", handler);
+  return () => client.off?.("progress", handler);
+}
+
+// In-source tests using Vitest
+if (import.meta.vitest) {
+  const { describe, it, expect, vi } = import.meta.vitest;
+  const { EventEmitter } = await import("events");
+
+  describe("onWorkDoneProgress", () => {
+    it("should only forward updates for the given token", () => {
+      const emitter = new EventEmitter();
+      const listener = vi.fn();
+      const stop = onWorkDoneProgress(emitter, "t1", listener);
+
+      emitter.emit("progress", {
+        token: "t1",
+        value: { kind: "begin", title: "Finding references" },
+      });
+      emitter.emit("progress", {
+        token: "other",
+        value: { kind: "report", percentage: 50 },
+      });
+      emitter.emit("progress", {
+        token: "t1",
+        value: { kind: "report", percentage: 40 },
+      });
+
+      expect(listener).toHaveBeenCalledTimes(2);
+      expect(listener).toHaveBeenLastCalledWith({
+        kind: "report",
+        percentage: 40,
+      });
+
+      stop();
+      emitter.emit("progress", { token: "t1", value: { kind: "end" } });
+      expect(listener).toHaveBeenCalledTimes(2);
+    });
+  });
+
+  describe("createWorkDoneToken", () => {
+    it("should create unique tokens", () => {
+      expect(createWorkDoneToken()).not.toBe(createWorkDoneToken());
+    });
+  });
+}
@@ -1,13 +1,20 @@
-import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import { describe, it, expect } from "vitest";
 import { goplsAdapter } from "../../../../src/presets/gopls.ts";
-import { spawn } from "child_process";
 import { createLSPClient } from "@internal/lsp-client"; // from "lspClient.ts";
-import path from "path";
-import fs from "fs/promises";
-import { fileURLToPath } from "url";
-import { randomBytes } from "crypto";
+import { MockLanguageServer } from "@internal/mock-lsp-server";
 
-const __dirname = path.dirname(fileURLToPath(import.meta.url));
+const source = `package main
+
+import "fmt"
+
+func main() {
+    fmt.Println("Hello, World!")
+}
+
+func add(a, b int) int {
+    return a + b
+}
+`;
 
 describe("gopls adapter", () => {
   it("should have correct configuration", () => {
@@ -28,123 +35,66 @@ describe("gopls adapter", () => {
 
   // Doctor functionality has been removed
 
-  describe("LSP functionality (if gopls is installed)", () => {
-    let tmpDir: string;
-    let lspProcess: any;
-    let client: any;
-
-    beforeEach(async () => {
-      // Create temporary directory for test files
-      const hash = randomBytes(8).toString("hex");
-      tmpDir = path.join(__dirname, `tmp-gopls-${hash}`);
-      await fs.mkdir(tmpDir, { recursive: true });
-
-      // Create go.mod file
-      await fs.writeFile(
-        path.join(tmpDir, "go.mod"),
-        `module example.com/test
-
-go 1.21
-`,
-      );
-    });
-
-    afterEach(async () => {
-      try {
-        if (client) {
-          await client.stop();
-        }
-      } catch {
-        // Ignore errors during cleanup
-      }
-
-      try {
-        if (lspProcess && !lspProcess.killed) {
-          lspProcess.kill();
-        }
-      } catch {
-        // Ignore errors during cleanup
-      }
-
-      try {
-        await fs.rm(tmpDir, { recursive: true, force: true });
-      } catch {
-        // Ignore errors during cleanup
-      }
-    });
-
-    it("should start gopls server and handle basic Go file", async () => {
-      // Skip test if gopls is not installed
-      try {
-        const { execSync } = await import("child_process");
-        execSync("which gopls", { stdio: "ignore" });
-      } catch {
-        console.log("Skipping gopls test - gopls not installed");
-        return;
-      }
-
-      // Create a simple Go file
-      const goFile = path.join(tmpDir, "main.go");
-      await fs.writeFile(
-        goFile,
-        `package main
-
-import "fmt"
-
-func main() {
-    fmt.Println("Hello, World!")
-}
-
-func add(a, b int) int {
-    return a + b
-}
-`,
-      );
-
-      // Start gopls
-      const bin = goplsAdapter.bin || "gopls";
-      const args = goplsAdapter.args || [];
-      lspProcess = spawn(bin, args, {
-        cwd: tmpDir,
-        stdio: ["pipe", "pipe", "pipe"],
+  // Runs against the mock server, so it does not depend on gopls being
+  // installed
+  describe("LSP functionality", () => {
+    it("should pass the adapter settings to the server and handle a Go file", async () => {
+      const server = new MockLanguageServer({
+        capabilities: { hoverProvider: true },
+        serverInfo: { name: "gopls" },
+        responses: {
+          "textDocument/hover": {
+            contents: {
+              kind: "markdown",
+              value: "```go\nfunc add(a int, b int) int\n```",
+            },
+          },
+        },
       });
-
-      client = createLSPClient({
-        rootPath: tmpDir,
-        process: lspProcess,
+      const client = createLSPClient({
+        rootPath: "/project",
+        process: server.process,
         languageId: "go",
         initializationOptions: goplsAdapter.initializationOptions as
           | Record<string, unknown>
           | undefined,
+        serverCharacteristics: { readinessCheckTimeout: 1 },
       });
 
-      await client.start();
-
-      // Open the Go file
-      const fileUri = `file://${goFile}`;
-      client.openDocument(fileUri, await fs.readFile(goFile, "utf8"), "go");
-
-      // Wait for LSP to process the document
-      await new Promise((resolve) => setTimeout(resolve, 1000));
-
-      // Try to get hover information
-      const hoverResponse = await client.sendRequest("textDocument/hover", {
-        textDocument: { uri: fileUri },
-        position: { line: 8, character: 5 }, // Position of 'add' function (0-indexed)
-      });
-
-      expect(hoverResponse).toBeDefined();
-      if (hoverResponse?.contents) {
-        const content =
-          typeof hoverResponse.contents === "string"
-            ? hoverResponse.contents
-            : hoverResponse.contents.value;
-        // gopls returns hover info that should contain function signature or type info
-        expect(content).toBeDefined();
-        expect(content.length).toBeGreaterThan(0);
-        // The content format may vary, so just check it's not empty
-        console.log("Hover response content:", content);
+      try {
+        await client.start();
+        expect(server.messages("initialize")[0].params).toMatchObject({
+          initializationOptions: goplsAdapter.initializationOptions,
+        });
+        // gopls reads its settings through workspace/configuration
+        expect(
+          await server.request("workspace/configuration", {
+            items: [{ section: "gopls" }],
+          }),
+        ).toEqual([goplsAdapter.initializationOptions]);
+
+        const fileUri = "file:///project/main.go";
+        client.openDocument(fileUri, source, "go");
+        const hoverResponse: any = await client.sendRequest(
+          "textDocument/hover",
+          {
+            textDocument: { uri: fileUri },
+            position: { line: 8, character: 5 }, // Position of 'add' function (0-indexed)
+          },
+        );
+
+        expect(server.documents.get(fileUri)).toBe(source);
+        expect(
+          server.messages("textDocument/didOpen")[0].params,
+        ).toMatchObject({ textDocument: { uri: fileUri, languageId: "go" } });
+        expect(server.messages("textDocument/hover")[0].params).toEqual({
+          textDocument: { uri: fileUri },
+          position: { line: 8, character: 5 },
+        });
+        expect(hoverResponse.contents.value).toContain("func add");
+      } finally {
+        await client.stop();
       }
-    }, 10000);
+    });
   });
 });
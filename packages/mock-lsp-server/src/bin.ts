@@ -0,0 +1,26 @@
+#!/usr/bin/env node
+/**
+ * Mock language server over stdio, for tests that spawn a server command
+ * (presets, "bin" in lsmcp.config.json):
+ *
+ *   node packages/mock-lsp-server/src/bin.ts script.json
+ *
+ * The script is a MockServerScript as JSON; without one the server only
+ * answers initialize and shutdown.
+ */
+
+import { readFileSync } from "fs";
+import {
+  MockLanguageServer,
+  serverOptionsFromScript,
+  type MockServerScript,
+} from "./server.ts";
+
+const scriptPath = process.argv[2];
+const script: MockServerScript = scriptPath
+  ? JSON.parse(readFileSync(scriptPath, "utf-8"))
+  : {};
+
+const server = new MockLanguageServer(serverOptionsFromScript(script));
+server.on("exit", (code: number | null) => process.exit(code ?? 0));
+server.listen(process.stdin, process.stdout, process.stderr);
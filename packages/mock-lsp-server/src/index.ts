@@ -0,0 +1,19 @@
+export {
+  MockLanguageServer,
+  reply,
+  serverOptionsFromScript,
+  type MockHandler,
+  type MockReply,
+  type MockResponse,
+  type MockServerOptions,
+  type MockServerScript,
+} from "./server.ts";
+export {
+  encodeMessage,
+  ErrorCodes,
+  frame,
+  MessageReader,
+  type JsonRpcId,
+  type JsonRpcMessage,
+  type ResponseError,
+} from "./messages.ts";
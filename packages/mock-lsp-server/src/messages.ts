@@ -0,0 +1,91 @@
+/**
+ * JSON-RPC messages with LSP base protocol framing (Content-Length headers)
+ */
+
+export type JsonRpcId = number | string;
+
+export interface ResponseError {
+  code: number;
+  message: string;
+  data?: unknown;
+}
+
+export interface JsonRpcMessage {
+  jsonrpc: "2.0";
+  id?: JsonRpcId | null;
+  method?: string;
+  params?: unknown;
+  result?: unknown;
+  error?: ResponseError;
+}
+
+/** JSON-RPC and LSP error codes */
+export const ErrorCodes = {
+  ParseError: -32700,
+  InvalidRequest: -32600,
+  MethodNotFound: -32601,
+  InternalError: -32603,
+  ServerNotInitialized: -32002,
+  RequestCancelled: -32800,
+  ContentModified: -32801,
+} as const;
+
+/**
+ * A body with its Content-Length header, valid JSON or not
+ */
+export function frame(body: string): string {
+  return `Content-Length: ${Buffer.byteLength(body)}\r\n\r\n${body}`;
+}
+
+export function encodeMessage(message: JsonRpcMessage): string {
+  return frame(JSON.stringify(message));
+}
+
+/**
+ * Splits a byte stream into message bodies. Bodies that are not JSON are
+ * passed to `onInvalid` instead of ending the stream.
+ */
+export class MessageReader {
+  private buffer = Buffer.alloc(0);
+
+  constructor(
+    private onMessage: (message: JsonRpcMessage) => void,
+    private onInvalid: (body: string) => void = () => {},
+  ) {}
+
+  push(chunk: Buffer | string): void {
+    this.buffer = Buffer.concat([
+      this.buffer,
+      typeof chunk === "string" ? Buffer.from(chunk) : chunk,
+    ]);
+    while (true) {
+      const headerEnd = this.buffer.indexOf("\r\n\r\n");
+      if (headerEnd === -1) {
+        return;
+      }
+      const header = this.buffer.subarray(0, headerEnd).toString();
+      const match = header.match(/Content-Length: (\d+)/i);
+      if (!match) {
+        this.onInvalid(header);
+        this.buffer = this.buffer.subarray(headerEnd + 4);
+        continue;
+      }
+      const length = Number(match[1]);
+      if (this.buffer.length < headerEnd + 4 + length) {
+        return;
+      }
+      const body = this.buffer
+        .subarray(headerEnd + 4, headerEnd + 4 + length)
+        .toString("utf-8");
+      this.buffer = this.buffer.subarray(headerEnd + 4 + length);
+      let message: JsonRpcMessage;
+      try {
+        message = JSON.parse(body);
+      } catch {
+        this.onInvalid(body);
+        continue;
+      }
+      this.onMessage(message);
+    }
+  }
+}
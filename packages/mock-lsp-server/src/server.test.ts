@@ -0,0 +1,297 @@
+import { describe, it, expect, vi } from "vitest";
+import {
+  encodeMessage,
+  ErrorCodes,
+  frame,
+  MessageReader,
+  type JsonRpcMessage,
+} from "./messages.ts";
+import {
+  MockLanguageServer,
+  reply,
+  serverOptionsFromScript,
+} from "./server.ts";
+
+/** Talk to the server through its process like the LSP client does */
+function connect(server: MockLanguageServer) {
+  const child = server.process;
+  const received: JsonRpcMessage[] = [];
+  const invalid: string[] = [];
+  const reader = new MessageReader(
+    (message) => received.push(message),
+    (body) => invalid.push(body),
+  );
+  child.stdout!.on("data", (chunk: Buffer) => reader.push(chunk));
+  let nextId = 0;
+  const send = (message: Omit<JsonRpcMessage, "jsonrpc">) =>
+    child.stdin!.write(encodeMessage({ jsonrpc: "2.0", ...message }));
+  const request = async (method: string, params?: unknown) => {
+    const id = ++nextId;
+    send({ id, method, params });
+    await vi.waitFor(() => {
+      expect(received.some((message) => message.id === id)).toBe(true);
+    });
+    return received.find((message) => message.id === id)!;
+  };
+  return { child, received, invalid, send, request };
+}
+
+const uri = "file:///project/main.go";
+
+describe("MessageReader", () => {
+  it("should join messages split across chunks", () => {
+    const messages: JsonRpcMessage[] = [];
+    const reader = new MessageReader((message) => messages.push(message));
+    const data = Buffer.from(
+      encodeMessage({ jsonrpc: "2.0", method: "a", params: { text: "é" } }) +
+        encodeMessage({ jsonrpc: "2.0", method: "b" }),
+    );
+
+    // Split inside the two bytes of "é"
+    const split = data.indexOf(Buffer.from("é")) + 1;
+    reader.push(data.subarray(0, split));
+    expect(messages).toEqual([]);
+    reader.push(data.subarray(split));
+
+    expect(messages.map((message) => message.method)).toEqual(["a", "b"]);
+    expect(messages[0].params).toEqual({ text: "é" });
+  });
+
+  it("should report bodies that are not JSON and read on", () => {
+    const messages: JsonRpcMessage[] = [];
+    const invalid: string[] = [];
+    const reader = new MessageReader(
+      (message) => messages.push(message),
+      (body) => invalid.push(body),
+    );
+
+    reader.push(frame("{oops") + encodeMessage({ jsonrpc: "2.0", id: 1 }));
+
+    expect(invalid).toEqual(["{oops"]);
+    expect(messages).toEqual([{ jsonrpc: "2.0", id: 1 }]);
+  });
+});
+
+describe("MockLanguageServer", () => {
+  it("should answer initialize and reject unscripted requests", async () => {
+    const server = new MockLanguageServer({
+      capabilities: { hoverProvider: true },
+    });
+    const { request } = connect(server);
+
+    const initialize = await request("initialize", { rootUri: "file:///" });
+    expect(initialize.result).toEqual({
+      capabilities: { textDocumentSync: 1, hoverProvider: true },
+      serverInfo: { name: "mock-lsp-server" },
+    });
+    expect((await request("textDocument/hover")).error).toEqual({
+      code: ErrorCodes.MethodNotFound,
+      message: "Unhandled method textDocument/hover",
+    });
+    expect(server.messages("initialize")[0].params).toEqual({
+      rootUri: "file:///",
+    });
+  });
+
+  it("should answer with canned results, handlers and errors", async () => {
+    const server = new MockLanguageServer({
+      responses: { "textDocument/hover": { contents: "func main()" } },
+    })
+      .respond("textDocument/definition", (params) => [
+        { uri: params.textDocument.uri, range: null },
+      ])
+      .respond(
+        "textDocument/references",
+        reply.error(ErrorCodes.ContentModified, "Content modified"),
+      )
+      .respond("textDocument/rename", () => {
+        throw new Error("cannot rename");
+      });
+    const { request } = connect(server);
+
+    expect((await request("textDocument/hover")).result).toEqual({
+      contents: "func main()",
+    });
+    expect(
+      (await request("textDocument/definition", { textDocument: { uri } }))
+        .result,
+    ).toEqual([{ uri, range: null }]);
+    expect((await request("textDocument/references")).error).toEqual({
+      code: ErrorCodes.ContentModified,
+      message: "Content modified",
+    });
+    expect((await request("textDocument/rename")).error).toEqual({
+      code: ErrorCodes.InternalError,
+      message: "cannot rename",
+    });
+  });
+
+  it("should keep documents up to date", async () => {
+    const server = new MockLanguageServer();
+    const { send } = connect(server);
+
+    send({
+      method: "textDocument/didOpen",
+      params: { textDocument: { uri, text: "package main\n\nfunc a() {}\n" } },
+    });
+    send({
+      method: "textDocument/didChange",
+      params: {
+        textDocument: { uri, version: 2 },
+        contentChanges: [
+          {
+            range: {
+              start: { line: 2, character: 5 },
+              end: { line: 2, character: 6 },
+            },
+            text: "b",
+          },
+        ],
+      },
+    });
+    await vi.waitFor(() => {
+      expect(server.documents.get(uri)).toBe("package main\n\nfunc b() {}\n");
+    });
+
+    send({
+      method: "textDocument/didClose",
+      params: { textDocument: { uri } },
+    });
+    await vi.waitFor(() => {
+      expect(server.documents.has(uri)).toBe(false);
+    });
+  });
+
+  it("should hold back delayed responses and honour cancellation", async () => {
+    const server = new MockLanguageServer({
+      responses: { "textDocument/hover": null },
+      delays: { "textDocument/hover": 50 },
+    });
+    const { request, send, received } = connect(server);
+
+    const started = Date.now();
+    await request("textDocument/hover");
+    expect(Date.now() - started).toBeGreaterThanOrEqual(45);
+
+    send({ id: 10, method: "textDocument/hover" });
+    send({ method: "$/cancelRequest", params: { id: 10 } });
+    await vi.waitFor(() => {
+      expect(received.find((message) => message.id === 10)?.error).toEqual({
+        code: ErrorCodes.RequestCancelled,
+        message: "Request cancelled",
+      });
+    });
+  });
+
+  it("should write malformed output and leave requests unanswered", async () => {
+    const server = new MockLanguageServer({
+      responses: {
+        "textDocument/hover": reply.raw(frame('{"jsonrpc":"2.0","id":')),
+        "textDocument/completion": reply.none(),
+      },
+    });
+    const { request, send, received, invalid } = connect(server);
+
+    send({ id: 101, method: "textDocument/hover" });
+    send({ id: 102, method: "textDocument/completion" });
+    // Later responses still arrive
+    await request("shutdown");
+
+    expect(invalid).toEqual(['{"jsonrpc":"2.0","id":']);
+    expect(received.map((message) => message.id)).toEqual([1]);
+  });
+
+  it("should send notifications and requests to the client", async () => {
+    const server = new MockLanguageServer();
+    const { send, received } = connect(server);
+
+    server.publishDiagnostics(uri, [{ message: "unused variable" }]);
+    const configuration = server.request("workspace/configuration", {
+      items: [{ section: "gopls" }],
+    });
+    await vi.waitFor(() => expect(received).toHaveLength(2));
+    send({ id: received[1].id, result: [{ staticcheck: true }] });
+
+    expect(received[0]).toEqual({
+      jsonrpc: "2.0",
+      method: "textDocument/publishDiagnostics",
+      params: { uri, diagnostics: [{ message: "unused variable" }] },
+    });
+    expect(await configuration).toEqual([{ staticcheck: true }]);
+  });
+
+  it("should exit with 0 after shutdown and 1 without", async () => {
+    const exits: unknown[][] = [];
+    for (const shutdown of [true, false]) {
+      const server = new MockLanguageServer();
+      const { child, request, send } = connect(server);
+      const exited = new Promise<void>((resolve) =>
+        child.once("exit", (...args) => {
+          exits.push(args);
+          resolve();
+        }),
+      );
+      if (shutdown) {
+        await request("shutdown");
+      }
+      send({ method: "exit" });
+      await exited;
+    }
+
+    expect(exits).toEqual([
+      [0, null],
+      [1, null],
+    ]);
+  });
+
+  it("should exit with SIGTERM when killed and report crashes", async () => {
+    const killed = new MockLanguageServer();
+    const onKill = new Promise<unknown[]>((resolve) =>
+      killed.process.once("exit", (...args) => resolve(args)),
+    );
+    expect(killed.process.kill()).toBe(true);
+    expect(killed.process.killed).toBe(true);
+    expect(await onKill).toEqual([null, "SIGTERM"]);
+
+    const crashed = new MockLanguageServer();
+    let stderr = "";
+    crashed.process.stderr!.on("data", (data) => (stderr += data));
+    const onCrash = new Promise<unknown[]>((resolve) =>
+      crashed.process.once("exit", (...args) => resolve(args)),
+    );
+    crashed.crash(2, "panic: out of memory\n");
+    expect(await onCrash).toEqual([2, null]);
+    expect(stderr).toBe("panic: out of memory\n");
+  });
+});
+
+describe("serverOptionsFromScript", () => {
+  it("should turn errors into replies and notify after initialized", async () => {
+    const server = new MockLanguageServer(
+      serverOptionsFromScript({
+        responses: { "textDocument/hover": { contents: "x" } },
+        errors: { "textDocument/hover": { code: -32603, message: "boom" } },
+        notifications: [
+          {
+            method: "textDocument/publishDiagnostics",
+            params: { uri, diagnostics: [] },
+          },
+        ],
+      }),
+    );
+    const { request, send, received } = connect(server);
+
+    expect((await request("textDocument/hover")).error).toEqual({
+      code: -32603,
+      message: "boom",
+    });
+    send({ method: "initialized", params: {} });
+    await vi.waitFor(() => {
+      expect(
+        received.some(
+          (message) => message.method === "textDocument/publishDiagnostics",
+        ),
+      ).toBe(true);
+    });
+  });
+});
@@ -0,0 +1,484 @@
+/**
+ * Scriptable language server for tests
+ *
+ * Speaks the LSP base protocol like a real server, but every answer comes
+ * from the test: canned results or handlers per method, induced delays,
+ * errors and malformed output. `server.process` can be handed to the LSP
+ * client in place of a spawned gopls or tsgo, so adapter and tool tests run
+ * without a language server installed and behave the same on every machine.
+ */
+
+import { EventEmitter } from "events";
+import { PassThrough, type Readable, type Writable } from "stream";
+import type { ChildProcess } from "child_process";
+import {
+  encodeMessage,
+  ErrorCodes,
+  MessageReader,
+  type JsonRpcId,
+  type JsonRpcMessage,
+  type ResponseError,
+} from "./messages.ts";
+
+const REPLY = Symbol("mockReply");
+
+export type MockReply =
+  | { [REPLY]: "error"; error: ResponseError }
+  | { [REPLY]: "raw"; data: string }
+  | { [REPLY]: "none" };
+
+/** Answers other than a result */
+export const reply = {
+  /** Respond with a JSON-RPC error */
+  error(code: number, message: string, data?: unknown): MockReply {
+    return {
+      [REPLY]: "error",
+      error: { code, message, ...(data !== undefined && { data }) },
+    };
+  },
+  /** Write `data` verbatim instead of a response, e.g. a malformed message */
+  raw(data: string): MockReply {
+    return { [REPLY]: "raw", data };
+  },
+  /** Never respond, so the request runs into the client's timeout */
+  none(): MockReply {
+    return { [REPLY]: "none" };
+  },
+};
+
+function isReply(value: unknown): value is MockReply {
+  return typeof value === "object" && value !== null && REPLY in value;
+}
+
+export type MockHandler = (
+  params: any,
+  server: MockLanguageServer,
+) => unknown | Promise<unknown>;
+
+/**
+ * What a method answers with: a result, a reply, or a handler returning
+ * either. Handlers also run for notifications, whose answer is dropped.
+ */
+export type MockResponse = MockHandler | MockReply | unknown;
+
+export interface MockServerOptions {
+  /** Sent in the initialize result; full document sync by default */
+  capabilities?: Record<string, unknown>;
+  serverInfo?: { name: string; version?: string };
+  /** Canned responses per method */
+  responses?: Record<string, MockResponse>;
+  /** Milliseconds each response to a method is held back; "*" for all */
+  delays?: Record<string, number>;
+}
+
+/**
+ * Server side of a mock-lsp-server script file (see bin.ts)
+ */
+export interface MockServerScript {
+  capabilities?: Record<string, unknown>;
+  serverInfo?: { name: string; version?: string };
+  /** Results per method */
+  responses?: Record<string, unknown>;
+  /** Errors per method, taking precedence over results */
+  errors?: Record<string, ResponseError>;
+  delays?: Record<string, number>;
+  /** Sent once the client reports initialized */
+  notifications?: Array<{ method: string; params?: unknown }>;
+}
+
+export function serverOptionsFromScript(
+  script: MockServerScript,
+): MockServerOptions {
+  const responses: Record<string, MockResponse> = { ...script.responses };
+  for (const [method, error] of Object.entries(script.errors ?? {})) {
+    responses[method] = reply.error(error.code, error.message, error.data);
+  }
+  if (script.notifications?.length) {
+    responses.initialized = (_params: unknown, server: MockLanguageServer) => {
+      for (const notification of script.notifications!) {
+        server.notify(notification.method, notification.params);
+      }
+    };
+  }
+  return {
+    capabilities: script.capabilities,
+    serverInfo: script.serverInfo,
+    responses,
+    delays: script.delays,
+  };
+}
+
+interface Position {
+  line: number;
+  character: number;
+}
+
+interface ContentChange {
+  range?: { start: Position; end: Position };
+  text: string;
+}
+
+/** Offset of a position, with characters counted in UTF-16 code units */
+function offsetAt(text: string, position: Position): number {
+  let offset = 0;
+  for (let line = 0; line < position.line; line++) {
+    const next = text.indexOf("\n", offset);
+    if (next === -1) {
+      return text.length;
+    }
+    offset = next + 1;
+  }
+  return Math.min(offset + position.character, text.length);
+}
+
+function applyChange(text: string, change: ContentChange): string {
+  if (!change.range) {
+    return change.text;
+  }
+  return (
+    text.slice(0, offsetAt(text, change.range.start)) +
+    change.text +
+    text.slice(offsetAt(text, change.range.end))
+  );
+}
+
+const sleep = (ms: number) =>
+  new Promise<void>((resolve) => setTimeout(resolve, ms));
+
+/**
+ * Emits "message" for every message received and "exit" (code, signal)
+ * when it stops.
+ */
+export class MockLanguageServer extends EventEmitter {
+  /** Every message received from the client, in order */
+  readonly received: JsonRpcMessage[] = [];
+  /** Messages received that were not valid JSON-RPC framing or JSON */
+  readonly invalid: string[] = [];
+  /** Text of the open documents by URI, kept up to date with didChange */
+  readonly documents = new Map<string, string>();
+
+  private capabilities: Record<string, unknown>;
+  private serverInfo: { name: string; version?: string };
+  private responses = new Map<string, MockResponse>();
+  private delays = new Map<string, number>();
+  private cancelled = new Set<JsonRpcId>();
+  private pending = new Map<
+    JsonRpcId,
+    { resolve: (result: unknown) => void; reject: (error: Error) => void }
+  >();
+  private nextRequestId = 0;
+  private output?: Writable;
+  private errorOutput?: Writable;
+  private shutdownRequested = false;
+  private exited = false;
+  private child?: ChildProcess;
+
+  constructor(options: MockServerOptions = {}) {
+    super();
+    this.capabilities = { textDocumentSync: 1, ...options.capabilities };
+    this.serverInfo = options.serverInfo ?? { name: "mock-lsp-server" };
+    for (const [method, response] of Object.entries(options.responses ?? {})) {
+      this.responses.set(method, response);
+    }
+    for (const [method, delay] of Object.entries(options.delays ?? {})) {
+      this.delays.set(method, delay);
+    }
+  }
+
+  /** Answer `method` with `response` from now on */
+  respond(method: string, response: MockResponse): this {
+    this.responses.set(method, response);
+    return this;
+  }
+
+  /** Hold back responses to `method` ("*" for all) by `ms` */
+  delay(method: string, ms: number): this {
+    this.delays.set(method, ms);
+    return this;
+  }
+
+  /**
+   * Serve the client on the other end of a pair of streams
+   */
+  listen(input: Readable, output: Writable, errorOutput?: Writable): void {
+    this.output = output;
+    this.errorOutput = errorOutput;
+    const reader = new MessageReader(
+      (message) => this.handle(message),
+      (body) => this.invalid.push(body),
+    );
+    input.on("data", (chunk: Buffer) => {
+      if (!this.exited) {
+        reader.push(chunk);
+      }
+    });
+  }
+
+  /**
+   * A ChildProcess stand-in connected to this server, for the LSP client.
+   * kill() stops the server and exits with SIGTERM.
+   */
+  get process(): ChildProcess {
+    if (!this.child) {
+      this.child = this.createProcess();
+    }
+    return this.child;
+  }
+
+  notify(method: string, params?: unknown): void {
+    this.write({ jsonrpc: "2.0", method, params });
+  }
+
+  publishDiagnostics(uri: string, diagnostics: unknown[]): void {
+    this.notify("textDocument/publishDiagnostics", { uri, diagnostics });
+  }
+
+  /**
+   * Send a request to the client (e.g. workspace/configuration) and wait
+   * for its answer
+   */
+  request<T = unknown>(method: string, params?: unknown): Promise<T> {
+    const id = `mock-${++this.nextRequestId}`;
+    return new Promise<T>((resolve, reject) => {
+      this.pending.set(id, {
+        resolve: resolve as (result: unknown) => void,
+        reject,
+      });
+      this.write({ jsonrpc: "2.0", id, method, params });
+    });
+  }
+
+  /** Write bytes to the client as they are, framed or not */
+  writeRaw(data: string): void {
+    if (!this.exited) {
+      this.output?.write(data);
+    }
+  }
+
+  /** Write to stderr, where clients look for startup failure details */
+  log(text: string): void {
+    this.errorOutput?.write(text);
+  }
+
+  /** Received messages of a method */
+  messages(method: string): JsonRpcMessage[] {
+    return this.received.filter((message) => message.method === method);
+  }
+
+  /**
+   * The next message of `method` to arrive
+   */
+  waitFor(method: string, timeout = 5000): Promise<JsonRpcMessage> {
+    return new Promise((resolve, reject) => {
+      const onMessage = (message: JsonRpcMessage) => {
+        if (message.method === method) {
+          clearTimeout(timer);
+          this.off("message", onMessage);
+          resolve(message);
+        }
+      };
+      const timer = setTimeout(() => {
+        this.off("message", onMessage);
+        reject(
+          new Error(`Timed out waiting for ${method} after ${timeout}ms`),
+        );
+      }, timeout);
+      this.on("message", onMessage);
+    });
+  }
+
+  /** Exit as if the server process crashed */
+  crash(code = 1, stderr?: string): void {
+    if (stderr) {
+      this.log(stderr);
+    }
+    this.terminate(code, null);
+  }
+
+  private terminate(code: number | null, signal: NodeJS.Signals | null): void {
+    if (this.exited) {
+      return;
+    }
+    this.exited = true;
+    for (const { reject } of this.pending.values()) {
+      reject(new Error("Mock language server exited"));
+    }
+    this.pending.clear();
+    this.emit("exit", code, signal);
+  }
+
+  private write(message: JsonRpcMessage): void {
+    if (!this.exited) {
+      this.output?.write(encodeMessage(message));
+    }
+  }
+
+  private handle(message: JsonRpcMessage): void {
+    this.received.push(message);
+    if (message.method === undefined) {
+      this.handleResponse(message);
+    } else if (message.id === undefined || message.id === null) {
+      this.handleNotification(message.method, message.params);
+    } else {
+      void this.handleRequest(message.id, message.method, message.params);
+    }
+    this.emit("message", message);
+  }
+
+  private handleResponse(message: JsonRpcMessage): void {
+    const pending =
+      message.id !== undefined && message.id !== null
+        ? this.pending.get(message.id)
+        : undefined;
+    if (!pending) {
+      return;
+    }
+    this.pending.delete(message.id!);
+    if (message.error) {
+      pending.reject(new Error(message.error.message));
+    } else {
+      pending.resolve(message.result);
+    }
+  }
+
+  private handleNotification(method: string, params: any): void {
+    switch (method) {
+      case "textDocument/didOpen":
+        this.documents.set(params.textDocument.uri, params.textDocument.text);
+        break;
+      case "textDocument/didChange": {
+        const uri = params.textDocument.uri;
+        let text = this.documents.get(uri) ?? "";
+        for (const change of params.contentChanges as ContentChange[]) {
+          text = applyChange(text, change);
+        }
+        this.documents.set(uri, text);
+        break;
+      }
+      case "textDocument/didClose":
+        this.documents.delete(params.textDocument.uri);
+        break;
+      case "$/cancelRequest":
+        this.cancelled.add(params.id);
+        break;
+      case "exit":
+        // Exit code 1 without a shutdown request first, as the spec says
+        this.terminate(this.shutdownRequested ? 0 : 1, null);
+        return;
+    }
+    const response = this.responses.get(method);
+    if (typeof response === "function") {
+      void Promise.resolve()
+        .then(() => (response as MockHandler)(params, this))
+        .catch(() => {});
+    }
+  }
+
+  private async handleRequest(
+    id: JsonRpcId,
+    method: string,
+    params: unknown,
+  ): Promise<void> {
+    if (method === "shutdown") {
+      this.shutdownRequested = true;
+    }
+    const delay = this.delays.get(method) ?? this.delays.get("*") ?? 0;
+    if (delay > 0) {
+      await sleep(delay);
+    }
+    if (this.cancelled.delete(id)) {
+      this.write({
+        jsonrpc: "2.0",
+        id,
+        error: {
+          code: ErrorCodes.RequestCancelled,
+          message: "Request cancelled",
+        },
+      });
+      return;
+    }
+
+    let answer: unknown;
+    try {
+      answer = await this.answer(method, params);
+    } catch (error) {
+      answer = reply.error(
+        ErrorCodes.InternalError,
+        error instanceof Error ? error.message : String(error),
+      );
+    }
+
+    if (!isReply(answer)) {
+      this.write({ jsonrpc: "2.0", id, result: answer ?? null });
+      return;
+    }
+    switch (answer[REPLY]) {
+      case "error":
+        this.write({ jsonrpc: "2.0", id, error: answer.error });
+        break;
+      case "raw":
+        this.writeRaw(answer.data);
+        break;
+      case "none":
+        break;
+    }
+  }
+
+  private answer(method: string, params: unknown): unknown {
+    if (this.responses.has(method)) {
+      const response = this.responses.get(method);
+      return typeof response === "function"
+        ? (response as MockHandler)(params, this)
+        : response;
+    }
+    switch (method) {
+      case "initialize":
+        return { capabilities: this.capabilities, serverInfo: this.serverInfo };
+      case "shutdown":
+        return null;
+    }
+    return reply.error(ErrorCodes.MethodNotFound, `Unhandled method ${method}`);
+  }
+
+  private createProcess(): ChildProcess {
+    const stdin = new PassThrough();
+    const stdout = new PassThrough();
+    const stderr = new PassThrough();
+    const child = new EventEmitter() as EventEmitter & {
+      stdin: PassThrough;
+      stdout: PassThrough;
+      stderr: PassThrough;
+      pid: undefined;
+      killed: boolean;
+      exitCode: number | null;
+      kill: (signal?: NodeJS.Signals) => boolean;
+    };
+    child.stdin = stdin;
+    child.stdout = stdout;
+    child.stderr = stderr;
+    child.pid = undefined;
+    child.killed = false;
+    child.exitCode = null;
+    child.kill = (signal = "SIGTERM") => {
+      if (this.exited) {
+        return false;
+      }
+      child.killed = true;
+      this.terminate(null, signal);
+      return true;
+    };
+
+    this.listen(stdin, stdout, stderr);
+    this.once("exit", (code: number | null, signal: NodeJS.Signals | null) => {
+      child.exitCode = code;
+      // Like a real process, output already written arrives before the exit
+      setImmediate(() => {
+        stdout.end();
+        stderr.end();
+        child.emit("exit", code, signal);
+        child.emit("close", code, signal);
+      });
+    });
+    return child as unknown as ChildProcess;
+  }
+}
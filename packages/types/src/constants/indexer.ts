@@ -3,7 +3,7 @@
  */
 
 // Cache constants
-export const SYMBOL_CACHE_SCHEMA_VERSION = 2;
+export const SYMBOL_CACHE_SCHEMA_VERSION = 3;
 export const INDEX_BATCH_SIZE = 10; // Files to process in parallel
 export const INDEX_CONCURRENCY_DEFAULT = 5;
 export const INDEX_CONCURRENCY_MAX = 20;
@@ -2,9 +2,21 @@
  * MCP (Model Context Protocol) related types
  */
 
-import type { z, ZodType } from "zod";
+import type { z, ZodRawShape, ZodType } from "zod";
 import type { FileSystemApi } from "./filesystem.ts";
 
+/**
+ * Progress update for a running tool call
+ */
+export interface McpProgress {
+  /** Work done so far (e.g. files processed) */
+  progress: number;
+  /** Total amount of work, if known */
+  total?: number;
+  /** Human readable status (e.g. current file) */
+  message?: string;
+}
+
 /**
  * MCP execution context passed to tools
  */
@@ -17,6 +29,22 @@ export interface McpContext {
   config?: Record<string, unknown>;
   /** Language ID or preset ID for language-specific handling */
   languageId?: string;
+  /** Workspace roots, primary root first (multi-root workspaces) */
+  roots?: string[];
+  /** Nothing may be written to disk (--read-only) */
+  readOnly?: boolean;
+  /** Report progress for the current call (set when the client sent a progressToken) */
+  reportProgress?: (update: McpProgress) => void;
+  /** Aborted when the client cancels the current call */
+  signal?: AbortSignal;
+  /** MCP session of the current call (HTTP transport; stdio has none) */
+  sessionId?: string;
+  /** Ask the user a yes/no question (set when the client supports elicitation) */
+  confirm?: (message: string) => Promise<boolean>;
+  /** Ask the client's model for a completion (set when the client supports sampling) */
+  sample?: (prompt: string, maxTokens: number) => Promise<string>;
+  /** Attach the machine-readable result of the current call (see McpToolDef.outputSchema) */
+  setStructuredContent?: (content: Record<string, unknown>) => void;
 }
 
 /**
@@ -27,6 +55,16 @@ export interface McpToolDef<TSchema extends ZodType> {
   description: string;
   schema: TSchema;
   execute: (args: z.infer<TSchema>, context?: McpContext) => Promise<string>;
+  /**
+   * Shape of the structured result the tool passes to
+   * context.setStructuredContent; tools without one return { text }
+   */
+  outputSchema?: ZodRawShape;
+  /**
+   * Arguments holding paths besides root, relativePath, filePath, file and
+   * path, checked against the sandbox as well
+   */
+  pathArgs?: string[];
 }
 
 /**
@@ -108,6 +108,8 @@ export type {
 export type {
   // Diagnostics
   DiagnosticResult,
+  DocumentDiagnosticReport,
+  WorkspaceDiagnosticReport,
   DiagnosticStats,
   FormattedDiagnostic,
 } from "./lsp/diagnostics.ts";
@@ -157,6 +159,7 @@ export type {
 export type {
   // MCP types
   McpContext,
+  McpProgress,
   McpToolDef,
   McpServerOptions,
 } from "./domain/mcp.ts";
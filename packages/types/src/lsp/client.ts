@@ -4,10 +4,13 @@ import {
   CompletionItem,
   CompletionList,
   Diagnostic,
+  DocumentLink,
   DocumentSymbol,
   DocumentUri,
+  FoldingRange,
   FormattingOptions,
   Hover,
+  InlayHint,
   integer,
   Location,
   LocationLink,
@@ -48,6 +51,7 @@ export {
 import type { ChildProcess } from "child_process";
 import type { EventEmitter } from "node:events";
 import type { FileSystemApi } from "../domain/filesystem.ts";
+import type { DiagnosticResult } from "./diagnostics.ts";
 
 // LSP Message types
 export interface LSPRequest {
@@ -106,6 +110,13 @@ export interface PublishDiagnosticsParams {
   diagnostics: Diagnostic[];
 }
 
+/** rust-analyzer experimental/serverStatus notification */
+export interface ServerStatusParams {
+  health: "ok" | "warning" | "error";
+  quiescent: boolean;
+  message?: string;
+}
+
 export interface ReferenceContext {
   includeDeclaration: boolean;
 }
@@ -200,7 +211,19 @@ export interface ServerCapabilities {
     | boolean
     | {
         codeActionKinds?: string[];
+        resolveProvider?: boolean;
       };
+  executeCommandProvider?: {
+    commands: string[];
+  };
+  semanticTokensProvider?: {
+    legend: SemanticTokensLegend;
+    range?: boolean | Record<string, unknown>;
+    full?: boolean | { delta?: boolean };
+  };
+  inlayHintProvider?: boolean | { resolveProvider?: boolean };
+  documentLinkProvider?: { resolveProvider?: boolean };
+  foldingRangeProvider?: boolean | Record<string, unknown>;
   diagnosticProvider?: {
     identifier?: string;
     interFileDependencies?: boolean;
@@ -234,6 +257,26 @@ export interface ServerCapabilities {
   [key: string]: unknown;
 }
 
+export interface SemanticTokensLegend {
+  tokenTypes: string[];
+  tokenModifiers: string[];
+}
+
+/**
+ * Semantic token decoded to an absolute position
+ */
+export interface SemanticToken {
+  /** 0-based line */
+  line: number;
+  /** 0-based start character */
+  character: number;
+  length: number;
+  /** Token type name from the server legend (e.g. "function", "comment") */
+  type: string;
+  /** Token modifier names (e.g. "declaration", "readonly") */
+  modifiers: string[];
+}
+
 export interface InitializeResult {
   capabilities: ServerCapabilities;
   serverInfo?: {
@@ -347,9 +390,33 @@ export type LSPClient = {
   stop: () => Promise<void>;
   openDocument: (uri: string, text: string, languageId?: string) => void;
   closeDocument: (uri: string) => void;
-  updateDocument: (uri: string, text: string, version: number) => void;
+  updateDocument: (uri: string, text: string, version?: number) => void;
+  saveDocument?: (uri: string, text?: string) => void;
+  /** A file changed outside the client, e.g. on disk; drops cached results */
+  fileChanged?: (uri: string) => void;
   isDocumentOpen: (uri: string) => boolean;
-  findReferences: (uri: string, position: Position) => Promise<Location[]>;
+  getOpenDocuments?: () => {
+    uri: string;
+    text: string;
+    languageId: string;
+    version: number;
+  }[];
+  getServerStatus?: () => ServerStatusParams | undefined;
+  getWorkspaceFolders?: () => string[];
+  changeWorkspaceFolders?: (added: string[], removed?: string[]) => void;
+  willRenameFiles?: (
+    files: { oldUri: string; newUri: string }[],
+  ) => Promise<WorkspaceEdit | null>;
+  didRenameFiles?: (files: { oldUri: string; newUri: string }[]) => void;
+  findReferences: (
+    uri: string,
+    position: Position,
+    options?: {
+      workDoneToken?: string | number;
+      signal?: AbortSignal;
+      includeDeclaration?: boolean;
+    },
+  ) => Promise<Location[]>;
   getDefinition: (
     uri: string,
     position: Position,
@@ -357,21 +424,31 @@ export type LSPClient = {
   getHover: (uri: string, position: Position) => Promise<HoverResult>;
   getDiagnostics: (uri: string) => Diagnostic[];
   pullDiagnostics?: (uri: string) => Promise<Diagnostic[]>;
+  pullWorkspaceDiagnostics?: () => Promise<DiagnosticResult[]>;
   getDocumentSymbols: (
     uri: string,
   ) => Promise<DocumentSymbol[] | SymbolInformation[]>;
-  getWorkspaceSymbols: (query: string) => Promise<SymbolInformation[]>;
+  getWorkspaceSymbols: (
+    query: string,
+    options?: { signal?: AbortSignal },
+  ) => Promise<SymbolInformation[]>;
   getCompletion: (uri: string, position: Position) => Promise<CompletionItem[]>;
   resolveCompletionItem: (item: CompletionItem) => Promise<CompletionItem>;
   getSignatureHelp: (
     uri: string,
     position: Position,
   ) => Promise<SignatureHelp | null>;
+  getSemanticTokens?: (uri: string) => Promise<SemanticToken[]>;
+  getInlayHints?: (uri: string, range: Range) => Promise<InlayHint[]>;
+  getDocumentLinks?: (uri: string) => Promise<DocumentLink[]>;
+  getFoldingRanges?: (uri: string) => Promise<FoldingRange[]>;
   getCodeActions: (
     uri: string,
     range: Range,
-    context?: { diagnostics?: Diagnostic[] },
+    context?: { diagnostics?: Diagnostic[]; only?: string[] },
   ) => Promise<(Command | CodeAction)[]>;
+  resolveCodeAction?: (action: CodeAction) => Promise<CodeAction>;
+  executeCommand?: (command: string, args?: unknown[]) => Promise<unknown>;
   formatDocument: (
     uri: string,
     options: FormattingOptions,
@@ -402,6 +479,7 @@ export type LSPClient = {
   on(event: string, listener: (...args: unknown[]) => void): void;
   emit(event: "diagnostics", params: PublishDiagnosticsParams): boolean;
   emit(event: string, ...args: unknown[]): boolean;
+  off?: (event: string, listener: (...args: any[]) => void) => void;
   waitForDiagnostics: (
     fileUri: string,
     timeout?: number,
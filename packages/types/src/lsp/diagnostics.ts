@@ -9,6 +9,17 @@ export interface DocumentDiagnosticReport {
   resultId?: string;
 }
 
+// Workspace pull diagnostics (workspace/diagnostic)
+export interface WorkspaceDocumentDiagnosticReport
+  extends DocumentDiagnosticReport {
+  uri: string;
+  version: number | null;
+}
+
+export interface WorkspaceDiagnosticReport {
+  items: WorkspaceDocumentDiagnosticReport[];
+}
+
 export interface DiagnosticResult {
   uri: string;
   diagnostics: Diagnostic[];
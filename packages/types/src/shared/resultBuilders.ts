@@ -13,6 +13,7 @@ export interface SimpleDiagnostic {
   endColumn?: number;
   message: string;
   source?: string;
+  code?: string | number;
 }
 
 /**
@@ -39,6 +40,7 @@ export class DiagnosticResultBuilder {
       endColumn: diagnostic.range.end.character + 1,
       message: diagnostic.message,
       source: diagnostic.source,
+      code: diagnostic.code,
     });
     return this;
   }
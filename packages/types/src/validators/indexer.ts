@@ -53,7 +53,7 @@ export const indexFilesSchema = z.object({
   concurrency: z
     .number()
     .min(1)
-    .max(20)
+    .max(64)
     .optional()
     .describe("Number of files to index in parallel"),
 });
@@ -2,7 +2,7 @@
  * Doctor command for analyzing environment and suggesting MCP configurations
  */
 
-import { existsSync } from "fs";
+import { existsSync, readFileSync } from "fs";
 import { readFile } from "fs/promises";
 import { join } from "path";
 import { execSync } from "child_process";
@@ -10,6 +10,14 @@ import { PresetRegistry, globalPresetRegistry } from "../config/loader.ts";
 import { resolveAdapterCommand } from "../presets/utils.ts";
 import type { Preset } from "../config/schema.ts";
 import { registerBuiltinAdapters } from "../config/presets.ts";
+import {
+  checkConfig,
+  checkIndexDirectory,
+  checkInitialize,
+  checkServerVersion,
+  checkWorkspaceMarkers,
+  type DoctorCheck,
+} from "./doctorChecks.ts";
 
 export interface DoctorResult {
   projectRoot: string;
@@ -17,6 +25,7 @@ export interface DoctorResult {
   availableServers: AvailableServer[];
   mcpConfigurations: McpConfiguration[];
   claudeCodeCommands: string[];
+  checks: DoctorCheck[];
 }
 
 export interface DetectedLanguage {
@@ -163,6 +172,22 @@ async function detectLanguages(
     });
   }
 
+  // Check for C/C++
+  const cBuildFile = [
+    "compile_commands.json",
+    "CMakeLists.txt",
+    "meson.build",
+    "compile_flags.txt",
+  ].find((file) => existsSync(join(projectRoot, file)));
+  if (cBuildFile) {
+    languages.push({
+      name: "C/C++",
+      reason: `${cBuildFile} found`,
+      files: ["**/*.c", "**/*.h", "**/*.cc", "**/*.cpp", "**/*.hpp"],
+      preset: "clangd",
+    });
+  }
+
   // Check for MoonBit
   if (existsSync(join(projectRoot, "moon.mod.json"))) {
     languages.push({
@@ -249,6 +274,9 @@ async function checkAvailableServers(
           case "pyright":
             server.installCommand = "npm install -g pyright";
             break;
+          case "basedpyright":
+            server.installCommand = "pip install basedpyright";
+            break;
           case "ruff":
             server.installCommand = "pip install ruff-lsp";
             break;
@@ -262,6 +290,10 @@ async function checkAvailableServers(
           case "fsharp":
             server.installCommand = "dotnet tool install -g fsautocomplete";
             break;
+          case "clangd":
+            server.installCommand =
+              "apt install clangd  # macOS: brew install llvm";
+            break;
         }
 
         servers.push(server);
@@ -292,8 +324,69 @@ function generateMcpConfigurations(
   return configurations;
 }
 
+/**
+ * Preset named in .lsmcp/config.json, if the file can be read
+ */
+function configuredPreset(projectRoot: string): string | undefined {
+  try {
+    const config = JSON.parse(
+      readFileSync(join(projectRoot, ".lsmcp", "config.json"), "utf-8"),
+    );
+    return typeof config.preset === "string" ? config.preset : undefined;
+  } catch {
+    return undefined;
+  }
+}
+
+/**
+ * Check the config, workspace, index directory and installed servers, then
+ * run an initialize round-trip against one server
+ */
+async function runChecks(
+  projectRoot: string,
+  servers: AvailableServer[],
+  adapterRegistry: PresetRegistry,
+  preset?: string,
+): Promise<DoctorCheck[]> {
+  const checks = [
+    checkConfig(projectRoot),
+    checkWorkspaceMarkers(projectRoot),
+    checkIndexDirectory(projectRoot),
+  ];
+  for (const server of servers) {
+    if (server.installed && server.command) {
+      checks.push(checkServerVersion(server.preset, server.command));
+    }
+  }
+
+  // Prefer the preset the project is configured with
+  const smokePreset =
+    preset ??
+    configuredPreset(projectRoot) ??
+    servers.find((s) => s.installed)?.preset;
+  const adapter = smokePreset ? adapterRegistry.get(smokePreset) : undefined;
+  if (adapter) {
+    console.log(`⏳ Starting ${adapter.name || smokePreset}...\n`);
+    checks.push(await checkInitialize(adapter as Preset, projectRoot));
+  }
+  return checks;
+}
+
+function printChecks(checks: DoctorCheck[]): void {
+  const icons = { ok: "✅", warn: "⚠️ ", fail: "❌" };
+  console.log("🩺 Checks:");
+  for (const check of checks) {
+    console.log(`  ${icons[check.status]} ${check.name}: ${check.detail}`);
+    if (check.fix) {
+      console.log(`      Fix: ${check.fix}`);
+    }
+  }
+  console.log();
+}
+
 /**
  * Run doctor command
+ * @returns false when a check failed
  */
 export async function doctorCommand(
   projectRoot: string,
@@ -301,7 +394,7 @@ export async function doctorCommand(
     preset?: string;
     json?: boolean;
   },
-): Promise<void> {
+): Promise<boolean> {
   // Use global preset registry and register built-in adapters
   const adapterRegistry = globalPresetRegistry;
   registerBuiltinAdapters(adapterRegistry);
@@ -352,12 +445,19 @@ export async function doctorCommand(
       case "pyright":
         server.installCommand = "npm install -g pyright";
         break;
+      case "basedpyright":
+        server.installCommand = "pip install basedpyright";
+        break;
       case "rust-analyzer":
         server.installCommand = "rustup component add rust-analyzer";
         break;
       case "gopls":
         server.installCommand = "go install golang.org/x/tools/gopls@latest";
         break;
+      case "clangd":
+        server.installCommand =
+          "apt install clangd  # macOS: brew install llvm";
+        break;
     }
 
     if (!installed) {
@@ -369,12 +469,21 @@ export async function doctorCommand(
     }
 
     console.log(`✅ ${server.name} is installed\n`);
+
+    const checks = await runChecks(
+      projectRoot,
+      [server],
+      adapterRegistry,
+      options.preset,
+    );
+    printChecks(checks);
+
     console.log("📋 Setup Command:");
     console.log(
       `  claude mcp add lsmcp npx -- -y @mizchi/lsmcp -p ${options.preset}\n`,
     );
 
-    return;
+    return checks.every((check) => check.status !== "fail");
   }
 
   // Detect languages
@@ -389,7 +498,10 @@ export async function doctorCommand(
     console.log("  - Go");
     console.log("  - F#");
     console.log("  - MoonBit\n");
-    return;
+
+    const checks = await runChecks(projectRoot, [], adapterRegistry);
+    printChecks(checks);
+    return checks.every((check) => check.status !== "fail");
   }
 
   console.log("📦 Detected Languages:");
@@ -415,6 +527,10 @@ export async function doctorCommand(
   }
   console.log();
 
+  const checks = await runChecks(projectRoot, servers, adapterRegistry);
+  printChecks(checks);
+  const healthy = checks.every((check) => check.status !== "fail");
+
   // Generate configurations for installed servers
   const configurations = generateMcpConfigurations(servers);
 
@@ -425,7 +541,7 @@ export async function doctorCommand(
         console.log(`  ${server.installCommand}`);
       }
     }
-    return;
+    return false;
   }
 
   console.log("📋 Setup Commands:\n");
@@ -445,8 +561,11 @@ export async function doctorCommand(
       availableServers: servers,
       mcpConfigurations: configurations,
       claudeCodeCommands: configurations.map((c) => c.claudeCommand),
+      checks,
     };
     console.log("\n📊 JSON Output:");
     console.log(JSON.stringify(result, null, 2));
   }
+
+  return healthy;
 }
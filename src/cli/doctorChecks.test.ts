@@ -0,0 +1,78 @@
+import { describe, it, expect } from "vitest";
+import { mkdirSync, mkdtempSync, readdirSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  checkConfig,
+  checkIndexDirectory,
+  checkServerVersion,
+  checkWorkspaceMarkers,
+} from "./doctorChecks.ts";
+
+function tempProject(config?: string): string {
+  const root = mkdtempSync(join(tmpdir(), "lsmcp-doctor-"));
+  if (config !== undefined) {
+    mkdirSync(join(root, ".lsmcp"));
+    writeFileSync(join(root, ".lsmcp", "config.json"), config);
+  }
+  return root;
+}
+
+describe("checkConfig", () => {
+  it("should suggest lsmcp init without a config", () => {
+    expect(checkConfig(tempProject())).toMatchObject({
+      status: "warn",
+      fix: "lsmcp init -p <preset>",
+    });
+  });
+
+  it("should accept a valid config", () => {
+    expect(checkConfig(tempProject('{"preset":"gopls"}')).status).toBe("ok");
+  });
+
+  it("should report syntax errors and schema issues", () => {
+    expect(checkConfig(tempProject("{preset")).detail).toMatch(
+      /not valid JSON/,
+    );
+
+    const invalid = checkConfig(tempProject('{"preset":"gopls","files":3}'));
+    expect(invalid.status).toBe("fail");
+    expect(invalid.detail).toMatch(/files: /);
+  });
+});
+
+describe("checkWorkspaceMarkers", () => {
+  it("should list the markers found", () => {
+    const root = tempProject();
+    expect(checkWorkspaceMarkers(root).status).toBe("warn");
+
+    writeFileSync(join(root, "go.mod"), "module example.com/m\n");
+    expect(checkWorkspaceMarkers(root)).toMatchObject({
+      status: "ok",
+      detail: "Found go.mod",
+    });
+  });
+});
+
+describe("checkIndexDirectory", () => {
+  it("should create the cache directory and leave no probe behind", () => {
+    const root = tempProject();
+
+    expect(checkIndexDirectory(root).status).toBe("ok");
+    expect(readdirSync(join(root, ".lsmcp", "cache"))).toEqual([]);
+  });
+});
+
+describe("checkServerVersion", () => {
+  it("should fail for a missing binary", () => {
+    expect(
+      checkServerVersion("missing", "lsmcp-no-such-server").status,
+    ).toBe("fail");
+  });
+
+  it("should report the first line the binary prints", () => {
+    expect(checkServerVersion("node", process.execPath).detail).toBe(
+      process.version,
+    );
+  });
+});
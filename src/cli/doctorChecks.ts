@@ -0,0 +1,250 @@
+/**
+ * Environment checks run by `lsmcp doctor`
+ *
+ * Each check reports ok, warn or fail with a short detail and, when something
+ * is wrong, the command or change that fixes it.
+ */
+
+import {
+  existsSync,
+  mkdirSync,
+  readFileSync,
+  unlinkSync,
+  writeFileSync,
+} from "fs";
+import { join } from "path";
+import { spawn, spawnSync } from "child_process";
+import { createLSPClient } from "@internal/lsp-client";
+import { configSchema, type Preset } from "../config/schema.ts";
+import { resolveAdapterCommand } from "../presets/utils.ts";
+
+export type DoctorCheckStatus = "ok" | "warn" | "fail";
+
+export interface DoctorCheck {
+  name: string;
+  status: DoctorCheckStatus;
+  detail: string;
+  fix?: string;
+}
+
+const VERSION_TIMEOUT = 5000;
+const INITIALIZE_TIMEOUT = 30000;
+
+/** Files that mark the root of a project */
+export const WORKSPACE_MARKERS = [
+  ".git",
+  "package.json",
+  "tsconfig.json",
+  "deno.json",
+  "go.mod",
+  "Cargo.toml",
+  "pyproject.toml",
+  "setup.py",
+  "requirements.txt",
+  "moon.mod.json",
+  "compile_commands.json",
+  "CMakeLists.txt",
+];
+
+/** Servers that do not understand --version */
+const VERSION_ARGS: Record<string, string[]> = {
+  gopls: ["version"],
+};
+
+/**
+ * Report the version a language server binary prints
+ */
+export function checkServerVersion(name: string, command: string): DoctorCheck {
+  const binary = command.split(/[\\/]/).pop() ?? command;
+  const result = spawnSync(command, VERSION_ARGS[binary] ?? ["--version"], {
+    encoding: "utf-8",
+    timeout: VERSION_TIMEOUT,
+  });
+  if (result.error) {
+    return {
+      name: `${name} version`,
+      status: "fail",
+      detail: `Cannot run ${command}: ${result.error.message}`,
+      fix: `Check that ${command} is installed and on PATH`,
+    };
+  }
+  const output = `${result.stdout ?? ""}\n${result.stderr ?? ""}`.trim();
+  const version = output.split("\n").find((line) => line.trim());
+  return {
+    name: `${name} version`,
+    status: "ok",
+    // Some servers exit non-zero on --version but still print it
+    detail: version?.trim() ?? `${command} (version unknown)`,
+  };
+}
+
+/**
+ * Validate .lsmcp/config.json against the config schema
+ */
+export function checkConfig(projectRoot: string): DoctorCheck {
+  const configPath = join(projectRoot, ".lsmcp", "config.json");
+  if (!existsSync(configPath)) {
+    return {
+      name: "config",
+      status: "warn",
+      detail: "No .lsmcp/config.json; presets must be passed with -p",
+      fix: "lsmcp init -p <preset>",
+    };
+  }
+  let raw: unknown;
+  try {
+    raw = JSON.parse(readFileSync(configPath, "utf-8"));
+  } catch (error) {
+    return {
+      name: "config",
+      status: "fail",
+      detail: `.lsmcp/config.json is not valid JSON: ${
+        error instanceof Error ? error.message : String(error)
+      }`,
+      fix: "Fix the JSON syntax or regenerate it with lsmcp init",
+    };
+  }
+  const parsed = configSchema.safeParse(raw);
+  if (!parsed.success) {
+    const issues = parsed.error.issues.map(
+      (issue) => `${issue.path.join(".") || "(root)"}: ${issue.message}`,
+    );
+    return {
+      name: "config",
+      status: "fail",
+      detail: `.lsmcp/config.json is invalid: ${issues.join("; ")}`,
+      fix: "Correct the fields above; see lsmcp.schema.json for the format",
+    };
+  }
+  return {
+    name: "config",
+    status: "ok",
+    detail: ".lsmcp/config.json is valid",
+  };
+}
+
+/**
+ * Warn when the directory does not look like a project root
+ */
+export function checkWorkspaceMarkers(projectRoot: string): DoctorCheck {
+  const found = WORKSPACE_MARKERS.filter((marker) =>
+    existsSync(join(projectRoot, marker)),
+  );
+  if (found.length === 0) {
+    return {
+      name: "workspace",
+      status: "warn",
+      detail: `No project markers in ${projectRoot}`,
+      fix: "Run lsmcp from the project root or pass --root <path>",
+    };
+  }
+  return {
+    name: "workspace",
+    status: "ok",
+    detail: `Found ${found.join(", ")}`,
+  };
+}
+
+/**
+ * Check that the symbol index directory can be created and written
+ */
+export function checkIndexDirectory(projectRoot: string): DoctorCheck {
+  const cacheDir = join(projectRoot, ".lsmcp", "cache");
+  const probe = join(cacheDir, `.doctor-${process.pid}`);
+  try {
+    mkdirSync(cacheDir, { recursive: true });
+    writeFileSync(probe, "");
+    unlinkSync(probe);
+  } catch (error) {
+    return {
+      name: "index directory",
+      status: "fail",
+      detail: `Cannot write to ${cacheDir}: ${
+        error instanceof Error ? error.message : String(error)
+      }`,
+      fix: `Make ${cacheDir} writable by the current user`,
+    };
+  }
+  return {
+    name: "index directory",
+    status: "ok",
+    detail: `${cacheDir} is writable`,
+  };
+}
+
+function withTimeout<T>(promise: Promise<T>, ms: number): Promise<T> {
+  let timer: ReturnType<typeof setTimeout> | undefined;
+  return Promise.race([
+    promise,
+    new Promise<never>((_, reject) => {
+      timer = setTimeout(
+        () => reject(new Error(`no response after ${ms / 1000}s`)),
+        ms,
+      );
+    }),
+  ]).finally(() => clearTimeout(timer));
+}
+
+/**
+ * Start the server, complete the initialize handshake and shut it down
+ */
+export async function checkInitialize(
+  preset: Preset,
+  projectRoot: string,
+  timeout: number = INITIALIZE_TIMEOUT,
+): Promise<DoctorCheck> {
+  const name = `${preset.presetId} initialize`;
+  let command: string;
+  let args: string[];
+  try {
+    ({ command, args } = resolveAdapterCommand(preset, projectRoot));
+  } catch (error) {
+    return {
+      name,
+      status: "fail",
+      detail: error instanceof Error ? error.message : String(error),
+      fix: `Install the ${preset.presetId} language server`,
+    };
+  }
+
+  const started = Date.now();
+  const lspProcess = spawn(command, args, {
+    stdio: ["pipe", "pipe", "pipe"],
+    cwd: projectRoot,
+  });
+  const spawnFailed = new Promise<never>((_, reject) =>
+    lspProcess.once("error", reject),
+  );
+  const client = createLSPClient({
+    process: lspProcess,
+    rootPath: projectRoot,
+    languageId: preset.baseLanguage || preset.presetId,
+    initializationOptions: preset.initializationOptions as
+      | Record<string, unknown>
+      | undefined,
+    serverCharacteristics: preset.serverCharacteristics,
+  });
+  try {
+    await withTimeout(Promise.race([client.start(), spawnFailed]), timeout);
+    return {
+      name,
+      status: "ok",
+      detail: `${command} answered initialize in ${Date.now() - started}ms`,
+    };
+  } catch (error) {
+    return {
+      name,
+      status: "fail",
+      detail: `${command} did not initialize: ${
+        error instanceof Error ? error.message : String(error)
+      }`,
+      fix: `Run lsmcp -p ${preset.presetId} --log-level lsp=debug to see the server's output`,
+    };
+  } finally {
+    // A server that never initialized may not answer shutdown either
+    await withTimeout(client.stop(), 2000).catch(() => {});
+    if (!lspProcess.killed) {
+      lspProcess.kill();
+    }
+  }
+}
@@ -0,0 +1,118 @@
+/**
+ * lsmcp export-diagnostics: collect workspace diagnostics once and write
+ * them as SARIF (or JSON), for CI steps that upload to code scanning
+ * lsmcp baseline: the same with format baseline, written to the baseline file
+ */
+
+import { mkdir, writeFile } from "fs/promises";
+import { dirname, relative, resolve } from "path";
+import type { ExtendedLSMCPConfig } from "../config/loader.ts";
+import { errorLog } from "../utils/debugLog.ts";
+import { startLanguageServer } from "../lspServerRunner.ts";
+import { NodeFileSystemApi } from "../infrastructure/NodeFileSystemApi.ts";
+import { baselinePath } from "../utils/diagnosticsBaseline.ts";
+import {
+  EXPORT_FORMATS,
+  exportDiagnostics,
+  type ExportFormat,
+} from "../tools/highlevel/exportDiagnostics.ts";
+
+/** How long each file may take to report its diagnostics */
+const FILE_WAIT_TIMEOUT = 10000;
+
+export interface ExportDiagnosticsCommandOptions {
+  format?: string;
+  /** File to write; stdout when unset */
+  output?: string;
+  severityFilter?: string;
+  pattern?: string;
+}
+
+/**
+ * Returns false when the diagnostics could not be collected; diagnostics
+ * themselves do not fail the command
+ */
+export async function exportDiagnosticsCommand(
+  projectRoot: string,
+  config: ExtendedLSMCPConfig,
+  options: ExportDiagnosticsCommandOptions,
+): Promise<boolean> {
+  const format = (options.format ?? "sarif") as ExportFormat;
+  if (!EXPORT_FORMATS.includes(format)) {
+    errorLog(
+      `Error: --format must be one of ${EXPORT_FORMATS.join(", ")}, got "${options.format}"`,
+    );
+    return false;
+  }
+  const severityFilter = (options.severityFilter ?? "all") as
+    | "error"
+    | "warning"
+    | "all";
+  if (!["error", "warning", "all"].includes(severityFilter)) {
+    errorLog(
+      `Error: --severity must be error, warning or all, got "${options.severityFilter}"`,
+    );
+    return false;
+  }
+
+  // A baseline goes where the tools look for it unless --output says otherwise
+  const output =
+    options.output ??
+    (format === "baseline" ? baselinePath(projectRoot, config) : undefined);
+  if (format === "baseline" && !output) {
+    errorLog("Error: diagnosticsBaseline is disabled in the configuration");
+    return false;
+  }
+
+  let server;
+  try {
+    server = await startLanguageServer(config, projectRoot);
+  } catch (error) {
+    errorLog(
+      `Failed to start the language server: ${
+        error instanceof Error ? error.message : String(error)
+      }`,
+    );
+    return false;
+  }
+
+  try {
+    const exported = await exportDiagnostics(
+      { root: projectRoot, format, pattern: options.pattern, severityFilter },
+      server.client,
+      {
+        lspClient: server.client,
+        fs: new NodeFileSystemApi(),
+        config: { ...config },
+        languageId: config.preset || config.id || "custom",
+        readOnly: config.readOnly,
+      },
+      { waitTimeout: FILE_WAIT_TIMEOUT },
+    );
+    if (output) {
+      await mkdir(dirname(resolve(output)), { recursive: true });
+      await writeFile(resolve(output), `${exported.text}\n`, "utf-8");
+    } else {
+      process.stdout.write(`${exported.text}\n`);
+    }
+    // The summary goes to stderr so stdout stays a valid document
+    errorLog(
+      `${exported.totalErrors} errors and ${exported.totalWarnings} warnings in ${exported.totalFiles} files${
+        output ? ` written to ${relative(projectRoot, resolve(output))}` : ""
+      }`,
+    );
+    return true;
+  } catch (error) {
+    errorLog(
+      `Failed to export diagnostics: ${
+        error instanceof Error ? error.message : String(error)
+      }`,
+    );
+    return false;
+  } finally {
+    await server.client.stop().catch(() => {});
+    if (!server.process.killed) {
+      server.process.kill();
+    }
+  }
+}
@@ -0,0 +1,221 @@
+/**
+ * lsmcp export: dump the symbol index with hovers and references for
+ * code-intel systems, as LSIF (--format lsif) or SCIP (--format scip), or
+ * its definitions as a tags file for vim (--format ctags) or Emacs
+ * (--format etags)
+ */
+
+import { mkdir, readFile, stat, writeFile } from "fs/promises";
+import { dirname, join, relative, resolve } from "path";
+import { fileURLToPath } from "url";
+import {
+  getOrCreateIndex,
+  type IndexedSymbol,
+  type SymbolIndex,
+} from "@internal/code-indexer";
+import type { McpContext } from "@internal/types";
+import type { ExtendedLSMCPConfig } from "../config/loader.ts";
+import { errorLog } from "../utils/debugLog.ts";
+import { startLanguageServer } from "../lspServerRunner.ts";
+import { NodeFileSystemApi } from "../infrastructure/NodeFileSystemApi.ts";
+import { ensureIndex } from "../tools/highlevel/ensureIndex.ts";
+import { collectCodeIntel } from "../utils/codeIntel.ts";
+import { formatLsif } from "../utils/lsif.ts";
+import { encodeScip, toScip } from "../utils/scip.ts";
+import {
+  fileTags,
+  formatTags,
+  mergeTags,
+  parseTags,
+  type TagsByFile,
+  type TagsFormat,
+} from "../utils/tags.ts";
+
+export const INDEX_EXPORT_FORMATS = ["lsif", "scip", "ctags", "etags"] as const;
+
+export type IndexExportFormat = (typeof INDEX_EXPORT_FORMATS)[number];
+
+export interface ExportIndexCommandOptions {
+  format?: string;
+  /** File to write; stdout when unset, or tags/TAGS for tags files */
+  output?: string;
+  /** Regenerate a tags file from scratch */
+  full?: boolean;
+}
+
+/** Where vim and Emacs look for tags by default */
+const TAGS_FILES: Record<TagsFormat, string> = {
+  ctags: "tags",
+  etags: "TAGS",
+};
+
+/**
+ * Write the definitions of the index to a tags file. An existing file only
+ * gets the tags of files changed since it was written replaced.
+ * Returns how many files had their tags rewritten.
+ */
+async function updateTagsFile(
+  root: string,
+  index: SymbolIndex,
+  format: TagsFormat,
+  output: string,
+  full: boolean,
+): Promise<number> {
+  const byFile = new Map<string, IndexedSymbol[]>();
+  for (const symbol of index.querySymbols({ includeChildren: false })) {
+    const file = relative(root, fileURLToPath(symbol.location.uri))
+      .split("\\")
+      .join("/");
+    if (!byFile.has(file)) {
+      byFile.set(file, []);
+    }
+    byFile.get(file)!.push(symbol);
+  }
+
+  let existing: TagsByFile = new Map();
+  let writtenAt: number | undefined;
+  if (!full) {
+    try {
+      writtenAt = (await stat(output)).mtimeMs;
+      existing = parseTags(format, await readFile(output, "utf-8"));
+    } catch {
+      writtenAt = undefined;
+    }
+  }
+
+  const fresh: TagsByFile = new Map();
+  for (const [file, symbols] of byFile) {
+    const absolutePath = join(root, file);
+    try {
+      if (
+        writtenAt !== undefined &&
+        existing.has(file) &&
+        (await stat(absolutePath)).mtimeMs <= writtenAt
+      ) {
+        continue;
+      }
+      const content = await readFile(absolutePath, "utf-8");
+      fresh.set(file, fileTags(format, file, symbols, content));
+    } catch {
+      // Deleted since it was indexed
+      fresh.set(file, []);
+    }
+  }
+  const removed = [...existing.keys()].filter((file) => !byFile.has(file));
+
+  await mkdir(dirname(output), { recursive: true });
+  await writeFile(
+    output,
+    formatTags(format, mergeTags(existing, fresh, removed)),
+    "utf-8",
+  );
+  return fresh.size + removed.length;
+}
+
+/**
+ * Returns false when the index could not be built or written
+ */
+export async function exportIndexCommand(
+  projectRoot: string,
+  config: ExtendedLSMCPConfig,
+  options: ExportIndexCommandOptions,
+): Promise<boolean> {
+  const format = (options.format ?? "lsif") as IndexExportFormat;
+  if (!INDEX_EXPORT_FORMATS.includes(format)) {
+    errorLog(
+      `Error: --format must be one of ${INDEX_EXPORT_FORMATS.join(", ")}, got "${options.format}"`,
+    );
+    return false;
+  }
+
+  let server;
+  try {
+    server = await startLanguageServer(config, projectRoot);
+  } catch (error) {
+    errorLog(
+      `Failed to start the language server: ${
+        error instanceof Error ? error.message : String(error)
+      }`,
+    );
+    return false;
+  }
+
+  try {
+    const context: McpContext = {
+      lspClient: server.client,
+      fs: new NodeFileSystemApi(),
+      config: { ...config },
+      languageId: config.preset || config.id || "custom",
+      readOnly: config.readOnly,
+    };
+    const index = getOrCreateIndex(projectRoot, context);
+    if (!index) {
+      errorLog("Failed to create the symbol index");
+      return false;
+    }
+    // Start from the cached index so only changed files are indexed again
+    await index.loadIndexFromCache();
+    const indexError = await ensureIndex(projectRoot, context);
+    if (indexError) {
+      errorLog(indexError);
+      return false;
+    }
+
+    if (format === "ctags" || format === "etags") {
+      const output = resolve(options.output ?? TAGS_FILES[format]);
+      const updated = await updateTagsFile(
+        projectRoot,
+        index,
+        format,
+        output,
+        options.full ?? false,
+      );
+      errorLog(
+        `Tags of ${updated} files written to ${relative(projectRoot, output)}`,
+      );
+      return true;
+    }
+
+    const documents = await collectCodeIntel(
+      projectRoot,
+      server.client,
+      index.querySymbols({ includeChildren: false }),
+      {
+        concurrency: config.settings?.indexConcurrency,
+        // Only SCIP has cross-repository symbol names
+        monikers: format === "scip",
+      },
+    );
+    const data =
+      format === "scip"
+        ? encodeScip(toScip(documents, { root: projectRoot }))
+        : `${formatLsif(documents, { root: projectRoot })}\n`;
+    const output = options.output && resolve(options.output);
+    if (output) {
+      await mkdir(dirname(output), { recursive: true });
+      await writeFile(output, data);
+    } else {
+      process.stdout.write(data);
+    }
+    // The summary goes to stderr so stdout stays a valid dump
+    const symbols = documents.reduce((n, d) => n + d.symbols.length, 0);
+    errorLog(
+      `${symbols} symbols in ${documents.length} documents${
+        output ? ` written to ${relative(projectRoot, output)}` : ""
+      }`,
+    );
+    return true;
+  } catch (error) {
+    errorLog(
+      `Failed to export the index: ${
+        error instanceof Error ? error.message : String(error)
+      }`,
+    );
+    return false;
+  } finally {
+    await server.client.stop().catch(() => {});
+    if (!server.process.killed) {
+      server.process.kill();
+    }
+  }
+}
@@ -12,21 +12,53 @@ Usage:
   lsmcp -p <preset>                        Start MCP server with preset
   lsmcp --files <pattern>                  Start MCP server with file patterns (comma-separated)
   lsmcp --bin <command> --files <pattern>  Start with custom LSP
-  lsmcp init [-p <preset>]                 Initialize project
-  lsmcp index                              Build symbol index
+  lsmcp init [-p <preset>] [--mcp-json]    Initialize project (and register it in .mcp.json)
+  lsmcp index [--full|--incremental]       Build or update the symbol index and exit
   lsmcp doctor [-p <preset>]               Analyze environment & suggest setup
+  lsmcp export-diagnostics [-o <file>]     Write workspace diagnostics as SARIF and exit
+  lsmcp baseline [-o <file>]               Record current diagnostics so tools hide them
+  lsmcp export [--format scip] [-o <file>] Dump the symbol index as LSIF, SCIP or tags
 
 Commands:
   init      Initialize lsmcp project configuration
   index     Build symbol index from config.json
-  doctor    Analyze environment and suggest MCP configurations
+  doctor    Check servers, config, workspace and index directory; suggest setup
+  export-diagnostics  Collect diagnostics of the configured files as SARIF 2.1.0 (or --format json)
+  baseline  Write .lsmcp/diagnostics-baseline.json; diagnostics tools leave its entries out
+  export    Write definitions, hovers and references of the symbol index as LSIF or SCIP,
+            or its definitions as a vim (ctags) or Emacs (etags) tags file
 
 Options:
   -p, --preset <preset>     Language adapter to use (see list below)
+                            Comma-separate to run several (e.g. gopls,typescript)
   --config <path>           Load language configuration from JSON file
+  --root <path>             Workspace root; repeat for multi-root workspaces
   --bin <command>           Custom LSP server command (requires --files)
   --files <pattern>         File patterns to handle (comma-separated, e.g., "**/*.ts,**/*.tsx")
   --initializationOptions <json>  JSON string for LSP initialization options
+  --http <[host]:port>      Serve MCP over streamable HTTP at /mcp (default host: 127.0.0.1)
+  --sse                     With --http, also serve legacy SSE at /sse and /messages
+  --allowed-hosts <list>    With --http, Host headers to accept (default: localhost, listen host)
+  --allowed-origins <list>  With --http, extra browser origins to accept
+  --no-install              Do not install missing language servers (gopls, rust-analyzer, ...)
+  --watch                   Keep workspace diagnostics current on save (get_current_diagnostics)
+  --read-only               Never write to disk: no editing, memory or test tools, in-memory index
+  --tools <list>            Only offer these tools (names, globs like "lsp_*", groups like @edit)
+  --disable <list>          Never offer these tools (names, globs or @edit, @memory, @exec, @cache)
+  --log-level <spec>        Log level (error, warn, info, debug, trace), per scope: "warn,lsp=debug"
+  --log-format <format>     Log record format: text (default) or json
+  --log-file <path>         Append log records to a file instead of stderr
+  --trace-lsp <path>        Record all LSP messages to a JSON lines file (attach it to bug reports)
+  --full                    With index, rebuild the whole index; with export, the whole tags file
+  --incremental             With index, re-index changed files only (default)
+  --workers <n>             With index, files to index in parallel (default: settings.indexConcurrency)
+  --auto-index              With init, build the symbol index right away
+  --force                   With init, overwrite an existing .lsmcp/config.json
+  --mcp-json                With init, add the server to the project's .mcp.json
+  --format <format>         With export-diagnostics, sarif (default), json or baseline;
+                            with export, lsif (default), scip, ctags or etags
+  -o, --output <path>       With export-diagnostics, baseline or export, the file to write
+  --severity <level>        With export-diagnostics, only error or warning diagnostics
   --list                    List all supported languages and presets
   -h, --help               Show this help message
 
@@ -36,9 +68,11 @@ Supported Presets:
   tsgo              TypeScript (Fast native implementation) - Recommended
   typescript        TypeScript/JavaScript (typescript-language-server)
   pyright           Python (Microsoft Pyright)
+  basedpyright      Python (basedpyright)
   ruff              Python (Ruff LSP)
   rust-analyzer     Rust
   gopls             Go
+  clangd            C/C++ (finds compile_commands.json in root or build/)
   fsharp            F#
   moonbit           MoonBit
   deno              Deno (TypeScript/JavaScript)
@@ -46,7 +80,6 @@ Supported Presets:
 Custom LSP Server:
   For languages not in the preset list, use --bin with --files:
   
-  lsmcp --bin "clangd" --files "**/*.{c,cpp,h,hpp}"          # C/C++
   lsmcp --bin "jdtls" --files "**/*.java"                    # Java
   lsmcp --bin "lua-language-server" --files "**/*.lua"       # Lua
   lsmcp --bin "solargraph" --files "**/*.rb"                 # Ruby
@@ -56,6 +89,9 @@ Examples:
   lsmcp init -p tsgo           Initialize with tsgo (recommended for TypeScript)
   lsmcp doctor                 Check environment and get setup commands
   lsmcp -p tsgo                Start tsgo TypeScript MCP server
+  lsmcp -p tsgo --http :8080   Run as a daemon shared by several MCP clients
+  lsmcp -p gopls --read-only   Let agents read code without any way to change it
+  lsmcp -p tsgo --log-level info --log-format json --log-file lsmcp.log  Log tool calls as JSON
   lsmcp --bin "deno lsp" --files "**/*.ts,**/*.tsx"  Use Deno LSP for TypeScript/TSX
 `);
 }
@@ -76,7 +112,6 @@ export function showListWithConfigLoader(
   console.log('  --config "./my-language.json"');
   console.log("\nFor other languages or custom LSP servers, use --bin:");
   console.log('  --bin "deno lsp" for Deno');
-  console.log('  --bin "clangd" for C/C++');
   console.log('  --bin "jdtls" for Java');
 }
 
@@ -0,0 +1,49 @@
+import { describe, it, expect } from "vitest";
+import { mkdtempSync, readFileSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { configSchema } from "../config/schema.ts";
+import { goplsAdapter } from "../presets/gopls.ts";
+import { createInitConfig, registerMcpServer } from "./initConfig.ts";
+
+describe("createInitConfig", () => {
+  it("should write the preset's file patterns and index settings", () => {
+    const config = createInitConfig(goplsAdapter, { autoIndex: true });
+
+    expect(config).toMatchObject({
+      preset: "gopls",
+      files: goplsAdapter.files,
+      settings: { autoIndex: true },
+    });
+    expect(configSchema.safeParse(config).success).toBe(true);
+  });
+});
+
+describe("registerMcpServer", () => {
+  it("should add lsmcp next to the servers already registered", () => {
+    const root = mkdtempSync(join(tmpdir(), "lsmcp-init-"));
+    writeFileSync(
+      join(root, ".mcp.json"),
+      JSON.stringify({ mcpServers: { other: { command: "other" } } }),
+    );
+
+    const written = registerMcpServer(root, "gopls");
+
+    expect(JSON.parse(readFileSync(written, "utf-8"))).toEqual({
+      mcpServers: {
+        other: { command: "other" },
+        lsmcp: {
+          command: "npx",
+          args: ["-y", "@mizchi/lsmcp", "-p", "gopls"],
+        },
+      },
+    });
+  });
+
+  it("should refuse to overwrite a malformed .mcp.json", () => {
+    const root = mkdtempSync(join(tmpdir(), "lsmcp-init-"));
+    writeFileSync(join(root, ".mcp.json"), "{");
+
+    expect(() => registerMcpServer(root)).toThrow(/Cannot update/);
+  });
+});
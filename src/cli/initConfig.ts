@@ -0,0 +1,68 @@
+/**
+ * Config files written by `lsmcp init`
+ */
+
+import { existsSync, readFileSync, writeFileSync } from "fs";
+import { join } from "path";
+import type { LSMCPConfig, Preset } from "../config/schema.ts";
+
+export const SCHEMA_REFERENCE =
+  "../node_modules/@mizchi/lsmcp/lsmcp.schema.json";
+
+/** Name of the server entry registered in .mcp.json */
+export const MCP_SERVER_NAME = "lsmcp";
+
+/**
+ * `.lsmcp/config.json` for a preset, with its file patterns and index settings
+ */
+export function createInitConfig(
+  preset: Preset,
+  options: { autoIndex?: boolean } = {},
+): Partial<LSMCPConfig> {
+  return {
+    $schema: SCHEMA_REFERENCE,
+    preset: preset.presetId,
+    ...(preset.files?.length ? { files: [...preset.files] } : {}),
+    settings: {
+      autoIndex: options.autoIndex ?? false,
+      indexConcurrency: 5,
+    },
+  } as Partial<LSMCPConfig>;
+}
+
+export interface McpServerEntry {
+  command: string;
+  args: string[];
+}
+
+/**
+ * Add (or replace) the lsmcp server in a project's .mcp.json
+ * Other servers and settings in the file are kept.
+ * @returns the path written
+ * @throws when an existing .mcp.json is not valid JSON
+ */
+export function registerMcpServer(
+  projectRoot: string,
+  preset?: string,
+): string {
+  const mcpJsonPath = join(projectRoot, ".mcp.json");
+  let content: { mcpServers?: Record<string, unknown> } = {};
+  if (existsSync(mcpJsonPath)) {
+    try {
+      content = JSON.parse(readFileSync(mcpJsonPath, "utf-8"));
+    } catch (error) {
+      throw new Error(
+        `Cannot update ${mcpJsonPath}: ${
+          error instanceof Error ? error.message : String(error)
+        }`,
+      );
+    }
+  }
+  const entry: McpServerEntry = {
+    command: "npx",
+    args: ["-y", "@mizchi/lsmcp", ...(preset ? ["-p", preset] : [])],
+  };
+  content.mcpServers = { ...content.mcpServers, [MCP_SERVER_NAME]: entry };
+  writeFileSync(mcpJsonPath, JSON.stringify(content, null, 2) + "\n");
+  return mcpJsonPath;
+}
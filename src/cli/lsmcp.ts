@@ -8,7 +8,7 @@
 
 import { parseArgs } from "node:util";
 import { existsSync } from "node:fs";
-import { join } from "node:path";
+import { join, resolve } from "node:path";
 import { debug as debugLog } from "../utils/mcpHelpers.ts";
 import {
   ConfigLoader as LspConfigLoader,
@@ -26,7 +26,6 @@ import { parseFilePatterns } from "../utils/filePatternParser.ts";
 
 // Initialize configuration system
 const adapterRegistry = globalPresetRegistry;
-const lspConfigLoader = new LspConfigLoader(process.cwd());
 
 // Register all adapters
 registerBuiltinAdapters(adapterRegistry);
@@ -34,7 +33,14 @@ registerBuiltinAdapters(adapterRegistry);
 // Import subcommands
 import { initCommand, indexCommand } from "./subcommands.ts";
 import { doctorCommand } from "./doctor.ts";
-import { detectProjectType } from "../utils/projectDetector.ts";
+import { exportDiagnosticsCommand } from "./exportDiagnostics.ts";
+import { exportIndexCommand } from "./exportIndex.ts";
+import {
+  formatDetectionReport,
+  selectProjectPreset,
+} from "../utils/projectDetector.ts";
+import { parseHostList, parseHttpAddress } from "../utils/httpTransport.ts";
+import { configureLogging } from "../utils/structuredLog.ts";
 
 // Parse command line arguments
 const { values, positionals } = parseArgs({
@@ -43,7 +49,7 @@ const { values, positionals } = parseArgs({
       type: "string",
       short: "p",
       description:
-        "Language adapter to use (typescript-language-server, tsgo, deno, pyright, etc.)",
+        "Language adapter to use (typescript-language-server, tsgo, deno, pyright, etc.); comma-separate to run several",
     },
     config: {
       type: "string",
@@ -85,21 +91,155 @@ const { values, positionals } = parseArgs({
     },
     disable: {
       type: "string",
-      description: "Comma-separated list of tools to disable",
+      description:
+        "Comma-separated tools to disable (names, '*' globs or @edit, @memory, @exec, @cache)",
+    },
+    tools: {
+      type: "string",
+      description: "Comma-separated tools to offer; every other tool is hidden",
+    },
+    "read-only": {
+      type: "boolean",
+      description:
+        "Never write to disk: hide editing, memory and test tools, keep the index in memory",
     },
     "auto-index": {
       type: "boolean",
       description: "Automatically build symbol index after init",
     },
+    force: {
+      type: "boolean",
+      description: "Overwrite an existing .lsmcp/config.json (for 'init')",
+    },
+    "mcp-json": {
+      type: "boolean",
+      description: "Register the server in the project's .mcp.json (for 'init')",
+    },
     full: {
       type: "boolean",
       description:
-        "Force full re-index instead of incremental update (for 'index' command)",
+        "Force full re-index instead of incremental update (for 'index' command), or rewrite the whole tags file (for 'export')",
+    },
+    incremental: {
+      type: "boolean",
+      description:
+        "Only re-index files changed since the last run (default for 'index')",
+    },
+    workers: {
+      type: "string",
+      description:
+        "Files to index in parallel (for 'index'; default: settings.indexConcurrency)",
+    },
+    http: {
+      type: "string",
+      description:
+        'Serve MCP over streamable HTTP instead of stdio (e.g., ":8080" or "0.0.0.0:8080")',
+    },
+    root: {
+      type: "string",
+      multiple: true,
+      description:
+        "Workspace root (repeat for multi-root workspaces; the first is the main root)",
+    },
+    sse: {
+      type: "boolean",
+      description: "Also serve the legacy HTTP+SSE transport (with --http)",
+    },
+    "allowed-hosts": {
+      type: "string",
+      description:
+        "Host headers to accept with --http, comma-separated (default: localhost and the listen host)",
+    },
+    "allowed-origins": {
+      type: "string",
+      description:
+        "Browser origins to accept with --http besides those of the allowed hosts, comma-separated",
+    },
+    "no-install": {
+      type: "boolean",
+      description: "Do not install missing language servers",
+    },
+    "log-level": {
+      type: "string",
+      description:
+        'Log level, optionally per scope (e.g., "info" or "warn,lsp=debug,indexer=trace")',
+    },
+    "log-format": {
+      type: "string",
+      description: 'Log record format: "text" (default) or "json"',
+    },
+    "log-file": {
+      type: "string",
+      description: "Append log records to this file instead of stderr",
+    },
+    watch: {
+      type: "boolean",
+      description:
+        "Keep workspace diagnostics current as files change and offer get_current_diagnostics",
+    },
+    "trace-lsp": {
+      type: "string",
+      description:
+        "Record every JSON-RPC message exchanged with the language servers to this file",
+    },
+    format: {
+      type: "string",
+      description:
+        'Output format: "sarif" (default), "json" or "baseline" for \'export-diagnostics\', "lsif", "scip", "ctags" or "etags" for \'export\'',
+    },
+    output: {
+      type: "string",
+      short: "o",
+      description:
+        "File to write instead of stdout (for 'export-diagnostics' and 'export')",
+    },
+    severity: {
+      type: "string",
+      description:
+        'Only export "error" or "warning" diagnostics (for \'export-diagnostics\')',
     },
   },
   allowPositionals: true,
 });
 
+// Configure logging before anything else writes records
+const logFormat = values["log-format"];
+if (logFormat !== undefined && logFormat !== "text" && logFormat !== "json") {
+  errorLog(`Error: --log-format must be "text" or "json", got "${logFormat}"`);
+  process.exit(1);
+}
+try {
+  configureLogging({
+    level: values["log-level"],
+    format: logFormat,
+    file: values["log-file"] && resolve(values["log-file"]),
+  });
+} catch (error) {
+  errorLog(`Error: ${error instanceof Error ? error.message : String(error)}`);
+  process.exit(1);
+}
+
+// Tool lists given on the command line
+const splitList = (list?: string) =>
+  list
+    ?.split(",")
+    .map((entry) => entry.trim())
+    .filter(Boolean) ?? [];
+const deniedTools = splitList(values.disable);
+const allowedTools = splitList(values.tools);
+
+// Paths given on the command line are relative to where lsmcp was started
+const traceLspFile = values["trace-lsp"] && resolve(values["trace-lsp"]);
+
+// The first --root becomes the working directory, the rest extra roots
+const [mainRoot, ...extraRoots] = (values.root ?? []).map((root) =>
+  resolve(root),
+);
+if (mainRoot) {
+  process.chdir(mainRoot);
+}
+const lspConfigLoader = new LspConfigLoader(process.cwd());
+
 async function main() {
   debugLog(
     `[lsmcp] main() called with values: ${JSON.stringify(
@@ -117,27 +257,89 @@ async function main() {
       adapterRegistry,
       lspConfigLoader,
       values["auto-index"],
+      { force: values.force, mcpJson: values["mcp-json"] },
     );
     process.exit(0);
   }
 
   if (subcommand === "index") {
-    await indexCommand(
+    if (values.full && values.incremental) {
+      errorLog("Error: --full and --incremental cannot be combined");
+      process.exit(1);
+    }
+    const workers = values.workers ? Number(values.workers) : undefined;
+    if (workers !== undefined && !(Number.isInteger(workers) && workers > 0)) {
+      errorLog(`Error: --workers must be a positive integer: ${values.workers}`);
+      process.exit(1);
+    }
+    const indexed = await indexCommand(
       process.cwd(),
       false,
       lspConfigLoader,
       adapterRegistry,
       values.full,
+      workers,
     );
-    process.exit(0);
+    process.exit(indexed ? 0 : 1);
   }
 
   if (subcommand === "doctor") {
-    await doctorCommand(process.cwd(), {
+    const healthy = await doctorCommand(process.cwd(), {
       preset: values.preset,
       json: values.list, // Reuse list flag for JSON output
     });
-    process.exit(0);
+    process.exit(healthy ? 0 : 1);
+  }
+
+  if (
+    subcommand === "export-diagnostics" ||
+    subcommand === "baseline" ||
+    subcommand === "export"
+  ) {
+    // Same sources as the server: -p (first preset), --config or
+    // .lsmcp/config.json
+    const configPath = join(process.cwd(), ".lsmcp", "config.json");
+    const sources: ConfigSources = {};
+    const [mainPreset] = (values.preset ?? "")
+      .split(",")
+      .map((id) => id.trim())
+      .filter(Boolean);
+    if (mainPreset) {
+      sources.preset = mainPreset;
+    }
+    if (values.config) {
+      sources.configFile = values.config;
+    } else if (!values.preset && existsSync(configPath)) {
+      sources.configFile = configPath;
+    }
+    if (!sources.preset && !sources.configFile) {
+      errorLog(
+        `Error: ${subcommand} needs --preset, --config or .lsmcp/config.json`,
+      );
+      process.exit(1);
+    }
+    const { config } = await lspConfigLoader.load(sources);
+    if (values["no-install"]) {
+      config.install = { ...config.install, enabled: false };
+    }
+    if (values["read-only"]) {
+      config.readOnly = true;
+    }
+    if (subcommand === "export") {
+      const exported = await exportIndexCommand(process.cwd(), config, {
+        format: values.format,
+        output: values.output,
+        full: values.full,
+      });
+      process.exit(exported ? 0 : 1);
+    }
+    const exported = await exportDiagnosticsCommand(process.cwd(), config, {
+      format: subcommand === "baseline" ? "baseline" : values.format,
+      output: values.output,
+      severityFilter: values.severity,
+      pattern: values.include,
+    });
+    process.exit(exported ? 0 : 1);
   }
 
   // If no arguments provided and no config exists, try auto-detection
@@ -154,32 +356,16 @@ async function main() {
     if (!existsSync(configPath)) {
       // Try auto-detection
       debugLog("[lsmcp] No config found, attempting auto-detection");
-      const detected = await detectProjectType(process.cwd());
+      const selection = await selectProjectPreset(process.cwd());
 
-      if (detected.length === 1) {
-        // Single match - use it automatically
-        debugLog(
-          `[lsmcp] Auto-detected ${detected[0].preset}: ${detected[0].reason}`,
-        );
-        values.preset = detected[0].preset;
-      } else if (detected.length === 0) {
+      if (!selection) {
         // No detection - show help
         showNoArgsHelp(adapterRegistry);
         process.exit(0);
-      } else {
-        // Multiple matches - show help with detected info
-        console.log("\n🌍 LSMCP - Language Service MCP\n");
-        console.log("Multiple project types detected:");
-        detected.forEach((d) => {
-          console.log(`  • ${d.preset}: ${d.reason}`);
-        });
-        console.log("\nPlease specify which preset to use:");
-        detected.forEach((d) => {
-          console.log(`  lsmcp -p ${d.preset}`);
-        });
-        console.log("\nOr initialize with: lsmcp init");
-        process.exit(0);
       }
+      // Report on stderr; stdout belongs to the MCP transport
+      errorLog(formatDetectionReport(selection));
+      values.preset = selection.preset;
     }
   }
 
@@ -204,7 +390,7 @@ async function mainWithConfigLoader() {
 
   // List tools if requested
   if (values["list-tools"]) {
-    await listTools(values.preset, values.disable);
+    await listTools(values.preset);
     process.exit(0);
   }
 
@@ -262,8 +448,13 @@ async function mainWithConfigLoader() {
       }
     }
 
-    if (values.preset) {
-      lspSources.preset = values.preset;
+    // "-p gopls,typescript" starts the first preset plus additional servers
+    const [mainPreset, ...extraPresets] = (values.preset ?? "")
+      .split(",")
+      .map((id) => id.trim())
+      .filter(Boolean);
+    if (mainPreset) {
+      lspSources.preset = mainPreset;
     }
 
     if (values.config) {
@@ -328,9 +519,41 @@ async function mainWithConfigLoader() {
       }
     }
 
-    // Load LSP configuration
-    const result = await lspConfigLoader.load(lspSources);
-    const config = result.config; // Extended config includes preset properties
+    // Load LSP configuration; command line options win over the file
+    const loadConfig = async () => {
+      const result = await lspConfigLoader.load(lspSources);
+      // Extended config includes preset properties
+      const loaded = result.config;
+      if (extraPresets.length > 0) {
+        loaded.servers = [...(loaded.servers ?? []), ...extraPresets];
+      }
+      if (extraRoots.length > 0) {
+        loaded.roots = [...(loaded.roots ?? []), ...extraRoots];
+      }
+      if (values["no-install"]) {
+        loaded.install = { ...loaded.install, enabled: false };
+      }
+      if (traceLspFile) {
+        loaded.traceLsp = traceLspFile;
+      }
+      if (allowedTools.length > 0) {
+        loaded.tools = { ...loaded.tools, allow: allowedTools };
+      }
+      if (deniedTools.length > 0) {
+        loaded.tools = {
+          ...loaded.tools,
+          deny: [...(loaded.tools?.deny ?? []), ...deniedTools],
+        };
+      }
+      if (values["read-only"]) {
+        loaded.readOnly = true;
+      }
+      if (values.watch) {
+        loaded.watchDiagnostics = true;
+      }
+      return loaded;
+    };
+    const config = await loadConfig();
 
     // Display final configuration details
     debugLog(`[lsmcp] ===== Final Configuration =====`);
@@ -361,7 +584,30 @@ async function mainWithConfigLoader() {
     debugLog(`[lsmcp] ================================`);
 
     // Start LSP server with resolved configuration
-    await runLanguageServerWithConfig(config, positionals);
+    const httpOptions = values.http
+      ? {
+          ...parseHttpAddress(values.http),
+          sse: values.sse,
+          allowedHosts: values["allowed-hosts"]
+            ? parseHostList(values["allowed-hosts"])
+            : undefined,
+          allowedOrigins: values["allowed-origins"]
+            ? parseHostList(values["allowed-origins"])
+            : undefined,
+        }
+      : undefined;
+    // Edits to a config file are picked up while running
+    const configFile =
+      lspSources.configFile && !lspSources.config
+        ? resolve(lspSources.configFile)
+        : undefined;
+    await runLanguageServerWithConfig(
+      config,
+      positionals,
+      undefined,
+      httpOptions,
+      configFile ? { file: configFile, load: loadConfig } : undefined,
+    );
   } catch (error) {
     errorLog(
       `Configuration error: ${
@@ -375,14 +621,9 @@ async function mainWithConfigLoader() {
 /**
  * List available MCP tools based on configuration
  */
-async function listTools(presetName?: string, disableList?: string) {
+async function listTools(presetName?: string) {
   console.log("\n🛠️  Available MCP Tools\n");
 
-  // Parse disable list
-  const disabledTools = disableList
-    ? disableList.split(",").map((t) => t.trim())
-    : [];
-
   try {
     // If no preset specified, try to load from config file
     let config: any = null;
@@ -424,10 +665,20 @@ async function listTools(presetName?: string, disableList?: string) {
       console.log(`Preset disabled tools: ${config.disable.join(", ")}\n`);
     }
 
-    // Apply user's disable list
-    if (disabledTools.length > 0) {
-      filteredTools = filterUnsupportedTools(filteredTools, disabledTools);
-      console.log(`User disabled tools: ${disabledTools.join(", ")}\n`);
+    // Apply the tool allowlist, denylist and read-only mode
+    const { createToolFilter } = await import("../tools/filterTools.ts");
+    const toolFilter = createToolFilter({
+      allow: allowedTools.length > 0 ? allowedTools : config?.tools?.allow,
+      deny: [...(config?.tools?.deny ?? []), ...deniedTools],
+      readOnly: values["read-only"] || config?.readOnly,
+    });
+    if (toolFilter) {
+      filteredTools = filteredTools.filter((tool) => toolFilter(tool.name));
+      console.log(
+        values["read-only"] || config?.readOnly
+          ? "Read-only: tools that write to disk are hidden\n"
+          : "Tools hidden by the allow/deny lists\n",
+      );
     }
 
     // If preset has capabilities, filter by them
@@ -490,7 +741,11 @@ async function listTools(presetName?: string, disableList?: string) {
       const name = tool.name;
 
       // High-level tools
-      if (name.includes("project_overview")) {
+      if (
+        name.includes("project_overview") ||
+        name === "analyze_dependencies" ||
+        name === "inspect_dependencies"
+      ) {
         categories["Project Overview"].push(tool);
       } else if (name.includes("memory") || name === "index_onboarding") {
         categories["Memory System"].push(tool);
@@ -500,6 +755,8 @@ async function listTools(presetName?: string, disableList?: string) {
         name.includes("clear_index") ||
         name.includes("search_symbol") ||
         name.includes("get_symbols_overview") ||
+        name === "read_file_skeleton" ||
+        name === "diff_symbols" ||
         name.includes("find_file") ||
         name === "index_files" ||
         name === "query_symbols"
@@ -510,6 +767,12 @@ async function listTools(presetName?: string, disableList?: string) {
       } else if (
         name === "replace_range" ||
         name === "replace_regex" ||
+        name === "apply_workspace_edit" ||
+        name === "confirm_edit" ||
+        name === "rename_file" ||
+        name === "delete_symbol" ||
+        name === "delete_file" ||
+        name.endsWith("_edit_transaction") ||
         (name.includes("replace") && !name.includes("lsp")) ||
         (name.includes("insert") && !name.includes("lsp"))
       ) {
@@ -521,21 +784,37 @@ async function listTools(presetName?: string, disableList?: string) {
         name.includes("lsp_get_definitions") ||
         name.includes("lsp_get_hover") ||
         name.includes("lsp_get_document_symbols") ||
-        name.includes("lsp_get_workspace_symbols")
+        name.includes("lsp_get_workspace_symbols") ||
+        name.includes("lsp_get_incoming_calls") ||
+        name.includes("lsp_get_outgoing_calls") ||
+        name.includes("lsp_get_call_graph") ||
+        name.includes("lsp_get_type_hierarchy")
       ) {
         categories["LSP: Code Navigation"].push(tool);
-      } else if (name.includes("lsp_get_diagnostics")) {
+      } else if (
+        name.includes("lsp_get_diagnostics") ||
+        name === "get_project_diagnostics" ||
+        name === "export_diagnostics" ||
+        name === "get_current_diagnostics" ||
+        name === "analyze_unused_symbols"
+      ) {
         categories["LSP: Diagnostics"].push(tool);
       } else if (
         name.includes("lsp_rename") ||
         name.includes("lsp_delete") ||
+        name.includes("lsp_move_symbol") ||
         name.includes("lsp_format") ||
-        name.includes("lsp_get_code_actions")
+        name.includes("lsp_get_code_actions") ||
+        name.includes("lsp_apply_code_action") ||
+        name.includes("lsp_organize_imports") ||
+        name.includes("lsp_execute_command") ||
+        name === "lsp_request"
       ) {
         categories["LSP: Code Actions"].push(tool);
       } else if (
         name.includes("lsp_get_completion") ||
-        name.includes("lsp_get_signature")
+        name.includes("lsp_get_signature") ||
+        name.includes("lsp_get_inlay_hints")
       ) {
         categories["LSP: Code Intelligence"].push(tool);
       } else if (name === "lsp_check_capabilities") {
@@ -12,11 +12,22 @@ import {
   PresetRegistry,
 } from "../config/loader.ts";
 import { resolveAdapterCommand } from "../presets/utils.ts";
+import { resolveClangdArgs } from "../presets/clangd.ts";
+import {
+  createInitConfig,
+  MCP_SERVER_NAME,
+  registerMcpServer,
+} from "./initConfig.ts";
+import {
+  resolvePythonInitializationOptions,
+} from "../utils/pythonEnvironment.ts";
 import {
   getOrCreateIndex,
   SymbolIndex,
   NodeFileSystem,
   SQLiteCache,
+  getFileContentHash,
+  type IndexingRunStats,
 } from "@internal/code-indexer";
 import { glob } from "gitaware-glob";
 import {
@@ -40,6 +51,12 @@ export async function initCommand(
   adapterRegistry?: PresetRegistry,
   configLoader?: MainConfigLoader,
   autoIndex?: boolean,
+  options: {
+    /** Overwrite an existing .lsmcp/config.json */
+    force?: boolean;
+    /** Add the server to the project's .mcp.json */
+    mcpJson?: boolean;
+  } = {},
 ): Promise<void> {
   console.log("Initializing lsmcp project...");
 
@@ -150,56 +167,51 @@ export async function initCommand(
   const configPath = join(lsmcpDir, "config.json");
   let configContent: any;
 
-  // If preset is provided, create minimal config with preset field
-  if (preset && adapterRegistry) {
-    const adapter = adapterRegistry.get(preset);
-    if (!adapter) {
-      errorLog(`❌ Unknown preset: ${preset}`);
+  if (existsSync(configPath) && !options.force) {
+    try {
+      configContent = JSON.parse(await readFile(configPath, "utf-8"));
+    } catch (error) {
+      errorLog(
+        "❌ Invalid .lsmcp/config.json (fix it or pass --force to overwrite):",
+        error instanceof Error ? error.message : String(error),
+      );
       process.exit(1);
     }
+    console.log(
+      "✓ Kept existing .lsmcp/config.json (pass --force to overwrite)",
+    );
+  } else {
+    if (preset && adapterRegistry) {
+      const adapter = adapterRegistry.get(preset);
+      if (!adapter) {
+        errorLog(`❌ Unknown preset: ${preset}`);
+        process.exit(1);
+      }
+      configContent = createInitConfig(adapter, { autoIndex });
+    } else {
+      // No preset - create boilerplate config
+      const boilerplate = generateManualConfigBoilerplate();
+      configContent = JSON.parse(boilerplate);
+    }
 
-    // Create minimal config with just preset
-    configContent = {
-      preset: preset,
-    };
+    await writeFile(configPath, JSON.stringify(configContent, null, 2) + "\n");
+    console.log("✓ Created .lsmcp/config.json");
+  }
 
-    // Set appropriate index patterns based on language if needed
-    let indexPatterns: string[] | undefined;
-    switch (preset) {
-      case "pyright":
-      case "ruff":
-        indexPatterns = ["**/*.py"];
-        break;
-      case "rust-analyzer":
-        indexPatterns = ["**/*.rs"];
-        break;
-      case "gopls":
-        indexPatterns = ["**/*.go"];
-        break;
-      case "fsharp":
-        indexPatterns = ["**/*.fs", "**/*.fsx", "**/*.fsi"];
-        break;
-      case "moonbit":
-        indexPatterns = ["**/*.mbt"];
-        break;
-      default:
-        // Keep default TypeScript/JavaScript patterns
-        break;
-    }
+  const configuredPreset: string | undefined = configContent.preset ?? preset;
 
-    if (indexPatterns) {
-      configContent.indexFiles = indexPatterns;
+  // 4. Register the server for MCP clients that read .mcp.json
+  if (options.mcpJson) {
+    try {
+      registerMcpServer(projectRoot, configuredPreset);
+      console.log(`✓ Registered ${MCP_SERVER_NAME} in .mcp.json`);
+    } catch (error) {
+      errorLog(`❌ ${error instanceof Error ? error.message : String(error)}`);
+      process.exit(1);
     }
-  } else {
-    // No preset - create boilerplate config
-    const boilerplate = generateManualConfigBoilerplate();
-    configContent = JSON.parse(boilerplate);
   }
 
-  await writeFile(configPath, JSON.stringify(configContent, null, 2));
-  console.log("✓ Created .lsmcp/config.json");
-
-  // 4. Check for CLAUDE.md
+  // 5. Check for CLAUDE.md
   const claudeMdPath = join(projectRoot, "CLAUDE.md");
   if (!existsSync(claudeMdPath)) {
     console.log(
@@ -222,14 +234,25 @@ export async function initCommand(
 
   console.log("\n✅ Initialization complete!");
 
+  if (!options.mcpJson) {
+    const presetFlag = configuredPreset ? ` -p ${configuredPreset}` : "";
+    console.log(
+      `\nTo register the server, run: claude mcp add ${MCP_SERVER_NAME} npx -- -y @mizchi/lsmcp${presetFlag}`,
+    );
+    console.log("  (or rerun init with --mcp-json to write .mcp.json)");
+  }
+
   // Show additional message for manual config
-  if (!preset || configContent.adapter?.id === "custom") {
+  if (
+    (!configuredPreset && !configContent.bin) ||
+    configContent.adapter?.id === "custom"
+  ) {
     console.log("\n⚠️  Manual configuration required!");
     console.log(
       "   Please edit .lsmcp/config.json to configure your language server:",
     );
     console.log("   - Set 'bin' to your language server command");
-    console.log("   - Adjust 'indexFiles' patterns for your language");
+    console.log("   - Adjust 'files' patterns for your language");
     console.log("   - Configure any necessary 'initializationOptions'");
     console.log(
       "\nAfter configuration, run 'lsmcp index' to build the symbol index.",
@@ -250,6 +273,8 @@ export async function initCommand(
  * @param configLoader - Config loader instance
  * @param adapterRegistry - Adapter registry instance
  * @param forceFullIndex - Force full re-index instead of incremental
+ * @param workers - Files to index in parallel (default: indexConcurrency)
+ * @returns false when indexing failed
  */
 export async function indexCommand(
   projectRoot: string,
@@ -257,7 +282,8 @@ export async function indexCommand(
   configLoader?: MainConfigLoader,
   adapterRegistry?: PresetRegistry,
   forceFullIndex: boolean = false,
-): Promise<void> {
+  workers?: number,
+): Promise<boolean> {
   const configPath = join(projectRoot, ".lsmcp", "config.json");
 
   if (!existsSync(configPath)) {
@@ -282,7 +308,7 @@ export async function indexCommand(
   }
 
   if (!config.files || config.files.length === 0) {
-    errorLog("❌ No indexFiles patterns found in config.json");
+    errorLog("❌ No files patterns found in config.json");
     process.exit(1);
   }
 
@@ -307,10 +333,10 @@ export async function indexCommand(
     console.log("No files found matching the patterns.");
     if (!isFromInit) {
       console.log(
-        "\nTip: Check your indexFiles patterns in .lsmcp/config.json",
+        "\nTip: Check your files patterns in .lsmcp/config.json",
       );
     }
-    return;
+    return isFromInit;
   }
 
   console.log(`Found ${uniqueFiles.length} files to index`);
@@ -333,10 +359,12 @@ export async function indexCommand(
       );
 
       // Resolve the command using binFinder if needed
-      const { command, args } = resolveAdapterCommand(
-        adapterConfig,
-        projectRoot,
-      );
+      const resolved = resolveAdapterCommand(adapterConfig, projectRoot);
+      const command = resolved.command;
+      const args =
+        adapterConfig.presetId === "clangd"
+          ? resolveClangdArgs(resolved.args, projectRoot, config.clangd)
+          : resolved.args;
 
       // Check if command exists before spawning
       const { execSync } = await import("child_process");
@@ -376,9 +404,11 @@ export async function indexCommand(
         process: lspProcess,
         rootPath: projectRoot,
         languageId: adapterConfig.baseLanguage || adapterConfig.presetId,
-        initializationOptions: adapterConfig.initializationOptions as
-          | Record<string, unknown>
-          | undefined,
+        initializationOptions: resolvePythonInitializationOptions(
+          adapterConfig.presetId,
+          adapterConfig.initializationOptions,
+          projectRoot,
+        ) as Record<string, unknown> | undefined,
         serverCharacteristics: (adapterConfig as any).serverCharacteristics,
       });
 
@@ -434,13 +464,16 @@ export async function indexCommand(
         } else if (config.preset === "gopls") {
           errorLog("\nTo install gopls:");
           errorLog("  go install golang.org/x/tools/gopls@latest");
+        } else if (config.preset === "clangd") {
+          errorLog("\nTo install clangd:");
+          errorLog("  Visit: https://clangd.llvm.org/installation");
         }
       }
 
       if (isFromInit) {
         console.log("\n⚠️  Symbol indexing skipped (LSP not available).");
         console.log("   You can run 'lsmcp index' later to build the index.");
-        return;
+        return true;
       } else {
         process.exit(1);
       }
@@ -455,7 +488,7 @@ export async function indexCommand(
     if (isFromInit) {
       console.log("\n⚠️  Symbol indexing skipped (LSP not available).");
       console.log("   You can run 'lsmcp index' later to build the index.");
-      return;
+      return true;
     } else {
       errorLog("❌ Failed to create symbol index. Make sure LSP is available.");
       process.exit(1);
@@ -464,6 +497,8 @@ export async function indexCommand(
 
   // Index files
   const startTime = Date.now();
+  const concurrency = workers ?? config.settings?.indexConcurrency ?? 5;
+  let run: IndexingRunStats | undefined;
   let result: {
     success: boolean;
     totalFiles: number;
@@ -487,7 +522,7 @@ export async function indexCommand(
       console.log("Performing incremental update...");
 
       const incrementalResult = await index.updateIncremental({
-        batchSize: config.settings?.indexConcurrency || 5,
+        batchSize: concurrency,
       });
 
       // Get updated stats
@@ -525,7 +560,13 @@ export async function indexCommand(
             const stats = statSync(absolutePath);
             const cacheInfo = await cache.getFileInfo(absolutePath);
 
-            if (!cacheInfo || stats.mtimeMs > cacheInfo.lastModified) {
+            if (
+              !cacheInfo ||
+              (stats.mtimeMs > cacheInfo.lastModified &&
+                // A restored index predates the checkout's mtimes
+                cacheInfo.contentHash !==
+                  (await getFileContentHash(absolutePath)))
+            ) {
               filesToUpdate.push(file);
             }
           } catch {
@@ -547,10 +588,7 @@ export async function indexCommand(
 
           // Update only changed files
           if (filesToUpdate.length > 0) {
-            await index.indexFiles(
-              filesToUpdate,
-              config.settings?.indexConcurrency || 5,
-            );
+            run = await index.indexFiles(filesToUpdate, concurrency);
           }
 
           // Remove deleted files from index
@@ -592,10 +630,7 @@ export async function indexCommand(
         // No cache, perform full index
         console.log("No existing cache found. Performing full index...");
 
-        await index.indexFiles(
-          uniqueFiles,
-          config.settings?.indexConcurrency || 5,
-        );
+        run = await index.indexFiles(uniqueFiles, concurrency);
 
         // Get stats
         const stats = index.getStats();
@@ -612,10 +647,7 @@ export async function indexCommand(
       // Full index with --full flag
       console.log("Performing full re-index (--full flag)...");
 
-      await index.indexFiles(
-        uniqueFiles,
-        config.settings?.indexConcurrency || 5,
-      );
+      run = await index.indexFiles(uniqueFiles, concurrency);
 
       // Get stats
       const stats = index.getStats();
@@ -668,6 +700,17 @@ export async function indexCommand(
     }
     console.log(`   Total files: ${result.totalFiles}`);
     console.log(`   Total symbols: ${result.totalSymbols}`);
+    if (run && run.files > 0) {
+      console.log(
+        `   Throughput: ${run.filesPerSecond} files/s with ${run.workers} workers (${run.indexed} parsed, ${run.reused} reused, ${run.failed} failed; peak heap ${run.peakHeapMB} MB)`,
+      );
+    }
+    const dbPath = join(projectRoot, ".lsmcp", "cache", "symbols.db");
+    if (existsSync(dbPath)) {
+      const { statSync } = await import("fs");
+      const size = (statSync(dbPath).size / 1024 / 1024).toFixed(1);
+      console.log(`   Index: ${dbPath} (${size} MB)`);
+    }
 
     if (result.errors.length > 0) {
       console.log(`\n⚠️  ${result.errors.length} files had errors:`);
@@ -696,4 +739,6 @@ export async function indexCommand(
       );
     }
   }
+
+  return result.success;
 }
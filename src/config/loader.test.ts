@@ -303,6 +303,43 @@ describe("ConfigLoader with Preset Language Features", () => {
     });
   });
 
+  describe("servers for polyglot workspaces", () => {
+    it("should expand additional servers from presets", async () => {
+      const configDir = join(tempDir, ".lsmcp");
+      mkdirSync(configDir, { recursive: true });
+      writeFileSync(
+        join(configDir, "config.json"),
+        JSON.stringify({
+          preset: "gopls",
+          gopls: { staticcheck: true },
+          servers: [
+            "typescript",
+            { preset: "pyright", files: ["scripts/**/*.py"] },
+          ],
+        }),
+      );
+
+      const result = await loader.load();
+      const servers = loader.resolveServers(result.config);
+
+      expect(result.config.initializationOptions).toMatchObject({
+        staticcheck: true,
+      });
+      expect(servers.map((s) => s.preset)).toEqual(["typescript", "pyright"]);
+      expect(servers[1].files).toEqual(["scripts/**/*.py"]);
+      // gopls settings are not sent to the other servers
+      expect(servers[0].initializationOptions).not.toHaveProperty(
+        "staticcheck",
+      );
+    });
+
+    it("should reject unknown presets", () => {
+      expect(() =>
+        loader.resolveServers({ servers: ["nope"] } as any),
+      ).toThrow("Unknown preset: nope");
+    });
+  });
+
   describe("experiments.* configuration enabling features", () => {
     it("should enable memory experiment from config", async () => {
       const configPath = join(tempDir, ".lsmcp", "config.json");
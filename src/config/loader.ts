@@ -5,9 +5,17 @@
 
 import { existsSync, readFileSync } from "fs";
 import { join, isAbsolute } from "path";
-import type { LSMCPConfig, ExtendedLSMCPConfig, Preset } from "./schema.ts";
+import type {
+  LSMCPConfig,
+  ExtendedLSMCPConfig,
+  Preset,
+  ServerEntry,
+} from "./schema.ts";
 import { validateConfig } from "./schema.ts";
 import { registerBuiltinAdapters } from "./presets.ts";
+import { applyGoplsOptions } from "../presets/gopls.ts";
+import { applyRustAnalyzerOptions } from "../presets/rust-analyzer.ts";
+import { applyClangdOptions } from "../presets/clangd.ts";
 
 /**
  * Default base configuration
@@ -137,6 +145,8 @@ export interface LoadResult {
   warnings?: string[];
 }
 
+type ServerDefinition = Exclude<ServerEntry, string>;
+
 /**
  * Registry for preset adapters
  */
@@ -236,6 +246,7 @@ export class ConfigLoader {
     config: Partial<ExtendedLSMCPConfig>,
     options: LoadOptions,
   ): LoadResult {
+    config = this.applyLanguageOptions(config);
     const merged = options.applyDefaults
       ? this.mergeWithDefaults(config)
       : ({ ...DEFAULT_BASE_CONFIG, ...config } as ExtendedLSMCPConfig);
@@ -332,6 +343,9 @@ export class ConfigLoader {
         }
       }
 
+      // Fold language-specific settings into initializationOptions
+      merged = this.applyLanguageOptions(merged);
+
       // Apply defaults AFTER preset expansion
       if (options.applyDefaults) {
         merged = this.mergeWithDefaults(merged);
@@ -416,6 +430,40 @@ export class ConfigLoader {
     );
   }
 
+  /**
+   * Expand `servers` entries into configs for the additional language
+   * servers of a polyglot workspace
+   */
+  resolveServers(config: ExtendedLSMCPConfig): ExtendedLSMCPConfig[] {
+    return (config.servers ?? []).map((entry) => {
+      const {
+        preset,
+        files,
+        initializationOptions,
+        connect,
+      }: ServerDefinition =
+        typeof entry === "string" ? { preset: entry } : entry;
+      const server: Partial<ExtendedLSMCPConfig> = {
+        ...this.loadFromPreset(preset, { applyDefaults: false }).config,
+        // Language sections of the main config apply to their server
+        gopls: config.gopls,
+        rustAnalyzer: config.rustAnalyzer,
+        clangd: config.clangd,
+        install: config.install,
+      };
+      if (files) {
+        server.files = files;
+      }
+      if (initializationOptions !== undefined) {
+        server.initializationOptions = initializationOptions;
+      }
+      if (connect) {
+        server.connect = connect;
+      }
+      return this.applyLanguageOptions(server) as ExtendedLSMCPConfig;
+    });
+  }
+
   /**
    * Load default configuration
    */
@@ -452,6 +500,40 @@ export class ConfigLoader {
     return null;
   }
 
+  /**
+   * Apply language-specific config sections (e.g. gopls) to initializationOptions
+   */
+  private applyLanguageOptions(
+    config: Partial<ExtendedLSMCPConfig>,
+  ): Partial<ExtendedLSMCPConfig> {
+    let initializationOptions = config.initializationOptions;
+    // Sections for another preset belong to a server in `servers`
+    const presetId = config.preset || config.id;
+    const appliesTo = (id: string) => !presetId || presetId === id;
+    if (config.gopls && appliesTo("gopls")) {
+      initializationOptions = applyGoplsOptions(
+        initializationOptions,
+        config.gopls,
+      );
+    }
+    if (config.rustAnalyzer && appliesTo("rust-analyzer")) {
+      initializationOptions = applyRustAnalyzerOptions(
+        initializationOptions,
+        config.rustAnalyzer,
+      );
+    }
+    if (config.clangd && appliesTo("clangd")) {
+      initializationOptions = applyClangdOptions(
+        initializationOptions,
+        config.clangd,
+      );
+    }
+    if (initializationOptions === config.initializationOptions) {
+      return config;
+    }
+    return { ...config, initializationOptions };
+  }
+
   /**
    * Merge configuration with defaults
    */
@@ -9,6 +9,7 @@ import { typescriptAdapter } from "../presets/typescript-language-server.ts";
 import { tsgoAdapter } from "../presets/tsgo.ts";
 import { denoAdapter } from "../presets/deno.ts";
 import { pyrightAdapter } from "../presets/pyright.ts";
+import { basedpyrightAdapter } from "../presets/basedpyright.ts";
 import { ruffAdapter } from "../presets/ruff.ts";
 import { rustAnalyzerAdapter } from "../presets/rust-analyzer.ts";
 import { fsharpAdapter } from "../presets/fsharp.ts";
@@ -16,6 +17,7 @@ import { moonbitAdapter } from "../presets/moonbit.ts";
 import { goplsAdapter } from "../presets/gopls.ts";
 import { hlsAdapter } from "../presets/hls.ts";
 import { ocamlAdapter } from "../presets/ocaml.ts";
+import { clangdAdapter } from "../presets/clangd.ts";
 
 /**
  * Register all built-in adapters to the registry
@@ -25,6 +27,7 @@ export function registerBuiltinAdapters(registry: PresetRegistry): void {
   registry.register(tsgoAdapter);
   registry.register(denoAdapter);
   registry.register(pyrightAdapter);
+  registry.register(basedpyrightAdapter);
   registry.register(ruffAdapter);
   registry.register(rustAnalyzerAdapter);
   registry.register(fsharpAdapter);
@@ -32,4 +35,5 @@ export function registerBuiltinAdapters(registry: PresetRegistry): void {
   registry.register(goplsAdapter);
   registry.register(hlsAdapter);
   registry.register(ocamlAdapter);
+  registry.register(clangdAdapter);
 }
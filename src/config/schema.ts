@@ -4,6 +4,27 @@
 
 import { z } from "zod";
 
+// Timeout and retry policy for one LSP method
+export const requestPolicySchema = z.object({
+  timeout: z.number().optional().describe("Milliseconds to wait for a response"),
+  retries: z
+    .number()
+    .optional()
+    .describe(
+      "Retries on transient failures (timeouts, ContentModified, ServerCancelled)",
+    ),
+  backoff: z
+    .number()
+    .optional()
+    .describe("Delay before the first retry (ms), doubled on each retry"),
+  maxBackoff: z
+    .number()
+    .optional()
+    .describe("Upper bound for the retry delay (ms)"),
+});
+
+export type RequestPolicyConfig = z.infer<typeof requestPolicySchema>;
+
 // Server characteristics schema
 export const serverCharacteristicsSchema = z.object({
   /** Time to wait after opening a document before sending requests (ms) */
@@ -57,6 +78,38 @@ export const serverCharacteristicsSchema = z.object({
     .boolean()
     .optional()
     .describe("Whether the server supports pull diagnostics"),
+
+  /** Window for merging rapid document edits into one didChange */
+  documentUpdateDebounce: z
+    .number()
+    .optional()
+    .describe(
+      "Milliseconds to wait for further edits before sending didChange (0 = send immediately)",
+    ),
+
+  /** Cap on documents kept open in the server */
+  maxOpenDocuments: z
+    .number()
+    .optional()
+    .describe(
+      "Most documents kept open in the server; least recently used ones are closed and reopened on access",
+    ),
+
+  /** Entries in the hover/definition/document symbol result cache */
+  resultCacheSize: z
+    .number()
+    .optional()
+    .describe(
+      "Entries kept in the hover/definition/document symbol cache (default: 500, 0 disables)",
+    ),
+
+  /** Timeout and retry policy per LSP method */
+  requestPolicies: z
+    .record(requestPolicySchema)
+    .optional()
+    .describe(
+      'Timeout and retry policy per LSP method (e.g. "textDocument/hover"); "*" applies to all methods',
+    ),
 });
 
 export type ServerCharacteristics = z.infer<typeof serverCharacteristicsSchema>;
@@ -227,6 +280,215 @@ export const presetSchema = lspClientConfigSchema.extend({
 
 export type Preset = z.infer<typeof presetSchema>;
 
+// gopls-specific settings passed through to initializationOptions
+export const goplsOptionsSchema = z.object({
+  /** Build flags passed to the go command (e.g. ["-tags=integration"]) */
+  buildFlags: z
+    .array(z.string())
+    .optional()
+    .describe("Build flags passed to the go command"),
+
+  /** Environment variables for the go command */
+  env: z
+    .record(z.string())
+    .optional()
+    .describe("Environment variables for the go command (e.g. GOFLAGS)"),
+
+  /** Analyzers to enable or disable */
+  analyses: z
+    .record(z.boolean())
+    .optional()
+    .describe("Analyzers to enable or disable, merged with preset defaults"),
+
+  /** Enable staticcheck analyzers */
+  staticcheck: z.boolean().optional().describe("Enable staticcheck analyzers"),
+
+  /** Directory filters (e.g. ["-node_modules", "+vendor"]) */
+  directoryFilters: z
+    .array(z.string())
+    .optional()
+    .describe("Directories to include (+) or exclude (-) from the workspace"),
+});
+
+export type GoplsOptions = z.infer<typeof goplsOptionsSchema>;
+
+// rust-analyzer-specific settings passed through to initializationOptions
+export const rustAnalyzerOptionsSchema = z.object({
+  /** Run cargo check on save and publish its diagnostics */
+  checkOnSave: z
+    .boolean()
+    .optional()
+    .describe("Run cargo check on save and publish its diagnostics"),
+
+  /** Cargo subcommand used for on-save checks (e.g. "check", "clippy") */
+  checkCommand: z
+    .string()
+    .optional()
+    .describe('Cargo subcommand for on-save checks (e.g. "check", "clippy")'),
+
+  /** Extra arguments for the check command */
+  extraArgs: z
+    .array(z.string())
+    .optional()
+    .describe("Extra arguments for the check command"),
+
+  /** Cargo features to enable ("all" or a list) */
+  features: z
+    .union([z.literal("all"), z.array(z.string())])
+    .optional()
+    .describe('Cargo features to enable ("all" or a list of features)'),
+
+  /** Target directory for rust-analyzer's cargo runs */
+  targetDir: z
+    .union([z.boolean(), z.string()])
+    .optional()
+    .describe(
+      "Separate target directory for rust-analyzer's cargo runs (true: target/rust-analyzer)",
+    ),
+
+  /** Environment variables for cargo invocations */
+  extraEnv: z
+    .record(z.string())
+    .optional()
+    .describe("Environment variables for cargo invocations"),
+
+  /** Expand procedural macros */
+  procMacro: z.boolean().optional().describe("Expand procedural macros"),
+});
+
+export type RustAnalyzerOptions = z.infer<typeof rustAnalyzerOptionsSchema>;
+
+// clangd-specific settings (command line flags and initializationOptions)
+export const clangdOptionsSchema = z.object({
+  /** Directory containing compile_commands.json (auto-detected if omitted) */
+  compileCommandsDir: z
+    .string()
+    .optional()
+    .describe(
+      "Directory containing compile_commands.json (default: root, build/ or build/*)",
+    ),
+
+  /** Flags used for files missing from the compilation database */
+  fallbackFlags: z
+    .array(z.string())
+    .optional()
+    .describe(
+      'Flags for files not in compile_commands.json (e.g. ["-std=c++20"])',
+    ),
+
+  /** Run clang-tidy checks */
+  clangTidy: z.boolean().optional().describe("Run clang-tidy checks"),
+});
+
+export type ClangdOptions = z.infer<typeof clangdOptionsSchema>;
+
+// Installing missing language servers
+export const installOptionsSchema = z.object({
+  /** Install a missing server automatically (--no-install disables) */
+  enabled: z
+    .boolean()
+    .optional()
+    .describe("Install missing language servers automatically (default: true)"),
+
+  /** Pinned versions by preset id */
+  versions: z
+    .record(z.string())
+    .optional()
+    .describe(
+      'Versions to install by preset id (e.g. { "gopls": "v0.16.2" }); a pinned server is always run from the lsmcp install',
+    ),
+
+  /** Install directory */
+  dir: z
+    .string()
+    .optional()
+    .describe("Install directory (default: ~/.cache/lsmcp/servers)"),
+});
+
+export type InstallOptions = z.infer<typeof installOptionsSchema>;
+
+// Container the language server runs in
+export const dockerOptionsSchema = z.object({
+  /** Image providing the server binary */
+  image: z.string().describe("Image that provides the language server binary"),
+
+  /** Container path the project root is mounted at */
+  mount: z
+    .string()
+    .optional()
+    .describe(
+      "Container path of the project root (default: /workspace); extra roots are mounted at <mount>-1, <mount>-2, ...",
+    ),
+
+  /** Extra `docker run` arguments */
+  args: z
+    .array(z.string())
+    .optional()
+    .describe("Extra docker run arguments (e.g. --user, cache volumes)"),
+
+  /** Container CLI */
+  command: z
+    .string()
+    .optional()
+    .describe("Container CLI to run (default: docker; e.g. podman)"),
+});
+
+export type DockerOptions = z.infer<typeof dockerOptionsSchema>;
+
+// Projects whose servers the HTTP daemon starts ahead of the first call
+export const warmPoolOptionsSchema = z.object({
+  projects: z
+    .array(z.string())
+    .describe(
+      "Project roots started and initialized when the daemon starts, absolute or relative to the project root",
+    ),
+  idleTimeout: z
+    .number()
+    .optional()
+    .describe(
+      "Stop a project's servers after this long without tool calls (ms, default: 1800000)",
+    ),
+  maxProjects: z
+    .number()
+    .optional()
+    .describe(
+      "Most projects kept running; the least recently used are stopped first",
+    ),
+});
+
+export type WarmPoolOptions = z.infer<typeof warmPoolOptionsSchema>;
+
+// Additional language server started next to the main preset
+export const serverEntrySchema = z.union([
+  z.string().describe("Preset id"),
+  z.object({
+    /** Preset adapter to start */
+    preset: z.string().describe("Preset adapter to start"),
+
+    /** Files routed to this server (defaults to the preset's patterns) */
+    files: z
+      .array(z.string())
+      .optional()
+      .describe("Glob patterns for files routed to this server"),
+
+    /** LSP initialization options (replace the preset's) */
+    initializationOptions: z
+      .unknown()
+      .optional()
+      .describe("LSP initialization options for this server"),
+
+    /** Running server to connect to instead of spawning the preset's */
+    connect: z
+      .string()
+      .optional()
+      .describe(
+        "Address of a running server for this preset (tcp://host:port or unix:///path/to/socket)",
+      ),
+  }),
+]);
+
+export type ServerEntry = z.infer<typeof serverEntrySchema>;
+
 // Main config schema
 export const configSchema = z
   .object({
@@ -255,7 +517,7 @@ export const configSchema = z
         indexConcurrency: z
           .number()
           .min(1)
-          .max(20)
+          .max(64)
           .default(5)
           .describe("Number of files to index in parallel"),
 
@@ -279,7 +541,9 @@ export const configSchema = z
           .min(100)
           .max(4096)
           .default(1024)
-          .describe("Memory limit for indexing operations (MB)"),
+          .describe(
+            "Memory limit for indexing (MB); no new file is started while the heap is above it",
+          ),
       })
       .default({})
       .describe("Additional settings"),
@@ -339,6 +603,14 @@ export const configSchema = z
     /** Command line arguments for the LSP server */
     args: z.array(z.string()).optional().describe("Command line arguments"),
 
+    /** Running LSP server to connect to instead of spawning bin */
+    connect: z
+      .string()
+      .optional()
+      .describe(
+        "Address of a running language server to connect to instead of spawning one (tcp://host:port or unix:///path/to/socket)",
+      ),
+
     /** LSP initialization options */
     initializationOptions: z
       .unknown()
@@ -351,8 +623,376 @@ export const configSchema = z
       .optional()
       .describe("Unsupported LSP features"),
 
+    /** Tools offered to MCP clients */
+    tools: z
+      .object({
+        /** Only offer these tools */
+        allow: z
+          .array(z.string())
+          .optional()
+          .describe(
+            "Only offer these tools: names, '*' globs or groups (@edit, @memory, @exec, @cache)",
+          ),
+        /** Never offer these tools */
+        deny: z
+          .array(z.string())
+          .optional()
+          .describe(
+            "Never offer these tools: names, '*' globs or groups (@edit, @memory, @exec, @cache)",
+          ),
+      })
+      .optional()
+      .describe("Tool allowlist and denylist; deny wins over allow"),
+
+    /** Secret redaction in tool results and logs */
+    redaction: z
+      .object({
+        /** Redact secrets (default: true) */
+        enabled: z
+          .boolean()
+          .optional()
+          .describe("Redact secrets in tool results and logs (default: true)"),
+        /** Extra patterns */
+        patterns: z
+          .array(z.string())
+          .optional()
+          .describe(
+            "Extra regular expressions to redact; only capture group 1 is replaced when the pattern has one",
+          ),
+        /** Entropy threshold for unknown token formats */
+        entropy: z
+          .number()
+          .min(0)
+          .max(8)
+          .optional()
+          .describe(
+            "Also redact tokens of 20+ letters and digits whose Shannon entropy (bits per character) is at least this value, e.g. 4.5",
+          ),
+      })
+      .optional()
+      .describe("Secret redaction for tool results and logs"),
+
+    /** Keep tool path arguments inside the workspace */
+    sandbox: z
+      .object({
+        /** Check path arguments (default: true) */
+        enabled: z
+          .boolean()
+          .optional()
+          .describe(
+            "Reject tool path arguments outside the workspace roots (default: true)",
+          ),
+        /** Extra directories tools may access */
+        paths: z
+          .array(z.string())
+          .optional()
+          .describe(
+            "Additional directories tools may access: absolute, relative to the project root or under ~/",
+          ),
+      })
+      .optional()
+      .describe("Workspace path sandbox for tool arguments"),
+
+    /** Ask the user before destructive operations (MCP elicitation) */
+    confirmations: z
+      .object({
+        /** Ask at all (default: true) */
+        enabled: z
+          .boolean()
+          .optional()
+          .describe(
+            "Ask the user through the client before destructive operations, when the client supports elicitation (default: true)",
+          ),
+        /** Edits of at most this many files apply without asking */
+        autoApproveFiles: z
+          .number()
+          .int()
+          .min(0)
+          .optional()
+          .describe(
+            "Workspace edits touching at most this many files are applied without asking (default: 1); 0 asks for every edit",
+          ),
+        /** Always ask before deleting files or symbols */
+        deletes: z
+          .boolean()
+          .optional()
+          .describe(
+            "Ask before deleting files or symbols regardless of the file count (default: true)",
+          ),
+        /** Always ask before workspace/executeCommand */
+        executeCommand: z
+          .boolean()
+          .optional()
+          .describe(
+            "Ask before running a language server command with lsp_execute_command (default: true)",
+          ),
+      })
+      .optional()
+      .describe(
+        "Confirmation prompts for multi-file edits, deletes and executeCommand",
+      ),
+
+    /** Summarize oversized documentation with the client's model */
+    summarizeDocumentation: z
+      .object({
+        /** Summarize at all (default: false) */
+        enabled: z
+          .boolean()
+          .optional()
+          .describe(
+            "Ask the client's model (MCP sampling) to summarize hover text and documentation over the threshold (default: false)",
+          ),
+        /** Documentation longer than this is summarized */
+        thresholdTokens: z
+          .number()
+          .int()
+          .positive()
+          .optional()
+          .describe(
+            "Summarize documentation longer than about this many tokens (default: 1000)",
+          ),
+        /** Length the summary is asked for */
+        maxSummaryTokens: z
+          .number()
+          .int()
+          .positive()
+          .optional()
+          .describe("Token limit of the summary (default: 300)"),
+      })
+      .optional()
+      .describe(
+        "Sampling-backed summaries of oversized hover and documentation; the full text stays readable as a resource",
+      ),
+
+    /** Embeddings of semantic_search_symbols */
+    semanticSearch: z
+      .object({
+        /** How symbols and queries are embedded (default: local) */
+        embedder: z
+          .enum(["local", "sampling", "http"])
+          .optional()
+          .describe(
+            "local embeds symbols and queries without a model; sampling embeds them the same way but first asks the client's model (MCP sampling) to expand each query into likely identifiers; http embeds both with the model at url (default: local)",
+          ),
+        /** Endpoint of the http embedder */
+        url: z
+          .string()
+          .optional()
+          .describe(
+            "OpenAI-compatible embeddings endpoint for the http embedder, e.g. http://localhost:11434/v1/embeddings",
+          ),
+        /** Model of the http embedder */
+        model: z
+          .string()
+          .optional()
+          .describe("Embedding model the http embedder asks for"),
+        /** Where the API key of the http embedder comes from */
+        apiKeyEnv: z
+          .string()
+          .optional()
+          .describe(
+            "Environment variable holding the API key sent to the http embedder",
+          ),
+      })
+      .optional()
+      .describe(
+        "Embeddings used by semantic_search_symbols; vectors are kept in the symbol index per embedder and file content",
+      ),
+
+    /** Default token budget for tool results */
+    maxTokens: z
+      .number()
+      .int()
+      .positive()
+      .optional()
+      .describe(
+        "Approximate token budget for every tool result (about 4 characters per token); longer results drop context lines, then are paginated. Tools also accept maxTokens per call",
+      ),
+
+    /** Never write to disk */
+    readOnly: z
+      .boolean()
+      .optional()
+      .describe(
+        "Disable every tool that edits files, writes memories or runs project code, keep the symbol index in memory and skip server installation",
+      ),
+
+    /** Diagnostics delta after edits */
+    diagnosticsDelta: z
+      .boolean()
+      .optional()
+      .describe(
+        "Report errors and warnings introduced and fixed in the files an editing tool writes (default: true)",
+      ),
+
+    /** Diagnostics recorded by lsmcp baseline */
+    diagnosticsBaseline: z
+      .object({
+        /** Honor the baseline file (default: true) */
+        enabled: z
+          .boolean()
+          .optional()
+          .describe(
+            "Leave diagnostics recorded in the baseline out of tool results (default: true)",
+          ),
+        /** Baseline file, relative to the project root */
+        path: z
+          .string()
+          .optional()
+          .describe(
+            "Baseline file relative to the project root (default: .lsmcp/diagnostics-baseline.json)",
+          ),
+      })
+      .optional()
+      .describe(
+        "Baseline written by `lsmcp baseline`; diagnostics it records are hidden from lsp_get_diagnostics, get_project_diagnostics and export_diagnostics unless includeBaseline is passed",
+      ),
+
+    /** Watch mode */
+    watchDiagnostics: z
+      .boolean()
+      .optional()
+      .describe(
+        "Check every configured file at startup and re-check files as they change on disk, so get_current_diagnostics answers from cache; same as --watch (default: false)",
+      ),
+
+    /** Language server messages forwarded as MCP log notifications */
+    serverLogLevel: z
+      .enum([
+        "debug",
+        "info",
+        "notice",
+        "warning",
+        "error",
+        "critical",
+        "alert",
+        "emergency",
+        "off",
+      ])
+      .optional()
+      .describe(
+        'Minimum level of language server messages (window/logMessage, window/showMessage, $/progress) sent to the MCP client as log notifications; "off" sends none. A client\'s logging/setLevel overrides it (default: warning)',
+      ),
+
+    /** Offer the raw lsp_request tool */
+    rawLspRequests: z
+      .boolean()
+      .optional()
+      .describe(
+        "Offer the lsp_request tool, which sends any JSON-RPC request to the language server and returns the raw result (default: false)",
+      ),
+
     /** Server characteristics */
     serverCharacteristics: serverCharacteristicsSchema.optional(),
+
+    /** Dead code report settings */
+    unusedSymbols: z
+      .object({
+        /** Symbol names that are never reported as unused */
+        allow: z
+          .array(z.string())
+          .optional()
+          .describe(
+            "Regex patterns for symbol names never reported as unused (e.g. entry points)",
+          ),
+      })
+      .optional()
+      .describe("Settings for analyze_unused_symbols"),
+
+    /** go vet / staticcheck next to gopls */
+    goAnalyzers: z
+      .object({
+        /** Run go vet (default: true) */
+        vet: z
+          .boolean()
+          .optional()
+          .describe(
+            "Run go vet -json and merge its findings into diagnostics (default: true)",
+          ),
+        /** Run staticcheck (default: false) */
+        staticcheck: z
+          .boolean()
+          .optional()
+          .describe(
+            "Also run staticcheck -f json; staticcheck must be on PATH (default: false)",
+          ),
+        /** Seconds each analyzer may run */
+        timeout: z
+          .number()
+          .positive()
+          .optional()
+          .describe("Seconds each analyzer may run (default: 120)"),
+      })
+      .optional()
+      .describe(
+        "Analyzers run with the gopls preset whose findings are merged into lsp_get_diagnostics and get_project_diagnostics (source \"go vet\" or \"staticcheck\"); not run in read-only mode",
+      ),
+
+    /** gopls-specific settings */
+    gopls: goplsOptionsSchema
+      .optional()
+      .describe("gopls settings merged into initializationOptions"),
+
+    /** rust-analyzer-specific settings */
+    rustAnalyzer: rustAnalyzerOptionsSchema
+      .optional()
+      .describe("rust-analyzer settings merged into initializationOptions"),
+
+    /** clangd-specific settings */
+    clangd: clangdOptionsSchema
+      .optional()
+      .describe("clangd command line flags and initializationOptions"),
+
+    /** Run the language server in a container */
+    docker: dockerOptionsSchema
+      .optional()
+      .describe(
+        "Run the language server with docker run, bind-mounting the workspace",
+      ),
+
+    /** Language server installation */
+    install: installOptionsSchema
+      .optional()
+      .describe("Automatic installation of missing language servers"),
+
+    /** Additional workspace roots (multi-root workspaces) */
+    roots: z
+      .array(z.string())
+      .optional()
+      .describe(
+        "Additional workspace roots, absolute or relative to the project root",
+      ),
+
+    /** Add sibling modules of go.work, pnpm/yarn and Cargo workspaces */
+    workspaceMembers: z
+      .boolean()
+      .optional()
+      .describe(
+        "Add the other members of an enclosing go.work, pnpm/yarn or Cargo workspace as roots (default: true)",
+      ),
+
+    /** Record LSP traffic for bug reports */
+    traceLsp: z
+      .string()
+      .optional()
+      .describe(
+        "Append every JSON-RPC message exchanged with the language servers to this JSON lines file",
+      ),
+
+    /** Pre-initialized servers for the HTTP daemon */
+    warmPool: warmPoolOptionsSchema
+      .optional()
+      .describe(
+        "Start servers for these projects when the HTTP daemon starts and stop idle ones (--http only)",
+      ),
+
+    /** Additional language servers for polyglot workspaces */
+    servers: z
+      .array(serverEntrySchema)
+      .optional()
+      .describe(
+        "Additional presets started alongside 'preset'; tool calls are routed by file extension",
+      ),
   })
   .refine(
     (data) => {
@@ -3,7 +3,11 @@ import { promises as fs } from "node:fs";
 import { tmpdir } from "node:os";
 import { join } from "node:path";
 import { mkdirSync, rmSync, existsSync } from "node:fs";
-import { MemoryManager } from "./memoryManager";
+import {
+  MAX_MEMORIES,
+  MAX_MEMORY_BYTES,
+  MemoryManager,
+} from "./memoryManager";
 
 describe("MemoryManager", () => {
   let testDir: string;
@@ -47,6 +51,12 @@ describe("MemoryManager", () => {
       expect(memories).toEqual([]);
     });
 
+    it("should not create the memories directory", async () => {
+      expect(await manager.listMemories()).toEqual([]);
+      expect(await manager.readMemory("missing")).toBeNull();
+      expect(existsSync(join(testDir, ".lsmcp", "memories"))).toBe(false);
+    });
+
     it("should return list of memory names without .md extension", async () => {
       await manager.writeMemory("memory1", "Content 1");
       await manager.writeMemory("memory2", "Content 2");
@@ -154,6 +164,53 @@ This is the actual content`;
     });
   });
 
+  describe("listMemoryInfos", () => {
+    it("should report size and timestamps, newest first", async () => {
+      await manager.writeMemory("older", "Old");
+      await new Promise((resolve) => setTimeout(resolve, 20));
+      await manager.writeMemory("newer", "New");
+
+      const infos = await manager.listMemoryInfos();
+      expect(infos.map((info) => info.name)).toEqual(["newer", "older"]);
+      expect(infos[0].size).toBeGreaterThan(3);
+      expect(infos[0].updatedAt.getTime()).toBeGreaterThan(
+        infos[1].updatedAt.getTime(),
+      );
+    });
+  });
+
+  describe("limits", () => {
+    it("should reject invalid names", async () => {
+      await expect(manager.writeMemory("../escape", "x")).rejects.toThrow(
+        /Invalid memory name/,
+      );
+      await expect(manager.writeMemory(".hidden", "x")).rejects.toThrow(
+        /Invalid memory name/,
+      );
+    });
+
+    it("should reject content over the size limit", async () => {
+      await expect(
+        manager.writeMemory("big", "x".repeat(MAX_MEMORY_BYTES + 1)),
+      ).rejects.toThrow(/limit/);
+      expect(await manager.readMemory("big")).toBeNull();
+    });
+
+    it("should reject new memories beyond the count limit", async () => {
+      for (let i = 0; i < MAX_MEMORIES; i++) {
+        await manager.writeMemory(`memory-${i}`, "Content");
+      }
+
+      await expect(
+        manager.writeMemory("one-more", "Content"),
+      ).rejects.toThrow(/limit/);
+      // Updating an existing memory is still allowed
+      await expect(
+        manager.writeMemory("memory-0", "Updated"),
+      ).resolves.toBeUndefined();
+    });
+  });
+
   describe("deleteMemory", () => {
     it("should delete existing memory", async () => {
       await manager.ensureMemoriesDir();
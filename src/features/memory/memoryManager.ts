@@ -1,4 +1,11 @@
-import { readFile, writeFile, readdir, unlink, mkdir } from "node:fs/promises";
+import {
+  readFile,
+  writeFile,
+  readdir,
+  unlink,
+  mkdir,
+  stat,
+} from "node:fs/promises";
 import { join } from "node:path";
 import { existsSync } from "node:fs";
 // Define SerenityMemory type locally
@@ -9,6 +16,40 @@ export interface SerenityMemory {
   updatedAt: Date;
 }
 
+export interface MemoryInfo {
+  name: string;
+  /** Size of the stored file in bytes */
+  size: number;
+  createdAt: Date;
+  updatedAt: Date;
+}
+
+/** Largest memory content accepted by writeMemory (UTF-8 bytes) */
+export const MAX_MEMORY_BYTES = 32 * 1024;
+
+/** Most memories a project can hold */
+export const MAX_MEMORIES = 100;
+
+// Names become file names, so keep them to a portable subset
+const MEMORY_NAME = /^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$/;
+
+function parseTimestamps(content: string): {
+  createdAt?: Date;
+  updatedAt?: Date;
+} {
+  const metadataMatch = content.match(/^---\n([\s\S]*?)\n---\n/);
+  if (!metadataMatch) {
+    return {};
+  }
+  const metadata = metadataMatch[1];
+  const createdMatch = metadata.match(/created: (.+)/);
+  const updatedMatch = metadata.match(/updated: (.+)/);
+  return {
+    createdAt: createdMatch ? new Date(createdMatch[1]) : undefined,
+    updatedAt: updatedMatch ? new Date(updatedMatch[1]) : undefined,
+  };
+}
+
 export class MemoryManager {
   private memoriesPath: string;
 
@@ -22,38 +63,52 @@ export class MemoryManager {
     }
   }
 
+  // Reads never create .lsmcp/memories, so they work in --read-only mode
   async listMemories(): Promise<string[]> {
-    await this.ensureMemoriesDir();
+    if (!existsSync(this.memoriesPath)) {
+      return [];
+    }
     const files = await readdir(this.memoriesPath);
     return files.filter((f) => f.endsWith(".md")).map((f) => f.slice(0, -3)); // Remove .md extension
   }
 
+  /**
+   * List memories with their size and timestamps, most recently updated first
+   */
+  async listMemoryInfos(): Promise<MemoryInfo[]> {
+    const infos = await Promise.all(
+      (await this.listMemories()).map(async (name): Promise<MemoryInfo> => {
+        const filePath = join(this.memoriesPath, `${name}.md`);
+        const [content, stats] = await Promise.all([
+          readFile(filePath, "utf-8"),
+          stat(filePath),
+        ]);
+        const { createdAt, updatedAt } = parseTimestamps(content);
+        return {
+          name,
+          size: stats.size,
+          createdAt: createdAt ?? stats.birthtime,
+          updatedAt: updatedAt ?? stats.mtime,
+        };
+      }),
+    );
+    return infos.sort((a, b) => b.updatedAt.getTime() - a.updatedAt.getTime());
+  }
+
   async readMemory(name: string): Promise<SerenityMemory | null> {
-    await this.ensureMemoriesDir();
     const filePath = join(this.memoriesPath, `${name}.md`);
 
     try {
       const content = await readFile(filePath, "utf-8");
 
       // Parse metadata from content if present
-      const metadataMatch = content.match(/^---\n([\s\S]*?)\n---\n/);
-      let createdAt = new Date();
-      let updatedAt = new Date();
-
-      if (metadataMatch) {
-        const metadata = metadataMatch[1];
-        const createdMatch = metadata.match(/created: (.+)/);
-        const updatedMatch = metadata.match(/updated: (.+)/);
-
-        if (createdMatch) createdAt = new Date(createdMatch[1]);
-        if (updatedMatch) updatedAt = new Date(updatedMatch[1]);
-      }
+      const { createdAt, updatedAt } = parseTimestamps(content);
 
       return {
         name,
         content: content.replace(/^---\n[\s\S]*?\n---\n/, "").trim(),
-        createdAt,
-        updatedAt,
+        createdAt: createdAt ?? new Date(),
+        updatedAt: updatedAt ?? new Date(),
       };
     } catch (error: any) {
       if (error.code === "ENOENT") {
@@ -63,12 +118,37 @@ export class MemoryManager {
     }
   }
 
+  /**
+   * Create or replace a memory
+   * @throws for invalid names, content over MAX_MEMORY_BYTES, or a new memory
+   * beyond MAX_MEMORIES
+   */
   async writeMemory(name: string, content: string): Promise<void> {
+    if (!MEMORY_NAME.test(name)) {
+      throw new Error(
+        `Invalid memory name '${name}': use up to 100 letters, digits, '.', '_' or '-'`,
+      );
+    }
+    const size = Buffer.byteLength(content, "utf-8");
+    if (size > MAX_MEMORY_BYTES) {
+      throw new Error(
+        `Memory '${name}' is ${size} bytes; the limit is ${MAX_MEMORY_BYTES} bytes. Split it into smaller memories.`,
+      );
+    }
+
+    const existingMemory = await this.readMemory(name);
+    if (!existingMemory) {
+      const count = (await this.listMemories()).length;
+      if (count >= MAX_MEMORIES) {
+        throw new Error(
+          `Project already has ${count} memories (limit ${MAX_MEMORIES}). Delete or merge memories before adding '${name}'.`,
+        );
+      }
+    }
+
     await this.ensureMemoriesDir();
     const filePath = join(this.memoriesPath, `${name}.md`);
-
     const now = new Date().toISOString();
-    const existingMemory = await this.readMemory(name);
 
     const metadata = `---
 created: ${existingMemory?.createdAt.toISOString() || now}
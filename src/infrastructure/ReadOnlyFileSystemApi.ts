@@ -0,0 +1,24 @@
+import { NodeFileSystemApi } from "./NodeFileSystemApi.ts";
+
+/**
+ * File system that refuses every write, for --read-only mode
+ */
+export class ReadOnlyFileSystemApi extends NodeFileSystemApi {
+  async writeFile(path: string): Promise<void> {
+    throw readOnlyError(path);
+  }
+
+  async mkdir(path: string): Promise<string | undefined> {
+    throw readOnlyError(path);
+  }
+
+  async rm(path: string): Promise<void> {
+    throw readOnlyError(path);
+  }
+}
+
+function readOnlyError(path: string): Error {
+  return new Error(`lsmcp is running read-only; refusing to modify ${path}`);
+}
+
+export const readOnlyFileSystemApi = new ReadOnlyFileSystemApi();
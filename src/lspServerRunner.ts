@@ -2,27 +2,364 @@
  * LSP server runner functions
  */
 
-import { spawn } from "child_process";
+import { spawn, type ChildProcess } from "child_process";
+import { resolve } from "path";
+import { pathToFileURL } from "url";
 import { debug as debugLog } from "./utils/mcpHelpers.ts";
 import type { McpToolDef, McpContext } from "@internal/types";
+import {
+  createLSPClient,
+  connectToServer,
+  createRoutingClient,
+  extensionsFromPatterns,
+  createTraceRecorder,
+  rewriteProcessMessages,
+  type LSPClient,
+} from "@internal/lsp-client";
+import type { IndexWatcherEvent } from "@internal/code-indexer";
 import { ErrorContext, formatError } from "./utils/errorHandler.ts";
 import { errorLog } from "./utils/debugLog.ts";
+import { createLogger } from "./utils/structuredLog.ts";
+import { configureRedaction } from "./utils/redaction.ts";
 import { createLSPTools } from "./tools/lsp/createLspTools.ts";
 import {
-  filterUnsupportedTools,
   createCapabilityFilter,
+  createToolFilter,
+  guardToolsByCapabilities,
 } from "./tools/filterTools.ts";
 import { highLevelTools, onboardingToolsList } from "./tools/toolLists.ts";
 import { getSerenityToolsList } from "./tools/index.ts";
 import { createGetSymbolDetailsTool } from "./tools/highlevel/indexTools.ts";
+import { createBatchLookupTool } from "./tools/highlevel/batchLookup.ts";
+import {
+  createGetProjectDiagnosticsTool,
+} from "./tools/highlevel/projectDiagnostics.ts";
+import {
+  createExportDiagnosticsTool,
+} from "./tools/highlevel/exportDiagnostics.ts";
+import {
+  createAnalyzeUnusedSymbolsTool,
+} from "./tools/highlevel/unusedSymbols.ts";
+import {
+  createAnalyzeDependenciesTool,
+} from "./tools/highlevel/dependencyGraph.ts";
+import {
+  createInspectDependenciesTool,
+} from "./tools/highlevel/inspectDependencies.ts";
+import {
+  createReadFileSkeletonTool,
+} from "./tools/highlevel/fileSkeleton.ts";
+import { createDiffSymbolsTool } from "./tools/highlevel/diffSymbols.ts";
+import { createRunGoTestsTool } from "./tools/highlevel/goTests.ts";
+import { createGetCoverageTool } from "./tools/highlevel/goCoverage.ts";
+import {
+  createGetCurrentDiagnosticsTool,
+} from "./tools/highlevel/currentDiagnostics.ts";
 import { resolveAdapterCommand } from "./presets/utils.ts";
-import { PresetRegistry, type ExtendedLSMCPConfig } from "./config/loader.ts";
+import { resolveClangdArgs } from "./presets/clangd.ts";
+import {
+  resolvePythonInitializationOptions,
+} from "./utils/pythonEnvironment.ts";
+import { ConfigLoader, type ExtendedLSMCPConfig } from "./config/loader.ts";
 import type { LspClientConfig } from "./config/schema.ts";
+import type { HttpTransportOptions } from "./utils/httpTransport.ts";
+import type { McpServerManager } from "./utils/mcpServerHelpers.ts";
+import { createIndexResources } from "./utils/indexResources.ts";
+import { createDiagnosticsResources } from "./utils/diagnosticsResources.ts";
+import { DiagnosticsWatcher } from "./utils/diagnosticsWatcher.ts";
+import { createArgumentCompletions } from "./utils/argumentCompletion.ts";
+import {
+  createDocumentationResources,
+} from "./utils/documentationSummaries.ts";
+import {
+  DEFAULT_SERVER_LOG_LEVEL,
+  forwardServerMessages,
+  type ServerLogLevel,
+} from "./utils/serverMessages.ts";
+import { WorkspaceRoots, resolveRoots } from "./utils/workspaceRoots.ts";
+import { WarmPool, withWarmPool } from "./utils/warmPool.ts";
+import { ensureLanguageServer } from "./utils/serverInstaller.ts";
+import {
+  createPathRewriter,
+  dockerMounts,
+  dockerRunCommand,
+} from "./utils/dockerServer.ts";
+import { ServerSupervisor } from "./utils/serverSupervisor.ts";
+import {
+  readOnlyFileSystemApi,
+} from "./infrastructure/ReadOnlyFileSystemApi.ts";
+import { createServerStatusTool } from "./tools/highlevel/serverStatus.ts";
+import {
+  workspaceMemberRoots,
+  type MemberRoots,
+} from "./utils/workspaceMembers.ts";
+import {
+  applyLiveCharacteristics,
+  keepProcessKeys,
+  planConfigReload,
+  watchConfigFile,
+} from "./utils/configReload.ts";
+
+export interface StartedServer {
+  client: LSPClient;
+  process: ChildProcess;
+  /** Full command line, for error messages */
+  command: string;
+  /** Characteristics object the client reads */
+  characteristics: Record<string, any>;
+}
+
+/**
+ * Characteristics handed to the client for a config
+ * Always an object: the client keeps the reference, so a config reload can
+ * update request policies and timeouts in place.
+ */
+function clientCharacteristics(
+  config: ExtendedLSMCPConfig,
+): Record<string, any> {
+  // Convert ServerCharacteristics to IServerCharacteristics (with required fields)
+  const chars = config.serverCharacteristics as any;
+  if (!chars) {
+    return {};
+  }
+  return {
+    documentOpenDelay: chars.documentOpenDelay ?? 100,
+    operationTimeout: chars.operationTimeout ?? 30000,
+    supportsIncrementalSync: chars.supportsIncrementalSync,
+    supportsPullDiagnostics: chars.supportsPullDiagnostics,
+    documentUpdateDebounce: chars.documentUpdateDebounce,
+    maxOpenDocuments: chars.maxOpenDocuments,
+    resultCacheSize: chars.resultCacheSize,
+    requestPolicies: chars.requestPolicies,
+  };
+}
+
+/**
+ * Initialize an LSP client over a spawned or connected server
+ */
+async function initializeClient(
+  config: ExtendedLSMCPConfig,
+  projectRoot: string,
+  lspProcess: ChildProcess,
+  serverChars: Record<string, any>,
+  workspaceFolders?: string[],
+): Promise<LSPClient> {
+  // Servers sharing one trace file are told apart by preset and root
+  const serverProcess = config.traceLsp
+    ? rewriteProcessMessages(
+        lspProcess,
+        createTraceRecorder(
+          config.traceLsp,
+          `${config.preset || config.id || "custom"}:${projectRoot}`,
+        ),
+      )
+    : lspProcess;
+
+  // Create and initialize LSP client
+  const client = createLSPClient({
+    rootPath: projectRoot,
+    process: serverProcess,
+    languageId: config.id || config.preset || "custom",
+    initializationOptions: resolvePythonInitializationOptions(
+      config.preset || config.id,
+      config.initializationOptions,
+      projectRoot,
+    ) as Record<string, unknown> | undefined,
+    serverCharacteristics: serverChars as any,
+    workspaceFolders,
+    // Edits the server asks for (workspace/applyEdit) are refused
+    fileSystemApi: config.readOnly ? readOnlyFileSystemApi : undefined,
+  });
+  await client.start();
+
+  return client;
+}
+
+/**
+ * Spawn (or connect to) the language server described by a config and
+ * initialize a client
+ */
+export async function startLanguageServer(
+  config: ExtendedLSMCPConfig,
+  projectRoot: string,
+  customEnv?: Record<string, string | undefined>,
+  workspaceFolders?: string[],
+): Promise<StartedServer> {
+  const characteristics = clientCharacteristics(config);
+
+  // A server that is already running (IDE, container) is dialed instead
+  if (config.connect) {
+    const lspProcess = await connectToServer(config.connect);
+    const client = await initializeClient(
+      config,
+      projectRoot,
+      lspProcess,
+      characteristics,
+      workspaceFolders,
+    );
+    return {
+      client,
+      process: lspProcess,
+      command: config.connect,
+      characteristics,
+    };
+  }
+
+  // The server binary comes from the image; host paths in messages are
+  // translated to the bind mounts
+  if (config.docker) {
+    if (!config.bin) {
+      throw new Error(
+        `'docker' needs 'bin': the language server command inside the image`,
+      );
+    }
+    const mappings = dockerMounts(
+      [projectRoot, ...(workspaceFolders ?? [])],
+      config.docker.mount,
+    );
+    const serverArgs =
+      (config.preset || config.id) === "clangd"
+        ? resolveClangdArgs(config.args ?? [], projectRoot, config.clangd)
+        : (config.args ?? []);
+    const docker = dockerRunCommand(
+      config.docker,
+      mappings,
+      config.bin,
+      serverArgs,
+      customEnv,
+    );
+    const child = spawn(docker.command, docker.args, { cwd: projectRoot });
+    const lspProcess = rewriteProcessMessages(
+      child,
+      createPathRewriter(mappings),
+    );
+    const client = await initializeClient(
+      config,
+      projectRoot,
+      lspProcess,
+      characteristics,
+      workspaceFolders,
+    );
+    return {
+      client,
+      process: lspProcess,
+      command: `${docker.command} ${docker.args.join(" ")}`,
+      characteristics,
+    };
+  }
+
+  // Resolve the command for node_modules binaries, installing the server
+  // when it is missing
+  const found = resolveAdapterCommand(
+    {
+      id: config.id || config.preset || "custom",
+      name: config.name || config.preset || "Custom LSP",
+      bin: config.bin,
+      args: config.args || [],
+      files: config.files || [],
+      binFindStrategy: config.binFindStrategy,
+    } as LspClientConfig,
+    projectRoot,
+  );
+  const resolved = await ensureLanguageServer(
+    config.preset || config.id,
+    found,
+    {
+      install: config.readOnly
+        ? { ...config.install, enabled: false }
+        : config.install,
+    },
+  );
+
+  // clangd needs to be told where the build writes compile_commands.json
+  const args =
+    (config.preset || config.id) === "clangd"
+      ? resolveClangdArgs(resolved.args, projectRoot, config.clangd)
+      : resolved.args;
+
+  const lspProcess = spawn(resolved.command, args, {
+    cwd: projectRoot,
+    env: {
+      ...process.env,
+      ...customEnv,
+    },
+  });
+
+  const client = await initializeClient(
+    config,
+    projectRoot,
+    lspProcess,
+    characteristics,
+    workspaceFolders,
+  );
+
+  return {
+    client,
+    process: lspProcess,
+    command:
+      args.length > 0
+        ? `${resolved.command} ${args.join(" ")}`
+        : resolved.command,
+    characteristics,
+  };
+}
+
+/** Where a reloaded config comes from */
+export interface ConfigReloadSource {
+  /** File to watch */
+  file: string;
+  /** Load the config again, with the command line overrides applied */
+  load: () => Promise<ExtendedLSMCPConfig>;
+}
+
+/**
+ * Send language server messages to the MCP client as log notifications, at
+ * the level of the current (possibly reloaded) config
+ */
+function forwardLspMessages(
+  server: McpServerManager,
+  context: McpContext,
+): void {
+  forwardServerMessages(
+    context.lspClient as LSPClient,
+    context.languageId ?? "lsp",
+    (level, logger, data) => {
+      const minimum =
+        (context.config?.serverLogLevel as ServerLogLevel | undefined) ??
+        DEFAULT_SERVER_LOG_LEVEL;
+      if (minimum !== "off") {
+        server.log({ level, logger, data }, minimum);
+      }
+    },
+  );
+}
+
+/**
+ * Tools offered for a config: allow/deny lists, unsupported tools, the
+ * opt-in lsp_request tool and read-only mode (fixed for the life of the
+ * process)
+ */
+function configToolFilter(
+  config: ExtendedLSMCPConfig,
+  readOnly: boolean | undefined,
+): ((name: string) => boolean) | undefined {
+  return createToolFilter({
+    allow: config.tools?.allow,
+    deny: [
+      ...(config.tools?.deny ?? []),
+      ...(config.rawLspRequests ? [] : ["lsp_request"]),
+    ],
+    unsupported: config.unsupported,
+    readOnly,
+  });
+}
 
 export async function runLanguageServerWithConfig(
   config: ExtendedLSMCPConfig,
   _positionals: string[] = [],
   customEnv?: Record<string, string | undefined>,
+  httpOptions?: HttpTransportOptions,
+  reload?: ConfigReloadSource,
 ) {
   debugLog(
     `[lsmcp] runLanguageServerWithConfig called with config: ${JSON.stringify(
@@ -42,66 +379,134 @@ export async function runLanguageServerWithConfig(
   try {
     const projectRoot = process.cwd();
 
+    // Secrets are redacted from tool results and logs
+    configureRedaction(config.redaction);
+
     // Check required fields - bin OR binFindStrategy must be present
-    if (!config.bin && !config.binFindStrategy) {
+    if (!config.bin && !config.binFindStrategy && !config.connect) {
       throw new Error(
-        `Missing 'bin' field in configuration. Please specify a language server command or binFindStrategy.`,
+        `Missing 'bin' field in configuration. Please specify a language server command, binFindStrategy or connect address.`,
       );
     }
 
-    // Resolve the command for node_modules binaries
-    const resolved = resolveAdapterCommand(
-      {
-        id: config.id || config.preset || "custom",
-        name: config.name || config.preset || "Custom LSP",
-        bin: config.bin,
-        args: config.args || [],
-        files: config.files || [],
-        binFindStrategy: config.binFindStrategy,
-      } as LspClientConfig,
-      projectRoot,
-    );
+    // Sibling members of an enclosing go.work, pnpm/yarn or Cargo workspace
+    // are extra roots so references across modules are found
+    const members: MemberRoots =
+      config.workspaceMembers === false
+        ? { roots: [] }
+        : workspaceMemberRoots(projectRoot);
+    if (members.workspace && members.roots.length > 0) {
+      debugLog(
+        `[lsmcp] ${members.workspace.kind} workspace at ${members.workspace.root}: adding ${members.roots.length} member root(s)`,
+      );
+    }
 
-    const lspProcess = spawn(resolved.command, resolved.args, {
-      cwd: projectRoot,
-      env: {
-        ...process.env,
-        ...customEnv,
+    // Every root is sent as a workspace folder; servers without
+    // workspaceFolders support get a process per extra root below
+    const roots = resolveRoots(projectRoot, [
+      ...(config.roots ?? []),
+      ...members.roots,
+    ]);
+
+    // Start the main server plus any additional servers for other languages
+    const serverConfigs = [
+      config,
+      ...new ConfigLoader(projectRoot).resolveServers(config),
+    ];
+    const servers: StartedServer[] = [];
+    for (const serverConfig of serverConfigs) {
+      servers.push(
+        await startLanguageServer(serverConfig, projectRoot, customEnv, roots),
+      );
+    }
+    const presetIds = serverConfigs.map((c) => c.preset || c.id || "custom");
+
+    // Crashed or hung servers are restarted and swapped into the router
+    const supervisor = new ServerSupervisor({
+      onReplace: (previous, next) => {
+        running = running.filter((r) => r.server.client !== previous);
+        lspClient.replaceClient(previous, next);
+        workspaceRoots.replaceClient(previous, next);
       },
     });
+    const toServerProcess = (started: StartedServer) => ({
+      client: started.client,
+      pid: started.process.pid,
+      kill: () => started.process.kill("SIGKILL"),
+    });
+    const logProcessErrors = (id: string, started: StartedServer) => {
+      started.process.on("error", (error) => {
+        const context: ErrorContext = {
+          operation: "LSP server process",
+          language: id,
+          details: { command: started.command },
+        };
+        errorLog(formatError(error, context));
+      });
+    };
+    // Running servers by config index, so a reload can update them
+    let running: { index: number; server: StartedServer }[] = [];
+    const supervise = (
+      index: number,
+      id: string,
+      started: StartedServer,
+      start: () => Promise<StartedServer>,
+    ) => {
+      logProcessErrors(id, started);
+      running.push({ index, server: started });
+      supervisor.watch({
+        id,
+        ...toServerProcess(started),
+        restart: async () => {
+          const next = await start();
+          logProcessErrors(id, next);
+          running.push({ index, server: next });
+          return toServerProcess(next);
+        },
+      });
+    };
 
-    // Create and initialize LSP client with the spawned process
-    // Convert ServerCharacteristics to IServerCharacteristics (with required fields)
-    const serverChars = config.serverCharacteristics
-      ? {
-          documentOpenDelay:
-            (config.serverCharacteristics as any).documentOpenDelay ?? 100,
-          operationTimeout:
-            (config.serverCharacteristics as any).operationTimeout ?? 30000,
-          supportsIncrementalSync: (config.serverCharacteristics as any)
-            .supportsIncrementalSync,
-          supportsPullDiagnostics: (config.serverCharacteristics as any)
-            .supportsPullDiagnostics,
-        }
-      : undefined;
+    const groups = servers.map((server, i) => ({
+      id: presetIds[i],
+      client: server.client,
+      extensions: extensionsFromPatterns(serverConfigs[i].files ?? []),
+      startForRoot: async (root: string) => {
+        const start = () =>
+          startLanguageServer(serverConfigs[i], root, customEnv);
+        const started = await start();
+        supervise(i, `${presetIds[i]} (${root})`, started, start);
+        return started.client;
+      },
+      onStop: (client: LSPClient) => {
+        running = running.filter((r) => r.server.client !== client);
+        supervisor.unwatch(client);
+      },
+    }));
 
-    // Create and initialize LSP client
-    const { createAndInitializeLSPClient } = await import(
-      "@internal/lsp-client"
-    );
-    const lspClient = await createAndInitializeLSPClient(
-      projectRoot,
-      lspProcess,
-      config.id || config.preset || "custom",
-      config.initializationOptions,
-      serverChars,
-    );
+    const lspClient = createRoutingClient(groups);
+    if (servers.length > 1) {
+      debugLog(`[lsmcp] Routing tool calls across: ${presetIds.join(", ")}`);
+    }
+    const workspaceRoots = new WorkspaceRoots(lspClient, groups, projectRoot);
+    await workspaceRoots.setRoots(roots);
+    for (const [i, server] of servers.entries()) {
+      supervise(i, presetIds[i], server, () =>
+        startLanguageServer(
+          serverConfigs[i],
+          projectRoot,
+          customEnv,
+          workspaceRoots.roots,
+        ),
+      );
+    }
 
     // Create file system API using Node.js implementation
     const { NodeFileSystemApi } = await import(
       "./infrastructure/NodeFileSystemApi.ts"
     );
-    const fileSystemApi = new NodeFileSystemApi();
+    const fileSystemApi = config.readOnly
+      ? readOnlyFileSystemApi
+      : new NodeFileSystemApi();
 
     // Create MCP context
     const mcpContext: McpContext = {
@@ -109,6 +514,8 @@ export async function runLanguageServerWithConfig(
       fs: fileSystemApi,
       config: { ...config },
       languageId: config.preset || config.id || "custom",
+      roots: workspaceRoots.roots,
+      readOnly: config.readOnly,
     };
 
     // Start MCP server
@@ -122,18 +529,7 @@ export async function runLanguageServerWithConfig(
 
     // Set context in server
     server.setContext(mcpContext);
-
-    // Create capability filter
-    const capabilityFilter = createCapabilityFilter();
-
-    // Create LSP tools with the adapter
-    const lspTools = createLSPTools(lspClient);
-
-    // Register all tools (filtered by unsupported list AND capabilities)
-    let filteredLspTools = filterUnsupportedTools(lspTools, config.unsupported);
-
-    // Apply capability-based filtering
-    filteredLspTools = capabilityFilter.filterTools(filteredLspTools);
+    forwardLspMessages(server, mcpContext);
 
     // Get Serenity tools based on config
     const serenityToolsConfig: any = {};
@@ -152,328 +548,258 @@ export async function runLanguageServerWithConfig(
         : undefined,
     );
 
-    // Create get_symbol_details tool with LSP client
-    const symbolDetailsTool = createGetSymbolDetailsTool(lspClient);
-
-    const allTools: McpToolDef<any>[] = [
-      ...filteredLspTools,
-      ...highLevelTools, // Analysis tools are always available
-      symbolDetailsTool, // High-level tool for comprehensive symbol details
-      ...serenityTools, // Serenity tools for symbol editing and memory (config-based)
-      ...onboardingToolsList, // Onboarding tools for symbol indexing
-    ];
-
-    // Register tools with the server
-    server.registerTools(allTools);
-
-    // Start the server
-    await server.start();
-    debugLog(`lsmcp MCP server connected for: ${config.name}`);
-
-    // Handle LSP process errors
-    const fullCommand =
-      resolved.args.length > 0
-        ? `${resolved.command} ${resolved.args.join(" ")}`
-        : resolved.command;
-
-    lspProcess.on("error", (error) => {
-      const context: ErrorContext = {
-        operation: "LSP server process",
-        language: config.id,
-        details: { command: fullCommand },
-      };
-      errorLog(formatError(error, context));
-      process.exit(1);
-    });
-
-    lspProcess.on("exit", (code) => {
-      if (code !== 0) {
-        errorLog(`LSP server exited with code ${code}`);
-        process.exit(code || 1);
-      }
-    });
-  } catch (error) {
-    const context: ErrorContext = {
-      operation: "MCP server startup",
-      language: config.id,
-      details: { command: `${config.bin} ${config.args?.join(" ") || ""}` },
-    };
-    errorLog(formatError(error as Error, context));
-    process.exit(1);
-  }
-}
-
-export async function runLanguageServer(
-  language: string,
-  positionals: string[] = [],
-  customEnv: Record<string, string | undefined> | undefined,
-  presetRegistry: PresetRegistry,
-) {
-  debugLog(
-    `[lsmcp] runLanguageServer called with language: ${language}, args: ${JSON.stringify(
-      positionals,
-    )}`,
-  );
-
-  // Check if this came from a preset
-  const preset = presetRegistry.get(language);
-  if (!preset) {
-    const supported = Array.from(presetRegistry.list());
-    errorLog(`Error: Language '${language}' is not supported.`);
-    errorLog(
-      `Supported languages: ${supported.map((c) => c.presetId).join(", ")}`,
-    );
-    errorLog("Or use --bin option to specify a custom LSP server.");
-    process.exit(1);
-  }
-
-  // Convert preset to adapter-like structure for compatibility
-  const adapter = preset as any;
-
-  // Use the adapter resolution for node_modules binaries
-  const resolved = resolveAdapterCommand(adapter, process.cwd());
-  const lspBin = resolved.command;
-  const lspArgs = resolved.args;
-
-  if (!lspBin) {
-    errorLog(`Error: No LSP command configured for language '${language}'.`);
-    errorLog("Please use --bin option to specify a custom LSP server.");
-    process.exit(1);
-  }
-
-  // Start MCP server directly
-  debugLog(`[lsmcp] Using LSP command '${lspBin}' for language '${language}'`);
-  const fullCommand =
-    lspArgs.length > 0 ? `${lspBin} ${lspArgs.join(" ")}` : lspBin;
-
-  try {
-    // Spawn LSP server process
-    const projectRoot = process.cwd();
-    const lspProcess = spawn(lspBin, lspArgs, {
-      cwd: projectRoot,
-      env: {
-        ...process.env,
-        ...customEnv,
-      },
-    });
-
-    // Initialize LSP client with the spawned process
-    const initOptions = adapter?.initializationOptions as
-      | Record<string, unknown>
-      | undefined;
-    const serverCharacteristics = adapter?.serverCharacteristics;
-
-    // Convert ServerCharacteristics to IServerCharacteristics (with required fields)
-    const serverChars = serverCharacteristics
-      ? {
-          documentOpenDelay: serverCharacteristics.documentOpenDelay ?? 100,
-          operationTimeout: serverCharacteristics.operationTimeout ?? 30000,
-          supportsIncrementalSync:
-            serverCharacteristics.supportsIncrementalSync,
-          supportsPullDiagnostics:
-            serverCharacteristics.supportsPullDiagnostics,
-        }
+    // Go-specific tools are only offered when gopls is running
+    const goTools: McpToolDef<any>[] = presetIds.includes("gopls")
+      ? [createRunGoTestsTool(), createGetCoverageTool()]
+      : [];
+
+    // Watch mode keeps the workspace diagnostics current in the background
+    const diagnosticsWatcher = config.watchDiagnostics
+      ? new DiagnosticsWatcher(projectRoot, lspClient, {
+          patterns: serverConfigs.flatMap((c) => c.files ?? []),
+          ignorePatterns: config.ignorePatterns,
+        })
       : undefined;
+    const watchTools: McpToolDef<any>[] = diagnosticsWatcher
+      ? [createGetCurrentDiagnosticsTool(diagnosticsWatcher)]
+      : [];
+
+    // Build the tool set bound to a client (one per HTTP session)
+    const createTools = (client: LSPClient): McpToolDef<any>[] => {
+      // Create LSP tools with the adapter
+      const lspTools = createLSPTools(client);
+
+      // Hide tools the server does not advertise capabilities for; the rest
+      // report a clear error if a restarted server drops the capability.
+      // Unsupported and denied tools are hidden by the tool filter so a
+      // config reload can change them.
+      const filteredLspTools = guardToolsByCapabilities(
+        createCapabilityFilter(client).filterTools(lspTools),
+        () => client.getServerCapabilities(),
+      );
 
-    // Create and initialize LSP client
-    const { createAndInitializeLSPClient } = await import(
-      "@internal/lsp-client"
-    );
-    const lspClient = await createAndInitializeLSPClient(
-      projectRoot,
-      lspProcess,
-      language,
-      initOptions,
-      serverChars,
-    );
-
-    // Create file system API using Node.js implementation
-    const { NodeFileSystemApi } = await import(
-      "./infrastructure/NodeFileSystemApi.ts"
-    );
-    const fileSystemApi = new NodeFileSystemApi();
-
-    // Create MCP context
-    const mcpContext: McpContext = {
-      lspClient: lspClient, // Direct LSPClient instance
-      fs: fileSystemApi,
-      config: { ...preset },
+      return [
+        ...filteredLspTools,
+        ...highLevelTools, // Analysis tools are always available
+        createGetSymbolDetailsTool(client), // Comprehensive symbol details
+        createBatchLookupTool(client), // Many hover/definition/references at once
+        createGetProjectDiagnosticsTool(client), // Workspace-wide diagnostics
+        createExportDiagnosticsTool(client), // SARIF for code scanning
+        ...watchTools, // Cached diagnostics with --watch
+        createAnalyzeUnusedSymbolsTool(client), // Dead code report
+        createAnalyzeDependenciesTool(client), // Import graph and cycles
+        createInspectDependenciesTool(), // go.mod / package.json / Cargo.toml
+        createReadFileSkeletonTool(client), // Signatures without bodies
+        createDiffSymbolsTool(client), // API changes between git revisions
+        createServerStatusTool(supervisor), // Health and restart log
+        ...goTools, // go test runner and coverage
+        ...serenityTools, // Serenity tools for symbol editing and memory (config-based)
+        ...onboardingToolsList, // Onboarding tools for symbol indexing
+      ];
     };
 
-    // Start MCP server
-    const { createMcpServerManager } = await import(
-      "./utils/mcpServerHelpers.ts"
-    );
-    const server = createMcpServerManager({
-      name: `lsmcp (${language})`,
-      version: "0.1.0",
-    });
-
-    // Set context in server
-    server.setContext(mcpContext);
-
-    // Create capability filter
-    const capabilityFilter = createCapabilityFilter();
-
-    // Create LSP tools with the adapter
-    const lspTools = createLSPTools(lspClient);
-
-    // Register all tools (filtered by unsupported list AND capabilities)
-    let filteredLspTools = filterUnsupportedTools(lspTools, adapter?.disable);
-
-    // Apply capability-based filtering
-    filteredLspTools = capabilityFilter.filterTools(filteredLspTools);
-
-    // Get Serenity tools based on config
-    // Note: For adapters, we need to check if languageFeatures is available
-    const adapterLanguageFeatures = (adapter as any)?.languageFeatures;
-    const serenityTools = getSerenityToolsList(
-      adapterLanguageFeatures
-        ? { languageFeatures: adapterLanguageFeatures }
-        : undefined,
-    );
+    const allTools = createTools(lspClient);
 
-    const allTools: McpToolDef<any>[] = [
-      ...filteredLspTools,
-      ...highLevelTools, // Analysis tools are always available
-      ...serenityTools, // Serenity tools for symbol editing and memory (config-based)
-      ...onboardingToolsList, // Onboarding tools for symbol indexing
-    ];
+    // Register tools with the server
+    server.setToolFilter(configToolFilter(config, config.readOnly));
     server.registerTools(allTools);
-
-    // Start the server
-    await server.start();
-    debugLog(`lsmcp MCP server connected for language: ${language}`);
-
-    // Handle LSP process errors
-    lspProcess.on("error", (error) => {
-      const context: ErrorContext = {
-        operation: "LSP server process",
-        language,
-        details: { command: fullCommand },
-      };
-      errorLog(formatError(error, context));
-      process.exit(1);
-    });
-
-    lspProcess.on("exit", (code) => {
-      if (code !== 0) {
-        errorLog(`LSP server exited with code ${code}`);
-        process.exit(code || 1);
+    // Symbol search, file outlines and diagnostics as subscribable resources
+    server.registerResources(createIndexResources(projectRoot, mcpContext));
+    server.registerResources(
+      createDiagnosticsResources(projectRoot, lspClient),
+    );
+    // Full text of summarized documentation
+    server.registerResources(createDocumentationResources());
+    // Completion of tool arguments and template variables, after the
+    // resources so it replaces the SDK's template-only handler
+    server.registerResources(createArgumentCompletions(projectRoot));
+
+    // Start the server (stdio by default, HTTP daemon with --http)
+    if (httpOptions) {
+      // Sessions share one language server with per-session documents
+      const { SessionManager } = await import("./utils/sessionManager.ts");
+      const sessions = new SessionManager(lspClient, mcpContext);
+
+      // Servers for pool projects are ready before the first session
+      let pool: WarmPool | undefined;
+      if (config.warmPool) {
+        pool = new WarmPool(
+          workspaceRoots,
+          roots,
+          config.warmPool.projects,
+          config.warmPool,
+        );
+        await pool.start();
+        debugLog(
+          `[lsmcp] Warm pool started: ${config.warmPool.projects.join(", ")}`,
+        );
       }
-    });
-  } catch (error) {
-    const context: ErrorContext = {
-      operation: "MCP server startup",
-      language,
-      details: { command: fullCommand },
-    };
-    errorLog(formatError(error as Error, context));
-    process.exit(1);
-  }
-}
-
-export async function runCustomLspServer(
-  bin: string,
-  customEnv?: Record<string, string | undefined>,
-): Promise<void> {
-  debugLog(`[lsmcp] Using custom LSP command: ${bin}`);
+      const { address } = await server.startHttp(httpOptions, {
+        createSession: (sessionId) => {
+          const session = sessions.open(sessionId);
+          const tools = createTools(session.client);
+          return {
+            tools: pool ? withWarmPool(tools, pool) : tools,
+            context: session.context,
+          };
+        },
+        closeSession: (sessionId) => sessions.close(sessionId),
+      });
+      errorLog(
+        `lsmcp MCP server listening on http://${address.host}:${address.port}/mcp`,
+      );
+    } else {
+      // Roots from the MCP client are added to the configured ones
+      server.watchRoots((clientRoots) =>
+        workspaceRoots.setRoots([...roots, ...clientRoots]),
+      );
+      await server.start();
+    }
+    debugLog(`lsmcp MCP server connected for: ${config.name}`);
 
-  try {
-    // Parse the custom command
-    const projectRoot = process.cwd();
-    const [cmd, ...cmdArgs] = bin.split(" ");
-
-    // Spawn LSP server process
-    const lspProcess = spawn(cmd, cmdArgs, {
-      cwd: projectRoot,
-      env: {
-        ...process.env,
-        ...customEnv,
-      },
+    // Keep the symbol index in sync with changes made outside MCP tools
+    const { startIndexWatcher, stopIndexWatcher, onIndexChange } =
+      await import("@internal/code-indexer");
+    // Cached hovers and definitions may point into files changed on disk
+    onIndexChange((change) => {
+      if (change.root === projectRoot && change.uri) {
+        lspClient.fileChanged(change.uri);
+      }
     });
-
-    // Create and initialize LSP client
-    const { createAndInitializeLSPClient } = await import(
-      "@internal/lsp-client"
-    );
-    const lspClient = await createAndInitializeLSPClient(
-      projectRoot,
-      lspProcess,
-      undefined,
-      undefined,
-      undefined,
-    );
-
-    // Create file system API using Node.js implementation
-    const { NodeFileSystemApi } = await import(
-      "./infrastructure/NodeFileSystemApi.ts"
-    );
-    const fileSystemApi = new NodeFileSystemApi();
-
-    // Create MCP context
-    const mcpContext: McpContext = {
-      lspClient: lspClient, // Direct LSPClient instance
-      fs: fileSystemApi,
-      config: {},
+    const watchIndex = (current: ExtendedLSMCPConfig) => {
+      stopIndexWatcher(projectRoot);
+      if (current.settings?.enableWatchers === false) {
+        return;
+      }
+      const watcher = startIndexWatcher(projectRoot, mcpContext, {
+        patterns: serverConfigs.flatMap((c) => c.files ?? []),
+        ignorePatterns: current.ignorePatterns,
+        delay: current.settings?.autoIndexDelay,
+      });
+      // Also files that changed without yielding symbols
+      watcher?.on("reindexed", ({ updated, removed }: IndexWatcherEvent) => {
+        for (const file of [...updated, ...removed]) {
+          lspClient.fileChanged(pathToFileURL(resolve(projectRoot, file)).href);
+        }
+      });
+      debugLog(
+        watcher
+          ? `[lsmcp] File watcher started for ${projectRoot}`
+          : `[lsmcp] File watcher not available for ${projectRoot}`,
+      );
     };
+    watchIndex(config);
+
+    if (diagnosticsWatcher) {
+      const watching = await diagnosticsWatcher.start().catch((error) => {
+        errorLog(
+          `[lsmcp] Watch mode could not list files: ${
+            error instanceof Error ? error.message : String(error)
+          }`,
+        );
+        return false;
+      });
+      debugLog(
+        watching
+          ? `[lsmcp] Watching diagnostics of ${projectRoot}`
+          : `[lsmcp] Watch mode not available for ${projectRoot}`,
+      );
+    }
 
-    // Start MCP server
-    const { createMcpServerManager } = await import(
-      "./utils/mcpServerHelpers.ts"
-    );
-    const server = createMcpServerManager({
-      name: `lsmcp (custom)`,
-      version: "0.1.0",
-    });
-
-    // Set context in server
-    server.setContext(mcpContext);
-
-    // Create capability filter
-    const capabilityFilter = createCapabilityFilter();
-
-    // Create LSP tools with the adapter
-    const lspTools = createLSPTools(lspClient);
-
-    // Register all LSP tools (filtered by capabilities) and analysis tools
-    const filteredLspTools = capabilityFilter.filterTools(lspTools);
-    // Get Serenity tools based on config
-    // Note: adapter/resolved doesn't have languageFeatures yet - using undefined
-    const serenityTools = getSerenityToolsList(undefined);
-
-    const allTools: McpToolDef<any>[] = [
-      ...filteredLspTools,
-      ...highLevelTools, // Analysis tools are always available
-      ...serenityTools, // Serenity tools for symbol editing and memory (config-based)
-      ...onboardingToolsList, // Onboarding tools for symbol indexing
-    ];
-    server.registerTools(allTools);
+    // Edits to the config file are applied without dropping sessions
+    if (reload) {
+      const logger = createLogger("mcp", "config");
+      // The configs in effect, which the next change is compared with
+      let applied = [...serverConfigs];
+      const applyReload = async () => {
+        let file: ExtendedLSMCPConfig[];
+        let nextConfigs: ExtendedLSMCPConfig[];
+        let toolFilter: ((name: string) => boolean) | undefined;
+        try {
+          const loaded = await reload.load();
+          file = [
+            loaded,
+            ...new ConfigLoader(projectRoot).resolveServers(loaded),
+          ];
+          // Process keys keep their running values until lsmcp restarts
+          nextConfigs = file.map((c, i) =>
+            applied[i] ? keepProcessKeys(c, applied[i]) : c,
+          );
+          toolFilter = configToolFilter(nextConfigs[0], config.readOnly);
+          configureRedaction(nextConfigs[0].redaction);
+        } catch (error) {
+          logger.warn(
+            `Keeping the previous config; ${reload.file} could not be loaded: ${
+              error instanceof Error ? error.message : String(error)
+            }`,
+          );
+          return;
+        }
+        const next = nextConfigs[0];
+        const plan = planConfigReload(applied, file);
+        applied = nextConfigs;
+
+        // Tools, handlers and the index read the shared context config
+        const shared = mcpContext.config as Record<string, unknown>;
+        for (const key of Object.keys(shared)) {
+          delete shared[key];
+        }
+        Object.assign(shared, next);
+        server.setToolFilter(toolFilter);
+        // Indexes only line up while the set of servers is unchanged
+        if (!plan.processRestart.includes("servers")) {
+          for (const { index, server: started } of running) {
+            applyLiveCharacteristics(
+              started.characteristics,
+              clientCharacteristics(nextConfigs[index]),
+            );
+          }
+        }
+        if (
+          plan.live.includes("ignorePatterns") ||
+          plan.live.includes("settings")
+        ) {
+          watchIndex(next);
+        }
 
-    // Start the server
-    await server.start();
-    debugLog(`lsmcp MCP server connected for custom LSP: ${bin}`);
+        // Server restarts read serverConfigs[i] when they run
+        for (const i of plan.restartServers) {
+          serverConfigs[i] = nextConfigs[i];
+          for (const { id } of supervisor.status()) {
+            if (id === presetIds[i] || id.startsWith(`${presetIds[i]} (`)) {
+              await supervisor.restartServer(id);
+            }
+          }
+        }
 
-    // Handle LSP process errors
-    lspProcess.on("error", (error) => {
-      const context: ErrorContext = {
-        operation: "LSP server process",
-        details: { command: bin },
+        if (plan.live.length > 0) {
+          logger.info(`Applied ${plan.live.join(", ")} from ${reload.file}`);
+        }
+        if (plan.restartServers.length > 0) {
+          logger.info(
+            `Re-initialized ${plan.restartServers
+              .map((i) => presetIds[i])
+              .join(", ")}`,
+          );
+        }
+        if (plan.processRestart.length > 0) {
+          logger.warn(
+            `Restart lsmcp to apply ${plan.processRestart.join(", ")}`,
+          );
+        }
       };
-      errorLog(formatError(error, context));
-      process.exit(1);
-    });
-
-    lspProcess.on("exit", (code) => {
-      if (code !== 0) {
-        errorLog(`LSP server exited with code ${code}`);
-        process.exit(code || 1);
-      }
-    });
+      watchConfigFile(reload.file, () => {
+        applyReload().catch((error) =>
+          logger.error(`Config reload failed: ${error}`),
+        );
+      });
+      debugLog(`[lsmcp] Watching ${reload.file} for changes`);
+    }
   } catch (error) {
     const context: ErrorContext = {
       operation: "MCP server startup",
-      details: { command: bin },
+      language: config.id,
+      details: { command: `${config.bin} ${config.args?.join(" ") || ""}` },
     };
     errorLog(formatError(error as Error, context));
     process.exit(1);
@@ -0,0 +1,50 @@
+import type { Preset } from "../config/schema.ts";
+
+/**
+ * basedpyright adapter - community fork of Pyright
+ * @see https://docs.basedpyright.com/latest/configuration/language-server-settings/
+ */
+export const basedpyrightAdapter: Preset = {
+  presetId: "basedpyright",
+  name: "basedpyright",
+  description: "Pyright fork with extra checks and Pylance features",
+  binFindStrategy: {
+    strategies: [
+      // 1. Try UV run first (preferred for Python projects)
+      { type: "uv", tool: "basedpyright", command: "basedpyright-langserver" },
+      // 2. Check Python virtual environments
+      {
+        type: "venv",
+        names: ["basedpyright-langserver"],
+        venvDirs: [".venv", "venv"],
+      },
+      // 3. Check global installation
+      { type: "global", names: ["basedpyright-langserver"] },
+      // 4. Check node_modules (if installed via npm)
+      { type: "node_modules", names: ["basedpyright-langserver"] },
+      // 5. Fall back to npx
+      { type: "npx", package: "basedpyright" },
+    ],
+    defaultArgs: ["--stdio"],
+  },
+  files: ["**/*.py", "**/*.pyi"],
+  initializationOptions: {
+    // python.pythonPath is filled in from the detected environment
+    python: {},
+    basedpyright: {
+      analysis: {
+        autoSearchPaths: true,
+        useLibraryCodeForTypes: true,
+        diagnosticMode: "workspace",
+      },
+    },
+  },
+  serverCharacteristics: {
+    documentOpenDelay: 1500,
+    readinessCheckTimeout: 800,
+    initialDiagnosticsTimeout: 2500,
+    requiresProjectInit: false,
+    sendsInitialDiagnostics: true,
+    operationTimeout: 12000,
+  },
+};
@@ -0,0 +1,88 @@
+import { describe, it, expect, beforeEach } from "vitest";
+import { mkdirSync, mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  applyClangdOptions,
+  findCompileCommandsDir,
+  resolveClangdArgs,
+} from "./clangd.ts";
+
+describe("findCompileCommandsDir", () => {
+  let root: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-clangd-"));
+  });
+
+  const writeDatabase = (dir: string) => {
+    mkdirSync(dir, { recursive: true });
+    writeFileSync(join(dir, "compile_commands.json"), "[]");
+  };
+
+  it("should prefer the project root", () => {
+    writeDatabase(root);
+    writeDatabase(join(root, "build"));
+
+    expect(findCompileCommandsDir(root)).toBe(root);
+  });
+
+  it("should find build/ and nested build directories", () => {
+    writeDatabase(join(root, "build", "release"));
+    writeDatabase(join(root, "build", "debug"));
+
+    expect(findCompileCommandsDir(root)).toBe(join(root, "build", "debug"));
+
+    writeDatabase(join(root, "build"));
+    expect(findCompileCommandsDir(root)).toBe(join(root, "build"));
+  });
+
+  it("should return undefined without a compilation database", () => {
+    mkdirSync(join(root, "build"));
+
+    expect(findCompileCommandsDir(root)).toBeUndefined();
+  });
+});
+
+describe("resolveClangdArgs", () => {
+  it("should add the discovered compile commands directory", () => {
+    const root = mkdtempSync(join(tmpdir(), "lsmcp-clangd-"));
+    mkdirSync(join(root, "out"));
+    writeFileSync(join(root, "out", "compile_commands.json"), "[]");
+
+    expect(resolveClangdArgs(["--background-index"], root)).toEqual([
+      "--background-index",
+      `--compile-commands-dir=${join(root, "out")}`,
+    ]);
+  });
+
+  it("should resolve a configured directory and keep explicit flags", () => {
+    expect(
+      resolveClangdArgs([], "/project", {
+        compileCommandsDir: "cmake/out",
+        clangTidy: true,
+      }),
+    ).toEqual([
+      "--compile-commands-dir=/project/cmake/out",
+      "--clang-tidy=true",
+    ]);
+
+    expect(
+      resolveClangdArgs(["--compile-commands-dir=/elsewhere"], "/project", {
+        compileCommandsDir: "build",
+      }),
+    ).toEqual(["--compile-commands-dir=/elsewhere"]);
+  });
+});
+
+describe("applyClangdOptions", () => {
+  it("should set fallback flags without mutating the input", () => {
+    const initializationOptions = { fallbackFlags: ["-std=c11"] };
+    const result = applyClangdOptions(initializationOptions, {
+      fallbackFlags: ["-std=c++20"],
+    });
+
+    expect(result.fallbackFlags).toEqual(["-std=c++20"]);
+    expect(initializationOptions.fallbackFlags).toEqual(["-std=c11"]);
+  });
+});
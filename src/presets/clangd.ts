@@ -0,0 +1,126 @@
+import { existsSync, readdirSync } from "fs";
+import { isAbsolute, join, resolve } from "path";
+import type { ClangdOptions, Preset } from "../config/schema.ts";
+
+/**
+ * clangd adapter for C/C++
+ * @see https://clangd.llvm.org/config
+ */
+export const clangdAdapter: Preset = {
+  presetId: "clangd",
+  name: "clangd",
+  description: "C/C++ language server from LLVM",
+  baseLanguage: "cpp",
+  binFindStrategy: {
+    strategies: [
+      // 1. Check global installation (including distro versioned names)
+      {
+        type: "global",
+        names: ["clangd", "clangd-19", "clangd-18", "clangd-17", "clangd-16"],
+      },
+      // 2. Check Homebrew LLVM (not linked into PATH by default)
+      { type: "path", path: "/opt/homebrew/opt/llvm/bin/clangd" },
+      { type: "path", path: "/usr/local/opt/llvm/bin/clangd" },
+    ],
+    defaultArgs: [
+      "--background-index",
+      // Include fixer: offer "#include <...>" quick fixes for unknown symbols
+      "--header-insertion=iwyu",
+      "--completion-style=detailed",
+    ],
+  },
+  files: [
+    "**/*.c",
+    "**/*.h",
+    "**/*.cc",
+    "**/*.cpp",
+    "**/*.cxx",
+    "**/*.hh",
+    "**/*.hpp",
+    "**/*.hxx",
+  ],
+};
+
+// Build directories generated by CMake, Meson and CLion, checked in order
+const BUILD_DIRS = ["build", "out", "builddir", "cmake-build-debug"];
+
+/**
+ * Find the directory holding compile_commands.json: the project root, a
+ * build directory, or one level below it (e.g. build/debug)
+ */
+export function findCompileCommandsDir(root: string): string | undefined {
+  const hasDatabase = (dir: string) =>
+    existsSync(join(dir, "compile_commands.json"));
+
+  if (hasDatabase(root)) {
+    return root;
+  }
+  for (const name of BUILD_DIRS) {
+    const dir = join(root, name);
+    if (!existsSync(dir)) continue;
+    if (hasDatabase(dir)) {
+      return dir;
+    }
+    try {
+      const nested = readdirSync(dir, { withFileTypes: true })
+        .filter((entry) => entry.isDirectory())
+        .map((entry) => join(dir, entry.name))
+        .sort()
+        .find(hasDatabase);
+      if (nested) {
+        return nested;
+      }
+    } catch {
+      // Unreadable build directory
+    }
+  }
+  return undefined;
+}
+
+/**
+ * Add --compile-commands-dir (configured or discovered) and other clangd
+ * flags from lsmcp config to the resolved command line
+ */
+export function resolveClangdArgs(
+  args: string[],
+  root: string,
+  options: ClangdOptions = {},
+): string[] {
+  const result = [...args];
+  const hasFlag = (flag: string) =>
+    result.some((arg) => arg === flag || arg.startsWith(`${flag}=`));
+
+  if (!hasFlag("--compile-commands-dir")) {
+    const dir = options.compileCommandsDir
+      ? isAbsolute(options.compileCommandsDir)
+        ? options.compileCommandsDir
+        : resolve(root, options.compileCommandsDir)
+      : findCompileCommandsDir(root);
+    if (dir) {
+      result.push(`--compile-commands-dir=${dir}`);
+    }
+  }
+  if (options.clangTidy !== undefined && !hasFlag("--clang-tidy")) {
+    result.push(`--clang-tidy=${options.clangTidy}`);
+  }
+  return result;
+}
+
+/**
+ * Merge clangd settings from lsmcp config into initializationOptions
+ */
+export function applyClangdOptions(
+  initializationOptions: unknown,
+  options: ClangdOptions,
+): Record<string, unknown> {
+  const base =
+    initializationOptions && typeof initializationOptions === "object"
+      ? { ...(initializationOptions as Record<string, unknown>) }
+      : {};
+
+  if (options.fallbackFlags !== undefined) {
+    base.fallbackFlags = options.fallbackFlags;
+  }
+
+  return base;
+}
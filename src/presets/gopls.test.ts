@@ -0,0 +1,36 @@
+import { describe, it, expect } from "vitest";
+import { applyGoplsOptions, goplsAdapter } from "./gopls.ts";
+
+describe("applyGoplsOptions", () => {
+  it("should add gopls settings to preset initializationOptions", () => {
+    const result = applyGoplsOptions(goplsAdapter.initializationOptions, {
+      buildFlags: ["-tags=integration"],
+      env: { GOFLAGS: "-mod=vendor" },
+      staticcheck: true,
+      directoryFilters: ["-node_modules"],
+    });
+
+    expect(result.buildFlags).toEqual(["-tags=integration"]);
+    expect(result.env).toEqual({ GOFLAGS: "-mod=vendor" });
+    expect(result.staticcheck).toBe(true);
+    expect(result.directoryFilters).toEqual(["-node_modules"]);
+    // Preset defaults are kept
+    expect(result.codelenses).toBeDefined();
+  });
+
+  it("should merge analyses with existing ones", () => {
+    const result = applyGoplsOptions(
+      { analyses: { unusedparams: true, shadow: true } },
+      { analyses: { shadow: false } },
+    );
+
+    expect(result.analyses).toEqual({ unusedparams: true, shadow: false });
+  });
+
+  it("should not mutate the input options", () => {
+    const initializationOptions = { staticcheck: false };
+    applyGoplsOptions(initializationOptions, { staticcheck: true });
+
+    expect(initializationOptions.staticcheck).toBe(false);
+  });
+});
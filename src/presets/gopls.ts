@@ -1,4 +1,4 @@
-import type { Preset } from "../config/schema.ts";
+import type { GoplsOptions, Preset } from "../config/schema.ts";
 
 /**
  * Gopls adapter for Go language support
@@ -35,3 +35,41 @@ export const goplsAdapter: Preset = {
     completionBudget: "500ms",
   },
 };
+
+/**
+ * Merge gopls settings from lsmcp config into initializationOptions
+ * Analyses are merged with existing ones; other settings override
+ */
+export function applyGoplsOptions(
+  initializationOptions: unknown,
+  options: GoplsOptions,
+): Record<string, unknown> {
+  const base =
+    initializationOptions && typeof initializationOptions === "object"
+      ? { ...(initializationOptions as Record<string, unknown>) }
+      : {};
+
+  if (options.buildFlags !== undefined) {
+    base.buildFlags = options.buildFlags;
+  }
+  if (options.env !== undefined) {
+    base.env = {
+      ...((base.env as Record<string, string> | undefined) ?? {}),
+      ...options.env,
+    };
+  }
+  if (options.analyses !== undefined) {
+    base.analyses = {
+      ...((base.analyses as Record<string, boolean> | undefined) ?? {}),
+      ...options.analyses,
+    };
+  }
+  if (options.staticcheck !== undefined) {
+    base.staticcheck = options.staticcheck;
+  }
+  if (options.directoryFilters !== undefined) {
+    base.directoryFilters = options.directoryFilters;
+  }
+
+  return base;
+}
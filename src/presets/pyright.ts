@@ -28,6 +28,7 @@ export const pyrightAdapter: Preset = {
   },
   files: ["**/*.py", "**/*.pyi"],
   initializationOptions: {
+    // python.pythonPath is filled in from the detected environment
     python: {
       analysis: {
         autoSearchPaths: true,
@@ -0,0 +1,69 @@
+import { describe, it, expect } from "vitest";
+import {
+  applyRustAnalyzerOptions,
+  rustAnalyzerAdapter,
+} from "./rust-analyzer.ts";
+
+describe("rustAnalyzerAdapter", () => {
+  it("should check on save and expand proc macros by default", () => {
+    const options = rustAnalyzerAdapter.initializationOptions as Record<
+      string,
+      any
+    >;
+
+    expect(options.checkOnSave).toBe(true);
+    expect(options.check.command).toBe("check");
+    expect(options.procMacro.enable).toBe(true);
+    expect(options.cargo.buildScripts.enable).toBe(true);
+  });
+});
+
+describe("applyRustAnalyzerOptions", () => {
+  it("should merge settings into nested tables", () => {
+    const result = applyRustAnalyzerOptions(
+      rustAnalyzerAdapter.initializationOptions,
+      {
+        checkCommand: "clippy",
+        extraArgs: ["--", "-W", "clippy::pedantic"],
+        features: ["serde"],
+        targetDir: true,
+        extraEnv: { RUSTFLAGS: "--cfg tokio_unstable" },
+      },
+    ) as Record<string, any>;
+
+    expect(result.check).toEqual({
+      command: "clippy",
+      allTargets: true,
+      extraArgs: ["--", "-W", "clippy::pedantic"],
+    });
+    expect(result.cargo).toEqual({
+      features: ["serde"],
+      buildScripts: { enable: true },
+      targetDir: true,
+      extraEnv: { RUSTFLAGS: "--cfg tokio_unstable" },
+    });
+    // Untouched preset defaults are kept
+    expect(result.checkOnSave).toBe(true);
+    expect(result.procMacro).toEqual({ enable: true });
+  });
+
+  it("should turn off on-save checks and proc macros", () => {
+    const result = applyRustAnalyzerOptions(
+      { procMacro: { enable: true, attributes: { enable: true } } },
+      { checkOnSave: false, procMacro: false },
+    );
+
+    expect(result.checkOnSave).toBe(false);
+    expect(result.procMacro).toEqual({
+      enable: false,
+      attributes: { enable: true },
+    });
+  });
+
+  it("should not mutate the input options", () => {
+    const initializationOptions = { cargo: { features: "all" } };
+    applyRustAnalyzerOptions(initializationOptions, { features: ["serde"] });
+
+    expect(initializationOptions.cargo.features).toBe("all");
+  });
+});
@@ -1,12 +1,14 @@
-import type { Preset } from "../config/schema.ts";
+import type { Preset, RustAnalyzerOptions } from "../config/schema.ts";
 
 /**
  * rust-analyzer adapter
+ * @see https://rust-analyzer.github.io/book/configuration.html
  */
 export const rustAnalyzerAdapter: Preset = {
   presetId: "rust-analyzer",
   name: "rust-analyzer",
   description: "Language Server for Rust",
+  baseLanguage: "rust",
   binFindStrategy: {
     strategies: [
       // 1. Check global installation (most common for Rust)
@@ -23,10 +25,37 @@ export const rustAnalyzerAdapter: Preset = {
   initializationOptions: {
     cargo: {
       features: "all",
+      // Needed for crates with build.rs (generated code, cfgs)
+      buildScripts: {
+        enable: true,
+      },
+    },
+    procMacro: {
+      enable: true,
+    },
+    // Run cargo check on didSave and publish its diagnostics
+    checkOnSave: true,
+    check: {
+      command: "check",
+      allTargets: true,
+    },
+    diagnostics: {
+      enable: true,
+    },
+    // Index dependencies in the background so the first queries are complete
+    cachePriming: {
+      enable: true,
+    },
+  },
+
+  serverCharacteristics: {
+    // Cold starts index the whole dependency graph before answering, and
+    // requests during indexing fail with ContentModified
+    requestPolicies: {
+      "*": { retries: 3, backoff: 500 },
+      "textDocument/references": { timeout: 120000 },
+      "workspace/symbol": { timeout: 120000 },
     },
-    // procMacro: {
-    //   enable: true,
-    // },
   },
 
   // Language-specific features
@@ -37,3 +66,59 @@ export const rustAnalyzerAdapter: Preset = {
     },
   },
 };
+
+function asRecord(value: unknown): Record<string, unknown> {
+  return value && typeof value === "object"
+    ? { ...(value as Record<string, unknown>) }
+    : {};
+}
+
+/**
+ * Merge rust-analyzer settings from lsmcp config into initializationOptions
+ * Nested tables (cargo, check, procMacro) are merged; values override
+ */
+export function applyRustAnalyzerOptions(
+  initializationOptions: unknown,
+  options: RustAnalyzerOptions,
+): Record<string, unknown> {
+  const base = asRecord(initializationOptions);
+
+  if (options.checkOnSave !== undefined) {
+    base.checkOnSave = options.checkOnSave;
+  }
+  if (options.checkCommand !== undefined || options.extraArgs !== undefined) {
+    const check = asRecord(base.check);
+    if (options.checkCommand !== undefined) {
+      check.command = options.checkCommand;
+    }
+    if (options.extraArgs !== undefined) {
+      check.extraArgs = options.extraArgs;
+    }
+    base.check = check;
+  }
+  if (
+    options.features !== undefined ||
+    options.targetDir !== undefined ||
+    options.extraEnv !== undefined
+  ) {
+    const cargo = asRecord(base.cargo);
+    if (options.features !== undefined) {
+      cargo.features = options.features;
+    }
+    if (options.targetDir !== undefined) {
+      cargo.targetDir = options.targetDir;
+    }
+    if (options.extraEnv !== undefined) {
+      cargo.extraEnv = {
+        ...((cargo.extraEnv as Record<string, string> | undefined) ?? {}),
+        ...options.extraEnv,
+      };
+    }
+    base.cargo = cargo;
+  }
+  if (options.procMacro !== undefined) {
+    base.procMacro = { ...asRecord(base.procMacro), enable: options.procMacro };
+  }
+
+  return base;
+}
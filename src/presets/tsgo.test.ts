@@ -48,6 +48,8 @@ describe("tsgoAdapter", () => {
       expect(tsgoAdapter.disable).not.toContain("get_document_symbols");
       expect(tsgoAdapter.disable).not.toContain("get_workspace_symbols");
       expect(tsgoAdapter.disable).toContain("get_code_actions");
+      expect(tsgoAdapter.disable).toContain("apply_code_action");
+      expect(tsgoAdapter.disable).toContain("organize_imports");
       expect(tsgoAdapter.disable).toContain("rename_symbol");
       expect(tsgoAdapter.disable).toContain("delete_symbol");
     });
@@ -22,7 +22,13 @@ export const tsgoAdapter: Preset = {
     defaultArgs: ["--lsp", "--stdio"],
   },
   files: ["**/*.ts", "**/*.tsx", "**/*.d.ts"],
-  disable: ["get_code_actions", "rename_symbol", "delete_symbol"],
+  disable: [
+    "get_code_actions",
+    "apply_code_action",
+    "organize_imports",
+    "rename_symbol",
+    "delete_symbol",
+  ],
   needsDiagnosticDeduplication: true,
 
   serverCharacteristics: {
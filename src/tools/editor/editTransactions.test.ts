@@ -0,0 +1,192 @@
+import { describe, it, expect, beforeEach, vi } from "vitest";
+import {
+  existsSync,
+  mkdirSync,
+  mkdtempSync,
+  readFileSync,
+  writeFileSync,
+} from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { pathToFileURL } from "node:url";
+import type { McpContext } from "@internal/types";
+import {
+  JOURNAL_DIR,
+  addToEditTransactionTool,
+  beginEditTransactionTool,
+  commitEditTransactionTool,
+} from "./editTransactions.ts";
+import { applyWorkspaceEditTool } from "./workspaceEditTools.ts";
+
+vi.mock("@internal/code-indexer");
+
+describe("edit transactions", () => {
+  let root: string;
+  let uri: string;
+
+  /** Replace the whole of line 2 (0-based) */
+  const setLine = (newText: string) => ({
+    changes: {
+      [uri]: [
+        {
+          range: {
+            start: { line: 2, character: 0 },
+            end: { line: 2, character: 9 },
+          },
+          newText,
+        },
+      ],
+    },
+  });
+
+  const begin = async () => {
+    const result = await beginEditTransactionTool.execute({ root });
+    return /transaction (\w+)/.exec(result)![1];
+  };
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-edit-transaction-"));
+    writeFileSync(join(root, "a.go"), "package a\n\nvar x = 1\n");
+    uri = pathToFileURL(join(root, "a.go")).toString();
+  });
+
+  it("should stage edits on top of each other and commit them", async () => {
+    const transaction = await begin();
+    await addToEditTransactionTool.execute({
+      transaction,
+      edit: setLine("var y = 2"),
+    });
+    await addToEditTransactionTool.execute({
+      transaction,
+      edit: setLine("var z = 3"),
+    });
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar x = 1\n",
+    );
+
+    const result = await commitEditTransactionTool.execute({
+      transaction,
+      dryRun: false,
+    });
+
+    expect(result).toContain("2 edit(s) written to 1 file(s)");
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar z = 3\n",
+    );
+    expect(existsSync(join(root, JOURNAL_DIR, `${transaction}.json`))).toBe(
+      false,
+    );
+  });
+
+  it("should refuse to commit when a file changed after staging", async () => {
+    const transaction = await begin();
+    await addToEditTransactionTool.execute({
+      transaction,
+      edit: setLine("var y = 2"),
+    });
+    writeFileSync(join(root, "a.go"), "package a\n\nvar x = 5\n");
+
+    await expect(
+      commitEditTransactionTool.execute({ transaction, dryRun: false }),
+    ).rejects.toThrow(/files changed on disk since they were staged: a\.go/);
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar x = 5\n",
+    );
+
+    // The staged edits survive the refusal
+    writeFileSync(join(root, "a.go"), "package a\n\nvar x = 1\n");
+    await commitEditTransactionTool.execute({ transaction, dryRun: false });
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar y = 2\n",
+    );
+  });
+
+  it("should reject previewed edits of files already staged", async () => {
+    const transaction = await begin();
+    await addToEditTransactionTool.execute({
+      transaction,
+      edit: setLine("var y = 2"),
+    });
+    const preview = await applyWorkspaceEditTool.execute({
+      root,
+      edit: setLine("var z = 3"),
+      dryRun: false,
+      preview: true,
+    });
+    const token = /Edit token: (\S+)/.exec(preview)![1];
+
+    await expect(
+      addToEditTransactionTool.execute({ transaction, token }),
+    ).rejects.toThrow(/other contents than those staged for a\.go/);
+  });
+
+  it("should roll back an interrupted commit from its journal", async () => {
+    writeFileSync(join(root, "a.go"), "half written");
+    writeFileSync(join(root, "new.go"), "package a\n");
+    mkdirSync(join(root, JOURNAL_DIR), { recursive: true });
+    writeFileSync(
+      join(root, JOURNAL_DIR, "abc.json"),
+      JSON.stringify({
+        createdAt: new Date().toISOString(),
+        changes: [
+          {
+            filePath: join(root, "a.go"),
+            before: "package a\n\nvar x = 1\n",
+          },
+          { filePath: join(root, "new.go"), before: null },
+        ],
+      }),
+    );
+
+    const result = await beginEditTransactionTool.execute({ root });
+
+    expect(result).toContain("restoring 2 file(s)");
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar x = 1\n",
+    );
+    expect(existsSync(join(root, "new.go"))).toBe(false);
+    expect(existsSync(join(root, JOURNAL_DIR, "abc.json"))).toBe(false);
+  });
+
+  it("should not roll back the journal of a commit in progress", async () => {
+    const transaction = await begin();
+    await addToEditTransactionTool.execute({
+      transaction,
+      edit: setLine("var y = 2"),
+    });
+    let approve!: (confirmed: boolean) => void;
+    const context = {
+      config: {
+        diagnosticsDelta: false,
+        confirmations: { enabled: true, autoApproveFiles: 0 },
+      },
+      confirm: () => new Promise<boolean>((resolve) => (approve = resolve)),
+    } as unknown as McpContext;
+    const commit = commitEditTransactionTool.execute(
+      { transaction, dryRun: false },
+      context,
+    );
+    await vi.waitFor(() => expect(approve).toBeDefined());
+
+    // Another transaction begins while the commit holds its journal
+    const journal = join(root, JOURNAL_DIR, `${transaction}.json`);
+    mkdirSync(join(root, JOURNAL_DIR), { recursive: true });
+    writeFileSync(
+      journal,
+      JSON.stringify({
+        createdAt: new Date().toISOString(),
+        changes: [{ filePath: join(root, "a.go"), before: "rolled back" }],
+      }),
+    );
+    const result = await beginEditTransactionTool.execute({ root });
+    expect(result).not.toContain("Rolled back");
+    expect(existsSync(journal)).toBe(true);
+
+    approve(true);
+    expect(await commit).toContain("1 edit(s) written to 1 file(s)");
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar y = 2\n",
+    );
+    expect(existsSync(journal)).toBe(false);
+  });
+});
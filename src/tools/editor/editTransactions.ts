@@ -0,0 +1,412 @@
+/**
+ * Multi-file edit transactions
+ *
+ * A transaction collects edits over several tool calls: edits previewed with
+ * `preview: true` (by their edit token) and WorkspaceEdits, which are planned
+ * against the contents staged so far. Committing refuses when any file changed
+ * on disk after it was staged and writes everything or nothing. A rollback
+ * journal under .lsmcp/journal holds the original contents until the last file
+ * is written, so a commit interrupted by a crash is undone when the next
+ * transaction begins.
+ */
+
+import { z } from "zod";
+import { randomBytes } from "node:crypto";
+import { existsSync } from "node:fs";
+import { readdir, readFile, unlink } from "node:fs/promises";
+import { join } from "node:path";
+import type { McpToolDef } from "@internal/types";
+import {
+  confirmWorkspaceEdit,
+  findStaleFiles,
+  formatWorkspaceEditDiff,
+  openDocumentVersions,
+  parseWorkspaceEdit,
+  planWorkspaceEdit,
+  resolveSandboxedEdit,
+  revertChange,
+  toRelative,
+  writeWorkspaceEditPlan,
+  type EditJournal,
+  type WorkspaceEditPlan,
+} from "./workspaceEditTools.ts";
+import { takePendingEdit } from "./pendingEdits.ts";
+import {
+  assertRenameTargetsAbsent,
+  notifyFileRenames,
+  type RenamedFile,
+} from "./fileRenames.ts";
+import {
+  deltaFilesOfPlan,
+  trackDiagnostics,
+} from "../lsp/diagnosticsDelta.ts";
+
+export const JOURNAL_DIR = join(".lsmcp", "journal");
+
+/** Transactions left open longer than this are discarded */
+const TRANSACTION_TTL_MS = 60 * 60 * 1000;
+
+interface EditTransaction {
+  root: string;
+  description?: string;
+  /** Staged changes, one per file, from the original to the latest content */
+  plan: WorkspaceEditPlan;
+  /** Edits added so far */
+  edits: number;
+  /** Files moved by staged rename_file previews */
+  renamedFiles: RenamedFile[];
+  createdAt: number;
+  /** Set while a commit is confirmed and written */
+  committing?: boolean;
+}
+
+const transactions = new Map<string, EditTransaction>();
+
+function getTransaction(id: string): EditTransaction {
+  const now = Date.now();
+  for (const [key, transaction] of transactions) {
+    if (
+      !transaction.committing &&
+      now - transaction.createdAt > TRANSACTION_TTL_MS
+    ) {
+      transactions.delete(key);
+    }
+  }
+  const transaction = transactions.get(id);
+  if (!transaction) {
+    throw new Error(
+      `Unknown or expired edit transaction '${id}'. Start one with begin_edit_transaction.`,
+    );
+  }
+  return transaction;
+}
+
+/**
+ * Content of each touched path after the staged changes (null when the path
+ * no longer exists)
+ */
+function stagedContents(plan: WorkspaceEditPlan): Map<string, string | null> {
+  const contents = new Map<string, string | null>();
+  for (const change of plan.changes) {
+    if (change.oldFilePath) {
+      contents.set(change.oldFilePath, null);
+    }
+    contents.set(change.filePath, change.after);
+  }
+  return contents;
+}
+
+/**
+ * Stage a plan on top of a transaction's changes. Each file of the plan must
+ * have been computed against its staged content.
+ * @returns paths that conflict with staged changes; nothing is merged then
+ */
+export function mergeWorkspaceEditPlan(
+  target: WorkspaceEditPlan,
+  plan: WorkspaceEditPlan,
+): string[] {
+  const conflicts: string[] = [];
+  for (const change of plan.changes) {
+    const sourcePath = change.oldFilePath ?? change.filePath;
+    const staged = target.changes.find((c) => c.filePath === sourcePath);
+    const movedAway = target.changes.some((c) => c.oldFilePath === sourcePath);
+    if (staged ? staged.after !== change.before : movedAway) {
+      conflicts.push(sourcePath);
+    }
+    // What a rename replaces is restored from disk, not from staged content
+    if (
+      change.replaced !== undefined &&
+      target.changes.some((c) => c.filePath === change.filePath)
+    ) {
+      conflicts.push(change.filePath);
+    }
+  }
+  if (conflicts.length > 0) {
+    return conflicts;
+  }
+
+  for (const change of plan.changes) {
+    const sourcePath = change.oldFilePath ?? change.filePath;
+    const staged = target.changes.find((c) => c.filePath === sourcePath);
+    if (!staged) {
+      target.changes.push({ ...change });
+      continue;
+    }
+
+    staged.after = change.after;
+    if (change.oldFilePath && staged.before === null) {
+      // A file created in this transaction is simply created elsewhere
+      staged.filePath = change.filePath;
+    } else if (change.oldFilePath) {
+      staged.oldFilePath = staged.oldFilePath ?? staged.filePath;
+      staged.filePath = change.filePath;
+      staged.kind = "rename";
+    } else if (change.after === null) {
+      // Deleting a renamed file deletes the original
+      staged.filePath = staged.oldFilePath ?? staged.filePath;
+      staged.oldFilePath = undefined;
+      staged.kind = "delete";
+    } else if (staged.kind === "delete") {
+      staged.kind = "edit";
+    }
+
+    if (staged.before === null && staged.after === null) {
+      // Created and deleted again: nothing to write
+      target.changes.splice(target.changes.indexOf(staged), 1);
+    }
+  }
+  return [];
+}
+
+/**
+ * Undo commits interrupted before their rollback journal was removed
+ * Journals of commits still in progress in this process are left alone.
+ * @returns files restored
+ */
+export async function recoverEditJournals(root: string): Promise<string[]> {
+  const dir = join(root, JOURNAL_DIR);
+  if (!existsSync(dir)) {
+    return [];
+  }
+  const restored: string[] = [];
+  for (const entry of (await readdir(dir)).sort()) {
+    const id = entry.slice(0, -".json".length);
+    if (!entry.endsWith(".json") || transactions.get(id)?.committing) {
+      continue;
+    }
+    const journalPath = join(dir, entry);
+    const journal = JSON.parse(
+      await readFile(journalPath, "utf-8"),
+    ) as EditJournal;
+    for (const change of [...journal.changes].reverse()) {
+      await revertChange(change);
+      restored.push(toRelative(root, change.oldFilePath ?? change.filePath));
+    }
+    await unlink(journalPath);
+  }
+  return restored;
+}
+
+function countFiles(plan: WorkspaceEditPlan): string {
+  const count = plan.changes.length;
+  return `${count} file${count === 1 ? "" : "s"}`;
+}
+
+const transactionId = z
+  .string()
+  .describe("Transaction id from begin_edit_transaction");
+
+const beginSchema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  description: z
+    .string()
+    .optional()
+    .describe("What the transaction is for, shown when it is committed"),
+});
+
+const addSchema = z.object({
+  transaction: transactionId,
+  token: z
+    .string()
+    .optional()
+    .describe(
+      "Edit token of an edit previewed with preview: true (computed against the files on disk)",
+    ),
+  edit: z
+    .union([z.string(), z.record(z.unknown())])
+    .optional()
+    .describe(
+      "LSP WorkspaceEdit (object or JSON string), applied on top of the edits already staged",
+    ),
+});
+
+const commitSchema = z.object({
+  transaction: transactionId,
+  dryRun: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Show the combined diff without writing or closing the transaction",
+    ),
+});
+
+const abortSchema = z.object({
+  transaction: transactionId,
+});
+
+export const beginEditTransactionTool: McpToolDef<typeof beginSchema> = {
+  name: "begin_edit_transaction",
+  description:
+    "Start a multi-file edit transaction. Stage edits with add_to_edit_transaction over several calls, " +
+    "then write them all or nothing with commit_edit_transaction. " +
+    "Commits interrupted by a crash are rolled back from their journal first.",
+  schema: beginSchema,
+  execute: async ({ root, description }) => {
+    const restored = await recoverEditJournals(root);
+    const id = randomBytes(6).toString("hex");
+    transactions.set(id, {
+      root,
+      description,
+      plan: { changes: [], errors: [] },
+      edits: 0,
+      renamedFiles: [],
+      createdAt: Date.now(),
+    });
+
+    const lines = [
+      `Started edit transaction ${id}. Stage edits with add_to_edit_transaction, then call commit_edit_transaction.`,
+    ];
+    if (restored.length > 0) {
+      lines.push(
+        "",
+        `Rolled back an interrupted commit, restoring ${restored.length} file(s):`,
+        ...restored.map((file) => `  ${file}`),
+      );
+    }
+    return lines.join("\n");
+  },
+};
+
+export const addToEditTransactionTool: McpToolDef<typeof addSchema> = {
+  name: "add_to_edit_transaction",
+  description:
+    "Stage an edit in an edit transaction without writing: either the edit token of a previewed edit " +
+    "(rename, code action, formatting, delete symbol) or an LSP WorkspaceEdit. " +
+    "WorkspaceEdits see the edits staged before them; previewed edits must not touch files already staged.",
+  schema: addSchema,
+  execute: async ({ transaction: id, token, edit }, context) => {
+    if ((token === undefined) === (edit === undefined)) {
+      throw new Error("Pass either token or edit");
+    }
+    const transaction = getTransaction(id);
+
+    let plan: WorkspaceEditPlan;
+    let title: string;
+    let renamedFiles: RenamedFile[] = [];
+    if (token !== undefined) {
+      ({ plan, title, renamedFiles = [] } = takePendingEdit(
+        token,
+        context?.sessionId,
+      ));
+    } else {
+      const workspaceEdit = resolveSandboxedEdit(
+        transaction.root,
+        parseWorkspaceEdit(edit!),
+        context,
+      );
+      plan = await planWorkspaceEdit(
+        workspaceEdit,
+        stagedContents(transaction.plan),
+        openDocumentVersions(context?.lspClient),
+      );
+      title = "workspace edit";
+      if (plan.errors.length > 0) {
+        throw new Error(
+          `Cannot stage workspace edit:\n${plan.errors
+            .map((e) => `  ${e}`)
+            .join("\n")}`,
+        );
+      }
+    }
+
+    const conflicts = mergeWorkspaceEditPlan(transaction.plan, plan);
+    if (conflicts.length > 0) {
+      const files = conflicts
+        .map((file) => toRelative(transaction.root, file))
+        .join(", ");
+      throw new Error(
+        `Cannot stage ${title}: it was computed against other contents than those staged for ${files}. ` +
+          "Stage it as a WorkspaceEdit on top of the staged contents instead.",
+      );
+    }
+    transaction.edits++;
+    transaction.renamedFiles.push(...renamedFiles);
+
+    return [
+      `Staged ${title} in transaction ${id} (${transaction.edits} edit(s), ${countFiles(transaction.plan)} so far)`,
+      "",
+      formatWorkspaceEditDiff(transaction.root, plan),
+    ].join("\n");
+  },
+};
+
+export const commitEditTransactionTool: McpToolDef<typeof commitSchema> = {
+  name: "commit_edit_transaction",
+  description:
+    "Write every edit staged in an edit transaction, all or nothing. Refused when any file changed on disk after it was staged. " +
+    "A rollback journal in .lsmcp/journal keeps the original contents until all files are written.",
+  schema: commitSchema,
+  execute: async ({ transaction: id, dryRun }, context) => {
+    const transaction = getTransaction(id);
+    const { root, plan, description } = transaction;
+    const label = description
+      ? `transaction ${id} (${description})`
+      : `transaction ${id}`;
+
+    if (dryRun) {
+      return plan.changes.length === 0
+        ? `Nothing staged in ${label}.`
+        : [
+            `Dry run: committing ${label} would change ${countFiles(plan)}.`,
+            "",
+            formatWorkspaceEditDiff(root, plan),
+          ].join("\n");
+    }
+
+    if (plan.changes.length === 0) {
+      transactions.delete(id);
+      return `Nothing staged in ${label}; closed it.`;
+    }
+    if (transaction.committing) {
+      throw new Error(`Cannot commit ${label}: a commit of it is in progress`);
+    }
+
+    // A declined, stale or failed commit keeps the transaction open
+    transaction.committing = true;
+    let delta: (root: string) => Promise<string>;
+    let written: string[];
+    try {
+      assertRenameTargetsAbsent(root, transaction.renamedFiles);
+      await confirmWorkspaceEdit(root, plan, `commit of ${label}`, context);
+      const stale = await findStaleFiles(root, plan);
+      if (stale.length > 0) {
+        throw new Error(
+          `Refusing to commit ${label}; files changed on disk since they were staged: ${stale.join(", ")}. Nothing was written.`,
+        );
+      }
+
+      delta = await trackDiagnostics(
+        context?.lspClient,
+        deltaFilesOfPlan(plan),
+        context,
+      );
+      written = await writeWorkspaceEditPlan(root, plan, {
+        journal: join(root, JOURNAL_DIR, `${id}.json`),
+        context,
+      });
+    } finally {
+      transaction.committing = false;
+    }
+    transactions.delete(id);
+    notifyFileRenames(context?.lspClient, transaction.renamedFiles);
+    const files = written.map((file) => `  ${file}`).join("\n");
+    const output = `Committed ${label}: ${transaction.edits} edit(s) written to ${written.length} file(s):\n${files}`;
+    const diagnostics = await delta(root);
+    return diagnostics ? `${output}\n\n${diagnostics}` : output;
+  },
+};
+
+export const abortEditTransactionTool: McpToolDef<typeof abortSchema> = {
+  name: "abort_edit_transaction",
+  description:
+    "Discard an edit transaction and everything staged in it. Nothing is written.",
+  schema: abortSchema,
+  execute: async ({ transaction: id }) => {
+    const transaction = getTransaction(id);
+    if (transaction.committing) {
+      throw new Error(`Transaction ${id} is being committed`);
+    }
+    transactions.delete(id);
+    return `Discarded transaction ${id} with ${transaction.edits} staged edit(s); nothing was written.`;
+  },
+};
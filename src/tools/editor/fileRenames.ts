@@ -0,0 +1,49 @@
+/**
+ * Steps around writing the file moves of rename_file, taken both when the
+ * tool writes right away and when its preview is confirmed later
+ */
+
+import { existsSync } from "node:fs";
+import { fileURLToPath } from "node:url";
+import type { LSPClient } from "@internal/lsp-client";
+import { toRelative } from "./workspaceEditTools.ts";
+
+/** A file moved by rename_file, as file URIs */
+export interface RenamedFile {
+  oldUri: string;
+  newUri: string;
+}
+
+/**
+ * @throws when a file already exists where a rename would move another
+ */
+export function assertRenameTargetsAbsent(
+  root: string,
+  files: RenamedFile[],
+): void {
+  for (const { newUri } of files) {
+    const target = fileURLToPath(newUri);
+    if (existsSync(target)) {
+      throw new Error(`${toRelative(root, target)} already exists`);
+    }
+  }
+}
+
+/**
+ * Tell the language server about written renames: the old documents no
+ * longer exist, and workspace/didRenameFiles lets it update its project
+ */
+export function notifyFileRenames(
+  client: LSPClient | undefined,
+  files: RenamedFile[],
+): void {
+  if (!client || files.length === 0) {
+    return;
+  }
+  for (const { oldUri } of files) {
+    if (client.isDocumentOpen(oldUri)) {
+      client.closeDocument(oldUri);
+    }
+  }
+  client.didRenameFiles(files);
+}
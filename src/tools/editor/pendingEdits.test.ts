@@ -0,0 +1,98 @@
+import { describe, it, expect, beforeEach, vi } from "vitest";
+import { mkdtempSync, readFileSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { pathToFileURL } from "node:url";
+import type { McpContext } from "@internal/types";
+import { applyWorkspaceEditTool } from "./workspaceEditTools.ts";
+import { confirmEditTool } from "./pendingEdits.ts";
+
+vi.mock("@internal/code-indexer");
+
+describe("confirmEditTool", () => {
+  let root: string;
+  let edit: Record<string, unknown>;
+
+  const previewToken = async (context?: McpContext) => {
+    const result = await applyWorkspaceEditTool.execute(
+      { root, edit, dryRun: false, preview: true },
+      context,
+    );
+    return /Edit token: (\S+)/.exec(result)![1];
+  };
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-pending-edit-"));
+    writeFileSync(join(root, "a.go"), "package a\n\nvar x = 1\n");
+    edit = {
+      changes: {
+        [pathToFileURL(join(root, "a.go")).toString()]: [
+          {
+            range: {
+              start: { line: 2, character: 4 },
+              end: { line: 2, character: 5 },
+            },
+            newText: "count",
+          },
+        ],
+      },
+    };
+  });
+
+  it("should return diffs and a token without writing", async () => {
+    const result = await applyWorkspaceEditTool.execute({
+      root,
+      edit,
+      dryRun: false,
+      preview: true,
+    });
+
+    expect(result).toContain("would change 1 file(s); nothing was written");
+    expect(result).toContain("+var count = 1");
+    expect(result).toContain("Call confirm_edit with this token");
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar x = 1\n",
+    );
+  });
+
+  it("should apply a previewed edit once", async () => {
+    const token = await previewToken();
+
+    const result = await confirmEditTool.execute({ token });
+
+    expect(result).toContain("Applied workspace edit to 1 file(s)");
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar count = 1\n",
+    );
+    await expect(confirmEditTool.execute({ token })).rejects.toThrow(
+      /Unknown or expired edit token/,
+    );
+  });
+
+  it("should refuse when a file changed after the preview", async () => {
+    const token = await previewToken();
+    writeFileSync(join(root, "a.go"), "package a\n\nvar x = 2\n");
+
+    await expect(confirmEditTool.execute({ token })).rejects.toThrow(
+      /files changed since the preview: a\.go/,
+    );
+    expect(readFileSync(join(root, "a.go"), "utf-8")).toBe(
+      "package a\n\nvar x = 2\n",
+    );
+  });
+
+  it("should only confirm edits previewed in the same session", async () => {
+    const session = (sessionId: string) => ({ sessionId }) as McpContext;
+    const token = await previewToken(session("a"));
+
+    await expect(
+      confirmEditTool.execute({ token }, session("b")),
+    ).rejects.toThrow(/Unknown or expired edit token/);
+    await expect(confirmEditTool.execute({ token })).rejects.toThrow(
+      /Unknown or expired edit token/,
+    );
+
+    const result = await confirmEditTool.execute({ token }, session("a"));
+    expect(result).toContain("Applied workspace edit to 1 file(s)");
+  });
+});
@@ -0,0 +1,181 @@
+/**
+ * Previewed edits waiting for confirmation
+ *
+ * Editing tools called with `preview: true` compute their edit, keep it here
+ * under a random token and return per-file diffs. confirm_edit applies the
+ * kept edit as a whole, refusing when any file changed after the preview.
+ * Over HTTP each session only sees the edits it previewed.
+ */
+
+import { z } from "zod";
+import { randomBytes } from "node:crypto";
+import type { McpToolDef } from "@internal/types";
+import {
+  deltaFilesOfPlan,
+  trackDiagnostics,
+} from "../lsp/diagnosticsDelta.ts";
+import {
+  confirmWorkspaceEdit,
+  findStaleFiles,
+  formatWorkspaceEditDiff,
+  writeWorkspaceEditPlan,
+  type WorkspaceEditPlan,
+} from "./workspaceEditTools.ts";
+import {
+  assertRenameTargetsAbsent,
+  notifyFileRenames,
+  type RenamedFile,
+} from "./fileRenames.ts";
+
+/** Tokens expire after this long */
+export const EDIT_TOKEN_TTL_MS = 15 * 60 * 1000;
+
+/** The oldest previews of a session are dropped beyond this count */
+const MAX_PENDING_EDITS = 50;
+
+interface PendingEdit {
+  root: string;
+  plan: WorkspaceEditPlan;
+  /** What the edit does, e.g. `rename "x" to "count"` */
+  title: string;
+  /** Files rename_file moves, for the server to be told after writing */
+  renamedFiles?: RenamedFile[];
+  createdAt: number;
+}
+
+export interface PendingEditOptions {
+  renamedFiles?: RenamedFile[];
+  /** MCP session of the previewing call (see McpContext.sessionId) */
+  sessionId?: string;
+}
+
+// Edits by session, then by token; calls without a session (stdio) use ""
+const pending = new Map<string, Map<string, PendingEdit>>();
+
+function prune(now: number): void {
+  for (const [sessionId, edits] of pending) {
+    for (const [token, edit] of edits) {
+      if (now - edit.createdAt > EDIT_TOKEN_TTL_MS) {
+        edits.delete(token);
+      }
+    }
+    // Map iteration follows insertion order, so the first key is the oldest
+    while (edits.size > MAX_PENDING_EDITS) {
+      edits.delete(edits.keys().next().value!);
+    }
+    if (edits.size === 0) {
+      pending.delete(sessionId);
+    }
+  }
+}
+
+/**
+ * Keep a planned edit for confirm_edit and return its token
+ */
+export function addPendingEdit(
+  root: string,
+  plan: WorkspaceEditPlan,
+  title: string,
+  { sessionId = "", ...options }: PendingEditOptions = {},
+): string {
+  const now = Date.now();
+  const token = randomBytes(9).toString("base64url");
+  const edits = pending.get(sessionId) ?? new Map<string, PendingEdit>();
+  edits.set(token, { root, plan, title, ...options, createdAt: now });
+  pending.set(sessionId, edits);
+  prune(now);
+  return token;
+}
+
+/**
+ * Remove and return a pending edit of a session; a token can be confirmed
+ * only once
+ * @throws for unknown and expired tokens, and tokens of other sessions
+ */
+export function takePendingEdit(token: string, sessionId = ""): PendingEdit {
+  prune(Date.now());
+  const edit = pending.get(sessionId)?.get(token);
+  if (!edit) {
+    throw new Error(
+      `Unknown or expired edit token '${token}'. Run the editing tool with preview: true again.`,
+    );
+  }
+  pending.get(sessionId)!.delete(token);
+  return edit;
+}
+
+/**
+ * Forget the pending edits of a closed session
+ */
+export function dropPendingEdits(sessionId: string): void {
+  pending.delete(sessionId);
+}
+
+export function formatEditTokenNote(token: string): string {
+  return `Edit token: ${token}\nCall confirm_edit with this token to apply the edit (valid for ${
+    EDIT_TOKEN_TTL_MS / 60_000
+  } minutes).`;
+}
+
+/**
+ * Keep the plan and describe it: diffs per file followed by the edit token
+ */
+export function previewWorkspaceEdit(
+  root: string,
+  plan: WorkspaceEditPlan,
+  title: string,
+  options: PendingEditOptions = {},
+): string {
+  if (plan.changes.length === 0) {
+    return `Preview: ${title} changes nothing.`;
+  }
+  const token = addPendingEdit(root, plan, title, options);
+  return [
+    `Preview: ${title} would change ${plan.changes.length} file(s); nothing was written.`,
+    "",
+    formatWorkspaceEditDiff(root, plan),
+    "",
+    formatEditTokenNote(token),
+  ].join("\n");
+}
+
+const confirmEditSchema = z.object({
+  token: z
+    .string()
+    .describe("Edit token returned by an editing tool called with preview"),
+});
+
+export const confirmEditTool: McpToolDef<typeof confirmEditSchema> = {
+  name: "confirm_edit",
+  description:
+    "Apply an edit previewed with preview: true (rename, file rename, code action, formatting, symbol edits, delete symbol, workspace edit). " +
+    "All files are written or none; the edit is refused when any file changed after the preview.",
+  schema: confirmEditSchema,
+  execute: async ({ token }, context) => {
+    const { root, plan, title, renamedFiles = [] } = takePendingEdit(
+      token,
+      context?.sessionId,
+    );
+
+    assertRenameTargetsAbsent(root, renamedFiles);
+    const stale = await findStaleFiles(root, plan);
+    if (stale.length > 0) {
+      throw new Error(
+        `Refusing to apply ${title}; files changed since the preview: ${stale.join(", ")}. Preview the edit again.`,
+      );
+    }
+    await confirmWorkspaceEdit(root, plan, title, context);
+
+    const delta = await trackDiagnostics(
+      context?.lspClient,
+      deltaFilesOfPlan(plan),
+      context,
+    );
+    const written = await writeWorkspaceEditPlan(root, plan, { context });
+    notifyFileRenames(context?.lspClient, renamedFiles);
+    const files = written.map((file) => `  ${file}`).join("\n");
+    const output = `Applied ${title} to ${written.length} file(s):\n${files}`;
+    const diagnostics = await delta(root);
+    return diagnostics ? `${output}\n\n${diagnostics}` : output;
+  },
+};
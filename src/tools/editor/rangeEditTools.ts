@@ -4,6 +4,8 @@ import type { SerenityEditResult } from "./regexEditTools.ts";
 import { readFile, writeFile } from "node:fs/promises";
 import { resolve } from "node:path";
 import { markFileModified } from "@internal/code-indexer";
+import { trackDiagnostics } from "../lsp/diagnosticsDelta.ts";
+import { assertNotLsmcpFiles } from "../../utils/pathSandbox.ts";
 
 const replaceRangeSchema = z.object({
   root: z.string().describe("Root directory for resolving relative paths"),
@@ -39,18 +41,22 @@ export const replaceRangeTool: McpToolDef<typeof replaceRangeSchema> = {
     "Can be used to: replace symbol bodies, insert before/after symbols, delete ranges, or make precise edits. " +
     "Line numbers are 1-based, character positions are 0-based.",
   schema: replaceRangeSchema,
-  execute: async ({
-    root,
-    relativePath,
-    startLine,
-    startCharacter,
-    endLine,
-    endCharacter,
-    newContent,
-    preserveIndentation,
-  }) => {
+  execute: async (
+    {
+      root,
+      relativePath,
+      startLine,
+      startCharacter,
+      endLine,
+      endCharacter,
+      newContent,
+      preserveIndentation,
+    },
+    context,
+  ) => {
     try {
       const absolutePath = resolve(root, relativePath);
+      assertNotLsmcpFiles([absolutePath]);
 
       // Read the file content
       const fileContent = await readFile(absolutePath, "utf-8");
@@ -137,6 +143,12 @@ export const replaceRangeTool: McpToolDef<typeof replaceRangeSchema> = {
         );
       }
 
+      const delta = await trackDiagnostics(
+        context?.lspClient,
+        [{ filePath: absolutePath }],
+        context,
+      );
+
       // Write back to file
       await writeFile(absolutePath, lines.join("\n"), "utf-8");
 
@@ -146,6 +158,7 @@ export const replaceRangeTool: McpToolDef<typeof replaceRangeSchema> = {
       return JSON.stringify({
         success: true,
         filesChanged: [relativePath],
+        diagnostics: (await delta(root)) || undefined,
       } as SerenityEditResult);
     } catch (error) {
       return JSON.stringify({
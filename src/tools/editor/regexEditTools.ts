@@ -4,11 +4,17 @@ export interface SerenityEditResult {
   success: boolean;
   error?: string;
   filesChanged?: string[];
+  /** New and fixed errors and warnings, when a language server is running */
+  diagnostics?: string;
+  /** Diff and edit token of a previewed edit; nothing was written */
+  preview?: string;
 }
 import { readFile, writeFile } from "node:fs/promises";
 import { resolve } from "node:path";
 import { markFileModified } from "@internal/code-indexer";
 import type { McpToolDef } from "@internal/types";
+import { trackDiagnostics } from "../lsp/diagnosticsDelta.ts";
+import { assertNotLsmcpFiles } from "../../utils/pathSandbox.ts";
 
 const replaceRegexSchema = z.object({
   root: z.string().describe("Root directory for resolving relative paths"),
@@ -28,15 +34,19 @@ export const replaceRegexTool: McpToolDef<typeof replaceRegexSchema> = {
   description:
     "Replace content using regular expressions with dotall and multiline flags",
   schema: replaceRegexSchema,
-  execute: async ({
-    root,
-    relativePath,
-    regex,
-    repl,
-    allowMultipleOccurrences = false,
-  }) => {
+  execute: async (
+    {
+      root,
+      relativePath,
+      regex,
+      repl,
+      allowMultipleOccurrences = false,
+    },
+    context,
+  ) => {
     try {
       const absolutePath = resolve(root, relativePath);
+      assertNotLsmcpFiles([absolutePath]);
 
       // Read the file
       const fileContent = await readFile(absolutePath, "utf-8");
@@ -82,6 +92,11 @@ export const replaceRegexTool: McpToolDef<typeof replaceRegexSchema> = {
       }
 
       // Write back
+      const delta = await trackDiagnostics(
+        context?.lspClient,
+        [{ filePath: absolutePath }],
+        context,
+      );
       await writeFile(absolutePath, newContent, "utf-8");
 
       // Mark file as modified for auto-indexing
@@ -90,6 +105,7 @@ export const replaceRegexTool: McpToolDef<typeof replaceRegexSchema> = {
       return JSON.stringify({
         success: true,
         filesChanged: [relativePath],
+        diagnostics: (await delta(root)) || undefined,
       } as SerenityEditResult);
     } catch (error) {
       return JSON.stringify({
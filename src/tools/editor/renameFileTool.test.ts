@@ -0,0 +1,181 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import { existsSync } from "node:fs";
+import { mkdir, mkdtemp, readFile, rm, writeFile } from "node:fs/promises";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { pathToFileURL } from "node:url";
+import { renameFileTool } from "./renameFileTool.ts";
+import { confirmEditTool } from "./pendingEdits.ts";
+
+vi.mock("@internal/code-indexer");
+
+describe("renameFileTool", () => {
+  let root: string;
+
+  beforeEach(async () => {
+    root = await mkdtemp(join(tmpdir(), "lsmcp-rename-file-"));
+    await writeFile(join(root, "util.ts"), "export const one = 1;\n");
+    await writeFile(
+      join(root, "main.ts"),
+      'import { one } from "./util";\nconsole.log(one);\n',
+    );
+  });
+
+  afterEach(async () => {
+    await rm(root, { recursive: true, force: true });
+  });
+
+  function createClient(willRename: boolean) {
+    const mainUri = pathToFileURL(join(root, "main.ts")).toString();
+    return {
+      getServerCapabilities: () => ({
+        workspace: willRename
+          ? { fileOperations: { willRename: {}, didRename: {} } }
+          : {},
+      }),
+      willRenameFiles: vi.fn(async () => ({
+        changes: {
+          [mainUri]: [
+            {
+              range: {
+                start: { line: 0, character: 21 },
+                end: { line: 0, character: 27 },
+              },
+              newText: "./lib/util",
+            },
+          ],
+        },
+      })),
+      didRenameFiles: vi.fn(),
+      isDocumentOpen: vi.fn(() => true),
+      closeDocument: vi.fn(),
+    };
+  }
+
+  const args = {
+    root: "",
+    relativePath: "util.ts",
+    newPath: "lib/util.ts",
+    dryRun: false,
+    preview: false,
+  };
+
+  it("should apply the server's import updates together with the rename", async () => {
+    const client = createClient(true);
+
+    const result = await renameFileTool.execute({ ...args, root }, {
+      lspClient: client,
+      config: { diagnosticsDelta: false },
+    } as any);
+
+    expect(result).toContain("Renamed util.ts to lib/util.ts");
+    expect(result).toContain("Updated imports in 1 file(s):\n  main.ts");
+    expect(existsSync(join(root, "util.ts"))).toBe(false);
+    expect(await readFile(join(root, "lib/util.ts"), "utf-8")).toBe(
+      "export const one = 1;\n",
+    );
+    expect(await readFile(join(root, "main.ts"), "utf-8")).toBe(
+      'import { one } from "./lib/util";\nconsole.log(one);\n',
+    );
+    const files = [
+      {
+        oldUri: pathToFileURL(join(root, "util.ts")).toString(),
+        newUri: pathToFileURL(join(root, "lib/util.ts")).toString(),
+      },
+    ];
+    expect(client.willRenameFiles).toHaveBeenCalledWith(files);
+    expect(client.didRenameFiles).toHaveBeenCalledWith(files);
+    expect(client.closeDocument).toHaveBeenCalledWith(files[0].oldUri);
+  });
+
+  it("should only move the file when the server has no file operations", async () => {
+    const client = createClient(false);
+
+    const result = await renameFileTool.execute({ ...args, root }, {
+      lspClient: client,
+      config: { diagnosticsDelta: false },
+    } as any);
+
+    expect(result).toContain("does not support workspace/willRenameFiles");
+    expect(client.willRenameFiles).not.toHaveBeenCalled();
+    expect(existsSync(join(root, "lib/util.ts"))).toBe(true);
+    expect(await readFile(join(root, "main.ts"), "utf-8")).toContain(
+      '"./util"',
+    );
+  });
+
+  it("should tell the server about a rename confirmed after its preview", async () => {
+    const client = createClient(true);
+    const context = {
+      lspClient: client,
+      config: { diagnosticsDelta: false },
+    } as any;
+
+    const preview = await renameFileTool.execute(
+      { ...args, root, preview: true },
+      context,
+    );
+    expect(existsSync(join(root, "util.ts"))).toBe(true);
+    expect(client.didRenameFiles).not.toHaveBeenCalled();
+
+    const token = /Edit token: (\S+)/.exec(preview)![1];
+    await confirmEditTool.execute({ token }, context);
+
+    const oldUri = pathToFileURL(join(root, "util.ts")).toString();
+    expect(existsSync(join(root, "lib/util.ts"))).toBe(true);
+    expect(client.closeDocument).toHaveBeenCalledWith(oldUri);
+    expect(client.didRenameFiles).toHaveBeenCalledWith([
+      { oldUri, newUri: pathToFileURL(join(root, "lib/util.ts")).toString() },
+    ]);
+  });
+
+  it("should refuse a confirmed rename once the target exists", async () => {
+    const client = createClient(true);
+    const context = {
+      lspClient: client,
+      config: { diagnosticsDelta: false },
+    } as any;
+    const preview = await renameFileTool.execute(
+      { ...args, root, preview: true },
+      context,
+    );
+    const token = /Edit token: (\S+)/.exec(preview)![1];
+
+    await mkdir(join(root, "lib"));
+    await writeFile(join(root, "lib/util.ts"), "export const two = 2;\n");
+
+    await expect(confirmEditTool.execute({ token }, context)).rejects.toThrow(
+      "lib/util.ts already exists",
+    );
+    expect(existsSync(join(root, "util.ts"))).toBe(true);
+    expect(client.didRenameFiles).not.toHaveBeenCalled();
+  });
+
+  it("should not write anything on a dry run", async () => {
+    const result = await renameFileTool.execute({
+      ...args,
+      root,
+      dryRun: true,
+    });
+
+    expect(result).toContain("rename util.ts -> lib/util.ts");
+    expect(result).toContain("No language server is running");
+    expect(existsSync(join(root, "util.ts"))).toBe(true);
+  });
+
+  it("should refuse to overwrite an existing file", async () => {
+    await expect(
+      renameFileTool.execute({ ...args, root, newPath: "main.ts" }),
+    ).rejects.toThrow("main.ts already exists");
+  });
+
+  it("should refuse to move a file outside the workspace", async () => {
+    await expect(
+      renameFileTool.execute({ ...args, root, newPath: "../x" }, {
+        roots: [root],
+      } as any),
+    ).rejects.toThrow(/outside the workspace/);
+    expect(existsSync(join(root, "util.ts"))).toBe(true);
+    expect(renameFileTool.pathArgs).toContain("newPath");
+  });
+});
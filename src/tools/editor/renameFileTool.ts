@@ -0,0 +1,152 @@
+/**
+ * File rename with import rewriting
+ *
+ * The language server is asked for the edits a rename needs through
+ * workspace/willRenameFiles (TypeScript and Java servers return import path
+ * updates), those edits and the rename itself are written as one
+ * WorkspaceEdit, and the server is told with workspace/didRenameFiles, also
+ * when the rename is previewed and confirmed later.
+ * Without a server supporting file operations the file is only moved.
+ */
+
+import { z } from "zod";
+import { existsSync } from "node:fs";
+import { stat } from "node:fs/promises";
+import { resolve } from "node:path";
+import { pathToFileURL } from "node:url";
+import type { LSPClient } from "@internal/lsp-client";
+import type { McpToolDef, WorkspaceEdit } from "@internal/types";
+import {
+  confirmWorkspaceEdit,
+  formatWorkspaceEditDiff,
+  planWorkspaceEdit,
+  writeWorkspaceEditPlan,
+} from "./workspaceEditTools.ts";
+import { previewWorkspaceEdit } from "./pendingEdits.ts";
+import {
+  assertRenameTargetsAbsent,
+  notifyFileRenames,
+  type RenamedFile,
+} from "./fileRenames.ts";
+import {
+  assertPathsInSandbox,
+  sandboxOptions,
+} from "../../utils/pathSandbox.ts";
+import {
+  deltaFilesOfPlan,
+  trackDiagnostics,
+} from "../lsp/diagnosticsDelta.ts";
+
+const renameFileSchema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z.string().describe("File to rename (relative to root)"),
+  newPath: z
+    .string()
+    .describe("New path of the file (relative to root); must not exist"),
+  dryRun: z
+    .boolean()
+    .default(false)
+    .describe("Show the rename and import updates as diffs without writing"),
+  preview: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Return the diffs and an edit token without renaming; apply it later with confirm_edit",
+    ),
+});
+
+export const renameFileTool: McpToolDef<typeof renameFileSchema> = {
+  name: "rename_file",
+  description:
+    "Rename or move a file and update the imports that refer to it, using the language server's workspace/willRenameFiles edits. " +
+    "Prefer this over mv in TypeScript and Java projects. The rename and import updates are written all or nothing.",
+  schema: renameFileSchema,
+  pathArgs: ["newPath"],
+  execute: async (
+    { root, relativePath, newPath, dryRun, preview },
+    context,
+  ) => {
+    const oldPath = resolve(root, relativePath);
+    const targetPath = resolve(root, newPath);
+    assertPathsInSandbox([targetPath], sandboxOptions(context, root));
+    if (!existsSync(oldPath)) {
+      throw new Error(`${relativePath} does not exist`);
+    }
+    if (!(await stat(oldPath)).isFile()) {
+      throw new Error(`${relativePath} is not a file`);
+    }
+    const files: RenamedFile[] = [
+      {
+        oldUri: pathToFileURL(oldPath).toString(),
+        newUri: pathToFileURL(targetPath).toString(),
+      },
+    ];
+    assertRenameTargetsAbsent(root, files);
+
+    const client = context?.lspClient as LSPClient | undefined;
+    const capabilities = client?.getServerCapabilities();
+    const supported = !!capabilities?.workspace?.fileOperations?.willRename;
+    const serverEdit: WorkspaceEdit | null = supported
+      ? await client!.willRenameFiles(files)
+      : null;
+
+    // Server edits refer to the files before the rename, so they go first
+    const plan = await planWorkspaceEdit({
+      changes: serverEdit?.changes,
+      documentChanges: [
+        ...(serverEdit?.documentChanges ?? []),
+        { kind: "rename", oldUri: files[0].oldUri, newUri: files[0].newUri },
+      ],
+    });
+    if (plan.errors.length > 0) {
+      throw new Error(
+        `Cannot rename ${relativePath}:\n${plan.errors
+          .map((e) => `  ${e}`)
+          .join("\n")}`,
+      );
+    }
+
+    const title = `rename of ${relativePath} to ${newPath}`;
+    const unsupportedNote = supported
+      ? ""
+      : client
+        ? "\nThe language server does not support workspace/willRenameFiles; imports of the file were not updated."
+        : "\nNo language server is running; imports of the file were not updated.";
+    if (preview) {
+      return (
+        previewWorkspaceEdit(root, plan, title, {
+          renamedFiles: files,
+          sessionId: context?.sessionId,
+        }) +
+        unsupportedNote
+      );
+    }
+    if (dryRun) {
+      const diff = formatWorkspaceEditDiff(root, plan);
+      return `Dry run: ${title} would change ${plan.changes.length} file(s).\n\n${diff}${unsupportedNote}`;
+    }
+
+    await confirmWorkspaceEdit(root, plan, title, context);
+    const delta = await trackDiagnostics(
+      client,
+      deltaFilesOfPlan(plan),
+      context,
+    );
+    const written = await writeWorkspaceEditPlan(root, plan, { context });
+    notifyFileRenames(client, files);
+
+    const updated = written.filter((file) => resolve(root, file) !== targetPath);
+    const lines = [`Renamed ${relativePath} to ${newPath}`];
+    if (updated.length > 0) {
+      lines.push(
+        `Updated imports in ${updated.length} file(s):`,
+        ...updated.map((file) => `  ${file}`),
+      );
+    }
+    const diagnostics = await delta(root);
+    if (diagnostics) {
+      lines.push("", diagnostics);
+    }
+    return lines.join("\n") + unsupportedNote;
+  },
+};
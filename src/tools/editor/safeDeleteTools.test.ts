@@ -0,0 +1,193 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import { existsSync } from "node:fs";
+import { mkdtemp, readFile, rm, writeFile } from "node:fs/promises";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { pathToFileURL } from "node:url";
+import type { Location, Range } from "@internal/types";
+import { deleteFileTool, deleteSymbolTool } from "./safeDeleteTools.ts";
+
+vi.mock("@internal/code-indexer");
+
+function range(
+  startLine: number,
+  startCharacter: number,
+  endLine: number,
+  endCharacter: number,
+): Range {
+  return {
+    start: { line: startLine, character: startCharacter },
+    end: { line: endLine, character: endCharacter },
+  };
+}
+
+const utilSource = [
+  "/** Adds one */",
+  "export function inc(n: number): number {",
+  "  return inc0(n) + 1;",
+  "}",
+  "",
+  "function inc0(n: number): number {",
+  "  return n;",
+  "}",
+  "",
+].join("\n");
+
+const symbols = [
+  {
+    name: "inc",
+    kind: 12,
+    range: range(1, 0, 3, 1),
+    selectionRange: range(1, 16, 1, 19),
+  },
+  {
+    name: "inc0",
+    kind: 12,
+    range: range(5, 0, 7, 1),
+    selectionRange: range(5, 9, 5, 13),
+  },
+];
+
+describe("safe delete tools", () => {
+  let root: string;
+  let references: Map<string, Location[]>;
+
+  beforeEach(async () => {
+    root = await mkdtemp(join(tmpdir(), "lsmcp-safe-delete-"));
+    await writeFile(join(root, "util.ts"), utilSource);
+    await writeFile(
+      join(root, "main.ts"),
+      'import { inc } from "./util";\nconsole.log(inc(1));\n',
+    );
+    const utilUri = pathToFileURL(join(root, "util.ts")).toString();
+    const mainUri = pathToFileURL(join(root, "main.ts")).toString();
+    references = new Map([
+      // inc is used from main.ts
+      [
+        "1:16",
+        [
+          { uri: mainUri, range: range(0, 9, 0, 12) },
+          { uri: mainUri, range: range(1, 12, 1, 15) },
+        ],
+      ],
+      // inc0 is only used inside inc
+      ["5:9", [{ uri: utilUri, range: range(2, 9, 2, 13) }]],
+    ]);
+  });
+
+  afterEach(async () => {
+    await rm(root, { recursive: true, force: true });
+  });
+
+  function createContext() {
+    const client = {
+      openDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      isDocumentOpen: vi.fn(() => false),
+      getDocumentSymbols: vi.fn(async (uri: string) =>
+        uri.endsWith("util.ts") ? symbols : [],
+      ),
+      findReferences: vi.fn(
+        async (_uri: string, position: { line: number; character: number }) =>
+          references.get(`${position.line}:${position.character}`) ?? [],
+      ),
+    };
+    return { lspClient: client, config: { diagnosticsDelta: false } } as any;
+  }
+
+  it("should refuse to delete a symbol that is still referenced", async () => {
+    await expect(
+      deleteSymbolTool.execute(
+        {
+          root,
+          relativePath: "util.ts",
+          namePath: "inc0",
+          force: false,
+          preview: false,
+        },
+        createContext(),
+      ),
+    ).rejects.toThrow(
+      /Refusing to delete "inc0": 1 reference\(s\) remain outside it:\n {2}util\.ts:3:10 {2}return inc0\(n\) \+ 1;/,
+    );
+    expect(await readFile(join(root, "util.ts"), "utf-8")).toBe(utilSource);
+  });
+
+  it("should delete a symbol with its doc comment when nothing references it", async () => {
+    references.set("1:16", []);
+
+    const result = await deleteSymbolTool.execute(
+      {
+        root,
+        relativePath: "util.ts",
+        namePath: "inc",
+        force: false,
+        preview: false,
+      },
+      createContext(),
+    );
+
+    expect(result).toBe('Deleted "inc" (lines 1-4 of util.ts)');
+    expect(await readFile(join(root, "util.ts"), "utf-8")).toBe(
+      "function inc0(n: number): number {\n  return n;\n}\n",
+    );
+  });
+
+  it("should warn about references when forced", async () => {
+    const result = await deleteSymbolTool.execute(
+      {
+        root,
+        relativePath: "util.ts",
+        namePath: "inc0",
+        force: true,
+        preview: false,
+      },
+      createContext(),
+    );
+
+    expect(result).toContain('Deleted "inc0" (lines 6-8 of util.ts)');
+    expect(result).toContain(
+      'Warning: 1 reference(s) to "inc0" no longer resolve:',
+    );
+  });
+
+  it("should refuse to delete a file whose symbols are used elsewhere", async () => {
+    await expect(
+      deleteFileTool.execute(
+        { root, relativePath: "util.ts", force: false, preview: false },
+        createContext(),
+      ),
+    ).rejects.toThrow(/2 reference\(s\) remain outside it:\n {2}main\.ts:1:10/);
+    expect(existsSync(join(root, "util.ts"))).toBe(true);
+  });
+
+  it("should delete a file nothing refers to", async () => {
+    const result = await deleteFileTool.execute(
+      { root, relativePath: "main.ts", force: false, preview: false },
+      createContext(),
+    );
+
+    expect(result).toBe("Deleted main.ts");
+    expect(existsSync(join(root, "main.ts"))).toBe(false);
+  });
+
+  it("should require force to delete a file without a language server", async () => {
+    await expect(
+      deleteFileTool.execute({
+        root,
+        relativePath: "util.ts",
+        force: false,
+        preview: false,
+      }),
+    ).rejects.toThrow(/Pass force: true/);
+
+    const result = await deleteFileTool.execute({
+      root,
+      relativePath: "util.ts",
+      force: true,
+      preview: false,
+    });
+    expect(result).toContain("without checking references");
+    expect(existsSync(join(root, "util.ts"))).toBe(false);
+  });
+});
@@ -0,0 +1,346 @@
+/**
+ * Deletion with a reference check
+ *
+ * Before a symbol or a file is deleted, the language server is asked for the
+ * references to it (to each top-level symbol of a file). References outside
+ * the deleted code are live: the deletion is refused with their list unless
+ * forced, in which case the list is returned as a warning.
+ */
+
+import { z } from "zod";
+import { readFile } from "node:fs/promises";
+import { resolve } from "node:path";
+import { pathToFileURL } from "node:url";
+import type { LSPClient } from "@internal/lsp-client";
+import { withTemporaryDocument } from "@internal/lsp-client";
+import type {
+  Location,
+  McpContext,
+  McpToolDef,
+  WorkspaceEdit,
+} from "@internal/types";
+import {
+  cutSymbol,
+  findSymbolByNamePath,
+  flattenSymbols,
+} from "./symbolEditTools.ts";
+import {
+  confirmWorkspaceEdit,
+  planWorkspaceEdit,
+  replaceContent,
+  toRelative,
+  uriToPath,
+  writeWorkspaceEditPlan,
+  type WorkspaceEditPlan,
+} from "./workspaceEditTools.ts";
+import { previewWorkspaceEdit } from "./pendingEdits.ts";
+import {
+  deltaFilesOfPlan,
+  trackDiagnostics,
+} from "../lsp/diagnosticsDelta.ts";
+
+/** Live references listed in refusals and warnings */
+const MAX_LISTED_REFERENCES = 20;
+
+function isInLines(location: Location, first: number, last: number): boolean {
+  const { start, end } = location.range;
+  return start.line >= first && end.line <= last;
+}
+
+/**
+ * One line per reference: `path:line:column  code`
+ */
+async function formatReferences(
+  root: string,
+  references: Location[],
+): Promise<string[]> {
+  const contents = new Map<string, string[]>();
+  const lines: string[] = [];
+  for (const reference of references.slice(0, MAX_LISTED_REFERENCES)) {
+    const filePath = uriToPath(reference.uri);
+    if (!contents.has(filePath)) {
+      const content = await readFile(filePath, "utf-8").catch(() => "");
+      contents.set(filePath, content.split("\n"));
+    }
+    const { line, character } = reference.range.start;
+    const code = contents.get(filePath)![line]?.trim() ?? "";
+    lines.push(
+      `  ${toRelative(root, filePath)}:${line + 1}:${character + 1}  ${code}`,
+    );
+  }
+  if (references.length > MAX_LISTED_REFERENCES) {
+    lines.push(`  ... and ${references.length - MAX_LISTED_REFERENCES} more`);
+  }
+  return lines;
+}
+
+function referencingFiles(references: Location[]): string[] {
+  return [...new Set(references.map((reference) => uriToPath(reference.uri)))];
+}
+
+function requireClient(client: LSPClient | undefined): LSPClient {
+  if (!client) {
+    throw new Error(
+      "No language server is running, so references cannot be checked. Pass force: true to delete anyway.",
+    );
+  }
+  return client;
+}
+
+/**
+ * Refuse when live references exist and the deletion is not forced
+ * @returns warning lines for forced deletions
+ */
+async function checkReferences(
+  root: string,
+  what: string,
+  live: Location[],
+  force: boolean,
+): Promise<string[]> {
+  if (live.length === 0) {
+    return [];
+  }
+  const listed = await formatReferences(root, live);
+  if (!force) {
+    throw new Error(
+      [
+        `Refusing to delete ${what}: ${live.length} reference(s) remain outside it:`,
+        ...listed,
+        "Remove or update them first, or pass force: true to delete anyway.",
+      ].join("\n"),
+    );
+  }
+  return [
+    `Warning: ${live.length} reference(s) to ${what} no longer resolve:`,
+    ...listed,
+  ];
+}
+
+function withWarning(output: string, warning: string[]): string {
+  return warning.length > 0 ? `${output}\n\n${warning.join("\n")}` : output;
+}
+
+async function planDeletion(
+  edit: WorkspaceEdit,
+  title: string,
+): Promise<WorkspaceEditPlan> {
+  const plan = await planWorkspaceEdit(edit);
+  if (plan.errors.length > 0) {
+    throw new Error(`Cannot plan ${title}: ${plan.errors.join("; ")}`);
+  }
+  return plan;
+}
+
+/**
+ * Write the deletion and describe the diagnostics it changed, in the deleted
+ * file and the files still referring to it
+ */
+async function applyDeletion(
+  root: string,
+  plan: WorkspaceEditPlan,
+  title: string,
+  client: LSPClient | undefined,
+  referencing: string[],
+  context: McpContext | undefined,
+): Promise<string> {
+  await confirmWorkspaceEdit(root, plan, title, context, "delete");
+  const delta = await trackDiagnostics(
+    client,
+    [
+      ...deltaFilesOfPlan(plan),
+      ...referencing.map((filePath) => ({ filePath })),
+    ],
+    context,
+  );
+  await writeWorkspaceEditPlan(root, plan, { context });
+  return await delta(root);
+}
+
+const deleteSymbolSchema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z
+    .string()
+    .describe("File containing the symbol (relative to root)"),
+  namePath: z
+    .string()
+    .describe(
+      'Symbol name path, e.g. "Server/start" for a method or "parseArgs" for a function',
+    ),
+  force: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Delete even when references remain; they are listed as a warning",
+    ),
+  preview: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Return the diff and an edit token without deleting; apply it later with confirm_edit",
+    ),
+});
+
+const deleteFileSchema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z.string().describe("File to delete (relative to root)"),
+  force: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Delete even when its symbols are still referenced (or without a language server); references are listed as a warning",
+    ),
+  preview: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Return the deletion and an edit token without deleting; apply it later with confirm_edit",
+    ),
+});
+
+export const deleteSymbolTool: McpToolDef<typeof deleteSymbolSchema> = {
+  name: "delete_symbol",
+  description:
+    "Delete the whole definition of a symbol found by its name path, doc comment included, after checking its references. " +
+    "Refused with the list of references when the symbol is still used outside its own definition, unless force is set.",
+  schema: deleteSymbolSchema,
+  execute: async (
+    { root, relativePath, namePath, force, preview },
+    context,
+  ) => {
+    const client = requireClient(context?.lspClient as LSPClient | undefined);
+    const absolutePath = resolve(root, relativePath);
+    const content = await readFile(absolutePath, "utf-8");
+    const fileUri = pathToFileURL(absolutePath).toString();
+
+    const { range, references } = await withTemporaryDocument(
+      client,
+      fileUri,
+      content,
+      async () => {
+        const symbols = (await client.getDocumentSymbols(fileUri)) ?? [];
+        const symbol = findSymbolByNamePath(symbols, namePath);
+        const references = await client.findReferences(
+          fileUri,
+          symbol.selectionRange.start,
+          { includeDeclaration: false },
+        );
+        return { range: symbol.range, references };
+      },
+    );
+
+    const cut = cutSymbol(content, range);
+    const live = references.filter(
+      (reference) =>
+        uriToPath(reference.uri) !== absolutePath ||
+        !isInLines(reference, cut.first, cut.last),
+    );
+    const what = `"${namePath}"`;
+    const warning = await checkReferences(root, what, live, force);
+
+    const remaining = cut.lines.join(cut.eol);
+    const title = `deletion of ${what}`;
+    const plan = await planDeletion(
+      { changes: { [fileUri]: [replaceContent(content, remaining)] } },
+      title,
+    );
+    if (preview) {
+      return withWarning(
+        previewWorkspaceEdit(root, plan, title, {
+          sessionId: context?.sessionId,
+        }),
+        warning,
+      );
+    }
+
+    const diagnostics = await applyDeletion(
+      root,
+      plan,
+      title,
+      client,
+      referencingFiles(live),
+      context,
+    );
+    const lines = [
+      `Deleted ${what} (lines ${cut.first + 1}-${cut.last + 1} of ${relativePath})`,
+    ];
+    if (warning.length > 0) {
+      lines.push("", ...warning);
+    }
+    if (diagnostics) {
+      lines.push("", diagnostics);
+    }
+    return lines.join("\n");
+  },
+};
+
+export const deleteFileTool: McpToolDef<typeof deleteFileSchema> = {
+  name: "delete_file",
+  description:
+    "Delete a file after checking that none of its top-level symbols is referenced from other files. " +
+    "Refused with the list of references otherwise, unless force is set.",
+  schema: deleteFileSchema,
+  execute: async ({ root, relativePath, force, preview }, context) => {
+    const absolutePath = resolve(root, relativePath);
+    const content = await readFile(absolutePath, "utf-8");
+    const fileUri = pathToFileURL(absolutePath).toString();
+    const client = context?.lspClient as LSPClient | undefined;
+
+    let live: Location[] = [];
+    if (client || !force) {
+      const lsp = requireClient(client);
+      live = await withTemporaryDocument(lsp, fileUri, content, async () => {
+        const symbols = (await lsp.getDocumentSymbols(fileUri)) ?? [];
+        const topLevel = flattenSymbols(symbols).filter((s) => s.topLevel);
+        const references = await Promise.all(
+          topLevel.map((symbol) =>
+            lsp.findReferences(fileUri, symbol.selectionRange.start, {
+              includeDeclaration: false,
+            }),
+          ),
+        );
+        return references
+          .flat()
+          .filter((reference) => uriToPath(reference.uri) !== absolutePath);
+      });
+    }
+    const warning = await checkReferences(root, relativePath, live, force);
+
+    const title = `deletion of ${relativePath}`;
+    const plan = await planDeletion(
+      { documentChanges: [{ kind: "delete", uri: fileUri }] },
+      title,
+    );
+    if (preview) {
+      return withWarning(
+        previewWorkspaceEdit(root, plan, title, {
+          sessionId: context?.sessionId,
+        }),
+        warning,
+      );
+    }
+
+    const diagnostics = await applyDeletion(
+      root,
+      plan,
+      title,
+      client,
+      referencingFiles(live),
+      context,
+    );
+    if (client?.isDocumentOpen(fileUri)) {
+      client.closeDocument(fileUri);
+    }
+    const lines = [
+      client
+        ? `Deleted ${relativePath}`
+        : `Deleted ${relativePath} without checking references (no language server is running)`,
+    ];
+    if (warning.length > 0) {
+      lines.push("", ...warning);
+    }
+    if (diagnostics) {
+      lines.push("", diagnostics);
+    }
+    return lines.join("\n");
+  },
+};
@@ -0,0 +1,234 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import { mkdtemp, readFile, rm, writeFile } from "node:fs/promises";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import type { DocumentSymbol, Range } from "@internal/types";
+import {
+  findSymbolByNamePath,
+  insertAfterSymbol,
+  insertBeforeSymbol,
+  replaceSymbolBody,
+  replaceSymbolBodyTool,
+} from "./symbolEditTools.ts";
+import { confirmEditTool } from "./pendingEdits.ts";
+
+vi.mock("@internal/code-indexer");
+
+function range(
+  startLine: number,
+  startCharacter: number,
+  endLine: number,
+  endCharacter: number,
+): Range {
+  return {
+    start: { line: startLine, character: startCharacter },
+    end: { line: endLine, character: endCharacter },
+  };
+}
+
+function symbol(
+  name: string,
+  symbolRange: Range,
+  children?: DocumentSymbol[],
+): DocumentSymbol {
+  return {
+    name,
+    kind: children ? 5 : 6,
+    range: symbolRange,
+    selectionRange: symbolRange,
+    children,
+  };
+}
+
+const serverSource = [
+  "class Server {",
+  "  start() {",
+  "    return 1;",
+  "  }",
+  "}",
+  "",
+].join("\n");
+
+const serverSymbols = [
+  symbol("Server", range(0, 0, 4, 1), [symbol("start", range(1, 2, 3, 3))]),
+];
+
+describe("findSymbolByNamePath", () => {
+  it("should match name paths by their end unless they start with /", () => {
+    expect(findSymbolByNamePath(serverSymbols, "Server/start").path).toEqual([
+      "Server",
+      "start",
+    ]);
+    expect(findSymbolByNamePath(serverSymbols, "start").range).toEqual(
+      range(1, 2, 3, 3),
+    );
+    expect(() => findSymbolByNamePath(serverSymbols, "/start")).toThrow(
+      /not found\. Top-level symbols: Server/,
+    );
+  });
+
+  it("should refuse ambiguous name paths", () => {
+    const symbols = [
+      ...serverSymbols,
+      symbol("Worker", range(6, 0, 8, 1), [
+        symbol("start", range(7, 2, 7, 12)),
+      ]),
+    ];
+    expect(() => findSymbolByNamePath(symbols, "start")).toThrow(
+      'Symbol "start" is ambiguous: Server/start (line 2), Worker/start (line 8)',
+    );
+    expect(findSymbolByNamePath(symbols, "Worker/start").range.start.line).toBe(
+      7,
+    );
+  });
+
+  it("should split Go receiver methods into type and method", () => {
+    const symbols = [
+      symbol("Server", range(0, 0, 2, 1), []),
+      symbol("(*Server).Start", range(4, 0, 6, 1)),
+    ];
+    expect(findSymbolByNamePath(symbols, "Server/Start").range.start.line).toBe(
+      4,
+    );
+  });
+});
+
+describe("replaceSymbolBody", () => {
+  it("should re-indent the new definition", () => {
+    const result = replaceSymbolBody(
+      serverSource,
+      range(1, 2, 3, 3),
+      "start() {\n  return 2;\n}\n",
+    );
+    expect(result).toBe(
+      "class Server {\n  start() {\n    return 2;\n  }\n}\n",
+    );
+  });
+
+  it("should keep code before the symbol unless repeated", () => {
+    const source = "export const f = () => 1;\n";
+    const symbolRange = range(0, 13, 0, 24);
+
+    expect(replaceSymbolBody(source, symbolRange, "f = () => 2")).toBe(
+      "export const f = () => 2;\n",
+    );
+    expect(
+      replaceSymbolBody(source, symbolRange, "export const f = () => 2"),
+    ).toBe("export const f = () => 2;\n");
+  });
+
+  it("should keep CRLF line endings", () => {
+    const source = "function f() {\r\n  return 1;\r\n}\r\n";
+    expect(
+      replaceSymbolBody(source, range(0, 0, 2, 1), "function f() {\n}"),
+    ).toBe("function f() {\r\n}\r\n");
+  });
+});
+
+describe("insertBeforeSymbol", () => {
+  it("should insert above the doc comment at the symbol's indentation", () => {
+    const source = "class A {\n  /** Runs */\n  run() {}\n}\n";
+    expect(
+      insertBeforeSymbol(source, range(2, 2, 2, 10), "stop() {}\n\n"),
+    ).toBe("class A {\n  stop() {}\n\n  /** Runs */\n  run() {}\n}\n");
+  });
+});
+
+describe("insertAfterSymbol", () => {
+  it("should insert below the symbol's last line", () => {
+    expect(
+      insertAfterSymbol(serverSource, range(1, 2, 4, 0), "\nstop() {}"),
+    ).toBe(
+      "class Server {\n  start() {\n    return 1;\n  }\n\n  stop() {}\n}\n",
+    );
+  });
+});
+
+describe("replaceSymbolBodyTool", () => {
+  let root: string;
+
+  beforeEach(async () => {
+    root = await mkdtemp(join(tmpdir(), "lsmcp-symbol-edit-"));
+    await writeFile(join(root, "server.ts"), serverSource);
+  });
+
+  afterEach(async () => {
+    await rm(root, { recursive: true, force: true });
+  });
+
+  it("should edit the symbol found through document symbols", async () => {
+    const client = {
+      openDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      getDocumentSymbols: vi.fn(async () => serverSymbols),
+    };
+
+    const result = JSON.parse(
+      await replaceSymbolBodyTool.execute(
+        {
+          root,
+          relativePath: "server.ts",
+          namePath: "Server/start",
+          body: "start() {\n  return 2;\n}",
+        },
+        { lspClient: client, config: { diagnosticsDelta: false } } as any,
+      ),
+    );
+
+    expect(result).toEqual({ success: true, filesChanged: ["server.ts"] });
+    expect(await readFile(join(root, "server.ts"), "utf-8")).toBe(
+      "class Server {\n  start() {\n    return 2;\n  }\n}\n",
+    );
+  });
+
+  it("should preview the edit and apply it with confirm_edit", async () => {
+    const client = {
+      openDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      getDocumentSymbols: vi.fn(async () => serverSymbols),
+    };
+    const context = {
+      lspClient: client,
+      config: { diagnosticsDelta: false },
+    } as any;
+
+    const result = JSON.parse(
+      await replaceSymbolBodyTool.execute(
+        {
+          root,
+          relativePath: "server.ts",
+          namePath: "Server/start",
+          body: "start() {\n  return 2;\n}",
+          preview: true,
+        },
+        context,
+      ),
+    );
+
+    expect(result.success).toBe(true);
+    expect(result.preview).toContain("+    return 2;");
+    expect(await readFile(join(root, "server.ts"), "utf-8")).toBe(
+      serverSource,
+    );
+
+    const token = /Edit token: (\S+)/.exec(result.preview)![1];
+    await confirmEditTool.execute({ token }, context);
+    expect(await readFile(join(root, "server.ts"), "utf-8")).toBe(
+      "class Server {\n  start() {\n    return 2;\n  }\n}\n",
+    );
+  });
+
+  it("should fail without a language server", async () => {
+    const result = JSON.parse(
+      await replaceSymbolBodyTool.execute({
+        root,
+        relativePath: "server.ts",
+        namePath: "Server/start",
+        body: "start() {}",
+      }),
+    );
+
+    expect(result.success).toBe(false);
+    expect(result.error).toMatch(/No language server is running/);
+  });
+});
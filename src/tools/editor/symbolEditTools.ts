@@ -0,0 +1,429 @@
+/**
+ * Symbol-anchored editing tools
+ *
+ * The symbol is looked up by its name path ("Class/method") in the document
+ * symbols of the file, so an edit lands on the right code even after line
+ * numbers moved. Inserted and replaced code is re-indented to the symbol's
+ * indentation. Edits go through the workspace edit pipeline: they are refused
+ * when the file changed meanwhile, confirmed and can be previewed.
+ */
+
+import { z } from "zod";
+import { readFile } from "node:fs/promises";
+import { resolve } from "node:path";
+import { pathToFileURL } from "node:url";
+import type { LSPClient } from "@internal/lsp-client";
+import { withTemporaryDocument } from "@internal/lsp-client";
+import type {
+  DocumentSymbol,
+  McpContext,
+  McpToolDef,
+  Range,
+  SymbolInformation,
+} from "@internal/types";
+import type { SerenityEditResult } from "./regexEditTools.ts";
+import {
+  deltaFilesOfPlan,
+  trackDiagnostics,
+} from "../lsp/diagnosticsDelta.ts";
+import { assertNotLsmcpFiles } from "../../utils/pathSandbox.ts";
+import {
+  confirmWorkspaceEdit,
+  findStaleFiles,
+  openDocumentVersions,
+  planWorkspaceEdit,
+  replaceContent,
+  writeWorkspaceEditPlan,
+} from "./workspaceEditTools.ts";
+import { previewWorkspaceEdit } from "./pendingEdits.ts";
+
+export interface LocatedSymbol {
+  /** Names from the outermost container down to the symbol */
+  path: string[];
+  /** Full range of the symbol, body included */
+  range: Range;
+  /** Range of the symbol's name, where references are looked up */
+  selectionRange: Range;
+  /** Whether the symbol is declared at the top level of the file */
+  topLevel: boolean;
+}
+
+/** gopls lists methods next to their type as "(*Server).Start" */
+const RECEIVER_METHOD = /^\(\*?([\w$]+)(?:\[[^\]]*\])?\)\.([\w$]+)$/;
+
+/** Lines above a symbol that belong to it: comments, decorators, attributes */
+const LEADING_LINE = /^\s*(\/\/|\/\*|\*|#(\[|\s|$)|@)/;
+
+function nameSegments(name: string): string[] {
+  const method = RECEIVER_METHOD.exec(name);
+  return method ? [method[1], method[2]] : [name];
+}
+
+export function flattenSymbols(
+  symbols: (DocumentSymbol | SymbolInformation)[],
+  parents: string[] = [],
+  result: LocatedSymbol[] = [],
+): LocatedSymbol[] {
+  for (const symbol of symbols) {
+    if ("location" in symbol && symbol.location) {
+      const container = symbol.containerName
+        ? symbol.containerName.split(".")
+        : [];
+      result.push({
+        path: [...container, ...nameSegments(symbol.name)],
+        range: symbol.location.range,
+        selectionRange: symbol.location.range,
+        topLevel: container.length === 0,
+      });
+      continue;
+    }
+    const documentSymbol = symbol as DocumentSymbol;
+    const path = [...parents, ...nameSegments(documentSymbol.name)];
+    result.push({
+      path,
+      range: documentSymbol.range,
+      selectionRange: documentSymbol.selectionRange ?? documentSymbol.range,
+      topLevel: parents.length === 0,
+    });
+    if (documentSymbol.children) {
+      flattenSymbols(documentSymbol.children, path, result);
+    }
+  }
+  return result;
+}
+
+/**
+ * Find the one symbol matching a name path. "Server/start" matches any
+ * symbol whose path ends with those names; "/Server/start" only from the top.
+ * @throws when no symbol or several symbols match
+ */
+export function findSymbolByNamePath(
+  symbols: (DocumentSymbol | SymbolInformation)[],
+  namePath: string,
+): LocatedSymbol {
+  const wanted = namePath.split("/").filter(Boolean);
+  if (wanted.length === 0) {
+    throw new Error("namePath is empty");
+  }
+  const fromTop = namePath.startsWith("/");
+  const flat = flattenSymbols(symbols);
+  const matches = flat.filter(({ path }) => {
+    const fits = fromTop
+      ? path.length === wanted.length
+      : path.length >= wanted.length;
+    if (!fits) {
+      return false;
+    }
+    const tail = path.slice(path.length - wanted.length);
+    return tail.every((name, i) => name === wanted[i]);
+  });
+
+  if (matches.length === 0) {
+    const topLevel = flat
+      .filter(({ path }) => path.length === 1)
+      .map(({ path }) => path[0]);
+    const available =
+      topLevel.length > 0
+        ? ` Top-level symbols: ${topLevel.slice(0, 20).join(", ")}`
+        : "";
+    throw new Error(`Symbol "${namePath}" not found.${available}`);
+  }
+  if (matches.length > 1) {
+    const candidates = matches
+      .map(
+        ({ path, range }) => `${path.join("/")} (line ${range.start.line + 1})`,
+      )
+      .join(", ");
+    throw new Error(
+      `Symbol "${namePath}" is ambiguous: ${candidates}. Use a longer name path.`,
+    );
+  }
+  return matches[0];
+}
+
+/**
+ * Lines of text moved so the least indented one starts at indent; blank lines
+ * are left empty
+ */
+export function indentBlock(text: string, indent: string): string[] {
+  const lines = text.replace(/\r\n/g, "\n").replace(/\n$/, "").split("\n");
+  const indents = lines
+    .filter((line) => line.trim())
+    .map((line) => /^\s*/.exec(line)![0].length);
+  const common = indents.length > 0 ? Math.min(...indents) : 0;
+  return lines.map((line) =>
+    line.trim() ? indent + line.slice(common) : "",
+  );
+}
+
+export interface SplitContent {
+  lines: string[];
+  eol: string;
+}
+
+export function splitContent(content: string): SplitContent {
+  const eol = content.includes("\r\n") ? "\r\n" : "\n";
+  return { lines: content.split(eol), eol };
+}
+
+/**
+ * Range end moved back onto the symbol's last line when the server ends it
+ * at the start of the next line
+ */
+export function lastLine(range: Range): number {
+  const { line, character } = range.end;
+  return character === 0 && line > range.start.line ? line - 1 : line;
+}
+
+function indentOf(line: string): string {
+  return /^\s*/.exec(line)![0];
+}
+
+/**
+ * First line of the symbol starting at line, its doc comment and decorators
+ * included
+ */
+export function leadingStart(lines: string[], line: number): number {
+  while (line > 0 && LEADING_LINE.test(lines[line - 1])) {
+    line--;
+  }
+  return line;
+}
+
+/**
+ * Replace the symbol's whole definition (signature and body) with body
+ */
+export function replaceSymbolBody(
+  content: string,
+  range: Range,
+  body: string,
+): string {
+  const { lines, eol } = splitContent(content);
+  const first = range.start.line;
+  const last = lastLine(range);
+  const endCharacter =
+    last === range.end.line ? range.end.character : lines[last].length;
+
+  const replacement = indentBlock(body, indentOf(lines[first]));
+  // Code before the symbol on its line (`export const` of a variable) stays
+  // unless the new code repeats it
+  const prefix = lines[first].slice(0, range.start.character);
+  const head = replacement[0].trimStart();
+  if (prefix.trim() && !head.startsWith(prefix.trim())) {
+    replacement[0] = prefix + head;
+  }
+  replacement[replacement.length - 1] += lines[last].slice(endCharacter);
+
+  lines.splice(first, last - first + 1, ...replacement);
+  return lines.join(eol);
+}
+
+/**
+ * Insert body on the lines above the symbol and its doc comment
+ */
+export function insertBeforeSymbol(
+  content: string,
+  range: Range,
+  body: string,
+): string {
+  const { lines, eol } = splitContent(content);
+  lines.splice(
+    leadingStart(lines, range.start.line),
+    0,
+    ...indentBlock(body, indentOf(lines[range.start.line])),
+  );
+  return lines.join(eol);
+}
+
+export interface SymbolCut {
+  /** Lines of the content without the symbol */
+  lines: string[];
+  /** Lines of the symbol, its doc comment and decorators included */
+  block: string[];
+  /** Lines cut (0-based, inclusive) */
+  first: number;
+  last: number;
+  eol: string;
+}
+
+/**
+ * Cut the symbol's lines out of content, with one of the blank lines that
+ * surrounded it
+ * @throws when other code shares the symbol's first or last line
+ */
+export function cutSymbol(content: string, range: Range): SymbolCut {
+  const { lines, eol } = splitContent(content);
+  const first = leadingStart(lines, range.start.line);
+  const last = lastLine(range);
+  // Keywords (`export const`) may come before the symbol, statements not
+  const prefix = lines[range.start.line].slice(0, range.start.character);
+  if (/[,;(){}]/.test(prefix)) {
+    throw new Error(
+      `Line ${range.start.line + 1} has other code before the symbol; split it first`,
+    );
+  }
+  const endCharacter =
+    last === range.end.line ? range.end.character : lines[last].length;
+  const rest = lines[last].slice(endCharacter).trim();
+  if (rest && rest !== ";" && rest !== ",") {
+    throw new Error(
+      `Line ${last + 1} continues after the symbol ("${rest}"); split it first`,
+    );
+  }
+
+  const block = lines.splice(first, last - first + 1);
+  const blank = (i: number) =>
+    i >= 0 && i < lines.length && lines[i].trim() === "";
+  if (blank(first) && (first === 0 || blank(first - 1))) {
+    const atEnd = first === lines.length - 1 && first > 0;
+    lines.splice(atEnd ? first - 1 : first, 1);
+  }
+  return { lines, block, first, last, eol };
+}
+
+/**
+ * Insert body on the lines below the symbol
+ */
+export function insertAfterSymbol(
+  content: string,
+  range: Range,
+  body: string,
+): string {
+  const { lines, eol } = splitContent(content);
+  const last = lastLine(range);
+  lines.splice(
+    last + 1,
+    0,
+    ...indentBlock(body, indentOf(lines[range.start.line])),
+  );
+  return lines.join(eol);
+}
+
+const symbolEditSchema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z
+    .string()
+    .describe("File containing the symbol (relative to root)"),
+  namePath: z
+    .string()
+    .describe(
+      'Symbol name path, e.g. "Server/start" for a method or "parseArgs" for a function; a leading "/" matches from the top level only',
+    ),
+  body: z.string().describe("Code to write"),
+  preview: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Return the diff and an edit token without writing; apply it later with confirm_edit",
+    ),
+});
+
+type SymbolEditArgs = z.infer<typeof symbolEditSchema>;
+
+async function editSymbol(
+  { root, relativePath, namePath, body, preview }: SymbolEditArgs,
+  context: McpContext | undefined,
+  edit: (content: string, range: Range, body: string) => string,
+): Promise<string> {
+  try {
+    const client = context?.lspClient as LSPClient | undefined;
+    if (!client) {
+      throw new Error(
+        "No language server is running; symbol editing needs its document symbols",
+      );
+    }
+    const absolutePath = resolve(root, relativePath);
+    assertNotLsmcpFiles([absolutePath]);
+    const content = await readFile(absolutePath, "utf-8");
+    const fileUri = pathToFileURL(absolutePath).toString();
+    const symbols = await withTemporaryDocument(
+      client,
+      fileUri,
+      content,
+      async () => (await client.getDocumentSymbols(fileUri)) ?? [],
+    );
+    const symbol = findSymbolByNamePath(symbols, namePath);
+
+    // Planned against the content the symbol was found in, so a change made
+    // meanwhile is caught as stale
+    const plan = await planWorkspaceEdit(
+      {
+        changes: {
+          [fileUri]: [
+            replaceContent(content, edit(content, symbol.range, body)),
+          ],
+        },
+      },
+      new Map([[absolutePath, content]]),
+      openDocumentVersions(client),
+    );
+    if (plan.errors.length > 0) {
+      throw new Error(plan.errors.join("; "));
+    }
+    const title = `edit of ${namePath} in ${relativePath}`;
+    if (preview) {
+      return JSON.stringify({
+        success: true,
+        filesChanged: [],
+        preview: previewWorkspaceEdit(root, plan, title, {
+          sessionId: context?.sessionId,
+        }),
+      } as SerenityEditResult);
+    }
+
+    const stale = await findStaleFiles(root, plan);
+    if (stale.length > 0) {
+      throw new Error(
+        `${relativePath} changed while the symbol was looked up; try again`,
+      );
+    }
+    await confirmWorkspaceEdit(root, plan, title, context);
+    const delta = await trackDiagnostics(
+      client,
+      deltaFilesOfPlan(plan),
+      context,
+    );
+    await writeWorkspaceEditPlan(root, plan, { context });
+
+    return JSON.stringify({
+      success: true,
+      filesChanged: [relativePath],
+      diagnostics: (await delta(root)) || undefined,
+    } as SerenityEditResult);
+  } catch (error) {
+    return JSON.stringify({
+      success: false,
+      error: error instanceof Error ? error.message : String(error),
+    } as SerenityEditResult);
+  }
+}
+
+export const replaceSymbolBodyTool: McpToolDef<typeof symbolEditSchema> = {
+  name: "replace_symbol_body",
+  description:
+    "Replace the whole definition of a symbol (signature and body) found by its name path in the file's document symbols. " +
+    "The doc comment above it is kept; the new code is re-indented to the symbol's indentation.",
+  schema: symbolEditSchema,
+  execute: async (args, context) =>
+    editSymbol(args, context, replaceSymbolBody),
+};
+
+export const insertBeforeSymbolTool: McpToolDef<typeof symbolEditSchema> = {
+  name: "insert_before_symbol",
+  description:
+    "Insert code on the lines above a symbol found by its name path, above its doc comment and decorators, at the symbol's indentation. " +
+    "End body with an empty line to separate it from the symbol.",
+  schema: symbolEditSchema,
+  execute: async (args, context) =>
+    editSymbol(args, context, insertBeforeSymbol),
+};
+
+export const insertAfterSymbolTool: McpToolDef<typeof symbolEditSchema> = {
+  name: "insert_after_symbol",
+  description:
+    "Insert code on the lines below a symbol found by its name path, at the symbol's indentation. " +
+    "Start body with an empty line to separate it from the symbol.",
+  schema: symbolEditSchema,
+  execute: async (args, context) =>
+    editSymbol(args, context, insertAfterSymbol),
+};
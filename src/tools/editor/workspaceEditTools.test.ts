@@ -0,0 +1,376 @@
+import { describe, it, expect, beforeEach, vi } from "vitest";
+import { mkdtempSync, readFileSync, writeFileSync, existsSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { pathToFileURL } from "node:url";
+import type { McpContext } from "@internal/types";
+import {
+  applyWorkspaceEditTool,
+  planWorkspaceEdit,
+  revertChange,
+  writeWorkspaceEditPlan,
+} from "./workspaceEditTools.ts";
+
+vi.mock("@internal/code-indexer");
+
+describe("applyWorkspaceEditTool", () => {
+  let root: string;
+  let uri: string;
+
+  const renameEdit = () => ({
+    changes: {
+      [uri]: [
+        {
+          range: {
+            start: { line: 0, character: 6 },
+            end: { line: 0, character: 7 },
+          },
+          newText: "count",
+        },
+        {
+          range: {
+            start: { line: 1, character: 12 },
+            end: { line: 1, character: 13 },
+          },
+          newText: "count",
+        },
+      ],
+    },
+  });
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    root = mkdtempSync(join(tmpdir(), "lsmcp-workspace-edit-"));
+    writeFileSync(join(root, "a.ts"), "const x = 1;\nconsole.log(x);\n");
+    uri = pathToFileURL(join(root, "a.ts")).toString();
+  });
+
+  it("should return a unified diff without writing on dry run", async () => {
+    const result = await applyWorkspaceEditTool.execute({
+      root,
+      edit: renameEdit(),
+      dryRun: true,
+    });
+
+    expect(result).toContain("--- a/a.ts");
+    expect(result).toContain("-const x = 1;");
+    expect(result).toContain("+const count = 1;");
+    expect(result).toContain("+console.log(count);");
+    expect(result).toContain("expectedHashes:");
+    expect(readFileSync(join(root, "a.ts"), "utf-8")).toBe(
+      "const x = 1;\nconsole.log(x);\n",
+    );
+  });
+
+  it("should apply edits to disk", async () => {
+    const result = await applyWorkspaceEditTool.execute({
+      root,
+      edit: JSON.stringify(renameEdit()),
+      dryRun: false,
+    });
+
+    expect(result).toContain("Applied workspace edit to 1 file(s)");
+    expect(readFileSync(join(root, "a.ts"), "utf-8")).toBe(
+      "const count = 1;\nconsole.log(count);\n",
+    );
+  });
+
+  it("should refuse to apply when a file changed after the dry run", async () => {
+    const preview = await applyWorkspaceEditTool.execute({
+      root,
+      edit: renameEdit(),
+      dryRun: true,
+    });
+    const expectedHashes = JSON.parse(
+      preview.slice(preview.indexOf("expectedHashes: ") + 16),
+    );
+
+    writeFileSync(join(root, "a.ts"), "const x = 2;\nconsole.log(x);\n");
+
+    await expect(
+      applyWorkspaceEditTool.execute({
+        root,
+        edit: renameEdit(),
+        dryRun: false,
+        expectedHashes,
+      }),
+    ).rejects.toThrow(/changed since it was computed: a\.ts/);
+    expect(readFileSync(join(root, "a.ts"), "utf-8")).toBe(
+      "const x = 2;\nconsole.log(x);\n",
+    );
+  });
+
+  it("should refuse edits whose ranges no longer fit the file", async () => {
+    writeFileSync(join(root, "a.ts"), "x\n");
+
+    await expect(
+      applyWorkspaceEditTool.execute({
+        root,
+        edit: renameEdit(),
+        dryRun: false,
+      }),
+    ).rejects.toThrow(/Refusing to apply workspace edit/);
+  });
+
+  it("should support create and rename operations", async () => {
+    const newUri = pathToFileURL(join(root, "lib/b.ts")).toString();
+    const createdUri = pathToFileURL(join(root, "c.ts")).toString();
+
+    await applyWorkspaceEditTool.execute({
+      root,
+      edit: {
+        documentChanges: [
+          { kind: "rename", oldUri: uri, newUri },
+          { kind: "create", uri: createdUri },
+          {
+            textDocument: { uri: createdUri, version: null },
+            edits: [
+              {
+                range: {
+                  start: { line: 0, character: 0 },
+                  end: { line: 0, character: 0 },
+                },
+                newText: "export {};\n",
+              },
+            ],
+          },
+        ],
+      },
+      dryRun: false,
+    });
+
+    expect(existsSync(join(root, "a.ts"))).toBe(false);
+    expect(readFileSync(join(root, "lib/b.ts"), "utf-8")).toBe(
+      "const x = 1;\nconsole.log(x);\n",
+    );
+    expect(readFileSync(join(root, "c.ts"), "utf-8")).toBe("export {};\n");
+  });
+
+  it("should not rename over an existing file unless told to", async () => {
+    const target = join(root, "b.ts");
+    writeFileSync(target, "export const b = 2;\n");
+    const newUri = pathToFileURL(target).toString();
+    const renameTo = (options?: Record<string, boolean>) =>
+      applyWorkspaceEditTool.execute({
+        root,
+        edit: {
+          documentChanges: [{ kind: "rename", oldUri: uri, newUri, options }],
+        },
+        dryRun: false,
+      });
+
+    await expect(renameTo()).rejects.toThrow(/rename target already exists/);
+    expect(await renameTo({ ignoreIfExists: true })).toBe(
+      "Workspace edit contains no changes.",
+    );
+    expect(readFileSync(target, "utf-8")).toBe("export const b = 2;\n");
+    expect(existsSync(join(root, "a.ts"))).toBe(true);
+
+    await renameTo({ overwrite: true, ignoreIfExists: true });
+    expect(readFileSync(target, "utf-8")).toBe(
+      "const x = 1;\nconsole.log(x);\n",
+    );
+    expect(existsSync(join(root, "a.ts"))).toBe(false);
+  });
+
+  it("should restore the file a rename overwrote", async () => {
+    const target = join(root, "b.ts");
+    writeFileSync(target, "export const b = 2;\n");
+    const plan = await planWorkspaceEdit({
+      documentChanges: [
+        {
+          kind: "rename",
+          oldUri: uri,
+          newUri: pathToFileURL(target).toString(),
+          options: { overwrite: true },
+        },
+      ],
+    });
+    expect(plan.changes[0].replaced).toBe("export const b = 2;\n");
+
+    await writeWorkspaceEditPlan(root, plan);
+    await revertChange(plan.changes[0]);
+
+    expect(readFileSync(join(root, "a.ts"), "utf-8")).toBe(
+      "const x = 1;\nconsole.log(x);\n",
+    );
+    expect(readFileSync(target, "utf-8")).toBe("export const b = 2;\n");
+  });
+
+  it("should apply only documentChanges when both forms are given", async () => {
+    const [first] = renameEdit().changes[uri];
+
+    await applyWorkspaceEditTool.execute({
+      root,
+      edit: {
+        ...renameEdit(),
+        documentChanges: [
+          { textDocument: { uri, version: null }, edits: [first] },
+        ],
+      },
+      dryRun: false,
+    });
+
+    expect(readFileSync(join(root, "a.ts"), "utf-8")).toBe(
+      "const count = 1;\nconsole.log(x);\n",
+    );
+  });
+
+  it("should refuse edits for another version of an open document", async () => {
+    const context = {
+      lspClient: {
+        getOpenDocuments: () => [
+          { uri, text: "", languageId: "typescript", version: 3 },
+        ],
+      },
+      config: { diagnosticsDelta: false },
+    } as unknown as McpContext;
+    const edit = (version: number) => ({
+      documentChanges: [
+        { textDocument: { uri, version }, edits: renameEdit().changes[uri] },
+      ],
+    });
+
+    await expect(
+      applyWorkspaceEditTool.execute(
+        { root, edit: edit(2), dryRun: false },
+        context,
+      ),
+    ).rejects.toThrow(
+      /edit is for version 2, the open document is at version 3/,
+    );
+    expect(readFileSync(join(root, "a.ts"), "utf-8")).toBe(
+      "const x = 1;\nconsole.log(x);\n",
+    );
+
+    await applyWorkspaceEditTool.execute(
+      { root, edit: edit(3), dryRun: false },
+      context,
+    );
+    expect(readFileSync(join(root, "a.ts"), "utf-8")).toBe(
+      "const count = 1;\nconsole.log(count);\n",
+    );
+  });
+
+  it("should refuse to write lsmcp's config", async () => {
+    const configUri = pathToFileURL(
+      join(root, ".lsmcp/config.json"),
+    ).toString();
+
+    await expect(
+      applyWorkspaceEditTool.execute({
+        root,
+        edit: {
+          documentChanges: [
+            { kind: "create", uri: configUri },
+            {
+              textDocument: { uri: configUri, version: null },
+              edits: [
+                {
+                  range: {
+                    start: { line: 0, character: 0 },
+                    end: { line: 0, character: 0 },
+                  },
+                  newText: '{ "sandbox": { "enabled": false } }',
+                },
+              ],
+            },
+          ],
+        },
+        dryRun: false,
+      }),
+    ).rejects.toThrow(/inside \.lsmcp/);
+    expect(existsSync(join(root, ".lsmcp/config.json"))).toBe(false);
+  });
+
+  it("should restore written files when a later write fails", async () => {
+    // A regular file where a directory is needed makes the create fail
+    writeFileSync(join(root, "blocker"), "");
+    const blockedUri = pathToFileURL(join(root, "blocker/b.ts")).toString();
+
+    await expect(
+      applyWorkspaceEditTool.execute({
+        root,
+        edit: {
+          documentChanges: [
+            {
+              textDocument: { uri, version: null },
+              edits: renameEdit().changes[uri],
+            },
+            { kind: "create", uri: blockedUri },
+          ],
+        },
+        dryRun: false,
+      }),
+    ).rejects.toThrow(/Failed to write workspace edit .*no files were changed/);
+    expect(readFileSync(join(root, "a.ts"), "utf-8")).toBe(
+      "const x = 1;\nconsole.log(x);\n",
+    );
+  });
+
+  it("should reject edits of files outside the workspace roots", async () => {
+    const outside = mkdtempSync(join(tmpdir(), "lsmcp-outside-"));
+    const target = join(outside, "victim.ts");
+    writeFileSync(target, "const x = 1;\n");
+    const context = { roots: [root] } as McpContext;
+    const edit = (key: string) => ({
+      changes: { [key]: renameEdit().changes[uri].slice(0, 1) },
+    });
+
+    await expect(
+      applyWorkspaceEditTool.execute(
+        { root, edit: edit(target), dryRun: false },
+        context,
+      ),
+    ).rejects.toThrow(/outside the workspace/);
+    await expect(
+      applyWorkspaceEditTool.execute(
+        {
+          root,
+          edit: {
+            documentChanges: [
+              { kind: "rename", oldUri: uri, newUri: "../escaped.ts" },
+            ],
+          },
+          dryRun: false,
+        },
+        context,
+      ),
+    ).rejects.toThrow(/outside the workspace/);
+    expect(readFileSync(target, "utf-8")).toBe("const x = 1;\n");
+    expect(existsSync(join(root, "a.ts"))).toBe(true);
+  });
+});
+
+describe("writeWorkspaceEditPlan", () => {
+  it("should keep edits from the language server inside the sandbox", async () => {
+    const root = mkdtempSync(join(tmpdir(), "lsmcp-workspace-edit-"));
+    const outside = mkdtempSync(join(tmpdir(), "lsmcp-outside-"));
+    const target = join(outside, "victim.ts");
+    writeFileSync(target, "const x = 1;\n");
+    const plan = await planWorkspaceEdit({
+      changes: {
+        [pathToFileURL(target).toString()]: [
+          {
+            range: {
+              start: { line: 0, character: 6 },
+              end: { line: 0, character: 7 },
+            },
+            newText: "y",
+          },
+        ],
+      },
+    });
+
+    await expect(
+      writeWorkspaceEditPlan(root, plan, {
+        context: { roots: [root] } as McpContext,
+      }),
+    ).rejects.toThrow(/outside the workspace/);
+    // Without workspace roots, root is the sandbox
+    await expect(writeWorkspaceEditPlan(root, plan)).rejects.toThrow(
+      /outside the workspace/,
+    );
+    expect(readFileSync(target, "utf-8")).toBe("const x = 1;\n");
+  });
+});
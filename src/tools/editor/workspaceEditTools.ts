@@ -0,0 +1,691 @@
+import { z } from "zod";
+import { createHash } from "node:crypto";
+import { existsSync } from "node:fs";
+import { mkdir, readFile, rename, unlink, writeFile } from "node:fs/promises";
+import { dirname, isAbsolute, relative, resolve } from "node:path";
+import { fileURLToPath } from "node:url";
+import { markFileModified } from "@internal/code-indexer";
+import type { LSPClient } from "@internal/lsp-client";
+import type {
+  McpContext,
+  McpToolDef,
+  TextEdit,
+  WorkspaceEdit,
+} from "@internal/types";
+import { applyTextEdits } from "../../utils/applyTextEdits.ts";
+import { confirmOperation } from "../../utils/confirmation.ts";
+import {
+  assertNotLsmcpFiles,
+  assertPathsInSandbox,
+  sandboxOptions,
+} from "../../utils/pathSandbox.ts";
+import { createUnifiedDiff } from "../../utils/unifiedDiff.ts";
+import {
+  deltaFilesOfPlan,
+  trackDiagnostics,
+} from "../lsp/diagnosticsDelta.ts";
+import { previewWorkspaceEdit } from "./pendingEdits.ts";
+
+const applyWorkspaceEditSchema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  edit: z
+    .union([z.string(), z.record(z.unknown())])
+    .describe(
+      "LSP WorkspaceEdit (object or JSON string) with changes and/or documentChanges",
+    ),
+  dryRun: z
+    .boolean()
+    .default(false)
+    .describe("Return a unified diff per file instead of writing to disk"),
+  preview: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Return a unified diff per file and an edit token instead of writing; apply it later with confirm_edit",
+    ),
+  expectedHashes: z
+    .record(z.string())
+    .optional()
+    .describe(
+      "Content hashes (relative path -> sha256) returned by a previous dry run. The edit is refused if any file changed since.",
+    ),
+});
+
+export interface WorkspaceEditFileChange {
+  kind: "edit" | "create" | "rename" | "delete";
+  /** Absolute path of the file (new path for renames) */
+  filePath: string;
+  /** Original path for renames */
+  oldFilePath?: string;
+  /** Content before the edit (null if the file does not exist yet) */
+  before: string | null;
+  /** Content after the edit (null if the file is deleted) */
+  after: string | null;
+  /** sha256 of the content the edit was computed against */
+  hash: string | null;
+  /** Content of the file a rename with `overwrite` replaces */
+  replaced?: string;
+}
+
+export interface WorkspaceEditPlan {
+  changes: WorkspaceEditFileChange[];
+  errors: string[];
+}
+
+type DocumentChange =
+  | {
+      textDocument: { uri: string; version?: number | null };
+      edits: TextEdit[];
+    }
+  | { kind: "create"; uri: string; options?: { overwrite?: boolean } }
+  | {
+      kind: "rename";
+      oldUri: string;
+      newUri: string;
+      options?: { overwrite?: boolean; ignoreIfExists?: boolean };
+    }
+  | { kind: "delete"; uri: string };
+
+export function hashContent(content: string): string {
+  return createHash("sha256").update(content).digest("hex");
+}
+
+export function uriToPath(uri: string): string {
+  return uri.startsWith("file://") ? fileURLToPath(uri) : uri;
+}
+
+/**
+ * Edit replacing the whole of before with after
+ */
+export function replaceContent(before: string, after: string): TextEdit {
+  const lines = before.split("\n");
+  const line = lines.length - 1;
+  return {
+    range: {
+      start: { line: 0, character: 0 },
+      end: { line, character: lines[line].length },
+    },
+    newText: after,
+  };
+}
+
+/**
+ * Check that all edit ranges still fit the current content.
+ * Out-of-range edits mean the file changed after the edit was computed.
+ */
+function validateEdits(content: string, edits: TextEdit[]): string | null {
+  const lines = content.split("\n");
+  for (const edit of edits) {
+    const { start, end } = edit.range;
+    if (
+      start.line > end.line ||
+      (start.line === end.line && start.character > end.character)
+    ) {
+      return `invalid range at line ${start.line + 1}`;
+    }
+    if (end.line >= lines.length) {
+      return `line ${end.line + 1} is out of range (file has ${lines.length} lines)`;
+    }
+    if (start.character > lines[start.line].length) {
+      return `column ${start.character + 1} is out of range at line ${start.line + 1}`;
+    }
+    if (end.character > lines[end.line].length) {
+      return `column ${end.character + 1} is out of range at line ${end.line + 1}`;
+    }
+  }
+  return null;
+}
+
+/**
+ * Versions of the documents open in the language server, by file path
+ */
+export function openDocumentVersions(
+  client: LSPClient | undefined,
+): Map<string, number> {
+  return new Map(
+    (client?.getOpenDocuments?.() ?? []).map((document) => [
+      uriToPath(document.uri),
+      document.version,
+    ]),
+  );
+}
+
+/**
+ * Compute the resulting file contents of a WorkspaceEdit without writing
+ * documentChanges take precedence over changes when an edit has both.
+ * @param baseContents contents to plan against instead of the files on disk
+ *   (null for files that do not exist), e.g. edits staged in a transaction
+ * @param documentVersions versions of the open documents by path; edits for
+ *   another version of one of them are refused
+ */
+export async function planWorkspaceEdit(
+  edit: WorkspaceEdit,
+  baseContents?: ReadonlyMap<string, string | null>,
+  documentVersions?: ReadonlyMap<string, number>,
+): Promise<WorkspaceEditPlan> {
+  const plan: WorkspaceEditPlan = { changes: [], errors: [] };
+  // Current (possibly already edited) content per path
+  const contents = new Map<string, string | null>(baseContents);
+  const byPath = new Map<string, WorkspaceEditFileChange>();
+
+  const load = async (filePath: string): Promise<string | null> => {
+    if (contents.has(filePath)) {
+      return contents.get(filePath)!;
+    }
+    const content = existsSync(filePath)
+      ? await readFile(filePath, "utf-8")
+      : null;
+    contents.set(filePath, content);
+    return content;
+  };
+
+  const track = (filePath: string, before: string | null) => {
+    let change = byPath.get(filePath);
+    if (!change) {
+      change = {
+        kind: "edit",
+        filePath,
+        before,
+        after: before,
+        hash: before === null ? null : hashContent(before),
+      };
+      byPath.set(filePath, change);
+      plan.changes.push(change);
+    }
+    return change;
+  };
+
+  const applyEdits = async (uri: string, edits: TextEdit[]) => {
+    const filePath = uriToPath(uri);
+    const content = await load(filePath);
+    if (content === null) {
+      plan.errors.push(`${filePath}: file does not exist`);
+      return;
+    }
+    const invalid = validateEdits(content, edits);
+    if (invalid) {
+      plan.errors.push(`${filePath}: ${invalid}`);
+      return;
+    }
+    const change = track(filePath, content);
+    const updated = applyTextEdits(content, edits);
+    change.after = updated;
+    contents.set(filePath, updated);
+  };
+
+  // Servers fill in both for clients without documentChanges support
+  if (edit.changes && !edit.documentChanges) {
+    for (const [uri, edits] of Object.entries(edit.changes)) {
+      if (edits && edits.length > 0) {
+        await applyEdits(uri, edits);
+      }
+    }
+  }
+
+  for (const change of (edit.documentChanges ?? []) as DocumentChange[]) {
+    if ("textDocument" in change) {
+      const { uri, version } = change.textDocument;
+      const current = documentVersions?.get(uriToPath(uri));
+      if (
+        typeof version === "number" &&
+        current !== undefined &&
+        version !== current
+      ) {
+        plan.errors.push(
+          `${uriToPath(uri)}: edit is for version ${version}, the open document is at version ${current}`,
+        );
+        continue;
+      }
+      await applyEdits(uri, change.edits);
+    } else if (change.kind === "create") {
+      const filePath = uriToPath(change.uri);
+      const existing = await load(filePath);
+      if (existing !== null && !change.options?.overwrite) {
+        continue;
+      }
+      const tracked = track(filePath, existing);
+      tracked.kind = "create";
+      tracked.after = "";
+      contents.set(filePath, "");
+    } else if (change.kind === "rename") {
+      const oldPath = uriToPath(change.oldUri);
+      const newPath = uriToPath(change.newUri);
+      const content = await load(oldPath);
+      if (content === null) {
+        plan.errors.push(`${oldPath}: file to rename does not exist`);
+        continue;
+      }
+      // Like LSP clients, keep an existing target unless told to overwrite
+      const existing = await load(newPath);
+      if (existing !== null && !change.options?.overwrite) {
+        if (!change.options?.ignoreIfExists) {
+          plan.errors.push(
+            `${newPath}: rename target already exists (set options.overwrite to replace it)`,
+          );
+        }
+        continue;
+      }
+      const overwritten = byPath.get(newPath);
+      if (overwritten?.oldFilePath) {
+        plan.errors.push(
+          `${newPath}: cannot rename over a file renamed in the same edit`,
+        );
+        continue;
+      }
+      if (overwritten) {
+        plan.changes.splice(plan.changes.indexOf(overwritten), 1);
+      }
+      const replaced = overwritten ? overwritten.before : existing;
+      const source = track(oldPath, content);
+      plan.changes.splice(plan.changes.indexOf(source), 1);
+      byPath.delete(oldPath);
+      const renamed: WorkspaceEditFileChange = {
+        ...source,
+        kind: "rename",
+        filePath: newPath,
+        oldFilePath: oldPath,
+        ...(replaced !== null ? { replaced } : {}),
+      };
+      byPath.set(newPath, renamed);
+      plan.changes.push(renamed);
+      contents.set(oldPath, null);
+      contents.set(newPath, content);
+    } else if (change.kind === "delete") {
+      const filePath = uriToPath(change.uri);
+      const content = await load(filePath);
+      if (content === null) {
+        continue;
+      }
+      const tracked = track(filePath, content);
+      tracked.kind = "delete";
+      tracked.after = null;
+      contents.set(filePath, null);
+    }
+  }
+
+  return plan;
+}
+
+export function toRelative(root: string, filePath: string): string {
+  const relativePath = relative(root, filePath);
+  return relativePath.startsWith("..") || isAbsolute(relativePath)
+    ? filePath
+    : relativePath;
+}
+
+/**
+ * Render a plan as unified diffs, one per file
+ */
+export function formatWorkspaceEditDiff(
+  root: string,
+  plan: WorkspaceEditPlan,
+): string {
+  return plan.changes
+    .map((change) => {
+      const path = toRelative(root, change.filePath);
+      const header =
+        change.kind === "rename"
+          ? `rename ${toRelative(root, change.oldFilePath!)} -> ${path}\n`
+          : change.kind === "create"
+            ? `create ${path}\n`
+            : change.kind === "delete"
+              ? `delete ${path}\n`
+              : "";
+      return (
+        header +
+        createUnifiedDiff(path, change.before ?? "", change.after ?? "")
+      ).trimEnd();
+    })
+    .filter((section) => section.length > 0)
+    .join("\n\n");
+}
+
+/**
+ * Find files whose current content no longer matches the planned edit
+ */
+export async function findStaleFiles(
+  root: string,
+  plan: WorkspaceEditPlan,
+  expectedHashes: Record<string, string> = {},
+): Promise<string[]> {
+  const stale: string[] = [];
+  for (const change of plan.changes) {
+    const sourcePath = change.oldFilePath ?? change.filePath;
+    const key = toRelative(root, sourcePath);
+    const expected = expectedHashes[key];
+    if (expected && change.hash !== expected) {
+      stale.push(key);
+      continue;
+    }
+    // Re-read to detect changes made while the plan was being computed
+    const current = existsSync(sourcePath)
+      ? hashContent(await readFile(sourcePath, "utf-8"))
+      : null;
+    if (current !== change.hash) {
+      stale.push(key);
+      continue;
+    }
+    // A rename target must still be absent, or what the rename replaces
+    if (change.oldFilePath) {
+      const target = existsSync(change.filePath)
+        ? await readFile(change.filePath, "utf-8")
+        : undefined;
+      if (target !== change.replaced) {
+        stale.push(toRelative(root, change.filePath));
+      }
+    }
+  }
+  return stale;
+}
+
+/**
+ * Ask the user before writing a plan when the confirmations config says so;
+ * plans that delete a file count as deletes
+ * @throws when the user declines
+ */
+export async function confirmWorkspaceEdit(
+  root: string,
+  plan: WorkspaceEditPlan,
+  title: string,
+  context: McpContext | undefined,
+  kind?: "edit" | "delete",
+): Promise<void> {
+  const deletesFile = plan.changes.some((change) => change.kind === "delete");
+  await confirmOperation(context, {
+    kind: kind ?? (deletesFile ? "delete" : "edit"),
+    title,
+    files: plan.changes.map((change) => toRelative(root, change.filePath)),
+  });
+}
+
+/** What is needed to undo the change of one file */
+export type FileOriginal = Pick<
+  WorkspaceEditFileChange,
+  "filePath" | "oldFilePath" | "before" | "replaced"
+>;
+
+async function writeChange(change: WorkspaceEditFileChange): Promise<void> {
+  if (change.kind === "rename" && change.oldFilePath) {
+    await mkdir(dirname(change.filePath), { recursive: true });
+    await rename(change.oldFilePath, change.filePath);
+  }
+  if (change.after === null) {
+    await unlink(change.filePath);
+  } else {
+    await mkdir(dirname(change.filePath), { recursive: true });
+    await writeFile(change.filePath, change.after, "utf-8");
+  }
+}
+
+/**
+ * Put a file back the way it was before the plan, as far as it was changed
+ */
+export async function revertChange(change: FileOriginal): Promise<void> {
+  const sourcePath = change.oldFilePath ?? change.filePath;
+  if (
+    change.oldFilePath &&
+    existsSync(change.filePath) &&
+    !existsSync(change.oldFilePath)
+  ) {
+    await rename(change.filePath, change.oldFilePath);
+  }
+  if (change.replaced !== undefined) {
+    await writeFile(change.filePath, change.replaced, "utf-8");
+  }
+  if (change.before === null) {
+    if (existsSync(sourcePath)) {
+      await unlink(sourcePath);
+    }
+  } else {
+    await mkdir(dirname(sourcePath), { recursive: true });
+    await writeFile(sourcePath, change.before, "utf-8");
+  }
+}
+
+/** Rollback journal: original contents of every file an edit writes */
+export interface EditJournal {
+  createdAt: string;
+  changes: FileOriginal[];
+}
+
+/**
+ * Write a planned WorkspaceEdit to disk, all or nothing: when a write fails,
+ * files already written are restored and the error is rethrown
+ * @param options.journal path of a rollback journal written before the first
+ *   file and removed once the edit is written or undone, so an interrupted
+ *   write can be undone later (see recoverEditJournals)
+ * @param options.context call whose sandbox every file must be in (root is
+ *   the only allowed directory without workspace roots)
+ */
+export async function writeWorkspaceEditPlan(
+  root: string,
+  plan: WorkspaceEditPlan,
+  options: { journal?: string; context?: McpContext } = {},
+): Promise<string[]> {
+  const paths = plan.changes.flatMap((change) =>
+    change.oldFilePath
+      ? [change.oldFilePath, change.filePath]
+      : [change.filePath],
+  );
+  assertPathsInSandbox(paths, sandboxOptions(options.context, root));
+  assertNotLsmcpFiles(paths);
+  if (options.journal) {
+    const journal: EditJournal = {
+      createdAt: new Date().toISOString(),
+      changes: plan.changes.map(
+        ({ filePath, oldFilePath, before, replaced }) => ({
+          filePath,
+          oldFilePath,
+          before,
+          replaced,
+        }),
+      ),
+    };
+    await mkdir(dirname(options.journal), { recursive: true });
+    await writeFile(options.journal, JSON.stringify(journal), "utf-8");
+  }
+
+  const started: WorkspaceEditFileChange[] = [];
+  try {
+    for (const change of plan.changes) {
+      started.push(change);
+      await writeChange(change);
+    }
+  } catch (error) {
+    const failed: string[] = [];
+    for (const change of started.reverse()) {
+      try {
+        await revertChange(change);
+      } catch {
+        failed.push(toRelative(root, change.oldFilePath ?? change.filePath));
+      }
+    }
+    const reason = error instanceof Error ? error.message : String(error);
+    if (failed.length === 0) {
+      if (options.journal) {
+        await unlink(options.journal);
+      }
+      throw new Error(
+        `Failed to write workspace edit (${reason}); no files were changed`,
+      );
+    }
+    const journalNote = options.journal
+      ? `; rollback journal kept at ${options.journal}`
+      : "";
+    throw new Error(
+      `Failed to write workspace edit (${reason}); could not restore: ${failed.join(", ")}${journalNote}`,
+    );
+  }
+
+  if (options.journal) {
+    await unlink(options.journal);
+  }
+
+  const written: string[] = [];
+  for (const change of plan.changes) {
+    if (change.oldFilePath) {
+      markFileModified(root, change.oldFilePath);
+    }
+    markFileModified(root, change.filePath);
+    written.push(toRelative(root, change.filePath));
+  }
+  return written;
+}
+
+export function parseWorkspaceEdit(edit: string | Record<string, unknown>) {
+  const parsed = typeof edit === "string" ? JSON.parse(edit) : edit;
+  if (!parsed || typeof parsed !== "object") {
+    throw new Error("edit must be a WorkspaceEdit object");
+  }
+  return parsed as WorkspaceEdit;
+}
+
+/**
+ * Resolve relative URIs against root so edits produced by hand also work
+ */
+export function resolveEditPaths(root: string, edit: WorkspaceEdit): WorkspaceEdit {
+  const fix = (uri: string) =>
+    uri.startsWith("file://") || isAbsolute(uri) ? uri : resolve(root, uri);
+
+  return {
+    ...edit,
+    changes: edit.changes
+      ? Object.fromEntries(
+          Object.entries(edit.changes).map(([uri, edits]) => [fix(uri), edits]),
+        )
+      : undefined,
+    documentChanges: edit.documentChanges
+      ? ((edit.documentChanges as DocumentChange[]).map((change) => {
+          if ("textDocument" in change) {
+            return {
+              ...change,
+              textDocument: {
+                ...change.textDocument,
+                uri: fix(change.textDocument.uri),
+              },
+            };
+          }
+          if (change.kind === "rename") {
+            return {
+              ...change,
+              oldUri: fix(change.oldUri),
+              newUri: fix(change.newUri),
+            };
+          }
+          return { ...change, uri: fix(change.uri) };
+        }) as WorkspaceEdit["documentChanges"])
+      : undefined,
+  };
+}
+
+/**
+ * Every file a WorkspaceEdit edits, creates, renames or deletes
+ */
+export function editTargets(edit: WorkspaceEdit): string[] {
+  const targets = Object.keys(edit.changes ?? {});
+  for (const change of (edit.documentChanges ?? []) as DocumentChange[]) {
+    if ("textDocument" in change) {
+      targets.push(change.textDocument.uri);
+    } else if (change.kind === "rename") {
+      targets.push(change.oldUri, change.newUri);
+    } else {
+      targets.push(change.uri);
+    }
+  }
+  return targets;
+}
+
+/**
+ * Resolve the paths of a WorkspaceEdit passed to a tool and reject it when
+ * a file it touches is outside the sandbox of the call
+ */
+export function resolveSandboxedEdit(
+  root: string,
+  edit: WorkspaceEdit,
+  context?: McpContext,
+): WorkspaceEdit {
+  const resolved = resolveEditPaths(root, edit);
+  assertPathsInSandbox(editTargets(resolved), sandboxOptions(context, root));
+  return resolved;
+}
+
+export const applyWorkspaceEditTool: McpToolDef<
+  typeof applyWorkspaceEditSchema
+> = {
+  name: "apply_workspace_edit",
+  description:
+    "Apply an LSP WorkspaceEdit (e.g. from rename or code actions) to files on disk. " +
+    "Use dryRun: true to preview a unified diff per file and get content hashes; " +
+    "pass those hashes as expectedHashes to refuse applying if any file changed in between. " +
+    "Use preview: true to get the diff with an edit token for confirm_edit instead.",
+  schema: applyWorkspaceEditSchema,
+  execute: async (
+    { root, edit, dryRun, preview, expectedHashes },
+    context,
+  ) => {
+    const workspaceEdit = resolveSandboxedEdit(
+      root,
+      parseWorkspaceEdit(edit),
+      context,
+    );
+    const plan = await planWorkspaceEdit(
+      workspaceEdit,
+      undefined,
+      openDocumentVersions(context?.lspClient),
+    );
+
+    if (plan.errors.length > 0) {
+      const details = plan.errors.map((e) => `  ${e}`).join("\n");
+      throw new Error(
+        `Refusing to apply workspace edit; files appear to have changed since it was computed:\n${details}`,
+      );
+    }
+
+    if (plan.changes.length === 0) {
+      return "Workspace edit contains no changes.";
+    }
+
+    if (preview) {
+      return previewWorkspaceEdit(root, plan, "workspace edit", {
+        sessionId: context?.sessionId,
+      });
+    }
+
+    if (dryRun) {
+      const hashes = Object.fromEntries(
+        plan.changes
+          .filter((change) => change.hash !== null)
+          .map((change) => [
+            toRelative(root, change.oldFilePath ?? change.filePath),
+            change.hash,
+          ]),
+      );
+      return [
+        `Dry run: ${plan.changes.length} file(s) would change.`,
+        "",
+        formatWorkspaceEditDiff(root, plan),
+        "",
+        `expectedHashes: ${JSON.stringify(hashes)}`,
+      ].join("\n");
+    }
+
+    const stale = await findStaleFiles(root, plan, expectedHashes);
+    if (stale.length > 0) {
+      throw new Error(
+        `Refusing to apply workspace edit; files changed since it was computed: ${stale.join(", ")}`,
+      );
+    }
+
+    await confirmWorkspaceEdit(root, plan, "workspace edit", context);
+    const delta = await trackDiagnostics(
+      context?.lspClient,
+      deltaFilesOfPlan(plan),
+      context,
+    );
+    const written = await writeWorkspaceEditPlan(root, plan, { context });
+    const files = written.map((file) => `  ${file}`).join("\n");
+    const output = `Applied workspace edit to ${written.length} file(s):\n${files}`;
+    const diagnostics = await delta(root);
+    return diagnostics ? `${output}\n\n${diagnostics}` : output;
+  },
+};
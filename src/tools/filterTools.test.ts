@@ -0,0 +1,140 @@
+import { describe, it, expect } from "vitest";
+import { z } from "zod";
+import type { McpToolDef } from "@internal/types";
+import {
+  createCapabilityFilter,
+  createToolFilter,
+  guardToolsByCapabilities,
+  missingCapabilities,
+  TOOL_GROUPS,
+} from "./filterTools.ts";
+import { getAllAvailableTools } from "./getAllTools.ts";
+import { getSerenityToolsList } from "./index.ts";
+
+function tool(name: string): McpToolDef<any> {
+  return {
+    name,
+    description: name,
+    schema: z.object({}),
+    execute: async () => `${name} ran`,
+  };
+}
+
+const tools = [
+  tool("lsp_get_hover"),
+  tool("lsp_rename_symbol"),
+  tool("lsp_get_diagnostics"),
+  tool("lsp_check_capabilities"),
+];
+
+describe("capability filtering", () => {
+  it("should hide tools whose capability the server does not advertise", () => {
+    const client = {
+      getServerCapabilities: () => ({
+        hoverProvider: true,
+        textDocumentSync: 1,
+      }),
+    };
+
+    const names = createCapabilityFilter(client)
+      .filterTools(tools)
+      .map((t) => t.name);
+
+    expect(names).toEqual([
+      "lsp_get_hover",
+      "lsp_get_diagnostics",
+      "lsp_check_capabilities",
+    ]);
+  });
+
+  it("should keep every tool while capabilities are unknown", () => {
+    const client = { getServerCapabilities: () => undefined };
+
+    expect(createCapabilityFilter(client).filterTools(tools)).toHaveLength(4);
+  });
+
+  it("should name the missing capabilities", () => {
+    expect(missingCapabilities("lsp_rename_symbol", {})).toEqual([
+      "renameProvider",
+    ]);
+    expect(missingCapabilities("lsp_get_diagnostics", {})).toEqual([
+      "diagnosticProvider or textDocumentSync",
+    ]);
+    expect(missingCapabilities("lsp_check_capabilities", {})).toEqual([]);
+  });
+
+  it("should fail clearly when a capability disappears after registration", async () => {
+    let capabilities: Record<string, unknown> = { renameProvider: true };
+    const [rename] = guardToolsByCapabilities(
+      [tool("lsp_rename_symbol")],
+      () => capabilities,
+    );
+
+    expect(await rename.execute({})).toBe("lsp_rename_symbol ran");
+
+    capabilities = {};
+    await expect(rename.execute({})).rejects.toThrow(
+      "lsp_rename_symbol is not available: the language server does not support renameProvider",
+    );
+  });
+});
+
+describe("createToolFilter", () => {
+  const names = [
+    "lsp_get_hover",
+    "lsp_rename_symbol",
+    "replace_range",
+    "write_memory",
+    "read_memory",
+    "run_tests",
+  ];
+  const offered = (filter: ((name: string) => boolean) | undefined) =>
+    names.filter((name) => !filter || filter(name));
+
+  it("should offer every tool without a policy", () => {
+    expect(createToolFilter({})).toBeUndefined();
+  });
+
+  it("should hide tools that write or run code in read-only mode", () => {
+    expect(offered(createToolFilter({ readOnly: true }))).toEqual([
+      "lsp_get_hover",
+      "read_memory",
+    ]);
+  });
+
+  it("should combine allow globs with deny groups", () => {
+    const filter = createToolFilter({
+      allow: ["lsp_*", "*_memory"],
+      deny: ["@edit"],
+      unsupported: ["read_memory"],
+    });
+
+    expect(offered(filter)).toEqual(["lsp_get_hover", "write_memory"]);
+  });
+
+  it("should reject unknown groups", () => {
+    expect(() => createToolFilter({ deny: ["@edits"] })).toThrow(
+      /Unknown tool group '@edits'/,
+    );
+  });
+});
+
+describe("TOOL_GROUPS", () => {
+  it("should only list registered tool names", async () => {
+    const registered = new Set(
+      [
+        ...(await getAllAvailableTools()),
+        // TypeScript-only tools are registered with the feature enabled
+        ...getSerenityToolsList({
+          languageFeatures: { typescript: { enabled: true } },
+        }),
+      ].map((tool) => tool.name),
+    );
+
+    for (const [group, names] of Object.entries(TOOL_GROUPS)) {
+      for (const name of names) {
+        expect(registered.has(name), `@${group}: ${name}`).toBe(true);
+      }
+    }
+  });
+});
@@ -18,57 +18,62 @@ const toolCapabilityMap = createToolCapabilityMap();
  */
 const CAPABILITY_SPECIAL_CASES: Record<
   string,
-  (checker: CapabilityChecker) => boolean
+  {
+    check: (checker: CapabilityChecker) => boolean;
+    requires: string;
+  }
 > = {
   // Diagnostics can be provided through different methods
-  get_diagnostics: (checker) => {
-    return (
+  lsp_get_diagnostics: {
+    check: (checker) =>
       checker.hasCapability("diagnosticProvider") ||
-      checker.hasCapability("textDocumentSync")
-    );
+      checker.hasCapability("textDocumentSync"),
+    requires: "diagnosticProvider or textDocumentSync",
   },
-  get_all_diagnostics: (checker) => {
-    return (
+  lsp_get_all_diagnostics: {
+    check: (checker) =>
       checker.hasCapability("diagnosticProvider") ||
-      checker.hasCapability("textDocumentSync")
-    );
+      checker.hasCapability("textDocumentSync"),
+    requires: "diagnosticProvider or textDocumentSync",
   },
-  // Delete symbol is typically part of code actions
-  delete_symbol: (checker) => {
-    return checker.hasCapability("codeActionProvider");
+  // Delete symbol edits the file itself and only needs references to find uses
+  lsp_delete_symbol: {
+    check: (checker) => checker.hasCapability("referencesProvider"),
+    requires: "referencesProvider",
   },
 };
 
 /**
- * Check if a tool is supported by the server capabilities
+ * Capabilities a tool needs that the server does not advertise
+ * Empty when the tool is supported or capabilities are not known yet
  */
-export function isToolSupportedByCapabilities(
+export function missingCapabilities(
   toolName: string,
   capabilities: ServerCapabilities | undefined,
-): boolean {
-  const checker = new CapabilityChecker(capabilities);
-
+): string[] {
   if (!capabilities) {
-    // If we don't have capabilities yet, assume all tools are supported
-    // This can happen during initialization
-    return true;
+    return [];
   }
+  const checker = new CapabilityChecker(capabilities);
 
-  // Check special cases first
   const specialCase = CAPABILITY_SPECIAL_CASES[toolName];
   if (specialCase) {
-    return specialCase(checker);
+    return specialCase.check(checker) ? [] : [specialCase.requires];
   }
 
-  // Check standard capability mapping
-  const requiredCapabilities = toolCapabilityMap.get(toolName);
-  if (!requiredCapabilities || requiredCapabilities.length === 0) {
-    // If no mapping exists, assume the tool is supported
-    return true;
-  }
+  const requiredCapabilities = toolCapabilityMap.get(toolName) ?? [];
+  return requiredCapabilities.filter((cap) => !checker.hasCapability(cap));
+}
 
-  // Check if all required capabilities are supported
-  return checker.hasCapabilities(requiredCapabilities);
+/**
+ * Check if a tool is supported by the server capabilities
+ */
+export function isToolSupportedByCapabilities(
+  toolName: string,
+  capabilities: ServerCapabilities | undefined,
+): boolean {
+  // Without capabilities (e.g. during initialization) assume support
+  return missingCapabilities(toolName, capabilities).length === 0;
 }
 
 /**
@@ -88,13 +93,13 @@ export function filterToolsByCapabilities(
   }
 
   const filtered = tools.filter((tool) => {
-    const isSupported = isToolSupportedByCapabilities(tool.name, capabilities);
-    if (!isSupported) {
+    const missing = missingCapabilities(tool.name, capabilities);
+    if (missing.length > 0) {
       debug(
-        `Tool '${tool.name}' filtered out - not supported by server capabilities`,
+        `Tool '${tool.name}' filtered out - server lacks ${missing.join(", ")}`,
       );
     }
-    return isSupported;
+    return missing.length === 0;
   });
 
   debug(
@@ -108,6 +113,30 @@ export function filterToolsByCapabilities(
   return filtered;
 }
 
+/**
+ * Make tools fail with a clear error when the server stops advertising what
+ * they need (e.g. after a restart with different settings), instead of the
+ * request failing inside the language server
+ */
+export function guardToolsByCapabilities(
+  tools: McpToolDef<any>[],
+  getCapabilities: () => ServerCapabilities | undefined,
+): McpToolDef<any>[] {
+  return tools.map((tool) => ({
+    ...tool,
+    execute: async (args, context) => {
+      const missing = missingCapabilities(tool.name, getCapabilities());
+      if (missing.length > 0) {
+        throw new Error(
+          `${tool.name} is not available: the language server does not support ${missing.join(", ")}. ` +
+            `Use lsp_check_capabilities to see what it supports.`,
+        );
+      }
+      return tool.execute(args, context);
+    },
+  }));
+}
+
 /**
  * Filter tools based on unsupported list from config
  */
@@ -121,6 +150,115 @@ export function filterUnsupportedTools(
   return tools.filter((tool) => !unsupportedSet.has(tool.name));
 }
 
+/**
+ * Tool groups, referenced as `@name` in tools.allow / tools.deny
+ */
+export const TOOL_GROUPS: Record<string, string[]> = {
+  // Change source files (directly or through the language server)
+  edit: [
+    "replace_range",
+    "replace_regex",
+    "replace_symbol_body",
+    "insert_before_symbol",
+    "insert_after_symbol",
+    "rename_file",
+    "delete_symbol",
+    "delete_file",
+    "apply_workspace_edit",
+    "confirm_edit",
+    "begin_edit_transaction",
+    "add_to_edit_transaction",
+    "commit_edit_transaction",
+    "abort_edit_transaction",
+    "lsp_rename_symbol",
+    "lsp_delete_symbol",
+    "lsp_move_symbol_to_file",
+    "lsp_apply_code_action",
+    "lsp_organize_imports",
+    "lsp_format_document",
+    "lsp_format_range",
+    "lsp_execute_command",
+    // Raw requests can make the server edit files (workspace/executeCommand)
+    "lsp_request",
+  ],
+  // Write or delete project memories
+  memory: ["write_memory", "delete_memory"],
+  // Build and run project code
+  exec: ["run_tests", "get_coverage"],
+  // Write caches outside the symbol index
+  cache: ["index_external_libraries"],
+};
+
+/** Groups denied by --read-only */
+export const READ_ONLY_DENIED_GROUPS = ["edit", "memory", "exec", "cache"];
+
+/** Whether a tool writes to disk or runs project code */
+export function hasSideEffects(toolName: string): boolean {
+  return READ_ONLY_DENIED_GROUPS.some((group) =>
+    TOOL_GROUPS[group].includes(toolName),
+  );
+}
+
+export interface ToolPolicy {
+  /** Only these tools are offered (names, `@group` or `*` globs) */
+  allow?: string[];
+  /** Tools never offered (names, `@group` or `*` globs) */
+  deny?: string[];
+  /** Deny every tool that writes to disk or runs project code */
+  readOnly?: boolean;
+  /** Tools the preset does not support */
+  unsupported?: string[];
+}
+
+function toolMatcher(entries: string[]): (name: string) => boolean {
+  const names = new Set<string>();
+  const patterns: RegExp[] = [];
+  for (const entry of entries) {
+    if (entry.startsWith("@")) {
+      const group = TOOL_GROUPS[entry.slice(1)];
+      if (!group) {
+        throw new Error(
+          `Unknown tool group '${entry}'. Available: ${Object.keys(TOOL_GROUPS)
+            .map((g) => `@${g}`)
+            .join(", ")}`,
+        );
+      }
+      group.forEach((name) => names.add(name));
+    } else if (entry.includes("*")) {
+      const source = entry.split("*").map(escapeRegExp).join(".*");
+      patterns.push(new RegExp(`^${source}$`));
+    } else {
+      names.add(entry);
+    }
+  }
+  return (name) => names.has(name) || patterns.some((p) => p.test(name));
+}
+
+function escapeRegExp(text: string): string {
+  return text.replace(/[.+?^${}()|[\]\\]/g, "\\$&");
+}
+
+/**
+ * Build the filter deciding which tools are offered
+ * Deny wins over allow. Undefined when every tool is offered.
+ * @throws for an unknown `@group`
+ */
+export function createToolFilter(
+  policy: ToolPolicy,
+): ((name: string) => boolean) | undefined {
+  const denyEntries = [
+    ...(policy.deny ?? []),
+    ...(policy.unsupported ?? []),
+    ...(policy.readOnly ? READ_ONLY_DENIED_GROUPS.map((g) => `@${g}`) : []),
+  ];
+  const allowed = policy.allow?.length ? toolMatcher(policy.allow) : undefined;
+  if (!allowed && denyEntries.length === 0) {
+    return undefined;
+  }
+  const denied = toolMatcher(denyEntries);
+  return (name) => (!allowed || allowed(name)) && !denied(name);
+}
+
 /**
  * Create a capability-aware tool filter that can be used after initialization
  * This allows for dynamic tool filtering based on runtime capabilities
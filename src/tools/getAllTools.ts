@@ -5,6 +5,23 @@
 import type { McpToolDef } from "@internal/types";
 import type { LSPClient } from "@internal/lsp-client";
 import { createLSPTools } from "./lsp/createLspTools.ts";
+import {
+  createGetProjectDiagnosticsTool,
+} from "./highlevel/projectDiagnostics.ts";
+import { createExportDiagnosticsTool } from "./highlevel/exportDiagnostics.ts";
+import {
+  createGetCurrentDiagnosticsTool,
+} from "./highlevel/currentDiagnostics.ts";
+import { createAnalyzeUnusedSymbolsTool } from "./highlevel/unusedSymbols.ts";
+import { createBatchLookupTool } from "./highlevel/batchLookup.ts";
+import { createAnalyzeDependenciesTool } from "./highlevel/dependencyGraph.ts";
+import { createRunGoTestsTool } from "./highlevel/goTests.ts";
+import { createGetCoverageTool } from "./highlevel/goCoverage.ts";
+import {
+  createInspectDependenciesTool,
+} from "./highlevel/inspectDependencies.ts";
+import { createReadFileSkeletonTool } from "./highlevel/fileSkeleton.ts";
+import { createDiffSymbolsTool } from "./highlevel/diffSymbols.ts";
 import {
   highLevelTools,
   serenityToolsList,
@@ -29,6 +46,17 @@ export async function getAllAvailableTools(
   // Add low-level LSP tools (subject to capability filtering)
   const lspTools = createLSPTools(lspClient);
   tools.push(...lspTools);
+  tools.push(createGetProjectDiagnosticsTool(lspClient));
+  tools.push(createExportDiagnosticsTool(lspClient));
+  tools.push(createGetCurrentDiagnosticsTool({} as any));
+  tools.push(createBatchLookupTool(lspClient));
+  tools.push(createAnalyzeUnusedSymbolsTool(lspClient));
+  tools.push(createAnalyzeDependenciesTool(lspClient));
+  tools.push(createInspectDependenciesTool());
+  tools.push(createReadFileSkeletonTool(lspClient));
+  tools.push(createDiffSymbolsTool(lspClient));
+  tools.push(createRunGoTestsTool());
+  tools.push(createGetCoverageTool());
 
   // Add serenity tools
   tools.push(...serenityToolsList);
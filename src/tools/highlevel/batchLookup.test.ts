@@ -0,0 +1,121 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import { mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import type { LSPClient } from "@internal/lsp-client";
+import { batchLookup, formatBatchLookup } from "./batchLookup.ts";
+
+const SOURCE = `import { helper } from "./helper";
+
+export function main() {
+  return helper(1);
+}
+`;
+
+describe("batch_lookup", () => {
+  let root: string;
+  let uri: string;
+  let helperUri: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-batch-"));
+    writeFileSync(join(root, "main.ts"), SOURCE);
+    uri = pathToFileURL(join(root, "main.ts")).toString();
+    helperUri = pathToFileURL(join(root, "helper.ts")).toString();
+  });
+
+  function createClient() {
+    const location = {
+      uri: helperUri,
+      range: {
+        start: { line: 0, character: 16 },
+        end: { line: 0, character: 22 },
+      },
+    };
+    return {
+      openDocument: vi.fn(),
+      getHover: vi.fn().mockResolvedValue({
+        contents: { kind: "markdown", value: "function helper(n: number)" },
+      }),
+      getDefinition: vi.fn().mockResolvedValue([location]),
+      findReferences: vi.fn().mockRejectedValue(new Error("server busy")),
+    } as unknown as LSPClient & Record<string, ReturnType<typeof vi.fn>>;
+  }
+
+  it("should open each file once and resolve every lookup", async () => {
+    const client = createClient();
+
+    const results = await batchLookup(
+      {
+        root,
+        concurrency: 2,
+        lookups: [
+          {
+            relativePath: "main.ts",
+            line: 4,
+            symbolName: "helper",
+            kind: "hover",
+          },
+          {
+            relativePath: "main.ts",
+            line: "return helper",
+            symbolName: "helper",
+            kind: "definition",
+          },
+          {
+            relativePath: "main.ts",
+            line: 3,
+            character: 16,
+            kind: "references",
+          },
+        ],
+      },
+      client,
+    );
+
+    expect(client.openDocument).toHaveBeenCalledTimes(1);
+    expect(client.getHover).toHaveBeenCalledWith(uri, {
+      line: 3,
+      character: 9,
+    });
+    expect(results.map((r) => r.result ?? r.error)).toEqual([
+      "function helper(n: number)",
+      "1 location(s)\n  helper.ts:1:17",
+      "server busy",
+    ]);
+  });
+
+  it("should report unresolvable positions per lookup", async () => {
+    const client = createClient();
+
+    const results = await batchLookup(
+      {
+        root,
+        concurrency: 8,
+        lookups: [
+          {
+            relativePath: "main.ts",
+            line: 4,
+            symbolName: "nope",
+            kind: "hover",
+          },
+          { relativePath: "missing.ts", line: 1, kind: "definition" },
+          {
+            relativePath: "main.ts",
+            line: 4,
+            symbolName: "helper",
+            kind: "hover",
+          },
+        ],
+      },
+      client,
+    );
+    const text = formatBatchLookup(results);
+
+    expect(text).toContain("Resolved 1/3 lookup(s)");
+    expect(text).toContain('Error: Symbol "nope" not found on line 4');
+    expect(text).toContain("Error: File not found: missing.ts");
+    expect(text).toContain("## [3] hover helper at main.ts:4:10");
+  });
+});
@@ -0,0 +1,255 @@
+/**
+ * High-level tool resolving many positions in one call
+ * Lookups are grouped by file so each document is opened once, then sent
+ * to the language server concurrently. A failed lookup is reported in its
+ * own entry instead of failing the whole batch.
+ */
+
+import { z } from "zod";
+import { relative } from "path";
+import { fileURLToPath } from "url";
+import type { McpToolDef } from "@internal/types";
+import type { LSPClient } from "@internal/lsp-client";
+import { getLanguageIdFromPath } from "@internal/lsp-client";
+import { resolveFileAndSymbol } from "../lsp/common.ts";
+
+const MAX_LOCATIONS_PER_LOOKUP = 20;
+
+const lookupSchema = z.object({
+  relativePath: z.string().describe("File path (relative to root)"),
+  line: z
+    .union([z.number(), z.string()])
+    .describe("Line number (1-based) or string to match in the line"),
+  symbolName: z
+    .string()
+    .optional()
+    .describe("Symbol on the line; its first occurrence is used"),
+  character: z
+    .number()
+    .optional()
+    .describe(
+      "Character position in the line (0-based), instead of symbolName",
+    ),
+  kind: z
+    .enum(["hover", "definition", "references"])
+    .describe("What to look up"),
+});
+
+const schema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  lookups: z
+    .array(lookupSchema)
+    .min(1)
+    .max(100)
+    .describe("Positions to resolve; answered concurrently"),
+  concurrency: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .default(8)
+    .describe("Lookups sent to the language server at once"),
+});
+
+type BatchLookupRequest = z.infer<typeof schema>;
+type Lookup = z.infer<typeof lookupSchema>;
+
+interface LookupResult {
+  lookup: Lookup;
+  position?: { line: number; character: number };
+  result?: string;
+  error?: string;
+}
+
+interface LocationLike {
+  uri?: string;
+  targetUri?: string;
+  range?: { start: { line: number; character: number } };
+  targetSelectionRange?: { start: { line: number; character: number } };
+}
+
+function formatLocation(root: string, location: LocationLike): string {
+  const uri = location.targetUri ?? location.uri ?? "";
+  const start = (location.targetSelectionRange ?? location.range)?.start;
+  let path = uri;
+  try {
+    path = relative(root, fileURLToPath(uri));
+  } catch {
+    // Non-file URIs are shown as they are
+  }
+  return start ? `${path}:${start.line + 1}:${start.character + 1}` : path;
+}
+
+function formatLocations(root: string, locations: LocationLike[]): string {
+  if (locations.length === 0) {
+    return "(none)";
+  }
+  const shown = locations
+    .slice(0, MAX_LOCATIONS_PER_LOOKUP)
+    .map((location) => `  ${formatLocation(root, location)}`);
+  if (locations.length > MAX_LOCATIONS_PER_LOOKUP) {
+    shown.push(
+      `  ... ${locations.length - MAX_LOCATIONS_PER_LOOKUP} more (use lsp_find_references to page)`,
+    );
+  }
+  return `${locations.length} location(s)\n${shown.join("\n")}`;
+}
+
+function hoverText(hover: unknown): string {
+  const contents = (hover as { contents?: unknown } | null)?.contents;
+  const render = (item: unknown): string =>
+    typeof item === "string"
+      ? item
+      : ((item as { value?: string } | null)?.value ?? "");
+  const text = Array.isArray(contents)
+    ? contents.map(render).join("\n")
+    : render(contents);
+  return text.trim() || "(no hover information)";
+}
+
+async function runLookup(
+  client: LSPClient,
+  root: string,
+  uri: string,
+  position: { line: number; character: number },
+  kind: Lookup["kind"],
+): Promise<string> {
+  switch (kind) {
+    case "hover":
+      return hoverText(await client.getHover(uri, position));
+    case "definition": {
+      const result = await client.getDefinition(uri, position);
+      const locations = (Array.isArray(result) ? result : [result]).filter(
+        Boolean,
+      ) as LocationLike[];
+      return formatLocations(root, locations);
+    }
+    case "references":
+      return formatLocations(
+        root,
+        (await client.findReferences(uri, position)) as LocationLike[],
+      );
+  }
+}
+
+/**
+ * Run `tasks` with at most `limit` in flight
+ */
+async function mapConcurrent<T, R>(
+  items: T[],
+  limit: number,
+  task: (item: T) => Promise<R>,
+): Promise<R[]> {
+  const results: R[] = new Array(items.length);
+  let next = 0;
+  const worker = async () => {
+    while (next < items.length) {
+      const index = next++;
+      results[index] = await task(items[index]);
+    }
+  };
+  await Promise.all(
+    Array.from({ length: Math.min(limit, items.length) }, worker),
+  );
+  return results;
+}
+
+export async function batchLookup(
+  request: BatchLookupRequest,
+  client: LSPClient,
+): Promise<LookupResult[]> {
+  // Resolve every position up front; files are read and opened once
+  const opened = new Set<string>();
+  const resolved = request.lookups.map((lookup): LookupResult & {
+    uri?: string;
+  } => {
+    try {
+      const resolution = resolveFileAndSymbol({
+        root: request.root,
+        relativePath: lookup.relativePath,
+        line: lookup.line,
+        symbolName:
+          lookup.character === undefined ? lookup.symbolName : undefined,
+      });
+      if (!opened.has(resolution.fileUri)) {
+        client.openDocument(
+          resolution.fileUri,
+          resolution.fileContent,
+          getLanguageIdFromPath(lookup.relativePath) || undefined,
+        );
+        opened.add(resolution.fileUri);
+      }
+      return {
+        lookup,
+        uri: resolution.fileUri,
+        position: {
+          line: resolution.lineIndex,
+          character: lookup.character ?? resolution.symbolIndex,
+        },
+      };
+    } catch (error) {
+      return {
+        lookup,
+        error: error instanceof Error ? error.message : String(error),
+      };
+    }
+  });
+
+  return mapConcurrent(resolved, request.concurrency, async (item) => {
+    if (item.error || !item.uri || !item.position) {
+      return item;
+    }
+    const { uri, ...result } = item;
+    try {
+      return {
+        ...result,
+        result: await runLookup(
+          client,
+          request.root,
+          uri,
+          item.position,
+          item.lookup.kind,
+        ),
+      };
+    } catch (error) {
+      return {
+        ...result,
+        error: error instanceof Error ? error.message : String(error),
+      };
+    }
+  });
+}
+
+export function formatBatchLookup(results: LookupResult[]): string {
+  const failed = results.filter((r) => r.error).length;
+  const sections = results.map((r, i) => {
+    const { lookup, position } = r;
+    const where = position
+      ? `${lookup.relativePath}:${position.line + 1}:${position.character + 1}`
+      : `${lookup.relativePath}:${lookup.line}`;
+    const label = lookup.symbolName ? ` ${lookup.symbolName}` : "";
+    const body = r.error ? `Error: ${r.error}` : r.result;
+    return `## [${i + 1}] ${lookup.kind}${label} at ${where}\n${body}`;
+  });
+  return [
+    `Resolved ${results.length - failed}/${results.length} lookup(s)`,
+    ...sections,
+  ].join("\n\n");
+}
+
+/**
+ * Create batch_lookup tool with injected LSP client
+ */
+export function createBatchLookupTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "batch_lookup",
+    description:
+      "Resolve many positions in one call: each lookup is a hover, definition or references query at a line and symbol. " +
+      "Lookups run concurrently and failures are reported per lookup. " +
+      "Use this instead of calling lsp_get_hover or lsp_get_definitions once per symbol.",
+    schema,
+    execute: async (args) => formatBatchLookup(await batchLookup(args, client)),
+  };
+}
@@ -0,0 +1,174 @@
+/**
+ * High-level tool answering from the watch mode diagnostics cache
+ * Returns the workspace diagnostics kept current by --watch without asking
+ * the language server, so fix loops do not wait on a full check each cycle
+ */
+
+import { z } from "zod";
+import type { McpContext, McpToolDef } from "@internal/types";
+import type {
+  DiagnosticsWatcher,
+  WatchedDiagnostics,
+} from "../../utils/diagnosticsWatcher.ts";
+import {
+  formatProjectDiagnostics,
+  projectDiagnosticsOutputSchema,
+  structuredProjectDiagnostics,
+  withoutBaselined,
+  type ProjectDiagnosticsResult,
+} from "./projectDiagnostics.ts";
+import { summarizeFileDiagnostics } from "../lsp/allDiagnostics.ts";
+import { MAX_FILES_TO_SHOW } from "../../constants/diagnostics.ts";
+import { queryFingerprint } from "../../utils/cursor.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project"),
+  relativePath: z
+    .string()
+    .optional()
+    .describe("Only this file (relative to root)"),
+  severityFilter: z
+    .enum(["error", "warning", "all"])
+    .optional()
+    .default("all")
+    .describe("Filter diagnostics by severity"),
+  wait: z
+    .boolean()
+    .optional()
+    .describe(
+      "Wait until files changed on disk so far have been checked (default: false, answer instantly)",
+    ),
+  includeBaseline: z
+    .boolean()
+    .optional()
+    .describe(
+      "Also return diagnostics recorded in .lsmcp/diagnostics-baseline.json (default: false)",
+    ),
+  limit: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .describe(
+      `Maximum number of files to show per page (default: ${MAX_FILES_TO_SHOW})`,
+    ),
+  cursor: z
+    .string()
+    .optional()
+    .describe("Opaque cursor from a previous response to fetch the next page"),
+});
+
+type GetCurrentDiagnosticsRequest = z.infer<typeof schema>;
+
+const outputSchema = {
+  ...projectDiagnosticsOutputSchema,
+  updatedAt: z
+    .string()
+    .optional()
+    .describe("When the cache last changed (ISO 8601)"),
+  pendingFiles: z.number().describe("Changed files not checked yet"),
+  sweeping: z
+    .boolean()
+    .describe("Files not changed are being checked again in the background"),
+};
+
+function formatAge(updatedAt: number | undefined): string {
+  if (updatedAt === undefined) {
+    return "not checked yet";
+  }
+  const seconds = Math.max(0, Math.round((Date.now() - updatedAt) / 1000));
+  return `updated ${seconds}s ago`;
+}
+
+/** Describes how current the cache is */
+export function formatWatchStatus(watched: WatchedDiagnostics): string {
+  const parts = [formatAge(watched.updatedAt)];
+  if (watched.pendingFiles > 0) {
+    parts.push(`${watched.pendingFiles} changed file(s) waiting to be checked`);
+  }
+  if (watched.checkedFiles < watched.totalFiles) {
+    parts.push(
+      `initial check at ${watched.checkedFiles} of ${watched.totalFiles} files`,
+    );
+  } else if (watched.sweeping) {
+    parts.push("re-checking the other files in the background");
+  }
+  return `Watch mode: ${parts.join("; ")}`;
+}
+
+export function currentDiagnosticsResult(
+  watched: WatchedDiagnostics,
+  request: Pick<
+    GetCurrentDiagnosticsRequest,
+    "relativePath" | "severityFilter"
+  >,
+): ProjectDiagnosticsResult {
+  const relativePath = request.relativePath?.replace(/^\.\//, "");
+  const files = watched.files.flatMap((file) => {
+    if (relativePath && file.filePath !== relativePath) {
+      return [];
+    }
+    const diagnostics =
+      request.severityFilter && request.severityFilter !== "all"
+        ? file.diagnostics.filter((d) => d.severity === request.severityFilter)
+        : file.diagnostics;
+    return diagnostics.length > 0 ? [{ ...file, diagnostics }] : [];
+  });
+  return {
+    ...summarizeFileDiagnostics(files),
+    method: "watch",
+    checkedFiles: watched.checkedFiles,
+  };
+}
+
+/**
+ * Create get_current_diagnostics tool with the watch mode cache
+ */
+export function createGetCurrentDiagnosticsTool(
+  watcher: DiagnosticsWatcher,
+): McpToolDef<typeof schema> {
+  return {
+    name: "get_current_diagnostics",
+    description:
+      "Get the workspace diagnostics kept current by watch mode (--watch) instantly from cache, grouped by file. " +
+      "Files are re-checked as they change on disk; pass wait: true right after an edit to wait for the changed files. " +
+      "Diagnostics recorded in the workspace baseline are left out unless includeBaseline is set.",
+    schema,
+    outputSchema,
+    execute: async (args, context?: McpContext) => {
+      if (args.wait) {
+        await watcher.settle();
+      }
+      const watched = watcher.current();
+      let result = currentDiagnosticsResult(watched, args);
+      if (!args.includeBaseline) {
+        result = await withoutBaselined(result, args.root, context);
+      }
+
+      const { root, relativePath, severityFilter, includeBaseline } = args;
+      const options = {
+        limit: args.limit,
+        cursor: args.cursor,
+        fingerprint: queryFingerprint("get_current_diagnostics", {
+          root,
+          relativePath,
+          severityFilter,
+          includeBaseline,
+        }),
+      };
+      context?.setStructuredContent?.({
+        ...structuredProjectDiagnostics(result, options),
+        updatedAt:
+          watched.updatedAt !== undefined
+            ? new Date(watched.updatedAt).toISOString()
+            : undefined,
+        pendingFiles: watched.pendingFiles,
+        sweeping: watched.sweeping,
+      });
+      const text = formatProjectDiagnostics(result, options).split("\n");
+      // Status right below the totals
+      text.splice(1, 0, formatWatchStatus(watched));
+      return text.join("\n");
+    },
+  };
+}
@@ -0,0 +1,118 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import { mkdirSync, mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+
+vi.mock("@internal/code-indexer", () => ({
+  getIndexedFiles: vi.fn(),
+}));
+
+import { getIndexedFiles } from "@internal/code-indexer";
+import {
+  analyzeDependencies,
+  formatDependencyAnalysis,
+} from "./dependencyGraph.ts";
+
+const FILES: Record<string, string> = {
+  "go.mod": "module example.com/app\n\ngo 1.22\n",
+  "main.go": 'package main\n\nimport "example.com/app/store"\n',
+  "store/store.go":
+    'package store\n\nimport (\n\t"fmt"\n\t"example.com/app/model"\n)\n',
+  "model/model.go": 'package model\n\nimport "example.com/app/store"\n',
+  "docs/notes.md": "See main.go\n",
+};
+
+describe("analyze_dependencies", () => {
+  let root: string;
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    root = mkdtempSync(join(tmpdir(), "lsmcp-deps-"));
+    for (const [file, content] of Object.entries(FILES)) {
+      mkdirSync(join(root, file, ".."), { recursive: true });
+      writeFileSync(join(root, file), content);
+    }
+    vi.mocked(getIndexedFiles).mockReturnValue([
+      "main.go",
+      "store/store.go",
+      "model/model.go",
+    ]);
+  });
+
+  const request = (overrides: Record<string, unknown> = {}) => ({
+    root,
+    granularity: "package" as const,
+    format: "adjacency" as const,
+    useDocumentLinks: false,
+    ...overrides,
+  });
+
+  it("should build the package graph and report cycles", async () => {
+    const result = await analyzeDependencies(request());
+
+    expect(result.graph.nodes).toEqual([".", "model", "store"]);
+    expect(result.cycles).toEqual([["model", "store"]]);
+
+    const output = formatDependencyAnalysis(result, "adjacency");
+    expect(output).toContain("3 packages, 3 dependencies (3 files analyzed)");
+    expect(output).toContain("Found 1 cycle:\n  model <-> store");
+    expect(output).toContain(". -> store");
+  });
+
+  it("should render a file graph as Mermaid", async () => {
+    const result = await analyzeDependencies(request({ granularity: "file" }));
+    const output = formatDependencyAnalysis(result, "mermaid");
+
+    expect(output).toContain("```mermaid\nflowchart LR");
+    expect(output).toContain('["main.go"]');
+    expect(output).toContain("linkStyle");
+  });
+
+  it("should add document links reported by the server", async () => {
+    vi.mocked(getIndexedFiles).mockReturnValue(["main.go", "docs/notes.md"]);
+    const client = {
+      openDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      getServerCapabilities: () => ({ documentLinkProvider: {} }),
+      getDocumentLinks: vi.fn(async (uri: string) =>
+        uri.endsWith("notes.md")
+          ? [
+              {
+                range: {
+                  start: { line: 0, character: 4 },
+                  end: { line: 0, character: 11 },
+                },
+                target: pathToFileURL(join(root, "main.go")).toString(),
+              },
+              {
+                range: {
+                  start: { line: 0, character: 0 },
+                  end: { line: 0, character: 3 },
+                },
+                target: "https://example.com",
+              },
+            ]
+          : [],
+      ),
+    } as any;
+
+    const result = await analyzeDependencies(
+      request({ granularity: "file", useDocumentLinks: true }),
+      client,
+    );
+
+    expect(client.getDocumentLinks).toHaveBeenCalledTimes(2);
+    expect(Array.from(result.graph.edges.get("docs/notes.md")!)).toEqual([
+      "main.go",
+    ]);
+  });
+
+  it("should fail clearly without indexed files or pattern", async () => {
+    vi.mocked(getIndexedFiles).mockReturnValue([]);
+
+    await expect(analyzeDependencies(request())).rejects.toThrow(
+      /No indexed files and no pattern provided/,
+    );
+  });
+});
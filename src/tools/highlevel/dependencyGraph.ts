@@ -0,0 +1,246 @@
+/**
+ * High-level tool for the import graph of the workspace
+ * Builds file dependencies from import statements of indexed files, optionally
+ * adds document links reported by the language server, and detects cycles
+ */
+
+import { z } from "zod";
+import { readFileSync } from "fs";
+import { join, relative, isAbsolute } from "path";
+import { fileURLToPath, pathToFileURL } from "url";
+import type { McpContext, McpToolDef } from "@internal/types";
+import type { LSPClient } from "@internal/lsp-client";
+import { debug } from "@internal/lsp-client";
+import { getIndexedFiles } from "@internal/code-indexer";
+import { getProjectFiles } from "../lsp/allDiagnostics.ts";
+import { withLSPDocument } from "../lsp/common.ts";
+import {
+  addDependency,
+  aggregateByPackage,
+  buildImportGraph,
+  countDependencies,
+  findCycles,
+  readGoModulePath,
+  renderAdjacency,
+  renderDependencyMermaid,
+  type DependencyGraph,
+} from "../../utils/importGraph.ts";
+
+const DOCUMENT_OPEN_DELAY = 200;
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project"),
+  pattern: z
+    .string()
+    .optional()
+    .describe(
+      "Glob pattern for files to analyze (defaults to indexed files, then config files)",
+    ),
+  granularity: z
+    .enum(["package", "file"])
+    .optional()
+    .default("package")
+    .describe("Graph between packages (directories) or individual files"),
+  format: z
+    .enum(["adjacency", "mermaid"])
+    .optional()
+    .default("adjacency")
+    .describe("Output an adjacency list or a Mermaid flowchart"),
+  useDocumentLinks: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe(
+      "Also ask the language server for document links (slower; opens every file)",
+    ),
+});
+
+type AnalyzeDependenciesRequest = z.infer<typeof schema>;
+
+export interface DependencyAnalysisResult {
+  graph: DependencyGraph;
+  cycles: string[][];
+  files: number;
+  granularity: AnalyzeDependenciesRequest["granularity"];
+}
+
+/**
+ * Resolve files to analyze: indexed files first, then config/pattern globs
+ */
+async function resolveFiles(
+  request: AnalyzeDependenciesRequest,
+  context?: McpContext,
+): Promise<string[]> {
+  const indexed = getIndexedFiles(request.root);
+  if (indexed.length > 0 && !request.pattern) {
+    return indexed;
+  }
+
+  const patterns = request.pattern
+    ? [request.pattern]
+    : (context?.config?.files as string[] | undefined) || [];
+  if (patterns.length === 0) {
+    throw new Error(
+      "No indexed files and no pattern provided. Run search_symbols or get_project_overview first, or pass a pattern.",
+    );
+  }
+
+  const files = new Set<string>();
+  for (const pattern of patterns) {
+    for (const file of await getProjectFiles(request.root, pattern)) {
+      files.add(file);
+    }
+  }
+  return Array.from(files).sort();
+}
+
+function readWorkspaceFile(root: string, file: string): string | undefined {
+  try {
+    return readFileSync(join(root, file), "utf-8");
+  } catch {
+    return undefined;
+  }
+}
+
+/**
+ * Add file:// document links that point at other workspace files
+ */
+async function addDocumentLinks(
+  graph: DependencyGraph,
+  files: string[],
+  request: AnalyzeDependenciesRequest,
+  client: LSPClient,
+  context?: McpContext,
+): Promise<void> {
+  if (
+    !client.getDocumentLinks ||
+    !client.getServerCapabilities()?.documentLinkProvider
+  ) {
+    debug("[analyzeDependencies] documentLink not supported by server");
+    return;
+  }
+
+  const known = new Set(graph.nodes);
+  for (const [i, file] of files.entries()) {
+    context?.signal?.throwIfAborted();
+    const content = readWorkspaceFile(request.root, file);
+    if (content === undefined) continue;
+    const uri = pathToFileURL(join(request.root, file)).toString();
+
+    try {
+      const links = await withLSPDocument(
+        client,
+        uri,
+        content,
+        () => client.getDocumentLinks!(uri),
+        DOCUMENT_OPEN_DELAY,
+      );
+      for (const link of links) {
+        if (!link.target?.startsWith("file://")) continue;
+        const target = relative(
+          request.root,
+          fileURLToPath(link.target.replace(/#.*$/, "")),
+        ).replace(/\\/g, "/");
+        if (target.startsWith("..") || isAbsolute(target)) continue;
+        if (known.has(target) && target !== file) {
+          addDependency(graph, file, target);
+        }
+      }
+    } catch (error) {
+      debug(`[analyzeDependencies] documentLink failed for ${file}:`, error);
+    }
+
+    context?.reportProgress?.({
+      progress: i + 1,
+      total: files.length,
+      message: file,
+    });
+  }
+}
+
+export async function analyzeDependencies(
+  request: AnalyzeDependenciesRequest,
+  client?: LSPClient,
+  context?: McpContext,
+): Promise<DependencyAnalysisResult> {
+  const files = (await resolveFiles(request, context)).map((file) =>
+    file.replace(/\\/g, "/"),
+  );
+  const fileGraph = buildImportGraph(
+    files,
+    (file) => readWorkspaceFile(request.root, file),
+    { goModule: readGoModulePath(request.root) },
+  );
+
+  if (request.useDocumentLinks) {
+    if (!client) {
+      throw new Error("LSP client not initialized");
+    }
+    await addDocumentLinks(fileGraph, files, request, client, context);
+  }
+
+  const graph =
+    request.granularity === "file" ? fileGraph : aggregateByPackage(fileGraph);
+  return {
+    graph,
+    cycles: findCycles(graph),
+    files: files.length,
+    granularity: request.granularity,
+  };
+}
+
+export function formatDependencyAnalysis(
+  result: DependencyAnalysisResult,
+  format: AnalyzeDependenciesRequest["format"],
+): string {
+  const unit = result.granularity === "file" ? "files" : "packages";
+  const lines = [
+    `${result.graph.nodes.length} ${unit}, ${countDependencies(
+      result.graph,
+    )} dependencies (${result.files} files analyzed)`,
+  ];
+
+  if (result.cycles.length === 0) {
+    lines.push("No dependency cycles found.");
+  } else {
+    lines.push(
+      `Found ${result.cycles.length} cycle${
+        result.cycles.length === 1 ? "" : "s"
+      }:`,
+    );
+    for (const cycle of result.cycles) {
+      lines.push(`  ${cycle.join(" <-> ")}`);
+    }
+  }
+
+  lines.push("");
+  if (format === "mermaid") {
+    lines.push(
+      "```mermaid",
+      renderDependencyMermaid(result.graph, result.cycles),
+      "```",
+    );
+  } else {
+    lines.push(renderAdjacency(result.graph));
+  }
+  return lines.join("\n");
+}
+
+/**
+ * Create analyze_dependencies tool with injected LSP client
+ */
+export function createAnalyzeDependenciesTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "analyze_dependencies",
+    description:
+      "Compute the import/include graph between packages or files of the workspace and report dependency cycles. " +
+      "Returns an adjacency list or a Mermaid diagram; imports of external packages are left out.",
+    schema,
+    execute: async (args, context?: McpContext) => {
+      const result = await analyzeDependencies(args, client, context);
+      return formatDependencyAnalysis(result, args.format);
+    },
+  };
+}
@@ -0,0 +1,199 @@
+import { describe, it, expect } from "vitest";
+import { SymbolKind, type DocumentSymbol } from "@internal/types";
+import {
+  collectRevisionSymbols,
+  diffRevisionSymbols,
+  formatSymbolDiff,
+  type RevisionSymbol,
+} from "./diffSymbols.ts";
+
+function range(line: number) {
+  return {
+    start: { line, character: 0 },
+    end: { line, character: 1 },
+  };
+}
+
+function symbol(
+  name: string,
+  kind: SymbolKind,
+  signature: string,
+  extra: Partial<RevisionSymbol> = {},
+): RevisionSymbol {
+  return { file: "pkg/client.go", name, kind, line: 1, signature, ...extra };
+}
+
+describe("collectRevisionSymbols", () => {
+  it("should keep members, skip body locals and fall back to the declaration line", () => {
+    const content = [
+      "export class Client {",
+      "  close(force: boolean): void {",
+      "    const pending = 1;",
+      "  }",
+      "}",
+    ].join("\n");
+    const symbols: DocumentSymbol[] = [
+      {
+        name: "Client",
+        kind: SymbolKind.Class,
+        range: range(0),
+        selectionRange: range(0),
+        children: [
+          {
+            name: "close",
+            kind: SymbolKind.Method,
+            range: range(1),
+            selectionRange: range(1),
+            children: [
+              {
+                name: "pending",
+                kind: SymbolKind.Variable,
+                range: range(2),
+                selectionRange: range(2),
+              },
+            ],
+          },
+        ],
+      },
+    ];
+
+    expect(collectRevisionSymbols("src/client.ts", content, symbols)).toEqual([
+      {
+        file: "src/client.ts",
+        name: "Client",
+        kind: SymbolKind.Class,
+        containerName: undefined,
+        line: 1,
+        signature: "export class Client",
+      },
+      {
+        file: "src/client.ts",
+        name: "close",
+        kind: SymbolKind.Method,
+        containerName: "Client",
+        line: 2,
+        signature: "close(force: boolean): void",
+      },
+    ]);
+  });
+
+  it("should prefer the detail the server reports", () => {
+    const symbols: DocumentSymbol[] = [
+      {
+        name: "Dial",
+        kind: SymbolKind.Function,
+        detail: "func(addr string) (*Client, error)",
+        range: range(0),
+        selectionRange: range(0),
+      },
+    ];
+
+    const [dial] = collectRevisionSymbols("client.go", "func Dial(", symbols);
+
+    expect(dial.signature).toBe("func(addr string) (*Client, error)");
+  });
+});
+
+describe("diffRevisionSymbols", () => {
+  it("should report added, removed and signature-changed symbols", () => {
+    const diff = diffRevisionSymbols(
+      [
+        symbol("Dial", SymbolKind.Function, "func(addr string) *Client"),
+        symbol("Close", SymbolKind.Method, "func() error", {
+          containerName: "Client",
+        }),
+        symbol("legacy", SymbolKind.Function, "func()"),
+      ],
+      [
+        symbol("Dial", SymbolKind.Function, "func(ctx Context) *Client"),
+        symbol("Close", SymbolKind.Method, "func() error", {
+          containerName: "Client",
+          line: 12,
+        }),
+        symbol("Option", SymbolKind.Struct, "struct{...}"),
+      ],
+    );
+
+    expect(diff.added.map((s) => s.name)).toEqual(["Option"]);
+    expect(diff.removed.map((s) => s.name)).toEqual(["legacy"]);
+    expect(diff.changed).toEqual([
+      {
+        before: expect.objectContaining({
+          signature: "func(addr string) *Client",
+        }),
+        after: expect.objectContaining({
+          signature: "func(ctx Context) *Client",
+        }),
+      },
+    ]);
+  });
+
+  it("should match overloads by signature before pairing the rest", () => {
+    const diff = diffRevisionSymbols(
+      [
+        symbol("parse", SymbolKind.Function, "parse(s: string): Ast"),
+        symbol("parse", SymbolKind.Function, "parse(b: Buffer): Ast"),
+      ],
+      [
+        symbol("parse", SymbolKind.Function, "parse(b: Buffer): Ast"),
+        symbol(
+          "parse",
+          SymbolKind.Function,
+          "parse(s: string, o: Options): Ast",
+        ),
+        symbol("parse", SymbolKind.Function, "parse(u: URL): Ast"),
+      ],
+    );
+
+    expect(diff.changed).toHaveLength(1);
+    expect(diff.changed[0].before.signature).toBe("parse(s: string): Ast");
+    expect(diff.added.map((s) => s.signature)).toEqual(["parse(u: URL): Ast"]);
+    expect(diff.removed).toEqual([]);
+  });
+});
+
+describe("formatSymbolDiff", () => {
+  it("should list changes by section", () => {
+    const output = formatSymbolDiff(
+      {
+        added: [symbol("Option", SymbolKind.Struct, "struct{...}")],
+        removed: [],
+        changed: [
+          {
+            before: symbol("Dial", SymbolKind.Function, "func(addr string)"),
+            after: symbol("Dial", SymbolKind.Function, "func(ctx Context)", {
+              line: 8,
+            }),
+          },
+        ],
+      },
+      { base: "main", files: 2, skipped: 0 },
+    );
+
+    expect(output).toBe(
+      [
+        "Symbol changes from main to the working tree: 1 added, 0 removed, 1 changed (2 changed files compared)",
+        "",
+        "Added:",
+        "  + pkg/client.go:1 Struct Option: struct{...}",
+        "",
+        "Signature changed:",
+        "  ~ pkg/client.go:8 Function Dial",
+        "      - func(addr string)",
+        "      + func(ctx Context)",
+      ].join("\n"),
+    );
+  });
+
+  it("should say when nothing changed and when files were skipped", () => {
+    const output = formatSymbolDiff(
+      { added: [], removed: [], changed: [] },
+      { base: "v1.2.0", head: "v1.3.0", files: 200, skipped: 5 },
+    );
+
+    expect(output).toContain(
+      "No symbol changes from v1.2.0 to v1.3.0 (200 changed files compared)",
+    );
+    expect(output).toContain("5 more changed files were not compared");
+  });
+});
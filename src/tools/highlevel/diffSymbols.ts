@@ -0,0 +1,388 @@
+/**
+ * High-level tool comparing the symbols of two git revisions
+ * Reads the files that differ between the revisions (or a revision and the
+ * working tree) with git, asks the language server for their document
+ * symbols and reports symbols that were added, removed or whose signature
+ * changed: an API-change summary for a branch
+ */
+
+import { z } from "zod";
+import { readFile } from "fs/promises";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import type {
+  DocumentSymbol,
+  McpToolDef,
+  SymbolInformation,
+} from "@internal/types";
+import { SymbolKind, getSymbolKindName } from "@internal/types";
+import type { LSPClient } from "@internal/lsp-client";
+import { getLanguageIdFromPath } from "@internal/lsp-client";
+import {
+  IgnoreRules,
+  getChangedFilesBetweenAsync,
+  getFileAtRevisionAsync,
+  isExportedSymbol,
+  loadIndexConfig,
+} from "@internal/code-indexer";
+import { debugLogWithPrefix } from "../../utils/debugLog.ts";
+import { matchesFileFilter } from "../../utils/symbolQueryParser.ts";
+
+const DEFAULT_MAX_FILES = 200;
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project").optional(),
+  base: z
+    .string()
+    .optional()
+    .default("HEAD")
+    .describe("Revision to compare from: commit, branch or tag"),
+  head: z
+    .string()
+    .optional()
+    .describe(
+      "Revision to compare to; omitted compares the working tree, including uncommitted and untracked files",
+    ),
+  file: z
+    .string()
+    .optional()
+    .describe(
+      "Only compare matching files (substring or glob, relative to root)",
+    ),
+  exportedOnly: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe("Only report exported symbols (the public API)"),
+  maxFiles: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .default(DEFAULT_MAX_FILES)
+    .describe("Most changed files to compare"),
+});
+
+/** Kinds whose children are locals of a body rather than members */
+const BODY_KINDS = new Set<SymbolKind>([
+  SymbolKind.Function,
+  SymbolKind.Method,
+  SymbolKind.Constructor,
+]);
+
+export interface RevisionSymbol {
+  file: string;
+  name: string;
+  kind: SymbolKind;
+  containerName?: string;
+  /** 1-based line of the declaration */
+  line: number;
+  /** Symbol detail from the server, or the declaration line */
+  signature: string;
+}
+
+export interface SignatureChange {
+  before: RevisionSymbol;
+  after: RevisionSymbol;
+}
+
+export interface SymbolDiff {
+  added: RevisionSymbol[];
+  removed: RevisionSymbol[];
+  changed: SignatureChange[];
+}
+
+function declarationLine(lines: string[], line: number): string {
+  return (lines[line] ?? "").trim().replace(/\s*\{\s*$/, "");
+}
+
+/**
+ * Flatten document symbols into declarations with their signature; locals
+ * inside function bodies are skipped
+ */
+export function collectRevisionSymbols(
+  file: string,
+  content: string,
+  symbols: (DocumentSymbol | SymbolInformation)[],
+): RevisionSymbol[] {
+  const lines = content.split("\n");
+  const result: RevisionSymbol[] = [];
+
+  const visit = (
+    items: (DocumentSymbol | SymbolInformation)[],
+    containerName?: string,
+  ) => {
+    for (const symbol of items) {
+      if ("location" in symbol && symbol.location) {
+        const line = symbol.location.range.start.line;
+        result.push({
+          file,
+          name: symbol.name,
+          kind: symbol.kind,
+          containerName: symbol.containerName || undefined,
+          line: line + 1,
+          signature: declarationLine(lines, line),
+        });
+        continue;
+      }
+
+      const documentSymbol = symbol as DocumentSymbol;
+      const line = (documentSymbol.selectionRange ?? documentSymbol.range)
+        .start.line;
+      result.push({
+        file,
+        name: documentSymbol.name,
+        kind: documentSymbol.kind,
+        containerName,
+        line: line + 1,
+        signature:
+          documentSymbol.detail?.trim() || declarationLine(lines, line),
+      });
+      if (documentSymbol.children && !BODY_KINDS.has(documentSymbol.kind)) {
+        const path = containerName
+          ? `${containerName}.${documentSymbol.name}`
+          : documentSymbol.name;
+        visit(documentSymbol.children, path);
+      }
+    }
+  };
+
+  visit(symbols);
+  return result;
+}
+
+function qualifiedName(symbol: RevisionSymbol): string {
+  return symbol.containerName
+    ? `${symbol.containerName}.${symbol.name}`
+    : symbol.name;
+}
+
+function symbolKey(symbol: RevisionSymbol): string {
+  return `${symbol.file}\0${symbol.kind}\0${qualifiedName(symbol)}`;
+}
+
+function groupByKey(
+  symbols: RevisionSymbol[],
+): Map<string, RevisionSymbol[]> {
+  const groups = new Map<string, RevisionSymbol[]>();
+  for (const symbol of symbols) {
+    const key = symbolKey(symbol);
+    groups.set(key, [...(groups.get(key) ?? []), symbol]);
+  }
+  return groups;
+}
+
+/**
+ * Compare symbols by file, kind and qualified name. Overloads sharing a
+ * name are matched by signature first; the ones left over are paired up as
+ * signature changes, and the rest are added or removed.
+ */
+export function diffRevisionSymbols(
+  before: RevisionSymbol[],
+  after: RevisionSymbol[],
+): SymbolDiff {
+  const diff: SymbolDiff = { added: [], removed: [], changed: [] };
+  const beforeGroups = groupByKey(before);
+  const afterGroups = groupByKey(after);
+
+  for (const key of new Set([...beforeGroups.keys(), ...afterGroups.keys()])) {
+    const signatures = new Set(
+      (afterGroups.get(key) ?? []).map((symbol) => symbol.signature),
+    );
+    const previous = new Set(
+      (beforeGroups.get(key) ?? []).map((symbol) => symbol.signature),
+    );
+    const removed = (beforeGroups.get(key) ?? []).filter(
+      (symbol) => !signatures.has(symbol.signature),
+    );
+    const added = (afterGroups.get(key) ?? []).filter(
+      (symbol) => !previous.has(symbol.signature),
+    );
+
+    const pairs = Math.min(removed.length, added.length);
+    for (let i = 0; i < pairs; i++) {
+      diff.changed.push({ before: removed[i], after: added[i] });
+    }
+    diff.removed.push(...removed.slice(pairs));
+    diff.added.push(...added.slice(pairs));
+  }
+
+  const byLocation = (a: RevisionSymbol, b: RevisionSymbol) =>
+    a.file.localeCompare(b.file) || a.line - b.line;
+  diff.added.sort(byLocation);
+  diff.removed.sort(byLocation);
+  diff.changed.sort((a, b) => byLocation(a.after, b.after));
+  return diff;
+}
+
+function describeSymbol(symbol: RevisionSymbol): string {
+  return `${symbol.file}:${symbol.line} ${getSymbolKindName(symbol.kind)} ${qualifiedName(symbol)}`;
+}
+
+export function formatSymbolDiff(
+  diff: SymbolDiff,
+  options: { base: string; head?: string; files: number; skipped: number },
+): string {
+  const range = `${options.base} to ${options.head ?? "the working tree"}`;
+  const total = diff.added.length + diff.removed.length + diff.changed.length;
+  const lines: string[] = [];
+
+  if (total === 0) {
+    lines.push(
+      `No symbol changes from ${range} (${options.files} changed files compared)`,
+    );
+  } else {
+    lines.push(
+      `Symbol changes from ${range}: ${diff.added.length} added, ${diff.removed.length} removed, ${diff.changed.length} changed (${options.files} changed files compared)`,
+    );
+  }
+
+  if (diff.added.length > 0) {
+    lines.push(
+      "",
+      "Added:",
+      ...diff.added.map(
+        (symbol) => `  + ${describeSymbol(symbol)}: ${symbol.signature}`,
+      ),
+    );
+  }
+  if (diff.removed.length > 0) {
+    lines.push(
+      "",
+      "Removed:",
+      ...diff.removed.map(
+        (symbol) => `  - ${describeSymbol(symbol)}: ${symbol.signature}`,
+      ),
+    );
+  }
+  if (diff.changed.length > 0) {
+    lines.push("", "Signature changed:");
+    for (const { before, after } of diff.changed) {
+      lines.push(
+        `  ~ ${describeSymbol(after)}`,
+        `      - ${before.signature}`,
+        `      + ${after.signature}`,
+      );
+    }
+  }
+
+  if (options.skipped > 0) {
+    lines.push(
+      "",
+      `${options.skipped} more changed files were not compared; narrow with file or raise maxFiles`,
+    );
+  }
+  return lines.join("\n");
+}
+
+/**
+ * Create diff_symbols tool with injected LSP client
+ */
+export function createDiffSymbolsTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  // Symbols of one side of a file, from a document under a URI of its own
+  // so documents open at the real path are left alone
+  const getSymbols = async (
+    rootPath: string,
+    label: string,
+    file: string,
+    content: string,
+  ): Promise<RevisionSymbol[]> => {
+    const uri = pathToFileURL(
+      join(rootPath, ".lsmcp", "revisions", label, file),
+    ).toString();
+    client.openDocument(uri, content, getLanguageIdFromPath(file) ?? undefined);
+    try {
+      const symbols = (await client.getDocumentSymbols(uri)) ?? [];
+      return collectRevisionSymbols(file, content, symbols);
+    } finally {
+      client.closeDocument(uri);
+    }
+  };
+
+  return {
+    name: "diff_symbols",
+    description:
+      "Compare the symbols of two git revisions, or of a revision and the working tree, and report symbols that were added, removed or whose signature changed. " +
+      "Only files that differ are read (with git, without checking anything out) and parsed by the language server, so it gives an instant API-change summary for a branch, " +
+      "e.g. base: 'main' for everything the current branch changed.",
+    schema,
+    execute: async ({ root, base, head, file, exportedOnly, maxFiles }) => {
+      if (!client) {
+        throw new Error("LSP client not initialized");
+      }
+      const rootPath = root || process.cwd();
+
+      const changed = await getChangedFilesBetweenAsync(rootPath, base, head);
+      if (changed.isErr()) {
+        return `Error: ${changed.error.message}`;
+      }
+
+      const rules = new IgnoreRules(rootPath, {
+        patterns: loadIndexConfig(rootPath).ignorePatterns,
+      });
+      const candidates = changed.value
+        .filter((path) => getLanguageIdFromPath(path) !== null)
+        .filter((path) => !rules.isIgnored(path))
+        .filter((path) => !file || matchesFileFilter(path, file))
+        .sort();
+      const files = candidates.slice(0, maxFiles);
+
+      const label = (revision?: string) =>
+        revision ? revision.replace(/[^\w.-]+/g, "_") : "worktree";
+      const read = async (
+        revision: string | undefined,
+        path: string,
+      ): Promise<string | null> => {
+        if (revision === undefined) {
+          return readFile(join(rootPath, path), "utf-8").catch(() => null);
+        }
+        const result = await getFileAtRevisionAsync(rootPath, revision, path);
+        if (result.isErr()) {
+          throw new Error(result.error.message);
+        }
+        return result.value;
+      };
+
+      const before: RevisionSymbol[] = [];
+      const after: RevisionSymbol[] = [];
+      for (const path of files) {
+        try {
+          const [oldContent, newContent] = await Promise.all([
+            read(base, path),
+            read(head, path),
+          ]);
+          if (oldContent !== null) {
+            before.push(
+              ...(await getSymbols(rootPath, label(base), path, oldContent)),
+            );
+          }
+          if (newContent !== null) {
+            after.push(
+              ...(await getSymbols(rootPath, label(head), path, newContent)),
+            );
+          }
+        } catch (error) {
+          debugLogWithPrefix(
+            "diff_symbols",
+            `Failed to compare ${path}: ${error instanceof Error ? error.message : String(error)}`,
+          );
+        }
+      }
+
+      const keep = (symbol: RevisionSymbol) =>
+        !exportedOnly || isExportedSymbol(symbol);
+      const diff = diffRevisionSymbols(
+        before.filter(keep),
+        after.filter(keep),
+      );
+      return formatSymbolDiff(diff, {
+        base,
+        head,
+        files: files.length,
+        skipped: candidates.length - files.length,
+      });
+    },
+  };
+}
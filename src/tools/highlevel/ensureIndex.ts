@@ -0,0 +1,113 @@
+/**
+ * Building the symbol index of a root on first use
+ * Tools that read the index call ensureIndex before querying it
+ */
+
+import type { McpContext } from "@internal/types";
+import {
+  getIndexStats,
+  getOrCreateIndex,
+  loadIndexConfig,
+  updateIndexIncremental,
+} from "@internal/code-indexer";
+import { debugLogWithPrefix } from "../../utils/debugLog.ts";
+import { createIndexProgressHandler } from "../../utils/progress.ts";
+import { findFilesToIndex, resolveIndexPattern } from "./indexPatterns.ts";
+
+/**
+ * Create the index for a root on first use, or bring it up to date.
+ * Returns an error message when the index cannot be built.
+ */
+export async function ensureIndex(
+  rootPath: string,
+  context?: McpContext,
+): Promise<string | undefined> {
+  // Get index stats first
+  const stats = getIndexStats(rootPath);
+  if (stats.totalFiles === 0) {
+    // Auto-create index if it doesn't exist
+    debugLogWithPrefix(
+      "search_symbol_from_index",
+      "No index found. Creating initial index...",
+    );
+
+    // Check if LSP client is initialized
+    // Get or create index
+    // Pass context which includes fs (FileSystemApi) and lspClient
+    const index = getOrCreateIndex(rootPath, context);
+    if (!index) {
+      return `Error: Failed to create symbol index. LSP client may not be properly initialized.`;
+    }
+
+    // Determine pattern for initial indexing
+    const config = loadIndexConfig(rootPath);
+    const resolved = resolveIndexPattern(rootPath, context);
+    if ("error" in resolved) {
+      return resolved.error;
+    }
+    const pattern = resolved.pattern;
+
+    // Determine concurrency
+    const concurrency = config?.settings?.indexConcurrency || 5;
+
+    // Find files to index
+    const files = await findFilesToIndex(rootPath, pattern);
+
+    if (files.length === 0) {
+      return `No files found matching pattern: ${pattern}`;
+    }
+
+    debugLogWithPrefix(
+      "search_symbol_from_index",
+      `Indexing ${files.length} files...`,
+    );
+
+    // Perform initial indexing
+    const startTime = Date.now();
+    const reportIndexProgress = createIndexProgressHandler(context);
+    await index.indexFiles(files, concurrency, {
+      onProgress: (progress) => {
+        if (
+          progress.current % 10 === 0 ||
+          progress.current === progress.total
+        ) {
+          debugLogWithPrefix(
+            "search_symbol_from_index",
+            `Progress: ${progress.current}/${progress.total} files`,
+          );
+        }
+        reportIndexProgress?.(progress);
+      },
+    });
+
+    const stats = index.getStats();
+    const duration = Date.now() - startTime;
+    debugLogWithPrefix(
+      "search_symbol_from_index",
+      `Initial indexing completed: ${stats.totalFiles} files, ${stats.totalSymbols} symbols in ${duration}ms`,
+    );
+  } else {
+    // Auto-update index with incremental changes if it already exists
+    try {
+      // Always pass context (which may be undefined)
+      const updateResult = await updateIndexIncremental(rootPath, context);
+      if (updateResult.success) {
+        const updatedCount = updateResult.updated.length;
+        const removedCount = updateResult.removed.length;
+        if (updatedCount > 0 || removedCount > 0) {
+          debugLogWithPrefix(
+            "search_symbol_from_index",
+            `Auto-updated index: ${updatedCount} files updated, ${removedCount} files removed`,
+          );
+        }
+      }
+    } catch (error) {
+      // Log error but continue with search
+      debugLogWithPrefix(
+        "search_symbol_from_index",
+        `Failed to auto-update index: ${error}`,
+      );
+    }
+  }
+  return undefined;
+}
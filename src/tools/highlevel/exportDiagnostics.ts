@@ -0,0 +1,120 @@
+/**
+ * High-level tool for exporting workspace diagnostics
+ * Collects the same diagnostics as get_project_diagnostics and returns them
+ * as a SARIF 2.1.0 log (GitHub code scanning), plain JSON or a diagnostics
+ * baseline
+ */
+
+import { z } from "zod";
+import type { McpToolDef, McpContext } from "@internal/types";
+import type { LSPClient } from "@internal/lsp-client";
+import { getProjectDiagnosticsImpl } from "./projectDiagnostics.ts";
+import { toSarif } from "../../utils/sarif.ts";
+import { createBaseline } from "../../utils/diagnosticsBaseline.ts";
+
+export const EXPORT_FORMATS = ["sarif", "json", "baseline"] as const;
+
+export type ExportFormat = (typeof EXPORT_FORMATS)[number];
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project"),
+  format: z
+    .enum(EXPORT_FORMATS)
+    .optional()
+    .default("sarif")
+    .describe(
+      "sarif: SARIF 2.1.0 for GitHub code scanning; json: files with their diagnostics; baseline: contents for .lsmcp/diagnostics-baseline.json",
+    ),
+  pattern: z
+    .string()
+    .optional()
+    .describe(
+      "Glob pattern for files to check when the symbol index is empty (defaults to config files)",
+    ),
+  severityFilter: z
+    .enum(["error", "warning", "all"])
+    .optional()
+    .default("all")
+    .describe("Filter diagnostics by severity"),
+  includeBaseline: z
+    .boolean()
+    .optional()
+    .describe(
+      "Also export diagnostics recorded in the baseline (always on for format: baseline)",
+    ),
+});
+
+type ExportDiagnosticsRequest = z.infer<typeof schema>;
+
+export interface ExportDiagnosticsOptions {
+  /** Per-file wait for the server, see collectDiagnosticsForFiles */
+  waitTimeout?: number;
+}
+
+export interface ExportedDiagnostics {
+  /** The SARIF, JSON or baseline document */
+  text: string;
+  totalErrors: number;
+  totalWarnings: number;
+  totalFiles: number;
+}
+
+/**
+ * Collect every diagnostic of the workspace and serialize it
+ */
+export async function exportDiagnostics(
+  request: ExportDiagnosticsRequest,
+  client: LSPClient,
+  context?: McpContext,
+  options: ExportDiagnosticsOptions = {},
+): Promise<ExportedDiagnostics> {
+  const result = await getProjectDiagnosticsImpl(
+    {
+      root: request.root,
+      pattern: request.pattern,
+      severityFilter: request.severityFilter,
+      // A new baseline records everything, including the old one
+      includeBaseline: request.includeBaseline || request.format === "baseline",
+    },
+    client,
+    context,
+    { waitTimeout: options.waitTimeout },
+  );
+  let document: unknown;
+  if (request.format === "sarif") {
+    document = toSarif(result.files, { root: request.root });
+  } else if (request.format === "baseline") {
+    document = createBaseline(result.files);
+  } else {
+    document = {
+      totalErrors: result.totalErrors,
+      totalWarnings: result.totalWarnings,
+      files: result.files,
+    };
+  }
+  return {
+    text: JSON.stringify(document, null, 2),
+    totalErrors: result.totalErrors,
+    totalWarnings: result.totalWarnings,
+    totalFiles: result.files.length,
+  };
+}
+
+/**
+ * Create export_diagnostics tool with injected LSP client
+ */
+export function createExportDiagnosticsTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "export_diagnostics",
+    description:
+      "Export the diagnostics of every indexed file as a SARIF 2.1.0 log for GitHub code scanning (format: sarif), as JSON (format: json) or as a diagnostics baseline (format: baseline). " +
+      "Returns the document as text; run `lsmcp export-diagnostics` in CI, or `lsmcp baseline` to record the baseline, to write it to a file instead.",
+    schema,
+    execute: async (args, context?: McpContext) => {
+      const exported = await exportDiagnostics(args, client, context);
+      return exported.text;
+    },
+  };
+}
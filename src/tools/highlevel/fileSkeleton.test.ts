@@ -0,0 +1,161 @@
+import { describe, it, expect, vi } from "vitest";
+import { mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { SymbolKind } from "vscode-languageserver-types";
+import { nodeFileSystemApi } from "../../infrastructure/NodeFileSystemApi.ts";
+import { buildSkeleton, createReadFileSkeletonTool } from "./fileSkeleton.ts";
+
+const GO_SOURCE = `package main
+
+import "fmt"
+
+// Greeter greets people.
+type Greeter struct {
+	Name string
+}
+
+// Greet returns a greeting.
+func (g *Greeter) Greet() string {
+	msg := "hi " + g.Name
+	return msg
+}
+
+func main() {
+	fmt.Println(Greeter{}.Greet())
+}
+`;
+
+function range(startLine: number, endLine: number) {
+  return {
+    start: { line: startLine, character: 0 },
+    end: { line: endLine, character: 1 },
+  };
+}
+
+const GO_SYMBOLS = [
+  {
+    name: "Greeter",
+    kind: SymbolKind.Struct,
+    range: range(5, 7),
+    selectionRange: range(5, 5),
+    children: [
+      {
+        name: "Name",
+        kind: SymbolKind.Field,
+        range: range(6, 6),
+        selectionRange: range(6, 6),
+      },
+    ],
+  },
+  {
+    name: "(*Greeter).Greet",
+    kind: SymbolKind.Method,
+    range: range(10, 13),
+    selectionRange: range(10, 10),
+  },
+  {
+    name: "main",
+    kind: SymbolKind.Function,
+    range: range(15, 17),
+    selectionRange: range(15, 15),
+  },
+];
+
+const GO_SKELETON = `package main
+
+import "fmt"
+
+// Greeter greets people.
+type Greeter struct {
+	Name string
+}
+
+// Greet returns a greeting.
+func (g *Greeter) Greet() string {
+	... lines 12-13 elided
+}
+
+func main() {
+	... line 17 elided
+}`;
+
+describe("buildSkeleton", () => {
+  it("should elide bodies using folding ranges", () => {
+    const skeleton = buildSkeleton(GO_SOURCE, GO_SYMBOLS, [
+      { startLine: 2, endLine: 2, kind: "imports" },
+      { startLine: 5, endLine: 6 },
+      { startLine: 10, endLine: 12 },
+      { startLine: 15, endLine: 16 },
+    ]);
+
+    expect(skeleton.text).toBe(GO_SKELETON);
+    expect(skeleton.elidedLines).toBe(3);
+    expect(skeleton.totalLines).toBe(18);
+  });
+
+  it("should find bodies from braces without folding ranges", () => {
+    expect(buildSkeleton(GO_SOURCE, GO_SYMBOLS, []).text).toBe(GO_SKELETON);
+  });
+
+  it("should keep Python docstrings", () => {
+    const source = `def add(a, b):
+    """Add two numbers."""
+    total = a + b
+    return total
+`;
+    const symbols = [
+      {
+        name: "add",
+        kind: SymbolKind.Function,
+        range: range(0, 3),
+        selectionRange: range(0, 0),
+      },
+    ];
+
+    expect(buildSkeleton(source, symbols, []).text).toBe(
+      `def add(a, b):
+    """Add two numbers."""
+    ... lines 3-4 elided`,
+    );
+  });
+
+  it("should prefix file lines with their number", () => {
+    const { text } = buildSkeleton(GO_SOURCE, GO_SYMBOLS, [], {
+      lineNumbers: true,
+    });
+
+    expect(text.split("\n").slice(10, 13)).toEqual([
+      "11  func (g *Greeter) Greet() string {",
+      "    \t... lines 12-13 elided",
+      "14  }",
+    ]);
+  });
+});
+
+describe("read_file_skeleton", () => {
+  it("should ask the server for symbols and folding ranges", async () => {
+    const root = mkdtempSync(join(tmpdir(), "lsmcp-skeleton-"));
+    writeFileSync(join(root, "main.go"), GO_SOURCE);
+    const client = {
+      fileSystemApi: nodeFileSystemApi,
+      openDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      getDocumentSymbols: vi.fn(async () => GO_SYMBOLS),
+      getFoldingRanges: vi.fn(async () => [{ startLine: 10, endLine: 12 }]),
+      getServerCapabilities: () => ({ foldingRangeProvider: true }),
+    };
+
+    const result = await createReadFileSkeletonTool(client as any).execute({
+      root,
+      relativePath: "main.go",
+      lineNumbers: false,
+    });
+
+    expect(result).toBe(
+      `Skeleton of main.go: 3 of 18 lines elided\n\n${GO_SKELETON}`,
+    );
+    expect(client.getFoldingRanges).toHaveBeenCalledOnce();
+    expect(client.closeDocument).toHaveBeenCalledOnce();
+  });
+});
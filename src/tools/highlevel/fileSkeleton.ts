@@ -0,0 +1,271 @@
+/**
+ * High-level tool returning the API shape of a file
+ * Keeps the package clause, imports, type declarations, signatures and doc
+ * comments and elides function bodies, located with document symbols and
+ * folding ranges
+ */
+
+import { z } from "zod";
+import type { LSPClient } from "@internal/lsp-client";
+import { loadFileContext, withTemporaryDocument } from "@internal/lsp-client";
+import type {
+  DocumentSymbol,
+  FoldingRange,
+  McpToolDef,
+  SymbolInformation,
+} from "@internal/types";
+import { SymbolKind } from "vscode-languageserver-types";
+import { debugLogWithPrefix } from "../../utils/debugLog.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z
+    .string()
+    .describe("File path to summarize (relative to root)"),
+  lineNumbers: z
+    .boolean()
+    .optional()
+    .default(true)
+    .describe(
+      "Prefix lines with their line number so elided ranges can be read or edited afterwards",
+    ),
+});
+
+/** Symbols whose bodies are elided */
+const BODY_KINDS = new Set<SymbolKind>([
+  SymbolKind.Function,
+  SymbolKind.Method,
+  SymbolKind.Constructor,
+]);
+
+interface BodySymbol {
+  /** Line of the symbol name */
+  nameLine: number;
+  startLine: number;
+  endLine: number;
+}
+
+/** 0-based inclusive line range */
+export interface ElidedLines {
+  start: number;
+  end: number;
+}
+
+function collectBodySymbols(
+  symbols: (DocumentSymbol | SymbolInformation)[],
+  result: BodySymbol[] = [],
+): BodySymbol[] {
+  for (const symbol of symbols) {
+    if ("location" in symbol && symbol.location) {
+      const { range } = symbol.location;
+      if (BODY_KINDS.has(symbol.kind)) {
+        result.push({
+          nameLine: range.start.line,
+          startLine: range.start.line,
+          endLine: range.end.line,
+        });
+      }
+      continue;
+    }
+    const documentSymbol = symbol as DocumentSymbol;
+    if (BODY_KINDS.has(documentSymbol.kind)) {
+      const name = documentSymbol.selectionRange ?? documentSymbol.range;
+      result.push({
+        nameLine: name.start.line,
+        startLine: documentSymbol.range.start.line,
+        endLine: documentSymbol.range.end.line,
+      });
+    } else if (documentSymbol.children) {
+      // Methods of classes, structs and interfaces
+      collectBodySymbols(documentSymbol.children, result);
+    }
+  }
+  return result;
+}
+
+/**
+ * Skip a leading Python docstring so it stays in the skeleton
+ */
+function skipDocstring(lines: string[], start: number, end: number): number {
+  const first = lines[start]?.trim() ?? "";
+  const quote = first.slice(0, 3);
+  if (quote !== '"""' && quote !== "'''") {
+    return start;
+  }
+  if (first.length > 3 && first.slice(3).includes(quote)) {
+    return start + 1;
+  }
+  for (let line = start + 1; line <= end; line++) {
+    if (lines[line].includes(quote)) {
+      return line + 1;
+    }
+  }
+  return start;
+}
+
+/**
+ * Lines of a function body: after the line opening the body up to the line
+ * before a closing brace. The body is the largest folding range inside the
+ * symbol; without folding ranges it opens at the first `{` or trailing `:`
+ * after the name.
+ */
+export function findBodyLines(
+  lines: string[],
+  symbol: BodySymbol,
+  foldingRanges: FoldingRange[],
+): ElidedLines | undefined {
+  let open: number | undefined;
+  let best: FoldingRange | undefined;
+  for (const range of foldingRanges) {
+    if (
+      range.kind !== "comment" &&
+      range.kind !== "imports" &&
+      range.startLine >= symbol.nameLine &&
+      range.endLine <= symbol.endLine &&
+      (!best ||
+        range.endLine - range.startLine > best.endLine - best.startLine)
+    ) {
+      best = range;
+    }
+  }
+  if (best) {
+    open = best.startLine;
+  } else {
+    for (let line = symbol.nameLine; line <= symbol.endLine; line++) {
+      const text = lines[line] ?? "";
+      if (text.includes("{") || /:\s*(#.*)?$/.test(text)) {
+        open = line;
+        break;
+      }
+    }
+  }
+  if (open === undefined) {
+    return undefined;
+  }
+
+  const closes = /^\s*[}\])]/.test(lines[symbol.endLine] ?? "");
+  const end = Math.min(
+    best ? best.endLine : symbol.endLine,
+    closes ? symbol.endLine - 1 : symbol.endLine,
+  );
+  const start = skipDocstring(lines, open + 1, end);
+  return start <= end ? { start, end } : undefined;
+}
+
+/**
+ * Render the file with function bodies replaced by a marker naming the
+ * elided lines (1-based)
+ */
+export function buildSkeleton(
+  content: string,
+  symbols: (DocumentSymbol | SymbolInformation)[],
+  foldingRanges: FoldingRange[],
+  options: { lineNumbers?: boolean } = {},
+): { text: string; elidedLines: number; totalLines: number } {
+  const lines = content.split("\n");
+  if (lines.length > 1 && lines[lines.length - 1] === "") {
+    lines.pop();
+  }
+
+  // Outermost bodies only; nested functions disappear with their parent
+  const bodies = collectBodySymbols(symbols)
+    .map((symbol) => findBodyLines(lines, symbol, foldingRanges))
+    .filter((body): body is ElidedLines => body !== undefined)
+    .sort((a, b) => a.start - b.start || b.end - a.end);
+  const elided: ElidedLines[] = [];
+  for (const body of bodies) {
+    const last = elided[elided.length - 1];
+    if (!last || body.start > last.end) {
+      elided.push(body);
+    }
+  }
+
+  // Markers get a blank number column so they never look like file lines
+  const width = String(lines.length).length;
+  const prefix = (line?: number) => {
+    if (!options.lineNumbers) {
+      return "";
+    }
+    const number = line === undefined ? "" : String(line + 1);
+    return `${number.padStart(width)}  `;
+  };
+
+  const output: string[] = [];
+  let elidedLines = 0;
+  let next = 0;
+  for (let line = 0; line < lines.length; line++) {
+    const body = elided[next];
+    if (body && line === body.start) {
+      const indent = /^\s*/.exec(lines[line])![0];
+      const count = body.end - body.start + 1;
+      const range =
+        count === 1
+          ? `line ${body.start + 1}`
+          : `lines ${body.start + 1}-${body.end + 1}`;
+      output.push(`${prefix()}${indent}... ${range} elided`);
+      elidedLines += count;
+      line = body.end;
+      next++;
+      continue;
+    }
+    output.push(`${prefix(line)}${lines[line]}`);
+  }
+
+  return {
+    text: output.join("\n"),
+    elidedLines,
+    totalLines: lines.length,
+  };
+}
+
+/**
+ * Create read_file_skeleton tool with injected LSP client
+ */
+export function createReadFileSkeletonTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "read_file_skeleton",
+    description:
+      "Read a file as its API shape: package clause, imports, type declarations, and function signatures with doc comments, with function bodies elided. " +
+      "Uses document symbols and folding ranges from the language server; elided line ranges are named so they can be read separately.",
+    schema,
+    execute: async ({ root, relativePath, lineNumbers }) => {
+      if (!client) {
+        throw new Error("LSP client not initialized");
+      }
+      const { fileUri, content } = await loadFileContext(
+        root,
+        relativePath,
+        client.fileSystemApi,
+      );
+
+      return withTemporaryDocument(client, fileUri, content, async () => {
+        const symbols = (await client.getDocumentSymbols(fileUri)) ?? [];
+        let foldingRanges: FoldingRange[] = [];
+        if (
+          client.getFoldingRanges &&
+          client.getServerCapabilities()?.foldingRangeProvider
+        ) {
+          try {
+            foldingRanges = await client.getFoldingRanges(fileUri);
+          } catch (error) {
+            debugLogWithPrefix(
+              "read_file_skeleton",
+              `foldingRange failed for ${relativePath}: ${error}`,
+            );
+          }
+        }
+
+        const skeleton = buildSkeleton(content, symbols, foldingRanges, {
+          lineNumbers,
+        });
+        return [
+          `Skeleton of ${relativePath}: ${skeleton.elidedLines} of ${skeleton.totalLines} lines elided`,
+          "",
+          skeleton.text,
+        ].join("\n");
+      });
+    },
+  };
+}
@@ -12,6 +12,10 @@ import { readFile } from "fs/promises";
 import { existsSync } from "fs";
 import { withLSPOperation, resolveLineParameter } from "@internal/lsp-client";
 import { findSymbolInLine } from "../../features/ts/utils/findSymbolInLine.ts";
+import {
+  summarizeDocumentation,
+  summaryNote,
+} from "../../utils/documentationSummaries.ts";
 
 const schema = z.object({
   root: z.string().describe("Root directory for the project").optional(),
@@ -405,7 +409,18 @@ export function createGetSymbolDetailsTool(
     schema,
     execute: async (args, context?: McpContext) => {
       const details = await getSymbolDetailsImpl(args, client, context);
-      return formatSymbolDetails(details);
+      let note = "";
+      if (details.hover?.documentation) {
+        const summary = await summarizeDocumentation(
+          details.hover.documentation,
+          `"${args.symbol}" in ${args.relativePath}`,
+          context,
+        );
+        details.hover.documentation = summary.text;
+        note = summaryNote(summary);
+      }
+      const output = formatSymbolDetails(details);
+      return note ? `${output}\n${note}` : output;
     },
   };
 }
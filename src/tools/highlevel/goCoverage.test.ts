@@ -0,0 +1,130 @@
+import { describe, it, expect, vi } from "vitest";
+import { pathToFileURL } from "url";
+import { join } from "path";
+import { SymbolKind } from "vscode-languageserver-types";
+
+vi.mock("@internal/code-indexer", () => ({
+  querySymbols: vi.fn(),
+}));
+
+import {
+  computeSymbolCoverage,
+  formatCoverageReport,
+  isGoExported,
+  parseCoverProfile,
+  profileFileToRelativePath,
+} from "./goCoverage.ts";
+
+const ROOT = "/work/app";
+
+const PROFILE = `mode: set
+example.com/app/store/store.go:10.33,12.2 2 1
+example.com/app/store/store.go:14.30,16.16 1 0
+example.com/app/store/store.go:16.16,18.3 1 0
+example.com/app/store/store.go:20.22,22.2 3 0
+example.com/app/store/store.go:10.33,12.2 2 0
+golang.org/x/other/lib.go:1.1,2.2 1 1
+`;
+
+function symbol(name: string, startLine: number, endLine: number) {
+  return {
+    name,
+    kind: SymbolKind.Function,
+    location: {
+      uri: pathToFileURL(join(ROOT, "store/store.go")).toString(),
+      range: {
+        start: { line: startLine - 1, character: 0 },
+        end: { line: endLine - 1, character: 1 },
+      },
+    },
+  };
+}
+
+const SYMBOLS = [
+  symbol("Load", 10, 12),
+  symbol("Save", 14, 19),
+  symbol("validate", 20, 22),
+  symbol("Empty", 30, 31),
+];
+
+describe("parseCoverProfile", () => {
+  it("should parse blocks and merge duplicates", () => {
+    const blocks = parseCoverProfile(PROFILE);
+
+    expect(blocks).toHaveLength(5);
+    expect(blocks[0]).toEqual({
+      file: "example.com/app/store/store.go",
+      startLine: 10,
+      endLine: 12,
+      statements: 2,
+      covered: true,
+    });
+  });
+});
+
+describe("profileFileToRelativePath", () => {
+  it("should strip the module path", () => {
+    expect(
+      profileFileToRelativePath("example.com/app/a/b.go", "example.com/app"),
+    ).toBe("a/b.go");
+    expect(
+      profileFileToRelativePath("golang.org/x/lib.go", "example.com/app"),
+    ).toBeNull();
+  });
+});
+
+describe("computeSymbolCoverage", () => {
+  it("should sum statements inside each function", () => {
+    const coverage = computeSymbolCoverage(
+      parseCoverProfile(PROFILE),
+      SYMBOLS,
+      ROOT,
+      "example.com/app",
+    );
+
+    expect(
+      coverage.map((s) => [s.name, s.covered, s.statements, s.exported]),
+    ).toEqual([
+      ["Load", 2, 2, true],
+      ["Save", 0, 2, true],
+      ["validate", 0, 3, false],
+    ]);
+  });
+});
+
+describe("isGoExported", () => {
+  it("should check capitalization after the receiver", () => {
+    expect(isGoExported("Save")).toBe(true);
+    expect(isGoExported("(*Store).Save")).toBe(true);
+    expect(isGoExported("(*Store).save")).toBe(false);
+    expect(isGoExported("validate")).toBe(false);
+  });
+});
+
+describe("formatCoverageReport", () => {
+  it("should highlight uncovered exported functions", () => {
+    const coverage = computeSymbolCoverage(
+      parseCoverProfile(PROFILE),
+      SYMBOLS,
+      ROOT,
+      "example.com/app",
+    );
+    const output = formatCoverageReport(
+      {
+        symbols: coverage,
+        totalStatements: 7,
+        coveredStatements: 2,
+        testsFailed: false,
+      },
+      { threshold: 100, limit: 100 },
+    );
+
+    expect(output).toContain("Coverage: 28.6% of statements in 3 functions");
+    expect(output).toContain(
+      "Exported functions with no coverage (1):\n  store/store.go:14 Save (2 statements)",
+    );
+    expect(output).toContain("Functions below 100% (2):");
+    expect(output).toContain("  0.0% store/store.go:20 validate [Function] 0/3");
+    expect(output).not.toContain("Load [Function]");
+  });
+});
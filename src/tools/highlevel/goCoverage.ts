@@ -0,0 +1,352 @@
+/**
+ * High-level tool for Go test coverage per symbol
+ * Runs `go test -coverprofile`, maps profile blocks onto indexed functions and
+ * methods, and points out exported functions no test reaches
+ */
+
+import { z } from "zod";
+import { mkdtempSync, readFileSync, rmSync } from "fs";
+import { tmpdir } from "os";
+import { join, relative } from "path";
+import { fileURLToPath } from "url";
+import type { McpContext, McpToolDef } from "@internal/types";
+import { SymbolKind, getSymbolKindName } from "@internal/types";
+import { querySymbols, type IndexedSymbol } from "@internal/code-indexer";
+import { runGoCommand } from "../../utils/goCommand.ts";
+import { readGoModulePath } from "../../utils/importGraph.ts";
+import { matchesFileFilter } from "../../utils/symbolQueryParser.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory of the Go module"),
+  packages: z
+    .array(z.string())
+    .optional()
+    .default(["./..."])
+    .describe("Package patterns to test (default: ./...)"),
+  run: z
+    .string()
+    .optional()
+    .describe(
+      "Only run tests matching this regular expression (go test -run)",
+    ),
+  file: z
+    .string()
+    .optional()
+    .describe("Only report functions in matching files (substring or glob)"),
+  threshold: z
+    .number()
+    .min(0)
+    .max(100)
+    .optional()
+    .default(100)
+    .describe("Only list functions with coverage below this percentage"),
+  limit: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .default(100)
+    .describe("Maximum number of functions to list"),
+  timeout: z
+    .number()
+    .positive()
+    .optional()
+    .default(300)
+    .describe("Timeout in seconds for the test run"),
+});
+
+type GetCoverageRequest = z.infer<typeof schema>;
+
+export interface CoverageBlock {
+  /** File as written in the profile (import path + file name) */
+  file: string;
+  /** 1-based */
+  startLine: number;
+  endLine: number;
+  statements: number;
+  covered: boolean;
+}
+
+export interface SymbolCoverage {
+  name: string;
+  kind: SymbolKind;
+  containerName?: string;
+  relativePath: string;
+  /** 1-based line of the declaration */
+  line: number;
+  statements: number;
+  covered: number;
+  exported: boolean;
+}
+
+export interface CoverageReport {
+  symbols: SymbolCoverage[];
+  totalStatements: number;
+  coveredStatements: number;
+  testsFailed: boolean;
+}
+
+const FUNCTION_KINDS = [SymbolKind.Function, SymbolKind.Method];
+
+/**
+ * Go exports by capitalization; method names may carry a "(*T)." receiver
+ */
+export function isGoExported(name: string): boolean {
+  return /^\p{Lu}/u.test(name.replace(/^\([^)]*\)\./, ""));
+}
+
+/**
+ * Parse a cover profile; blocks repeated across packages are merged
+ */
+export function parseCoverProfile(content: string): CoverageBlock[] {
+  const blocks = new Map<string, CoverageBlock>();
+  const pattern = /^(.+):(\d+)\.\d+,(\d+)\.\d+ (\d+) (\d+)$/;
+
+  for (const line of content.split("\n")) {
+    const match = pattern.exec(line.trim());
+    if (!match) continue;
+    const [, file, startLine, endLine, statements, count] = match;
+    const key = line.trim().replace(/ \d+$/, "");
+    const existing = blocks.get(key);
+    if (existing) {
+      existing.covered = existing.covered || Number(count) > 0;
+      continue;
+    }
+    blocks.set(key, {
+      file,
+      startLine: Number(startLine),
+      endLine: Number(endLine),
+      statements: Number(statements),
+      covered: Number(count) > 0,
+    });
+  }
+  return Array.from(blocks.values());
+}
+
+/**
+ * Map a profile file ("example.com/app/store/store.go") to a path relative
+ * to the module root; files outside the module resolve to null
+ */
+export function profileFileToRelativePath(
+  file: string,
+  goModule: string | undefined,
+): string | null {
+  if (!goModule) {
+    return null;
+  }
+  if (file.startsWith(`${goModule}/`)) {
+    return file.slice(goModule.length + 1);
+  }
+  return null;
+}
+
+/**
+ * Attribute profile blocks to the functions that contain them
+ */
+export function computeSymbolCoverage(
+  blocks: CoverageBlock[],
+  symbols: IndexedSymbol[],
+  root: string,
+  goModule: string | undefined,
+): SymbolCoverage[] {
+  const blocksByFile = new Map<string, CoverageBlock[]>();
+  for (const block of blocks) {
+    const relativePath = profileFileToRelativePath(block.file, goModule);
+    if (!relativePath) continue;
+    blocksByFile.set(relativePath, [
+      ...(blocksByFile.get(relativePath) ?? []),
+      block,
+    ]);
+  }
+
+  const results: SymbolCoverage[] = [];
+  for (const symbol of symbols) {
+    const relativePath = relative(
+      root,
+      fileURLToPath(symbol.location.uri),
+    ).replace(/\\/g, "/");
+    const fileBlocks = blocksByFile.get(relativePath);
+    if (!fileBlocks) continue;
+
+    const start = symbol.location.range.start.line + 1;
+    const end = symbol.location.range.end.line + 1;
+    let statements = 0;
+    let covered = 0;
+    for (const block of fileBlocks) {
+      if (block.startLine >= start && block.endLine <= end) {
+        statements += block.statements;
+        if (block.covered) {
+          covered += block.statements;
+        }
+      }
+    }
+    if (statements === 0) continue;
+
+    results.push({
+      name: symbol.name,
+      kind: symbol.kind,
+      containerName: symbol.containerName,
+      relativePath,
+      line: start,
+      statements,
+      covered,
+      exported: isGoExported(symbol.name),
+    });
+  }
+  return results;
+}
+
+export async function getCoverage(
+  request: GetCoverageRequest,
+  context?: McpContext,
+): Promise<CoverageReport> {
+  const symbols = querySymbols(request.root, { kind: FUNCTION_KINDS });
+  if (symbols.length === 0) {
+    throw new Error(
+      "The symbol index is empty. Run search_symbols or get_project_overview first.",
+    );
+  }
+  const goModule = readGoModulePath(request.root);
+  if (!goModule) {
+    throw new Error(`No go.mod found in ${request.root}`);
+  }
+
+  const dir = mkdtempSync(join(tmpdir(), "lsmcp-cover-"));
+  const profilePath = join(dir, "cover.out");
+  try {
+    const args = [
+      "test",
+      `-coverprofile=${profilePath}`,
+      `-timeout=${request.timeout}s`,
+    ];
+    if (request.run) {
+      args.push("-run", request.run);
+    }
+    args.push(...request.packages);
+
+    const result = await runGoCommand(args, {
+      cwd: request.root,
+      timeout: (request.timeout + 30) * 1000,
+      signal: context?.signal,
+    });
+
+    let profile: string;
+    try {
+      profile = readFileSync(profilePath, "utf-8");
+    } catch {
+      throw new Error(
+        `go test did not write a coverage profile:\n${(
+          result.stderr || result.stdout
+        ).trim()}`,
+      );
+    }
+
+    const coverage = computeSymbolCoverage(
+      parseCoverProfile(profile),
+      symbols,
+      request.root,
+      goModule,
+    ).filter(
+      (symbol) =>
+        !request.file || matchesFileFilter(symbol.relativePath, request.file),
+    );
+
+    return {
+      symbols: coverage,
+      totalStatements: coverage.reduce((sum, s) => sum + s.statements, 0),
+      coveredStatements: coverage.reduce((sum, s) => sum + s.covered, 0),
+      testsFailed: result.exitCode !== 0,
+    };
+  } finally {
+    rmSync(dir, { recursive: true, force: true });
+  }
+}
+
+function percent(covered: number, total: number): string {
+  return `${total === 0 ? "0.0" : ((covered / total) * 100).toFixed(1)}%`;
+}
+
+function displayName(symbol: SymbolCoverage): string {
+  return symbol.containerName
+    ? `${symbol.containerName}.${symbol.name}`
+    : symbol.name;
+}
+
+export function formatCoverageReport(
+  report: CoverageReport,
+  options: { threshold: number; limit: number },
+): string {
+  const lines = [
+    `Coverage: ${percent(
+      report.coveredStatements,
+      report.totalStatements,
+    )} of statements in ${report.symbols.length} functions`,
+  ];
+  if (report.testsFailed) {
+    lines.push("Some tests failed; coverage reflects the tests that ran.");
+  }
+
+  const uncoveredExported = report.symbols
+    .filter((symbol) => symbol.exported && symbol.covered === 0)
+    .sort(
+      (a, b) => a.relativePath.localeCompare(b.relativePath) || a.line - b.line,
+    );
+  if (uncoveredExported.length > 0) {
+    lines.push(
+      "",
+      `Exported functions with no coverage (${uncoveredExported.length}):`,
+    );
+    for (const symbol of uncoveredExported.slice(0, options.limit)) {
+      lines.push(
+        `  ${symbol.relativePath}:${symbol.line} ${displayName(symbol)} (${symbol.statements} statements)`,
+      );
+    }
+  }
+
+  const below = report.symbols
+    .filter(
+      (symbol) =>
+        (symbol.covered / symbol.statements) * 100 < options.threshold,
+    )
+    .sort(
+      (a, b) =>
+        a.covered / a.statements - b.covered / b.statements ||
+        b.statements - a.statements,
+    );
+  const shown = below.slice(0, options.limit);
+  if (shown.length > 0) {
+    lines.push("", `Functions below ${options.threshold}% (${below.length}):`);
+    for (const symbol of shown) {
+      const kindName = getSymbolKindName(symbol.kind) ?? "Unknown";
+      lines.push(
+        `  ${percent(symbol.covered, symbol.statements).padStart(6)} ${
+          symbol.relativePath
+        }:${symbol.line} ${displayName(symbol)} [${kindName}] ${
+          symbol.covered
+        }/${symbol.statements}`,
+      );
+    }
+    if (below.length > shown.length) {
+      lines.push(`  ... ${below.length - shown.length} more`);
+    }
+  }
+
+  return lines.join("\n");
+}
+
+/**
+ * Create get_coverage tool for Go projects
+ */
+export function createGetCoverageTool(): McpToolDef<typeof schema> {
+  return {
+    name: "get_coverage",
+    description:
+      "Run Go tests with -coverprofile and report statement coverage per indexed function and method, " +
+      "listing exported functions that no test reaches. Use it to decide which functions need tests.",
+    schema,
+    execute: async (args, context?: McpContext) => {
+      const report = await getCoverage(args, context);
+      return formatCoverageReport(report, args);
+    },
+  };
+}
@@ -0,0 +1,187 @@
+import { describe, it, expect } from "vitest";
+import {
+  buildGoTestArgs,
+  formatGoTestReport,
+  parseGoTestEvents,
+} from "./goTests.ts";
+
+const event = (fields: Record<string, unknown>) => JSON.stringify(fields);
+
+const STREAM = [
+  event({ Action: "start", Package: "example.com/app/store" }),
+  event({ Action: "run", Package: "example.com/app/store", Test: "TestSave" }),
+  event({
+    Action: "output",
+    Package: "example.com/app/store",
+    Test: "TestSave",
+    Output: "=== RUN   TestSave\n",
+  }),
+  event({
+    Action: "output",
+    Package: "example.com/app/store",
+    Test: "TestSave",
+    Output: "    store_test.go:12: expected 2 rows, got 1\n",
+  }),
+  event({
+    Action: "output",
+    Package: "example.com/app/store",
+    Test: "TestSave",
+    Output: "--- FAIL: TestSave (0.01s)\n",
+  }),
+  event({
+    Action: "fail",
+    Package: "example.com/app/store",
+    Test: "TestSave",
+    Elapsed: 0.01,
+  }),
+  event({ Action: "run", Package: "example.com/app/store", Test: "TestLoad" }),
+  event({
+    Action: "pass",
+    Package: "example.com/app/store",
+    Test: "TestLoad",
+    Elapsed: 0,
+  }),
+  event({ Action: "run", Package: "example.com/app/store", Test: "TestSlow" }),
+  event({
+    Action: "skip",
+    Package: "example.com/app/store",
+    Test: "TestSlow",
+    Elapsed: 0,
+  }),
+  event({
+    Action: "output",
+    Package: "example.com/app/store",
+    Output: "FAIL\n",
+  }),
+  event({ Action: "fail", Package: "example.com/app/store", Elapsed: 0.2 }),
+  event({ Action: "run", Package: "example.com/app/model", Test: "TestUser" }),
+  event({
+    Action: "pass",
+    Package: "example.com/app/model",
+    Test: "TestUser",
+    Elapsed: 0.05,
+  }),
+  event({ Action: "pass", Package: "example.com/app/model", Elapsed: 0.1 }),
+].join("\n");
+
+describe("parseGoTestEvents", () => {
+  it("should collect per-test status, durations and output", () => {
+    const report = parseGoTestEvents(STREAM);
+
+    expect(report.packages.map((p) => [p.package, p.status])).toEqual([
+      ["example.com/app/store", "fail"],
+      ["example.com/app/model", "pass"],
+    ]);
+    expect(report.tests.map((t) => [t.name, t.status])).toEqual([
+      ["TestSave", "fail"],
+      ["TestLoad", "pass"],
+      ["TestSlow", "skip"],
+      ["TestUser", "pass"],
+    ]);
+    expect(report.tests[0].elapsed).toBe(0.01);
+    // Framing lines are dropped, assertion output is kept
+    expect(report.tests[0].output).toEqual([
+      "    store_test.go:12: expected 2 rows, got 1",
+    ]);
+  });
+
+  it("should treat tests without a result as failures", () => {
+    const report = parseGoTestEvents(
+      [
+        event({ Action: "run", Package: "p", Test: "TestHang" }),
+        event({ Action: "output", Package: "p", Output: "panic: timeout\n" }),
+        event({ Action: "fail", Package: "p", Elapsed: 30 }),
+      ].join("\n"),
+    );
+
+    expect(report.tests[0].status).toBe("fail");
+    expect(report.packages[0].output).toEqual(["panic: timeout"]);
+  });
+
+  it("should keep non-JSON lines and build output", () => {
+    const report = parseGoTestEvents(
+      [
+        "# example.com/app/broken",
+        event({
+          Action: "build-output",
+          ImportPath: "example.com/app/broken [example.com/app/broken.test]",
+          Output: "broken.go:3:2: undefined: missing\n",
+        }),
+        event({ Action: "fail", Package: "example.com/app/broken" }),
+      ].join("\n"),
+    );
+
+    expect(report.stray).toEqual(["# example.com/app/broken"]);
+    expect(report.packages[0].output).toEqual([
+      "broken.go:3:2: undefined: missing",
+    ]);
+  });
+});
+
+describe("formatGoTestReport", () => {
+  it("should summarize and show failures only by default", () => {
+    const output = formatGoTestReport(parseGoTestEvents(STREAM));
+
+    expect(output).toContain(
+      "FAIL: 2 passed, 1 failed, 1 skipped in 2 packages (0.30s)",
+    );
+    expect(output).toContain("FAIL example.com/app/store (0.20s)");
+    expect(output).toContain(
+      "  FAIL TestSave (0.01s)\n        store_test.go:12: expected 2 rows, got 1",
+    );
+    expect(output).not.toContain("TestLoad");
+    expect(output).not.toContain("example.com/app/model");
+  });
+
+  it("should list every test when verbose", () => {
+    const output = formatGoTestReport(parseGoTestEvents(STREAM), true);
+
+    expect(output).toContain("  PASS TestLoad (0.00s)");
+    expect(output).toContain("  SKIP TestSlow (0.00s)");
+    expect(output).toContain("ok example.com/app/model (0.10s)");
+  });
+
+  it("should show package output for build failures", () => {
+    const output = formatGoTestReport(
+      parseGoTestEvents(
+        [
+          event({
+            Action: "build-output",
+            ImportPath: "example.com/app/broken",
+            Output: "broken.go:3:2: undefined: missing\n",
+          }),
+          event({ Action: "fail", Package: "example.com/app/broken" }),
+        ].join("\n"),
+      ),
+    );
+
+    expect(output).toContain(
+      "FAIL example.com/app/broken\n    broken.go:3:2: undefined: missing",
+    );
+  });
+});
+
+describe("buildGoTestArgs", () => {
+  it("should pass filters to go test", () => {
+    expect(
+      buildGoTestArgs({
+        root: "/tmp",
+        packages: ["./internal/..."],
+        run: "^TestSave$",
+        short: true,
+        noCache: true,
+        timeout: 60,
+        verbose: false,
+      }),
+    ).toEqual([
+      "test",
+      "-json",
+      "-timeout=60s",
+      "-run",
+      "^TestSave$",
+      "-short",
+      "-count=1",
+      "./internal/...",
+    ]);
+  });
+});
@@ -0,0 +1,305 @@
+/**
+ * High-level tool for running Go tests
+ * Runs `go test -json` and turns the event stream into per-test results
+ */
+
+import { z } from "zod";
+import type { McpContext, McpToolDef } from "@internal/types";
+import { runGoCommand } from "../../utils/goCommand.ts";
+
+const MAX_OUTPUT_LINES_PER_FAILURE = 40;
+
+const schema = z.object({
+  root: z.string().describe("Root directory of the Go module"),
+  packages: z
+    .array(z.string())
+    .optional()
+    .default(["./..."])
+    .describe("Package patterns to test (default: ./...)"),
+  run: z
+    .string()
+    .optional()
+    .describe(
+      "Only run tests matching this regular expression (go test -run)",
+    ),
+  short: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe("Pass -short to skip long-running tests"),
+  noCache: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe("Pass -count=1 so cached results are not reused"),
+  timeout: z
+    .number()
+    .positive()
+    .optional()
+    .default(300)
+    .describe("Timeout in seconds for the whole run"),
+  verbose: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe("List passing and skipped tests too, not only failures"),
+});
+
+type RunTestsRequest = z.infer<typeof schema>;
+
+export type GoTestStatus = "pass" | "fail" | "skip";
+
+export interface GoTestCase {
+  package: string;
+  name: string;
+  status: GoTestStatus;
+  /** Seconds */
+  elapsed?: number;
+  output: string[];
+}
+
+export interface GoPackageResult {
+  package: string;
+  status: GoTestStatus;
+  /** Seconds */
+  elapsed?: number;
+  /** Package-level output (build errors, panics outside tests) */
+  output: string[];
+}
+
+export interface GoTestReport {
+  packages: GoPackageResult[];
+  tests: GoTestCase[];
+  /** Lines that were not JSON events (e.g. build errors on older Go) */
+  stray: string[];
+}
+
+interface TestEvent {
+  Action: string;
+  Package?: string;
+  ImportPath?: string;
+  Test?: string;
+  Elapsed?: number;
+  Output?: string;
+}
+
+/**
+ * Lines go test prints around every test; the status is already structured
+ */
+function isFramingLine(line: string): boolean {
+  return /^\s*(=== (RUN|PAUSE|CONT|NAME)|--- (PASS|FAIL|SKIP):)/.test(line);
+}
+
+/**
+ * Parse the `go test -json` event stream
+ * Tests that started but never reported a result (panic, timeout) are failures
+ */
+export function parseGoTestEvents(stream: string): GoTestReport {
+  const packages = new Map<string, GoPackageResult>();
+  const tests = new Map<string, GoTestCase>();
+  const stray: string[] = [];
+
+  const getPackage = (name: string) => {
+    let pkg = packages.get(name);
+    if (!pkg) {
+      pkg = { package: name, status: "pass", output: [] };
+      packages.set(name, pkg);
+    }
+    return pkg;
+  };
+
+  for (const line of stream.split("\n")) {
+    if (!line.trim()) continue;
+    let event: TestEvent;
+    try {
+      event = JSON.parse(line);
+    } catch {
+      stray.push(line);
+      continue;
+    }
+
+    // Go 1.24+ reports compiler output as build-output events
+    if (event.Action === "build-output" || event.Action === "build-fail") {
+      if (event.ImportPath && event.Output) {
+        getPackage(event.ImportPath.split(" ")[0]).output.push(
+          event.Output.replace(/\n$/, ""),
+        );
+      }
+      continue;
+    }
+    if (!event.Package) continue;
+
+    const pkg = getPackage(event.Package);
+    if (!event.Test) {
+      if (event.Action === "output" && event.Output) {
+        pkg.output.push(event.Output.replace(/\n$/, ""));
+      } else if (["pass", "fail", "skip"].includes(event.Action)) {
+        pkg.status = event.Action as GoTestStatus;
+        pkg.elapsed = event.Elapsed;
+      }
+      continue;
+    }
+
+    const key = `${event.Package}\u0000${event.Test}`;
+    let test = tests.get(key);
+    if (!test) {
+      test = {
+        package: event.Package,
+        name: event.Test,
+        status: "fail",
+        output: [],
+      };
+      tests.set(key, test);
+    }
+    if (event.Action === "output" && event.Output) {
+      const text = event.Output.replace(/\n$/, "");
+      if (!isFramingLine(text)) {
+        test.output.push(text);
+      }
+    } else if (["pass", "fail", "skip"].includes(event.Action)) {
+      test.status = event.Action as GoTestStatus;
+      test.elapsed = event.Elapsed;
+    }
+  }
+
+  return {
+    packages: Array.from(packages.values()),
+    tests: Array.from(tests.values()),
+    stray,
+  };
+}
+
+export function buildGoTestArgs(request: RunTestsRequest): string[] {
+  const args = ["test", "-json", `-timeout=${request.timeout}s`];
+  if (request.run) {
+    args.push("-run", request.run);
+  }
+  if (request.short) {
+    args.push("-short");
+  }
+  if (request.noCache) {
+    args.push("-count=1");
+  }
+  args.push(...request.packages);
+  return args;
+}
+
+export async function runGoTests(
+  request: RunTestsRequest,
+  context?: McpContext,
+): Promise<GoTestReport> {
+  const result = await runGoCommand(buildGoTestArgs(request), {
+    cwd: request.root,
+    // Give go test a moment to report its own -timeout panic first
+    timeout: (request.timeout + 30) * 1000,
+    signal: context?.signal,
+  });
+  const report = parseGoTestEvents(result.stdout);
+  report.stray.push(...result.stderr.split("\n").filter((l) => l.trim()));
+
+  if (
+    result.exitCode !== 0 &&
+    report.packages.length === 0 &&
+    report.stray.length > 0
+  ) {
+    // Nothing ran (bad package pattern, go.mod errors)
+    throw new Error(`go test failed:\n${report.stray.join("\n")}`);
+  }
+  return report;
+}
+
+function formatElapsed(elapsed?: number): string {
+  return elapsed !== undefined ? ` (${elapsed.toFixed(2)}s)` : "";
+}
+
+function count(tests: GoTestCase[], status: GoTestStatus): number {
+  return tests.filter((test) => test.status === status).length;
+}
+
+export function formatGoTestReport(
+  report: GoTestReport,
+  verbose: boolean = false,
+): string {
+  const failedPackages = report.packages.filter((p) => p.status === "fail");
+  const elapsed = report.packages.reduce(
+    (sum, pkg) => sum + (pkg.elapsed ?? 0),
+    0,
+  );
+  const verdict = failedPackages.length > 0 ? "FAIL" : "PASS";
+  const packageCount = `${report.packages.length} package${
+    report.packages.length === 1 ? "" : "s"
+  }`;
+  const counts = [
+    `${count(report.tests, "pass")} passed`,
+    `${count(report.tests, "fail")} failed`,
+    `${count(report.tests, "skip")} skipped`,
+  ].join(", ");
+  const lines = [
+    `${verdict}: ${counts} in ${packageCount}${formatElapsed(elapsed)}`,
+  ];
+
+  for (const pkg of report.packages) {
+    if (!verbose && pkg.status !== "fail") {
+      continue;
+    }
+    const tests = report.tests.filter((test) => test.package === pkg.package);
+    const shown = verbose ? tests : tests.filter((t) => t.status === "fail");
+    const label =
+      pkg.status === "fail" ? "FAIL" : pkg.status === "skip" ? "SKIP" : "ok";
+    const note =
+      pkg.status === "skip" && tests.length === 0 ? " [no test files]" : "";
+
+    lines.push(
+      "",
+      `${label} ${pkg.package}${formatElapsed(pkg.elapsed)}${note}`,
+    );
+    for (const test of shown) {
+      const status = test.status.toUpperCase();
+      lines.push(`  ${status} ${test.name}${formatElapsed(test.elapsed)}`);
+      if (test.status === "fail") {
+        const output = test.output.slice(0, MAX_OUTPUT_LINES_PER_FAILURE);
+        lines.push(...output.map((line) => `    ${line.trimEnd()}`));
+        if (test.output.length > output.length) {
+          const more = test.output.length - output.length;
+          lines.push(`    ... ${more} more lines`);
+        }
+      }
+    }
+
+    // Build failures and package-level panics have no test to attach to
+    if (pkg.status === "fail" && !tests.some((t) => t.status === "fail")) {
+      const output = pkg.output
+        .filter((line) => !/^(FAIL|PASS|ok)(\s|$)/.test(line))
+        .slice(0, MAX_OUTPUT_LINES_PER_FAILURE);
+      lines.push(...output.map((line) => `    ${line.trimEnd()}`));
+    }
+  }
+
+  if (report.stray.length > 0 && failedPackages.length > 0) {
+    lines.push("", "Other output:");
+    lines.push(
+      ...report.stray
+        .slice(0, MAX_OUTPUT_LINES_PER_FAILURE)
+        .map((line) => `  ${line}`),
+    );
+  }
+
+  return lines.join("\n");
+}
+
+/**
+ * Create run_tests tool for Go projects
+ */
+export function createRunGoTestsTool(): McpToolDef<typeof schema> {
+  return {
+    name: "run_tests",
+    description:
+      "Run Go tests with `go test -json` and return pass/fail/skip results per test, failure output and durations. " +
+      "Filter with packages (e.g. ./internal/...) and run (a -run regular expression).",
+    schema,
+    execute: async (args, context?: McpContext) => {
+      const report = await runGoTests(args, context);
+      return formatGoTestReport(report, args.verbose);
+    },
+  };
+}
@@ -0,0 +1,50 @@
+import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { getIgnoreRulesTool } from "./ignoreRules.ts";
+
+describe("get_ignore_rules", () => {
+  let rootPath: string;
+
+  beforeEach(() => {
+    rootPath = mkdtempSync(join(tmpdir(), "lsmcp-ignore-tool-"));
+    writeFileSync(join(rootPath, ".gitignore"), "# output\nbuild/\ngen/\n");
+    writeFileSync(join(rootPath, ".lsmcpignore"), "!gen/\n*.pb.go\n");
+    mkdirSync(join(rootPath, "gen"));
+  });
+
+  afterEach(() => {
+    rmSync(rootPath, { recursive: true, force: true });
+  });
+
+  it("should list the rules by source with line numbers", async () => {
+    const output = await getIgnoreRulesTool.execute({ root: rootPath });
+
+    expect(output).toContain(
+      [".gitignore", "  2: build/", "  3: gen/"].join("\n"),
+    );
+    expect(output).toContain(
+      [".lsmcpignore", "  1: !gen/", "  2: *.pb.go"].join("\n"),
+    );
+    expect(output).toContain(
+      ["ignorePatterns", "  **/node_modules/**"].join("\n"),
+    );
+  });
+
+  it("should explain which rule decides for each path", async () => {
+    const output = await getIgnoreRulesTool.execute({
+      root: rootPath,
+      paths: ["build/main.go", "gen", "api/user.pb.go", "cmd/main.go"],
+    });
+
+    expect(output).toContain(
+      "- build/main.go: ignored by .gitignore:2 (build/)",
+    );
+    expect(output).toContain("- gen: re-included by .lsmcpignore:1 (!gen/)");
+    expect(output).toContain(
+      "- api/user.pb.go: ignored by .lsmcpignore:2 (*.pb.go)",
+    );
+    expect(output).toContain("- cmd/main.go: indexed");
+  });
+});
@@ -0,0 +1,97 @@
+/**
+ * High-level tool showing which files the symbol index leaves out
+ * Lists the effective ignore rules (.git/info/exclude, .gitignore and
+ * .lsmcpignore files, config ignorePatterns) and explains for given paths
+ * whether they are indexed and which rule decided
+ */
+
+import { z } from "zod";
+import { statSync } from "fs";
+import { join } from "path";
+import type { McpToolDef } from "@internal/types";
+import {
+  IgnoreRules,
+  loadIndexConfig,
+  type IgnoreMatch,
+  type IgnoreRule,
+  type IgnoreSource,
+} from "@internal/code-indexer";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project").optional(),
+  paths: z
+    .array(z.string())
+    .optional()
+    .describe(
+      "Paths relative to root to check; a trailing slash marks a directory",
+    ),
+});
+
+function formatRule(rule: IgnoreRule): string {
+  return rule.line ? `${rule.line}: ${rule.pattern}` : rule.pattern;
+}
+
+function formatMatch(path: string, match: IgnoreMatch): string {
+  if (!match.rule) {
+    return `- ${path}: indexed`;
+  }
+  const location = match.rule.line
+    ? `${match.rule.source}:${match.rule.line}`
+    : match.rule.source;
+  const verdict = match.ignored ? "ignored by" : "re-included by";
+  return `- ${path}: ${verdict} ${location} (${match.rule.pattern})`;
+}
+
+export function formatIgnoreRules(
+  rootPath: string,
+  sources: IgnoreSource[],
+  checks: Array<{ path: string; match: IgnoreMatch }>,
+): string {
+  const lines = [
+    `Ignore rules for ${rootPath} (later rules override earlier ones):`,
+  ];
+  for (const { source, rules } of sources) {
+    lines.push("", source, ...rules.map((rule) => `  ${formatRule(rule)}`));
+  }
+  if (checks.length > 0) {
+    lines.push(
+      "",
+      "Paths:",
+      ...checks.map(({ path, match }) => formatMatch(path, match)),
+    );
+  }
+  return lines.join("\n");
+}
+
+function isDirectory(path: string): boolean {
+  try {
+    return statSync(path).isDirectory();
+  } catch {
+    return false;
+  }
+}
+
+export const getIgnoreRulesTool: McpToolDef<typeof schema> = {
+  name: "get_ignore_rules",
+  description:
+    "Show the ignore rules the symbol index applies: .git/info/exclude, .gitignore and .lsmcpignore files " +
+    "(gitignore syntax; .lsmcpignore can re-include git-ignored files with !pattern) and the config's ignorePatterns. " +
+    "With paths, tells for each path whether it is indexed and which rule decided.",
+  schema,
+  execute: async ({ root, paths = [] }) => {
+    const rootPath = root || process.cwd();
+    const config = loadIndexConfig(rootPath);
+    const rules = new IgnoreRules(rootPath, {
+      patterns: config.ignorePatterns,
+    });
+
+    const checks = paths.map((path) => ({
+      path,
+      match: rules.match(
+        path,
+        path.endsWith("/") || isDirectory(join(rootPath, path)),
+      ),
+    }));
+    return formatIgnoreRules(rootPath, rules.listSources(), checks);
+  },
+};
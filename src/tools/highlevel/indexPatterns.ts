@@ -0,0 +1,96 @@
+/**
+ * File patterns the symbol index is built from
+ * Shared by the tools that build the index on first use and the ones that
+ * report on it
+ */
+
+import type { McpContext } from "@internal/types";
+import { glob } from "gitaware-glob";
+import {
+  getAdapterDefaultPattern,
+  loadIndexConfig,
+} from "@internal/code-indexer";
+import { debugLogWithPrefix } from "../../utils/debugLog.ts";
+
+/**
+ * File patterns to index for a root: config.files, then the files of the
+ * context's config, then the defaults of its preset
+ */
+export function resolveIndexPattern(
+  rootPath: string,
+  context?: McpContext,
+): { pattern: string } | { error: string } {
+  let pattern: string;
+  const config = loadIndexConfig(rootPath);
+
+  // Priority: 1. files from config, 2. files from context, 3. preset defaults, 4. empty (no auto-indexing)
+  if (config?.files && config.files.length > 0) {
+    pattern = config.files.join(",");
+    debugLogWithPrefix(
+      "search_symbol_from_index",
+      `Using patterns from config.files: ${pattern}`,
+    );
+  } else if (context?.config?.files && Array.isArray(context.config.files)) {
+    // Check if files are provided in context (from preset)
+    pattern = (context.config.files as string[]).join(",");
+    debugLogWithPrefix(
+      "search_symbol_from_index",
+      `Using patterns from context.config.files: ${pattern}`,
+    );
+  } else if (context?.config?.preset) {
+    // Use preset-specific patterns
+    const presetId = context.config.preset as string;
+    pattern = getAdapterDefaultPattern(presetId);
+    if (!pattern) {
+      // Preset not found or has no default patterns
+      debugLogWithPrefix(
+        "search_symbol_from_index",
+        `Unknown preset '${presetId}' or preset has no default patterns`,
+      );
+      return {
+        error: `Unknown preset '${presetId}' or preset has no default patterns. Please specify 'files' in your .lsmcp/config.json`,
+      };
+    }
+    debugLogWithPrefix(
+      "search_symbol_from_index",
+      `Using patterns from preset '${presetId}': ${pattern}`,
+    );
+  } else {
+    // No preset or files configured - don't auto-index
+    debugLogWithPrefix(
+      "search_symbol_from_index",
+      "No file patterns configured. Please specify 'files' or 'preset' in config.",
+    );
+    return {
+      error:
+        "No file patterns configured. Please specify 'files' or 'preset' in your .lsmcp/config.json",
+    };
+  }
+  return { pattern };
+}
+
+/**
+ * Files under the root matching comma-separated glob patterns
+ */
+export async function findFilesToIndex(
+  rootPath: string,
+  pattern: string,
+): Promise<string[]> {
+  const files: string[] = [];
+  // Handle patterns with braces properly (e.g., **/*.{ts,tsx})
+  const patterns =
+    pattern.includes("{") && pattern.includes("}")
+      ? [pattern]
+      : pattern.split(",").map((p) => p.trim());
+
+  for (const p of patterns) {
+    for await (const file of glob(p, { cwd: rootPath })) {
+      if (typeof file === "string") {
+        files.push(file);
+      } else if (file && typeof file === "object" && "name" in file) {
+        files.push((file as any).name);
+      }
+    }
+  }
+  return files;
+}
@@ -0,0 +1,116 @@
+import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  formatIndexStatus,
+  indexStatusTool,
+  type IndexStatus,
+} from "./indexStatus.ts";
+
+describe("formatIndexStatus", () => {
+  const status: IndexStatus = {
+    rootPath: "/project",
+    files: 3,
+    symbols: 42,
+    byKind: [
+      { kind: "Function", count: 30 },
+      { kind: "Class", count: 12 },
+    ],
+    byLanguage: [
+      { language: "typescript", files: 2, symbols: 40 },
+      { language: "go", files: 1, symbols: 2 },
+    ],
+    lastIndexedAt: new Date("2026-10-01T12:00:00Z"),
+    lastRun: {
+      files: 3,
+      indexed: 3,
+      reused: 0,
+      empty: 0,
+      failed: 0,
+      duration: 120,
+      filesPerSecond: 25,
+      workers: 3,
+      throttled: 0,
+      peakHeapMB: 80,
+    },
+    modified: ["src/a.ts"],
+    deleted: ["src/old.ts"],
+    notIndexed: [],
+    diskBytes: 3 * 1024 * 1024,
+    errors: [{ file: "src/broken.ts", error: "Request timed out" }],
+  };
+
+  it("should report counts, freshness, size and errors", () => {
+    const output = formatIndexStatus(status);
+
+    expect(output).toContain("Files: 3");
+    expect(output).toContain("  By kind: Function 30, Class 12");
+    expect(output).toContain(
+      "  By language: typescript 40 in 2 files, go 2 in 1 file",
+    );
+    expect(output).toContain(
+      "Last full index: 2026-10-01T12:00:00.000Z (3 files in 120ms, 25 files/s with 3 workers)",
+    );
+    expect(output).toContain("Last incremental update: never");
+    expect(output).toContain(
+      [
+        "Stale files: 2 (1 modified, 1 deleted since indexed)",
+        "  - src/a.ts (modified)",
+        "  - src/old.ts (deleted)",
+      ].join("\n"),
+    );
+    expect(output).toContain("Not indexed: 0 files");
+    expect(output).toContain("On disk: 3.0 MB (.lsmcp/cache/symbols.db)");
+    expect(output).toContain(
+      ["Indexing errors (1):", "  - src/broken.ts: Request timed out"].join(
+        "\n",
+      ),
+    );
+  });
+
+  it("should summarize long file lists", () => {
+    const notIndexed = Array.from({ length: 12 }, (_, i) => `src/f${i}.ts`);
+    const output = formatIndexStatus({ ...status, notIndexed });
+
+    expect(output).toContain(
+      "Not indexed: 12 files matching the index patterns",
+    );
+    expect(output).toContain("  - src/f9.ts\n  ... and 2 more");
+    expect(output).not.toContain("src/f10.ts");
+  });
+});
+
+describe("index_status", () => {
+  let rootPath: string;
+
+  beforeEach(() => {
+    rootPath = mkdtempSync(join(tmpdir(), "lsmcp-index-status-"));
+    mkdirSync(join(rootPath, ".lsmcp"));
+    mkdirSync(join(rootPath, "src"));
+    writeFileSync(
+      join(rootPath, ".lsmcp", "config.json"),
+      JSON.stringify({ files: ["src/**/*.ts"] }),
+    );
+    writeFileSync(join(rootPath, "src", "a.ts"), "export const a = 1;\n");
+    writeFileSync(join(rootPath, "src", "b.ts"), "export const b = 2;\n");
+  });
+
+  afterEach(() => {
+    rmSync(rootPath, { recursive: true, force: true });
+  });
+
+  it("should tell an unbuilt index apart from one without matches", async () => {
+    const output = await indexStatusTool.execute({ root: rootPath });
+
+    expect(output).toContain("Index not built");
+    expect(output).toContain(
+      [
+        "Not indexed: 2 files matching the index patterns",
+        "  - src/a.ts",
+        "  - src/b.ts",
+      ].join("\n"),
+    );
+    expect(output).toContain("On disk: no cache");
+  });
+});
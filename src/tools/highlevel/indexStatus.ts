@@ -0,0 +1,228 @@
+/**
+ * High-level tool reporting the health of the symbol index
+ * Tells whether a root is indexed at all, how much of it, how fresh the
+ * index is and which files failed, so that empty search results can be
+ * told apart from a missing or stale index
+ */
+
+import { z } from "zod";
+import { existsSync, statSync } from "fs";
+import { join } from "path";
+import type { McpToolDef, McpContext } from "@internal/types";
+import { getLanguageIdFromPath } from "@internal/lsp-client";
+import {
+  IgnoreRules,
+  findStaleFiles,
+  getIndexErrors,
+  getIndexStats,
+  getIndexedFiles,
+  getOrCreateIndex,
+  getSymbolCounts,
+  getSymbolKindName,
+  loadIndexConfig,
+  type IndexingRunStats,
+} from "@internal/code-indexer";
+import { findFilesToIndex, resolveIndexPattern } from "./indexPatterns.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project").optional(),
+});
+
+// Stale and unindexed files listed by name before the rest is summarized
+const MAX_LISTED_FILES = 10;
+
+const CACHE_FILES = ["symbols.db", "symbols.db-wal", "symbols.db-shm"];
+
+export interface IndexStatus {
+  rootPath: string;
+  files: number;
+  symbols: number;
+  byKind: Array<{ kind: string; count: number }>;
+  byLanguage: Array<{ language: string; files: number; symbols: number }>;
+  lastIndexedAt?: Date;
+  lastIncrementalAt?: Date;
+  lastRun?: IndexingRunStats;
+  modified: string[];
+  deleted: string[];
+  /** Files matching the index patterns that are neither indexed nor ignored */
+  notIndexed: string[];
+  /** Why matching files could not be listed, e.g. no patterns configured */
+  patternError?: string;
+  /** Bytes of the SQLite cache, undefined when there is none */
+  diskBytes?: number;
+  errors: Array<{ file: string; error: string }>;
+}
+
+function formatFileList(files: string[]): string[] {
+  const lines = files.slice(0, MAX_LISTED_FILES).map((file) => `  - ${file}`);
+  if (files.length > MAX_LISTED_FILES) {
+    lines.push(`  ... and ${files.length - MAX_LISTED_FILES} more`);
+  }
+  return lines;
+}
+
+function formatBytes(bytes: number): string {
+  if (bytes < 1024 * 1024) {
+    return `${(bytes / 1024).toFixed(1)} KB`;
+  }
+  return `${(bytes / 1024 / 1024).toFixed(1)} MB`;
+}
+
+export function formatIndexStatus(status: IndexStatus): string {
+  const lines = [`Index status for ${status.rootPath}:`];
+
+  if (status.files === 0) {
+    lines.push(
+      "Index not built: no files are indexed yet, so searches return nothing.",
+      "Symbol search and get_project_overview build it on first use; `lsmcp index` builds it from the command line.",
+    );
+  } else {
+    lines.push(
+      `Files: ${status.files}`,
+      `Symbols: ${status.symbols}`,
+      `  By kind: ${status.byKind.map(({ kind, count }) => `${kind} ${count}`).join(", ")}`,
+      `  By language: ${status.byLanguage.map(({ language, files, symbols }) => `${language} ${symbols} in ${files} ${files === 1 ? "file" : "files"}`).join(", ")}`,
+    );
+  }
+
+  const run = status.lastRun;
+  lines.push(
+    "",
+    `Last full index: ${status.lastIndexedAt?.toISOString() ?? "never"}` +
+      (run && run.files > 0
+        ? ` (${run.files} files in ${run.duration}ms, ${run.filesPerSecond} files/s with ${run.workers} workers)`
+        : ""),
+    `Last incremental update: ${status.lastIncrementalAt?.toISOString() ?? "never"}`,
+  );
+
+  const stale = status.modified.length + status.deleted.length;
+  lines.push(
+    `Stale files: ${stale}` +
+      (stale > 0
+        ? ` (${status.modified.length} modified, ${status.deleted.length} deleted since indexed)`
+        : ""),
+    ...formatFileList([
+      ...status.modified.map((file) => `${file} (modified)`),
+      ...status.deleted.map((file) => `${file} (deleted)`),
+    ]),
+  );
+
+  if (status.patternError) {
+    lines.push(`Not indexed: unknown (${status.patternError})`);
+  } else {
+    lines.push(
+      `Not indexed: ${status.notIndexed.length} files matching the index patterns`,
+      ...formatFileList(status.notIndexed),
+    );
+  }
+
+  lines.push(
+    `On disk: ${status.diskBytes === undefined ? "no cache (.lsmcp/cache/symbols.db)" : `${formatBytes(status.diskBytes)} (.lsmcp/cache/symbols.db)`}`,
+  );
+
+  if (status.errors.length > 0) {
+    lines.push(
+      "",
+      `Indexing errors (${status.errors.length}):`,
+      ...status.errors.map(({ file, error }) => `  - ${file}: ${error}`),
+    );
+  }
+  return lines.join("\n");
+}
+
+function getCacheSize(rootPath: string): number | undefined {
+  const cacheDir = join(rootPath, ".lsmcp", "cache");
+  if (!existsSync(join(cacheDir, CACHE_FILES[0]))) {
+    return undefined;
+  }
+  let bytes = 0;
+  for (const file of CACHE_FILES) {
+    try {
+      bytes += statSync(join(cacheDir, file)).size;
+    } catch {
+      // -wal and -shm only exist while a connection is open
+    }
+  }
+  return bytes;
+}
+
+/**
+ * Collect the status of the index of a root, loading it from the on-disk
+ * cache when this process has not opened it yet
+ */
+export async function getIndexStatus(
+  rootPath: string,
+  context?: McpContext,
+): Promise<IndexStatus> {
+  if (getIndexStats(rootPath).totalFiles === 0 && context?.lspClient) {
+    await getOrCreateIndex(rootPath, context)?.loadIndexFromCache();
+  }
+  const stats = getIndexStats(rootPath);
+
+  const byKind = new Map<string, number>();
+  const byLanguage = new Map<string, { files: number; symbols: number }>();
+  for (const { file, byKind: kinds } of getSymbolCounts(rootPath)) {
+    const language = getLanguageIdFromPath(file) ?? "other";
+    const entry = byLanguage.get(language) ?? { files: 0, symbols: 0 };
+    entry.files++;
+    for (const [kind, count] of kinds) {
+      const name = getSymbolKindName(kind);
+      byKind.set(name, (byKind.get(name) ?? 0) + count);
+      entry.symbols += count;
+    }
+    byLanguage.set(language, entry);
+  }
+
+  const errors = getIndexErrors(rootPath);
+  const status: IndexStatus = {
+    rootPath,
+    files: stats.totalFiles,
+    symbols: stats.totalSymbols,
+    byKind: Array.from(byKind, ([kind, count]) => ({ kind, count })).sort(
+      (a, b) => b.count - a.count,
+    ),
+    byLanguage: Array.from(byLanguage, ([language, entry]) => ({
+      language,
+      ...entry,
+    })).sort((a, b) => b.symbols - a.symbols),
+    lastIndexedAt: stats.lastIndexedAt,
+    lastIncrementalAt: stats.lastIncrementalAt,
+    lastRun: stats.lastRun,
+    ...(await findStaleFiles(rootPath)),
+    notIndexed: [],
+    diskBytes: getCacheSize(rootPath),
+    errors,
+  };
+
+  const resolved = resolveIndexPattern(rootPath, context);
+  if ("error" in resolved) {
+    status.patternError = resolved.error;
+  } else {
+    const config = loadIndexConfig(rootPath);
+    const rules = new IgnoreRules(rootPath, {
+      patterns: config.ignorePatterns,
+    });
+    const known = new Set([
+      ...getIndexedFiles(rootPath),
+      ...errors.map(({ file }) => file),
+    ]);
+    status.notIndexed = (await findFilesToIndex(rootPath, resolved.pattern))
+      .filter((file) => !known.has(file) && !rules.isIgnored(file))
+      .sort();
+  }
+  return status;
+}
+
+export const indexStatusTool: McpToolDef<typeof schema> = {
+  name: "index_status",
+  description:
+    "Report the health of the symbol index: indexed files, symbol counts per kind and language, " +
+    "when it was last fully and incrementally indexed, files changed or deleted since, " +
+    "matching files that are not indexed, on-disk size, and per-file indexing errors. " +
+    "Use it to tell whether empty search results mean no matches or a missing or stale index.",
+  schema,
+  execute: async ({ root }, context) => {
+    const rootPath = root || process.cwd();
+    return formatIndexStatus(await getIndexStatus(rootPath, context));
+  },
+};
@@ -833,6 +833,66 @@ describe("searchSymbolsTool", () => {
         sourceLibrary: undefined,
       });
     });
+
+    it("should parse structured filters from the query text", async () => {
+      vi.mocked(IndexerAdapter.querySymbols).mockReturnValue([
+        {
+          name: "Save",
+          kind: SymbolKind.Method,
+          containerName: "User",
+          location: {
+            uri: "file:///test/api/handlers/user.go",
+            range: {
+              start: { line: 3, character: 0 },
+              end: { line: 5, character: 1 },
+            },
+          },
+        },
+        {
+          name: "Save",
+          kind: SymbolKind.Method,
+          containerName: "User",
+          location: {
+            uri: "file:///test/models/user.go",
+            range: {
+              start: { line: 8, character: 0 },
+              end: { line: 9, character: 1 },
+            },
+          },
+        },
+      ]);
+
+      const result = await searchSymbolsTool.execute({
+        query: "kind:method container:User file:**/handlers/*.go Save",
+        root: "/test",
+        includeChildren: true,
+        includeExternal: false,
+        onlyExternal: false,
+      } as any);
+
+      expect(IndexerAdapter.querySymbols).toHaveBeenCalledWith(
+        "/test",
+        expect.objectContaining({
+          name: "Save",
+          kind: SymbolKind.Method,
+          containerName: "User",
+          file: undefined,
+        }),
+      );
+      expect(result).toContain("Found 1 symbol(s)");
+      expect(result).toContain("api/handlers/user.go");
+      expect(result).not.toContain("models/user.go");
+    });
+
+    it("should report invalid structured filters", async () => {
+      const result = await searchSymbolsTool.execute({
+        query: "exported:maybe Save",
+        root: "/test",
+      } as any);
+
+      expect(result).toContain("Supported filters");
+      expect(IndexerAdapter.querySymbols).not.toHaveBeenCalled();
+    });
   });
 
   describe("Auto-indexing", () => {
@@ -992,5 +1052,84 @@ describe("searchSymbolsTool", () => {
       expect(result).not.toContain("Symbol10");
       expect(result).toContain("... and 50 more results");
     });
+
+    it("should continue from a cursor with the same query", async () => {
+      const symbols = Array.from({ length: 25 }, (_, i) => ({
+        name: `Symbol${i}`,
+        kind: SymbolKind.Class,
+        location: {
+          uri: `file:///test/file${i}.ts`,
+          range: {
+            start: { line: 0, character: 0 },
+            end: { line: 10, character: 0 },
+          },
+        },
+      }));
+      vi.mocked(IndexerAdapter.querySymbols).mockReturnValue(symbols);
+
+      const first = await searchSymbolsTool.execute({
+        kind: "Class",
+        root: "/test",
+      } as any);
+      const cursor = /cursor: "([^"]+)"/.exec(first)![1];
+
+      const second = await searchSymbolsTool.execute({
+        kind: "Class",
+        root: "/test",
+        cursor,
+      } as any);
+      expect(second).toContain("11. Symbol10");
+      expect(second).toContain("20. Symbol19");
+      expect(second).not.toContain("Symbol20");
+      expect(second).toContain("Showing 11-20 of 25.");
+
+      await expect(
+        searchSymbolsTool.execute({
+          kind: "Function",
+          root: "/test",
+          cursor,
+        } as any),
+      ).rejects.toThrow(/different query/);
+    });
+  });
+
+  describe("Multiple roots", () => {
+    it("should search every root from the context", async () => {
+      vi.mocked(IndexerAdapter.querySymbols).mockImplementation(
+        (rootPath: string) => [
+          {
+            name: "Handler",
+            kind: SymbolKind.Struct,
+            root: rootPath,
+            location: {
+              uri: `file://${rootPath}/handler.go`,
+              range: {
+                start: { line: 2, character: 5 },
+                end: { line: 4, character: 1 },
+              },
+            },
+          },
+        ],
+      );
+
+      const result = await searchSymbolsTool.execute(
+        { name: "Handler" } as any,
+        { roots: ["/work/api", "/work/worker"] } as any,
+      );
+
+      expect(IndexerAdapter.querySymbols).toHaveBeenCalledWith(
+        "/work/api",
+        expect.anything(),
+      );
+      expect(IndexerAdapter.querySymbols).toHaveBeenCalledWith(
+        "/work/worker",
+        expect.anything(),
+      );
+      expect(result).toContain("Found 2 symbol(s)");
+      expect(result).toContain("Root: /work/worker");
+      expect(result).toContain(
+        '--root "/work/worker" --relativePath "handler.go"',
+      );
+    });
   });
 });
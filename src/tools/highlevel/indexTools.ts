@@ -6,22 +6,30 @@ import { z } from "zod";
 import type { McpToolDef, McpContext } from "@internal/types";
 import { debugLogWithPrefix } from "../../utils/debugLog.ts";
 import {
-  querySymbols,
-  getIndexStats,
-  updateIndexIncremental,
-  getOrCreateIndex,
-} from "@internal/code-indexer";
-import { glob } from "gitaware-glob";
-import { relative } from "path";
+  filterWorkspaceSymbols,
+  mergeSymbolResults,
+} from "../../utils/symbolMerge.ts";
+import {
+  formatPageInfo,
+  paginateResults,
+  queryFingerprint,
+} from "../../utils/cursor.ts";
+import { ensureIndex } from "./ensureIndex.ts";
+import {
+  SYMBOL_QUERY_FILTERS,
+  isGlobPattern,
+  matchesFileFilter,
+  parseSymbolQuery,
+  type ParsedSymbolQuery,
+} from "../../utils/symbolQueryParser.ts";
+import { querySymbols, isExportedSymbol } from "@internal/code-indexer";
+import { relative, sep } from "path";
 import { fileURLToPath } from "url";
 import {
   SYMBOL_KIND_NAMES,
   getSymbolKindName,
   parseSymbolKind,
 } from "@internal/code-indexer";
-// Remove getLSPClient - no longer needed
-import { loadIndexConfig } from "@internal/code-indexer";
-import { getAdapterDefaultPattern } from "@internal/code-indexer";
 
 // Index management tools removed - now using internal functions from @internal/code-indexer
 
@@ -35,13 +43,14 @@ const searchSymbolSchema = z.object({
   query: z
     .string()
     .describe(
-      "Symbol name or pattern to search for (supports partial matching)",
+      "Symbol name to search for (fuzzy matching, e.g. 'prcUsrs' finds 'processUsers'). " +
+        "May include structured filters: kind:function container:User file:**/handlers/*.go exported:true",
     )
     .optional(),
   name: z
     .string()
     .describe(
-      "Symbol name to search for (alias for query, supports partial matching)",
+      "Symbol name to search for (alias for query, supports fuzzy matching)",
     )
     .optional(),
   kind: z
@@ -78,9 +87,50 @@ const searchSymbolSchema = z.object({
       "Filter by specific library name (e.g., 'neverthrow', '@types/node')",
     )
     .optional(),
+  includeLsp: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Also query workspace/symbol on the language server and merge the results (deduplicated by location). " +
+        "Used automatically when the index finds nothing.",
+    ),
   root: z.string().describe("Root directory for the project").optional(),
+  limit: z
+    .number()
+    .int()
+    .positive()
+    .default(10)
+    .describe("Maximum number of symbols to show per page"),
+  cursor: z
+    .string()
+    .describe("Opaque cursor from a previous response to fetch the next page")
+    .optional(),
 });
 
+const searchSymbolsOutputSchema = {
+  total: z.number().describe("Number of matching symbols before paging"),
+  symbols: z.array(
+    z.object({
+      name: z.string(),
+      kind: z.string(),
+      containerName: z.string().optional(),
+      root: z.string(),
+      relativePath: z.string(),
+      line: z.number().describe("1-based"),
+      column: z.number().describe("1-based"),
+      detail: z.string().optional(),
+      deprecated: z.boolean().optional(),
+      source: z
+        .enum(["index", "lsp"])
+        .describe("Whether the symbol came from the index or the server"),
+    }),
+  ),
+  nextCursor: z
+    .string()
+    .optional()
+    .describe("Pass as cursor to fetch the next page"),
+};
+
 export const searchSymbolsTool: McpToolDef<typeof searchSymbolSchema> = {
   name: "search_symbols",
   description:
@@ -92,6 +142,7 @@ export const searchSymbolsTool: McpToolDef<typeof searchSymbolSchema> = {
     "Constructor, Enum, Interface, Function, Variable, Constant, String, Number, Boolean, Array, Object, Key, " +
     "Null, EnumMember, Struct, Event, Operator, TypeParameter.",
   schema: searchSymbolSchema,
+  outputSchema: searchSymbolsOutputSchema,
   execute: async (
     {
       query,
@@ -103,157 +154,62 @@ export const searchSymbolsTool: McpToolDef<typeof searchSymbolSchema> = {
       includeExternal,
       onlyExternal,
       sourceLibrary,
+      includeLsp,
       root,
+      limit = 10,
+      cursor,
     },
     context?: McpContext,
   ) => {
-    const rootPath = root || process.cwd();
-
-    // Get index stats first
-    const stats = getIndexStats(rootPath);
-    if (stats.totalFiles === 0) {
-      // Auto-create index if it doesn't exist
-      debugLogWithPrefix(
-        "search_symbol_from_index",
-        "No index found. Creating initial index...",
-      );
-
-      // Check if LSP client is initialized
-      // Get or create index
-      // Pass context which includes fs (FileSystemApi) and lspClient
-      const index = getOrCreateIndex(rootPath, context);
-      if (!index) {
-        return `Error: Failed to create symbol index. LSP client may not be properly initialized.`;
+    // Without an explicit root, search every workspace root
+    const rootPaths = root
+      ? [root]
+      : context?.roots && context.roots.length > 0
+        ? context.roots
+        : [process.cwd()];
+    const rootPath = rootPaths[0];
+
+    for (const indexRoot of rootPaths) {
+      const error = await ensureIndex(indexRoot, context);
+      if (error && rootPaths.length === 1) {
+        return error;
       }
-
-      // Determine pattern for initial indexing
-      let pattern: string;
-      const config = loadIndexConfig(rootPath);
-
-      // Priority: 1. files from config, 2. files from context, 3. preset defaults, 4. empty (no auto-indexing)
-      if (config?.files && config.files.length > 0) {
-        pattern = config.files.join(",");
+      if (error) {
         debugLogWithPrefix(
           "search_symbol_from_index",
-          `Using patterns from config.files: ${pattern}`,
+          `Skipping root ${indexRoot}: ${error}`,
         );
-      } else if (
-        context?.config?.files &&
-        Array.isArray(context.config.files)
-      ) {
-        // Check if files are provided in context (from preset)
-        pattern = (context.config.files as string[]).join(",");
-        debugLogWithPrefix(
-          "search_symbol_from_index",
-          `Using patterns from context.config.files: ${pattern}`,
-        );
-      } else if (context?.config?.preset) {
-        // Use preset-specific patterns
-        const presetId = context.config.preset as string;
-        pattern = getAdapterDefaultPattern(presetId);
-        if (!pattern) {
-          // Preset not found or has no default patterns
-          debugLogWithPrefix(
-            "search_symbol_from_index",
-            `Unknown preset '${presetId}' or preset has no default patterns`,
-          );
-          return `Unknown preset '${presetId}' or preset has no default patterns. Please specify 'files' in your .lsmcp/config.json`;
-        }
-        debugLogWithPrefix(
-          "search_symbol_from_index",
-          `Using patterns from preset '${presetId}': ${pattern}`,
-        );
-      } else {
-        // No preset or files configured - don't auto-index
-        debugLogWithPrefix(
-          "search_symbol_from_index",
-          "No file patterns configured. Please specify 'files' or 'preset' in config.",
-        );
-        return "No file patterns configured. Please specify 'files' or 'preset' in your .lsmcp/config.json";
-      }
-
-      // Determine concurrency
-      const concurrency = config?.settings?.indexConcurrency || 5;
-
-      // Find files to index
-      const files: string[] = [];
-      // Handle patterns with braces properly (e.g., **/*.{ts,tsx})
-      const patterns =
-        pattern.includes("{") && pattern.includes("}")
-          ? [pattern]
-          : pattern.split(",").map((p) => p.trim());
-
-      for (const p of patterns) {
-        for await (const file of glob(p, { cwd: rootPath })) {
-          if (typeof file === "string") {
-            files.push(file);
-          } else if (file && typeof file === "object" && "name" in file) {
-            files.push((file as any).name);
-          }
-        }
-      }
-
-      if (files.length === 0) {
-        return `No files found matching pattern: ${pattern}`;
       }
+    }
 
-      debugLogWithPrefix(
-        "search_symbol_from_index",
-        `Indexing ${files.length} files...`,
-      );
+    // Structured filters (kind:, container:, file:, exported:) may be
+    // embedded in the query text; explicit parameters take precedence.
+    // Support both 'name' and 'query' parameters
+    let parsedQuery: ParsedSymbolQuery;
+    try {
+      parsedQuery = parseSymbolQuery(name || query || "");
+    } catch (error) {
+      const errorMessage =
+        error instanceof Error ? error.message : String(error);
+      return `Error: ${errorMessage}
 
-      // Perform initial indexing
-      const startTime = Date.now();
-      await index.indexFiles(files, concurrency, {
-        onProgress: (progress: any) => {
-          if (
-            progress.completed % 10 === 0 ||
-            progress.completed === progress.total
-          ) {
-            debugLogWithPrefix(
-              "search_symbol_from_index",
-              `Progress: ${progress.completed}/${progress.total} files`,
-            );
-          }
-        },
-      });
+Supported filters: ${SYMBOL_QUERY_FILTERS.map((key) => `${key}:`).join(", ")}
 
-      const stats = index.getStats();
-      const duration = Date.now() - startTime;
-      debugLogWithPrefix(
-        "search_symbol_from_index",
-        `Initial indexing completed: ${stats.totalFiles} files, ${stats.totalSymbols} symbols in ${duration}ms`,
-      );
-    } else {
-      // Auto-update index with incremental changes if it already exists
-      try {
-        // Always pass context (which may be undefined)
-        const updateResult = await updateIndexIncremental(rootPath, context);
-        if (updateResult.success) {
-          const updatedCount = updateResult.updated.length;
-          const removedCount = updateResult.removed.length;
-          if (updatedCount > 0 || removedCount > 0) {
-            debugLogWithPrefix(
-              "search_symbol_from_index",
-              `Auto-updated index: ${updatedCount} files updated, ${removedCount} files removed`,
-            );
-          }
-        }
-      } catch (error) {
-        // Log error but continue with search
-        debugLogWithPrefix(
-          "search_symbol_from_index",
-          `Failed to auto-update index: ${error}`,
-        );
-      }
+Example: kind:function container:User file:**/handlers/*.go exported:true Save`;
     }
+    const fileFilter = file ?? parsedQuery.file;
+    const kindFilter =
+      kind !== undefined && kind !== null && kind !== ""
+        ? kind
+        : parsedQuery.kind;
 
-    // Build query (use 'query' parameter as alias for 'name')
+    // Build query
     const searchQuery: any = {
-      name: name || query, // Support both 'name' and 'query' parameters
-      containerName,
+      name: parsedQuery.text,
+      containerName: containerName ?? parsedQuery.containerName,
       includeChildren,
-      file,
+      // Glob patterns are applied to the results below
+      file: fileFilter && !isGlobPattern(fileFilter) ? fileFilter : undefined,
       includeExternal,
       onlyExternal,
       sourceLibrary,
@@ -261,9 +217,9 @@ export const searchSymbolsTool: McpToolDef<typeof searchSymbolSchema> = {
 
     // Use the parseSymbolKind function to handle case-insensitive strings
     // If kind is not specified, search all symbol kinds
-    if (kind !== undefined && kind !== null && kind !== "") {
+    if (kindFilter !== undefined) {
       try {
-        const parsedKinds = parseSymbolKind(kind);
+        const parsedKinds = parseSymbolKind(kindFilter);
         searchQuery.kind =
           parsedKinds && parsedKinds.length === 1
             ? parsedKinds[0]
@@ -287,37 +243,130 @@ Examples:
     // If kind is not specified, don't set it in searchQuery to search all kinds
 
     // Execute query
-    const results = querySymbols(rootPath, searchQuery);
+    const indexResults = rootPaths.flatMap((indexRoot) =>
+      querySymbols(indexRoot, searchQuery),
+    );
+    let results = mergeSymbolResults(indexResults, []);
+
+    // The language server may know generated or external symbols the index
+    // misses, so merge its results in when asked or when the index is empty
+    const lspClient = context?.lspClient;
+    if (
+      searchQuery.name &&
+      !onlyExternal &&
+      lspClient?.getWorkspaceSymbols &&
+      (includeLsp || indexResults.length === 0)
+    ) {
+      try {
+        const lspSymbols = await lspClient.getWorkspaceSymbols(
+          searchQuery.name,
+          { signal: context?.signal },
+        );
+        results = mergeSymbolResults(
+          indexResults,
+          rootPaths.flatMap((indexRoot) =>
+            filterWorkspaceSymbols(indexRoot, lspSymbols ?? [], searchQuery),
+          ),
+        );
+      } catch (error) {
+        debugLogWithPrefix(
+          "search_symbol_from_index",
+          `workspace/symbol failed: ${error}`,
+        );
+      }
+    }
+
+    // Index symbols carry their root; language server results are matched
+    // to the most specific root containing them
+    const rootOf = (symbol: { root?: string; location: { uri: string } }) => {
+      if (symbol.root) {
+        return symbol.root;
+      }
+      const filePath = fileURLToPath(symbol.location.uri);
+      const containing = rootPaths
+        .filter((r) => filePath.startsWith(r + sep))
+        .sort((a, b) => b.length - a.length);
+      return containing[0] ?? rootPath;
+    };
+
+    if (fileFilter && isGlobPattern(fileFilter)) {
+      results = results.filter((symbol) =>
+        matchesFileFilter(
+          relative(rootOf(symbol), fileURLToPath(symbol.location.uri)),
+          fileFilter,
+        ),
+      );
+    }
+    if (parsedQuery.exported !== undefined) {
+      results = results.filter(
+        (symbol) => isExportedSymbol(symbol) === parsedQuery.exported,
+      );
+    }
 
     if (results.length === 0) {
+      context?.setStructuredContent?.({ total: 0, symbols: [] });
       return "No symbols found matching the query.";
     }
 
+    const page = paginateResults(results, {
+      cursor,
+      limit,
+      fingerprint: queryFingerprint("search_symbols", {
+        query,
+        name,
+        kind,
+        file,
+        containerName,
+        includeChildren,
+        includeExternal,
+        onlyExternal,
+        sourceLibrary,
+        includeLsp,
+        roots: rootPaths,
+      }),
+    });
+
     // Format results with LSP tool guidance
     let output = `Found ${results.length} symbol(s) matching your search:\n\n`;
+    const structuredSymbols: Record<string, unknown>[] = [];
 
-    // Show up to 10 results with detailed guidance
-    const displayCount = Math.min(results.length, 10);
-
-    for (let i = 0; i < displayCount; i++) {
-      const symbol = results[i];
+    for (const [index, symbol] of page.items.entries()) {
+      const symbolRoot = rootOf(symbol);
       const filePath = fileURLToPath(symbol.location.uri);
-      const relativePath = relative(rootPath, filePath);
+      const relativePath = relative(symbolRoot, filePath);
       const range = symbol.location.range;
       const kindName =
         getSymbolKindName(symbol.kind) || `Unknown(${symbol.kind})`;
       const line = range.start.line + 1;
       const column = range.start.character + 1;
+      structuredSymbols.push({
+        name: symbol.name,
+        kind: kindName,
+        containerName: symbol.containerName,
+        root: symbolRoot,
+        relativePath,
+        line,
+        column,
+        detail: symbol.detail,
+        deprecated: symbol.deprecated,
+        source: symbol.source,
+      });
 
-      output += `${i + 1}. ${symbol.name} [${kindName}]`;
+      output += `${page.offset + index + 1}. ${symbol.name} [${kindName}]`;
       if (symbol.containerName) {
         output += ` in ${symbol.containerName}`;
       }
       if (symbol.deprecated) {
         output += " (deprecated)";
       }
+      if (symbol.source === "lsp") {
+        output += " (from language server)";
+      }
       output += `\n`;
       output += `   Location: ${relativePath}:${line}:${column}\n`;
+      if (rootPaths.length > 1) {
+        output += `   Root: ${symbolRoot}\n`;
+      }
 
       if (symbol.detail) {
         output += `   Details: ${symbol.detail}\n`;
@@ -325,18 +374,27 @@ Examples:
 
       // Add tool guidance with get_symbol_details as primary recommendation
       output += `\n   Use get_symbol_details for comprehensive information:\n`;
-      output += `   • mcp__lsmcp__get_symbol_details --root "${rootPath}" --relativePath "${relativePath}" --line ${line} --symbol "${symbol.name}"\n`;
+      output += `   • mcp__lsmcp__get_symbol_details --root "${symbolRoot}" --relativePath "${relativePath}" --line ${line} --symbol "${symbol.name}"\n`;
       output += `\n   Or use specific LSP tools for targeted operations:\n`;
-      output += `   • View definition: lsp_get_definitions --root "${rootPath}" --relativePath "${relativePath}" --line ${line} --symbolName "${symbol.name}" --includeBody true\n`;
-      output += `   • Rename symbol: lsp_rename_symbol --root "${rootPath}" --relativePath "${relativePath}" --line ${line} --textTarget "${symbol.name}" --newName "NEW_NAME"\n`;
+      output += `   • View definition: lsp_get_definitions --root "${symbolRoot}" --relativePath "${relativePath}" --line ${line} --symbolName "${symbol.name}" --includeBody true\n`;
+      output += `   • Rename symbol: lsp_rename_symbol --root "${symbolRoot}" --relativePath "${relativePath}" --line ${line} --textTarget "${symbol.name}" --newName "NEW_NAME"\n`;
       output += `\n`;
     }
 
-    if (results.length > displayCount) {
-      output += `\n... and ${results.length - displayCount} more results.\n`;
-      output += `Refine your search with more specific criteria (name, kind, or file pattern) to see more relevant results.`;
+    const remaining = results.length - page.offset - page.items.length;
+    if (remaining > 0) {
+      output += `\n... and ${remaining} more results.\n`;
+      output += `${formatPageInfo(page)}\n`;
+      output += `Or refine your search with more specific criteria (name, kind, or file pattern) to see more relevant results.`;
+    } else if (page.offset > 0) {
+      output += `\n${formatPageInfo(page)}`;
     }
 
+    context?.setStructuredContent?.({
+      total: results.length,
+      symbols: structuredSymbols,
+      nextCursor: page.nextCursor,
+    });
     return output;
   },
 };
@@ -347,11 +405,17 @@ Examples:
 
 import { getProjectOverviewTool } from "./projectOverview.ts";
 import { createGetSymbolDetailsTool } from "./getSymbolDetails.ts";
+import { getIgnoreRulesTool } from "./ignoreRules.ts";
+import { indexStatusTool } from "./indexStatus.ts";
+import { semanticSearchSymbolsTool } from "./semanticSearch.ts";
 
 // Export index tools - only user-facing tools
 export const indexTools = [
   getProjectOverviewTool, // Quick project overview with statistics
   searchSymbolsTool, // Unified symbol search tool (combines search_symbol_from_index, find_symbols, query_symbols)
+  getIgnoreRulesTool, // Effective .gitignore/.lsmcpignore rules of the index
+  indexStatusTool, // Whether the index is built, fresh and complete
+  semanticSearchSymbolsTool, // Symbol search by meaning (embeddings of names and doc comments)
 ];
 
 // Export function to create symbol details tool with LSP client
@@ -0,0 +1,92 @@
+import { describe, it, expect, beforeEach } from "vitest";
+import { mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  formatDependencyReports,
+  inspectDependencies,
+} from "./inspectDependencies.ts";
+
+describe("inspect_dependencies", () => {
+  let root: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-deps-"));
+    writeFileSync(
+      join(root, "go.mod"),
+      [
+        "module example.com/app",
+        "",
+        "go 1.22",
+        "",
+        "require (",
+        "\tgithub.com/spf13/cobra v1.8.0",
+        "\tgolang.org/x/sys v0.20.0 // indirect",
+        "\texample.com/shared v0.3.0",
+        ")",
+        "",
+        "replace example.com/shared => ../shared",
+        "",
+      ].join("\n"),
+    );
+    writeFileSync(
+      join(root, "go.sum"),
+      "github.com/spf13/cobra v1.8.0 h1:abc=\ngolang.org/x/sys v0.20.0 h1:def=\n",
+    );
+    writeFileSync(
+      join(root, "package.json"),
+      JSON.stringify({
+        name: "app-web",
+        dependencies: { zod: "^3.22.0" },
+        devDependencies: { vitest: "^1.0.0" },
+      }),
+    );
+  });
+
+  const request = (overrides: Record<string, unknown> = {}) => ({
+    root,
+    ecosystem: "auto" as const,
+    includeIndirect: false,
+    resolve: false,
+    ...overrides,
+  });
+
+  it("should report every manifest found", async () => {
+    const reports = await inspectDependencies(request());
+    const output = formatDependencyReports(reports, {
+      includeIndirect: false,
+    });
+
+    expect(reports.map((r) => r.ecosystem)).toEqual(["go", "npm"]);
+    expect(output).toContain("go.mod (example.com/app, go 1.22)");
+    expect(output).toContain("Direct (2):\n  github.com/spf13/cobra v1.8.0");
+    expect(output).toContain("  example.com/shared v0.3.0 => ../shared");
+    expect(output).toContain(
+      "Indirect: 1 (use includeIndirect: true to list)",
+    );
+    expect(output).toContain(
+      "Replacements (1):\n  example.com/shared => ../shared",
+    );
+    expect(output).toContain("package.json (app-web, no package-lock.json)");
+    expect(output).toContain("  zod ^3.22.0 (not installed)");
+    expect(output).toContain("  vitest ^1.0.0 (not installed) [dev]");
+  });
+
+  it("should filter by ecosystem and name", async () => {
+    const reports = await inspectDependencies(request({ ecosystem: "go" }));
+    const output = formatDependencyReports(reports, {
+      filter: "x/sys",
+      includeIndirect: true,
+    });
+
+    expect(reports).toHaveLength(1);
+    expect(output).toContain("Direct (0):");
+    expect(output).toContain("Indirect (1):\n  golang.org/x/sys v0.20.0");
+  });
+
+  it("should fail when no manifest matches", async () => {
+    await expect(
+      inspectDependencies(request({ ecosystem: "cargo" })),
+    ).rejects.toThrow(/No cargo manifest found/);
+  });
+});
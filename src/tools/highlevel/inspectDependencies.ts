@@ -0,0 +1,345 @@
+/**
+ * High-level tool for inspecting declared dependencies
+ * Reads go.mod/go.sum, package.json and Cargo.toml (with their lock files) and
+ * reports direct and indirect dependencies, versions and replacements
+ */
+
+import { z } from "zod";
+import { existsSync, readFileSync } from "fs";
+import { join } from "path";
+import type { McpContext, McpToolDef } from "@internal/types";
+import { debug } from "@internal/lsp-client";
+import { runGoCommand } from "../../utils/goCommand.ts";
+import {
+  cargoDependencies,
+  goDependencies,
+  npmDependencies,
+  npmOverrides,
+  parseCargoLock,
+  parseGoModFile,
+  parseGoSum,
+  parsePackageLock,
+  type ManifestDependency,
+} from "../../utils/dependencyManifests.ts";
+
+const GO_LIST_TIMEOUT = 60_000;
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project"),
+  ecosystem: z
+    .enum(["auto", "go", "npm", "cargo"])
+    .optional()
+    .default("auto")
+    .describe("Manifest to read (auto: every manifest found in root)"),
+  filter: z
+    .string()
+    .optional()
+    .describe("Only show dependencies whose name contains this text"),
+  includeIndirect: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe("List indirect dependencies too (otherwise only counted)"),
+  resolve: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe(
+      "Go only: run `go list -m all` to report the versions the build actually selects",
+    ),
+});
+
+type InspectDependenciesRequest = z.infer<typeof schema>;
+
+export interface ManifestReport {
+  ecosystem: "go" | "npm" | "cargo";
+  manifest: string;
+  /** Module, package or crate name */
+  name?: string;
+  details: string[];
+  dependencies: ManifestDependency[];
+  /** Replace directives / overrides / patches as written */
+  replacements: string[];
+}
+
+function joinDefined(...parts: (string | undefined)[]): string {
+  return parts.filter(Boolean).join(" ");
+}
+
+function readOptional(path: string): string | undefined {
+  try {
+    return readFileSync(path, "utf-8");
+  } catch {
+    return undefined;
+  }
+}
+
+/**
+ * Selected module versions from `go list -m all` (path -> version)
+ */
+async function listGoModules(
+  root: string,
+  context?: McpContext,
+): Promise<Map<string, { version: string; indirect: boolean }> | null> {
+  try {
+    const result = await runGoCommand(
+      ["list", "-m", "-f", "{{.Path}} {{.Version}} {{.Indirect}}", "all"],
+      { cwd: root, timeout: GO_LIST_TIMEOUT, signal: context?.signal },
+    );
+    if (result.exitCode !== 0) {
+      debug("[inspectDependencies] go list failed:", result.stderr);
+      return null;
+    }
+    const modules = new Map<string, { version: string; indirect: boolean }>();
+    for (const line of result.stdout.split("\n")) {
+      const [path, version, indirect] = line.trim().split(" ");
+      // The main module has no version
+      if (path && version && version !== "<nil>") {
+        modules.set(path, { version, indirect: indirect === "true" });
+      }
+    }
+    return modules;
+  } catch (error) {
+    debug("[inspectDependencies] go list failed:", error);
+    return null;
+  }
+}
+
+async function inspectGo(
+  root: string,
+  request: InspectDependenciesRequest,
+  context?: McpContext,
+): Promise<ManifestReport> {
+  const goMod = parseGoModFile(readFileSync(join(root, "go.mod"), "utf-8"));
+  const goSumContent = readOptional(join(root, "go.sum"));
+  const dependencies = goDependencies(
+    goMod,
+    goSumContent !== undefined ? parseGoSum(goSumContent) : undefined,
+  );
+  const details = [goMod.go && `go ${goMod.go}`, goMod.toolchain].filter(
+    (detail): detail is string => !!detail,
+  );
+  if (goSumContent === undefined) {
+    details.push("no go.sum");
+  }
+
+  if (request.resolve) {
+    const modules = await listGoModules(root, context);
+    if (modules) {
+      for (const dependency of dependencies) {
+        const selected = modules.get(dependency.name);
+        if (selected && !dependency.replacedBy) {
+          dependency.resolved = selected.version;
+        }
+      }
+      const declared = new Set(dependencies.map((d) => d.name));
+      for (const [path, selected] of modules) {
+        if (!declared.has(path)) {
+          dependencies.push({
+            name: path,
+            requested: "",
+            resolved: selected.version,
+            indirect: true,
+          });
+        }
+      }
+    } else {
+      details.push("go list -m all failed; showing go.mod versions");
+    }
+  }
+
+  return {
+    ecosystem: "go",
+    manifest: "go.mod",
+    name: goMod.module,
+    details,
+    dependencies,
+    replacements: goMod.replaces.map(
+      (r) =>
+        `${joinDefined(r.old, r.oldVersion)} => ${joinDefined(
+          r.new,
+          r.newVersion,
+        )}`,
+    ),
+  };
+}
+
+function inspectNpm(root: string): ManifestReport {
+  const packageJson = JSON.parse(
+    readFileSync(join(root, "package.json"), "utf-8"),
+  );
+  const lockContent = readOptional(join(root, "package-lock.json"));
+  const locked = lockContent
+    ? parsePackageLock(JSON.parse(lockContent))
+    : new Map<string, string>();
+
+  const resolveVersion = (name: string) => {
+    if (locked.has(name)) {
+      return locked.get(name);
+    }
+    const installed = readOptional(
+      join(root, "node_modules", name, "package.json"),
+    );
+    try {
+      return installed ? JSON.parse(installed).version : undefined;
+    } catch {
+      return undefined;
+    }
+  };
+
+  const info = npmDependencies(packageJson, resolveVersion);
+  const direct = new Set(info.dependencies.map((d) => d.name));
+  const dependencies = [...info.dependencies];
+  for (const [name, version] of locked) {
+    if (!direct.has(name)) {
+      dependencies.push({
+        name,
+        requested: "",
+        resolved: version,
+        indirect: true,
+      });
+    }
+  }
+
+  return {
+    ecosystem: "npm",
+    manifest: "package.json",
+    name: info.name,
+    details: [lockContent ? "package-lock.json" : "no package-lock.json"],
+    dependencies,
+    replacements: Object.entries(npmOverrides(packageJson)).map(
+      ([name, value]) =>
+        `${name} => ${typeof value === "string" ? value : JSON.stringify(value)}`,
+    ),
+  };
+}
+
+function inspectCargo(root: string): ManifestReport {
+  const cargoToml = readFileSync(join(root, "Cargo.toml"), "utf-8");
+  const lockContent = readOptional(join(root, "Cargo.lock"));
+  const info = cargoDependencies(
+    cargoToml,
+    lockContent ? parseCargoLock(lockContent) : undefined,
+  );
+  return {
+    ecosystem: "cargo",
+    manifest: "Cargo.toml",
+    name: info.name,
+    details: [lockContent ? "Cargo.lock" : "no Cargo.lock"],
+    dependencies: info.dependencies,
+    replacements: info.dependencies
+      .filter((d) => d.replacedBy)
+      .map((d) => `${d.name} => ${d.replacedBy}`),
+  };
+}
+
+export async function inspectDependencies(
+  request: InspectDependenciesRequest,
+  context?: McpContext,
+): Promise<ManifestReport[]> {
+  const want = (ecosystem: string, manifest: string) =>
+    (request.ecosystem === "auto" || request.ecosystem === ecosystem) &&
+    existsSync(join(request.root, manifest));
+
+  const reports: ManifestReport[] = [];
+  if (want("go", "go.mod")) {
+    reports.push(await inspectGo(request.root, request, context));
+  }
+  if (want("npm", "package.json")) {
+    reports.push(inspectNpm(request.root));
+  }
+  if (want("cargo", "Cargo.toml")) {
+    reports.push(inspectCargo(request.root));
+  }
+
+  if (reports.length === 0) {
+    throw new Error(
+      request.ecosystem === "auto"
+        ? `No go.mod, package.json or Cargo.toml found in ${request.root}`
+        : `No ${request.ecosystem} manifest found in ${request.root}`,
+    );
+  }
+  return reports;
+}
+
+function formatDependency(dependency: ManifestDependency): string {
+  const parts = [`  ${dependency.name}`];
+  if (dependency.requested) {
+    parts.push(dependency.requested);
+  }
+  if (dependency.replacedBy) {
+    parts.push(`=> ${dependency.replacedBy}`);
+  }
+  if (
+    dependency.resolved &&
+    dependency.resolved !== dependency.requested &&
+    !dependency.replacedBy
+  ) {
+    parts.push(
+      dependency.requested
+        ? `(resolved ${dependency.resolved})`
+        : dependency.resolved,
+    );
+  } else if (!dependency.resolved && !dependency.replacedBy) {
+    parts.push("(not installed)");
+  }
+  if (dependency.group) {
+    parts.push(`[${dependency.group}]`);
+  }
+  if (dependency.missingChecksum) {
+    parts.push("[missing go.sum entry]");
+  }
+  return parts.join(" ");
+}
+
+export function formatDependencyReports(
+  reports: ManifestReport[],
+  options: { filter?: string; includeIndirect: boolean },
+): string {
+  const sections = reports.map((report) => {
+    const matching = report.dependencies.filter(
+      (d) => !options.filter || d.name.includes(options.filter),
+    );
+    const direct = matching.filter((d) => !d.indirect);
+    const indirect = matching.filter((d) => d.indirect);
+    const details = [report.name, ...report.details].filter(Boolean);
+    const lines = [`${report.manifest} (${details.join(", ")})`];
+
+    lines.push(`Direct (${direct.length}):`);
+    lines.push(...direct.map(formatDependency));
+
+    if (options.includeIndirect) {
+      lines.push(`Indirect (${indirect.length}):`);
+      lines.push(...indirect.map(formatDependency));
+    } else if (indirect.length > 0) {
+      lines.push(
+        `Indirect: ${indirect.length} (use includeIndirect: true to list)`,
+      );
+    }
+
+    if (report.replacements.length > 0) {
+      lines.push(`Replacements (${report.replacements.length}):`);
+      lines.push(...report.replacements.map((r) => `  ${r}`));
+    }
+    return lines.join("\n");
+  });
+  return sections.join("\n\n");
+}
+
+/**
+ * Create inspect_dependencies tool
+ */
+export function createInspectDependenciesTool(): McpToolDef<typeof schema> {
+  return {
+    name: "inspect_dependencies",
+    description:
+      "List the project's declared dependencies from go.mod/go.sum, package.json or Cargo.toml: " +
+      "direct vs indirect, requested vs resolved versions, and replace directives/overrides/patches. " +
+      "Check this before suggesting upgrades or adding imports.",
+    schema,
+    execute: async (args, context?: McpContext) => {
+      const reports = await inspectDependencies(args, context);
+      return formatDependencyReports(reports, args);
+    },
+  };
+}
@@ -0,0 +1,162 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import { mkdirSync, mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+
+vi.mock("@internal/code-indexer", () => ({
+  getIndexedFiles: vi.fn(),
+}));
+
+import { getIndexedFiles } from "@internal/code-indexer";
+import {
+  formatProjectDiagnostics,
+  getProjectDiagnosticsImpl,
+} from "./projectDiagnostics.ts";
+
+const error = (message: string, line = 0) => ({
+  range: {
+    start: { line, character: 0 },
+    end: { line, character: 1 },
+  },
+  message,
+  severity: 1,
+});
+
+function createClient(overrides: Record<string, unknown> = {}) {
+  return {
+    getServerCapabilities: vi.fn().mockReturnValue({}),
+    openDocument: vi.fn(),
+    closeDocument: vi.fn(),
+    getDiagnostics: vi.fn().mockReturnValue([]),
+    ...overrides,
+  } as any;
+}
+
+describe("get_project_diagnostics", () => {
+  let root: string;
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    root = mkdtempSync(join(tmpdir(), "lsmcp-project-diagnostics-"));
+    writeFileSync(join(root, "a.go"), "package a\n");
+    writeFileSync(join(root, "b.go"), "package b\n");
+  });
+
+  it("should use workspace/diagnostic when supported", async () => {
+    const client = createClient({
+      getServerCapabilities: vi.fn().mockReturnValue({
+        diagnosticProvider: { workspaceDiagnostics: true },
+      }),
+      pullWorkspaceDiagnostics: vi.fn().mockResolvedValue([
+        {
+          uri: pathToFileURL(join(root, "a.go")).toString(),
+          diagnostics: [error("undefined: x")],
+        },
+        { uri: "file:///outside/root.go", diagnostics: [error("ignored")] },
+      ]),
+    });
+
+    const result = await getProjectDiagnosticsImpl(
+      { root, severityFilter: "all" },
+      client,
+    );
+
+    expect(result.method).toBe("workspace");
+    expect(result.totalErrors).toBe(1);
+    expect(result.files.map((f) => f.filePath)).toEqual(["a.go"]);
+    expect(client.openDocument).not.toHaveBeenCalled();
+  });
+
+  it("should check indexed files in batches otherwise", async () => {
+    vi.mocked(getIndexedFiles).mockReturnValue(["a.go", "b.go"]);
+    const client = createClient({
+      getDiagnostics: vi.fn((uri: string) =>
+        uri.endsWith("b.go") ? [error("unused variable", 2)] : [],
+      ),
+    });
+
+    const result = await getProjectDiagnosticsImpl(
+      { root, severityFilter: "all", concurrency: 1 },
+      client,
+    );
+
+    expect(result.method).toBe("batched");
+    expect(result.checkedFiles).toBe(2);
+    expect(client.openDocument).toHaveBeenCalledTimes(2);
+    expect(formatProjectDiagnostics(result)).toContain(
+      "b.go (1 error)\n  ERROR 3:1 unused variable",
+    );
+  });
+
+  it("should leave out diagnostics recorded in the baseline", async () => {
+    vi.mocked(getIndexedFiles).mockReturnValue(["a.go", "b.go"]);
+    mkdirSync(join(root, ".lsmcp"));
+    writeFileSync(
+      join(root, ".lsmcp", "diagnostics-baseline.json"),
+      JSON.stringify({
+        version: 1,
+        files: {
+          "b.go": [{ severity: "error", message: "unused variable", count: 1 }],
+        },
+      }),
+    );
+    const client = createClient({
+      getDiagnostics: vi.fn((uri: string) =>
+        uri.endsWith("b.go")
+          ? [error("unused variable", 2), error("undefined: y", 4)]
+          : [],
+      ),
+    });
+
+    const result = await getProjectDiagnosticsImpl(
+      { root, severityFilter: "all" },
+      client,
+    );
+    expect(result.totalErrors).toBe(1);
+    expect(result.baselined).toBe(1);
+    expect(formatProjectDiagnostics(result)).toContain(
+      "Hidden 1 diagnostic recorded in the baseline",
+    );
+
+    const all = await getProjectDiagnosticsImpl(
+      { root, severityFilter: "all", includeBaseline: true },
+      client,
+    );
+    expect(all.totalErrors).toBe(2);
+  });
+
+  it("should page files with a cursor", () => {
+    const files = ["a.go", "b.go", "c.go"].map((filePath) => ({
+      filePath,
+      diagnostics: [
+        {
+          severity: "error" as const,
+          line: 1,
+          column: 1,
+          endLine: 1,
+          endColumn: 2,
+          message: "broken",
+        },
+      ],
+    }));
+    const result = {
+      message: "Found 3 errors and 0 warnings in 3 files",
+      totalErrors: 3,
+      totalWarnings: 0,
+      files,
+      method: "workspace" as const,
+    };
+
+    const first = formatProjectDiagnostics(result, { limit: 2 });
+    expect(first).toContain("b.go");
+    expect(first).not.toContain("c.go");
+    expect(first).toContain("... 1 more files with diagnostics");
+    const cursor = /cursor: "([^"]+)"/.exec(first)![1];
+
+    const second = formatProjectDiagnostics(result, { limit: 2, cursor });
+    expect(second).toContain("c.go");
+    expect(second).not.toContain("a.go");
+    expect(second).toContain("Showing 3-3 of 3.");
+  });
+});
@@ -0,0 +1,442 @@
+/**
+ * High-level tool for workspace-wide diagnostics
+ * Uses workspace/diagnostic when the server supports it, otherwise opens
+ * indexed files in batches
+ */
+
+import { z } from "zod";
+import { relative, isAbsolute } from "path";
+import { fileURLToPath } from "url";
+import type { McpToolDef, McpContext } from "@internal/types";
+import type { LSPClient } from "@internal/lsp-client";
+import { debug } from "@internal/lsp-client";
+import { getIndexedFiles } from "@internal/code-indexer";
+import {
+  collectDiagnosticsForFiles,
+  getProjectFiles,
+  summarizeFileDiagnostics,
+  toFileDiagnostics,
+  type FileDiagnostic,
+  type GetAllDiagnosticsSuccess,
+} from "../lsp/allDiagnostics.ts";
+import {
+  DIAGNOSTICS_BATCH_SIZE,
+  MAX_DIAGNOSTICS_PER_FILE,
+  MAX_FILES_TO_SHOW,
+} from "../../constants/diagnostics.ts";
+import {
+  findingsByRelativePath,
+  goAnalyzerPolicy,
+  runGoAnalyzers,
+} from "../../utils/goAnalyzers.ts";
+import {
+  applyBaseline,
+  baselineNote,
+  loadBaseline,
+} from "../../utils/diagnosticsBaseline.ts";
+import {
+  formatPageInfo,
+  paginateResults,
+  queryFingerprint,
+} from "../../utils/cursor.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project"),
+  pattern: z
+    .string()
+    .optional()
+    .describe(
+      "Glob pattern for files to check when the symbol index is empty (defaults to config files)",
+    ),
+  severityFilter: z
+    .enum(["error", "warning", "all"])
+    .optional()
+    .default("all")
+    .describe("Filter diagnostics by severity"),
+  concurrency: z
+    .number()
+    .optional()
+    .describe(
+      `Number of files opened at once when falling back to per-file diagnostics (default: ${DIAGNOSTICS_BATCH_SIZE})`,
+    ),
+  limit: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .describe(
+      `Maximum number of files to show per page (default: ${MAX_FILES_TO_SHOW})`,
+    ),
+  cursor: z
+    .string()
+    .optional()
+    .describe("Opaque cursor from a previous response to fetch the next page"),
+  includeBaseline: z
+    .boolean()
+    .optional()
+    .describe(
+      "Also return diagnostics recorded in .lsmcp/diagnostics-baseline.json (default: false)",
+    ),
+});
+
+export type GetProjectDiagnosticsRequest = z.infer<typeof schema>;
+
+export const projectDiagnosticsOutputSchema = {
+  totalErrors: z.number(),
+  totalWarnings: z.number(),
+  method: z
+    .enum(["workspace", "batched", "watch"])
+    .describe(
+      "workspace/diagnostic, files checked in batches or the watch mode cache",
+    ),
+  totalFiles: z.number().describe("Files with diagnostics before paging"),
+  files: z.array(
+    z.object({
+      filePath: z.string(),
+      diagnostics: z.array(
+        z.object({
+          severity: z.enum(["error", "warning", "information", "hint"]),
+          line: z.number().describe("1-based"),
+          column: z.number().describe("1-based"),
+          endLine: z.number(),
+          endColumn: z.number(),
+          message: z.string(),
+          source: z.string().optional(),
+          code: z.union([z.string(), z.number()]).optional(),
+        }),
+      ),
+    }),
+  ),
+  nextCursor: z
+    .string()
+    .optional()
+    .describe("Pass as cursor to fetch the next page"),
+  baselined: z
+    .number()
+    .optional()
+    .describe("Diagnostics left out because the baseline records them"),
+};
+
+export interface ProjectDiagnosticsResult extends GetAllDiagnosticsSuccess {
+  method: "workspace" | "batched" | "watch";
+  checkedFiles?: number;
+  /** Analyzers run next to the server (go vet, staticcheck) */
+  analyzers?: string[];
+  analyzerErrors?: string[];
+  /** Diagnostics left out because the baseline records them */
+  baselined?: number;
+}
+
+function uriToRelativePath(root: string, uri: string): string | null {
+  try {
+    const relativePath = relative(root, fileURLToPath(uri));
+    if (relativePath.startsWith("..") || isAbsolute(relativePath)) {
+      return null;
+    }
+    return relativePath;
+  } catch {
+    return null;
+  }
+}
+
+/**
+ * Pull diagnostics for the whole workspace in a single request
+ * Returns null when the server does not support workspace/diagnostic
+ */
+async function tryWorkspaceDiagnostics(
+  request: GetProjectDiagnosticsRequest,
+  client: LSPClient,
+): Promise<ProjectDiagnosticsResult | null> {
+  const capabilities = client.getServerCapabilities();
+  if (
+    !capabilities?.diagnosticProvider?.workspaceDiagnostics ||
+    !client.pullWorkspaceDiagnostics
+  ) {
+    return null;
+  }
+
+  try {
+    const reports = await client.pullWorkspaceDiagnostics();
+    const files: FileDiagnostic[] = [];
+    for (const report of reports) {
+      const filePath = uriToRelativePath(request.root, report.uri);
+      if (!filePath) continue;
+      const diagnostics = toFileDiagnostics(
+        report.diagnostics,
+        request.severityFilter,
+      );
+      if (diagnostics.length > 0) {
+        files.push({ filePath, diagnostics });
+      }
+    }
+    return { ...summarizeFileDiagnostics(files), method: "workspace" };
+  } catch (error) {
+    debug("[getProjectDiagnostics] workspace/diagnostic failed:", error);
+    return null;
+  }
+}
+
+/**
+ * Resolve files to check: indexed files first, then config/pattern globs
+ */
+async function resolveFiles(
+  request: GetProjectDiagnosticsRequest,
+  context?: McpContext,
+): Promise<string[]> {
+  const indexed = getIndexedFiles(request.root);
+  if (indexed.length > 0 && !request.pattern) {
+    return indexed;
+  }
+
+  const patterns = request.pattern
+    ? [request.pattern]
+    : (context?.config?.files as string[] | undefined) || [];
+  if (patterns.length === 0) {
+    throw new Error(
+      "No indexed files and no pattern provided. Run search_symbols or get_project_overview first, or pass a pattern.",
+    );
+  }
+
+  const files = new Set<string>();
+  for (const pattern of patterns) {
+    for (const file of await getProjectFiles(request.root, pattern)) {
+      files.add(file);
+    }
+  }
+  return Array.from(files).sort();
+}
+
+export async function getProjectDiagnosticsImpl(
+  request: GetProjectDiagnosticsRequest,
+  client: LSPClient,
+  context?: McpContext,
+  options: { waitTimeout?: number } = {},
+): Promise<ProjectDiagnosticsResult> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  let result: ProjectDiagnosticsResult | null = await tryWorkspaceDiagnostics(
+    request,
+    client,
+  );
+  if (!result) {
+    const files = await resolveFiles(request, context);
+    const collected = await collectDiagnosticsForFiles(
+      request.root,
+      files,
+      client,
+      {
+        severityFilter: request.severityFilter,
+        concurrency: request.concurrency,
+        waitTimeout: options.waitTimeout,
+      },
+    );
+    result = { ...collected, method: "batched", checkedFiles: files.length };
+  }
+  result = await withGoAnalyzers(result, request, context);
+  return request.includeBaseline
+    ? result
+    : withoutBaselined(result, request.root, context);
+}
+
+/**
+ * Leave out the diagnostics recorded in the workspace baseline
+ */
+export async function withoutBaselined(
+  result: ProjectDiagnosticsResult,
+  root: string,
+  context?: McpContext,
+): Promise<ProjectDiagnosticsResult> {
+  const baseline = await loadBaseline(root, context);
+  if (!baseline) {
+    return result;
+  }
+  const { files, suppressed } = applyBaseline(baseline, result.files);
+  return {
+    ...result,
+    ...summarizeFileDiagnostics(files),
+    baselined: suppressed,
+  };
+}
+
+/**
+ * Merge go vet / staticcheck findings for the whole module into the result
+ */
+async function withGoAnalyzers(
+  result: ProjectDiagnosticsResult,
+  request: GetProjectDiagnosticsRequest,
+  context?: McpContext,
+): Promise<ProjectDiagnosticsResult> {
+  const policy = goAnalyzerPolicy(context);
+  if (!policy) {
+    return result;
+  }
+  const analyzed = await runGoAnalyzers(
+    request.root,
+    ["./..."],
+    policy,
+    context?.signal,
+  );
+  const files = new Map(result.files.map((file) => [file.filePath, file]));
+  for (const [filePath, diagnostics] of findingsByRelativePath(
+    request.root,
+    analyzed.diagnostics,
+  )) {
+    const existing = files.get(filePath)?.diagnostics ?? [];
+    // gopls runs some vet analyzers itself
+    const seen = new Set(existing.map((d) => `${d.line}\u0000${d.message}`));
+    const added = toFileDiagnostics(diagnostics, request.severityFilter).filter(
+      (d) => !seen.has(`${d.line}\u0000${d.message}`),
+    );
+    if (added.length > 0) {
+      files.set(filePath, { filePath, diagnostics: [...existing, ...added] });
+    }
+  }
+  return {
+    ...result,
+    ...summarizeFileDiagnostics([...files.values()]),
+    analyzers: analyzed.analyzers,
+    analyzerErrors: analyzed.errors,
+  };
+}
+
+function countBySeverity(file: FileDiagnostic): string {
+  const counts = new Map<string, number>();
+  for (const d of file.diagnostics) {
+    counts.set(d.severity, (counts.get(d.severity) ?? 0) + 1);
+  }
+  return ["error", "warning", "information", "hint"]
+    .filter((severity) => counts.has(severity))
+    .map((severity) => {
+      const count = counts.get(severity)!;
+      return `${count} ${severity}${count !== 1 ? "s" : ""}`;
+    })
+    .join(", ");
+}
+
+interface ProjectDiagnosticsPageOptions {
+  limit?: number;
+  cursor?: string;
+  fingerprint?: string;
+}
+
+function pageFiles(
+  result: ProjectDiagnosticsResult,
+  options: ProjectDiagnosticsPageOptions,
+) {
+  // Files are sorted by path, so pages stay stable while diagnostics are fixed
+  return paginateResults(result.files, {
+    cursor: options.cursor,
+    limit: options.limit ?? MAX_FILES_TO_SHOW,
+    fingerprint:
+      options.fingerprint ?? queryFingerprint("get_project_diagnostics", {}),
+  });
+}
+
+/**
+ * Structured result for the same page of files formatProjectDiagnostics
+ * shows; unlike the text, every diagnostic of a file is included
+ */
+export function structuredProjectDiagnostics(
+  result: ProjectDiagnosticsResult,
+  options: ProjectDiagnosticsPageOptions = {},
+): Record<string, unknown> {
+  const page = pageFiles(result, options);
+  return {
+    totalErrors: result.totalErrors,
+    totalWarnings: result.totalWarnings,
+    method: result.method,
+    totalFiles: page.total,
+    files: page.items,
+    nextCursor: page.nextCursor,
+    baselined: result.baselined,
+  };
+}
+
+export function formatProjectDiagnostics(
+  result: ProjectDiagnosticsResult,
+  options: ProjectDiagnosticsPageOptions = {},
+): string {
+  const checked = `${result.checkedFiles ?? 0} files checked`;
+  const source =
+    result.method === "workspace"
+      ? "workspace/diagnostic"
+      : result.method === "watch"
+        ? `watch mode, ${checked}`
+        : checked;
+  const lines = [`${result.message} (${source})`];
+  if (result.analyzers?.length) {
+    lines.push(`Also checked with: ${result.analyzers.join(", ")}`);
+  }
+  for (const error of result.analyzerErrors ?? []) {
+    lines.push(`Analyzer failed: ${error}`);
+  }
+  if (result.baselined) {
+    lines.push(baselineNote(result.baselined));
+  }
+
+  const page = pageFiles(result, options);
+  for (const file of page.items) {
+    lines.push("", `${file.filePath} (${countBySeverity(file)})`);
+    for (const d of file.diagnostics.slice(0, MAX_DIAGNOSTICS_PER_FILE)) {
+      const sourceInfo = d.source ? ` (${d.source})` : "";
+      lines.push(
+        `  ${d.severity.toUpperCase()} ${d.line}:${d.column} ${d.message}${sourceInfo}`,
+      );
+    }
+    if (file.diagnostics.length > MAX_DIAGNOSTICS_PER_FILE) {
+      lines.push(
+        `  ... ${file.diagnostics.length - MAX_DIAGNOSTICS_PER_FILE} more`,
+      );
+    }
+  }
+
+  const remaining = page.total - page.offset - page.items.length;
+  if (remaining > 0) {
+    lines.push("", `... ${remaining} more files with diagnostics`);
+  }
+  const pageInfo = formatPageInfo(page);
+  if (pageInfo) {
+    lines.push(pageInfo);
+  }
+
+  return lines.join("\n");
+}
+
+/**
+ * Create get_project_diagnostics tool with injected LSP client
+ */
+export function createGetProjectDiagnosticsTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "get_project_diagnostics",
+    description:
+      "Get diagnostics for every indexed file in the project, grouped by file and severity. " +
+      "Uses workspace/diagnostic when supported by the language server, otherwise checks files in batches. " +
+      "Use this instead of calling lsp_get_diagnostics file by file. " +
+      "Diagnostics recorded in the workspace baseline (.lsmcp/diagnostics-baseline.json) are left out unless includeBaseline is set. " +
+      "Large results are paged by file; pass the returned cursor to fetch the next page.",
+    schema,
+    outputSchema: projectDiagnosticsOutputSchema,
+    execute: async (args, context?: McpContext) => {
+      const result = await getProjectDiagnosticsImpl(args, client, context);
+      const { root, pattern, severityFilter, includeBaseline } = args;
+      const options = {
+        limit: args.limit,
+        cursor: args.cursor,
+        fingerprint: queryFingerprint("get_project_diagnostics", {
+          root,
+          pattern,
+          severityFilter,
+          includeBaseline,
+        }),
+      };
+      context?.setStructuredContent?.(
+        structuredProjectDiagnostics(result, options),
+      );
+      return formatProjectDiagnostics(result, options);
+    },
+  };
+}
@@ -11,6 +11,7 @@ vi.mock("@internal/code-indexer", () => ({
   querySymbols: vi.fn(),
   loadIndexConfig: vi.fn(),
   getAdapterDefaultPattern: vi.fn(),
+  getIndexedFiles: vi.fn(),
 }));
 
 vi.mock("fs/promises", () => ({
@@ -34,6 +35,7 @@ import { glob } from "gitaware-glob";
 // Remove getLSPClient - no longer needed
 import {
   getAdapterDefaultPattern,
+  getIndexedFiles,
   loadIndexConfig,
 } from "@internal/code-indexer";
 import { getProjectOverviewTool } from "./projectOverview";
@@ -57,6 +59,9 @@ describe("getProjectOverviewTool", () => {
 
     // Setup default config
     vi.mocked(loadIndexConfig).mockReturnValue(null as any);
+
+    // No files for the dependency graph summary
+    vi.mocked(getIndexedFiles).mockReturnValue([]);
   });
 
   it("should return project overview with basic structure", async () => {
@@ -671,4 +676,51 @@ describe("getProjectOverviewTool", () => {
     // Should indicate that Variables/Constants are excluded
     expect(result).toContain("*Variables/Constants excluded by config*");
   });
+
+  it("should list entry points, largest files and the dependency summary", async () => {
+    const mockRoot = "/test/project";
+    const symbolAt = (name: string, file: string) => ({
+      name,
+      kind: SymbolKind.Function,
+      location: {
+        uri: `file://${mockRoot}/${file}`,
+        range: {
+          start: { line: 0, character: 0 },
+          end: { line: 0, character: 0 },
+        },
+      },
+    });
+
+    vi.mocked(fs.readFile).mockImplementation(async (filePath) => {
+      if (filePath === path.join(mockRoot, "package.json")) {
+        return JSON.stringify({ name: "tool", bin: { tool: "./bin/tool.js" } });
+      }
+      throw new Error("File not found");
+    });
+    vi.mocked(IndexerAdapter.getIndexStats).mockReturnValue({
+      totalFiles: 2,
+      totalSymbols: 3,
+      indexingTime: 100,
+      lastUpdated: new Date(),
+    });
+    vi.mocked(IndexerAdapter.querySymbols).mockReturnValue([
+      symbolAt("main", "cmd/app/main.go"),
+      symbolAt("Open", "internal/store/store.go"),
+      symbolAt("Close", "internal/store/store.go"),
+    ]);
+    vi.mocked(getIndexedFiles).mockReturnValue([
+      "cmd/app/main.go",
+      "internal/store/store.go",
+    ]);
+
+    const result = await getProjectOverviewTool.execute({ root: mockRoot });
+
+    expect(result).toContain(
+      "### Entry Points:\n• bin/tool.js\n• cmd/app/main.go",
+    );
+    expect(result).toContain(
+      "### Largest Files (by symbols):\n• internal/store/store.go (2 symbols)\n• cmd/app/main.go (1 symbol)",
+    );
+    expect(result).toContain("### Dependency Graph:\n- 2 packages");
+  });
 });
@@ -15,11 +15,17 @@ import {
 import { loadIndexConfig } from "@internal/code-indexer";
 import { glob } from "gitaware-glob";
 import { debugLogWithPrefix } from "../../utils/debugLog.ts";
+import { createIndexProgressHandler } from "../../utils/progress.ts";
 import { SymbolKind } from "vscode-languageserver-types";
 import * as fs from "fs/promises";
 import * as path from "path";
 import { fileURLToPath } from "url";
 import { getProjectDiagnostics } from "./getDiagnostics.ts";
+import { analyzeDependencies } from "./dependencyGraph.ts";
+import {
+  countDependencies,
+  readGoModulePath,
+} from "../../utils/importGraph.ts";
 
 const getProjectOverviewSchema = z.object({
   root: z.string().describe("Root directory for the project").optional(),
@@ -31,6 +37,8 @@ interface ProjectInfo {
   description?: string;
   type?: string;
   dependencies?: string[];
+  /** Files named by package.json main/bin */
+  entryPoints?: string[];
 }
 
 /**
@@ -68,16 +76,132 @@ async function getProjectInfo(rootPath: string): Promise<ProjectInfo> {
       ...packageJson.devDependencies,
     }).slice(0, 10); // Limit to 10 most important
 
+    const bin =
+      typeof packageJson.bin === "string"
+        ? [packageJson.bin]
+        : Object.values(packageJson.bin ?? {});
+
     return {
       name: packageJson.name,
       version: packageJson.version,
       description: packageJson.description,
       type: detectProjectType(packageJson),
       dependencies,
+      entryPoints: [packageJson.main, ...bin].filter(
+        (entry): entry is string => typeof entry === "string",
+      ),
     };
   } catch {
-    // No package.json or error reading it
-    return {};
+    // No package.json: fall back to go.mod
+    const goModule = readGoModulePath(rootPath);
+    return goModule ? { name: goModule, type: "Go Module" } : {};
+  }
+}
+
+/**
+ * Workspace-relative path of a symbol's file
+ */
+function relativeFile(rootPath: string, uri: string): string {
+  return path.relative(rootPath, fileURLToPath(uri)).replace(/\\/g, "/");
+}
+
+/**
+ * Files with the most indexed symbols
+ */
+function getLargestFiles(
+  rootPath: string,
+  symbols: any[],
+  limit: number,
+): [string, number][] {
+  const counts = new Map<string, number>();
+  for (const symbol of symbols) {
+    if (symbol.location?.uri) {
+      const file = relativeFile(rootPath, symbol.location.uri);
+      counts.set(file, (counts.get(file) ?? 0) + 1);
+    }
+  }
+  return [...counts.entries()]
+    .sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]))
+    .slice(0, limit);
+}
+
+/**
+ * Entry points: package.json main/bin and files declaring a top-level main
+ * function (Go commands, C-style programs)
+ */
+function findEntryPoints(
+  rootPath: string,
+  symbols: any[],
+  projectInfo: ProjectInfo,
+): string[] {
+  const entries = new Set(
+    (projectInfo.entryPoints ?? []).map((entry) =>
+      path.posix.normalize(entry.replace(/\\/g, "/")),
+    ),
+  );
+  for (const symbol of symbols) {
+    if (
+      symbol.name === "main" &&
+      symbol.kind === SymbolKind.Function &&
+      !symbol.containerName &&
+      symbol.location?.uri
+    ) {
+      entries.add(relativeFile(rootPath, symbol.location.uri));
+    }
+  }
+  return [...entries].sort();
+}
+
+/**
+ * One-paragraph summary of the package dependency graph, or undefined when
+ * it cannot be computed (no indexed files)
+ */
+async function summarizeDependencies(
+  rootPath: string,
+  context?: McpContext,
+): Promise<string | undefined> {
+  try {
+    const result = await analyzeDependencies(
+      {
+        root: rootPath,
+        granularity: "package",
+        format: "adjacency",
+        useDocumentLinks: false,
+      },
+      undefined,
+      context,
+    );
+    if (result.graph.nodes.length === 0) {
+      return undefined;
+    }
+
+    const dependents = new Map<string, number>();
+    for (const targets of result.graph.edges.values()) {
+      for (const target of targets) {
+        dependents.set(target, (dependents.get(target) ?? 0) + 1);
+      }
+    }
+    const core = [...dependents.entries()]
+      .sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]))
+      .slice(0, 5);
+
+    let output = `- ${result.graph.nodes.length} packages, ${countDependencies(result.graph)} internal dependencies\n`;
+    output +=
+      result.cycles.length > 0
+        ? `- ${result.cycles.length} cycle(s), e.g. ${result.cycles[0].join(" <-> ")}\n`
+        : "- No dependency cycles\n";
+    if (core.length > 0) {
+      output += `- Most imported: ${core
+        .map(([pkg, count]) => `${pkg} (${count})`)
+        .join(", ")}\n`;
+    }
+    return output;
+  } catch (error) {
+    debugLogWithPrefix(
+      "get_project_overview",
+      `Dependency summary failed: ${error}`,
+    );
+    return undefined;
   }
 }
 
@@ -140,6 +264,7 @@ export const getProjectOverviewTool: McpToolDef<
   name: "get_project_overview",
   description:
     "Get a quick overview of the project structure, key components, and statistics. " +
+    "Includes symbol counts by kind, the largest files, entry points and a summary of the package dependency graph. " +
     "This tool automatically creates an index if needed and provides a concise summary.",
   schema: getProjectOverviewSchema,
   execute: async ({ root }, context?: McpContext) => {
@@ -192,6 +317,7 @@ export const getProjectOverviewTool: McpToolDef<
             await indexFiles(rootPath, files, {
               concurrency: 5,
               context,
+              onProgress: createIndexProgressHandler(context),
             });
           }
 
@@ -291,6 +417,12 @@ export const getProjectOverviewTool: McpToolDef<
 
     // Get directory structure with file counts
     const directories = getDirectoryStructure(rootPath, allSymbols);
+    const largestFiles = getLargestFiles(rootPath, allSymbols, 5);
+    const entryPoints = findEntryPoints(rootPath, allSymbols, projectInfo);
+    const dependencySummary =
+      stats.totalFiles > 0
+        ? await summarizeDependencies(rootPath, context)
+        : undefined;
 
     // Build overview
     let output = "## Project Overview\n\n";
@@ -376,6 +508,34 @@ export const getProjectOverviewTool: McpToolDef<
       output += "```\n\n";
     }
 
+    // Entry points
+    if (entryPoints.length > 0) {
+      output += "### Entry Points:\n";
+      entryPoints.slice(0, 10).forEach((entry) => {
+        output += `• ${entry}\n`;
+      });
+      if (entryPoints.length > 10) {
+        output += `... and ${entryPoints.length - 10} more\n`;
+      }
+      output += "\n";
+    }
+
+    // Largest files by symbol count
+    if (largestFiles.length > 0) {
+      output += "### Largest Files (by symbols):\n";
+      largestFiles.forEach(([file, count]) => {
+        output += `• ${file} (${count} symbol${count === 1 ? "" : "s"})\n`;
+      });
+      output += "\n";
+    }
+
+    // Package dependency graph
+    if (dependencySummary) {
+      output += "### Dependency Graph:\n";
+      output += dependencySummary;
+      output += "Use `analyze_dependencies` for the full graph.\n\n";
+    }
+
     // Key components
     output += "### Key Components:\n\n";
 
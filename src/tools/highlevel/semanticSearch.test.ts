@@ -0,0 +1,127 @@
+import { describe, it, expect, vi } from "vitest";
+import { SymbolKind, type McpContext } from "@internal/types";
+import type { Embedder } from "@internal/code-indexer";
+import {
+  createSamplingEmbedder,
+  formatSemanticMatches,
+  resolveEmbedder,
+  semanticSearchPolicy,
+} from "./semanticSearch.ts";
+
+function recordingEmbedder(): Embedder & { queries: string[] } {
+  const queries: string[] = [];
+  return {
+    id: "fake",
+    queries,
+    embedDocuments: async (texts) => texts.map(() => new Float32Array(1)),
+    embedQuery: async (text) => {
+      queries.push(text);
+      return new Float32Array(1);
+    },
+  };
+}
+
+describe("createSamplingEmbedder", () => {
+  it("should embed the query together with the model's expansion", async () => {
+    const base = recordingEmbedder();
+    const sample = vi.fn().mockResolvedValue("isValidEmail checkAddress");
+    const embedder = createSamplingEmbedder(base, sample);
+
+    await embedder.embedQuery("function that validates email addresses");
+
+    expect(embedder.id).toBe("fake");
+    expect(sample.mock.calls[0][0]).toContain(
+      "function that validates email addresses",
+    );
+    expect(base.queries).toEqual([
+      "function that validates email addresses isValidEmail checkAddress",
+    ]);
+  });
+
+  it("should embed the query as written when sampling fails", async () => {
+    const base = recordingEmbedder();
+    const embedder = createSamplingEmbedder(
+      base,
+      vi.fn().mockRejectedValue(new Error("timeout")),
+    );
+
+    await embedder.embedQuery("retry scheduling");
+
+    expect(base.queries).toEqual(["retry scheduling"]);
+  });
+});
+
+describe("resolveEmbedder", () => {
+  const sample = vi.fn().mockResolvedValue("words");
+
+  it("should default to the local embedder", () => {
+    expect(semanticSearchPolicy(undefined)).toEqual({ embedder: "local" });
+    expect(resolveEmbedder({ sample } as McpContext).id).toMatch(/^local-/);
+  });
+
+  it("should expand queries only when configured and supported", async () => {
+    const config = { semanticSearch: { embedder: "sampling" } };
+
+    await resolveEmbedder({ config, sample } as McpContext).embedQuery("email");
+    expect(sample).toHaveBeenCalledTimes(1);
+
+    // Without sampling support the local embedder answers alone
+    await expect(
+      resolveEmbedder({ config } as McpContext).embedQuery("email"),
+    ).resolves.toBeInstanceOf(Float32Array);
+  });
+
+  it("should use the http embedder only with a url and a model", () => {
+    const http = { embedder: "http", model: "nomic-embed-text" };
+
+    expect(
+      resolveEmbedder({
+        config: { semanticSearch: { ...http, url: "http://localhost/v1" } },
+      } as McpContext).id,
+    ).toBe("http-nomic-embed-text");
+    expect(
+      resolveEmbedder({ config: { semanticSearch: http } } as McpContext).id,
+    ).toMatch(/^local-/);
+  });
+});
+
+describe("formatSemanticMatches", () => {
+  it("should list symbols with location, score and doc", () => {
+    const output = formatSemanticMatches("validate email", "/project", [
+      {
+        symbol: {
+          name: "checkAddr",
+          kind: SymbolKind.Function,
+          containerName: "validators",
+          detail: "(value: string) => boolean",
+          location: {
+            uri: "file:///project/src/check.ts",
+            range: {
+              start: { line: 11, character: 16 },
+              end: { line: 11, character: 25 },
+            },
+          },
+        },
+        score: 0.4033,
+        doc: "Reject strings that are not e-mail addresses",
+      },
+    ]);
+
+    expect(output).toContain(
+      [
+        'Found 1 symbol(s) related to "validate email":',
+        "",
+        "1. checkAddr [Function] in validators (score 0.40)",
+        "   Location: src/check.ts:12:17",
+        "   Details: (value: string) => boolean",
+        "   Doc: Reject strings that are not e-mail addresses",
+      ].join("\n"),
+    );
+  });
+
+  it("should point to search_symbols when nothing matches", () => {
+    expect(formatSemanticMatches("frobnicate", "/project", [])).toContain(
+      'No symbols found related to "frobnicate"',
+    );
+  });
+});
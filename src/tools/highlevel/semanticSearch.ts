@@ -0,0 +1,236 @@
+/**
+ * High-level tool searching symbols by meaning rather than by name
+ * Symbols are embedded from their names and doc comments, so a query in
+ * plain words ("function that validates email addresses") finds symbols
+ * whose names use the project's own terms. With `semanticSearch.embedder`
+ * set to "sampling", the client's model (MCP sampling) first expands the
+ * query into identifiers the code is likely to use; symbols are still
+ * embedded locally. "http" embeds both with a model behind an
+ * OpenAI-compatible embeddings endpoint.
+ */
+
+import { z } from "zod";
+import { relative } from "path";
+import { fileURLToPath } from "url";
+import type { McpContext, McpToolDef, SymbolKind } from "@internal/types";
+import {
+  SYMBOL_KIND_NAMES,
+  createHttpEmbedder,
+  createLocalEmbedder,
+  getSymbolKindName,
+  isExportedSymbol,
+  parseSymbolKind,
+  semanticSearchSymbols,
+  type Embedder,
+  type SemanticMatch,
+} from "@internal/code-indexer";
+import { debugLogWithPrefix } from "../../utils/debugLog.ts";
+import { matchesFileFilter } from "../../utils/symbolQueryParser.ts";
+import { ensureIndex } from "./ensureIndex.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project").optional(),
+  query: z
+    .string()
+    .describe(
+      "What the symbol does, in plain words, e.g. 'function that validates email addresses'",
+    ),
+  kind: z
+    .any()
+    .describe(
+      `Symbol kind(s) to filter by, case-insensitive: a string or an array. Valid kinds: ${SYMBOL_KIND_NAMES.join(", ")}`,
+    )
+    .optional(),
+  file: z
+    .string()
+    .describe(
+      "Only search matching files (substring or glob, relative to root)",
+    )
+    .optional(),
+  exportedOnly: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe("Only return exported symbols"),
+  limit: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .default(10)
+    .describe("Most symbols to return"),
+});
+
+// Length of the query expansion the client's model is asked for
+const EXPANSION_MAX_TOKENS = 100;
+
+export interface SemanticSearchPolicy {
+  /**
+   * "local" embeds without a model; "sampling" also expands queries;
+   * "http" asks the embeddings endpoint at `url`
+   */
+  embedder: "local" | "sampling" | "http";
+  url?: string;
+  model?: string;
+  /** Environment variable holding the endpoint's API key */
+  apiKeyEnv?: string;
+}
+
+export const DEFAULT_SEMANTIC_SEARCH_POLICY: SemanticSearchPolicy = {
+  embedder: "local",
+};
+
+export function semanticSearchPolicy(
+  config?: Record<string, unknown>,
+): SemanticSearchPolicy {
+  const configured = config?.semanticSearch as
+    | Partial<SemanticSearchPolicy>
+    | undefined;
+  return { ...DEFAULT_SEMANTIC_SEARCH_POLICY, ...configured };
+}
+
+function expansionPrompt(query: string): string {
+  return [
+    "A developer is searching a codebase for a symbol matching this description:",
+    "",
+    query,
+    "",
+    "List up to 20 identifiers and words such code is likely to use in names and doc comments (synonyms, abbreviations, camelCase names).",
+    "Answer with the words separated by spaces and nothing else.",
+  ].join("\n");
+}
+
+/**
+ * Embedder that asks the client's model to expand queries before embedding
+ * them with `base`. Symbols are embedded by `base` alone, so the vectors
+ * stay comparable; a failed expansion embeds the query as written.
+ */
+export function createSamplingEmbedder(
+  base: Embedder,
+  sample: (prompt: string, maxTokens: number) => Promise<string>,
+): Embedder {
+  return {
+    id: base.id,
+    embedDocuments: (texts) => base.embedDocuments(texts),
+    embedQuery: async (text) => {
+      try {
+        const expansion = await sample(
+          expansionPrompt(text),
+          EXPANSION_MAX_TOKENS,
+        );
+        return base.embedQuery(`${text} ${expansion}`);
+      } catch (error) {
+        debugLogWithPrefix(
+          "semantic_search_symbols",
+          `Query expansion failed: ${error instanceof Error ? error.message : String(error)}`,
+        );
+        return base.embedQuery(text);
+      }
+    },
+  };
+}
+
+/**
+ * Embedder configured for a tool call; sampling falls back to the local
+ * embedder when the client does not support it, http when no url or model
+ * is configured
+ */
+export function resolveEmbedder(context?: McpContext): Embedder {
+  const local = createLocalEmbedder();
+  const policy = semanticSearchPolicy(context?.config);
+  if (policy.embedder === "sampling" && context?.sample) {
+    return createSamplingEmbedder(local, context.sample);
+  }
+  if (policy.embedder === "http") {
+    if (policy.url && policy.model) {
+      return createHttpEmbedder({
+        url: policy.url,
+        model: policy.model,
+        apiKey: policy.apiKeyEnv ? process.env[policy.apiKeyEnv] : undefined,
+      });
+    }
+    debugLogWithPrefix(
+      "semantic_search_symbols",
+      "semanticSearch.embedder is http but url or model is missing",
+    );
+  }
+  return local;
+}
+
+export function formatSemanticMatches(
+  query: string,
+  rootPath: string,
+  matches: SemanticMatch[],
+): string {
+  if (matches.length === 0) {
+    return `No symbols found related to "${query}". Try other words, or search_symbols for a name.`;
+  }
+  const lines = [`Found ${matches.length} symbol(s) related to "${query}":`];
+  for (const [index, { symbol, score, doc }] of matches.entries()) {
+    const relativePath = relative(rootPath, fileURLToPath(symbol.location.uri));
+    const { line, character } = symbol.location.range.start;
+    const kindName =
+      getSymbolKindName(symbol.kind) || `Unknown(${symbol.kind})`;
+    lines.push(
+      "",
+      `${index + 1}. ${symbol.name} [${kindName}]` +
+        (symbol.containerName ? ` in ${symbol.containerName}` : "") +
+        ` (score ${score.toFixed(2)})`,
+      `   Location: ${relativePath}:${line + 1}:${character + 1}`,
+    );
+    if (symbol.detail) {
+      lines.push(`   Details: ${symbol.detail}`);
+    }
+    if (doc) {
+      lines.push(`   Doc: ${doc}`);
+    }
+  }
+  lines.push(
+    "",
+    "Use get_symbol_details with the location for the definition, type and references.",
+  );
+  return lines.join("\n");
+}
+
+export const semanticSearchSymbolsTool: McpToolDef<typeof schema> = {
+  name: "semantic_search_symbols",
+  description:
+    "Search symbols by what they do rather than by name: describe the symbol in plain words, e.g. 'function that validates email addresses' or 'where retries are scheduled'. " +
+    "Symbol names, kinds and doc comments are embedded and ranked by similarity, so it finds symbols when you don't know the project's terminology. " +
+    "Use search_symbols when you know (part of) the name.",
+  schema,
+  execute: async (
+    { root, query, kind, file, exportedOnly, limit },
+    context?: McpContext,
+  ) => {
+    const rootPath = root || process.cwd();
+
+    const indexError = await ensureIndex(rootPath, context);
+    if (indexError) {
+      return indexError;
+    }
+
+    let kinds: SymbolKind[] | undefined;
+    if (kind !== undefined && kind !== null && kind !== "") {
+      try {
+        kinds = parseSymbolKind(kind);
+      } catch (error) {
+        return `Error: ${error instanceof Error ? error.message : String(error)}`;
+      }
+    }
+
+    const matches = await semanticSearchSymbols(rootPath, query, {
+      embedder: resolveEmbedder(context),
+      limit,
+      filter: (symbol) =>
+        (!kinds || kinds.includes(symbol.kind)) &&
+        (!file ||
+          matchesFileFilter(
+            relative(rootPath, fileURLToPath(symbol.location.uri)),
+            file,
+          )) &&
+        (!exportedOnly || isExportedSymbol(symbol)),
+    });
+    return formatSemanticMatches(query, rootPath, matches);
+  },
+};
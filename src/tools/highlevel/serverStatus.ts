@@ -0,0 +1,109 @@
+/**
+ * High-level tool reporting language server health
+ * Shows each supervised server's state, restarts and last exit, the server's
+ * own status report where available, and the recent health event log
+ */
+
+import { z } from "zod";
+import type { McpToolDef } from "@internal/types";
+import type {
+  ServerEvent,
+  ServerHealth,
+  ServerSupervisor,
+} from "../../utils/serverSupervisor.ts";
+
+const schema = z.object({
+  restart: z
+    .string()
+    .optional()
+    .describe("Id of a server to restart now (as listed by this tool)"),
+  events: z
+    .number()
+    .int()
+    .min(0)
+    .optional()
+    .default(20)
+    .describe("Number of recent health events to show"),
+});
+
+function formatServer(health: ServerHealth): string {
+  const details = [
+    health.pid ? `pid ${health.pid}` : undefined,
+    `${health.restarts} restart(s)`,
+    health.consecutiveTimeouts > 0
+      ? `${health.consecutiveTimeouts} consecutive timeout(s)`
+      : undefined,
+  ].filter(Boolean);
+  let line = `- ${health.id}: ${health.state} (${details.join(", ")})`;
+  if (health.lastExit) {
+    line += `\n  Last exit: ${health.lastExit.signal ?? `code ${health.lastExit.code}`}`;
+  }
+  const reported = health.client.getServerStatus();
+  if (reported) {
+    line += `\n  Server reports: ${reported.health}${reported.quiescent ? "" : " (loading)"}`;
+    if (reported.message) {
+      line += ` - ${reported.message}`;
+    }
+  }
+  const documents = health.client.getOpenDocuments().length;
+  if (documents > 0) {
+    line += `\n  Open documents: ${documents}`;
+  }
+  return line;
+}
+
+function formatEvent(event: ServerEvent): string {
+  return `  ${event.time.toISOString()} ${event.server} ${event.kind}: ${event.message}`;
+}
+
+export function formatServerStatus(
+  servers: ServerHealth[],
+  events: ServerEvent[],
+): string {
+  const lines = ["Language servers:", ...servers.map(formatServer)];
+  if (servers.some((server) => server.state === "failed")) {
+    lines.push(
+      "",
+      "A failed server is not restarted automatically; fix the cause and use restart to try again.",
+    );
+  }
+  if (events.length > 0) {
+    lines.push("", "Recent events:", ...events.map(formatEvent));
+  }
+  return lines.join("\n");
+}
+
+export function createServerStatusTool(
+  supervisor: ServerSupervisor,
+): McpToolDef<typeof schema> {
+  return {
+    name: "server_status",
+    description:
+      "Health of the running language servers: state, pid, automatic restarts, last exit and timeouts, " +
+      "plus recent crash/restart events. Crashed or hung servers are restarted automatically; " +
+      "pass restart to restart one manually.",
+    schema,
+    execute: async ({ restart, events }) => {
+      let prefix = "";
+      if (restart) {
+        const found = await supervisor.restartServer(restart);
+        if (!found) {
+          const ids = supervisor.status().map((s) => s.id);
+          return `Unknown server '${restart}'. Servers: ${ids.join(", ")}`;
+        }
+        const state = supervisor.status().find((s) => s.id === restart)?.state;
+        prefix =
+          state === "running"
+            ? `Restarted ${restart}.\n\n`
+            : `Restarting ${restart} failed; see the events below.\n\n`;
+      }
+      return (
+        prefix +
+        formatServerStatus(
+          supervisor.status(),
+          events > 0 ? supervisor.events.slice(-events) : [],
+        )
+      );
+    },
+  };
+}
@@ -0,0 +1,199 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import { mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import { SymbolKind } from "vscode-languageserver-types";
+
+vi.mock("@internal/code-indexer", () => ({
+  querySymbols: vi.fn(),
+  isExportedSymbol: (symbol: { name: string }) => /^[A-Z]/.test(symbol.name),
+}));
+
+import { querySymbols } from "@internal/code-indexer";
+import {
+  analyzeUnusedSymbols,
+  countExternalReferences,
+  findNamePosition,
+  formatUnusedSymbols,
+} from "./unusedSymbols.ts";
+
+const SOURCE = `package main
+
+func main() {
+	Used()
+}
+
+func Used() {}
+
+func unused() {
+	unused()
+}
+
+func TestSomething() {}
+`;
+
+function range(startLine: number, endLine: number) {
+  return {
+    start: { line: startLine, character: 0 },
+    end: { line: endLine, character: 1 },
+  };
+}
+
+describe("analyze_unused_symbols", () => {
+  let root: string;
+  let uri: string;
+
+  const symbol = (name: string, startLine: number, endLine: number) => ({
+    name,
+    kind: SymbolKind.Function,
+    location: { uri, range: range(startLine, endLine) },
+  });
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    root = mkdtempSync(join(tmpdir(), "lsmcp-unused-"));
+    writeFileSync(join(root, "main.go"), SOURCE);
+    uri = pathToFileURL(join(root, "main.go")).toString();
+    vi.mocked(querySymbols).mockReturnValue([
+      symbol("main", 2, 4),
+      symbol("Used", 6, 6),
+      symbol("unused", 8, 10),
+      symbol("TestSomething", 12, 12),
+    ]);
+  });
+
+  function createClient() {
+    return {
+      openDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      findReferences: vi.fn(async (_uri: string, position: any) => {
+        if (position.line === 6) {
+          // Used() is called from main
+          return [{ uri, range: range(3, 3) }];
+        }
+        if (position.line === 8) {
+          // unused() only calls itself
+          return [{ uri, range: range(9, 9) }];
+        }
+        return [];
+      }),
+    } as any;
+  }
+
+  it("should report symbols referenced only from their own body", async () => {
+    const client = createClient();
+
+    const result = await analyzeUnusedSymbols(
+      { root, scope: "all", limit: 300, concurrency: 5 },
+      client,
+    );
+
+    expect(result.unused.map((s) => s.name)).toEqual(["unused"]);
+    expect(result.checked).toBe(2);
+    // main and TestSomething are skipped by the default allowlist
+    expect(client.findReferences).toHaveBeenCalledTimes(2);
+    expect(client.findReferences).toHaveBeenCalledWith(
+      uri,
+      { line: 6, character: 5 },
+      expect.objectContaining({ includeDeclaration: false }),
+    );
+    expect(client.openDocument).toHaveBeenCalledTimes(1);
+  });
+
+  it("should honor allow patterns from arguments and config", async () => {
+    const client = createClient();
+
+    const fromArgs = await analyzeUnusedSymbols(
+      { root, scope: "all", limit: 300, concurrency: 5, allow: ["^unused$"] },
+      client,
+    );
+    const fromConfig = await analyzeUnusedSymbols(
+      { root, scope: "all", limit: 300, concurrency: 5 },
+      client,
+      { config: { unusedSymbols: { allow: ["^un"] } } } as any,
+    );
+
+    expect(fromArgs.unused).toEqual([]);
+    expect(fromConfig.unused).toEqual([]);
+  });
+
+  it("should filter by scope", async () => {
+    const client = createClient();
+
+    const result = await analyzeUnusedSymbols(
+      { root, scope: "exported", limit: 300, concurrency: 5 },
+      client,
+    );
+
+    expect(result.checked).toBe(1);
+    expect(result.unused).toEqual([]);
+  });
+
+  it("should format results grouped by file", async () => {
+    const result = await analyzeUnusedSymbols(
+      { root, scope: "all", limit: 1, concurrency: 5 },
+      createClient(),
+    );
+
+    const output = formatUnusedSymbols(result);
+    expect(output).toContain(
+      "Found 0 unused symbols (1 checked, 1 not checked",
+    );
+
+    const full = formatUnusedSymbols({
+      ...result,
+      unused: [
+        {
+          name: "unused",
+          kind: SymbolKind.Function,
+          relativePath: "main.go",
+          line: 9,
+          exported: false,
+        },
+      ],
+    });
+    expect(full).toContain("main.go\n  9: unused [Function] (internal)");
+  });
+
+  it("should fail clearly when the index is empty", async () => {
+    vi.mocked(querySymbols).mockReturnValue([]);
+
+    await expect(
+      analyzeUnusedSymbols(
+        { root, scope: "all", limit: 300, concurrency: 5 },
+        createClient(),
+      ),
+    ).rejects.toThrow(/symbol index is empty/);
+  });
+});
+
+describe("findNamePosition", () => {
+  it("should locate the name inside the declaration range", () => {
+    const lines = ["func (s *Server) Run() error {", "}"];
+    expect(
+      findNamePosition(lines, {
+        name: "Run",
+        kind: SymbolKind.Method,
+        location: { uri: "file:///a.go", range: range(0, 1) },
+      }),
+    ).toEqual({ line: 0, character: 17 });
+  });
+});
+
+describe("countExternalReferences", () => {
+  it("should ignore references inside the declaration", () => {
+    const symbol = {
+      name: "walk",
+      kind: SymbolKind.Function,
+      location: { uri: "file:///a.go", range: range(2, 6) },
+    };
+    expect(
+      countExternalReferences(symbol, [
+        { uri: "file:///a.go", range: range(4, 4) },
+        { uri: "file:///b.go", range: range(4, 4) },
+        { uri: "file:///a.go", range: range(10, 10) },
+      ]),
+    ).toBe(2);
+  });
+});
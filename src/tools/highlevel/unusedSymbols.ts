@@ -0,0 +1,386 @@
+/**
+ * High-level tool for finding dead code
+ * Walks the symbol index and asks the language server for references of each
+ * symbol, reporting the ones nothing else refers to
+ */
+
+import { z } from "zod";
+import { readFileSync } from "fs";
+import { relative } from "path";
+import { fileURLToPath } from "url";
+import type {
+  Location,
+  McpContext,
+  McpToolDef,
+  Position,
+  Range,
+} from "@internal/types";
+import {
+  SymbolKind,
+  getSymbolKindName,
+  parseSymbolKind,
+} from "@internal/types";
+import type { LSPClient } from "@internal/lsp-client";
+import { debug } from "@internal/lsp-client";
+import {
+  isExportedSymbol,
+  querySymbols,
+  type IndexedSymbol,
+} from "@internal/code-indexer";
+import { withLSPDocument } from "../lsp/common.ts";
+import { matchesFileFilter } from "../../utils/symbolQueryParser.ts";
+
+/** Entry points and test functions that are called by tooling, not code */
+export const DEFAULT_UNUSED_ALLOWLIST = [
+  "^main$",
+  "^init$",
+  "^(Test|Benchmark|Example|Fuzz)",
+  "^test_",
+  "^constructor$",
+];
+
+const DEFAULT_KINDS = [
+  SymbolKind.Class,
+  SymbolKind.Interface,
+  SymbolKind.Struct,
+  SymbolKind.Enum,
+  SymbolKind.Function,
+  SymbolKind.Method,
+  SymbolKind.Constant,
+];
+
+const DOCUMENT_OPEN_DELAY = 200;
+
+const schema = z.object({
+  root: z.string().describe("Root directory for the project"),
+  file: z
+    .string()
+    .optional()
+    .describe(
+      "Only check symbols in matching files (substring or glob, relative to root)",
+    ),
+  kind: z
+    .any()
+    .optional()
+    .describe(
+      "Symbol kind(s) to check (default: Class, Interface, Struct, Enum, Function, Method, Constant)",
+    ),
+  scope: z
+    .enum(["all", "exported", "internal"])
+    .optional()
+    .default("all")
+    .describe(
+      "Check all symbols, only exported ones, or only internal (unexported) ones",
+    ),
+  allow: z
+    .array(z.string())
+    .optional()
+    .describe(
+      "Regex patterns for symbol names that are never reported (added to config unusedSymbols.allow and the defaults)",
+    ),
+  limit: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .default(300)
+    .describe("Maximum number of symbols to check"),
+  concurrency: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .default(5)
+    .describe("Number of reference requests sent at once"),
+});
+
+type AnalyzeUnusedSymbolsRequest = z.infer<typeof schema>;
+
+export interface UnusedSymbol {
+  name: string;
+  kind: SymbolKind;
+  containerName?: string;
+  relativePath: string;
+  line: number;
+  exported: boolean;
+}
+
+export interface UnusedSymbolsResult {
+  unused: UnusedSymbol[];
+  checked: number;
+  candidates: number;
+  failed: number;
+}
+
+function contains(range: Range, position: Position): boolean {
+  if (position.line < range.start.line || position.line > range.end.line) {
+    return false;
+  }
+  if (
+    position.line === range.start.line &&
+    position.character < range.start.character
+  ) {
+    return false;
+  }
+  if (
+    position.line === range.end.line &&
+    position.character > range.end.character
+  ) {
+    return false;
+  }
+  return true;
+}
+
+/**
+ * Index ranges cover the whole declaration; find the symbol name inside it
+ */
+export function findNamePosition(
+  lines: string[],
+  symbol: IndexedSymbol,
+): Position | null {
+  const { start, end } = symbol.location.range;
+  const pattern = new RegExp(
+    `\\b${symbol.name.replace(/[.*+?^${}()|[\]\\]/g, "\\$&")}\\b`,
+  );
+  const last = Math.min(end.line, lines.length - 1);
+  for (let line = start.line; line <= last; line++) {
+    const text = lines[line];
+    const from = line === start.line ? start.character : 0;
+    const match = pattern.exec(text.slice(from));
+    if (match) {
+      return { line, character: from + match.index };
+    }
+  }
+  return null;
+}
+
+/**
+ * References that are not inside the symbol's own declaration
+ * (recursive calls and self-references do not count as uses)
+ */
+export function countExternalReferences(
+  symbol: IndexedSymbol,
+  references: Location[],
+): number {
+  return references.filter(
+    (ref) =>
+      ref.uri !== symbol.location.uri ||
+      !contains(symbol.location.range, ref.range.start),
+  ).length;
+}
+
+function compileAllowlist(patterns: string[]): RegExp[] {
+  return patterns.map((pattern) => {
+    try {
+      return new RegExp(pattern);
+    } catch {
+      throw new Error(`Invalid allow pattern: ${pattern}`);
+    }
+  });
+}
+
+function configAllowlist(context?: McpContext): string[] {
+  const config = context?.config?.unusedSymbols as
+    | { allow?: string[] }
+    | undefined;
+  return config?.allow ?? [];
+}
+
+function selectCandidates(
+  request: AnalyzeUnusedSymbolsRequest,
+  allowlist: RegExp[],
+): IndexedSymbol[] {
+  const kinds =
+    request.kind !== undefined && request.kind !== null && request.kind !== ""
+      ? parseSymbolKind(request.kind)
+      : DEFAULT_KINDS;
+
+  return querySymbols(request.root, { kind: kinds }).filter((symbol) => {
+    if (allowlist.some((pattern) => pattern.test(symbol.name))) {
+      return false;
+    }
+    if (request.scope !== "all") {
+      const exported = isExportedSymbol(symbol);
+      if (exported !== (request.scope === "exported")) {
+        return false;
+      }
+    }
+    if (request.file) {
+      const relativePath = relative(
+        request.root,
+        fileURLToPath(symbol.location.uri),
+      );
+      if (!matchesFileFilter(relativePath, request.file)) {
+        return false;
+      }
+    }
+    return true;
+  });
+}
+
+export async function analyzeUnusedSymbols(
+  request: AnalyzeUnusedSymbolsRequest,
+  client: LSPClient,
+  context?: McpContext,
+): Promise<UnusedSymbolsResult> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const allowlist = compileAllowlist([
+    ...DEFAULT_UNUSED_ALLOWLIST,
+    ...configAllowlist(context),
+    ...(request.allow ?? []),
+  ]);
+  const candidates = selectCandidates(request, allowlist);
+  if (candidates.length === 0 && querySymbols(request.root, {}).length === 0) {
+    throw new Error(
+      "The symbol index is empty. Run search_symbols or get_project_overview first.",
+    );
+  }
+  const toCheck = candidates.slice(0, request.limit);
+
+  // Group by file so each document is opened once
+  const byFile = new Map<string, IndexedSymbol[]>();
+  for (const symbol of toCheck) {
+    const uri = symbol.location.uri;
+    if (!byFile.has(uri)) {
+      byFile.set(uri, []);
+    }
+    byFile.get(uri)!.push(symbol);
+  }
+
+  const unused: UnusedSymbol[] = [];
+  let checked = 0;
+  let failed = 0;
+
+  for (const [uri, symbols] of byFile) {
+    context?.signal?.throwIfAborted();
+    const filePath = fileURLToPath(uri);
+    let content: string;
+    try {
+      content = readFileSync(filePath, "utf-8");
+    } catch {
+      failed += symbols.length;
+      continue;
+    }
+    const lines = content.split("\n");
+    const relativePath = relative(request.root, filePath);
+
+    await withLSPDocument(
+      client,
+      uri,
+      content,
+      async () => {
+        for (let i = 0; i < symbols.length; i += request.concurrency) {
+          const batch = symbols.slice(i, i + request.concurrency);
+          await Promise.all(
+            batch.map(async (symbol) => {
+              const position = findNamePosition(lines, symbol);
+              if (!position) {
+                failed++;
+                return;
+              }
+              try {
+                const references = await client.findReferences(
+                  uri,
+                  position,
+                  { includeDeclaration: false, signal: context?.signal },
+                );
+                if (countExternalReferences(symbol, references) === 0) {
+                  unused.push({
+                    name: symbol.name,
+                    kind: symbol.kind,
+                    containerName: symbol.containerName,
+                    relativePath,
+                    line: position.line + 1,
+                    exported: isExportedSymbol(symbol),
+                  });
+                }
+                checked++;
+              } catch (error) {
+                debug(
+                  `[analyzeUnusedSymbols] references failed for ${symbol.name}:`,
+                  error,
+                );
+                failed++;
+              }
+            }),
+          );
+          context?.reportProgress?.({
+            progress: checked + failed,
+            total: toCheck.length,
+            message: relativePath,
+          });
+        }
+      },
+      DOCUMENT_OPEN_DELAY,
+    );
+  }
+
+  unused.sort(
+    (a, b) => a.relativePath.localeCompare(b.relativePath) || a.line - b.line,
+  );
+  return { unused, checked, candidates: candidates.length, failed };
+}
+
+export function formatUnusedSymbols(result: UnusedSymbolsResult): string {
+  const header = `Found ${result.unused.length} unused symbol${
+    result.unused.length === 1 ? "" : "s"
+  } (${result.checked} checked`;
+  const notes: string[] = [];
+  if (result.candidates > result.checked + result.failed) {
+    notes.push(
+      `${result.candidates - result.checked - result.failed} not checked; raise limit or narrow with file/kind`,
+    );
+  }
+  if (result.failed > 0) {
+    notes.push(`${result.failed} failed`);
+  }
+  const lines = [
+    `${header}${notes.length > 0 ? `, ${notes.join(", ")}` : ""})`,
+  ];
+
+  let currentFile = "";
+  for (const symbol of result.unused) {
+    if (symbol.relativePath !== currentFile) {
+      currentFile = symbol.relativePath;
+      lines.push("", currentFile);
+    }
+    const kindName = getSymbolKindName(symbol.kind) ?? "Unknown";
+    const container = symbol.containerName
+      ? ` in ${symbol.containerName}`
+      : "";
+    const visibility = symbol.exported ? "exported" : "internal";
+    lines.push(
+      `  ${symbol.line}: ${symbol.name} [${kindName}]${container} (${visibility})`,
+    );
+  }
+
+  if (result.unused.length > 0) {
+    lines.push(
+      "",
+      "Symbols used only via reflection, interfaces or external packages may appear here; review before deleting.",
+    );
+  }
+  return lines.join("\n");
+}
+
+/**
+ * Create analyze_unused_symbols tool with injected LSP client
+ */
+export function createAnalyzeUnusedSymbolsTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "analyze_unused_symbols",
+    description:
+      "Report indexed symbols with no references outside their own declaration (dead code candidates). " +
+      "Entry points like main, init and test functions are skipped; add more with allow or unusedSymbols.allow in .lsmcp/config.json.",
+    schema,
+    execute: async (args, context?: McpContext) => {
+      const result = await analyzeUnusedSymbols(args, client, context);
+      return formatUnusedSymbols(result);
+    },
+  };
+}
@@ -9,6 +9,13 @@ describe("Configurable Language Features", () => {
       // Core tools should be present
       expect(tools.replaceRange).toBeDefined();
       expect(tools.replaceRegex).toBeDefined();
+      expect(tools.applyWorkspaceEdit).toBeDefined();
+      expect(tools.replaceSymbolBody).toBeDefined();
+      expect(tools.insertBeforeSymbol).toBeDefined();
+      expect(tools.insertAfterSymbol).toBeDefined();
+      expect(tools.renameFile).toBeDefined();
+      expect(tools.deleteSymbol).toBeDefined();
+      expect(tools.deleteFile).toBeDefined();
       expect(tools.listMemories).toBeDefined();
       expect(tools.readMemory).toBeDefined();
       expect(tools.writeMemory).toBeDefined();
@@ -74,7 +81,7 @@ describe("Configurable Language Features", () => {
 
       // Count total tools
       const toolCount = Object.keys(tools).length;
-      const coreToolCount = 8; // Number of core tools (removed findFile, searchForPattern and 3 symbol tools, added replaceRange)
+      const coreToolCount = 20; // Number of core tools (removed findFile and searchForPattern; added replaceRange, applyWorkspaceEdit, confirmEdit, 4 edit transaction, 3 symbol editing, renameFile and 2 safe delete tools)
       const typescriptToolCount = 6; // Number of TypeScript-specific tools
 
       expect(toolCount).toBe(coreToolCount + typescriptToolCount);
@@ -1,5 +1,11 @@
 export * from "./editor/regexEditTools.ts";
 export * from "./editor/rangeEditTools.ts";
+export * from "./editor/workspaceEditTools.ts";
+export * from "./editor/pendingEdits.ts";
+export * from "./editor/editTransactions.ts";
+export * from "./editor/symbolEditTools.ts";
+export * from "./editor/renameFileTool.ts";
+export * from "./editor/safeDeleteTools.ts";
 export * from "./memory/memoryTools.ts";
 // Internal tools - not exported
 export * from "./highlevel/fileSystemTools.ts";
@@ -12,6 +18,21 @@ export * from "./highlevel/symbolResolverTools.ts";
 import type { McpToolDef } from "@internal/types";
 import { replaceRegexTool } from "./editor/regexEditTools.ts";
 import { replaceRangeTool } from "./editor/rangeEditTools.ts";
+import { applyWorkspaceEditTool } from "./editor/workspaceEditTools.ts";
+import { confirmEditTool } from "./editor/pendingEdits.ts";
+import {
+  abortEditTransactionTool,
+  addToEditTransactionTool,
+  beginEditTransactionTool,
+  commitEditTransactionTool,
+} from "./editor/editTransactions.ts";
+import {
+  insertAfterSymbolTool,
+  insertBeforeSymbolTool,
+  replaceSymbolBodyTool,
+} from "./editor/symbolEditTools.ts";
+import { renameFileTool } from "./editor/renameFileTool.ts";
+import { deleteFileTool, deleteSymbolTool } from "./editor/safeDeleteTools.ts";
 import {
   listMemoriesTool,
   readMemoryTool,
@@ -38,6 +59,24 @@ const coreTools = {
   // Range and regex editing tools
   replaceRange: replaceRangeTool,
   replaceRegex: replaceRegexTool,
+  applyWorkspaceEdit: applyWorkspaceEditTool,
+  confirmEdit: confirmEditTool,
+  beginEditTransaction: beginEditTransactionTool,
+  addToEditTransaction: addToEditTransactionTool,
+  commitEditTransaction: commitEditTransactionTool,
+  abortEditTransaction: abortEditTransactionTool,
+
+  // Symbol-anchored editing tools
+  replaceSymbolBody: replaceSymbolBodyTool,
+  insertBeforeSymbol: insertBeforeSymbolTool,
+  insertAfterSymbol: insertAfterSymbolTool,
+
+  // File rename with import updates
+  renameFile: renameFileTool,
+
+  // Deletion with a reference check
+  deleteSymbol: deleteSymbolTool,
+  deleteFile: deleteFileTool,
 
   // Memory tools
   listMemories: listMemoriesTool,
@@ -3,7 +3,11 @@ import { z } from "zod";
 import { readFile } from "fs/promises";
 import { join } from "path";
 import { minimatch } from "minimatch";
-import { debug } from "@internal/lsp-client";
+import {
+  debug,
+  getLanguageIdFromPath,
+  waitForDiagnosticsWithRetry,
+} from "@internal/lsp-client";
 import { pathToFileURL } from "url";
 import { Diagnostic } from "@internal/types";
 import { glob as gitawareGlob } from "gitaware-glob";
@@ -35,7 +39,7 @@ const schema = z.object({
 
 type GetAllDiagnosticsRequest = z.infer<typeof schema>;
 
-interface FileDiagnostic {
+export interface FileDiagnostic {
   filePath: string;
   diagnostics: Array<{
     severity: "error" | "warning" | "information" | "hint";
@@ -49,7 +53,7 @@ interface FileDiagnostic {
   }>;
 }
 
-interface GetAllDiagnosticsSuccess {
+export interface GetAllDiagnosticsSuccess {
   message: string;
   totalErrors: number;
   totalWarnings: number;
@@ -71,7 +75,7 @@ const SEVERITY_MAP: Record<
  * Get all project files using gitaware-glob
  * This automatically respects .gitignore
  */
-async function getProjectFiles(
+export async function getProjectFiles(
   root: string,
   pattern: string,
   exclude?: string,
@@ -147,47 +151,96 @@ async function getProjectFiles(
 }
 
 /**
- * Gets diagnostics for all files in the project
+ * Convert LSP diagnostics to file diagnostics, applying the severity filter
  */
-export async function getAllDiagnostics(
-  request: GetAllDiagnosticsRequest,
-  client: LSPClient,
-): Promise<GetAllDiagnosticsSuccess> {
-  if (!client) {
-    throw new Error("LSP client not initialized");
-  }
+export function toFileDiagnostics(
+  diagnostics: Diagnostic[],
+  severityFilter: GetAllDiagnosticsRequest["severityFilter"] = "all",
+): FileDiagnostic["diagnostics"] {
+  return diagnostics
+    .filter((d: Diagnostic) => d && d.range) // Filter out invalid diagnostics
+    .map((d: Diagnostic) => ({
+      severity: SEVERITY_MAP[d.severity || 2] || "warning",
+      line: d.range.start.line + 1, // Convert to 1-based
+      column: d.range.start.character + 1, // Convert to 1-based
+      endLine: d.range.end.line + 1,
+      endColumn: d.range.end.character + 1,
+      message: d.message,
+      source: d.source,
+      code: d.code,
+    }))
+    .filter((d) => {
+      // Apply severity filter
+      if (severityFilter === "error" && d.severity !== "error") {
+        return false;
+      }
+      if (severityFilter === "warning" && d.severity !== "warning") {
+        return false;
+      }
+      return true;
+    });
+}
 
-  // Get all project files
-  let files: string[];
-  try {
-    files = await getProjectFiles(
-      request.root,
-      request.pattern,
-      request.exclude,
-      request.useGitignore ?? true,
-    );
-    debug(
-      `[lspGetAllDiagnostics] getProjectFiles returned ${files.length} files`,
-    );
-  } catch (error) {
-    debug(`[lspGetAllDiagnostics] Error in getProjectFiles:`, error);
-    throw error;
+/**
+ * Build the summary result from per-file diagnostics
+ */
+export function summarizeFileDiagnostics(
+  fileDiagnostics: FileDiagnostic[],
+): GetAllDiagnosticsSuccess {
+  let totalErrors = 0;
+  let totalWarnings = 0;
+  for (const file of fileDiagnostics) {
+    for (const d of file.diagnostics) {
+      if (d.severity === "error") totalErrors++;
+      else if (d.severity === "warning") totalWarnings++;
+    }
   }
 
-  debug(`[lspGetAllDiagnostics] Found ${files.length} files to check`);
+  // Sort files by path
+  const files = [...fileDiagnostics].sort((a, b) =>
+    a.filePath.localeCompare(b.filePath),
+  );
 
+  return {
+    message: `Found ${totalErrors} error${
+      totalErrors !== 1 ? "s" : ""
+    } and ${totalWarnings} warning${
+      totalWarnings !== 1 ? "s" : ""
+    } in ${files.length} file${files.length !== 1 ? "s" : ""}`,
+    totalErrors,
+    totalWarnings,
+    files,
+  };
+}
+
+/**
+ * Collect diagnostics for the given files by opening them in batches
+ */
+export async function collectDiagnosticsForFiles(
+  root: string,
+  files: string[],
+  client: LSPClient,
+  options: {
+    severityFilter?: GetAllDiagnosticsRequest["severityFilter"];
+    concurrency?: number;
+    /**
+     * Wait up to this many milliseconds per file for the server to report
+     * (CLI runs); tool calls only give it a moment
+     */
+    waitTimeout?: number;
+  } = {},
+): Promise<GetAllDiagnosticsSuccess> {
+  const batchSize = Math.max(1, options.concurrency ?? DIAGNOSTICS_BATCH_SIZE);
   const fileDiagnostics: FileDiagnostic[] = [];
-  let totalErrors = 0;
-  let totalWarnings = 0;
 
   // Process files in batches to avoid overwhelming the LSP server
-  for (let i = 0; i < files.length; i += DIAGNOSTICS_BATCH_SIZE) {
-    const batch = files.slice(i, i + DIAGNOSTICS_BATCH_SIZE);
+  for (let i = 0; i < files.length; i += batchSize) {
+    const batch = files.slice(i, i + batchSize);
 
     await Promise.all(
       batch.map(async (filePath) => {
         try {
-          const absolutePath = join(request.root, filePath);
+          const absolutePath = join(root, filePath);
           const fileUri = pathToFileURL(absolutePath).toString();
 
           // Read file content
@@ -202,63 +255,43 @@ export async function getAllDiagnostics(
             return; // Skip this file
           }
 
-          // Open document in LSP
-          client.openDocument(fileUri, fileContent);
+          let diagnostics;
+          if (options.waitTimeout !== undefined) {
+            diagnostics = await waitForDiagnosticsWithRetry(
+              client,
+              fileUri,
+              fileContent,
+              getLanguageIdFromPath(filePath) || undefined,
+              { timeout: options.waitTimeout },
+            );
+          } else {
+            // Open document in LSP
+            client.openDocument(fileUri, fileContent);
 
-          // Wait a bit for LSP to process
-          await new Promise((resolve) => setTimeout(resolve, 50)); // Reduced wait time
+            // Wait a bit for LSP to process
+            await new Promise((resolve) => setTimeout(resolve, 50)); // Reduced wait time
 
-          // Try pull diagnostics first if available
-          let diagnostics;
-          if (client.pullDiagnostics) {
-            try {
-              diagnostics = await client.pullDiagnostics(fileUri);
-            } catch {
-              // Fall back to stored diagnostics
+            // Try pull diagnostics first if available
+            if (client.pullDiagnostics) {
+              try {
+                diagnostics = await client.pullDiagnostics(fileUri);
+              } catch {
+                // Fall back to stored diagnostics
+                diagnostics = client.getDiagnostics(fileUri);
+              }
+            } else {
+              // Get stored diagnostics
               diagnostics = client.getDiagnostics(fileUri);
             }
-          } else {
-            // Get stored diagnostics
-            diagnostics = client.getDiagnostics(fileUri);
           }
 
           if (diagnostics && diagnostics.length > 0) {
-            const mappedDiagnostics = diagnostics
-              .filter((d: Diagnostic) => d && d.range) // Filter out invalid diagnostics
-              .map((d: Diagnostic) => ({
-                severity: SEVERITY_MAP[d.severity || 2] || "warning",
-                line: d.range.start.line + 1, // Convert to 1-based
-                column: d.range.start.character + 1, // Convert to 1-based
-                endLine: d.range.end.line + 1,
-                endColumn: d.range.end.character + 1,
-                message: d.message,
-                source: d.source,
-                code: d.code,
-              }))
-              .filter((d: any) => {
-                // Apply severity filter
-                if (
-                  request.severityFilter === "error" &&
-                  d.severity !== "error"
-                ) {
-                  return false;
-                }
-                if (
-                  request.severityFilter === "warning" &&
-                  d.severity !== "warning"
-                ) {
-                  return false;
-                }
-                return true;
-              });
+            const mappedDiagnostics = toFileDiagnostics(
+              diagnostics,
+              options.severityFilter,
+            );
 
             if (mappedDiagnostics.length > 0) {
-              // Count errors and warnings
-              mappedDiagnostics.forEach((d: any) => {
-                if (d.severity === "error") totalErrors++;
-                else if (d.severity === "warning") totalWarnings++;
-              });
-
               fileDiagnostics.push({
                 filePath,
                 diagnostics: mappedDiagnostics,
@@ -278,27 +311,49 @@ export async function getAllDiagnostics(
     );
 
     // Small delay between batches
-    if (i + DIAGNOSTICS_BATCH_SIZE < files.length) {
+    if (i + batchSize < files.length) {
       await new Promise((resolve) => setTimeout(resolve, 50));
     }
   }
 
-  // Sort files by path
-  fileDiagnostics.sort((a, b) => a.filePath.localeCompare(b.filePath));
+  return summarizeFileDiagnostics(fileDiagnostics);
+}
 
-  return {
-    message: `Found ${totalErrors} error${
-      totalErrors !== 1 ? "s" : ""
-    } and ${totalWarnings} warning${
-      totalWarnings !== 1 ? "s" : ""
-    } in ${fileDiagnostics.length} file${
-      fileDiagnostics.length !== 1 ? "s" : ""
-    }`,
-    totalErrors,
-    totalWarnings,
-    files: fileDiagnostics,
-  };
+/**
+ * Gets diagnostics for all files in the project
+ */
+export async function getAllDiagnostics(
+  request: GetAllDiagnosticsRequest,
+  client: LSPClient,
+): Promise<GetAllDiagnosticsSuccess> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  // Get all project files
+  let files: string[];
+  try {
+    files = await getProjectFiles(
+      request.root,
+      request.pattern,
+      request.exclude,
+      request.useGitignore ?? true,
+    );
+    debug(
+      `[lspGetAllDiagnostics] getProjectFiles returned ${files.length} files`,
+    );
+  } catch (error) {
+    debug(`[lspGetAllDiagnostics] Error in getProjectFiles:`, error);
+    throw error;
+  }
+
+  debug(`[lspGetAllDiagnostics] Found ${files.length} files to check`);
+
+  return collectDiagnosticsForFiles(request.root, files, client, {
+    severityFilter: request.severityFilter,
+  });
 }
 
 // Note: createAllDiagnosticsTool has been removed
-// getAllDiagnostics is now only used internally by getProjectDiagnostics
+// getAllDiagnostics is used internally by getProjectDiagnostics and the
+// get_project_diagnostics tool
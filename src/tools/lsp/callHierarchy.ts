@@ -0,0 +1,409 @@
+import type {
+  LSPClient,
+  CallHierarchyItem,
+  Range,
+} from "@internal/lsp-client";
+import type { McpContext, McpToolDef } from "@internal/types";
+import { getSymbolKindName } from "@internal/types";
+import { z } from "zod";
+import { readFileSync } from "fs";
+import path from "path";
+import { fileURLToPath } from "url";
+import { resolveFileAndSymbol, withLSPDocument } from "./common.ts";
+import {
+  addCallEdge,
+  createCallGraph,
+  renderCallGraph,
+  type CallGraph,
+} from "../../utils/callGraph.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z
+    .string()
+    .describe("File path containing the symbol (relative to root)"),
+  line: z
+    .union([z.number(), z.string()])
+    .describe("Line number (1-based) or string to match in the line"),
+  symbolName: z.string().describe("Name of the function or method to inspect"),
+});
+
+type CallHierarchyRequest = z.infer<typeof schema>;
+
+const callGraphSchema = schema.extend({
+  direction: z
+    .enum(["outgoing", "incoming"])
+    .optional()
+    .default("outgoing")
+    .describe(
+      "outgoing: what the symbol calls (top-down). incoming: what calls the symbol (bottom-up)",
+    ),
+  depth: z
+    .number()
+    .int()
+    .min(1)
+    .max(6)
+    .optional()
+    .default(3)
+    .describe("How many levels of calls to follow"),
+  format: z
+    .enum(["mermaid", "dot"])
+    .optional()
+    .default("mermaid")
+    .describe("Output format: Mermaid flowchart or Graphviz DOT"),
+  maxNodes: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .default(50)
+    .describe("Stop expanding once the graph has this many functions"),
+  includeExternal: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe(
+      "Include functions outside the project root (standard library, dependencies)",
+    ),
+});
+
+type CallGraphRequest = z.infer<typeof callGraphSchema>;
+
+type CallDirection = "incoming" | "outgoing";
+
+interface CallSite {
+  relativePath: string;
+  line: number;
+  column: number;
+  snippet: string;
+}
+
+interface CallEntry {
+  name: string;
+  kind: string;
+  detail?: string;
+  relativePath: string;
+  range: Range;
+  callSites: CallSite[];
+}
+
+function uriToRelativePath(root: string, uri: string): string {
+  try {
+    return path.relative(root, fileURLToPath(uri));
+  } catch {
+    return uri;
+  }
+}
+
+function readLines(uri: string, cache: Map<string, string[]>): string[] {
+  const cached = cache.get(uri);
+  if (cached) return cached;
+  let lines: string[] = [];
+  try {
+    lines = readFileSync(fileURLToPath(uri), "utf-8").split("\n");
+  } catch {
+    // Files outside the workspace may not be readable
+  }
+  cache.set(uri, lines);
+  return lines;
+}
+
+/**
+ * Build call sites from the ranges reported by the server.
+ * For incoming calls the ranges are in the caller, for outgoing calls
+ * they are in the item the hierarchy was prepared for.
+ */
+function toCallSites(
+  root: string,
+  uri: string,
+  ranges: Range[],
+  cache: Map<string, string[]>,
+): CallSite[] {
+  const lines = readLines(uri, cache);
+  return ranges.map((range) => ({
+    relativePath: uriToRelativePath(root, uri),
+    line: range.start.line + 1,
+    column: range.start.character + 1,
+    snippet: (lines[range.start.line] ?? "").trim(),
+  }));
+}
+
+function toEntry(
+  root: string,
+  item: CallHierarchyItem,
+  callSites: CallSite[],
+): CallEntry {
+  return {
+    name: item.name,
+    kind: getSymbolKindName(item.kind) ?? "Unknown",
+    detail: item.detail,
+    relativePath: uriToRelativePath(root, item.uri),
+    range: item.range,
+    callSites,
+  };
+}
+
+async function getCallHierarchy(
+  request: CallHierarchyRequest,
+  client: LSPClient,
+  direction: CallDirection,
+  signal?: AbortSignal,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const { fileUri, fileContent, lineIndex, symbolIndex } =
+    resolveFileAndSymbol({
+      root: request.root,
+      relativePath: request.relativePath,
+      line: request.line,
+      symbolName: request.symbolName,
+    });
+
+  return await withLSPDocument(client, fileUri, fileContent, async () => {
+    const items = await client.prepareCallHierarchy(
+      fileUri,
+      { line: lineIndex, character: symbolIndex },
+      { signal },
+    );
+
+    if (items.length === 0) {
+      return `No call hierarchy item found for "${request.symbolName}" at ${request.relativePath}:${lineIndex + 1}`;
+    }
+
+    const cache = new Map<string, string[]>();
+    const entries: CallEntry[] = [];
+
+    for (const item of items) {
+      if (direction === "incoming") {
+        const calls = await client.getIncomingCalls(item, { signal });
+        for (const call of calls) {
+          entries.push(
+            toEntry(
+              request.root,
+              call.from,
+              toCallSites(request.root, call.from.uri, call.fromRanges, cache),
+            ),
+          );
+        }
+      } else {
+        const calls = await client.getOutgoingCalls(item, { signal });
+        for (const call of calls) {
+          entries.push(
+            toEntry(
+              request.root,
+              call.to,
+              toCallSites(request.root, item.uri, call.fromRanges, cache),
+            ),
+          );
+        }
+      }
+    }
+
+    const label =
+      direction === "incoming"
+        ? `Callers of "${request.symbolName}"`
+        : `Calls made by "${request.symbolName}"`;
+
+    if (entries.length === 0) {
+      return `${label}: none found`;
+    }
+
+    const sections = entries.map((entry) => {
+      const header = `${entry.name} [${entry.kind}] - ${entry.relativePath}:${
+        entry.range.start.line + 1
+      }:${entry.range.start.character + 1}-${entry.range.end.line + 1}:${
+        entry.range.end.character + 1
+      }`;
+      const detail = entry.detail ? `\n  ${entry.detail}` : "";
+      const sites = entry.callSites
+        .map(
+          (site) =>
+            `  ${site.relativePath}:${site.line}:${site.column}: ${site.snippet}`,
+        )
+        .join("\n");
+      return `${header}${detail}\n${sites}`;
+    });
+
+    return `${label} (${entries.length}):\n\n${sections.join("\n\n")}`;
+  });
+}
+
+function itemKey(item: CallHierarchyItem): string {
+  const { line, character } = item.selectionRange.start;
+  return `${item.uri}#${line}:${character}#${item.name}`;
+}
+
+function isInsideRoot(root: string, uri: string): boolean {
+  const relativePath = uriToRelativePath(root, uri);
+  return !relativePath.startsWith("..") && !path.isAbsolute(relativePath);
+}
+
+/**
+ * Breadth-first walk of the call hierarchy starting at the prepared items
+ */
+async function buildCallGraph(
+  request: CallGraphRequest,
+  client: LSPClient,
+  roots: CallHierarchyItem[],
+  signal?: AbortSignal,
+): Promise<CallGraph> {
+  const graph = createCallGraph();
+  const addNode = (item: CallHierarchyItem): string => {
+    const id = itemKey(item);
+    if (!graph.nodes.has(id)) {
+      graph.nodes.set(id, {
+        id,
+        name: item.name,
+        kind: getSymbolKindName(item.kind) ?? "Unknown",
+        relativePath: uriToRelativePath(request.root, item.uri),
+        line: item.selectionRange.start.line + 1,
+      });
+    }
+    return id;
+  };
+
+  let frontier = roots;
+  for (const item of roots) {
+    graph.roots.push(addNode(item));
+  }
+  const expanded = new Set<string>();
+
+  for (let level = 0; level < request.depth && frontier.length > 0; level++) {
+    const next: CallHierarchyItem[] = [];
+    for (const item of frontier) {
+      const id = itemKey(item);
+      if (expanded.has(id)) continue;
+      expanded.add(id);
+
+      const neighbors =
+        request.direction === "incoming"
+          ? (await client.getIncomingCalls(item, { signal })).map(
+              (call) => call.from,
+            )
+          : (await client.getOutgoingCalls(item, { signal })).map(
+              (call) => call.to,
+            );
+
+      for (const neighbor of neighbors) {
+        if (
+          !request.includeExternal &&
+          !isInsideRoot(request.root, neighbor.uri)
+        ) {
+          continue;
+        }
+        const known = graph.nodes.has(itemKey(neighbor));
+        if (!known && graph.nodes.size >= request.maxNodes) {
+          graph.truncated = true;
+          continue;
+        }
+        const neighborId = addNode(neighbor);
+        if (request.direction === "incoming") {
+          addCallEdge(graph, neighborId, id);
+        } else {
+          addCallEdge(graph, id, neighborId);
+        }
+        if (!expanded.has(neighborId)) {
+          next.push(neighbor);
+        }
+      }
+    }
+    frontier = next;
+  }
+
+  return graph;
+}
+
+async function getCallGraph(
+  request: CallGraphRequest,
+  client: LSPClient,
+  signal?: AbortSignal,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const { fileUri, fileContent, lineIndex, symbolIndex } =
+    resolveFileAndSymbol({
+      root: request.root,
+      relativePath: request.relativePath,
+      line: request.line,
+      symbolName: request.symbolName,
+    });
+
+  return await withLSPDocument(client, fileUri, fileContent, async () => {
+    const items = await client.prepareCallHierarchy(
+      fileUri,
+      { line: lineIndex, character: symbolIndex },
+      { signal },
+    );
+
+    if (items.length === 0) {
+      return `No call hierarchy item found for "${request.symbolName}" at ${request.relativePath}:${lineIndex + 1}`;
+    }
+
+    const graph = await buildCallGraph(request, client, items, signal);
+    const label =
+      request.direction === "incoming" ? "Callers of" : "Calls from";
+    let summary = `${label} "${request.symbolName}" (depth ${request.depth}): ${graph.nodes.size} functions, ${graph.edges.length} calls`;
+    if (graph.truncated) {
+      summary += `. Stopped at maxNodes ${request.maxNodes}; raise it or lower depth for the full graph`;
+    }
+
+    const diagram = renderCallGraph(graph, request.format);
+    return `${summary}\n\n\`\`\`${request.format}\n${diagram}\n\`\`\``;
+  });
+}
+
+/**
+ * Create incoming calls tool with injected LSP client
+ */
+export function createIncomingCallsTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "lsp_get_incoming_calls",
+    description:
+      "Find all functions that call the given function using LSP call hierarchy. Returns caller file, range and call site snippets.",
+    schema,
+    execute: async (args, context?: McpContext) => {
+      return getCallHierarchy(args, client, "incoming", context?.signal);
+    },
+  };
+}
+
+/**
+ * Create outgoing calls tool with injected LSP client
+ */
+export function createOutgoingCallsTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "lsp_get_outgoing_calls",
+    description:
+      "Find all functions called by the given function using LSP call hierarchy. Returns callee file, range and call site snippets.",
+    schema,
+    execute: async (args, context?: McpContext) => {
+      return getCallHierarchy(args, client, "outgoing", context?.signal);
+    },
+  };
+}
+
+/**
+ * Create call graph tool with injected LSP client
+ */
+export function createCallGraphTool(
+  client: LSPClient,
+): McpToolDef<typeof callGraphSchema> {
+  return {
+    name: "lsp_get_call_graph",
+    description:
+      "Build a call graph rooted at a function using LSP call hierarchy, following calls to the given depth. " +
+      "Returns Mermaid or Graphviz DOT text ready to paste into a design doc.",
+    schema: callGraphSchema,
+    execute: async (args, context?: McpContext) => {
+      return getCallGraph(args, client, context?.signal);
+    },
+  };
+}
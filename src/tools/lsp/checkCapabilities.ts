@@ -23,6 +23,7 @@ async function handleCheckCapabilities(client: LSPClient): Promise<string> {
 
   // Basic information
   result += `**Language ID**: ${client.languageId}\n\n`;
+  result += `**Position Encoding**: ${capabilities.positionEncoding ?? "utf-16"}\n\n`;
 
   // Core capabilities
   result += "## Core Capabilities\n\n";
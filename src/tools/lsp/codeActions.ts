@@ -3,10 +3,35 @@ import { resolveLineParameter } from "@internal/lsp-client";
 import { z } from "zod";
 import path from "path";
 import fs from "fs/promises";
-import { pathToFileURL } from "url";
+import { fileURLToPath, pathToFileURL } from "url";
+import { markFileModified } from "@internal/code-indexer";
 import { CodeAction, CodeActionKind, Command } from "@internal/types";
-import type { McpToolDef } from "@internal/types";
+import type {
+  Diagnostic,
+  McpContext,
+  McpToolDef,
+  Range,
+  WorkspaceEdit,
+} from "@internal/types";
 import { withLSPDocument } from "./common.ts";
+import {
+  confirmWorkspaceEdit,
+  findStaleFiles,
+  formatWorkspaceEditDiff,
+  planWorkspaceEdit,
+  toRelative,
+  writeWorkspaceEditPlan,
+} from "../editor/workspaceEditTools.ts";
+import { previewWorkspaceEdit } from "../editor/pendingEdits.ts";
+import { confirmOperation } from "../../utils/confirmation.ts";
+import {
+  deltaFilesOfPlan,
+  trackDiagnostics,
+  type DeltaFile,
+} from "./diagnosticsDelta.ts";
+
+// How long to wait for a freshly opened document's first diagnostics
+const DIAGNOSTICS_WAIT_TIMEOUT = 1500;
 
 const schemaShape = {
   root: z.string().describe("Root directory for resolving relative paths"),
@@ -26,10 +51,57 @@ const schemaShape = {
       "Filter for specific code action kinds (e.g., 'quickfix', 'refactor')",
     )
     .optional(),
+  diagnostic: z
+    .string()
+    .describe(
+      "Only request actions for diagnostics whose message or code contains this text",
+    )
+    .optional(),
 };
 
 const schema = z.object(schemaShape);
 
+const applySchema = z.object({
+  ...schemaShape,
+  index: z
+    .number()
+    .int()
+    .positive()
+    .describe("Number of the action as listed by lsp_get_code_actions")
+    .optional(),
+  title: z
+    .string()
+    .describe("Title of the action to apply (case-insensitive substring)")
+    .optional(),
+  dryRun: z
+    .boolean()
+    .default(false)
+    .describe("Show the resulting diff without writing files"),
+  preview: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Return the diff and an edit token without writing; apply it later with confirm_edit",
+    ),
+});
+
+const organizeImportsSchema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z
+    .string()
+    .describe("File whose imports should be organized (relative to root)"),
+  dryRun: z
+    .boolean()
+    .default(false)
+    .describe("Show the resulting diff without writing files"),
+  preview: z
+    .boolean()
+    .default(false)
+    .describe(
+      "Return the diff and an edit token without writing; apply it later with confirm_edit",
+    ),
+});
+
 function getCodeActionKindName(kind?: string | CodeActionKind): string {
   if (!kind) return "General";
 
@@ -51,6 +123,20 @@ function isCommand(action: Command | CodeAction): action is Command {
   return "command" in action && typeof action.command === "string";
 }
 
+function editedUris(edit: WorkspaceEdit): string[] {
+  const uris = new Set(Object.keys(edit.changes ?? {}));
+  for (const change of edit.documentChanges ?? []) {
+    if ("textDocument" in change) {
+      uris.add(change.textDocument.uri);
+    } else if (change.kind === "rename") {
+      uris.add(change.newUri);
+    } else {
+      uris.add(change.uri);
+    }
+  }
+  return Array.from(uris);
+}
+
 function formatCodeAction(action: Command | CodeAction): string {
   if (isCommand(action)) {
     // Format as command
@@ -81,31 +167,40 @@ function formatCodeAction(action: Command | CodeAction): string {
     }
 
     if (action.edit) {
-      const changes = action.edit.changes;
-      if (changes) {
-        const fileCount = Object.keys(changes).length;
+      const fileCount = editedUris(action.edit).length;
+      if (fileCount > 0) {
         result += `\n  Edits ${fileCount} file(s)`;
       }
+    } else if (action.data !== undefined) {
+      result += "\n  Edit resolved on apply";
     }
 
     return result;
   }
 }
 
-async function handleGetCodeActions(
-  {
-    root,
-    relativePath,
-    startLine,
-    endLine,
-    includeKinds,
-  }: z.infer<typeof schema>,
-  client: LSPClient,
-): Promise<string> {
-  if (!client) {
-    throw new Error("LSP client not initialized");
-  }
+interface CodeActionRequest {
+  root: string;
+  relativePath: string;
+  startLine: number | string;
+  endLine?: number | string;
+  includeKinds?: string[];
+  diagnostic?: string;
+}
+
+interface CodeActionTarget {
+  fileUri: string;
+  content: string;
+  startLineIndex: number;
+  endLineIndex: number;
+}
 
+async function resolveCodeActionTarget({
+  root,
+  relativePath,
+  startLine,
+  endLine,
+}: CodeActionRequest): Promise<CodeActionTarget> {
   // Convert to absolute path
   const absolutePath = path.isAbsolute(relativePath)
     ? relativePath
@@ -130,80 +225,449 @@ async function handleGetCodeActions(
     endLineIndex = resolveLineParameter(lines, endLine);
   }
 
-  // Use common LSP document wrapper
-  return await withLSPDocument(client, fileUri, content, async () => {
-    // Get diagnostics for the range (to provide context for code actions)
-    const diagnostics = client.getDiagnostics(fileUri);
-    const rangeDiagnostics = diagnostics.filter((d: any) => {
-      const line = d.range.start.line;
-      return line >= startLineIndex && line <= endLineIndex;
-    });
+  return { fileUri, content, startLineIndex, endLineIndex };
+}
 
-    // Get code actions
-    const range = {
-      start: { line: startLineIndex, character: 0 },
-      end: {
-        line: endLineIndex,
-        character: content.split("\n")[endLineIndex]?.length ?? 0,
-      },
-    };
-
-    const actions = await client.getCodeActions(fileUri, range, {
-      diagnostics: rangeDiagnostics,
-    });
+function matchesDiagnostic(d: Diagnostic, text: string): boolean {
+  const needle = text.toLowerCase();
+  return (
+    d.message.toLowerCase().includes(needle) ||
+    (d.code !== undefined && String(d.code).toLowerCase().includes(needle))
+  );
+}
+
+/**
+ * Request code actions for the target range and apply kind filters.
+ * Must be called while the document is open.
+ */
+async function requestCodeActions(
+  client: LSPClient,
+  target: CodeActionTarget,
+  { includeKinds, diagnostic }: CodeActionRequest,
+): Promise<(Command | CodeAction)[]> {
+  const { fileUri, content, startLineIndex, endLineIndex } = target;
+
+  // Get diagnostics for the range (to provide context for code actions).
+  // Quick fixes such as clangd's include fixer are only returned for
+  // diagnostics sent back in the context, so wait for the first publish
+  // when the document was just opened.
+  let diagnostics = client.getDiagnostics(fileUri);
+  if (diagnostics.length === 0 && client.waitForDiagnostics) {
+    diagnostics = await client
+      .waitForDiagnostics(fileUri, DIAGNOSTICS_WAIT_TIMEOUT)
+      .catch(() => client.getDiagnostics(fileUri));
+  }
+  const rangeDiagnostics = diagnostics.filter((d: Diagnostic) => {
+    const line = d.range.start.line;
+    return (
+      line >= startLineIndex &&
+      line <= endLineIndex &&
+      (!diagnostic || matchesDiagnostic(d, diagnostic))
+    );
+  });
+
+  // Get code actions
+  const range: Range = {
+    start: { line: startLineIndex, character: 0 },
+    end: {
+      line: endLineIndex,
+      character: content.split("\n")[endLineIndex]?.length ?? 0,
+    },
+  };
+
+  const actions = await client.getCodeActions(fileUri, range, {
+    diagnostics: rangeDiagnostics,
+    only: includeKinds,
+  });
 
-    if (actions.length === 0) {
-      return `No code actions available for ${relativePath}:${startLineIndex + 1}-${
-        endLineIndex + 1
-      }`;
+  // Filter by kinds if specified (servers may ignore context.only)
+  if (!includeKinds || includeKinds.length === 0) {
+    return actions;
+  }
+  return actions.filter((action) => {
+    if (isCommand(action)) {
+      // Commands don't have kinds, so exclude them when filtering
+      return false;
     }
+    return action.kind && includeKinds.some((k) => action.kind?.startsWith(k));
+  });
+}
+
+function formatRangeLabel(
+  relativePath: string,
+  target: CodeActionTarget,
+): string {
+  return `${relativePath}:${target.startLineIndex + 1}-${target.endLineIndex + 1}`;
+}
 
-    // Filter by kinds if specified
-    let filteredActions = actions;
-    if (includeKinds && includeKinds.length > 0) {
-      filteredActions = actions.filter((action: any) => {
-        if (isCommand(action)) {
-          // Commands don't have kinds, so exclude them when filtering
-          return false;
+async function handleGetCodeActions(
+  request: z.infer<typeof schema>,
+  client: LSPClient,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const target = await resolveCodeActionTarget(request);
+  const label = formatRangeLabel(request.relativePath, target);
+
+  // Use common LSP document wrapper
+  return await withLSPDocument(
+    client,
+    target.fileUri,
+    target.content,
+    async () => {
+      const actions = await requestCodeActions(client, target, request);
+
+      if (actions.length === 0) {
+        return request.includeKinds && request.includeKinds.length > 0
+          ? `No code actions matching the specified kinds found for ${label}`
+          : `No code actions available for ${label}`;
+      }
+
+      // Group actions by kind, keeping their position in the request order
+      const grouped = new Map<string, number[]>();
+      actions.forEach((action, index) => {
+        const kind = isCommand(action) ? "command" : action.kind || "general";
+        if (!grouped.has(kind)) {
+          grouped.set(kind, []);
         }
-        return (
-          action.kind && includeKinds.some((k) => action.kind?.startsWith(k))
-        );
+        grouped.get(kind)!.push(index);
       });
+
+      // Format the code actions
+      let result = `Code actions for ${label}:\n\n`;
+
+      for (const [kind, indices] of grouped) {
+        const kindName = getCodeActionKindName(kind);
+        result += `=== ${kindName} ===\n`;
+
+        for (const index of indices) {
+          result += `${index + 1}. ${formatCodeAction(actions[index])}\n\n`;
+        }
+      }
+
+      result +=
+        "Use lsp_apply_code_action with the same range and index to apply.";
+      return result.trim();
+    },
+  );
+}
+
+function selectCodeAction(
+  actions: (Command | CodeAction)[],
+  index?: number,
+  title?: string,
+): Command | CodeAction {
+  if (index !== undefined) {
+    const action = actions[index - 1];
+    if (!action) {
+      throw new Error(
+        `Code action #${index} not found (${actions.length} available)`,
+      );
+    }
+    return action;
+  }
+
+  if (title === undefined) {
+    throw new Error("Either index or title is required");
+  }
+
+  const needle = title.toLowerCase();
+  const exact = actions.filter((a) => a.title.toLowerCase() === needle);
+  const matches =
+    exact.length > 0
+      ? exact
+      : actions.filter((a) => a.title.toLowerCase().includes(needle));
+  if (matches.length === 0) {
+    const available = actions.map((a, i) => `  ${i + 1}. ${a.title}`);
+    throw new Error(
+      `No code action matching "${title}". Available:\n${available.join("\n")}`,
+    );
+  }
+  if (matches.length > 1) {
+    throw new Error(
+      `"${title}" matches ${matches.length} code actions: ${matches
+        .map((a) => a.title)
+        .join(", ")}. Use index instead.`,
+    );
+  }
+  return matches[0];
+}
+
+function supportsResolve(client: LSPClient): boolean {
+  const provider = client.getServerCapabilities()?.codeActionProvider;
+  return typeof provider === "object" && !!provider.resolveProvider;
+}
+
+/**
+ * Execute a command and collect edits the server applies through
+ * workspace/applyEdit while it runs
+ */
+export async function executeCommandWithEdits(
+  client: LSPClient,
+  root: string,
+  command: Command,
+): Promise<{ result: unknown; touched: string[] }> {
+  if (!client.executeCommand) {
+    throw new Error("LSP client does not support workspace/executeCommand");
+  }
+
+  const touched = new Set<string>();
+  const listener = (params: unknown) => {
+    const { edit } = params as { edit: WorkspaceEdit };
+    for (const uri of editedUris(edit)) {
+      touched.add(uri.startsWith("file://") ? fileURLToPath(uri) : uri);
     }
+  };
+
+  client.on("workspaceEditApplied", listener);
+  let result: unknown;
+  try {
+    result = await client.executeCommand(command.command, command.arguments);
+  } finally {
+    client.off?.("workspaceEditApplied", listener);
+  }
+
+  return {
+    result,
+    touched: Array.from(touched).map((filePath) => {
+      markFileModified(root, filePath);
+      return path.relative(root, filePath);
+    }),
+  };
+}
 
-    if (filteredActions.length === 0) {
-      return `No code actions matching the specified kinds found for ${relativePath}:${
-        startLineIndex + 1
-      }-${endLineIndex + 1}`;
+/**
+ * Apply (or preview) a single code action and describe what happened.
+ * Must be called while the document is open.
+ */
+async function applyCodeAction(
+  client: LSPClient,
+  root: string,
+  target: CodeActionTarget,
+  selected: Command | CodeAction,
+  dryRun: boolean,
+  preview = false,
+  context?: McpContext,
+): Promise<string[]> {
+  let action = selected;
+  if (!isCommand(action) && action.disabled) {
+    throw new Error(
+      `Code action "${action.title}" is disabled: ${action.disabled.reason}`,
+    );
+  }
+
+  // Resolve lazily computed edits
+  if (
+    !isCommand(action) &&
+    !action.edit &&
+    supportsResolve(client) &&
+    client.resolveCodeAction
+  ) {
+    action = await client.resolveCodeAction(action);
+  }
+
+  const command = isCommand(action) ? action : action.command;
+  const edit = isCommand(action) ? undefined : action.edit;
+  if (!edit && !command) {
+    throw new Error(
+      `Code action "${action.title}" has no edit or command to apply`,
+    );
+  }
+
+  const plan = edit ? await planWorkspaceEdit(edit) : undefined;
+  if (plan && plan.errors.length > 0) {
+    throw new Error(
+      `Cannot apply code action "${action.title}":\n${plan.errors
+        .map((e) => `  ${e}`)
+        .join("\n")}`,
+    );
+  }
+
+  if (preview) {
+    if (command) {
+      throw new Error(
+        `Code action "${action.title}" runs the server command ${command.command}, whose edits cannot be previewed. Use dryRun: true or apply it directly.`,
+      );
     }
+    return [
+      previewWorkspaceEdit(root, plan!, `code action "${action.title}"`, {
+        sessionId: context?.sessionId,
+      }),
+    ];
+  }
 
-    // Group actions by kind
-    const grouped = new Map<string, (Command | CodeAction)[]>();
-    for (const action of filteredActions) {
-      const kind = isCommand(action) ? "command" : action.kind || "general";
-      if (!grouped.has(kind)) {
-        grouped.set(kind, []);
-      }
-      grouped.get(kind)!.push(action);
+  const lines: string[] = [];
+  if (dryRun) {
+    lines.push(`Dry run: "${action.title}"`);
+    if (plan && plan.changes.length > 0) {
+      lines.push(
+        `${plan.changes.length} file(s) would change:`,
+        "",
+        formatWorkspaceEditDiff(root, plan),
+      );
     }
+    if (command) {
+      lines.push(
+        "",
+        `Would execute command: ${command.command} (its edits are computed by the server and not previewed)`,
+      );
+    }
+    return lines;
+  }
 
-    // Format the code actions
-    let result = `Code actions for ${relativePath}:${startLineIndex + 1}-${
-      endLineIndex + 1
-    }:\n\n`;
+  const title = `code action "${action.title}"`;
+  if (command) {
+    // Server commands may edit any file, whatever the action's edit says
+    await confirmOperation(context, {
+      kind: "command",
+      title: `${title} (runs ${command.command})`,
+      files: (plan?.changes ?? []).map((change) =>
+        toRelative(root, change.filePath),
+      ),
+    });
+  } else if (plan) {
+    await confirmWorkspaceEdit(root, plan, title, context);
+  }
 
-    for (const [kind, kindActions] of grouped) {
-      const kindName = getCodeActionKindName(kind);
-      result += `=== ${kindName} ===\n`;
+  lines.push(`Applied ${title}`);
 
-      for (const action of kindActions) {
-        result += formatCodeAction(action) + "\n\n";
-      }
+  // Commands may edit the target file without saying so beforehand
+  const deltaFiles: DeltaFile[] = plan ? deltaFilesOfPlan(plan) : [];
+  const targetPath = fileURLToPath(target.fileUri);
+  if (command && !deltaFiles.some((file) => file.filePath === targetPath)) {
+    deltaFiles.push({ filePath: targetPath });
+  }
+  const delta = await trackDiagnostics(client, deltaFiles, context);
+
+  if (plan && plan.changes.length > 0) {
+    const stale = await findStaleFiles(root, plan);
+    if (stale.length > 0) {
+      throw new Error(
+        `Refusing to apply code action; files changed since it was computed: ${stale.join(", ")}`,
+      );
+    }
+    const written = await writeWorkspaceEditPlan(root, plan, { context });
+    lines.push(`Edited ${written.length} file(s):`);
+    lines.push(...written.map((file) => `  ${file}`));
+
+    // Keep the server's view in sync before running a follow-up command
+    const updated = plan.changes.find(
+      (change) => pathToFileURL(change.filePath).toString() === target.fileUri,
+    );
+    if (command && updated && updated.after !== null) {
+      client.updateDocument(target.fileUri, updated.after);
     }
+  }
 
-    return result.trim();
-  });
+  if (command) {
+    const { touched } = await executeCommandWithEdits(client, root, command);
+    lines.push(`Executed command: ${command.command}`);
+    if (touched.length > 0) {
+      lines.push(`Server edited ${touched.length} file(s):`);
+      lines.push(...touched.map((file) => `  ${file}`));
+    }
+  }
+
+  const diagnostics = await delta(root);
+  if (diagnostics) {
+    lines.push("", diagnostics);
+  }
+  return lines;
+}
+
+async function handleApplyCodeAction(
+  request: z.infer<typeof applySchema>,
+  client: LSPClient,
+  context?: McpContext,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const { root, index, title, dryRun, preview } = request;
+  if (index === undefined && title === undefined) {
+    throw new Error("Either index or title is required");
+  }
+
+  const target = await resolveCodeActionTarget(request);
+  const label = formatRangeLabel(request.relativePath, target);
+
+  return await withLSPDocument(
+    client,
+    target.fileUri,
+    target.content,
+    async () => {
+      const actions = await requestCodeActions(client, target, request);
+      if (actions.length === 0) {
+        throw new Error(`No code actions available for ${label}`);
+      }
+
+      const action = selectCodeAction(actions, index, title);
+      const lines = await applyCodeAction(
+        client,
+        root,
+        target,
+        action,
+        dryRun,
+        preview,
+        context,
+      );
+      return lines.join("\n");
+    },
+  );
+}
+
+async function handleOrganizeImports(
+  request: z.infer<typeof organizeImportsSchema>,
+  client: LSPClient,
+  context?: McpContext,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const { root, relativePath, dryRun, preview } = request;
+  const codeActionRequest: CodeActionRequest = {
+    root,
+    relativePath,
+    startLine: 1,
+    includeKinds: [CodeActionKind.SourceOrganizeImports],
+  };
+  const target = await resolveCodeActionTarget(codeActionRequest);
+  // Source actions apply to the whole file
+  target.endLineIndex = target.content.split("\n").length - 1;
+
+  return await withLSPDocument(
+    client,
+    target.fileUri,
+    target.content,
+    async () => {
+      const actions = await requestCodeActions(
+        client,
+        target,
+        codeActionRequest,
+      );
+      const action = actions.find((a) => !isCommand(a) && !a.disabled);
+      if (!action) {
+        return `No organize imports action available for ${relativePath}`;
+      }
+
+      const lines = await applyCodeAction(
+        client,
+        root,
+        target,
+        action,
+        dryRun,
+        preview,
+        context,
+      );
+      return lines.length === 1 && !dryRun && !preview
+        ? `Imports in ${relativePath} are already organized`
+        : lines.join("\n");
+    },
+  );
 }
 
 /**
@@ -215,10 +679,49 @@ export function createCodeActionsTool(
   return {
     name: "lsp_get_code_actions",
     description:
-      "Get available code actions (quick fixes, refactorings) for a specific range using LSP. Requires line range specification.",
+      "Get available code actions (quick fixes, refactorings) for a specific range using LSP. Requires line range specification. " +
+      "Actions are numbered; pass the number to lsp_apply_code_action to apply one.",
     schema,
     execute: async (args) => {
       return handleGetCodeActions(args, client);
     },
   };
 }
+
+/**
+ * Create apply code action tool with injected LSP client
+ */
+export function createApplyCodeActionTool(
+  client: LSPClient,
+): McpToolDef<typeof applySchema> {
+  return {
+    name: "lsp_apply_code_action",
+    description:
+      "Apply a code action (quick fix, refactoring) listed by lsp_get_code_actions. " +
+      "Select it by index or title using the same range arguments. " +
+      "Edits are resolved via codeAction/resolve when needed; commands are executed on the server. " +
+      "Use dryRun: true to preview the diff, or preview: true to get the diff with an edit token for confirm_edit.",
+    schema: applySchema,
+    execute: async (args, context) => {
+      return handleApplyCodeAction(args, client, context);
+    },
+  };
+}
+
+/**
+ * Create organize imports tool with injected LSP client
+ */
+export function createOrganizeImportsTool(
+  client: LSPClient,
+): McpToolDef<typeof organizeImportsSchema> {
+  return {
+    name: "lsp_organize_imports",
+    description:
+      "Organize imports in a file via the source.organizeImports code action (sorts, groups and removes unused imports). " +
+      "Applies the edit; use dryRun: true to preview the diff, or preview: true to get the diff with an edit token for confirm_edit.",
+    schema: organizeImportsSchema,
+    execute: async (args, context) => {
+      return handleOrganizeImports(args, client, context);
+    },
+  };
+}
@@ -31,6 +31,32 @@ const schema = z.object({
     .describe("Whether to include auto-import suggestions")
     .optional()
     .default(false),
+  kinds: z
+    .array(z.string())
+    .describe(
+      "Only include these completion kinds (e.g. method, field, function, keyword)",
+    )
+    .optional(),
+  limit: z
+    .number()
+    .int()
+    .positive()
+    .describe("Maximum number of items to return")
+    .optional()
+    .default(20),
+  offset: z
+    .number()
+    .int()
+    .min(0)
+    .describe("Number of items to skip (for paging through results)")
+    .optional()
+    .default(0),
+  resolveItems: z
+    .array(z.string())
+    .describe(
+      "Labels of items to resolve via completionItem/resolve for full documentation",
+    )
+    .optional(),
 });
 
 function getCompletionItemKindName(kind?: CompletionItemKind): string {
@@ -67,6 +93,39 @@ function getCompletionItemKindName(kind?: CompletionItemKind): string {
   return kindNames[kind] || "Unknown";
 }
 
+/**
+ * Map kind names (case-insensitive) to CompletionItemKind values
+ */
+function parseCompletionKinds(names: string[]): Set<CompletionItemKind> {
+  const byName = new Map<string, CompletionItemKind>();
+  for (let kind = 1; kind <= 25; kind++) {
+    byName.set(
+      getCompletionItemKindName(kind as CompletionItemKind).toLowerCase(),
+      kind as CompletionItemKind,
+    );
+  }
+
+  const kinds = new Set<CompletionItemKind>();
+  for (const name of names) {
+    const kind = byName.get(name.toLowerCase());
+    if (kind === undefined) {
+      throw new Error(
+        `Unknown completion kind "${name}". Valid kinds: ${Array.from(
+          byName.keys(),
+        ).join(", ")}`,
+      );
+    }
+    kinds.add(kind);
+  }
+  return kinds;
+}
+
+function compareCompletionItems(a: CompletionItem, b: CompletionItem): number {
+  const aKey = a.sortText ?? a.label;
+  const bKey = b.sortText ?? b.label;
+  return aKey < bKey ? -1 : aKey > bKey ? 1 : a.label.localeCompare(b.label);
+}
+
 function formatCompletionItem(
   item: CompletionItem,
   showImportInfo: boolean = false,
@@ -126,12 +185,18 @@ async function handleGetCompletion(
     textTarget,
     resolve,
     includeAutoImport,
+    kinds,
+    limit,
+    offset,
+    resolveItems,
   }: z.infer<typeof schema>,
   client: LSPClient,
 ): Promise<string> {
   if (!client) {
     throw new Error("LSP client not initialized");
   }
+  const kindFilter =
+    kinds && kinds.length > 0 ? parseCompletionKinds(kinds) : undefined;
   const { fileUri, content } = await loadFileContext(
     root,
     relativePath,
@@ -167,11 +232,30 @@ async function handleGetCompletion(
       character,
     });
 
-    // Process with handler
-    const processedCompletions = handler.processCompletionItems(
-      completions || [],
+    // Process with handler, then filter by kind and sort for stable paging
+    const processedCompletions = handler
+      .processCompletionItems(completions || [])
+      .filter((item) => !kindFilter || kindFilter.has(item.kind!))
+      .sort(compareCompletionItems);
+    const page = processedCompletions.slice(offset, offset + limit);
+
+    // Resolve documentation only for the items that were asked for
+    const resolveLabels = new Set(resolveItems ?? []);
+    const provider = client.getServerCapabilities()?.completionProvider;
+    const canResolve =
+      typeof provider === "object" && !!provider.resolveProvider;
+    const finalCompletions = await Promise.all(
+      page.map(async (item) => {
+        if (!canResolve || (!resolve && !resolveLabels.has(item.label))) {
+          return item;
+        }
+        try {
+          return await client.resolveCompletionItem(item);
+        } catch {
+          return item;
+        }
+      }),
     );
-    const finalCompletions = processedCompletions.slice(0, 20);
 
     if (completions.length === 0) {
       const message = includeAutoImport
@@ -184,17 +268,26 @@ async function handleGetCompletion(
       return message;
     }
 
+    const position = `${relativePath}:${lineIndex + 1}:${character + 1}`;
+    if (processedCompletions.length === 0) {
+      return `No completions matching the given filters at ${position}`;
+    }
+    if (finalCompletions.length === 0) {
+      return `No completions at ${position} after offset ${offset} (${processedCompletions.length} total)`;
+    }
+
     // Format the completions
-    let result = `Completions at ${relativePath}:${lineIndex + 1}:${
-      character + 1
-    }:\n\n`;
+    const end = offset + finalCompletions.length;
+    let result = `Completions at ${position} (${offset + 1}-${end} of ${processedCompletions.length}):\n\n`;
 
     for (const item of finalCompletions) {
-      result += formatCompletionItem(item, resolve) + "\n\n";
+      const showDetails = resolve || resolveLabels.has(item.label);
+      result += formatCompletionItem(item, showDetails) + "\n\n";
     }
 
-    // Note: We can't show "more completions" count anymore since we're using the handler
-    // which already limits the results
+    if (end < processedCompletions.length) {
+      result += `Use offset: ${end} to see more.`;
+    }
 
     return result.trim();
   });
@@ -209,7 +302,8 @@ export function createCompletionTool(
   return {
     name: "lsp_get_completion",
     description:
-      "Get code completion suggestions at a specific position using LSP. Requires exact line:column coordinates.",
+      "Get code completion suggestions at a specific position using LSP. Requires exact line:column coordinates. " +
+      "Filter by kinds (e.g. method, field), page with limit/offset, and resolve documentation for selected labels with resolveItems.",
     schema,
     execute: async (args) => {
       return handleGetCompletion(args, client);
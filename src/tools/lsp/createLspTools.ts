@@ -14,11 +14,28 @@ import { createRenameSymbolTool } from "./rename.ts";
 import { createDocumentSymbolsTool } from "./documentSymbols.ts";
 import { createCompletionTool } from "./completion.ts";
 import { createSignatureHelpTool } from "./signatureHelp.ts";
-import { createFormatDocumentTool } from "./formatting.ts";
+import { createInlayHintsTool } from "./inlayHints.ts";
+import {
+  createFormatDocumentTool,
+  createFormatRangeTool,
+} from "./formatting.ts";
 import { createWorkspaceSymbolsTool } from "./workspaceSymbols.ts";
-import { createCodeActionsTool } from "./codeActions.ts";
+import {
+  createApplyCodeActionTool,
+  createCodeActionsTool,
+  createOrganizeImportsTool,
+} from "./codeActions.ts";
 import { createCheckCapabilitiesTool } from "./checkCapabilities.ts";
 import { createDeleteSymbolTool } from "./deleteSymbol.ts";
+import {
+  createCallGraphTool,
+  createIncomingCallsTool,
+  createOutgoingCallsTool,
+} from "./callHierarchy.ts";
+import { createTypeHierarchyTool } from "./typeHierarchy.ts";
+import { createExecuteCommandTool } from "./executeCommand.ts";
+import { createMoveSymbolToFileTool } from "./moveSymbol.ts";
+import { createLspRequestTool } from "./lspRequest.ts";
 
 /**
  * Create all LSP tools with an injected client
@@ -33,10 +50,21 @@ export function createLSPTools(client: LSPClient): McpToolDef<any>[] {
     createDocumentSymbolsTool(client),
     createCompletionTool(client),
     createSignatureHelpTool(client),
+    createInlayHintsTool(client),
     createFormatDocumentTool(client),
+    createFormatRangeTool(client),
     createWorkspaceSymbolsTool(client),
     createCodeActionsTool(client),
+    createApplyCodeActionTool(client),
+    createOrganizeImportsTool(client),
     createCheckCapabilitiesTool(client),
     createDeleteSymbolTool(client),
+    createIncomingCallsTool(client),
+    createOutgoingCallsTool(client),
+    createCallGraphTool(client),
+    createTypeHierarchyTool(client),
+    createExecuteCommandTool(client),
+    createMoveSymbolToFileTool(client),
+    createLspRequestTool(client),
   ];
 }
@@ -56,6 +56,18 @@ interface GetDefinitionsSuccess {
   definitions: Definition[];
 }
 
+const outputSchema = {
+  definitions: z.array(
+    z.object({
+      relativePath: z.string(),
+      line: z.number().describe("1-based"),
+      column: z.number().describe("1-based"),
+      symbolName: z.string(),
+      preview: z.string().describe("Numbered source lines at the definition"),
+    }),
+  ),
+};
+
 // Import Location and LocationLink types from vscode-languageserver-types via lspTypes
 import type {
   Location,
@@ -362,9 +374,13 @@ export function createDefinitionsTool(
     description:
       "Get the definition(s) of a symbol at a specific position using LSP. Requires exact line:column coordinates.",
     schema,
-    execute: async (args: z.infer<typeof schema>) => {
+    outputSchema,
+    execute: async (args: z.infer<typeof schema>, context) => {
       const result = await getDefinitionsWithLSP(args, client);
       if (result.isOk()) {
+        context?.setStructuredContent?.({
+          definitions: result.value.definitions,
+        });
         const messages = [result.value.message];
 
         if (result.value.definitions.length > 0) {
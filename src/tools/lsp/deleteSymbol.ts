@@ -10,8 +10,12 @@ import {
   TextEdit,
   WorkspaceEdit,
 } from "@internal/types";
-import type { McpToolDef } from "@internal/types";
+import type { McpContext, McpToolDef } from "@internal/types";
 import { resolveLineParameter } from "@internal/lsp-client";
+import { planWorkspaceEdit } from "../editor/workspaceEditTools.ts";
+import { previewWorkspaceEdit } from "../editor/pendingEdits.ts";
+import { trackDiagnostics } from "./diagnosticsDelta.ts";
+import { confirmOperation } from "../../utils/confirmation.ts";
 
 const schemaShape = {
   root: z.string().describe("Root directory for resolving relative paths"),
@@ -27,6 +31,13 @@ const schemaShape = {
     .optional()
     .default(true)
     .describe("Also delete all references to the symbol"),
+  preview: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe(
+      "Return the diff and an edit token without deleting; apply it later with confirm_edit",
+    ),
 };
 
 const schema = z.object(schemaShape);
@@ -36,6 +47,10 @@ interface DeleteSymbolResult {
   deletedFromFiles: Set<string>;
   totalDeleted: number;
   failureReason?: string;
+  /** Diff and edit token when previewing */
+  preview?: string;
+  /** Diagnostics introduced and fixed by the deletion */
+  diagnostics?: string;
 }
 
 async function handleDeleteSymbol(
@@ -45,8 +60,10 @@ async function handleDeleteSymbol(
     line,
     textTarget,
     removeReferences = true,
+    preview = false,
   }: z.infer<typeof schema>,
   client: LSPClient,
+  context?: McpContext,
 ): Promise<DeleteSymbolResult> {
   if (!client) {
     throw new Error("LSP client not initialized");
@@ -174,6 +191,40 @@ async function handleDeleteSymbol(
       workspaceEdit.changes![uri] = edits;
     }
 
+    if (preview) {
+      const plan = await planWorkspaceEdit(workspaceEdit);
+      if (plan.errors.length > 0) {
+        throw new Error(
+          `Cannot preview deleting "${textTarget}": ${plan.errors.join("; ")}`,
+        );
+      }
+      return {
+        applied: false,
+        deletedFromFiles: new Set(fileChanges.keys()),
+        totalDeleted: locations.length,
+        preview: previewWorkspaceEdit(
+          root,
+          plan,
+          `deletion of "${textTarget}"`,
+          { sessionId: context?.sessionId },
+        ),
+      };
+    }
+
+    await confirmOperation(context, {
+      kind: "delete",
+      title: `deletion of "${textTarget}"`,
+      files: [...fileChanges.keys()].map((uri) =>
+        path.relative(root, fileURLToPath(uri)),
+      ),
+    });
+
+    const delta = await trackDiagnostics(
+      client,
+      [...fileChanges.keys()].map((uri) => ({ filePath: fileURLToPath(uri) })),
+      context,
+    );
+
     // Apply the workspace edit
     const result = await client.applyEdit(
       workspaceEdit,
@@ -193,6 +244,7 @@ async function handleDeleteSymbol(
       applied: true,
       deletedFromFiles: new Set(fileChanges.keys()),
       totalDeleted: locations.length,
+      diagnostics: await delta(root),
     };
   } finally {
     // Close all opened documents
@@ -203,6 +255,9 @@ async function handleDeleteSymbol(
 }
 
 function formatDeleteSymbolResult(result: DeleteSymbolResult): string {
+  if (result.preview) {
+    return result.preview;
+  }
   if (!result.applied) {
     return `Failed to delete symbol: ${result.failureReason}`;
   }
@@ -218,7 +273,8 @@ function formatDeleteSymbolResult(result: DeleteSymbolResult): string {
     })
     .join("\n  ");
 
-  return `Successfully deleted symbol from ${fileCount} file(s) with ${result.totalDeleted} occurrence(s)\n\nModified files:\n  ${fileList}`;
+  const output = `Successfully deleted symbol from ${fileCount} file(s) with ${result.totalDeleted} occurrence(s)\n\nModified files:\n  ${fileList}`;
+  return result.diagnostics ? `${output}\n\n${result.diagnostics}` : output;
 }
 
 export function createDeleteSymbolTool(
@@ -227,10 +283,11 @@ export function createDeleteSymbolTool(
   return {
     name: "lsp_delete_symbol",
     description:
-      "Delete a symbol and optionally all its references using LSP. Requires exact line:column position of the symbol.",
+      "Delete a symbol and optionally all its references using LSP. Requires exact line:column position of the symbol. " +
+        "Use preview: true to get the diff with an edit token for confirm_edit first.",
     schema,
-    execute: async (args) => {
-      const result = await handleDeleteSymbol(args, client);
+    execute: async (args, context) => {
+      const result = await handleDeleteSymbol(args, client, context);
       return formatDeleteSymbolResult(result);
     },
   };
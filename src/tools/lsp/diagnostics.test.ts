@@ -0,0 +1,106 @@
+import { describe, it, expect } from "vitest";
+import type { Diagnostic } from "@internal/types";
+import { filterDiagnostics, groupDiagnostics } from "./diagnostics.ts";
+
+function diagnostic(
+  severity: Diagnostic["severity"],
+  source?: string,
+  code?: string | number,
+): Diagnostic {
+  return {
+    range: {
+      start: { line: 0, character: 0 },
+      end: { line: 0, character: 1 },
+    },
+    severity,
+    source,
+    code,
+    message: `${source} ${code}`,
+  };
+}
+
+describe("filterDiagnostics", () => {
+  const diagnostics = [
+    diagnostic(1, "ts", 2322),
+    diagnostic(2, "ts", 6133),
+    diagnostic(3, "eslint", "no-console"),
+    diagnostic(4, "ts", 80001),
+    diagnostic(undefined, "go vet", "printf"),
+  ];
+
+  it("should keep diagnostics at least as severe as minSeverity", () => {
+    expect(
+      filterDiagnostics(diagnostics, { minSeverity: "warning" }).map(
+        (d) => d.code,
+      ),
+    ).toEqual([2322, 6133, "printf"]);
+  });
+
+  it("should match codes as strings and sources exactly", () => {
+    expect(
+      filterDiagnostics(diagnostics, { codes: ["2322", "no-console"] }).map(
+        (d) => d.code,
+      ),
+    ).toEqual([2322, "no-console"]);
+    expect(
+      filterDiagnostics(diagnostics, { sources: ["go vet", "eslint"] }).map(
+        (d) => d.code,
+      ),
+    ).toEqual(["no-console", "printf"]);
+  });
+
+  it("should keep everything without filters", () => {
+    expect(filterDiagnostics(diagnostics, { codes: [] })).toHaveLength(5);
+  });
+});
+
+describe("groupDiagnostics", () => {
+  it("should count by severity, source and code", () => {
+    const at = (
+      line: number,
+      severity: "error" | "warning",
+      code: string,
+    ) => ({
+      severity,
+      line,
+      column: 1,
+      message: `problem ${code} at ${line}`,
+      source: "ts",
+      code,
+    });
+
+    expect(
+      groupDiagnostics([
+        at(1, "warning", "6133"),
+        at(2, "error", "2322"),
+        at(3, "warning", "7006"),
+        at(4, "warning", "7006"),
+      ]),
+    ).toEqual([
+      {
+        severity: "error",
+        source: "ts",
+        code: "2322",
+        count: 1,
+        line: 2,
+        message: "problem 2322 at 2",
+      },
+      {
+        severity: "warning",
+        source: "ts",
+        code: "7006",
+        count: 2,
+        line: 3,
+        message: "problem 7006 at 3",
+      },
+      {
+        severity: "warning",
+        source: "ts",
+        code: "6133",
+        count: 1,
+        line: 1,
+        message: "problem 6133 at 1",
+      },
+    ]);
+  });
+});
@@ -5,10 +5,25 @@ import {
   getLanguageIdFromPath,
   log,
   LogLevel,
+  supportsPullDiagnostics,
   waitForDiagnosticsWithRetry,
 } from "@internal/lsp-client";
 import { createLSPTool } from "./toolFactory.ts";
-import { DiagnosticResultBuilder } from "@internal/types";
+import {
+  DiagnosticResultBuilder,
+  type Diagnostic,
+  type McpContext,
+} from "@internal/types";
+import {
+  goAnalyzerCache,
+  goAnalyzerPolicy,
+  mergeDiagnostics,
+} from "../../utils/goAnalyzers.ts";
+import {
+  baselineNote,
+  filterBaselined,
+  loadBaseline,
+} from "../../utils/diagnosticsBaseline.ts";
 
 const schema = z.object({
   root: z.string().describe("Root directory for resolving relative paths"),
@@ -23,35 +38,189 @@ const schema = z.object({
     .boolean()
     .optional()
     .describe("Force document refresh (default: true)"),
+  includeBaseline: z
+    .boolean()
+    .optional()
+    .describe(
+      "Also return diagnostics recorded in .lsmcp/diagnostics-baseline.json (default: false)",
+    ),
+  minSeverity: z
+    .enum(["error", "warning", "info", "hint"])
+    .optional()
+    .describe(
+      'Only return diagnostics at least this severe, e.g. "warning" drops info and hints (default: hint)',
+    ),
+  codes: z
+    .array(z.string())
+    .optional()
+    .describe(
+      'Only return diagnostics with one of these codes (e.g. ["2322", "SA4006"])',
+    ),
+  sources: z
+    .array(z.string())
+    .optional()
+    .describe(
+      'Only return diagnostics from one of these sources (e.g. ["ts", "go vet"])',
+    ),
+  summary: z
+    .boolean()
+    .optional()
+    .describe(
+      "Return the number of diagnostics per severity, source and code instead of each diagnostic (default: false)",
+    ),
 });
 
 type GetDiagnosticsRequest = z.infer<typeof schema>;
 
+type Severity = "error" | "warning" | "info" | "hint";
+
 interface GetDiagnosticsSuccess {
   message: string;
   diagnostics: Array<{
-    severity: "error" | "warning" | "info" | "hint";
+    severity: Severity;
     line: number;
     column: number;
     endLine?: number;
     endColumn?: number;
     message: string;
     source?: string;
+    code?: string | number;
   }>;
+  /** Set in summary mode */
+  groups?: DiagnosticGroup[];
   debug: {
     method: "push" | "pull" | "polling";
     attempts: number;
     totalTime: number;
     documentWasOpen: boolean;
+    /** Analyzers run next to the server (go vet, staticcheck) */
+    analyzers?: string[];
+    analyzerErrors?: string[];
+    /** Diagnostics left out because the baseline records them */
+    baselined?: number;
+    /** Diagnostics left out by minSeverity, codes and sources */
+    filtered?: number;
   };
 }
 
+const outputSchema = {
+  diagnostics: z.array(
+    z.object({
+      severity: z.enum(["error", "warning", "info", "hint"]),
+      line: z.number().describe("1-based"),
+      column: z.number().describe("1-based"),
+      endLine: z.number().optional(),
+      endColumn: z.number().optional(),
+      message: z.string(),
+      source: z.string().optional(),
+      code: z.union([z.string(), z.number()]).optional(),
+    }),
+  ),
+  groups: z
+    .array(
+      z.object({
+        severity: z.enum(["error", "warning", "info", "hint"]),
+        source: z.string().optional(),
+        code: z.union([z.string(), z.number()]).optional(),
+        count: z.number(),
+        line: z.number().describe("Line of the first diagnostic"),
+        message: z.string().describe("Message of the first diagnostic"),
+      }),
+    )
+    .optional()
+    .describe("Counts in summary mode, where diagnostics is empty"),
+  method: z
+    .enum(["push", "pull", "polling"])
+    .describe("How the diagnostics were obtained"),
+  baselined: z
+    .number()
+    .optional()
+    .describe("Diagnostics left out because the baseline records them"),
+};
+
+/** LSP severities by name; servers that send none mean an error */
+const SEVERITY_RANK: Record<Severity, number> = {
+  error: 1,
+  warning: 2,
+  info: 3,
+  hint: 4,
+};
+
+/**
+ * Apply the minSeverity, codes and sources filters
+ */
+export function filterDiagnostics(
+  diagnostics: Diagnostic[],
+  filters: Pick<GetDiagnosticsRequest, "minSeverity" | "codes" | "sources">,
+): Diagnostic[] {
+  const maxRank = SEVERITY_RANK[filters.minSeverity ?? "hint"];
+  const codes = filters.codes?.length ? new Set(filters.codes) : undefined;
+  const sources = filters.sources?.length
+    ? new Set(filters.sources)
+    : undefined;
+  return diagnostics.filter(
+    (d) =>
+      (d.severity || 1) <= maxRank &&
+      (!codes || (d.code !== undefined && codes.has(String(d.code)))) &&
+      (!sources || (d.source !== undefined && sources.has(d.source))),
+  );
+}
+
+export interface DiagnosticGroup {
+  severity: Severity;
+  source?: string;
+  code?: string | number;
+  count: number;
+  /** First diagnostic of the group */
+  line: number;
+  message: string;
+}
+
+/**
+ * Count diagnostics by severity, source and code, most severe and most
+ * frequent first
+ */
+export function groupDiagnostics(
+  diagnostics: GetDiagnosticsSuccess["diagnostics"],
+): DiagnosticGroup[] {
+  const groups = new Map<string, DiagnosticGroup>();
+  for (const d of diagnostics) {
+    const key = [d.severity, d.source ?? "", d.code ?? ""].join("\0");
+    const group = groups.get(key);
+    if (group) {
+      group.count++;
+      continue;
+    }
+    groups.set(key, {
+      severity: d.severity,
+      source: d.source,
+      code: d.code,
+      count: 1,
+      line: d.line,
+      message: d.message,
+    });
+  }
+  return [...groups.values()].sort(
+    (a, b) =>
+      SEVERITY_RANK[a.severity] - SEVERITY_RANK[b.severity] ||
+      b.count - a.count,
+  );
+}
+
+/** "ts 2322", "go vet printf" or just the source */
+function ruleLabel(d: { source?: string; code?: string | number }): string {
+  return [d.source, d.code]
+    .filter((part) => part !== undefined && part !== "")
+    .join(" ");
+}
+
 /**
  * Enhanced diagnostics with better error handling and debugging
  */
 export async function getDiagnosticsWithLSPV2(
   request: GetDiagnosticsRequest,
   lspClient?: LSPClient,
+  context?: McpContext,
 ): Promise<Result<GetDiagnosticsSuccess, string>> {
   const startTime = Date.now();
   const timeout = request.timeout || 5000;
@@ -78,7 +247,7 @@ export async function getDiagnosticsWithLSPV2(
     const documentWasOpen = client.isDocumentOpen(fileUri);
 
     // Use unified diagnostic wait logic
-    const diagnostics = await waitForDiagnosticsWithRetry(
+    let diagnostics = await waitForDiagnosticsWithRetry(
       client,
       fileUri,
       fileContent,
@@ -90,21 +259,47 @@ export async function getDiagnosticsWithLSPV2(
     );
 
     // Determine which method was used (for debug info)
-    const diagnosticSupport = client.getDiagnosticSupport();
-    if (diagnostics.length > 0) {
-      if (diagnosticSupport.pushDiagnostics) {
-        method = "push";
-      } else if (
-        diagnosticSupport.pullDiagnostics &&
-        typeof client.pullDiagnostics === "function"
-      ) {
-        method = "pull";
-      } else {
-        method = "polling";
-      }
+    // Pull-capable servers are queried directly, without waiting for push
+    if (supportsPullDiagnostics(client)) {
+      method = "pull";
     }
     attempts = Math.max(3, Math.floor((Date.now() - startTime) / 100));
 
+    // Merge go vet / staticcheck findings in Go workspaces
+    const analyzerPolicy = goAnalyzerPolicy(context);
+    let analyzers: GetDiagnosticsSuccess["debug"]["analyzers"];
+    let analyzerErrors: GetDiagnosticsSuccess["debug"]["analyzerErrors"];
+    if (analyzerPolicy && absolutePath.endsWith(".go")) {
+      const analyzed = await goAnalyzerCache.forFile(
+        request.root,
+        absolutePath,
+        analyzerPolicy,
+        context?.signal,
+      );
+      diagnostics = mergeDiagnostics(diagnostics, analyzed.diagnostics);
+      analyzers = analyzed.analyzers;
+      analyzerErrors = analyzed.errors;
+    }
+
+    // Leave out what the workspace baseline records
+    let baselined: number | undefined;
+    const baseline = request.includeBaseline
+      ? undefined
+      : await loadBaseline(request.root, context);
+    if (baseline) {
+      const filtered = filterBaselined(
+        baseline,
+        path.relative(request.root, absolutePath),
+        diagnostics,
+      );
+      diagnostics = filtered.diagnostics;
+      baselined = filtered.suppressed;
+    }
+
+    const unfiltered = diagnostics.length;
+    diagnostics = filterDiagnostics(diagnostics, request);
+    const filtered = unfiltered - diagnostics.length;
+
     // Build result
     const builder = new DiagnosticResultBuilder(
       request.root,
@@ -126,11 +321,19 @@ export async function getDiagnosticsWithLSPV2(
     const result = builder.build();
     return ok({
       ...result,
+      ...(request.summary && {
+        diagnostics: [],
+        groups: groupDiagnostics(result.diagnostics),
+      }),
       debug: {
         method,
         attempts,
         totalTime,
         documentWasOpen,
+        analyzers,
+        analyzerErrors,
+        baselined,
+        filtered: filtered || undefined,
       },
     });
   } catch (error) {
@@ -158,21 +361,54 @@ export function createDiagnosticsTool(client: LSPClient) {
   return createLSPTool({
     name: "lsp_get_diagnostics",
     description:
-      "Get diagnostics (errors, warnings) for a specific file using LSP. Provides detailed error and warning information.",
+      "Get diagnostics (errors, warnings) for a specific file using LSP. Provides detailed error and warning information. " +
+      "Diagnostics recorded in the workspace baseline (.lsmcp/diagnostics-baseline.json) are left out unless includeBaseline is set.",
     schema,
     language: "lsp",
-    handler: (request) => getDiagnosticsWithLSPV2(request, client),
+    handler: (request, context) =>
+      getDiagnosticsWithLSPV2(request, client, context),
+    outputSchema,
+    formatStructured: (result) => ({
+      diagnostics: result.diagnostics,
+      groups: result.groups,
+      method: result.debug.method,
+      baselined: result.debug.baselined,
+    }),
     formatSuccess: (result) => {
       const messages = [
         result.message,
         `\nDebug Info: ${result.debug.method} method, ${result.debug.attempts} attempts, ${result.debug.totalTime}ms`,
       ];
+      if (result.debug.analyzers?.length) {
+        messages.push(
+          `Also checked with: ${result.debug.analyzers.join(", ")}`,
+        );
+      }
+      for (const error of result.debug.analyzerErrors ?? []) {
+        messages.push(`Analyzer failed: ${error}`);
+      }
+      if (result.debug.baselined) {
+        messages.push(baselineNote(result.debug.baselined));
+      }
+      if (result.debug.filtered) {
+        messages.push(
+          `Hidden ${result.debug.filtered} diagnostic(s) by minSeverity, codes or sources`,
+        );
+      }
 
-      if (result.diagnostics.length > 0) {
+      if (result.groups?.length) {
+        messages.push("\nBy severity, source and code:");
+        for (const group of result.groups) {
+          messages.push(
+            `  ${group.severity.toUpperCase()} ${ruleLabel(group) || "(no code)"}: ${group.count} (first at line ${group.line}: ${group.message})`,
+          );
+        }
+      } else if (result.diagnostics.length > 0) {
         messages.push(`\nFound ${result.diagnostics.length} diagnostic(s):`);
 
         for (const diag of result.diagnostics) {
-          const sourceInfo = diag.source ? ` (${diag.source})` : "";
+          const rule = ruleLabel(diag);
+          const sourceInfo = rule ? ` (${rule})` : "";
           messages.push(
             `\n${diag.severity.toUpperCase()}: ${diag.message}${sourceInfo}\n` +
               `  at line ${diag.line}:${diag.column}`,
@@ -0,0 +1,176 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import { mkdtemp, rm, writeFile } from "fs/promises";
+import { tmpdir } from "os";
+import { join } from "path";
+import type { Diagnostic } from "@internal/types";
+import {
+  compareDiagnostics,
+  formatDiagnosticsDelta,
+  trackDiagnostics,
+} from "./diagnosticsDelta.ts";
+
+vi.mock("@internal/lsp-client", async (importOriginal) => ({
+  ...(await importOriginal<typeof import("@internal/lsp-client")>()),
+  // One error per line containing "bad"
+  waitForDiagnosticsWithRetry: vi.fn(
+    async (_client: unknown, _uri: string, content: string) =>
+      content.split("\n").flatMap((text, line) =>
+        text.includes("bad")
+          ? [
+              {
+                message: `'${text.trim()}' is bad`,
+                severity: 1,
+                source: "ts",
+                range: {
+                  start: { line, character: 0 },
+                  end: { line, character: 1 },
+                },
+              },
+            ]
+          : [],
+      ),
+  ),
+}));
+
+function diagnostic(message: string, line: number, severity = 1): Diagnostic {
+  return {
+    message,
+    severity: severity as Diagnostic["severity"],
+    source: "ts",
+    range: {
+      start: { line, character: 0 },
+      end: { line, character: 1 },
+    },
+  };
+}
+
+describe("compareDiagnostics", () => {
+  it("should match diagnostics regardless of their position", () => {
+    const before = new Map([
+      ["/p/a.ts", [diagnostic("unused x", 3, 2), diagnostic("missing y", 5)]],
+    ]);
+    const after = new Map([
+      ["/p/a.ts", [diagnostic("unused x", 8, 2), diagnostic("missing z", 9)]],
+    ]);
+    const delta = compareDiagnostics(before, after);
+
+    expect(delta.checkedFiles).toBe(1);
+    expect(delta.introduced.map((d) => d.diagnostic.message)).toEqual([
+      "missing z",
+    ]);
+    expect(delta.fixed.map((d) => d.diagnostic.message)).toEqual([
+      "missing y",
+    ]);
+  });
+
+  it("should count repeated diagnostics", () => {
+    const before = new Map([["/p/a.ts", [diagnostic("missing y", 1)]]]);
+    const after = new Map([
+      ["/p/a.ts", [diagnostic("missing y", 1), diagnostic("missing y", 4)]],
+    ]);
+    const delta = compareDiagnostics(before, after);
+
+    expect(delta.introduced).toHaveLength(1);
+    expect(delta.introduced[0].diagnostic.range.start.line).toBe(4);
+    expect(delta.fixed).toHaveLength(0);
+  });
+
+  it("should leave out files only checked once", () => {
+    const after = new Map([["/p/a.ts", [diagnostic("missing y", 1)]]]);
+    const delta = compareDiagnostics(new Map(), after);
+
+    expect(delta.checkedFiles).toBe(0);
+    expect(delta.introduced).toHaveLength(0);
+  });
+});
+
+describe("formatDiagnosticsDelta", () => {
+  it("should list introduced and fixed diagnostics", () => {
+    const text = formatDiagnosticsDelta(
+      "/p",
+      {
+        introduced: [
+          { filePath: "/p/src/a.ts", diagnostic: diagnostic("missing z", 9) },
+        ],
+        fixed: [
+          {
+            filePath: "/p/src/a.ts",
+            diagnostic: diagnostic("unused x", 3, 2),
+          },
+        ],
+        checkedFiles: 2,
+      },
+      1,
+    );
+
+    expect(text).toBe(
+      [
+        "Diagnostics after the edit: 1 new error(s), 0 new warning(s), 1 fixed (2 file(s) checked, 1 not checked)",
+        "Introduced:",
+        "  src/a.ts:10:1 ERROR missing z (ts)",
+        "Fixed:",
+        "  src/a.ts:4:1 WARNING unused x (ts)",
+      ].join("\n"),
+    );
+  });
+});
+
+describe("trackDiagnostics", () => {
+  let root: string;
+  const client = {
+    isDocumentOpen: vi.fn(() => false),
+    closeDocument: vi.fn(),
+  };
+
+  beforeEach(async () => {
+    root = await mkdtemp(join(tmpdir(), "lsmcp-delta-"));
+  });
+
+  afterEach(async () => {
+    await rm(root, { recursive: true, force: true });
+  });
+
+  it("should report diagnostics changed by the write", async () => {
+    const filePath = join(root, "a.ts");
+    await writeFile(filePath, "const a = bad;\nconst b = 1;\n");
+
+    const delta = await trackDiagnostics(client as any, [{ filePath }]);
+    await writeFile(filePath, "const a = 1;\nconst b = bad;\n");
+
+    const text = await delta(root);
+    expect(text).toContain("1 new error(s), 0 new warning(s), 1 fixed");
+    expect(text).toContain("a.ts:2:1 ERROR 'const b = bad;' is bad");
+    expect(text).toContain("a.ts:1:1 ERROR 'const a = bad;' is bad");
+  });
+
+  it("should follow files moved by the edit", async () => {
+    const oldFilePath = join(root, "old.ts");
+    const filePath = join(root, "new.ts");
+    await writeFile(oldFilePath, "bad\n");
+
+    const delta = await trackDiagnostics(client as any, [
+      { filePath, oldFilePath },
+    ]);
+    await rm(oldFilePath);
+    await writeFile(filePath, "fine\n");
+
+    expect(await delta(root)).toContain(
+      "0 new error(s), 0 new warning(s), 1 fixed",
+    );
+  });
+
+  it("should do nothing without a client or when disabled", async () => {
+    const filePath = join(root, "a.ts");
+    await writeFile(filePath, "bad\n");
+
+    const withoutClient = await trackDiagnostics(undefined, [{ filePath }]);
+    const disabled = await trackDiagnostics(client as any, [{ filePath }], {
+      lspClient: client,
+      config: { diagnosticsDelta: false },
+    } as any);
+    await writeFile(filePath, "fine\n");
+
+    expect(await withoutClient(root)).toBe("");
+    expect(await disabled(root)).toBe("");
+  });
+});
@@ -0,0 +1,213 @@
+/**
+ * Diagnostics delta for editing tools
+ *
+ * Diagnostics of the files an edit touches are taken right before and right
+ * after the write. They are matched by severity, message, source and code
+ * rather than position, since edits move lines; what is left over after the
+ * edit is a new problem, what is left over before it was fixed. Only errors
+ * and warnings are compared. Disabled with `"diagnosticsDelta": false`.
+ */
+
+import { existsSync } from "fs";
+import { readFile } from "fs/promises";
+import { relative } from "path";
+import { pathToFileURL } from "url";
+import type { LSPClient } from "@internal/lsp-client";
+import {
+  debug,
+  getLanguageIdFromPath,
+  waitForDiagnosticsWithRetry,
+} from "@internal/lsp-client";
+import type { Diagnostic, McpContext } from "@internal/types";
+import type { WorkspaceEditPlan } from "../editor/workspaceEditTools.ts";
+
+/** Files beyond this count are not checked */
+export const MAX_DELTA_FILES = 20;
+
+/** How long to wait for the diagnostics of one file */
+const DELTA_TIMEOUT_MS = 3000;
+
+/** Polls, 50ms apart, when the server publishes nothing (clean files) */
+const DELTA_MAX_POLLS = 10;
+
+export interface DeltaFile {
+  /** Absolute path after the edit */
+  filePath: string;
+  /** Absolute path before the edit, when the edit moves the file */
+  oldFilePath?: string;
+}
+
+export interface DiagnosticsDelta {
+  introduced: { filePath: string; diagnostic: Diagnostic }[];
+  fixed: { filePath: string; diagnostic: Diagnostic }[];
+  checkedFiles: number;
+}
+
+type Snapshot = Map<string, Diagnostic[]>;
+
+function isProblem(diagnostic: Diagnostic): boolean {
+  return (diagnostic.severity ?? 2) <= 2;
+}
+
+function diagnosticKey(diagnostic: Diagnostic): string {
+  return [
+    diagnostic.severity ?? 2,
+    diagnostic.source ?? "",
+    diagnostic.code ?? "",
+    diagnostic.message,
+  ].join("\0");
+}
+
+/**
+ * Diagnostics per file, keyed by the path after the edit
+ */
+async function snapshot(
+  client: LSPClient,
+  files: DeltaFile[],
+  when: "before" | "after",
+): Promise<Snapshot> {
+  const result: Snapshot = new Map();
+  await Promise.all(
+    files.map(async (file) => {
+      const filePath =
+        when === "before" ? (file.oldFilePath ?? file.filePath) : file.filePath;
+      if (!existsSync(filePath)) {
+        result.set(file.filePath, []);
+        return;
+      }
+      const fileUri = pathToFileURL(filePath).toString();
+      const wasOpen = client.isDocumentOpen(fileUri);
+      try {
+        const content = await readFile(filePath, "utf-8");
+        const diagnostics = await waitForDiagnosticsWithRetry(
+          client,
+          fileUri,
+          content,
+          getLanguageIdFromPath(filePath) || undefined,
+          { timeout: DELTA_TIMEOUT_MS, maxPolls: DELTA_MAX_POLLS },
+        );
+        result.set(file.filePath, diagnostics.filter(isProblem));
+      } catch (error) {
+        debug(`[diagnosticsDelta] ${when} ${filePath}: ${error}`);
+      } finally {
+        if (!wasOpen && client.isDocumentOpen(fileUri)) {
+          client.closeDocument(fileUri);
+        }
+      }
+    }),
+  );
+  return result;
+}
+
+/**
+ * Diagnostics only present after the edit, and only before it
+ */
+export function compareDiagnostics(
+  before: Snapshot,
+  after: Snapshot,
+): DiagnosticsDelta {
+  const delta: DiagnosticsDelta = {
+    introduced: [],
+    fixed: [],
+    checkedFiles: 0,
+  };
+  for (const [filePath, current] of after) {
+    // Files whose diagnostics could not be taken both times are left out
+    const previous = before.get(filePath);
+    if (!previous) {
+      continue;
+    }
+    delta.checkedFiles++;
+
+    const unmatched = new Map<string, Diagnostic[]>();
+    for (const diagnostic of previous) {
+      const key = diagnosticKey(diagnostic);
+      unmatched.set(key, [...(unmatched.get(key) ?? []), diagnostic]);
+    }
+    for (const diagnostic of current) {
+      const same = unmatched.get(diagnosticKey(diagnostic));
+      if (same && same.length > 0) {
+        same.shift();
+      } else {
+        delta.introduced.push({ filePath, diagnostic });
+      }
+    }
+    for (const left of unmatched.values()) {
+      delta.fixed.push(...left.map((diagnostic) => ({ filePath, diagnostic })));
+    }
+  }
+  return delta;
+}
+
+function formatEntry(
+  root: string,
+  { filePath, diagnostic }: DiagnosticsDelta["introduced"][number],
+): string {
+  const { line, character } = diagnostic.range.start;
+  const severity = diagnostic.severity === 1 ? "ERROR" : "WARNING";
+  const source = diagnostic.source ? ` (${diagnostic.source})` : "";
+  return `  ${relative(root, filePath)}:${line + 1}:${character + 1} ${severity} ${diagnostic.message}${source}`;
+}
+
+export function formatDiagnosticsDelta(
+  root: string,
+  delta: DiagnosticsDelta,
+  skippedFiles = 0,
+): string {
+  const errors = delta.introduced.filter(
+    (entry) => entry.diagnostic.severity === 1,
+  ).length;
+  const warnings = delta.introduced.length - errors;
+  const skipped = skippedFiles > 0 ? `, ${skippedFiles} not checked` : "";
+  const lines = [
+    `Diagnostics after the edit: ${errors} new error(s), ${warnings} new warning(s), ${delta.fixed.length} fixed (${delta.checkedFiles} file(s) checked${skipped})`,
+  ];
+  if (delta.introduced.length > 0) {
+    lines.push(
+      "Introduced:",
+      ...delta.introduced.map((entry) => formatEntry(root, entry)),
+    );
+  }
+  if (delta.fixed.length > 0) {
+    lines.push(
+      "Fixed:",
+      ...delta.fixed.map((entry) => formatEntry(root, entry)),
+    );
+  }
+  return lines.join("\n");
+}
+
+export function deltaFilesOfPlan(plan: WorkspaceEditPlan): DeltaFile[] {
+  return plan.changes.map(({ filePath, oldFilePath }) => ({
+    filePath,
+    oldFilePath,
+  }));
+}
+
+/**
+ * Take diagnostics of files about to be written. The returned function takes
+ * them again once the edit is on disk and describes the difference; it returns
+ * an empty string when there is no language server or the check is disabled.
+ */
+export async function trackDiagnostics(
+  client: LSPClient | undefined,
+  files: DeltaFile[],
+  context?: McpContext,
+): Promise<(root: string) => Promise<string>> {
+  if (
+    !client ||
+    files.length === 0 ||
+    context?.config?.diagnosticsDelta === false
+  ) {
+    return async () => "";
+  }
+  const checked = files.slice(0, MAX_DELTA_FILES);
+  const before = await snapshot(client, checked, "before");
+  return async (root) => {
+    const after = await snapshot(client, checked, "after");
+    const delta = compareDiagnostics(before, after);
+    return delta.checkedFiles > 0
+      ? formatDiagnosticsDelta(root, delta, files.length - checked.length)
+      : "";
+  };
+}
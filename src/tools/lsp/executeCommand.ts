@@ -0,0 +1,265 @@
+import type { LSPClient } from "@internal/lsp-client";
+import type { McpContext, McpToolDef } from "@internal/types";
+import { z } from "zod";
+import path from "path";
+import { pathToFileURL } from "url";
+import { executeCommandWithEdits } from "./codeActions.ts";
+import { confirmOperation } from "../../utils/confirmation.ts";
+
+const MAX_RESULT_LENGTH = 4000;
+
+const schema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  command: z
+    .string()
+    .optional()
+    .describe(
+      "Command to run (e.g. gopls.tidy). Omit to list the commands the server supports",
+    ),
+  arguments: z
+    .union([z.array(z.unknown()), z.record(z.unknown())])
+    .optional()
+    .describe(
+      "Command arguments. An object is passed as the single argument (gopls style); relative paths in URI fields are converted to file URIs",
+    ),
+});
+
+type ExecuteCommandRequest = z.infer<typeof schema>;
+
+interface KnownCommand {
+  description: string;
+  /** Fields of the first argument object that must be present */
+  required?: string[];
+  /** Fields holding a document URI (relative paths are converted) */
+  uriFields?: string[];
+  /** Fields holding a list of document URIs */
+  uriListFields?: string[];
+}
+
+/**
+ * Argument shapes of common server commands, used for validation and help
+ * @see https://github.com/golang/tools/blob/master/gopls/doc/commands.md
+ */
+const KNOWN_COMMANDS: Record<string, KnownCommand> = {
+  "gopls.tidy": {
+    description: "Run go mod tidy",
+    required: ["URIs"],
+    uriListFields: ["URIs"],
+  },
+  "gopls.update_go_sum": {
+    description: "Update go.sum",
+    required: ["URIs"],
+    uriListFields: ["URIs"],
+  },
+  "gopls.vendor": {
+    description: "Run go mod vendor",
+    required: ["URI"],
+    uriFields: ["URI"],
+  },
+  "gopls.add_dependency": {
+    description: "Add a dependency (GoCmdArgs: module@version)",
+    required: ["URI", "GoCmdArgs"],
+    uriFields: ["URI"],
+  },
+  "gopls.upgrade_dependency": {
+    description: "Upgrade a dependency (GoCmdArgs: module@version)",
+    required: ["URI", "GoCmdArgs"],
+    uriFields: ["URI"],
+  },
+  "gopls.remove_dependency": {
+    description: "Remove a dependency",
+    required: ["URI", "ModulePath"],
+    uriFields: ["URI"],
+  },
+  "gopls.check_upgrades": {
+    description: "Check for module upgrades",
+    required: ["URI", "Modules"],
+    uriFields: ["URI"],
+  },
+  "gopls.go_get_package": {
+    description: "Run go get for a package",
+    required: ["URI", "Pkg"],
+    uriFields: ["URI"],
+  },
+  "gopls.generate": {
+    description: "Run go generate",
+    required: ["Dir"],
+    uriFields: ["Dir"],
+  },
+  "gopls.run_tests": {
+    description: "Run tests in a file",
+    required: ["URI"],
+    uriFields: ["URI"],
+  },
+  "gopls.run_govulncheck": {
+    description: "Run govulncheck",
+    required: ["URI"],
+    uriFields: ["URI"],
+  },
+  "gopls.list_known_packages": {
+    description: "List importable packages",
+    required: ["URI"],
+    uriFields: ["URI"],
+  },
+};
+
+/**
+ * Commands that editors implement themselves; servers never advertise them
+ */
+const CLIENT_SIDE_COMMANDS: Record<string, string> = {
+  "rust-analyzer.runSingle":
+    "is run by the editor extension, not rust-analyzer; run cargo test directly",
+  "rust-analyzer.debugSingle": "is run by the editor extension",
+  "rust-analyzer.showReferences": "is run by the editor extension",
+  "editor.action.triggerParameterHints": "is an editor UI command",
+};
+
+function toUri(root: string, value: unknown): unknown {
+  if (typeof value !== "string" || /^[a-z][\w+.-]*:\/\//i.test(value)) {
+    return value;
+  }
+  return pathToFileURL(path.resolve(root, value)).toString();
+}
+
+/**
+ * Normalize arguments to an array and fill in file URIs for known commands
+ */
+function prepareCommandArguments(
+  root: string,
+  command: string,
+  args: ExecuteCommandRequest["arguments"],
+): unknown[] {
+  const list = args === undefined ? [] : Array.isArray(args) ? args : [args];
+  const known = KNOWN_COMMANDS[command];
+  if (!known) {
+    return list;
+  }
+
+  const first = list[0];
+  if (typeof first !== "object" || first === null || Array.isArray(first)) {
+    throw new Error(
+      `${command} takes one object argument with fields: ${(
+        known.required ?? []
+      ).join(", ")}`,
+    );
+  }
+  const missing = (known.required ?? []).filter((field) => !(field in first));
+  if (missing.length > 0) {
+    throw new Error(
+      `${command} is missing required argument field(s): ${missing.join(", ")}`,
+    );
+  }
+
+  const normalized: Record<string, unknown> = { ...first };
+  for (const field of known.uriFields ?? []) {
+    if (field in normalized) {
+      normalized[field] = toUri(root, normalized[field]);
+    }
+  }
+  for (const field of known.uriListFields ?? []) {
+    const value = normalized[field];
+    if (Array.isArray(value)) {
+      normalized[field] = value.map((item) => toUri(root, item));
+    } else if (value !== undefined) {
+      normalized[field] = [toUri(root, value)];
+    }
+  }
+  return [normalized, ...list.slice(1)];
+}
+
+function formatCommandList(commands: string[]): string {
+  if (commands.length === 0) {
+    return "The language server does not advertise any commands.";
+  }
+  const lines = [`Server commands (${commands.length}):`];
+  for (const command of [...commands].sort()) {
+    const known = KNOWN_COMMANDS[command];
+    lines.push(
+      known
+        ? `  ${command} - ${known.description} (fields: ${(
+            known.required ?? []
+          ).join(", ")})`
+        : `  ${command}`,
+    );
+  }
+  return lines.join("\n");
+}
+
+function formatResult(result: unknown): string {
+  const text =
+    typeof result === "string" ? result : JSON.stringify(result, null, 2);
+  return text.length > MAX_RESULT_LENGTH
+    ? `${text.slice(0, MAX_RESULT_LENGTH)}\n... (truncated)`
+    : text;
+}
+
+async function handleExecuteCommand(
+  request: ExecuteCommandRequest,
+  client: LSPClient,
+  context?: McpContext,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const provider = client.getServerCapabilities()?.executeCommandProvider;
+  if (!provider || !client.executeCommand) {
+    return "workspace/executeCommand is not supported by this language server.";
+  }
+  const commands = provider.commands ?? [];
+
+  if (!request.command) {
+    return formatCommandList(commands);
+  }
+
+  if (!commands.includes(request.command)) {
+    const hint = CLIENT_SIDE_COMMANDS[request.command];
+    throw new Error(
+      hint
+        ? `${request.command} ${hint}.`
+        : `Unknown command: ${request.command}\n\n${formatCommandList(commands)}`,
+    );
+  }
+
+  const args = prepareCommandArguments(
+    request.root,
+    request.command,
+    request.arguments,
+  );
+  // The server may edit any file, so there is nothing to list up front
+  await confirmOperation(context, {
+    kind: "command",
+    title: `running ${request.command}`,
+    files: [],
+  });
+  const { result, touched } = await executeCommandWithEdits(
+    client,
+    request.root,
+    { title: request.command, command: request.command, arguments: args },
+  );
+
+  const lines = [`Executed ${request.command}`];
+  if (result !== null && result !== undefined) {
+    lines.push("", "Result:", formatResult(result));
+  }
+  if (touched.length > 0) {
+    lines.push("", `Server edited ${touched.length} file(s):`);
+    lines.push(...touched.map((file) => `  ${file}`));
+  }
+  return lines.join("\n");
+}
+
+export function createExecuteCommandTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "lsp_execute_command",
+    description:
+      "Run a server-specific command via workspace/executeCommand (e.g. gopls.tidy, gopls.upgrade_dependency). " +
+      "Call without command to list the commands the server advertises. Edits the server applies are reported.",
+    schema,
+    execute: async (args, context) => {
+      return handleExecuteCommand(args, client, context);
+    },
+  };
+}
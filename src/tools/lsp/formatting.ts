@@ -3,9 +3,19 @@ import { z } from "zod";
 import path from "path";
 import fs from "fs/promises";
 import { pathToFileURL } from "url";
-import { FormattingOptions, TextEdit } from "@internal/types";
-import type { McpToolDef } from "@internal/types";
+import { markFileModified } from "@internal/code-indexer";
+import type {
+  FormattingOptions,
+  McpContext,
+  McpToolDef,
+  Range,
+  TextEdit,
+} from "@internal/types";
 import { applyTextEdits } from "../../utils/applyTextEdits.ts";
+import { createUnifiedDiff } from "../../utils/unifiedDiff.ts";
+import { planWorkspaceEdit } from "../editor/workspaceEditTools.ts";
+import { previewWorkspaceEdit } from "../editor/pendingEdits.ts";
+import { trackDiagnostics } from "./diagnosticsDelta.ts";
 
 const schemaShape = {
   root: z.string().describe("Root directory for resolving relative paths"),
@@ -25,78 +35,51 @@ const schemaShape = {
     .describe("Insert final newline"),
   trimFinalNewlines: z.boolean().default(true).describe("Trim final newlines"),
   applyChanges: z
+    .boolean()
+    .default(true)
+    .describe(
+      "Write the formatted content to the file. Set to false to only preview the diff",
+    ),
+  preview: z
     .boolean()
     .default(false)
-    .describe("Apply formatting changes to the file"),
+    .describe(
+      "Return the diff and an edit token without writing; apply it later with confirm_edit",
+    ),
 };
 
 const schema = z.object(schemaShape);
 
-function formatTextEdit(edit: TextEdit, content: string): string {
-  const lines = content.split("\n");
-  const startLine = edit.range.start.line;
-  const endLine = edit.range.end.line;
-
-  // Extract the text being replaced
-  let originalText = "";
-  if (startLine === endLine) {
-    const line = lines[startLine] || "";
-    originalText = line.substring(
-      edit.range.start.character,
-      edit.range.end.character,
-    );
-  } else {
-    // Multi-line range
-    for (let i = startLine; i <= endLine && i < lines.length; i++) {
-      if (i === startLine) {
-        originalText += (lines[i] || "").substring(edit.range.start.character);
-      } else if (i === endLine) {
-        originalText +=
-          "\n" + (lines[i] || "").substring(0, edit.range.end.character);
-      } else {
-        originalText += "\n" + (lines[i] || "");
-      }
-    }
-  }
-
-  // Show the change
-  const arrow = " → ";
-  const newText = edit.newText;
-
-  // For readability, truncate very long texts
-  const maxLength = 50;
-  const truncate = (text: string) => {
-    if (text.length > maxLength) {
-      return text.substring(0, maxLength) + "...";
-    }
-    return text;
-  };
-
-  const displayOld = truncate(originalText.replace(/\n/g, "\\n"));
-  const displayNew = truncate(newText.replace(/\n/g, "\\n"));
+const rangeSchema = z.object({
+  ...schemaShape,
+  startLine: z.number().describe("First line to format (1-based)"),
+  endLine: z
+    .number()
+    .optional()
+    .describe("Last line to format (1-based, inclusive). Defaults to startLine"),
+});
 
-  return `Line ${startLine + 1}:${
-    edit.range.start.character + 1
-  }: "${displayOld}"${arrow}"${displayNew}"`;
-}
+type FormatRequest = z.infer<typeof schema>;
 
-async function handleFormatDocument(
-  {
-    root,
-    relativePath,
-    tabSize,
-    insertSpaces,
-    trimTrailingWhitespace,
-    insertFinalNewline,
-    trimFinalNewlines,
-    applyChanges,
-  }: z.infer<typeof schema>,
+/**
+ * Open the file, request formatting edits, and apply them (or preview)
+ */
+async function formatWithLSP(
+  request: FormatRequest,
   client: LSPClient,
+  getEdits: (
+    fileUri: string,
+    content: string,
+    options: FormattingOptions,
+  ) => Promise<TextEdit[]>,
+  context?: McpContext,
 ): Promise<string> {
   if (!client) {
     throw new Error("LSP client not initialized");
   }
 
+  const { root, relativePath, applyChanges, preview } = request;
+
   // Convert to absolute path
   const absolutePath = path.isAbsolute(relativePath)
     ? relativePath
@@ -120,53 +103,84 @@ async function handleFormatDocument(
 
     // Prepare formatting options
     const options: FormattingOptions = {
-      tabSize,
-      insertSpaces,
-      trimTrailingWhitespace,
-      insertFinalNewline,
-      trimFinalNewlines,
+      tabSize: request.tabSize,
+      insertSpaces: request.insertSpaces,
+      trimTrailingWhitespace: request.trimTrailingWhitespace,
+      insertFinalNewline: request.insertFinalNewline,
+      trimFinalNewlines: request.trimFinalNewlines,
     };
 
     // Get formatting edits
-    const edits = await client.formatDocument(fileUri, options);
+    const edits = await getEdits(fileUri, content, options);
+    const formattedContent = applyTextEdits(content, edits);
 
-    if (edits.length === 0) {
+    if (edits.length === 0 || formattedContent === content) {
       return `No formatting changes needed for ${relativePath}`;
     }
 
-    // Sort edits by position (reverse order for applying)
-    const sortedEdits = edits.sort((a: any, b: any) => {
-      const lineDiff = b.range.start.line - a.range.start.line;
-      if (lineDiff !== 0) return lineDiff;
-      return b.range.start.character - a.range.start.character;
-    });
-
-    // Format the result
-    let result = `Formatting changes for ${relativePath}:\n\n`;
-
-    // Show each edit
-    for (const edit of sortedEdits) {
-      result += formatTextEdit(edit, content) + "\n";
+    if (preview) {
+      const plan = await planWorkspaceEdit({ changes: { [fileUri]: edits } });
+      if (plan.errors.length > 0) {
+        throw new Error(
+          `Cannot preview formatting of ${relativePath}: ${plan.errors.join("; ")}`,
+        );
+      }
+      return previewWorkspaceEdit(root, plan, `formatting of ${relativePath}`, {
+        sessionId: context?.sessionId,
+      });
     }
 
-    result += `\nTotal changes: ${edits.length}`;
+    const diff = createUnifiedDiff(
+      relativePath,
+      content,
+      formattedContent,
+    ).trimEnd();
 
-    // Apply changes if requested
-    if (applyChanges) {
-      const formattedContent = applyTextEdits(content, edits);
-      await fs.writeFile(absolutePath, formattedContent, "utf-8");
-      result += "\n\n✓ Changes applied to file";
-    } else {
-      result += "\n\n(Use applyChanges: true to apply these changes)";
+    if (!applyChanges) {
+      return `Formatting changes for ${relativePath} (not applied):\n\n${diff}`;
     }
 
-    return result;
+    const delta = await trackDiagnostics(
+      client,
+      [{ filePath: absolutePath }],
+      context,
+    );
+    await fs.writeFile(absolutePath, formattedContent, "utf-8");
+    markFileModified(root, absolutePath);
+    const diagnostics = await delta(root);
+    const output = `Formatted ${relativePath}:\n\n${diff}`;
+    return diagnostics ? `${output}\n\n${diagnostics}` : output;
   } finally {
     // Close the document
     client.closeDocument(fileUri);
   }
 }
 
+/**
+ * Range from the start of startLine to the end of endLine (0-based LSP range)
+ */
+function getLineRange(
+  content: string,
+  startLine: number,
+  endLine: number,
+): Range {
+  const lines = content.split("\n");
+  if (startLine < 1 || startLine > lines.length) {
+    throw new Error(
+      `startLine ${startLine} is out of range (file has ${lines.length} lines)`,
+    );
+  }
+  if (endLine < startLine || endLine > lines.length) {
+    throw new Error(
+      `endLine ${endLine} must be between ${startLine} and ${lines.length}`,
+    );
+  }
+  return {
+    start: { line: startLine - 1, character: 0 },
+    end: { line: endLine - 1, character: lines[endLine - 1].length },
+  };
+}
+
 /**
  * Create format document tool with injected LSP client
  */
@@ -176,10 +190,43 @@ export function createFormatDocumentTool(
   return {
     name: "lsp_format_document",
     description:
-      "Format an entire document using LSP's formatting provider. Applies language-specific formatting rules.",
+      "Format an entire document using LSP's formatting provider (e.g. gofmt, rustfmt, prettier). Applies the edits and returns a unified diff; preview: true returns an edit token for confirm_edit instead.",
     schema,
-    execute: async (args) => {
-      return handleFormatDocument(args, client);
+    execute: async (args, context) => {
+      return formatWithLSP(
+        args,
+        client,
+        (fileUri, _content, options) =>
+          client.formatDocument(fileUri, options),
+        context,
+      );
+    },
+  };
+}
+
+/**
+ * Create format range tool with injected LSP client
+ */
+export function createFormatRangeTool(
+  client: LSPClient,
+): McpToolDef<typeof rangeSchema> {
+  return {
+    name: "lsp_format_range",
+    description:
+      "Format a range of lines using LSP's range formatting provider. Applies the edits and returns a unified diff; preview: true returns an edit token for confirm_edit instead.",
+    schema: rangeSchema,
+    execute: async ({ startLine, endLine, ...args }, context) => {
+      return formatWithLSP(
+        args,
+        client,
+        (fileUri, content, options) =>
+          client.formatRange(
+            fileUri,
+            getLineRange(content, startLine, endLine ?? startLine),
+            options,
+          ),
+        context,
+      );
     },
   };
 }
@@ -3,7 +3,13 @@ import { err, ok, type Result } from "neverthrow";
 import { withLSPOperation, getLanguageIdFromPath } from "@internal/lsp-client";
 import { createLSPTool } from "./toolFactory.ts";
 import type { LSPClient } from "@internal/lsp-client";
+import type { McpContext } from "@internal/types";
 import { resolveFileAndSymbol } from "./common.ts";
+import {
+  summarizeDocumentation,
+  summaryNote,
+  type DocumentationText,
+} from "../../utils/documentationSummaries.ts";
 
 const schema = z.object({
   root: z.string().describe("Root directory for resolving relative paths"),
@@ -30,6 +36,23 @@ const schema = z.object({
 
 type GetHoverRequest = z.infer<typeof schema>;
 
+const outputSchema = {
+  contents: z
+    .string()
+    .nullable()
+    .describe("Hover text (usually markdown), null when there is none"),
+  line: z.number().optional().describe("1-based start of the hovered range"),
+  column: z.number().optional().describe("1-based"),
+  endLine: z.number().optional(),
+  endColumn: z.number().optional(),
+  fullTextUri: z
+    .string()
+    .optional()
+    .describe(
+      "Set when contents is a summary of long documentation: resource holding the full text",
+    ),
+};
+
 /**
  * LSP Hover response types
  */
@@ -57,6 +80,8 @@ interface GetHoverSuccess {
       end: { line: number; character: number };
     };
   } | null;
+  /** Set when the contents were summarized */
+  summary?: DocumentationText;
 }
 
 /**
@@ -129,6 +154,7 @@ function formatHoverResult(
 async function getHover(
   request: GetHoverRequest,
   client: LSPClient,
+  context?: McpContext,
 ): Promise<Result<GetHoverSuccess, string>> {
   try {
     // Resolve file and position
@@ -210,7 +236,32 @@ async function getHover(
       },
     });
 
-    return formatHoverResult(result, request, targetLine, symbolPosition);
+    const formatted = formatHoverResult(
+      result,
+      request,
+      targetLine,
+      symbolPosition,
+    );
+    if (formatted.isErr() || !formatted.value.hover) {
+      return formatted;
+    }
+    // Long library documentation is summarized when configured
+    const { hover } = formatted.value;
+    const summary = await summarizeDocumentation(
+      hover.contents,
+      `${request.textTarget ? `"${request.textTarget}" at ` : ""}${
+        request.relativePath
+      }:${targetLine + 1}:${symbolPosition + 1}`,
+      context,
+    );
+    if (!summary.fullTextUri) {
+      return formatted;
+    }
+    return ok({
+      ...formatted.value,
+      hover: { ...hover, contents: summary.text },
+      summary,
+    });
   } catch (error) {
     return err(error instanceof Error ? error.message : String(error));
   }
@@ -250,12 +301,26 @@ export function createHoverTool(client: LSPClient) {
       "Get hover information (type signature, documentation) at a specific position using LSP. Requires exact line:column coordinates.",
     schema,
     language: "lsp",
-    handler: (request) => getHover(request, client),
+    handler: (request, context) => getHover(request, client, context),
+    outputSchema,
+    formatStructured: ({ hover, summary }) => ({
+      contents: hover?.contents ?? null,
+      ...(summary?.fullTextUri && { fullTextUri: summary.fullTextUri }),
+      ...(hover?.range && {
+        line: hover.range.start.line + 1,
+        column: hover.range.start.character + 1,
+        endLine: hover.range.end.line + 1,
+        endColumn: hover.range.end.character + 1,
+      }),
+    }),
     formatSuccess: (result) => {
       const messages = [result.message];
       if (result.hover) {
         messages.push(result.hover.contents);
       }
+      if (result.summary) {
+        messages.push(summaryNote(result.summary));
+      }
       return messages.join("\n\n");
     },
   });
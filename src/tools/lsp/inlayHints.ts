@@ -0,0 +1,132 @@
+import type { LSPClient } from "@internal/lsp-client";
+import { resolveLineParameter } from "@internal/lsp-client";
+import type { InlayHint, McpToolDef, Range } from "@internal/types";
+import { z } from "zod";
+import { readFileWithMetadata, withLSPDocument } from "./common.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z
+    .string()
+    .describe("File path to get inlay hints for (relative to root)"),
+  startLine: z
+    .union([z.number(), z.string()])
+    .describe("Start line number (1-based) or string to match. Defaults to 1")
+    .optional(),
+  endLine: z
+    .union([z.number(), z.string()])
+    .describe(
+      "End line number (1-based) or string to match. Defaults to the end of the file",
+    )
+    .optional(),
+});
+
+type InlayHintsRequest = z.infer<typeof schema>;
+
+const UNSUPPORTED_MESSAGE =
+  "Inlay hints are not supported by this language server. Try lsp_get_hover instead.";
+
+function hintLabel(hint: InlayHint): string {
+  const label =
+    typeof hint.label === "string"
+      ? hint.label
+      : hint.label.map((part) => part.value).join("");
+  return `${hint.paddingLeft ? " " : ""}${label}${hint.paddingRight ? " " : ""}`;
+}
+
+/**
+ * Render a line with its hints inserted inline as block comments
+ */
+function renderLine(text: string, hints: InlayHint[]): string {
+  let result = "";
+  let offset = 0;
+  const sorted = [...hints].sort(
+    (a, b) => a.position.character - b.position.character,
+  );
+  for (const hint of sorted) {
+    const character = Math.min(hint.position.character, text.length);
+    result += text.slice(offset, character) + `/*${hintLabel(hint)}*/`;
+    offset = character;
+  }
+  return result + text.slice(offset);
+}
+
+async function getInlayHints(
+  request: InlayHintsRequest,
+  client: LSPClient,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const capabilities = client.getServerCapabilities();
+  if (capabilities && !capabilities.inlayHintProvider) {
+    return UNSUPPORTED_MESSAGE;
+  }
+
+  const { fileContent, fileUri } = readFileWithMetadata(
+    request.root,
+    request.relativePath,
+  );
+  const lines = fileContent.split("\n");
+  const startLineIndex =
+    request.startLine !== undefined
+      ? resolveLineParameter(lines, request.startLine)
+      : 0;
+  const endLineIndex =
+    request.endLine !== undefined
+      ? resolveLineParameter(lines, request.endLine)
+      : lines.length - 1;
+
+  const range: Range = {
+    start: { line: startLineIndex, character: 0 },
+    end: { line: endLineIndex, character: lines[endLineIndex]?.length ?? 0 },
+  };
+  const label = `${request.relativePath}:${startLineIndex + 1}-${endLineIndex + 1}`;
+
+  return await withLSPDocument(client, fileUri, fileContent, async () => {
+    const hints = await client.getInlayHints(fileUri, range);
+    if (hints.length === 0) {
+      return `No inlay hints for ${label}`;
+    }
+
+    // Group hints by line
+    const byLine = new Map<number, InlayHint[]>();
+    for (const hint of hints) {
+      const line = hint.position.line;
+      if (!byLine.has(line)) {
+        byLine.set(line, []);
+      }
+      byLine.get(line)!.push(hint);
+    }
+
+    const rendered = Array.from(byLine.keys())
+      .sort((a, b) => a - b)
+      .map((line) => {
+        const text = renderLine(lines[line] ?? "", byLine.get(line)!);
+        return `${line + 1}: ${text}`;
+      });
+
+    return `Inlay hints for ${label} (${hints.length} hint${
+      hints.length === 1 ? "" : "s"
+    }):\n\n${rendered.join("\n")}`;
+  });
+}
+
+/**
+ * Create inlay hints tool with injected LSP client
+ */
+export function createInlayHintsTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "lsp_get_inlay_hints",
+    description:
+      "Get inlay hints (inferred types, parameter names) for a range of lines using LSP. " +
+      "Lines are shown with hints inserted inline as /*hint*/ comments.",
+    schema,
+    execute: async (args) => {
+      return getInlayHints(args, client);
+    },
+  };
+}
@@ -0,0 +1,98 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import { mkdtemp, rm, writeFile } from "node:fs/promises";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { pathToFileURL } from "node:url";
+import { createLspRequestTool } from "./lspRequest.ts";
+
+describe("lsp_request", () => {
+  let root: string;
+
+  beforeEach(async () => {
+    root = await mkdtemp(join(tmpdir(), "lsmcp-lsp-request-"));
+    await writeFile(join(root, "main.rs"), "fn main() {}\n");
+  });
+
+  afterEach(async () => {
+    await rm(root, { recursive: true, force: true });
+  });
+
+  function createClient(result: unknown) {
+    return {
+      sendRequest: vi.fn(async () => result),
+      openDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      isDocumentOpen: vi.fn(() => false),
+    } as any;
+  }
+
+  it("should send the method and params and return the raw result", async () => {
+    const client = createClient({ name: "main", expansion: "fn main() {}" });
+    const tool = createLspRequestTool(client);
+
+    const result = await tool.execute({
+      root,
+      method: "workspace/symbol",
+      params: { query: "main" },
+    });
+
+    expect(client.sendRequest).toHaveBeenCalledWith("workspace/symbol", {
+      query: "main",
+    });
+    expect(JSON.parse(result)).toEqual({
+      name: "main",
+      expansion: "fn main() {}",
+    });
+  });
+
+  it("should parse params given as a JSON string", async () => {
+    const client = createClient(null);
+    const tool = createLspRequestTool(client);
+
+    const result = await tool.execute({
+      root,
+      method: "workspace/symbol",
+      params: '{"query":"main"}',
+    });
+
+    expect(result).toBe("null");
+    expect(client.sendRequest).toHaveBeenCalledWith("workspace/symbol", {
+      query: "main",
+    });
+    await expect(
+      tool.execute({ root, method: "workspace/symbol", params: "{" }),
+    ).rejects.toThrow("params is not valid JSON");
+  });
+
+  it("should resolve a relative document uri and open the document", async () => {
+    const client = createClient([]);
+    const tool = createLspRequestTool(client);
+    const uri = pathToFileURL(join(root, "main.rs")).toString();
+
+    await tool.execute({
+      root,
+      method: "rust-analyzer/expandMacro",
+      params: {
+        textDocument: { uri: "main.rs" },
+        position: { line: 0, character: 3 },
+      },
+    });
+
+    expect(client.openDocument).toHaveBeenCalledWith(uri, "fn main() {}\n");
+    expect(client.sendRequest).toHaveBeenCalledWith(
+      "rust-analyzer/expandMacro",
+      { textDocument: { uri }, position: { line: 0, character: 3 } },
+    );
+    expect(client.closeDocument).toHaveBeenCalledWith(uri);
+  });
+
+  it("should refuse lifecycle methods", async () => {
+    const client = createClient(null);
+    const tool = createLspRequestTool(client);
+
+    await expect(tool.execute({ root, method: "shutdown" })).rejects.toThrow(
+      "shutdown is managed by lsmcp",
+    );
+    expect(client.sendRequest).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,114 @@
+/**
+ * Raw LSP request passthrough
+ *
+ * Sends any JSON-RPC request to the language server and returns the raw
+ * result, for server extensions without a dedicated tool (e.g.
+ * rust-analyzer/expandMacro, gopls commands, clangd/switchSourceHeader).
+ * Only offered when `"rawLspRequests": true` is set in the config.
+ */
+
+import type { LSPClient } from "@internal/lsp-client";
+import type { McpToolDef } from "@internal/types";
+import { z } from "zod";
+import { existsSync } from "fs";
+import { readFile } from "fs/promises";
+import path from "path";
+import { fileURLToPath, pathToFileURL } from "url";
+import { withLSPDocument } from "./common.ts";
+
+const MAX_RESULT_LENGTH = 20000;
+
+/** Lifecycle methods that would break the session */
+const FORBIDDEN_METHODS = ["initialize", "shutdown", "exit"];
+
+const schema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  method: z
+    .string()
+    .describe(
+      'JSON-RPC method, e.g. "rust-analyzer/expandMacro" or "textDocument/foldingRange"',
+    ),
+  params: z
+    .union([z.record(z.unknown()), z.array(z.unknown()), z.string()])
+    .optional()
+    .describe(
+      "Request params as an object, array or JSON string. A relative textDocument.uri is resolved against root, and the document is opened for the request",
+    ),
+});
+
+function parseParams(
+  params: z.infer<typeof schema>["params"],
+): Record<string, unknown> | unknown[] | undefined {
+  if (typeof params !== "string") {
+    return params;
+  }
+  try {
+    return JSON.parse(params);
+  } catch (error) {
+    throw new Error(
+      `params is not valid JSON: ${error instanceof Error ? error.message : String(error)}`,
+    );
+  }
+}
+
+function formatResult(result: unknown): string {
+  if (result === undefined || result === null) {
+    return "null";
+  }
+  const text =
+    typeof result === "string" ? result : JSON.stringify(result, null, 2);
+  return text.length > MAX_RESULT_LENGTH
+    ? `${text.slice(0, MAX_RESULT_LENGTH)}\n... (truncated, ${text.length} characters in total)`
+    : text;
+}
+
+async function handleLspRequest(
+  { root, method, params }: z.infer<typeof schema>,
+  client: LSPClient,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+  if (FORBIDDEN_METHODS.includes(method)) {
+    throw new Error(`${method} is managed by lsmcp and cannot be sent`);
+  }
+
+  const parsed = parseParams(params);
+  const textDocument =
+    parsed && !Array.isArray(parsed)
+      ? (parsed.textDocument as { uri?: unknown } | undefined)
+      : undefined;
+  if (typeof textDocument?.uri !== "string") {
+    return formatResult(await client.sendRequest(method, parsed));
+  }
+
+  // Servers only answer document requests for open documents
+  if (!textDocument.uri.includes("://")) {
+    textDocument.uri = pathToFileURL(
+      path.resolve(root, textDocument.uri),
+    ).toString();
+  }
+  const fileUri = textDocument.uri;
+  const filePath = fileUri.startsWith("file://") ? fileURLToPath(fileUri) : "";
+  if (client.isDocumentOpen(fileUri) || !existsSync(filePath)) {
+    return formatResult(await client.sendRequest(method, parsed));
+  }
+  const content = await readFile(filePath, "utf-8");
+  return await withLSPDocument(client, fileUri, content, async () =>
+    formatResult(await client.sendRequest(method, parsed)),
+  );
+}
+
+export function createLspRequestTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "lsp_request",
+    description:
+      "Send any JSON-RPC request to the language server and return its raw result. " +
+      "For server-specific extensions without a dedicated tool, e.g. rust-analyzer/expandMacro. " +
+      "Prefer the dedicated lsp_* tools when one exists.",
+    schema,
+    execute: async (args) => handleLspRequest(args, client),
+  };
+}
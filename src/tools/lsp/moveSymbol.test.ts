@@ -0,0 +1,320 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import { mkdtemp, readFile, rm, writeFile } from "fs/promises";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import type { DocumentSymbol, Range } from "@internal/types";
+import {
+  createMoveSymbolToFileTool,
+  moveSymbolText,
+  retargetImports,
+} from "./moveSymbol.ts";
+import { confirmEditTool } from "../editor/pendingEdits.ts";
+
+vi.mock("@internal/code-indexer");
+
+function range(
+  startLine: number,
+  startCharacter: number,
+  endLine: number,
+  endCharacter: number,
+): Range {
+  return {
+    start: { line: startLine, character: startCharacter },
+    end: { line: endLine, character: endCharacter },
+  };
+}
+
+const mainGo = [
+  "package main",
+  "",
+  "func main() {",
+  "\trun()",
+  "}",
+  "",
+  "// run starts the server",
+  "func run() {",
+  "}",
+  "",
+  "func stop() {}",
+  "",
+].join("\n");
+
+describe("moveSymbolText", () => {
+  it("should move the symbol with its doc comment into a new file", () => {
+    const move = moveSymbolText(mainGo, range(7, 0, 8, 1), null, {
+      header: "package main",
+    });
+
+    expect(move.source).toBe(
+      "package main\n\nfunc main() {\n\trun()\n}\n\nfunc stop() {}\n",
+    );
+    expect(move.target).toBe(
+      "package main\n\n// run starts the server\nfunc run() {\n}\n",
+    );
+    expect([move.firstLine, move.lastLine]).toEqual([6, 8]);
+  });
+
+  it("should append to an existing file and drop the surplus blank line at the end", () => {
+    const move = moveSymbolText(
+      mainGo,
+      range(10, 0, 10, 14),
+      "package main\n\nfunc other() {}\n\n\n",
+    );
+
+    expect(move.source).toBe(
+      "package main\n\nfunc main() {\n\trun()\n}\n\n// run starts the server\nfunc run() {\n}\n",
+    );
+    expect(move.target).toBe(
+      "package main\n\nfunc other() {}\n\nfunc stop() {}\n",
+    );
+  });
+
+  it("should export declarations still used by the source", () => {
+    const move = moveSymbolText(
+      "const a = f();\n\nfunction f() {\n  return 1;\n}\n",
+      range(2, 0, 4, 1),
+      null,
+      { exportSymbol: true },
+    );
+
+    expect(move.source).toBe("const a = f();\n");
+    expect(move.target).toBe("export function f() {\n  return 1;\n}\n");
+  });
+
+  it("should refuse symbols sharing their lines with other code", () => {
+    expect(() =>
+      moveSymbolText("var a = 1; var b = 2\n", range(0, 0, 0, 9), null),
+    ).toThrow(/continues after the symbol/);
+    expect(() =>
+      moveSymbolText("var (\n\ta = 1\n)\n", range(1, 1, 1, 6), null),
+    ).toThrow(/top level/);
+  });
+});
+
+describe("retargetImports", () => {
+  it("should import the moved symbol from the new file", () => {
+    expect(
+      retargetImports(
+        'import { run, stop } from "./main";\nimport { other } from "./other";\n',
+        "/p/app.ts",
+        "run",
+        "/p/main.ts",
+        "/p/server.ts",
+      ),
+    ).toBe(
+      'import { stop } from "./main";\nimport { run } from "./server";\nimport { other } from "./other";\n',
+    );
+  });
+
+  it("should keep aliases and the .js extension style", () => {
+    expect(
+      retargetImports(
+        "import type { run as start } from '../lib/main.js'\n",
+        "/p/src/app.ts",
+        "run",
+        "/p/lib/main.ts",
+        "/p/lib/server.ts",
+      ),
+    ).toBe("import type { run as start } from '../lib/server.js'\n");
+  });
+});
+
+describe("lsp_move_symbol_to_file", () => {
+  let root: string;
+
+  const symbols: DocumentSymbol[] = [
+    {
+      name: "main",
+      kind: 12,
+      range: range(2, 0, 4, 1),
+      selectionRange: range(2, 5, 2, 9),
+    },
+    {
+      name: "run",
+      kind: 12,
+      range: range(7, 0, 8, 1),
+      selectionRange: range(7, 5, 7, 8),
+    },
+  ];
+
+  beforeEach(async () => {
+    root = await mkdtemp(join(tmpdir(), "lsmcp-move-symbol-"));
+    await writeFile(join(root, "main.go"), mainGo);
+  });
+
+  afterEach(async () => {
+    await rm(root, { recursive: true, force: true });
+  });
+
+  function createClient() {
+    return {
+      openDocument: vi.fn(),
+      closeDocument: vi.fn(),
+      isDocumentOpen: vi.fn(() => false),
+      updateDocument: vi.fn(),
+      getDiagnostics: vi.fn(() => []),
+      getDocumentSymbols: vi.fn(async () => symbols),
+      findReferences: vi.fn(async () => [
+        {
+          uri: pathToFileURL(join(root, "main.go")).toString(),
+          range: range(3, 1, 3, 4),
+        },
+      ]),
+      getCodeActions: vi.fn(async () => []),
+    };
+  }
+
+  it("should move a Go function into a new file of the package", async () => {
+    const client = createClient();
+    const tool = createMoveSymbolToFileTool(client as any);
+
+    const result = await tool.execute(
+      {
+        root,
+        relativePath: "main.go",
+        namePath: "run",
+        targetPath: "server.go",
+        dryRun: false,
+        preview: false,
+      },
+      { lspClient: client, config: { diagnosticsDelta: false } } as any,
+    );
+
+    expect(result).toContain(
+      'Moved "run" (lines 7-9 of main.go) to server.go (new file)',
+    );
+    expect(await readFile(join(root, "server.go"), "utf-8")).toBe(
+      "package main\n\n// run starts the server\nfunc run() {\n}\n",
+    );
+    expect(await readFile(join(root, "main.go"), "utf-8")).toBe(
+      "package main\n\nfunc main() {\n\trun()\n}\n\nfunc stop() {}\n",
+    );
+    expect(client.getCodeActions).toHaveBeenCalledWith(
+      pathToFileURL(join(root, "server.go")).toString(),
+      expect.anything(),
+      expect.objectContaining({ only: ["source.organizeImports"] }),
+    );
+  });
+
+  it("should refuse moving Go symbols to another package", async () => {
+    const tool = createMoveSymbolToFileTool(createClient() as any);
+
+    await expect(
+      tool.execute({
+        root,
+        relativePath: "main.go",
+        namePath: "run",
+        targetPath: "server/server.go",
+        dryRun: false,
+        preview: false,
+      }),
+    ).rejects.toThrow(/another Go package/);
+  });
+
+  it("should refuse moving symbols outside the workspace", async () => {
+    const client = createClient();
+    const tool = createMoveSymbolToFileTool(client as any);
+
+    await expect(
+      tool.execute(
+        {
+          root,
+          relativePath: "main.go",
+          namePath: "run",
+          targetPath: "../server.go",
+          dryRun: false,
+          preview: false,
+        },
+        { lspClient: client, roots: [root] } as any,
+      ),
+    ).rejects.toThrow(/outside the workspace/);
+    expect(await readFile(join(root, "main.go"), "utf-8")).toBe(mainGo);
+    expect(tool.pathArgs).toEqual(["targetPath"]);
+  });
+
+  it("should preview the move with the import updates as one edit", async () => {
+    await writeFile(
+      join(root, "main.ts"),
+      "export function run() {}\n\nexport function stop() {}\n",
+    );
+    await writeFile(
+      join(root, "app.ts"),
+      'import { run, stop } from "./main";\n\nrun();\nstop();\n',
+    );
+    const serverUri = pathToFileURL(join(root, "server.ts")).toString();
+    const client = {
+      ...createClient(),
+      getDocumentSymbols: vi.fn(async () => [
+        {
+          name: "run",
+          kind: 12,
+          range: range(0, 0, 0, 24),
+          selectionRange: range(0, 16, 0, 19),
+        },
+      ]),
+      findReferences: vi.fn(async () => [
+        {
+          uri: pathToFileURL(join(root, "app.ts")).toString(),
+          range: range(2, 0, 2, 3),
+        },
+      ]),
+      getCodeActions: vi.fn(
+        async (uri: string, _range: Range, options: { only: string[] }) =>
+          uri === serverUri && options.only[0] === "source.addMissingImports"
+            ? [
+                {
+                  title: "Add all missing imports",
+                  kind: "source.addMissingImports",
+                  edit: {
+                    changes: {
+                      [serverUri]: [
+                        {
+                          range: range(0, 0, 0, 0),
+                          newText: 'import { helper } from "./util";\n',
+                        },
+                      ],
+                    },
+                  },
+                },
+              ]
+            : [],
+      ),
+    };
+    const context = {
+      lspClient: client,
+      config: { diagnosticsDelta: false },
+    } as any;
+    const tool = createMoveSymbolToFileTool(client as any);
+
+    const preview = await tool.execute(
+      {
+        root,
+        relativePath: "main.ts",
+        namePath: "run",
+        targetPath: "server.ts",
+        dryRun: false,
+        preview: true,
+      },
+      context,
+    );
+
+    expect(preview).toContain('+import { run } from "./server";');
+    expect(preview).toContain('+import { helper } from "./util";');
+    expect(await readFile(join(root, "app.ts"), "utf-8")).toContain(
+      'import { run, stop } from "./main";',
+    );
+
+    const token = /Edit token: (\S+)/.exec(preview)![1];
+    await confirmEditTool.execute({ token }, context);
+    expect(await readFile(join(root, "server.ts"), "utf-8")).toBe(
+      'import { helper } from "./util";\nexport function run() {}\n',
+    );
+    expect(await readFile(join(root, "main.ts"), "utf-8")).toBe(
+      "export function stop() {}\n",
+    );
+    expect(await readFile(join(root, "app.ts"), "utf-8")).toBe(
+      'import { stop } from "./main";\nimport { run } from "./server";\n\nrun();\nstop();\n',
+    );
+  });
+});
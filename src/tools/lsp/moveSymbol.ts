@@ -0,0 +1,561 @@
+/**
+ * Move a top-level symbol to another file
+ *
+ * The symbol's lines, doc comment and decorators included, are cut from the
+ * source file and appended to the target, which is created when missing
+ * (with the source's package clause for Go). JavaScript and TypeScript files
+ * importing the symbol by name import it from the target instead. The server
+ * is shown the planned contents and its addMissingImports and organizeImports
+ * code actions fix the imports of every changed file; the move and all import
+ * fixes are one workspace edit, previewed and written as a whole. Go
+ * declarations only move within their package, since gopls cannot rewrite
+ * references across packages.
+ */
+
+import type { LSPClient } from "@internal/lsp-client";
+import { z } from "zod";
+import { existsSync } from "fs";
+import { readFile } from "fs/promises";
+import { dirname, extname, relative, resolve, sep } from "path";
+import { pathToFileURL } from "url";
+import type {
+  CodeAction,
+  Command,
+  Location,
+  McpContext,
+  McpToolDef,
+  Range,
+  WorkspaceEdit,
+} from "@internal/types";
+import { withLSPDocument } from "./common.ts";
+import {
+  cutSymbol,
+  findSymbolByNamePath,
+  lastLine,
+  splitContent,
+} from "../editor/symbolEditTools.ts";
+import {
+  confirmWorkspaceEdit,
+  findStaleFiles,
+  formatWorkspaceEditDiff,
+  planWorkspaceEdit,
+  replaceContent,
+  toRelative,
+  uriToPath,
+  writeWorkspaceEditPlan,
+} from "../editor/workspaceEditTools.ts";
+import { previewWorkspaceEdit } from "../editor/pendingEdits.ts";
+import {
+  assertPathsInSandbox,
+  sandboxOptions,
+} from "../../utils/pathSandbox.ts";
+import { deltaFilesOfPlan, trackDiagnostics } from "./diagnosticsDelta.ts";
+
+/** Source actions run on each changed file, in this order */
+const IMPORT_ACTION_KINDS = [
+  "source.addMissingImports",
+  "source.organizeImports",
+];
+
+/**
+ * JavaScript and TypeScript files, where a symbol used from another module
+ * has to be exported
+ */
+const SCRIPT_EXTENSION = /\.[cm]?[jt]sx?$/;
+
+const GO_PACKAGE_CLAUSE = /^package\s+(\w+)/m;
+
+/** `import { a, type b, c as d } from "./x";`, also `import type { ... }` */
+const NAMED_IMPORT =
+  /^import\s+(type\s+)?\{([^}]*)\}\s*from\s*(["'])([^"']+)\3(;?)[ \t]*$/gm;
+
+const schema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z
+    .string()
+    .describe("File containing the symbol (relative to root)"),
+  namePath: z
+    .string()
+    .describe(
+      'Name path of a top-level symbol, e.g. "parseArgs" or "Server"; Go methods as "Server/Start"',
+    ),
+  targetPath: z
+    .string()
+    .describe(
+      "File to move the symbol to (relative to root); created when it does not exist",
+    ),
+  dryRun: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe("Show the diff without writing"),
+  preview: z
+    .boolean()
+    .optional()
+    .default(false)
+    .describe(
+      "Return the diff and an edit token without moving; apply it later with confirm_edit",
+    ),
+});
+
+export interface SymbolMove {
+  /** Source content without the symbol */
+  source: string;
+  /** Target content with the symbol appended */
+  target: string;
+  /** Lines cut from the source (0-based, inclusive) */
+  firstLine: number;
+  lastLine: number;
+}
+
+/**
+ * Cut the lines of a top-level symbol out of source and append them to target
+ * @param target content of the target file, null when it is created
+ * @param options.header first line of a created target, e.g. a package clause
+ * @param options.exportSymbol prefix the declaration with `export`
+ * @throws when the symbol shares its lines with other code
+ */
+export function moveSymbolText(
+  source: string,
+  range: Range,
+  target: string | null,
+  options: { header?: string; exportSymbol?: boolean } = {},
+): SymbolMove {
+  const declaration = range.start.line;
+  if (/^\s/.test(splitContent(source).lines[declaration])) {
+    throw new Error(
+      `Line ${declaration + 1} is indented; only declarations at the top level of the file can be moved`,
+    );
+  }
+  const { lines, block, first, last, eol } = cutSymbol(source, range);
+
+  const declarationIndex = declaration - first;
+  if (options.exportSymbol && !/^export\b/.test(block[declarationIndex])) {
+    block[declarationIndex] = `export ${block[declarationIndex]}`;
+  }
+
+  let moved: string;
+  if (target === null) {
+    const header = options.header ? [options.header, ""] : [];
+    moved = [...header, ...block, ""].join(eol);
+  } else {
+    const targetEol = splitContent(target).eol;
+    const existing = target.replace(/\s+$/, "");
+    moved =
+      (existing ? existing + targetEol + targetEol : "") +
+      block.join(targetEol) +
+      targetEol;
+  }
+
+  return {
+    source: lines.join(eol),
+    target: moved,
+    firstLine: first,
+    lastLine: last,
+  };
+}
+
+/**
+ * Import a moved symbol from its new file where a JavaScript or TypeScript
+ * file imported it by name from the old one; other imports stay as they are
+ * @param filePath the importing file
+ * @param from absolute path the symbol moved from
+ * @param to absolute path the symbol moved to
+ */
+export function retargetImports(
+  content: string,
+  filePath: string,
+  name: string,
+  from: string,
+  to: string,
+): string {
+  const directory = dirname(filePath);
+  return content.replace(
+    NAMED_IMPORT,
+    (
+      statement: string,
+      type: string | undefined,
+      names: string,
+      quote: string,
+      specifier: string,
+      semicolon: string,
+    ) => {
+      const imported = resolve(directory, specifier);
+      if (
+        !specifier.startsWith(".") ||
+        imported.replace(SCRIPT_EXTENSION, "") !==
+          from.replace(SCRIPT_EXTENSION, "")
+      ) {
+        return statement;
+      }
+      const entries = names
+        .split(",")
+        .map((entry) => entry.trim())
+        .filter(Boolean);
+      const moved = entries.filter(
+        (entry) =>
+          entry.replace(/^type\s+/, "").split(/\s+as\s+/)[0] === name,
+      );
+      if (moved.length === 0) {
+        return statement;
+      }
+      // Keep the extension style of the old specifier (none, or .js for .ts)
+      const extension = SCRIPT_EXTENSION.exec(specifier)?.[0] ?? "";
+      let target = relative(directory, to)
+        .replace(SCRIPT_EXTENSION, extension)
+        .split(sep)
+        .join("/");
+      if (!target.startsWith(".")) {
+        target = `./${target}`;
+      }
+      const line = (list: string[], path: string) =>
+        `import ${type ?? ""}{ ${list.join(", ")} } from ${quote}${path}${quote}${semicolon}`;
+      const rest = entries.filter((entry) => !moved.includes(entry));
+      return [
+        ...(rest.length > 0 ? [line(rest, specifier)] : []),
+        line(moved, target),
+      ].join("\n");
+    },
+  );
+}
+
+function isCommand(action: Command | CodeAction): action is Command {
+  return typeof action.command === "string";
+}
+
+/**
+ * Show the planned contents to the server while operation runs; documents
+ * opened for it are closed again, the others get their saved content back
+ */
+async function withPlannedDocuments<T>(
+  client: LSPClient,
+  planned: Map<string, string>,
+  originals: Map<string, string | null>,
+  operation: () => Promise<T>,
+): Promise<T> {
+  const opened = new Set<string>();
+  for (const [filePath, content] of planned) {
+    const uri = pathToFileURL(filePath).toString();
+    if (client.isDocumentOpen(uri)) {
+      client.updateDocument(uri, content);
+    } else {
+      client.openDocument(uri, content);
+      opened.add(uri);
+    }
+  }
+  try {
+    // Wait a bit for the server to analyze the new contents
+    await new Promise((resolve) => setTimeout(resolve, 500));
+    return await operation();
+  } finally {
+    for (const filePath of planned.keys()) {
+      const uri = pathToFileURL(filePath).toString();
+      if (opened.has(uri)) {
+        client.closeDocument(uri);
+      } else if (client.isDocumentOpen(uri)) {
+        client.updateDocument(uri, originals.get(filePath) ?? "");
+      }
+    }
+  }
+}
+
+/**
+ * Run the server's import fixing source actions on each file and apply them
+ * to the planned contents; files the actions change elsewhere join the plan
+ * @returns problems met on the way
+ */
+async function fixImports(
+  client: LSPClient,
+  root: string,
+  planned: Map<string, string>,
+  originals: Map<string, string | null>,
+  files: string[],
+): Promise<string[]> {
+  const notes: string[] = [];
+  for (const filePath of files) {
+    const fileUri = pathToFileURL(filePath).toString();
+    try {
+      for (const kind of IMPORT_ACTION_KINDS) {
+        const actions = await client.getCodeActions(
+          fileUri,
+          replaceContent(planned.get(filePath) ?? "", "").range,
+          { diagnostics: client.getDiagnostics(fileUri), only: [kind] },
+        );
+        let action = actions.find(
+          (a): a is CodeAction =>
+            !isCommand(a) && !a.disabled && !!a.kind?.startsWith(kind),
+        );
+        if (action && !action.edit && client.resolveCodeAction) {
+          action = await client.resolveCodeAction(action);
+        }
+        if (!action?.edit) {
+          continue;
+        }
+        const plan = await planWorkspaceEdit(action.edit, planned);
+        if (plan.errors.length > 0) {
+          throw new Error(plan.errors.join("; "));
+        }
+        for (const change of plan.changes) {
+          if (change.kind !== "edit" || change.after === null) {
+            continue;
+          }
+          if (!originals.has(change.filePath)) {
+            originals.set(change.filePath, change.before);
+          }
+          planned.set(change.filePath, change.after);
+          const uri = pathToFileURL(change.filePath).toString();
+          if (client.isDocumentOpen(uri)) {
+            client.updateDocument(uri, change.after);
+          }
+        }
+      }
+    } catch (error) {
+      const reason = error instanceof Error ? error.message : String(error);
+      notes.push(
+        `Could not update the imports of ${toRelative(root, filePath)}: ${reason}`,
+      );
+    }
+  }
+  return notes;
+}
+
+async function handleMoveSymbol(
+  {
+    root,
+    relativePath,
+    namePath,
+    targetPath,
+    dryRun = false,
+    preview = false,
+  }: z.infer<typeof schema>,
+  client: LSPClient,
+  context?: McpContext,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const sourcePath = resolve(root, relativePath);
+  const destinationPath = resolve(root, targetPath);
+  assertPathsInSandbox([destinationPath], sandboxOptions(context, root));
+  if (sourcePath === destinationPath) {
+    throw new Error("targetPath must be another file than relativePath");
+  }
+  const isGo = extname(sourcePath) === ".go";
+  if (isGo !== (extname(destinationPath) === ".go")) {
+    throw new Error(
+      `Cannot move a symbol from ${relativePath} to ${targetPath}: the files are in different languages`,
+    );
+  }
+  if (isGo && dirname(sourcePath) !== dirname(destinationPath)) {
+    throw new Error(
+      `${targetPath} is in another directory, so in another Go package. ` +
+        `Moving declarations across packages is not supported; pick a file in ${toRelative(root, dirname(sourcePath))}`,
+    );
+  }
+
+  const content = await readFile(sourcePath, "utf-8");
+  const targetContent = existsSync(destinationPath)
+    ? await readFile(destinationPath, "utf-8")
+    : null;
+  const sourceUri = pathToFileURL(sourcePath).toString();
+
+  const { symbol, references } = await withLSPDocument(
+    client,
+    sourceUri,
+    content,
+    async () => {
+      const symbols = (await client.getDocumentSymbols(sourceUri)) ?? [];
+      const symbol = findSymbolByNamePath(symbols, namePath);
+      if (!symbol.topLevel) {
+        throw new Error(
+          `"${symbol.path.join("/")}" is declared inside another symbol; only top-level symbols can be moved`,
+        );
+      }
+      // Servers without references still allow the move itself
+      const references: Location[] = await client
+        .findReferences(sourceUri, symbol.selectionRange.start)
+        .catch(() => []);
+      return { symbol, references };
+    },
+  );
+
+  let header: string | undefined;
+  if (isGo) {
+    const sourcePackage = GO_PACKAGE_CLAUSE.exec(content);
+    const targetPackage =
+      targetContent === null ? null : GO_PACKAGE_CLAUSE.exec(targetContent);
+    if (targetPackage && targetPackage[1] !== sourcePackage?.[1]) {
+      throw new Error(
+        `${targetPath} is in package ${targetPackage[1]}, not ${sourcePackage?.[1]}`,
+      );
+    }
+    header = sourcePackage?.[0];
+  }
+
+  const first = symbol.range.start.line;
+  const last = lastLine(symbol.range);
+  const usedInSource = references.some(
+    (ref) =>
+      uriToPath(ref.uri) === sourcePath &&
+      (ref.range.start.line < first || ref.range.start.line > last),
+  );
+  const move = moveSymbolText(content, symbol.range, targetContent, {
+    header,
+    exportSymbol: usedInSource && SCRIPT_EXTENSION.test(sourcePath),
+  });
+
+  // Same-package Go references need no change; other files import the symbol
+  const referencing = isGo
+    ? []
+    : [
+        ...new Set(
+          references
+            .map((ref) => uriToPath(ref.uri))
+            .filter((file) => file !== sourcePath && file !== destinationPath),
+        ),
+      ];
+
+  // Planned content of every file the move changes, next to what is on disk
+  const originals = new Map<string, string | null>([
+    [destinationPath, targetContent],
+    [sourcePath, content],
+  ]);
+  const planned = new Map<string, string>([
+    [destinationPath, move.target],
+    [sourcePath, move.source],
+  ]);
+  const name = symbol.path[symbol.path.length - 1];
+  const retargeted: string[] = [];
+  for (const filePath of referencing) {
+    if (!SCRIPT_EXTENSION.test(filePath)) {
+      continue;
+    }
+    const original = await readFile(filePath, "utf-8");
+    const updated = retargetImports(
+      original,
+      filePath,
+      name,
+      sourcePath,
+      destinationPath,
+    );
+    if (updated !== original) {
+      originals.set(filePath, original);
+      planned.set(filePath, updated);
+      retargeted.push(filePath);
+    }
+  }
+  const notes = await withPlannedDocuments(client, planned, originals, () =>
+    fixImports(client, root, planned, originals, [
+      destinationPath,
+      sourcePath,
+      ...retargeted,
+    ]),
+  );
+
+  const targetUri = pathToFileURL(destinationPath).toString();
+  const edit: WorkspaceEdit = {
+    documentChanges: [
+      ...(targetContent === null
+        ? [{ kind: "create" as const, uri: targetUri }]
+        : []),
+      ...[...planned]
+        .filter(([filePath, after]) => after !== originals.get(filePath))
+        .map(([filePath, after]) => ({
+          textDocument: {
+            uri: pathToFileURL(filePath).toString(),
+            version: null,
+          },
+          edits: [replaceContent(originals.get(filePath) ?? "", after)],
+        })),
+    ],
+  };
+  // Planned against the contents read above, so later changes are stale
+  const plan = await planWorkspaceEdit(edit, originals);
+  if (plan.errors.length > 0) {
+    throw new Error(`Cannot move "${namePath}": ${plan.errors.join("; ")}`);
+  }
+
+  const title = `move of "${namePath}" to ${targetPath}`;
+  const unchanged = referencing.filter((file) => !planned.has(file));
+  const notices = [
+    ...(unchanged.length > 0
+      ? [
+          "",
+          `Also referenced from ${unchanged.length} other file(s) whose imports were not updated:`,
+          ...unchanged.map((file) => `  ${toRelative(root, file)}`),
+        ]
+      : []),
+    ...(notes.length > 0 ? ["", ...notes] : []),
+  ];
+  if (preview) {
+    return [
+      previewWorkspaceEdit(root, plan, title, {
+        sessionId: context?.sessionId,
+      }),
+      ...notices,
+    ].join("\n");
+  }
+  if (dryRun) {
+    return [
+      `Dry run: ${title}`,
+      "",
+      formatWorkspaceEditDiff(root, plan),
+      ...notices,
+    ].join("\n");
+  }
+
+  const stale = await findStaleFiles(root, plan);
+  if (stale.length > 0) {
+    throw new Error(
+      `Files changed while computing the move: ${stale.join(", ")}`,
+    );
+  }
+  await confirmWorkspaceEdit(root, plan, title, context);
+  const delta = await trackDiagnostics(
+    client,
+    [
+      ...deltaFilesOfPlan(plan),
+      ...unchanged.map((filePath) => ({ filePath })),
+    ],
+    context,
+  );
+
+  const written = await writeWorkspaceEditPlan(root, plan, { context });
+  // Documents open in the server get the written content
+  for (const change of plan.changes) {
+    const uri = pathToFileURL(change.filePath).toString();
+    if (change.after !== null && client.isDocumentOpen(uri)) {
+      client.updateDocument(uri, change.after);
+    }
+  }
+
+  const lines = [
+    `Moved "${symbol.path.join("/")}" (lines ${move.firstLine + 1}-${move.lastLine + 1} of ${relativePath}) to ${targetPath}${targetContent === null ? " (new file)" : ""}`,
+    `Edited ${written.length} file(s):`,
+    ...written.map((file) => `  ${file}`),
+    ...notices,
+  ];
+  const diagnostics = await delta(root);
+  if (diagnostics) {
+    lines.push("", diagnostics);
+  }
+  return lines.join("\n");
+}
+
+export function createMoveSymbolToFileTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "lsp_move_symbol_to_file",
+    description:
+      "Move a top-level function, type or variable (with its doc comment) to another file, creating it when needed, " +
+      "then fix the imports of the changed files with the server's organize imports actions. " +
+      "JavaScript and TypeScript files importing it by name import it from the new file; other references are listed for follow-up. " +
+      "Go symbols move within their package only. " +
+      "Use dryRun: true for the diff, or preview: true for an edit token for confirm_edit.",
+    schema,
+    pathArgs: ["targetPath"],
+    execute: async (args, context) => handleMoveSymbol(args, client, context),
+  };
+}
@@ -1,12 +1,19 @@
 import type { LSPClient } from "@internal/lsp-client";
-import type { McpToolDef } from "@internal/types";
+import type { McpContext, McpToolDef } from "@internal/types";
 import { z } from "zod";
 import { err, ok, type Result } from "neverthrow";
 import { readFileSync } from "fs";
 import path from "path";
-import type { ErrorContext } from "@internal/lsp-client";
-import { formatError, validateLineAndSymbol } from "@internal/lsp-client";
+import type { ErrorContext, Location } from "@internal/lsp-client";
+import {
+  createWorkDoneToken,
+  formatError,
+  onWorkDoneProgress,
+  validateLineAndSymbol,
+} from "@internal/lsp-client";
 import { pathToFileURL } from "url";
+import { findReferencesCached } from "@internal/code-indexer";
+import { paginateResults, queryFingerprint } from "../../utils/cursor.ts";
 
 // Helper functions
 function readFileWithMetadata(root: string, filePath: string) {
@@ -33,6 +40,41 @@ const schema = z.object({
     .optional()
     .describe("Character position in the line (0-based)"),
   symbolName: z.string().describe("Name of the symbol to find references for"),
+  includeDeclaration: z
+    .boolean()
+    .optional()
+    .default(true)
+    .describe("Include the declaration of the symbol in the results"),
+  contextLines: z
+    .number()
+    .int()
+    .min(0)
+    .max(10)
+    .optional()
+    .default(1)
+    .describe(
+      "Number of lines of context to show before and after each reference",
+    ),
+  limit: z
+    .number()
+    .int()
+    .positive()
+    .describe("Maximum number of references to return")
+    .optional()
+    .default(50),
+  offset: z
+    .number()
+    .int()
+    .min(0)
+    .describe("Number of references to skip (for paging through results)")
+    .optional()
+    .default(0),
+  cursor: z
+    .string()
+    .optional()
+    .describe(
+      "Opaque cursor from a previous response to fetch the next page; takes precedence over offset",
+    ),
 });
 
 type FindReferencesRequest = z.infer<typeof schema>;
@@ -48,6 +90,91 @@ interface Reference {
 interface FindReferencesSuccess {
   message: string;
   references: Reference[];
+  /** Total number of references before paging */
+  total: number;
+  /** Cursor of the next page, if there is one */
+  nextCursor?: string;
+}
+
+const outputSchema = {
+  symbolName: z.string(),
+  total: z.number().describe("Number of references before paging"),
+  references: z.array(
+    z.object({
+      relativePath: z.string(),
+      line: z.number().describe("1-based"),
+      column: z.number().describe("1-based"),
+      text: z.string().describe("Text of the reference"),
+    }),
+  ),
+  nextCursor: z
+    .string()
+    .optional()
+    .describe("Pass as cursor to fetch the next page"),
+};
+
+/**
+ * Lines around `line` (0-based), rendered with 1-based line numbers
+ */
+function buildPreview(
+  lines: string[],
+  line: number,
+  contextLines: number,
+): string {
+  const start = Math.max(0, line - contextLines);
+  const end = Math.min(lines.length - 1, line + contextLines);
+  const preview: string[] = [];
+  for (let i = start; i <= end; i++) {
+    // Skip blank context lines but always keep the reference line itself
+    if (i === line || lines[i]) {
+      preview.push(`${i + 1}: ${lines[i]}`);
+    }
+  }
+  return preview.join("\n");
+}
+
+/**
+ * Ask the server for the references at a position, forwarding its work done
+ * progress to the MCP client
+ */
+async function lookupReferences(
+  client: LSPClient,
+  fileUri: string,
+  fileContent: string,
+  position: { line: number; character: number },
+  includeDeclaration: boolean,
+  context?: McpContext,
+): Promise<Location[]> {
+  // Open document in LSP
+  client.openDocument(fileUri, fileContent);
+
+  // Give LSP server time to process the document
+  await new Promise<void>((resolve) => setTimeout(resolve, 1000));
+  context?.signal?.throwIfAborted();
+  const reportProgress = context?.reportProgress;
+  const workDoneToken = reportProgress
+    ? createWorkDoneToken("references")
+    : undefined;
+  const stopProgress = workDoneToken
+    ? onWorkDoneProgress(client, workDoneToken, (update) => {
+        if (update.percentage !== undefined) {
+          reportProgress!({
+            progress: update.percentage,
+            total: 100,
+            message: update.message ?? update.title,
+          });
+        }
+      })
+    : undefined;
+  try {
+    return await client.findReferences(fileUri, position, {
+      workDoneToken,
+      signal: context?.signal,
+      includeDeclaration,
+    });
+  } finally {
+    stopProgress?.();
+  }
 }
 
 /**
@@ -56,6 +183,7 @@ interface FindReferencesSuccess {
 async function findReferencesWithLSP(
   request: FindReferencesRequest,
   client: LSPClient,
+  context?: McpContext,
 ): Promise<Result<FindReferencesSuccess, string>> {
   try {
     if (!client) {
@@ -65,10 +193,12 @@ async function findReferencesWithLSP(
     // Read file content with metadata
     let fileContent: string;
     let fileUri: string;
+    let absolutePath: string;
     try {
       const result = readFileWithMetadata(request.root, request.relativePath);
       fileContent = result.fileContent;
       fileUri = result.fileUri;
+      absolutePath = result.absolutePath;
     } catch (error) {
       const context: ErrorContext = {
         operation: "find references",
@@ -100,30 +230,82 @@ async function findReferencesWithLSP(
       return err(formatError(error, context));
     }
 
-    // Open document in LSP
-    client.openDocument(fileUri, fileContent);
+    // Reuse the references of an earlier lookup at the same position while
+    // the index shows no file changed since
+    const key = [
+      path.relative(request.root, absolutePath),
+      targetLine,
+      symbolPosition,
+      request.includeDeclaration ? "declaration" : "usages",
+    ].join(":");
+    const cached = await findReferencesCached(request.root, key, async () => {
+      const locations = await lookupReferences(
+        client,
+        fileUri,
+        fileContent,
+        { line: targetLine, character: symbolPosition },
+        request.includeDeclaration,
+        context,
+      );
+      return locations.map((location) => ({
+        namePath: key,
+        filePath: path.relative(
+          request.root,
+          location.uri?.replace("file://", "") || "",
+        ),
+        startLine: location.range.start.line,
+        startCharacter: location.range.start.character,
+        endLine: location.range.end.line,
+        endCharacter: location.range.end.character,
+      }));
+    });
 
-    // Give LSP server time to process the document
-    await new Promise<void>((resolve) => setTimeout(resolve, 1000));
-    // Find references
-    const locations = await client.findReferences(fileUri, {
-      line: targetLine,
-      character: symbolPosition,
+    // Sort so that paging is stable across calls
+    const sorted = cached
+      .map((reference) => ({
+        location: {
+          range: {
+            start: {
+              line: reference.startLine,
+              character: reference.startCharacter,
+            },
+            end: { line: reference.endLine, character: reference.endCharacter },
+          },
+        },
+        refPath: path.resolve(request.root, reference.filePath),
+      }))
+      .sort(
+        (a, b) =>
+          a.refPath.localeCompare(b.refPath) ||
+          a.location.range.start.line - b.location.range.start.line ||
+          a.location.range.start.character - b.location.range.start.character,
+      );
+    const { limit, offset, cursor, contextLines, ...query } = request;
+    const paged = paginateResults(sorted, {
+      cursor,
+      offset,
+      limit,
+      fingerprint: queryFingerprint("lsp_find_references", query),
     });
+    const page = paged.items;
 
     // Convert LSP locations to our Reference format
     const references: Reference[] = [];
+    const fileLines = new Map<string, string[] | null>();
 
-    for (const location of locations) {
-      const refPath = location.uri?.replace("file://", "") || "";
-      let refContent: string;
-      try {
-        refContent = readFileSync(refPath, "utf-8");
-      } catch (error) {
+    for (const { location, refPath } of page) {
+      if (!fileLines.has(refPath)) {
+        try {
+          fileLines.set(refPath, readFileSync(refPath, "utf-8").split("\n"));
+        } catch (error) {
+          fileLines.set(refPath, null);
+        }
+      }
+      const refLines = fileLines.get(refPath);
+      if (!refLines) {
         // Skip references in files we can't read
         continue;
       }
-      const refLines = refContent.split("\n");
 
       // Get the text at the reference location
       const startLine = location.range.start.line;
@@ -132,33 +314,31 @@ async function findReferencesWithLSP(
       const refLineText = refLines[startLine] || "";
       const text = refLineText.substring(startCol, endCol);
 
-      // Create preview with context
-      const prevLine = startLine > 0 ? refLines[startLine - 1] : "";
-      const nextLine =
-        startLine < refLines.length - 1 ? refLines[startLine + 1] : "";
-      const preview = [
-        prevLine && `${startLine}: ${prevLine}`,
-        `${startLine + 1}: ${refLineText}`,
-        nextLine && `${startLine + 2}: ${nextLine}`,
-      ]
-        .filter(Boolean)
-        .join("\n");
-
       references.push({
         relativePath: path.relative(request.root, refPath),
         line: startLine + 1, // Convert to 1-based
         column: startCol + 1, // Convert to 1-based
         text,
-        preview,
+        preview: buildPreview(refLines, startLine, contextLines),
       });
     }
 
-    return ok({
-      message: `Found ${references.length} reference${
-        references.length === 1 ? "" : "s"
-      } to "${request.symbolName}"`,
-      references,
-    });
+    const total = sorted.length;
+    let message = `Found ${total} reference${
+      total === 1 ? "" : "s"
+    } to "${request.symbolName}"`;
+    if (total > 0 && (paged.offset > 0 || page.length < total)) {
+      const end = paged.offset + page.length;
+      message +=
+        page.length > 0
+          ? ` (showing ${paged.offset + 1}-${end})`
+          : ` (none after offset ${paged.offset})`;
+      if (paged.nextCursor) {
+        message += `. Use cursor: "${paged.nextCursor}" (or offset: ${end}) to see more.`;
+      }
+    }
+
+    return ok({ message, references, total, nextCursor: paged.nextCursor });
   } catch (error) {
     const context: ErrorContext = {
       operation: "find references",
@@ -186,11 +366,27 @@ export function createReferencesTool(
   return {
     name: "lsp_find_references",
     description:
-      "Find all references to a symbol at a specific position using LSP. Requires exact line:column coordinates.",
+      "Find all references to a symbol at a specific position using LSP. Requires exact line:column coordinates. " +
+      "Each reference includes contextLines of surrounding code; page through large result sets with limit and the returned cursor.",
     schema,
-    execute: async (args: z.infer<typeof schema>) => {
-      const result = await findReferencesWithLSP(args, client);
+    outputSchema,
+    execute: async (args: z.infer<typeof schema>, context) => {
+      const result = await findReferencesWithLSP(args, client, context);
       if (result.isOk()) {
+        const { references, total, nextCursor } = result.value;
+        context?.setStructuredContent?.({
+          symbolName: args.symbolName,
+          total,
+          references: references.map(
+            ({ relativePath, line, column, text }) => ({
+              relativePath,
+              line,
+              column,
+              text,
+            }),
+          ),
+          nextCursor,
+        });
         const messages = [result.value.message];
 
         if (result.value.references.length > 0) {
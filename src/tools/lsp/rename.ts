@@ -1,7 +1,6 @@
 import type { LSPClient } from "@internal/lsp-client";
 import { z } from "zod";
 import { err, ok, type Result } from "neverthrow";
-import { applyTextEdits } from "../../utils/applyTextEdits.ts";
 // Helper functions
 function parseLineNumber(content: string, line: number | string): number {
   if (typeof line === "number") {
@@ -44,11 +43,24 @@ function findTargetInFile(
   throw new Error(`Target "${target}" not found in file`);
 }
 
-import type { McpToolDef } from "@internal/types";
-import { readdirSync, readFileSync, statSync, writeFileSync } from "fs";
+import type { McpContext, McpToolDef } from "@internal/types";
+import { readdirSync, readFileSync, statSync } from "fs";
 import path from "path";
+import { fileURLToPath } from "url";
 import { Position, TextEdit, WorkspaceEdit } from "@internal/types";
 import { debug } from "@internal/lsp-client";
+import {
+  confirmWorkspaceEdit,
+  findStaleFiles,
+  formatWorkspaceEditDiff,
+  planWorkspaceEdit,
+  writeWorkspaceEditPlan,
+} from "../editor/workspaceEditTools.ts";
+import { addPendingEdit, formatEditTokenNote } from "../editor/pendingEdits.ts";
+import { deltaFilesOfPlan, trackDiagnostics } from "./diagnosticsDelta.ts";
+
+// Files the TypeScript server only knows about once they are opened
+const TS_EXTENSIONS = [".ts", ".tsx", ".js", ".jsx", ".mts", ".mjs"];
 
 const schema = z.object({
   root: z.string().describe("Root directory for resolving relative paths"),
@@ -61,6 +73,16 @@ const schema = z.object({
     .optional(),
   textTarget: z.string().describe("Symbol to rename"),
   newName: z.string().describe("New name for the symbol"),
+  dryRun: z
+    .boolean()
+    .optional()
+    .describe("Preview the rename as a unified diff without writing files"),
+  preview: z
+    .boolean()
+    .optional()
+    .describe(
+      "Return the unified diff and an edit token without writing; apply it later with confirm_edit",
+    ),
 });
 
 type RenameSymbolRequest = z.infer<typeof schema>;
@@ -76,6 +98,12 @@ interface RenameSymbolSuccess {
       newText: string;
     }[];
   }[];
+  renamedFiles: { from: string; to: string }[];
+  diff?: string;
+  /** Token for confirm_edit when previewing */
+  editToken?: string;
+  /** Diagnostics introduced and fixed by the written edit */
+  diagnostics?: string;
 }
 
 /**
@@ -84,6 +112,7 @@ interface RenameSymbolSuccess {
 async function performRenameWithoutLine(
   request: RenameSymbolRequest,
   client: LSPClient,
+  context?: McpContext,
 ): Promise<Result<RenameSymbolSuccess, string>> {
   try {
     // Read file content
@@ -104,6 +133,7 @@ async function performRenameWithoutLine(
       targetLine,
       symbolPosition,
       client,
+      context,
     );
   } catch (error) {
     return err(error instanceof Error ? error.message : String(error));
@@ -116,6 +146,7 @@ async function performRenameWithoutLine(
 async function performRenameWithLine(
   request: RenameSymbolRequest,
   client: LSPClient,
+  context?: McpContext,
 ): Promise<Result<RenameSymbolSuccess, string>> {
   try {
     // Read file content
@@ -138,6 +169,7 @@ async function performRenameWithLine(
       targetLine,
       symbolPosition,
       client,
+      context,
     );
   } catch (error) {
     return err(error instanceof Error ? error.message : String(error));
@@ -154,6 +186,7 @@ async function performRenameAtPosition(
   targetLine: number,
   symbolPosition: number,
   client: LSPClient,
+  context?: McpContext,
 ): Promise<Result<RenameSymbolSuccess, string>> {
   try {
     if (!client) {
@@ -161,7 +194,12 @@ async function performRenameAtPosition(
     }
 
     // Open all TypeScript/JavaScript files in the project to ensure LSP knows about them
-    const projectFiles = await findProjectFiles(request.root);
+    // Other servers (gopls, rust-analyzer, ...) load the workspace themselves
+    const projectFiles = TS_EXTENSIONS.includes(
+      path.extname(request.relativePath),
+    )
+      ? await findProjectFiles(request.root)
+      : [];
     for (const file of projectFiles) {
       if (file !== path.resolve(request.root, request.relativePath)) {
         try {
@@ -228,7 +266,15 @@ async function performRenameAtPosition(
     );
 
     // Apply changes and format result
-    const result = await applyWorkspaceEdit(request.root, workspaceEdit);
+    const result = await applyWorkspaceEdit(
+      request.root,
+      workspaceEdit,
+      request.dryRun,
+      `rename of "${request.textTarget}" to "${request.newName}"`,
+      request.preview,
+      client,
+      context,
+    );
 
     // Close all opened documents
     client.closeDocument(fileUri);
@@ -250,95 +296,109 @@ async function performRenameAtPosition(
 
 /**
  * Apply workspace edit and return formatted result
+ * @param preview keep the edit for confirm_edit instead of writing
  */
 async function applyWorkspaceEdit(
-  _root: string,
+  root: string,
   workspaceEdit: WorkspaceEdit,
+  dryRun = false,
+  title: string,
+  preview = false,
+  client?: LSPClient,
+  context?: McpContext,
 ): Promise<RenameSymbolSuccess> {
-  const changedFiles: RenameSymbolSuccess["changedFiles"] = [];
-  const allFileContents = new Map<string, string[]>();
-
-  // Collect all file contents before applying changes
-  if (workspaceEdit.changes) {
-    for (const [uri, _edits] of Object.entries(workspaceEdit.changes)) {
-      if (!uri) continue;
-      const filePath = uri.replace("file://", "");
-      const content = readFileSync(filePath, "utf-8");
-      allFileContents.set(filePath, content.split("\n"));
-    }
+  const plan = await planWorkspaceEdit(workspaceEdit);
+  if (plan.errors.length > 0) {
+    throw new Error(`Cannot apply rename: ${plan.errors.join("; ")}`);
   }
 
-  if (workspaceEdit.documentChanges) {
-    for (const change of workspaceEdit.documentChanges) {
-      if ("textDocument" in change && change.textDocument?.uri) {
-        const filePath = change.textDocument.uri.replace("file://", "");
-        if (!allFileContents.has(filePath)) {
-          const content = readFileSync(filePath, "utf-8");
-          allFileContents.set(filePath, content.split("\n"));
-        }
-      }
+  // Original contents, used to report the replaced text
+  const originalLines = new Map<string, string[]>();
+  for (const change of plan.changes) {
+    if (change.before !== null) {
+      originalLines.set(
+        change.oldFilePath ?? change.filePath,
+        change.before.split("\n"),
+      );
     }
   }
 
-  // Process changes from WorkspaceEdit.changes
-  if (workspaceEdit.changes) {
-    for (const [uri, edits] of Object.entries(workspaceEdit.changes)) {
-      if (!uri) continue;
-      const filePath = uri.replace("file://", "");
-      const lines = allFileContents.get(filePath);
-      if (!lines) continue;
-      const fileChanges = processTextEdits(filePath, lines, edits);
-
-      if (fileChanges.changes.length > 0) {
-        changedFiles.push(fileChanges);
-
-        // Apply edits to file
-        const newContent = applyTextEdits(lines.join("\n"), edits);
-        writeFileSync(filePath, newContent, "utf-8");
-      }
+  const changedFiles: RenameSymbolSuccess["changedFiles"] = [];
+  const addEdits = (uri: string, edits: TextEdit[]) => {
+    const filePath = uri.startsWith("file://") ? fileURLToPath(uri) : uri;
+    const lines = originalLines.get(filePath);
+    if (!lines || edits.length === 0) return;
+    const fileChanges = processTextEdits(filePath, lines, edits);
+    const existingFile = changedFiles.find((f) => f.filePath === filePath);
+    if (existingFile) {
+      existingFile.changes.push(...fileChanges.changes);
+    } else {
+      changedFiles.push(fileChanges);
     }
+  };
+
+  // Process changes from WorkspaceEdit.changes
+  for (const [uri, edits] of Object.entries(workspaceEdit.changes ?? {})) {
+    if (uri && edits) addEdits(uri, edits);
   }
 
   // Process changes from WorkspaceEdit.documentChanges
-  if (workspaceEdit.documentChanges) {
-    for (const change of workspaceEdit.documentChanges) {
-      if (
-        "textDocument" in change &&
-        "edits" in change &&
-        change.textDocument?.uri
-      ) {
-        const filePath = change.textDocument.uri.replace("file://", "");
-        const lines = allFileContents.get(filePath);
-        if (!lines) continue;
-        const fileChanges = processTextEdits(filePath, lines, change.edits);
-
-        if (fileChanges.changes.length > 0) {
-          // Check if we already processed this file
-          const existingFile = changedFiles.find(
-            (f) => f.filePath === filePath,
-          );
-          if (existingFile) {
-            existingFile.changes.push(...fileChanges.changes);
-          } else {
-            changedFiles.push(fileChanges);
-          }
-
-          // Apply edits to file
-          const newContent = applyTextEdits(lines.join("\n"), change.edits);
-          writeFileSync(filePath, newContent, "utf-8");
-        }
-      }
+  for (const change of workspaceEdit.documentChanges ?? []) {
+    if ("textDocument" in change && "edits" in change) {
+      addEdits(change.textDocument.uri, change.edits);
     }
   }
 
+  const renamedFiles = plan.changes
+    .filter((change) => change.kind === "rename" && change.oldFilePath)
+    .map((change) => ({ from: change.oldFilePath!, to: change.filePath }));
+
   const totalChanges = changedFiles.reduce(
     (sum, file) => sum + file.changes.length,
     0,
   );
+  const summary = `${changedFiles.length} file(s) with ${totalChanges} change(s)`;
+
+  if (preview) {
+    return {
+      message: `Rename would change ${summary} (preview, nothing written)`,
+      changedFiles,
+      renamedFiles,
+      diff: formatWorkspaceEditDiff(root, plan),
+      editToken: addPendingEdit(root, plan, title, {
+        sessionId: context?.sessionId,
+      }),
+    };
+  }
+
+  if (dryRun) {
+    return {
+      message: `Rename would change ${summary} (dry run, nothing written)`,
+      changedFiles,
+      renamedFiles,
+      diff: formatWorkspaceEditDiff(root, plan),
+    };
+  }
+
+  const stale = await findStaleFiles(root, plan);
+  if (stale.length > 0) {
+    throw new Error(
+      `Files changed while computing the rename: ${stale.join(", ")}`,
+    );
+  }
+  await confirmWorkspaceEdit(root, plan, title, context);
+  const delta = await trackDiagnostics(
+    client,
+    deltaFilesOfPlan(plan),
+    context,
+  );
+  await writeWorkspaceEditPlan(root, plan, { context });
 
   return {
-    message: `Successfully renamed symbol in ${changedFiles.length} file(s) with ${totalChanges} change(s)`,
+    message: `Successfully renamed symbol in ${summary}`,
     changedFiles,
+    renamedFiles,
+    diagnostics: await delta(root),
   };
 }
 
@@ -391,12 +451,13 @@ function processTextEdits(
 async function handleRenameSymbol(
   request: RenameSymbolRequest,
   client: LSPClient,
+  context?: McpContext,
 ): Promise<Result<RenameSymbolSuccess, string>> {
   try {
     if (request.line !== undefined) {
-      return performRenameWithLine(request, client);
+      return performRenameWithLine(request, client, context);
     } else {
-      return performRenameWithoutLine(request, client);
+      return performRenameWithoutLine(request, client, context);
     }
   } catch (error) {
     return err(error instanceof Error ? error.message : String(error));
@@ -408,7 +469,6 @@ async function handleRenameSymbol(
  */
 async function findProjectFiles(rootPath: string): Promise<string[]> {
   const files: string[] = [];
-  const extensions = [".ts", ".tsx", ".js", ".jsx", ".mts", ".mjs"];
 
   function walkDir(dir: string) {
     try {
@@ -424,7 +484,7 @@ async function findProjectFiles(rootPath: string): Promise<string[]> {
           }
         } else if (stat.isFile()) {
           const ext = path.extname(fullPath);
-          if (extensions.includes(ext)) {
+          if (TS_EXTENSIONS.includes(ext)) {
             files.push(fullPath);
           }
         }
@@ -447,21 +507,30 @@ export function createRenameSymbolTool(
   return {
     name: "lsp_rename_symbol",
     description:
-      "Rename a symbol across the codebase using LSP. Requires exact position or text target in the specified line.",
+      "Rename a symbol across the codebase using LSP (prepareRename + rename) and apply the edits to every affected file. " +
+      "Requires exact position or text target in the specified line. Use dryRun: true to preview a diff first, " +
+        "or preview: true to get the diff with an edit token for confirm_edit.",
     schema,
-    execute: async (args) => {
-      const result = await handleRenameSymbol(args, client);
+    execute: async (args, context) => {
+      const result = await handleRenameSymbol(args, client, context);
       if (result.isErr()) {
         throw new Error(result.error);
       }
 
       // Format output
-      const { message, changedFiles } = result.value;
+      const {
+        message,
+        changedFiles,
+        renamedFiles,
+        diff,
+        editToken,
+        diagnostics,
+      } = result.value;
       const output = [message, "", "Changes:"];
 
       for (const file of changedFiles) {
         const relativePath = path.relative(args.root, file.filePath);
-        output.push(`  ${relativePath}:`);
+        output.push(`  ${relativePath} (${file.changes.length} edit(s)):`);
 
         for (const change of file.changes) {
           output.push(
@@ -470,6 +539,25 @@ export function createRenameSymbolTool(
         }
       }
 
+      if (renamedFiles.length > 0) {
+        output.push("", "Renamed files:");
+        for (const { from, to } of renamedFiles) {
+          output.push(
+            `  ${path.relative(args.root, from)} → ${path.relative(args.root, to)}`,
+          );
+        }
+      }
+
+      if (diff) {
+        output.push("", diff);
+      }
+      if (editToken) {
+        output.push("", formatEditTokenNote(editToken));
+      }
+      if (diagnostics) {
+        output.push("", diagnostics);
+      }
+
       return output.join("\n");
     },
   };
@@ -4,7 +4,12 @@ import path from "path";
 import fs from "fs/promises";
 import { pathToFileURL } from "url";
 import { SignatureHelp } from "@internal/types";
-import type { McpToolDef } from "@internal/types";
+import type {
+  MarkupContent,
+  McpToolDef,
+  ParameterInformation,
+  SignatureInformation,
+} from "@internal/types";
 import { resolveLineParameter } from "@internal/lsp-client";
 import { withLSPDocument } from "./common.ts";
 
@@ -24,10 +29,74 @@ const schemaShape = {
     .number()
     .describe("Column position in the line (0-based)")
     .optional(),
+  format: z
+    .enum(["text", "json"])
+    .default("text")
+    .describe(
+      "Output format. json returns signatures with parameter labels and the active parameter",
+    ),
 };
 
 const schema = z.object(schemaShape);
 
+interface StructuredSignature {
+  label: string;
+  documentation?: string;
+  parameters: { label: string; documentation?: string }[];
+  activeParameter?: number;
+}
+
+interface StructuredSignatureHelp {
+  activeSignature: number;
+  signatures: StructuredSignature[];
+}
+
+function documentationText(
+  documentation?: string | MarkupContent,
+): string | undefined {
+  if (!documentation) return undefined;
+  const text =
+    typeof documentation === "string" ? documentation : documentation.value;
+  return text || undefined;
+}
+
+function parameterLabel(
+  signature: SignatureInformation,
+  param: ParameterInformation,
+): string {
+  // Label is either the text itself or [start, end] offsets into signature.label
+  return typeof param.label === "string"
+    ? param.label
+    : signature.label.substring(param.label[0], param.label[1]);
+}
+
+/**
+ * The active parameter may be set per signature (LSP 3.16) or on the result
+ */
+function activeParameterOf(
+  help: SignatureHelp,
+  signature: SignatureInformation,
+): number | undefined {
+  return signature.activeParameter ?? help.activeParameter ?? undefined;
+}
+
+function toStructuredSignatureHelp(
+  help: SignatureHelp,
+): StructuredSignatureHelp {
+  return {
+    activeSignature: help.activeSignature ?? 0,
+    signatures: help.signatures.map((signature) => ({
+      label: signature.label,
+      documentation: documentationText(signature.documentation),
+      parameters: (signature.parameters ?? []).map((param) => ({
+        label: parameterLabel(signature, param),
+        documentation: documentationText(param.documentation),
+      })),
+      activeParameter: activeParameterOf(help, signature),
+    })),
+  };
+}
+
 function formatSignatureHelp(help: SignatureHelp): string {
   if (help.signatures.length === 0) {
     return "No signature help available";
@@ -46,46 +115,27 @@ function formatSignatureHelp(help: SignatureHelp): string {
   result += `Signature: ${signature.label}\n`;
 
   // Add documentation if available
-  if (signature.documentation) {
-    const doc =
-      typeof signature.documentation === "string"
-        ? signature.documentation
-        : signature.documentation.value;
-    if (doc) {
-      result += `\nDocumentation:\n${doc}\n`;
-    }
+  const doc = documentationText(signature.documentation);
+  if (doc) {
+    result += `\nDocumentation:\n${doc}\n`;
   }
 
   // Format parameters
   if (signature.parameters && signature.parameters.length > 0) {
     result += "\nParameters:\n";
-    const activeParameter = help.activeParameter ?? 0;
+    const activeParameter = activeParameterOf(help, signature) ?? 0;
 
     for (let i = 0; i < signature.parameters.length; i++) {
       const param = signature.parameters[i];
       const isActive = i === activeParameter;
       const prefix = isActive ? "→ " : "  ";
 
-      // Extract parameter name from label
-      let paramName = "";
-      if (typeof param.label === "string") {
-        paramName = param.label;
-      } else {
-        // Label is [start, end] offsets into signature.label
-        paramName = signature.label.substring(param.label[0], param.label[1]);
-      }
-
-      result += `${prefix}${paramName}`;
+      result += `${prefix}${parameterLabel(signature, param)}`;
 
       // Add parameter documentation if available
-      if (param.documentation) {
-        const paramDoc =
-          typeof param.documentation === "string"
-            ? param.documentation
-            : param.documentation.value;
-        if (paramDoc) {
-          result += ` - ${paramDoc}`;
-        }
+      const paramDoc = documentationText(param.documentation);
+      if (paramDoc) {
+        result += ` - ${paramDoc}`;
       }
 
       result += "\n";
@@ -101,7 +151,14 @@ function formatSignatureHelp(help: SignatureHelp): string {
 }
 
 async function handleGetSignatureHelp(
-  { root, relativePath, line, column, textTarget }: z.infer<typeof schema>,
+  {
+    root,
+    relativePath,
+    line,
+    column,
+    textTarget,
+    format,
+  }: z.infer<typeof schema>,
   client: LSPClient,
 ): Promise<string> {
   if (!client) {
@@ -161,6 +218,14 @@ async function handleGetSignatureHelp(
       character,
     });
 
+    if (format === "json") {
+      return JSON.stringify(
+        help ? toStructuredSignatureHelp(help) : null,
+        null,
+        2,
+      );
+    }
+
     if (!help) {
       return `No signature help available at ${relativePath}:${lineIndex + 1}:${
         character + 1
@@ -184,7 +249,8 @@ export function createSignatureHelpTool(
   return {
     name: "lsp_get_signature_help",
     description:
-      "Get signature help (parameter hints) for function calls using LSP. Requires exact line:column position within a function call.",
+      "Get signature help (parameter hints) for function calls using LSP. Requires exact line:column position within a function call, or textTarget naming the callee. " +
+      "Returns parameter labels, the active parameter and documentation; use format: json for structured output.",
     schema,
     execute: async (args) => {
       return handleGetSignatureHelp(args, client);
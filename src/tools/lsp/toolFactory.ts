@@ -1,6 +1,6 @@
-import { z, type ZodType } from "zod";
+import { z, type ZodRawShape, type ZodType } from "zod";
 import type { Result } from "neverthrow";
-import type { McpToolDef } from "@internal/types";
+import type { McpContext, McpToolDef } from "@internal/types";
 
 /**
  * Options for creating a tool with the factory
@@ -16,11 +16,20 @@ export interface CreateToolOptions<TSchema extends ZodType, TSuccess> {
   schema: TSchema;
 
   /** Main handler function that implements the tool logic */
-  handler: (args: z.infer<TSchema>) => Promise<Result<TSuccess, string>>;
+  handler: (
+    args: z.infer<TSchema>,
+    context?: McpContext,
+  ) => Promise<Result<TSuccess, string>>;
 
   /** Function to format success result into a string */
   formatSuccess: (result: TSuccess) => string;
 
+  /** Shape of the structured result built by formatStructured */
+  outputSchema?: ZodRawShape;
+
+  /** Function to build the structured result (MCP structuredContent) */
+  formatStructured?: (result: TSuccess) => Record<string, unknown>;
+
   /** Optional error formatter for custom error handling */
   formatError?: (error: string, args: z.infer<TSchema>) => Error;
 }
@@ -45,18 +54,30 @@ export interface CreateToolOptions<TSchema extends ZodType, TSuccess> {
 export function createTool<TSchema extends ZodType, TSuccess>(
   options: CreateToolOptions<TSchema, TSuccess>,
 ): McpToolDef<TSchema> {
-  const { name, description, schema, handler, formatSuccess, formatError } =
-    options;
+  const {
+    name,
+    description,
+    schema,
+    handler,
+    formatSuccess,
+    outputSchema,
+    formatStructured,
+    formatError,
+  } = options;
 
   return {
     name,
     description,
     schema,
-    execute: async (args: z.infer<TSchema>) => {
+    outputSchema,
+    execute: async (args: z.infer<TSchema>, context) => {
       try {
-        const result = await handler(args);
+        const result = await handler(args, context);
 
         if (result.isOk()) {
+          if (formatStructured) {
+            context?.setStructuredContent?.(formatStructured(result.value));
+          }
           return formatSuccess(result.value);
         } else {
           // Use custom error formatter if provided
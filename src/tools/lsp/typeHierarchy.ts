@@ -0,0 +1,143 @@
+import type { LSPClient, TypeHierarchyItem } from "@internal/lsp-client";
+import type { McpToolDef } from "@internal/types";
+import { getSymbolKindName } from "@internal/types";
+import { z } from "zod";
+import path from "path";
+import { fileURLToPath } from "url";
+import { resolveFileAndSymbol, withLSPDocument } from "./common.ts";
+
+const schema = z.object({
+  root: z.string().describe("Root directory for resolving relative paths"),
+  relativePath: z
+    .string()
+    .describe("File path containing the type (relative to root)"),
+  line: z
+    .union([z.number(), z.string()])
+    .describe("Line number (1-based) or string to match in the line"),
+  symbolName: z
+    .string()
+    .describe("Name of the class, interface or type to inspect"),
+  direction: z
+    .enum(["supertypes", "subtypes", "both"])
+    .default("both")
+    .describe(
+      "Which side of the hierarchy to return: supertypes (parents/implemented interfaces), subtypes (implementations/subclasses) or both",
+    ),
+});
+
+type TypeHierarchyRequest = z.infer<typeof schema>;
+
+const UNSUPPORTED_MESSAGE =
+  "Type hierarchy is not supported by this language server. Try lsp_find_references or lsp_get_definitions instead.";
+
+function formatItem(root: string, item: TypeHierarchyItem): string {
+  let relativePath = item.uri;
+  try {
+    relativePath = path.relative(root, fileURLToPath(item.uri));
+  } catch {
+    // Keep the raw URI for non-file schemes
+  }
+  const kind = getSymbolKindName(item.kind) ?? "Unknown";
+  const location = `${relativePath}:${item.selectionRange.start.line + 1}:${
+    item.selectionRange.start.character + 1
+  }`;
+  const detail = item.detail ? ` - ${item.detail}` : "";
+  return `  ${item.name} [${kind}] ${location}${detail}`;
+}
+
+function isUnsupportedError(error: unknown): boolean {
+  const message = error instanceof Error ? error.message : String(error);
+  return (
+    message.includes("Unhandled method") ||
+    message.includes("Method not found")
+  );
+}
+
+async function getTypeHierarchy(
+  request: TypeHierarchyRequest,
+  client: LSPClient,
+): Promise<string> {
+  if (!client) {
+    throw new Error("LSP client not initialized");
+  }
+
+  const capabilities = client.getServerCapabilities();
+  if (capabilities && !capabilities.typeHierarchyProvider) {
+    return UNSUPPORTED_MESSAGE;
+  }
+
+  const { fileUri, fileContent, lineIndex, symbolIndex } =
+    resolveFileAndSymbol({
+      root: request.root,
+      relativePath: request.relativePath,
+      line: request.line,
+      symbolName: request.symbolName,
+    });
+
+  return await withLSPDocument(client, fileUri, fileContent, async () => {
+    let items: TypeHierarchyItem[];
+    try {
+      items = await client.prepareTypeHierarchy(fileUri, {
+        line: lineIndex,
+        character: symbolIndex,
+      });
+    } catch (error) {
+      if (isUnsupportedError(error)) {
+        return UNSUPPORTED_MESSAGE;
+      }
+      throw error;
+    }
+
+    if (items.length === 0) {
+      return `No type hierarchy item found for "${request.symbolName}" at ${request.relativePath}:${lineIndex + 1}`;
+    }
+
+    const sections: string[] = [];
+    for (const item of items) {
+      const lines = [
+        `Type hierarchy for ${formatItem(request.root, item).trim()}`,
+      ];
+
+      if (request.direction !== "subtypes") {
+        const supertypes = await client.getSupertypes(item);
+        lines.push(`\nSupertypes (${supertypes.length}):`);
+        lines.push(
+          ...(supertypes.length > 0
+            ? supertypes.map((s) => formatItem(request.root, s))
+            : ["  (none)"]),
+        );
+      }
+
+      if (request.direction !== "supertypes") {
+        const subtypes = await client.getSubtypes(item);
+        lines.push(`\nSubtypes (${subtypes.length}):`);
+        lines.push(
+          ...(subtypes.length > 0
+            ? subtypes.map((s) => formatItem(request.root, s))
+            : ["  (none)"]),
+        );
+      }
+
+      sections.push(lines.join("\n"));
+    }
+
+    return sections.join("\n\n");
+  });
+}
+
+/**
+ * Create type hierarchy tool with injected LSP client
+ */
+export function createTypeHierarchyTool(
+  client: LSPClient,
+): McpToolDef<typeof schema> {
+  return {
+    name: "lsp_get_type_hierarchy",
+    description:
+      "Get supertypes and/or subtypes of a class, interface or type using LSP type hierarchy. Useful for finding implementations of an interface or class inheritance chains.",
+    schema,
+    execute: async (args) => {
+      return getTypeHierarchy(args, client);
+    },
+  };
+}
@@ -1,7 +1,7 @@
 import type { LSPClient } from "@internal/lsp-client";
 import { z } from "zod";
 import { SymbolInformation, SymbolKind } from "@internal/types";
-import type { McpToolDef } from "@internal/types";
+import type { McpContext, McpToolDef } from "@internal/types";
 import { fileURLToPath } from "url";
 
 const schemaShape = {
@@ -16,6 +16,21 @@ const schemaShape = {
 
 const schema = z.object(schemaShape);
 
+const outputSchema = {
+  symbols: z.array(
+    z.object({
+      name: z.string(),
+      kind: z.string(),
+      containerName: z.string().optional(),
+      path: z.string().describe("Relative to root when inside it"),
+      line: z.number().describe("1-based"),
+      column: z.number().describe("1-based"),
+      endLine: z.number(),
+      endColumn: z.number(),
+    }),
+  ),
+};
+
 function getSymbolKindName(kind: SymbolKind): string {
   const symbolKindNames: Record<SymbolKind, string> = {
     [SymbolKind.File]: "File",
@@ -48,6 +63,21 @@ function getSymbolKindName(kind: SymbolKind): string {
   return symbolKindNames[kind] || "Unknown";
 }
 
+/**
+ * Path of a file URI, relative to root when inside it
+ */
+function displayPath(uri: string, root?: string): string {
+  try {
+    const absolutePath = fileURLToPath(uri);
+    return root && absolutePath.startsWith(root + "/")
+      ? absolutePath.substring(root.length + 1)
+      : absolutePath;
+  } catch {
+    // Keep original URI if conversion fails
+    return uri;
+  }
+}
+
 function formatSymbolInformation(
   symbol: SymbolInformation,
   root?: string,
@@ -55,22 +85,7 @@ function formatSymbolInformation(
   const kind = getSymbolKindName(symbol.kind);
   const deprecated = symbol.deprecated ? " (deprecated)" : "";
   const container = symbol.containerName ? ` in ${symbol.containerName}` : "";
-
-  // Convert file URI to relative path if possible
-  let filePath = symbol.location.uri;
-  try {
-    const absolutePath = fileURLToPath(symbol.location.uri);
-    if (root) {
-      // Make path relative to root
-      filePath = absolutePath.startsWith(root + "/")
-        ? absolutePath.substring(root.length + 1)
-        : absolutePath;
-    } else {
-      filePath = absolutePath;
-    }
-  } catch {
-    // Keep original URI if conversion fails
-  }
+  const filePath = displayPath(symbol.location.uri, root);
 
   return `${symbol.name} [${kind}]${deprecated}${container}
   File: ${filePath}
@@ -85,6 +100,7 @@ function formatSymbolInformation(
 async function handleGetWorkspaceSymbols(
   { query, root }: z.infer<typeof schema>,
   client: LSPClient,
+  context?: McpContext,
 ): Promise<string> {
   if (!client) {
     throw new Error("LSP client not initialized");
@@ -112,7 +128,22 @@ async function handleGetWorkspaceSymbols(
   }
 
   // Get workspace symbols
-  const symbols = await client.getWorkspaceSymbols(query);
+  const symbols = await client.getWorkspaceSymbols(query, {
+    signal: context?.signal,
+  });
+
+  context?.setStructuredContent?.({
+    symbols: symbols.map((symbol: SymbolInformation) => ({
+      name: symbol.name,
+      kind: getSymbolKindName(symbol.kind),
+      containerName: symbol.containerName,
+      path: displayPath(symbol.location.uri, root),
+      line: symbol.location.range.start.line + 1,
+      column: symbol.location.range.start.character + 1,
+      endLine: symbol.location.range.end.line + 1,
+      endColumn: symbol.location.range.end.character + 1,
+    })),
+  });
 
   if (symbols.length === 0) {
     return `No symbols found matching "${query}"`;
@@ -138,17 +169,7 @@ async function handleGetWorkspaceSymbols(
     // Add file header when switching files
     if (symbol.location.uri !== currentFile) {
       currentFile = symbol.location.uri;
-      let displayPath = currentFile;
-      try {
-        const absolutePath = fileURLToPath(currentFile);
-        displayPath =
-          root && absolutePath.startsWith(root + "/")
-            ? absolutePath.substring(root.length + 1)
-            : absolutePath;
-      } catch {
-        // Keep original URI
-      }
-      result += `\n=== ${displayPath} ===\n\n`;
+      result += `\n=== ${displayPath(currentFile, root)} ===\n\n`;
     }
 
     result += formatSymbolInformation(symbol, root) + "\n\n";
@@ -169,8 +190,9 @@ export function createWorkspaceSymbolsTool(
       "Search for symbols across the entire workspace using LSP. " +
       "Note: Feature availability depends on language server support.",
     schema,
-    execute: async (args) => {
-      return handleGetWorkspaceSymbols(args, client);
+    outputSchema,
+    execute: async (args, context) => {
+      return handleGetWorkspaceSymbols(args, client, context);
     },
   };
 }
@@ -24,6 +24,7 @@ describe("memoryTools", () => {
     // Mock MemoryManager
     mockManager = {
       listMemories: vi.fn(),
+      listMemoryInfos: vi.fn(),
       readMemory: vi.fn(),
       writeMemory: vi.fn(),
       deleteMemory: vi.fn(),
@@ -64,6 +65,32 @@ describe("memoryTools", () => {
 
       expect(JSON.parse(result)).toEqual([]);
     });
+
+    it("should include size and timestamps with details", async () => {
+      mockManager.listMemoryInfos.mockResolvedValue([
+        {
+          name: "project-overview",
+          size: 120,
+          createdAt: new Date("2024-01-01T00:00:00Z"),
+          updatedAt: new Date("2024-01-15T00:00:00Z"),
+        },
+      ]);
+
+      const result = await listMemoriesTool.execute({
+        root: testDir,
+        details: true,
+      });
+
+      expect(JSON.parse(result)).toEqual([
+        {
+          name: "project-overview",
+          size: 120,
+          createdAt: "2024-01-01T00:00:00.000Z",
+          updatedAt: "2024-01-15T00:00:00.000Z",
+        },
+      ]);
+      expect(mockManager.listMemories).not.toHaveBeenCalled();
+    });
   });
 
   describe("readMemory", () => {
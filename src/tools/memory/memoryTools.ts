@@ -1,17 +1,38 @@
 import { z } from "zod";
 import type { McpToolDef } from "@internal/types";
-import { MemoryManager } from "../../features/memory/memoryManager.ts";
+import {
+  MAX_MEMORIES,
+  MAX_MEMORY_BYTES,
+  MemoryManager,
+} from "../../features/memory/memoryManager.ts";
 
 const listMemoriesSchema = z.object({
   root: z.string().describe("Root directory of the project"),
+  details: z
+    .boolean()
+    .optional()
+    .describe(
+      "Include size and created/updated timestamps, most recently updated first",
+    ),
 });
 
 export const listMemoriesTool: McpToolDef<typeof listMemoriesSchema> = {
   name: "list_memories",
   description: "List available memories for the current project",
   schema: listMemoriesSchema,
-  execute: async ({ root }) => {
+  execute: async ({ root, details }) => {
     const manager = new MemoryManager(root);
+    if (details) {
+      const infos = await manager.listMemoryInfos();
+      return JSON.stringify(
+        infos.map((info) => ({
+          name: info.name,
+          size: info.size,
+          createdAt: info.createdAt.toISOString(),
+          updatedAt: info.updatedAt.toISOString(),
+        })),
+      );
+    }
     const memories = await manager.listMemories();
     return JSON.stringify(memories);
   },
@@ -46,7 +67,7 @@ const writeMemorySchema = z.object({
 
 export const writeMemoryTool: McpToolDef<typeof writeMemorySchema> = {
   name: "write_memory",
-  description: "Write or update a memory for the project",
+  description: `Write or update a markdown memory for the project (stored in .lsmcp/memories/, at most ${MAX_MEMORY_BYTES / 1024} KiB each and ${MAX_MEMORIES} per project)`,
   schema: writeMemorySchema,
   execute: async ({ root, memoryName, content }) => {
     const manager = new MemoryManager(root);
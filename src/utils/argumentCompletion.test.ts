@@ -0,0 +1,147 @@
+import { describe, it, expect, vi, beforeAll, afterAll } from "vitest";
+import { mkdir, mkdtemp, rm, writeFile } from "fs/promises";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  completeArgument,
+  completeFilePath,
+  completePresetName,
+  completeSymbolName,
+  createArgumentCompletions,
+} from "./argumentCompletion.ts";
+
+const { symbols } = vi.hoisted(() => ({
+  symbols: [] as { name: string }[],
+}));
+
+vi.mock("@internal/code-indexer", () => ({
+  querySymbols: vi.fn(() => symbols),
+}));
+
+describe("argument completion", () => {
+  let root: string;
+
+  beforeAll(async () => {
+    root = await mkdtemp(join(tmpdir(), "lsmcp-completion-"));
+    await mkdir(join(root, "src/utils"), { recursive: true });
+    await mkdir(join(root, "node_modules"));
+    await writeFile(join(root, "src/server.ts"), "");
+    await writeFile(join(root, "src/session.ts"), "");
+    await writeFile(join(root, "src/.env"), "");
+    symbols.push(
+      { name: "createServer" },
+      { name: "ServerOptions" },
+      { name: "Server" },
+      { name: "serverName" },
+      { name: "Server" },
+      { name: "Client" },
+    );
+  });
+
+  afterAll(async () => {
+    await rm(root, { recursive: true, force: true });
+  });
+
+  it("should complete paths within the directory typed so far", async () => {
+    expect(await completeFilePath(root, "src/se")).toEqual([
+      "src/server.ts",
+      "src/session.ts",
+    ]);
+    expect(await completeFilePath(root, "src/")).toEqual([
+      "src/server.ts",
+      "src/session.ts",
+      "src/utils/",
+    ]);
+    expect(await completeFilePath(root, "src/.")).toEqual(["src/.env"]);
+  });
+
+  it("should skip node_modules and paths outside the root", async () => {
+    expect(await completeFilePath(root, "")).toEqual(["src/"]);
+    expect(await completeFilePath(root, "../")).toEqual([]);
+  });
+
+  it("should rank symbol names that start with the value first", () => {
+    expect(completeSymbolName(root, "Server")).toEqual([
+      "Server",
+      "ServerOptions",
+      "serverName",
+      "createServer",
+    ]);
+  });
+
+  it("should complete preset ids from the registry", () => {
+    expect(completePresetName("typescript")).toContain("typescript");
+    expect(completePresetName("no-such-preset")).toEqual([]);
+  });
+
+  it("should complete tool arguments by name and resource variables by template", async () => {
+    expect(
+      await completeArgument(
+        {
+          ref: { type: "ref/tool", name: "lsp_find_references" },
+          argument: { name: "relativePath", value: "src/ser" },
+        },
+        root,
+      ),
+    ).toEqual(["src/server.ts"]);
+    expect(
+      await completeArgument(
+        {
+          ref: { type: "ref/resource", uri: "lsmcp://outline/{+path}" },
+          argument: { name: "path", value: "src/u" },
+        },
+        root,
+      ),
+    ).toEqual(["src/utils/"]);
+    expect(
+      await completeArgument(
+        {
+          ref: { type: "ref/tool", name: "lsp_find_references" },
+          argument: { name: "line", value: "1" },
+        },
+        root,
+      ),
+    ).toEqual([]);
+  });
+
+  it("should resolve tool paths against the root argument", async () => {
+    expect(
+      await completeArgument(
+        {
+          ref: { type: "ref/tool", name: "lsp_get_hover" },
+          argument: { name: "relativePath", value: "s" },
+          context: { arguments: { root: join(root, "src") } },
+        },
+        root,
+      ),
+    ).toEqual(["server.ts", "session.ts"]);
+  });
+
+  it("should answer completion/complete on the server", async () => {
+    let handler: ((request: any) => Promise<any>) | undefined;
+    const server = {
+      server: {
+        registerCapabilities: vi.fn(),
+        setRequestHandler: vi.fn((_schema, h) => {
+          handler = h;
+        }),
+      },
+    };
+    createArgumentCompletions(root)(server as any, {} as any);
+
+    const result = await handler!({
+      method: "completion/complete",
+      params: {
+        ref: { type: "ref/tool", name: "lsp_rename_symbol" },
+        argument: { name: "symbolName", value: "Cli" },
+      },
+    });
+
+    expect(server.server.registerCapabilities).toHaveBeenCalledWith({
+      completions: {},
+    });
+    expect(result).toEqual({
+      completion: { values: ["Client"], total: 1, hasMore: false },
+    });
+  });
+});
@@ -0,0 +1,192 @@
+/**
+ * Argument completion (completion/complete)
+ *
+ * Completes file paths from the workspace, symbol names from the index and
+ * preset (adapter) names from the registry. MCP only defines completion for
+ * prompt arguments and resource template variables, so besides
+ * `ref/resource` for lsmcp's own templates this also answers
+ * `{ type: "ref/tool", name }` for tool arguments, going by the argument name
+ * (relativePath, symbolName, ...).
+ */
+
+import { readdir } from "fs/promises";
+import { isAbsolute, relative, resolve } from "path";
+import { z } from "zod";
+import { querySymbols } from "@internal/code-indexer";
+import { globalPresetRegistry } from "../config/loader.ts";
+import { FILE_DIAGNOSTICS_URI_TEMPLATE } from "./diagnosticsResources.ts";
+import { OUTLINE_URI_TEMPLATE, SYMBOLS_URI_TEMPLATE } from "./indexResources.ts";
+import type { ResourceSetup } from "./mcpServerHelpers.ts";
+
+/** Most values a completion may return (MCP limit) */
+export const MAX_COMPLETIONS = 100;
+
+export type CompletionKind = "path" | "symbol" | "preset";
+
+/** Tool arguments completed by name */
+export const TOOL_ARGUMENT_COMPLETIONS: Record<string, CompletionKind> = {
+  relativePath: "path",
+  filePath: "path",
+  file: "path",
+  symbolName: "symbol",
+  textTarget: "symbol",
+  containerName: "symbol",
+  name: "symbol",
+  query: "symbol",
+  preset: "preset",
+};
+
+/** Template variables completed per resource template */
+const RESOURCE_COMPLETIONS: Record<string, Record<string, CompletionKind>> = {
+  [SYMBOLS_URI_TEMPLATE]: { query: "symbol" },
+  [OUTLINE_URI_TEMPLATE]: { path: "path" },
+  [FILE_DIAGNOSTICS_URI_TEMPLATE]: { path: "path" },
+};
+
+/** Directories never offered as path completions */
+const SKIPPED_DIRECTORIES = new Set([".git", "node_modules"]);
+
+/**
+ * Entries of the directory `value` points into, relative to root;
+ * directories end with "/"
+ */
+export async function completeFilePath(
+  root: string,
+  value: string,
+): Promise<string[]> {
+  const slash = value.lastIndexOf("/");
+  const directory = value.slice(0, slash + 1);
+  const prefix = value.slice(slash + 1);
+  const absolute = resolve(root, directory);
+  const fromRoot = relative(root, absolute);
+  if (isAbsolute(value) || fromRoot.startsWith("..")) {
+    return [];
+  }
+
+  let entries;
+  try {
+    entries = await readdir(absolute, { withFileTypes: true });
+  } catch {
+    return [];
+  }
+  return entries
+    .filter(
+      (entry) =>
+        entry.name.startsWith(prefix) &&
+        (prefix.startsWith(".") || !entry.name.startsWith(".")) &&
+        !(entry.isDirectory() && SKIPPED_DIRECTORIES.has(entry.name)),
+    )
+    .map((entry) => directory + entry.name + (entry.isDirectory() ? "/" : ""))
+    .sort();
+}
+
+/**
+ * Indexed symbol names containing `value`, names starting with it first
+ */
+export function completeSymbolName(root: string, value: string): string[] {
+  const needle = value.toLowerCase();
+  const names = new Set<string>();
+  for (const symbol of querySymbols(root, {})) {
+    if (symbol.name.toLowerCase().includes(needle)) {
+      names.add(symbol.name);
+    }
+  }
+  const rank = (name: string) =>
+    name.startsWith(value) ? 0 : name.toLowerCase().startsWith(needle) ? 1 : 2;
+  return [...names].sort((a, b) => rank(a) - rank(b) || a.localeCompare(b));
+}
+
+/**
+ * Preset ids from the registry starting with `value`
+ */
+export function completePresetName(value: string): string[] {
+  return globalPresetRegistry
+    .list()
+    .map((preset) => preset.presetId)
+    .filter((id) => id.startsWith(value))
+    .sort();
+}
+
+export async function completeValue(
+  kind: CompletionKind,
+  value: string,
+  root: string,
+): Promise<string[]> {
+  switch (kind) {
+    case "path":
+      return completeFilePath(root, value);
+    case "symbol":
+      return completeSymbolName(root, value);
+    case "preset":
+      return completePresetName(value);
+  }
+}
+
+/**
+ * completion/complete with lsmcp's `ref/tool` reference besides the standard
+ * ones; other references are answered with no values
+ */
+const CompleteArgumentRequestSchema = z.object({
+  method: z.literal("completion/complete"),
+  params: z.object({
+    ref: z
+      .object({
+        type: z.string(),
+        name: z.string().optional(),
+        uri: z.string().optional(),
+      })
+      .passthrough(),
+    argument: z.object({ name: z.string(), value: z.string() }),
+    context: z
+      .object({ arguments: z.record(z.string()).optional() })
+      .optional(),
+  }),
+});
+
+type CompleteArgumentRequest = z.infer<typeof CompleteArgumentRequestSchema>;
+
+export async function completeArgument(
+  params: CompleteArgumentRequest["params"],
+  root: string,
+): Promise<string[]> {
+  const { ref, argument } = params;
+  const kind =
+    ref.type === "ref/tool"
+      ? TOOL_ARGUMENT_COMPLETIONS[argument.name]
+      : ref.type === "ref/resource" && ref.uri
+        ? RESOURCE_COMPLETIONS[ref.uri]?.[argument.name]
+        : undefined;
+  if (!kind) {
+    return [];
+  }
+  // Tools resolve paths against their root argument
+  const argumentRoot = params.context?.arguments?.root;
+  return completeValue(
+    kind,
+    argument.value,
+    argumentRoot ? resolve(root, argumentRoot) : root,
+  );
+}
+
+/**
+ * Answer completion/complete on a server. Register it after the resources:
+ * it replaces the handler McpServer installs for resource templates.
+ */
+export function createArgumentCompletions(root: string): ResourceSetup {
+  return (server) => {
+    server.server.registerCapabilities({ completions: {} });
+    server.server.setRequestHandler(
+      CompleteArgumentRequestSchema,
+      async (request) => {
+        const values = await completeArgument(request.params, root);
+        return {
+          completion: {
+            values: values.slice(0, MAX_COMPLETIONS),
+            total: values.length,
+            hasMore: values.length > MAX_COMPLETIONS,
+          },
+        };
+      },
+    );
+  };
+}
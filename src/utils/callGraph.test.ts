@@ -0,0 +1,78 @@
+import { describe, it, expect } from "vitest";
+import {
+  addCallEdge,
+  createCallGraph,
+  renderDot,
+  renderMermaid,
+  type CallGraph,
+} from "./callGraph.ts";
+
+function sampleGraph(): CallGraph {
+  const graph = createCallGraph();
+  graph.nodes.set("main", {
+    id: "main",
+    name: "main",
+    kind: "Function",
+    relativePath: "cmd/app/main.go",
+    line: 10,
+  });
+  graph.nodes.set("process", {
+    id: "process",
+    name: "processUsers",
+    kind: "Function",
+    relativePath: "internal/users.go",
+    line: 4,
+  });
+  graph.nodes.set("save", {
+    id: "save",
+    name: 'Store.Save"v2"',
+    kind: "Method",
+    relativePath: "internal/store.go",
+    line: 22,
+  });
+  graph.roots.push("main");
+  addCallEdge(graph, "main", "process");
+  addCallEdge(graph, "process", "save");
+  addCallEdge(graph, "process", "save");
+  return graph;
+}
+
+describe("addCallEdge", () => {
+  it("should not duplicate edges", () => {
+    expect(sampleGraph().edges).toEqual([
+      { from: "main", to: "process" },
+      { from: "process", to: "save" },
+    ]);
+  });
+});
+
+describe("renderMermaid", () => {
+  it("should render a flowchart with escaped labels", () => {
+    expect(renderMermaid(sampleGraph())).toBe(
+      [
+        "flowchart LR",
+        '  n0["main<br/>cmd/app/main.go:10"]',
+        '  n1["processUsers<br/>internal/users.go:4"]',
+        '  n2["Store.Save#quot;v2#quot;<br/>internal/store.go:22"]',
+        "  n0 --> n1",
+        "  n1 --> n2",
+        "  style n0 stroke-width:3px",
+      ].join("\n"),
+    );
+  });
+});
+
+describe("renderDot", () => {
+  it("should render a digraph with escaped labels", () => {
+    const dot = renderDot(sampleGraph());
+    expect(dot).toContain("digraph calls {");
+    expect(dot).toContain(
+      '  n0 [label="main\\ncmd/app/main.go:10", penwidth=2];',
+    );
+    expect(dot).toContain(
+      '  n2 [label="Store.Save\\"v2\\"\\ninternal/store.go:22"];',
+    );
+    expect(dot).toContain("  n0 -> n1;");
+    expect(dot.endsWith("}")).toBe(true);
+  });
+});
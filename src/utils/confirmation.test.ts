@@ -0,0 +1,147 @@
+import { describe, it, expect, vi } from "vitest";
+import {
+  confirmOperation,
+  createConfirmer,
+  DEFAULT_CONFIRMATION_POLICY,
+  formatConfirmation,
+  needsConfirmation,
+} from "./confirmation.ts";
+
+const rename = {
+  kind: "edit" as const,
+  title: 'rename of "x" to "count"',
+  files: ["src/a.ts", "src/b.ts"],
+};
+
+describe("needsConfirmation", () => {
+  it("should ask for edits of more files than the threshold", () => {
+    expect(needsConfirmation(DEFAULT_CONFIRMATION_POLICY, rename)).toBe(true);
+    expect(
+      needsConfirmation(
+        { ...DEFAULT_CONFIRMATION_POLICY, autoApproveFiles: 3 },
+        rename,
+      ),
+    ).toBe(false);
+  });
+
+  it("should always ask for deletes and commands unless turned off", () => {
+    const policy = DEFAULT_CONFIRMATION_POLICY;
+    const deletion = { kind: "delete" as const, title: "x", files: ["a.ts"] };
+    const command = { kind: "command" as const, title: "x", files: [] };
+
+    expect(needsConfirmation(policy, deletion)).toBe(true);
+    expect(needsConfirmation(policy, command)).toBe(true);
+    expect(needsConfirmation({ ...policy, deletes: false }, deletion)).toBe(
+      false,
+    );
+    expect(
+      needsConfirmation({ ...policy, executeCommand: false }, command),
+    ).toBe(false);
+    expect(needsConfirmation({ ...policy, enabled: false }, rename)).toBe(
+      false,
+    );
+  });
+});
+
+describe("formatConfirmation", () => {
+  it("should list the files", () => {
+    expect(formatConfirmation(rename)).toBe(
+      'Rename of "x" to "count" changes 2 file(s):\n  src/a.ts\n  src/b.ts\nApply?',
+    );
+  });
+
+  it("should ask to allow operations without files", () => {
+    expect(
+      formatConfirmation({
+        kind: "command",
+        title: "running gopls.tidy",
+        files: [],
+      }),
+    ).toBe("Allow running gopls.tidy?");
+  });
+});
+
+describe("createConfirmer", () => {
+  it("should send elicitation/create and read the answer", async () => {
+    const sendRequest = vi.fn(async () => ({
+      action: "accept",
+      content: { confirm: true },
+    }));
+    const confirm = createConfirmer({ sendRequest }, { elicitation: {} });
+
+    expect(await confirm!("Apply?")).toBe(true);
+    expect(sendRequest).toHaveBeenCalledWith(
+      expect.objectContaining({
+        method: "elicitation/create",
+        params: expect.objectContaining({ message: "Apply?" }),
+      }),
+      expect.anything(),
+      expect.objectContaining({ timeout: expect.any(Number) }),
+    );
+  });
+
+  it("should treat decline and an unchecked box as no", async () => {
+    const declined = createConfirmer(
+      { sendRequest: async () => ({ action: "decline" }) },
+      { elicitation: {} },
+    );
+    const unchecked = createConfirmer(
+      {
+        sendRequest: async () => ({
+          action: "accept",
+          content: { confirm: false },
+        }),
+      },
+      { elicitation: {} },
+    );
+
+    expect(await declined!("Apply?")).toBe(false);
+    expect(await unchecked!("Apply?")).toBe(false);
+  });
+
+  it("should not confirm when the client lacks elicitation", () => {
+    expect(
+      createConfirmer({ sendRequest: vi.fn() }, { sampling: {} }),
+    ).toBeUndefined();
+  });
+});
+
+describe("confirmOperation", () => {
+  it("should proceed without asking below the threshold", async () => {
+    const confirm = vi.fn();
+
+    await confirmOperation({ confirm } as any, { ...rename, files: ["a.ts"] });
+
+    expect(confirm).not.toHaveBeenCalled();
+  });
+
+  it("should throw when the user declines", async () => {
+    await expect(
+      confirmOperation({ confirm: async () => false } as any, rename),
+    ).rejects.toThrow('Not applied: the user declined rename of "x"');
+  });
+
+  it("should throw when the question times out", async () => {
+    await expect(
+      confirmOperation(
+        {
+          confirm: async () => {
+            throw new Error("Request timed out");
+          },
+        } as any,
+        rename,
+      ),
+    ).rejects.toThrow("could not be confirmed (Request timed out)");
+  });
+
+  it("should use the configured policy", async () => {
+    const confirm = vi.fn();
+
+    await confirmOperation(
+      { confirm, config: { confirmations: { autoApproveFiles: 5 } } } as any,
+      rename,
+    );
+
+    expect(confirm).not.toHaveBeenCalled();
+  });
+});
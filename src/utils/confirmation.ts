@@ -0,0 +1,169 @@
+/**
+ * Confirmation of destructive operations through MCP elicitation
+ *
+ * Before a multi-file edit, a delete or workspace/executeCommand, tools ask
+ * the user with elicitation/create when the client supports it. The
+ * `confirmations` config decides what is asked; clients without elicitation
+ * keep relying on their own tool approval.
+ */
+
+import { z, type ZodType } from "zod";
+import type { McpContext } from "@internal/types";
+
+/** How long the user has to answer */
+export const CONFIRMATION_TIMEOUT_MS = 5 * 60 * 1000;
+
+/** Files listed in the question at most */
+const MAX_LISTED_FILES = 20;
+
+export interface ConfirmationPolicy {
+  enabled: boolean;
+  /** Edits of at most this many files apply without asking */
+  autoApproveFiles: number;
+  deletes: boolean;
+  executeCommand: boolean;
+}
+
+export const DEFAULT_CONFIRMATION_POLICY: ConfirmationPolicy = {
+  enabled: true,
+  autoApproveFiles: 1,
+  deletes: true,
+  executeCommand: true,
+};
+
+export function confirmationPolicy(
+  config?: Record<string, unknown>,
+): ConfirmationPolicy {
+  const configured = config?.confirmations as
+    | Partial<ConfirmationPolicy>
+    | undefined;
+  return { ...DEFAULT_CONFIRMATION_POLICY, ...configured };
+}
+
+/** An operation to confirm */
+export interface DestructiveOperation {
+  kind: "edit" | "delete" | "command";
+  /** What happens, e.g. `rename "x" to "count"` */
+  title: string;
+  /** Files it changes, relative to the root */
+  files: string[];
+}
+
+export function needsConfirmation(
+  policy: ConfirmationPolicy,
+  operation: DestructiveOperation,
+): boolean {
+  if (!policy.enabled) {
+    return false;
+  }
+  switch (operation.kind) {
+    case "command":
+      return policy.executeCommand;
+    case "delete":
+      return (
+        policy.deletes || operation.files.length > policy.autoApproveFiles
+      );
+    case "edit":
+      return operation.files.length > policy.autoApproveFiles;
+  }
+}
+
+export function formatConfirmation(operation: DestructiveOperation): string {
+  const { title, files } = operation;
+  if (files.length === 0) {
+    return `Allow ${title}?`;
+  }
+  const lines = [
+    `${title[0].toUpperCase()}${title.slice(1)} changes ${files.length} file(s):`,
+    ...files.slice(0, MAX_LISTED_FILES).map((file) => `  ${file}`),
+  ];
+  if (files.length > MAX_LISTED_FILES) {
+    lines.push(`  ... and ${files.length - MAX_LISTED_FILES} more`);
+  }
+  lines.push("Apply?");
+  return lines.join("\n");
+}
+
+/**
+ * Subset of the MCP SDK request handler extra used to send requests
+ */
+export interface ElicitationRequestExtra {
+  sendRequest?: (
+    request: { method: "elicitation/create"; params: unknown },
+    resultSchema: ZodType,
+    options?: { timeout?: number },
+  ) => Promise<unknown>;
+}
+
+const ElicitResultSchema = z
+  .object({
+    action: z.enum(["accept", "decline", "cancel"]),
+    content: z.record(z.unknown()).optional(),
+  })
+  .passthrough();
+
+const CONFIRM_SCHEMA = {
+  type: "object",
+  properties: {
+    confirm: {
+      type: "boolean",
+      title: "Apply",
+      description: "Apply the change",
+      default: true,
+    },
+  },
+  required: ["confirm"],
+};
+
+/**
+ * Create the confirm function of a tool call.
+ * Returns undefined when the client does not support elicitation.
+ */
+export function createConfirmer(
+  extra: ElicitationRequestExtra | undefined,
+  clientCapabilities: Record<string, unknown> | undefined,
+): ((message: string) => Promise<boolean>) | undefined {
+  const sendRequest = extra?.sendRequest;
+  if (!clientCapabilities?.elicitation || !sendRequest) {
+    return undefined;
+  }
+  return async (message) => {
+    const result = (await sendRequest(
+      {
+        method: "elicitation/create",
+        params: { message, requestedSchema: CONFIRM_SCHEMA },
+      },
+      ElicitResultSchema,
+      { timeout: CONFIRMATION_TIMEOUT_MS },
+    )) as z.infer<typeof ElicitResultSchema>;
+    return result.action === "accept" && result.content?.confirm !== false;
+  };
+}
+
+/**
+ * Ask the user before a destructive operation when the policy says so
+ * @throws when the user declines or does not answer
+ */
+export async function confirmOperation(
+  context: McpContext | undefined,
+  operation: DestructiveOperation,
+): Promise<void> {
+  if (
+    !context?.confirm ||
+    !needsConfirmation(confirmationPolicy(context.config), operation)
+  ) {
+    return;
+  }
+  let confirmed: boolean;
+  try {
+    confirmed = await context.confirm(formatConfirmation(operation));
+  } catch (error) {
+    const reason = error instanceof Error ? error.message : String(error);
+    throw new Error(
+      `Not applied: ${operation.title} could not be confirmed (${reason})`,
+    );
+  }
+  if (!confirmed) {
+    throw new Error(`Not applied: the user declined ${operation.title}`);
+  }
+}
@@ -0,0 +1,69 @@
+import { describe, it, expect } from "vitest";
+import {
+  decodeCursor,
+  encodeCursor,
+  formatPageInfo,
+  paginateResults,
+  queryFingerprint,
+} from "./cursor.ts";
+
+describe("queryFingerprint", () => {
+  it("should ignore key order and undefined values", () => {
+    expect(queryFingerprint("t", { a: 1, b: "x", c: undefined })).toBe(
+      queryFingerprint("t", { b: "x", a: 1 }),
+    );
+    expect(queryFingerprint("t", { a: 1 })).not.toBe(
+      queryFingerprint("other", { a: 1 }),
+    );
+  });
+});
+
+describe("decodeCursor", () => {
+  const fingerprint = queryFingerprint("t", { q: "Handler" });
+
+  it("should round-trip an offset", () => {
+    expect(decodeCursor(encodeCursor(40, fingerprint), fingerprint)).toBe(40);
+  });
+
+  it("should reject malformed cursors and cursors of another query", () => {
+    expect(() => decodeCursor("not a cursor", fingerprint)).toThrow(
+      /Invalid cursor/,
+    );
+    expect(() =>
+      decodeCursor(encodeCursor(10, "other"), fingerprint),
+    ).toThrow(/different query/);
+  });
+});
+
+describe("paginateResults", () => {
+  const items = Array.from({ length: 25 }, (_, i) => i);
+  const fingerprint = queryFingerprint("t", {});
+
+  it("should hand out cursors until the last page", () => {
+    const first = paginateResults(items, { limit: 10, fingerprint });
+    expect(first.items).toEqual([0, 1, 2, 3, 4, 5, 6, 7, 8, 9]);
+    expect(formatPageInfo(first)).toBe(
+      `Showing 1-10 of 25. Next page: cursor: "${first.nextCursor}"`,
+    );
+
+    const second = paginateResults(items, {
+      limit: 10,
+      cursor: first.nextCursor,
+      fingerprint,
+    });
+    const last = paginateResults(items, {
+      limit: 10,
+      cursor: second.nextCursor,
+      fingerprint,
+    });
+    expect(last.items).toEqual([20, 21, 22, 23, 24]);
+    expect(last.nextCursor).toBeUndefined();
+    expect(formatPageInfo(last)).toBe("Showing 21-25 of 25.");
+  });
+
+  it("should say nothing when everything fits", () => {
+    const page = paginateResults(items, { limit: 50, fingerprint });
+    expect(page.nextCursor).toBeUndefined();
+    expect(formatPageInfo(page)).toBe("");
+  });
+});
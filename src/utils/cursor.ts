@@ -0,0 +1,124 @@
+/**
+ * Opaque pagination cursors
+ *
+ * A cursor records where the next page starts together with a fingerprint of
+ * the query it belongs to, so a cursor passed with different arguments is
+ * rejected instead of silently skipping results.
+ */
+
+import { createHash } from "crypto";
+
+interface CursorState {
+  /** Offset of the next page */
+  o: number;
+  /** Query fingerprint */
+  q: string;
+}
+
+export interface ResultPage<T> {
+  items: T[];
+  /** Offset of the first item */
+  offset: number;
+  total: number;
+  /** Cursor for the next page; undefined on the last page */
+  nextCursor?: string;
+}
+
+export interface PageOptions {
+  /** Cursor from a previous page; wins over offset */
+  cursor?: string;
+  offset?: number;
+  limit: number;
+  fingerprint: string;
+}
+
+function stableStringify(value: unknown): string {
+  if (Array.isArray(value)) {
+    return `[${value.map(stableStringify).join(",")}]`;
+  }
+  if (value && typeof value === "object") {
+    const record = value as Record<string, unknown>;
+    const entries = Object.keys(record)
+      .filter((key) => record[key] !== undefined)
+      .sort()
+      .map((key) => `${JSON.stringify(key)}:${stableStringify(record[key])}`);
+    return `{${entries.join(",")}}`;
+  }
+  return JSON.stringify(value) ?? "null";
+}
+
+/**
+ * Fingerprint of a tool's query; leave paging arguments (cursor, limit,
+ * offset) out so a page size change keeps the cursor valid
+ */
+export function queryFingerprint(tool: string, query: unknown): string {
+  return createHash("sha256")
+    .update(`${tool}\0${stableStringify(query)}`)
+    .digest("base64url")
+    .slice(0, 12);
+}
+
+export function encodeCursor(offset: number, fingerprint: string): string {
+  const state: CursorState = { o: offset, q: fingerprint };
+  return Buffer.from(JSON.stringify(state)).toString("base64url");
+}
+
+/**
+ * Offset a cursor points at
+ * @throws for malformed cursors and cursors of another query
+ */
+export function decodeCursor(cursor: string, fingerprint: string): number {
+  let state: Partial<CursorState>;
+  try {
+    state = JSON.parse(Buffer.from(cursor, "base64url").toString("utf-8"));
+  } catch {
+    throw new Error(`Invalid cursor '${cursor}'`);
+  }
+  if (!Number.isInteger(state.o) || state.o! < 0) {
+    throw new Error(`Invalid cursor '${cursor}'`);
+  }
+  if (state.q !== fingerprint) {
+    throw new Error(
+      "Cursor belongs to a different query; repeat the call with the same arguments or drop the cursor to start over",
+    );
+  }
+  return state.o!;
+}
+
+/**
+ * Slice one page out of a stable, ordered result list
+ */
+export function paginateResults<T>(
+  items: T[],
+  options: PageOptions,
+): ResultPage<T> {
+  const offset = options.cursor
+    ? decodeCursor(options.cursor, options.fingerprint)
+    : (options.offset ?? 0);
+  const end = offset + options.limit;
+  return {
+    items: items.slice(offset, end),
+    offset,
+    total: items.length,
+    nextCursor:
+      end < items.length ? encodeCursor(end, options.fingerprint) : undefined,
+  };
+}
+
+/**
+ * "Showing 51-100 of 240. Next page: cursor: "…"" for a partial page, or an
+ * empty string when everything fits
+ */
+export function formatPageInfo(page: ResultPage<unknown>): string {
+  if (page.total === 0 || (page.offset === 0 && !page.nextCursor)) {
+    return "";
+  }
+  if (page.items.length === 0) {
+    return `No results after position ${page.offset} of ${page.total}.`;
+  }
+  let info = `Showing ${page.offset + 1}-${page.offset + page.items.length} of ${page.total}.`;
+  if (page.nextCursor) {
+    info += ` Next page: cursor: "${page.nextCursor}"`;
+  }
+  return info;
+}
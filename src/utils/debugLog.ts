@@ -4,6 +4,7 @@
  * This file now serves as a compatibility layer that delegates to the MCP logger.
  * Use MCP_DEBUG=1 or LSMCP_DEBUG=1 to enable debug logging for MCP server.
  * Use LSP_DEBUG=1 to enable debug logging for LSP client (handled separately).
+ * `--log-level` / LSMCP_LOG_LEVEL set levels per scope (see structuredLog.ts).
  *
  * IMPORTANT: For MCP servers, all debug output must go to stderr (console.error)
  * since stdout is used for MCP protocol communication.
@@ -0,0 +1,223 @@
+import { describe, it, expect } from "vitest";
+import {
+  cargoDependencies,
+  goDependencies,
+  npmDependencies,
+  parseCargoLock,
+  parseGoModFile,
+  parseGoSum,
+  parsePackageLock,
+} from "./dependencyManifests.ts";
+
+const GO_MOD = `module example.com/app
+
+go 1.22
+toolchain go1.22.3
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/sys v0.20.0 // indirect
+	example.com/shared v0.3.0
+)
+
+replace example.com/shared => ../shared
+
+replace (
+	golang.org/x/sys v0.20.0 => golang.org/x/sys v0.21.0
+)
+
+exclude github.com/old/lib v1.0.0
+`;
+
+const GO_SUM = `github.com/spf13/cobra v1.8.0 h1:abc=
+github.com/spf13/cobra v1.8.0/go.mod h1:def=
+golang.org/x/sys v0.21.0 h1:ghi=
+`;
+
+describe("parseGoModFile", () => {
+  it("should read single-line and block directives", () => {
+    const goMod = parseGoModFile(GO_MOD);
+
+    expect(goMod.module).toBe("example.com/app");
+    expect(goMod.go).toBe("1.22");
+    expect(goMod.toolchain).toBe("go1.22.3");
+    expect(goMod.requires).toEqual([
+      { path: "github.com/spf13/cobra", version: "v1.8.0", indirect: false },
+      {
+        path: "github.com/stretchr/testify",
+        version: "v1.9.0",
+        indirect: false,
+      },
+      { path: "golang.org/x/sys", version: "v0.20.0", indirect: true },
+      { path: "example.com/shared", version: "v0.3.0", indirect: false },
+    ]);
+    expect(goMod.replaces).toEqual([
+      {
+        old: "example.com/shared",
+        oldVersion: undefined,
+        new: "../shared",
+        newVersion: undefined,
+      },
+      {
+        old: "golang.org/x/sys",
+        oldVersion: "v0.20.0",
+        new: "golang.org/x/sys",
+        newVersion: "v0.21.0",
+      },
+    ]);
+    expect(goMod.excludes).toEqual([
+      { path: "github.com/old/lib", version: "v1.0.0" },
+    ]);
+  });
+});
+
+describe("goDependencies", () => {
+  it("should apply replacements and check go.sum", () => {
+    const dependencies = goDependencies(
+      parseGoModFile(GO_MOD),
+      parseGoSum(GO_SUM),
+    );
+    const byName = new Map(dependencies.map((d) => [d.name, d]));
+
+    expect(byName.get("github.com/spf13/cobra")).toMatchObject({
+      resolved: "v1.8.0",
+      missingChecksum: undefined,
+    });
+    expect(byName.get("github.com/stretchr/testify")?.missingChecksum).toBe(
+      true,
+    );
+    expect(byName.get("example.com/shared")).toMatchObject({
+      replacedBy: "../shared",
+      resolved: undefined,
+    });
+    expect(byName.get("golang.org/x/sys")).toMatchObject({
+      indirect: true,
+      replacedBy: "golang.org/x/sys v0.21.0",
+      resolved: "v0.21.0",
+    });
+  });
+});
+
+describe("npmDependencies", () => {
+  it("should group dependencies and use lockfile versions", () => {
+    const locked = parsePackageLock({
+      packages: {
+        "": { name: "app" },
+        "node_modules/zod": { version: "3.23.8" },
+        "node_modules/@types/node": { version: "20.11.0" },
+        "node_modules/zod/node_modules/nested": { version: "1.0.0" },
+      },
+    });
+    expect(Array.from(locked.keys())).toEqual(["zod", "@types/node"]);
+
+    const info = npmDependencies(
+      {
+        name: "app",
+        dependencies: { zod: "^3.22.0" },
+        devDependencies: { "@types/node": "^20.0.0", vitest: "^1.0.0" },
+        peerDependencies: { zod: "^3.0.0" },
+        overrides: { vitest: "1.6.0" },
+      },
+      (name) => locked.get(name),
+    );
+
+    expect(info.name).toBe("app");
+    expect(info.dependencies).toEqual([
+      {
+        name: "zod",
+        requested: "^3.22.0",
+        resolved: "3.23.8",
+        indirect: false,
+        group: undefined,
+        replacedBy: undefined,
+      },
+      {
+        name: "@types/node",
+        requested: "^20.0.0",
+        resolved: "20.11.0",
+        indirect: false,
+        group: "dev",
+        replacedBy: undefined,
+      },
+      {
+        name: "vitest",
+        requested: "^1.0.0",
+        resolved: undefined,
+        indirect: false,
+        group: "dev",
+        replacedBy: "1.6.0",
+      },
+    ]);
+  });
+});
+
+describe("cargoDependencies", () => {
+  const CARGO_TOML = `[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+anyhow = "1" # errors
+local-util = { path = "../util" }
+
+[dependencies.tokio]
+version = "1.37"
+features = ["full"]
+
+[dev-dependencies]
+insta = "1.38"
+
+[target.'cfg(unix)'.dependencies]
+libc = "0.2"
+
+[patch.crates-io]
+serde = { git = "https://github.com/serde-rs/serde", branch = "master" }
+`;
+
+  const CARGO_LOCK = `version = 3
+
+[[package]]
+name = "anyhow"
+version = "1.0.82"
+
+[[package]]
+name = "serde"
+version = "1.0.200"
+
+[[package]]
+name = "itoa"
+version = "1.0.11"
+
+[[package]]
+name = "app"
+version = "0.1.0"
+`;
+
+  it("should read dependency tables, patches and the lock file", () => {
+    const info = cargoDependencies(CARGO_TOML, parseCargoLock(CARGO_LOCK));
+    const byName = new Map(info.dependencies.map((d) => [d.name, d]));
+
+    expect(info.name).toBe("app");
+    expect(byName.get("serde")).toMatchObject({
+      requested: "1.0",
+      resolved: "1.0.200",
+      replacedBy: "https://github.com/serde-rs/serde master",
+    });
+    expect(byName.get("anyhow")).toMatchObject({
+      requested: "1",
+      resolved: "1.0.82",
+    });
+    expect(byName.get("local-util")?.requested).toBe("../util");
+    expect(byName.get("tokio")?.requested).toBe("1.37");
+    expect(byName.get("insta")?.group).toBe("dev");
+    expect(byName.get("libc")?.group).toBeUndefined();
+    expect(byName.get("itoa")).toMatchObject({
+      indirect: true,
+      resolved: "1.0.11",
+    });
+    expect(byName.has("app")).toBe(false);
+  });
+});
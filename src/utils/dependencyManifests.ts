@@ -0,0 +1,410 @@
+/**
+ * Parsers for dependency manifests and lock files
+ * go.mod/go.sum, package.json with package-lock.json, Cargo.toml with
+ * Cargo.lock. Only the parts needed to list dependencies are understood.
+ */
+
+export interface ManifestDependency {
+  name: string;
+  /** Version or range written in the manifest */
+  requested: string;
+  /** Version actually selected (lock file, go.sum, node_modules) */
+  resolved?: string;
+  indirect: boolean;
+  /** dev, build, peer, optional; undefined for normal dependencies */
+  group?: string;
+  /** Replace/patch target, e.g. "../local/fork" or "github.com/fork/x v1.2.0" */
+  replacedBy?: string;
+  /** Go only: go.sum exists but has no checksum for this version */
+  missingChecksum?: boolean;
+}
+
+export interface GoModFile {
+  module: string;
+  go?: string;
+  toolchain?: string;
+  requires: { path: string; version: string; indirect: boolean }[];
+  replaces: {
+    old: string;
+    oldVersion?: string;
+    new: string;
+    newVersion?: string;
+  }[];
+  excludes: { path: string; version: string }[];
+}
+
+function stripGoComment(line: string): string {
+  const index = line.indexOf("//");
+  return (index === -1 ? line : line.slice(0, index)).trim();
+}
+
+function unquote(value: string): string {
+  return value.replace(/^"(.*)"$/, "$1").replace(/^`(.*)`$/, "$1");
+}
+
+/**
+ * Parse go.mod, including single-line and block forms of each directive
+ */
+export function parseGoModFile(content: string): GoModFile {
+  const result: GoModFile = {
+    module: "",
+    requires: [],
+    replaces: [],
+    excludes: [],
+  };
+  let block: string | null = null;
+
+  const apply = (directive: string, rest: string, rawLine: string) => {
+    const args = stripGoComment(rest)
+      .split(/\s+/)
+      .filter(Boolean)
+      .map(unquote);
+    switch (directive) {
+      case "module":
+        result.module = args[0] ?? "";
+        break;
+      case "go":
+        result.go = args[0];
+        break;
+      case "toolchain":
+        result.toolchain = args[0];
+        break;
+      case "require":
+        if (args.length >= 2) {
+          result.requires.push({
+            path: args[0],
+            version: args[1],
+            indirect: /\/\/\s*indirect\b/.test(rawLine),
+          });
+        }
+        break;
+      case "exclude":
+        if (args.length >= 2) {
+          result.excludes.push({ path: args[0], version: args[1] });
+        }
+        break;
+      case "replace": {
+        const arrow = args.indexOf("=>");
+        if (arrow <= 0 || arrow === args.length - 1) break;
+        const [old, oldVersion] = args.slice(0, arrow);
+        const [target, newVersion] = args.slice(arrow + 1);
+        result.replaces.push({ old, oldVersion, new: target, newVersion });
+        break;
+      }
+    }
+  };
+
+  for (const rawLine of content.split("\n")) {
+    const line = rawLine.trim();
+    if (!line || line.startsWith("//")) continue;
+
+    if (block) {
+      if (line === ")") {
+        block = null;
+      } else {
+        apply(block, line, rawLine);
+      }
+      continue;
+    }
+
+    const match = /^(\w+)\s*(.*)$/.exec(line);
+    if (!match) continue;
+    const [, directive, rest] = match;
+    if (rest.trim() === "(") {
+      block = directive;
+    } else {
+      apply(directive, rest, rawLine);
+    }
+  }
+  return result;
+}
+
+/**
+ * Module versions with a checksum in go.sum (path -> versions)
+ */
+export function parseGoSum(content: string): Map<string, Set<string>> {
+  const sums = new Map<string, Set<string>>();
+  for (const line of content.split("\n")) {
+    const [path, version] = line.trim().split(/\s+/);
+    if (!path || !version) continue;
+    const moduleVersion = version.replace(/\/go\.mod$/, "");
+    if (!sums.has(path)) {
+      sums.set(path, new Set());
+    }
+    sums.get(path)!.add(moduleVersion);
+  }
+  return sums;
+}
+
+export function goDependencies(
+  goMod: GoModFile,
+  goSum?: Map<string, Set<string>>,
+): ManifestDependency[] {
+  return goMod.requires.map((require) => {
+    const replace = goMod.replaces.find(
+      (r) =>
+        r.old === require.path &&
+        (!r.oldVersion || r.oldVersion === require.version),
+    );
+    const replacedBy = replace
+      ? [replace.new, replace.newVersion].filter(Boolean).join(" ")
+      : undefined;
+    // A local directory replacement has no version; a module replacement
+    // resolves to the replacement version
+    const resolved = replace ? replace.newVersion : require.version;
+    const missingChecksum =
+      goSum !== undefined &&
+      !replace &&
+      !goSum.get(require.path)?.has(require.version);
+    return {
+      name: require.path,
+      requested: require.version,
+      resolved,
+      indirect: require.indirect,
+      replacedBy,
+      missingChecksum: missingChecksum || undefined,
+    };
+  });
+}
+
+const NPM_GROUPS: [string, string | undefined][] = [
+  ["dependencies", undefined],
+  ["devDependencies", "dev"],
+  ["peerDependencies", "peer"],
+  ["optionalDependencies", "optional"],
+];
+
+export interface PackageJsonInfo {
+  name?: string;
+  dependencies: ManifestDependency[];
+}
+
+/**
+ * overrides (npm), resolutions (yarn) and pnpm.overrides merged
+ */
+export function npmOverrides(
+  packageJson: Record<string, any>,
+): Record<string, unknown> {
+  return {
+    ...(packageJson.overrides ?? {}),
+    ...(packageJson.resolutions ?? {}),
+    ...(packageJson.pnpm?.overrides ?? {}),
+  };
+}
+
+/**
+ * Direct dependencies from package.json; resolved versions come from
+ * lockfile "packages" entries or installed package.json files
+ */
+export function npmDependencies(
+  packageJson: Record<string, any>,
+  resolveVersion: (name: string) => string | undefined,
+): PackageJsonInfo {
+  const overrides = npmOverrides(packageJson);
+  const dependencies: ManifestDependency[] = [];
+  const seen = new Set<string>();
+
+  for (const [field, group] of NPM_GROUPS) {
+    const entries = packageJson[field] as Record<string, string> | undefined;
+    for (const [name, requested] of Object.entries(entries ?? {})) {
+      // peer deps usually repeat a devDependency; keep the first occurrence
+      if (seen.has(name)) continue;
+      seen.add(name);
+      const override = overrides[name];
+      dependencies.push({
+        name,
+        requested,
+        resolved: resolveVersion(name),
+        indirect: false,
+        group,
+        replacedBy: typeof override === "string" ? override : undefined,
+      });
+    }
+  }
+  return { name: packageJson.name, dependencies };
+}
+
+/**
+ * Installed versions from package-lock.json (lockfileVersion 2/3)
+ */
+export function parsePackageLock(
+  lock: Record<string, any>,
+): Map<string, string> {
+  const versions = new Map<string, string>();
+  for (const [path, entry] of Object.entries(lock.packages ?? {})) {
+    const match = /^node_modules\/((?:@[^/]+\/)?[^/]+)$/.exec(path);
+    if (match && (entry as any)?.version) {
+      versions.set(match[1], (entry as any).version);
+    }
+  }
+  return versions;
+}
+
+type TomlTable = Record<string, string | Record<string, string>>;
+
+function parseTomlValue(raw: string): string | Record<string, string> {
+  const value = raw.trim();
+  if (value.startsWith("{")) {
+    const table: Record<string, string> = {};
+    const inner = value.replace(/^\{|\}$/g, "");
+    for (const match of inner.matchAll(
+      /(\w[\w-]*)\s*=\s*("(?:[^"\\]|\\.)*"|'[^']*'|\[[^\]]*\]|true|false|[\w.+-]+)/g,
+    )) {
+      table[match[1]] = match[2].replace(/^["']|["']$/g, "");
+    }
+    return table;
+  }
+  return value.replace(/\s+#.*$/, "").replace(/^["']|["']$/g, "");
+}
+
+/**
+ * Minimal TOML reader: [section] and [section.sub] headers with key = value
+ * pairs (strings and inline tables). Enough for Cargo.toml dependency tables.
+ */
+export function parseTomlSections(content: string): Map<string, TomlTable> {
+  const sections = new Map<string, TomlTable>();
+  let current: TomlTable | null = null;
+
+  for (const rawLine of content.split("\n")) {
+    const line = rawLine.trim();
+    if (!line || line.startsWith("#")) continue;
+
+    const header = /^\[([^[\]]+)\]$/.exec(line);
+    if (header) {
+      const name = header[1].trim().replace(/["']/g, "");
+      current = sections.get(name) ?? {};
+      sections.set(name, current);
+      continue;
+    }
+    if (line.startsWith("[[")) {
+      // Arrays of tables ([[bin]], [[package]]) are not dependency tables
+      current = null;
+      continue;
+    }
+
+    const pair = /^("?[\w.-]+"?)\s*=\s*(.+)$/.exec(line);
+    if (pair && current) {
+      current[pair[1].replace(/"/g, "")] = parseTomlValue(pair[2]);
+    }
+  }
+  return sections;
+}
+
+function cargoGroup(section: string): string | undefined | null {
+  const table = section.replace(/^target\.[^.]+(?:\([^)]*\))?\./, "");
+  if (table === "dependencies") return undefined;
+  if (table === "dev-dependencies") return "dev";
+  if (table === "build-dependencies") return "build";
+  return null;
+}
+
+function describeCargoSource(
+  spec: string | Record<string, string>,
+): string | undefined {
+  if (typeof spec === "string") return undefined;
+  if (spec.path) return spec.path;
+  if (spec.git) {
+    return [spec.git, spec.branch ?? spec.tag ?? spec.rev]
+      .filter(Boolean)
+      .join(" ");
+  }
+  return undefined;
+}
+
+/**
+ * Package versions from Cargo.lock ([[package]] name/version pairs)
+ */
+export function parseCargoLock(content: string): Map<string, string[]> {
+  const versions = new Map<string, string[]>();
+  let name: string | undefined;
+  for (const rawLine of content.split("\n")) {
+    const line = rawLine.trim();
+    if (line === "[[package]]") {
+      name = undefined;
+      continue;
+    }
+    const match = /^(name|version)\s*=\s*"([^"]*)"/.exec(line);
+    if (!match) continue;
+    if (match[1] === "name") {
+      name = match[2];
+    } else if (name) {
+      versions.set(name, [...(versions.get(name) ?? []), match[2]]);
+    }
+  }
+  return versions;
+}
+
+export function cargoDependencies(
+  cargoToml: string,
+  lock?: Map<string, string[]>,
+): { name?: string; dependencies: ManifestDependency[] } {
+  const sections = parseTomlSections(cargoToml);
+  const dependencies: ManifestDependency[] = [];
+  const patches = new Map<string, string>();
+
+  for (const [section, table] of sections) {
+    if (!section.startsWith("patch.")) continue;
+    for (const [name, spec] of Object.entries(table)) {
+      const source = describeCargoSource(spec);
+      if (source) {
+        patches.set(name, source);
+      }
+    }
+  }
+
+  const add = (
+    name: string,
+    spec: string | Record<string, string>,
+    group: string | undefined,
+  ) => {
+    const crate = typeof spec === "string" ? name : (spec.package ?? name);
+    const requested =
+      typeof spec === "string"
+        ? spec
+        : (spec.version ?? describeCargoSource(spec) ?? "*");
+    dependencies.push({
+      name,
+      requested,
+      resolved: lock?.get(crate)?.[0],
+      indirect: false,
+      group,
+      replacedBy: patches.get(crate),
+    });
+  };
+
+  for (const [section, table] of sections) {
+    // [dependencies.serde] style tables
+    const dotted = /^(.*dependencies)\.([\w-]+)$/.exec(section);
+    if (dotted) {
+      const group = cargoGroup(dotted[1]);
+      if (group !== null) {
+        add(dotted[2], table as Record<string, string>, group);
+      }
+      continue;
+    }
+    const group = cargoGroup(section);
+    if (group === null) continue;
+    for (const [name, spec] of Object.entries(table)) {
+      add(name, spec, group);
+    }
+  }
+
+  const packageName = sections.get("package")?.name;
+  if (lock) {
+    const direct = new Set(dependencies.map((d) => d.name));
+    for (const [name, versions] of lock) {
+      if (direct.has(name) || name === packageName) continue;
+      dependencies.push({
+        name,
+        requested: "",
+        resolved: versions.join(", "),
+        indirect: true,
+      });
+    }
+  }
+
+  return {
+    name: typeof packageName === "string" ? packageName : undefined,
+    dependencies,
+  };
+}
@@ -0,0 +1,156 @@
+import { describe, it, expect } from "vitest";
+import { mkdtemp, mkdir, rm, writeFile } from "fs/promises";
+import { tmpdir } from "os";
+import { join } from "path";
+import type { FileDiagnostic } from "../tools/lsp/allDiagnostics.ts";
+import {
+  applyBaseline,
+  baselinePath,
+  createBaseline,
+  filterBaselined,
+  loadBaseline,
+} from "./diagnosticsBaseline.ts";
+
+const at = (
+  line: number,
+  message: string,
+  severity: "error" | "warning" = "warning",
+): FileDiagnostic["diagnostics"][number] => ({
+  severity,
+  line,
+  column: 1,
+  endLine: line,
+  endColumn: 2,
+  message,
+  source: "ts",
+  code: 6133,
+});
+
+const files: FileDiagnostic[] = [
+  {
+    filePath: "src/b.ts",
+    diagnostics: [at(3, "'x' is declared but never used")],
+  },
+  {
+    filePath: "src/a.ts",
+    diagnostics: [
+      at(1, "'y' is declared but never used"),
+      at(9, "'y' is declared but never used"),
+    ],
+  },
+];
+
+describe("createBaseline", () => {
+  it("should count matching diagnostics per file", () => {
+    expect(createBaseline(files)).toEqual({
+      version: 1,
+      files: {
+        "src/a.ts": [
+          {
+            severity: "warning",
+            message: "'y' is declared but never used",
+            source: "ts",
+            code: 6133,
+            count: 2,
+          },
+        ],
+        "src/b.ts": [
+          {
+            severity: "warning",
+            message: "'x' is declared but never used",
+            source: "ts",
+            code: 6133,
+            count: 1,
+          },
+        ],
+      },
+    });
+  });
+});
+
+describe("filterBaselined", () => {
+  const baseline = createBaseline(files);
+
+  it("should match by fields, not position", () => {
+    const result = filterBaselined(baseline, "src/a.ts", [
+      at(20, "'y' is declared but never used"),
+      at(30, "'y' is declared but never used"),
+      at(31, "'y' is declared but never used"),
+      at(32, "'y' is declared but never used", "error"),
+    ]);
+
+    expect(result.suppressed).toBe(2);
+    expect(result.diagnostics.map((d) => d.line)).toEqual([31, 32]);
+  });
+
+  it("should match LSP diagnostics", () => {
+    const result = filterBaselined(baseline, "./src/b.ts", [
+      {
+        range: {
+          start: { line: 0, character: 0 },
+          end: { line: 0, character: 1 },
+        },
+        severity: 2,
+        source: "ts",
+        code: 6133,
+        message: "'x' is declared but never used",
+      },
+    ]);
+
+    expect(result).toEqual({ diagnostics: [], suppressed: 1 });
+  });
+
+  it("should drop files left without diagnostics", () => {
+    const result = applyBaseline(baseline, [
+      ...files,
+      { filePath: "src/c.ts", diagnostics: [at(1, "new problem")] },
+    ]);
+
+    expect(result.suppressed).toBe(3);
+    expect(result.files.map((f) => f.filePath)).toEqual(["src/c.ts"]);
+  });
+});
+
+describe("loadBaseline", () => {
+  it("should read the configured file", async () => {
+    const root = await mkdtemp(join(tmpdir(), "lsmcp-baseline-"));
+    try {
+      const config = { diagnosticsBaseline: { path: "ci/baseline.json" } };
+      expect(baselinePath(root, config)).toBe(join(root, "ci/baseline.json"));
+      expect(await loadBaseline(root, { config } as any)).toBeUndefined();
+
+      await mkdir(join(root, "ci"));
+      await writeFile(
+        join(root, "ci/baseline.json"),
+        JSON.stringify(createBaseline(files)),
+      );
+      expect(
+        (await loadBaseline(root, { config } as any))?.files["src/b.ts"],
+      ).toHaveLength(1);
+      const disabled = {
+        diagnosticsBaseline: { ...config.diagnosticsBaseline, enabled: false },
+      };
+      expect(
+        await loadBaseline(root, { config: disabled } as any),
+      ).toBeUndefined();
+    } finally {
+      await rm(root, { recursive: true, force: true });
+    }
+  });
+
+  it("should reject a file it cannot use", async () => {
+    const root = await mkdtemp(join(tmpdir(), "lsmcp-baseline-"));
+    try {
+      await mkdir(join(root, ".lsmcp"));
+      await writeFile(
+        join(root, ".lsmcp", "diagnostics-baseline.json"),
+        JSON.stringify({ version: 2, files: {} }),
+      );
+      await expect(loadBaseline(root)).rejects.toThrow(
+        /Unsupported diagnostics baseline/,
+      );
+    } finally {
+      await rm(root, { recursive: true, force: true });
+    }
+  });
+});
@@ -0,0 +1,231 @@
+/**
+ * Diagnostics baseline
+ *
+ * `lsmcp baseline` records the diagnostics the workspace has right now in
+ * .lsmcp/diagnostics-baseline.json, and the diagnostics tools leave those out
+ * of their results. An agent working in a codebase with thousands of old
+ * warnings then only sees the problems its own changes introduce. Entries are
+ * matched per file by severity, source, code and message rather than
+ * position, like the diagnostics delta, so edits that move lines do not bring
+ * them back; a file that gains another copy of a recorded problem shows it.
+ */
+
+import { readFile, stat } from "fs/promises";
+import { resolve, sep } from "path";
+import type { McpContext } from "@internal/types";
+import type { FileDiagnostic } from "../tools/lsp/allDiagnostics.ts";
+
+export const DEFAULT_BASELINE_PATH = ".lsmcp/diagnostics-baseline.json";
+
+export const BASELINE_VERSION = 1;
+
+type Severity = FileDiagnostic["diagnostics"][number]["severity"];
+
+export interface BaselineEntry {
+  severity: Severity;
+  message: string;
+  source?: string;
+  code?: string | number;
+  /** How many diagnostics of the file have these fields */
+  count: number;
+}
+
+export interface DiagnosticsBaseline {
+  version: typeof BASELINE_VERSION;
+  /** Entries by path relative to the project root, with "/" */
+  files: Record<string, BaselineEntry[]>;
+}
+
+/** A file or LSP diagnostic (numeric severity) */
+interface MatchedDiagnostic {
+  severity?: Severity | number;
+  message: string;
+  source?: string;
+  code?: string | number;
+}
+
+const SEVERITIES: Record<number, Severity> = {
+  1: "error",
+  2: "warning",
+  3: "information",
+  4: "hint",
+};
+
+function entryKey(diagnostic: MatchedDiagnostic): string {
+  const severity =
+    typeof diagnostic.severity === "string"
+      ? diagnostic.severity
+      : (SEVERITIES[diagnostic.severity ?? 2] ?? "warning");
+  return [
+    severity,
+    diagnostic.source ?? "",
+    diagnostic.code ?? "",
+    diagnostic.message,
+  ].join("\0");
+}
+
+function toPosix(filePath: string): string {
+  return filePath.split(sep).join("/").replace(/^\.\//, "");
+}
+
+export function createBaseline(files: FileDiagnostic[]): DiagnosticsBaseline {
+  const baseline: DiagnosticsBaseline = {
+    version: BASELINE_VERSION,
+    files: {},
+  };
+  const sorted = [...files].sort((a, b) =>
+    a.filePath.localeCompare(b.filePath),
+  );
+  for (const file of sorted) {
+    const entries = new Map<string, BaselineEntry>();
+    for (const diagnostic of file.diagnostics) {
+      const key = entryKey(diagnostic);
+      const entry = entries.get(key);
+      if (entry) {
+        entry.count++;
+        continue;
+      }
+      entries.set(key, {
+        severity: diagnostic.severity,
+        message: diagnostic.message,
+        ...(diagnostic.source !== undefined && { source: diagnostic.source }),
+        ...(diagnostic.code !== undefined && { code: diagnostic.code }),
+        count: 1,
+      });
+    }
+    if (entries.size > 0) {
+      baseline.files[toPosix(file.filePath)] = [...entries.values()];
+    }
+  }
+  return baseline;
+}
+
+/**
+ * Diagnostics of one file the baseline does not cover
+ */
+export function filterBaselined<T extends MatchedDiagnostic>(
+  baseline: DiagnosticsBaseline,
+  filePath: string,
+  diagnostics: T[],
+): { diagnostics: T[]; suppressed: number } {
+  const entries = baseline.files[toPosix(filePath)];
+  if (!entries) {
+    return { diagnostics, suppressed: 0 };
+  }
+  const remaining = new Map<string, number>();
+  for (const entry of entries) {
+    const key = entryKey(entry);
+    remaining.set(key, (remaining.get(key) ?? 0) + entry.count);
+  }
+  const kept = diagnostics.filter((diagnostic) => {
+    const key = entryKey(diagnostic);
+    const left = remaining.get(key) ?? 0;
+    if (left === 0) {
+      return true;
+    }
+    remaining.set(key, left - 1);
+    return false;
+  });
+  return { diagnostics: kept, suppressed: diagnostics.length - kept.length };
+}
+
+/**
+ * Workspace diagnostics the baseline does not cover; files left without
+ * diagnostics are dropped
+ */
+export function applyBaseline(
+  baseline: DiagnosticsBaseline,
+  files: FileDiagnostic[],
+): { files: FileDiagnostic[]; suppressed: number } {
+  let suppressed = 0;
+  const kept: FileDiagnostic[] = [];
+  for (const file of files) {
+    const filtered = filterBaselined(baseline, file.filePath, file.diagnostics);
+    suppressed += filtered.suppressed;
+    if (filtered.diagnostics.length > 0) {
+      kept.push({ ...file, diagnostics: filtered.diagnostics });
+    }
+  }
+  return { files: kept, suppressed };
+}
+
+export interface DiagnosticsBaselineConfig {
+  enabled?: boolean;
+  /** Relative to the project root */
+  path?: string;
+}
+
+/**
+ * Absolute path of the baseline file, or undefined when disabled
+ */
+export function baselinePath(
+  root: string,
+  config?: { diagnosticsBaseline?: DiagnosticsBaselineConfig },
+): string | undefined {
+  const configured = config?.diagnosticsBaseline;
+  if (configured?.enabled === false) {
+    return undefined;
+  }
+  return resolve(root, configured?.path ?? DEFAULT_BASELINE_PATH);
+}
+
+const loaded = new Map<
+  string,
+  { mtimeMs: number; baseline: DiagnosticsBaseline }
+>();
+
+/**
+ * The baseline of the workspace, or undefined when there is none; reread
+ * when the file changes
+ */
+export async function loadBaseline(
+  root: string,
+  context?: McpContext,
+): Promise<DiagnosticsBaseline | undefined> {
+  const path = baselinePath(
+    root,
+    context?.config as { diagnosticsBaseline?: DiagnosticsBaselineConfig },
+  );
+  if (!path) {
+    return undefined;
+  }
+  let mtimeMs: number;
+  try {
+    ({ mtimeMs } = await stat(path));
+  } catch {
+    return undefined;
+  }
+  const cached = loaded.get(path);
+  if (cached?.mtimeMs === mtimeMs) {
+    return cached.baseline;
+  }
+
+  let baseline: DiagnosticsBaseline;
+  try {
+    baseline = JSON.parse(await readFile(path, "utf-8"));
+  } catch (error) {
+    throw new Error(
+      `Failed to read diagnostics baseline ${path}: ${
+        error instanceof Error ? error.message : String(error)
+      }. Regenerate it with \`lsmcp baseline\`.`,
+    );
+  }
+  if (
+    baseline?.version !== BASELINE_VERSION ||
+    typeof baseline.files !== "object" ||
+    baseline.files === null
+  ) {
+    throw new Error(
+      `Unsupported diagnostics baseline ${path}. Regenerate it with \`lsmcp baseline\`.`,
+    );
+  }
+  loaded.set(path, { mtimeMs, baseline });
+  return baseline;
+}
+
+/** Line appended to results that left diagnostics out */
+export function baselineNote(suppressed: number): string {
+  return `Hidden ${suppressed} diagnostic${
+    suppressed !== 1 ? "s" : ""
+  } recorded in the baseline; pass includeBaseline: true to see them`;
+}
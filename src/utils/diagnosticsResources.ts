@@ -0,0 +1,234 @@
+/**
+ * Diagnostics as MCP resources
+ *
+ * lsmcp://diagnostics summarizes the diagnostics the language server has
+ * published for the workspace and lsmcp://diagnostics/{+path} holds those of
+ * one file (JSON). Both follow textDocument/publishDiagnostics: subscribers
+ * are notified whenever new diagnostics arrive, so clients can react instead
+ * of polling get_diagnostics. Secrets quoted in messages are redacted as in
+ * tool results.
+ */
+
+import { ResourceTemplate } from "@modelcontextprotocol/sdk/server/mcp.js";
+import { readFile } from "fs/promises";
+import { isAbsolute, relative, resolve, sep } from "path";
+import { fileURLToPath, pathToFileURL } from "url";
+import {
+  getLanguageIdFromPath,
+  waitForDiagnosticsWithRetry,
+  type LSPClient,
+} from "@internal/lsp-client";
+import type { Diagnostic, PublishDiagnosticsParams } from "@internal/types";
+import {
+  summarizeFileDiagnostics,
+  toFileDiagnostics,
+} from "../tools/lsp/allDiagnostics.ts";
+import {
+  decodeUriVariable,
+  pathResourceUri,
+  type ResourceSetup,
+} from "./mcpServerHelpers.ts";
+import { redact } from "./redaction.ts";
+
+export const WORKSPACE_DIAGNOSTICS_URI = "lsmcp://diagnostics";
+export const FILE_DIAGNOSTICS_URI_TEMPLATE = "lsmcp://diagnostics/{+path}";
+
+const FILE_DIAGNOSTICS_PREFIX = "lsmcp://diagnostics/";
+
+/** How long a read waits for a file nobody has diagnostics for yet */
+const READ_TIMEOUT_MS = 5000;
+
+export function fileDiagnosticsUri(relativePath: string): string {
+  return pathResourceUri(FILE_DIAGNOSTICS_PREFIX, relativePath);
+}
+
+/** Path of a file URI relative to root, or undefined outside it */
+function relativeToRoot(root: string, uri: string): string | undefined {
+  if (!uri.startsWith("file:")) {
+    return undefined;
+  }
+  const path = relative(root, fileURLToPath(uri));
+  return path && !path.startsWith("..") && !isAbsolute(path)
+    ? path.split(sep).join("/")
+    : undefined;
+}
+
+/**
+ * Latest diagnostics per document, as published by the language server
+ */
+export class PublishedDiagnostics {
+  private diagnostics = new Map<string, Diagnostic[]>();
+  private listeners = new Set<(uri: string) => void>();
+
+  constructor(client: Pick<LSPClient, "on">) {
+    client.on("diagnostics", (params: PublishDiagnosticsParams) => {
+      this.diagnostics.set(params.uri, params.diagnostics ?? []);
+      for (const listener of this.listeners) {
+        listener(params.uri);
+      }
+    });
+  }
+
+  get(uri: string): Diagnostic[] | undefined {
+    return this.diagnostics.get(uri);
+  }
+
+  /** Remember diagnostics read on demand (pull servers publish nothing) */
+  set(uri: string, diagnostics: Diagnostic[]): void {
+    this.diagnostics.set(uri, diagnostics);
+  }
+
+  entries(): [string, Diagnostic[]][] {
+    return [...this.diagnostics];
+  }
+
+  /** Returns a function that stops listening */
+  onPublish(listener: (uri: string) => void): () => void {
+    this.listeners.add(listener);
+    return () => this.listeners.delete(listener);
+  }
+}
+
+export function readWorkspaceDiagnostics(
+  root: string,
+  published: PublishedDiagnostics,
+): string {
+  const files = published.entries().flatMap(([uri, diagnostics]) => {
+    const filePath = relativeToRoot(root, uri);
+    const converted = toFileDiagnostics(diagnostics);
+    return filePath && converted.length > 0
+      ? [{ filePath, diagnostics: converted }]
+      : [];
+  });
+  return redact(JSON.stringify(summarizeFileDiagnostics(files), null, 2));
+}
+
+/**
+ * Diagnostics of one file; a file without published diagnostics is opened
+ * and waited for
+ */
+export async function readFileDiagnostics(
+  root: string,
+  relativePath: string,
+  client: LSPClient,
+  published: PublishedDiagnostics,
+): Promise<string> {
+  if (
+    !relativePath ||
+    isAbsolute(relativePath) ||
+    relativePath.split("/").includes("..")
+  ) {
+    throw new Error(`Invalid diagnostics path: ${relativePath}`);
+  }
+  const absolutePath = resolve(root, relativePath);
+  const uri = pathToFileURL(absolutePath).toString();
+
+  let diagnostics = published.get(uri);
+  if (!diagnostics) {
+    const content = await readFile(absolutePath, "utf-8");
+    const wasOpen = client.isDocumentOpen(uri);
+    try {
+      diagnostics = await waitForDiagnosticsWithRetry(
+        client,
+        uri,
+        content,
+        getLanguageIdFromPath(relativePath) || undefined,
+        { timeout: READ_TIMEOUT_MS },
+      );
+    } finally {
+      if (!wasOpen && client.isDocumentOpen(uri)) {
+        client.closeDocument(uri);
+      }
+    }
+    published.set(uri, diagnostics);
+  }
+  return redact(
+    JSON.stringify(
+      { filePath: relativePath, diagnostics: toFileDiagnostics(diagnostics) },
+      null,
+      2,
+    ),
+  );
+}
+
+/**
+ * Register the diagnostics resources for `root` on a server
+ */
+export function createDiagnosticsResources(
+  root: string,
+  client: LSPClient,
+): ResourceSetup {
+  // One store for every session; diagnostics come from the shared server
+  const published = new PublishedDiagnostics(client);
+
+  return (server, subscriptions) => {
+    server.resource(
+      "diagnostics",
+      WORKSPACE_DIAGNOSTICS_URI,
+      {
+        description:
+          "Errors and warnings the language server has published for the workspace, with totals, as JSON",
+        mimeType: "application/json",
+      },
+      async (uri) => ({
+        contents: [
+          {
+            uri: uri.href,
+            mimeType: "application/json",
+            text: readWorkspaceDiagnostics(root, published),
+          },
+        ],
+      }),
+    );
+    server.resource(
+      "file-diagnostics",
+      new ResourceTemplate(FILE_DIAGNOSTICS_URI_TEMPLATE, {
+        // Files that currently have diagnostics
+        list: async () => ({
+          resources: published.entries().flatMap(([uri, diagnostics]) => {
+            const filePath = relativeToRoot(root, uri);
+            return filePath && diagnostics.length > 0
+              ? [
+                  {
+                    uri: fileDiagnosticsUri(filePath),
+                    name: filePath,
+                    mimeType: "application/json",
+                  },
+                ]
+              : [];
+          }),
+        }),
+      }),
+      {
+        description:
+          "Diagnostics of a file (path relative to the root), as JSON",
+        mimeType: "application/json",
+      },
+      async (uri, { path }) => ({
+        contents: [
+          {
+            uri: uri.href,
+            mimeType: "application/json",
+            text: await readFileDiagnostics(
+              root,
+              decodeUriVariable(path),
+              client,
+              published,
+            ),
+          },
+        ],
+      }),
+    );
+
+    const stop = published.onPublish((uri) => {
+      const filePath = relativeToRoot(root, uri);
+      if (filePath !== undefined) {
+        subscriptions.updated([
+          WORKSPACE_DIAGNOSTICS_URI,
+          fileDiagnosticsUri(filePath),
+        ]);
+      }
+    });
+    subscriptions.onClose(stop);
+  };
+}
@@ -0,0 +1,176 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import { DiagnosticsWatcher } from "./diagnosticsWatcher.ts";
+import {
+  currentDiagnosticsResult,
+  formatWatchStatus,
+} from "../tools/highlevel/currentDiagnostics.ts";
+
+const error = (message: string, line = 0) => ({
+  range: {
+    start: { line, character: 0 },
+    end: { line, character: 1 },
+  },
+  message,
+  severity: 1,
+});
+
+function createClient(diagnostics: Map<string, ReturnType<typeof error>[]>) {
+  const listeners: ((params: any) => void)[] = [];
+  return {
+    on: vi.fn((_event: string, listener: (params: any) => void) => {
+      listeners.push(listener);
+    }),
+    off: vi.fn(),
+    publish: (params: any) => listeners.forEach((l) => l(params)),
+    // Open documents are read without opening or closing them
+    isDocumentOpen: vi.fn().mockReturnValue(true),
+    getDiagnostics: vi.fn((uri: string) => {
+      const file = [...diagnostics.keys()].find((f) => uri.endsWith(f));
+      return file ? diagnostics.get(file) : [];
+    }),
+  } as any;
+}
+
+describe("DiagnosticsWatcher", () => {
+  let root: string;
+  let watcher: DiagnosticsWatcher | undefined;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-watch-"));
+    mkdirSync(join(root, "src"));
+    mkdirSync(join(root, "node_modules"));
+    writeFileSync(join(root, "src", "a.ts"), "export const a = 1;\n");
+    writeFileSync(join(root, "src", "b.ts"), "export const b = 2;\n");
+  });
+
+  afterEach(() => {
+    watcher?.stop();
+    rmSync(root, { recursive: true, force: true });
+  });
+
+  it("should check every file once at startup", async () => {
+    const client = createClient(new Map([["b.ts", [error("unused", 2)]]]));
+    watcher = new DiagnosticsWatcher(root, client, { patterns: ["**/*.ts"] });
+
+    expect(await watcher.start()).toBe(true);
+    await watcher.idle();
+
+    const watched = watcher.current();
+    expect(watched.checkedFiles).toBe(2);
+    expect(watched.totalFiles).toBe(2);
+    expect(watched.sweeping).toBe(false);
+    expect(watched.files).toEqual([
+      {
+        filePath: "src/b.ts",
+        diagnostics: [expect.objectContaining({ line: 3, message: "unused" })],
+      },
+    ]);
+  });
+
+  it("should check changed files and drop deleted ones", async () => {
+    const diagnostics = new Map([["b.ts", [error("unused", 2)]]]);
+    const client = createClient(diagnostics);
+    watcher = new DiagnosticsWatcher(root, client, {
+      patterns: ["**/*.ts"],
+      delay: 0,
+    });
+    await watcher.start();
+    await watcher.idle();
+
+    diagnostics.set("a.ts", [error("type mismatch")]);
+    rmSync(join(root, "src", "b.ts"));
+    (watcher as any).handleChange("src/a.ts");
+    (watcher as any).handleChange("src/b.ts");
+    await watcher.settle();
+    await watcher.idle();
+
+    const watched = watcher.current();
+    expect(watched.totalFiles).toBe(1);
+    expect(watched.files.map((f) => f.filePath)).toEqual(["src/a.ts"]);
+  });
+
+  it("should take diagnostics the server publishes", async () => {
+    const client = createClient(new Map());
+    watcher = new DiagnosticsWatcher(root, client, { patterns: ["**/*.ts"] });
+    await watcher.start();
+    await watcher.idle();
+
+    client.publish({
+      uri: pathToFileURL(join(root, "src", "a.ts")).toString(),
+      diagnostics: [error("cannot find name 'x'")],
+    });
+    client.publish({
+      uri: pathToFileURL(join(root, "other.ts")).toString(),
+      diagnostics: [error("not tracked")],
+    });
+
+    expect(watcher.current().files.map((f) => f.filePath)).toEqual([
+      "src/a.ts",
+    ]);
+  });
+
+  it("should ignore files outside the patterns", () => {
+    watcher = new DiagnosticsWatcher(root, createClient(new Map()), {
+      patterns: ["**/*.ts"],
+      ignorePatterns: ["**/*.gen.ts"],
+    });
+
+    expect(watcher.shouldTrack("src/a.ts")).toBe(true);
+    expect(watcher.shouldTrack("src/a.gen.ts")).toBe(false);
+    expect(watcher.shouldTrack("node_modules/x/index.ts")).toBe(false);
+    expect(watcher.shouldTrack("README.md")).toBe(false);
+  });
+});
+
+describe("get_current_diagnostics", () => {
+  const watched = {
+    files: [
+      {
+        filePath: "src/a.ts",
+        diagnostics: [
+          { severity: "error" as const, line: 1, column: 1, message: "a" },
+          { severity: "warning" as const, line: 2, column: 1, message: "b" },
+        ],
+      },
+      {
+        filePath: "src/b.ts",
+        diagnostics: [
+          { severity: "warning" as const, line: 1, column: 1, message: "c" },
+        ],
+      },
+    ],
+    checkedFiles: 3,
+    totalFiles: 10,
+    updatedAt: Date.now(),
+    pendingFiles: 1,
+    sweeping: true,
+  };
+
+  it("should filter the cache by file and severity", () => {
+    const result = currentDiagnosticsResult(watched, {
+      relativePath: "./src/a.ts",
+      severityFilter: "error",
+    });
+
+    expect(result.method).toBe("watch");
+    expect(result.checkedFiles).toBe(3);
+    expect(result.totalErrors).toBe(1);
+    expect(result.totalWarnings).toBe(0);
+    expect(result.files.map((f) => f.filePath)).toEqual(["src/a.ts"]);
+  });
+
+  it("should say how current the cache is", () => {
+    expect(formatWatchStatus(watched)).toBe(
+      "Watch mode: updated 0s ago; 1 changed file(s) waiting to be checked; initial check at 3 of 10 files",
+    );
+    expect(
+      formatWatchStatus({ ...watched, updatedAt: undefined, checkedFiles: 10 }),
+    ).toBe(
+      "Watch mode: not checked yet; 1 changed file(s) waiting to be checked; re-checking the other files in the background",
+    );
+  });
+});
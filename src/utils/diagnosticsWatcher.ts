@@ -0,0 +1,351 @@
+/**
+ * Watch mode: workspace diagnostics kept current in the background
+ *
+ * With --watch, lsmcp checks every configured file once at startup and then
+ * re-checks files as they change on disk: the changed files right away, then
+ * the rest of the workspace, since a change can break or fix the files that
+ * depend on it. A new change interrupts that sweep and is checked first.
+ * Diagnostics the server publishes on its own update the cache as well.
+ * get_current_diagnostics answers from the cache without waiting for the
+ * language server.
+ */
+
+import { existsSync, watch, type FSWatcher } from "fs";
+import { isAbsolute, join, relative, resolve, sep } from "path";
+import { fileURLToPath, pathToFileURL } from "url";
+import { minimatch } from "minimatch";
+import {
+  debug,
+  supportsPullDiagnostics,
+  type LSPClient,
+} from "@internal/lsp-client";
+import type { Diagnostic, PublishDiagnosticsParams } from "@internal/types";
+import {
+  collectDiagnosticsForFiles,
+  getProjectFiles,
+  toFileDiagnostics,
+  type FileDiagnostic,
+} from "../tools/lsp/allDiagnostics.ts";
+import { DIAGNOSTICS_BATCH_SIZE } from "../constants/diagnostics.ts";
+
+export interface DiagnosticsWatcherOptions {
+  /** Glob patterns (relative to root) of the files to check */
+  patterns: string[];
+  /** Additional glob patterns to ignore */
+  ignorePatterns?: string[];
+  /** Debounce delay before changed files are checked (ms) */
+  delay?: number;
+  /** How long each file may take to report its diagnostics (ms) */
+  waitTimeout?: number;
+  /** Files checked at once */
+  concurrency?: number;
+}
+
+export interface WatchedDiagnostics {
+  /** Files with diagnostics, sorted by path */
+  files: FileDiagnostic[];
+  /** Files checked at least once */
+  checkedFiles: number;
+  /** Files watched */
+  totalFiles: number;
+  /** When the last check finished (ms since epoch) */
+  updatedAt?: number;
+  /** Changed files not checked yet */
+  pendingFiles: number;
+  /** The initial check or a sweep after a change is running */
+  sweeping: boolean;
+}
+
+// Directories that never contain checked sources
+const ALWAYS_IGNORED = ["**/.git/**", "**/.lsmcp/**", "**/node_modules/**"];
+
+const DEFAULT_DELAY_MS = 300;
+
+const DEFAULT_WAIT_TIMEOUT_MS = 3000;
+
+export class DiagnosticsWatcher {
+  private watcher: FSWatcher | null = null;
+  /** Tracked files, relative to root with "/" */
+  private files = new Set<string>();
+  private checked = new Set<string>();
+  private diagnostics = new Map<string, FileDiagnostic["diagnostics"]>();
+  private pending = new Set<string>();
+  private timer: NodeJS.Timeout | null = null;
+  private running: Promise<void> | null = null;
+  private sweepQueue: string[] = [];
+  /** Changed files taken from pending are being checked */
+  private checkingChanges = false;
+  private updatedAt?: number;
+  private settled: (() => void)[] = [];
+  private readonly ignorePatterns: string[];
+  private readonly onPublish = (params: PublishDiagnosticsParams) =>
+    this.handlePublish(params);
+
+  constructor(
+    private root: string,
+    private client: LSPClient,
+    private options: DiagnosticsWatcherOptions,
+  ) {
+    this.ignorePatterns = [
+      ...ALWAYS_IGNORED,
+      ...(options.ignorePatterns ?? []),
+    ];
+  }
+
+  /**
+   * Watch the root recursively and start the initial check
+   * Returns false if the platform does not support recursive watching
+   */
+  async start(): Promise<boolean> {
+    if (this.watcher) {
+      return true;
+    }
+    try {
+      this.watcher = watch(
+        this.root,
+        { recursive: true, persistent: false },
+        (_eventType, filename) => {
+          if (filename) {
+            this.handleChange(filename.toString());
+          }
+        },
+      );
+      this.watcher.on("error", (error) => {
+        debug(`[diagnosticsWatcher] Watcher error: ${error.message}`);
+      });
+    } catch (error) {
+      debug(
+        `[diagnosticsWatcher] Failed to start watcher: ${
+          error instanceof Error ? error.message : String(error)
+        }`,
+      );
+      this.watcher = null;
+      return false;
+    }
+    this.client.on("diagnostics", this.onPublish);
+
+    for (const pattern of this.options.patterns) {
+      for (const file of await getProjectFiles(this.root, pattern)) {
+        const normalized = file.split(sep).join("/");
+        if (this.shouldTrack(normalized)) {
+          this.files.add(normalized);
+        }
+      }
+    }
+    this.sweepQueue = [...this.files].sort();
+    this.run();
+    return true;
+  }
+
+  stop(): void {
+    if (this.timer) {
+      clearTimeout(this.timer);
+      this.timer = null;
+    }
+    this.client.off("diagnostics", this.onPublish);
+    this.watcher?.close();
+    this.watcher = null;
+    this.pending.clear();
+    this.sweepQueue = [];
+    this.resolveSettled();
+  }
+
+  /**
+   * Whether a path (relative to root, with "/") is checked
+   */
+  shouldTrack(relativePath: string): boolean {
+    const options = { dot: true };
+    if (this.ignorePatterns.some((p) => minimatch(relativePath, p, options))) {
+      return false;
+    }
+    return this.options.patterns.some((p) =>
+      minimatch(relativePath, p, options),
+    );
+  }
+
+  /** The cached diagnostics, instantly */
+  current(): WatchedDiagnostics {
+    const files = [...this.diagnostics]
+      .map(([filePath, diagnostics]) => ({ filePath, diagnostics }))
+      .sort((a, b) => a.filePath.localeCompare(b.filePath));
+    return {
+      files,
+      checkedFiles: this.checked.size,
+      totalFiles: this.files.size,
+      updatedAt: this.updatedAt,
+      pendingFiles: this.pending.size,
+      sweeping: this.sweepQueue.length > 0,
+    };
+  }
+
+  /**
+   * Resolves once the changed files seen so far have been checked (the
+   * sweep of the rest of the workspace may still be running)
+   */
+  async settle(): Promise<void> {
+    if (this.pending.size === 0 && !this.timer && !this.checkingChanges) {
+      return;
+    }
+    if (this.timer) {
+      clearTimeout(this.timer);
+      this.timer = null;
+      this.run();
+    }
+    await new Promise<void>((resolve) => this.settled.push(resolve));
+  }
+
+  /** Resolves when there is nothing left to check (tests) */
+  async idle(): Promise<void> {
+    while (this.running || this.timer) {
+      await (this.running ?? this.settle());
+    }
+  }
+
+  private handleChange(filename: string): void {
+    const relativePath = relative(this.root, resolve(this.root, filename))
+      .split(sep)
+      .join("/");
+    if (!this.shouldTrack(relativePath)) {
+      return;
+    }
+    this.pending.add(relativePath);
+    if (this.timer) {
+      clearTimeout(this.timer);
+    }
+    this.timer = setTimeout(() => {
+      this.timer = null;
+      this.run();
+    }, this.options.delay ?? DEFAULT_DELAY_MS);
+  }
+
+  private handlePublish(params: PublishDiagnosticsParams): void {
+    let filePath: string;
+    try {
+      filePath = relative(this.root, fileURLToPath(params.uri));
+    } catch {
+      return;
+    }
+    if (filePath.startsWith("..") || isAbsolute(filePath)) {
+      return;
+    }
+    filePath = filePath.split(sep).join("/");
+    if (this.files.has(filePath)) {
+      this.record(filePath, params.diagnostics ?? []);
+    }
+  }
+
+  private record(filePath: string, diagnostics: Diagnostic[]): void {
+    const converted = toFileDiagnostics(diagnostics);
+    if (converted.length > 0) {
+      this.diagnostics.set(filePath, converted);
+    } else {
+      this.diagnostics.delete(filePath);
+    }
+    this.checked.add(filePath);
+    this.updatedAt = Date.now();
+  }
+
+  /** Start checking unless a run is already going */
+  private run(): void {
+    if (this.running) {
+      return;
+    }
+    this.running = this.loop()
+      .catch((error) => {
+        debug(`[diagnosticsWatcher] Check failed: ${error}`);
+      })
+      .finally(() => {
+        this.running = null;
+        this.checkingChanges = false;
+        this.resolveSettled();
+      });
+  }
+
+  private resolveSettled(): void {
+    for (const resolve of this.settled.splice(0)) {
+      resolve();
+    }
+  }
+
+  private async loop(): Promise<void> {
+    const batchSize = this.options.concurrency ?? DIAGNOSTICS_BATCH_SIZE;
+    for (;;) {
+      if (this.pending.size > 0) {
+        const changed = [...this.pending];
+        this.pending.clear();
+        this.checkingChanges = true;
+        try {
+          await this.check(changed);
+        } finally {
+          this.checkingChanges = false;
+        }
+        if (this.pending.size === 0 && !this.timer) {
+          this.resolveSettled();
+        }
+        // Dependents may have changed too; check everything else again
+        const skip = new Set(changed);
+        this.sweepQueue = [...this.files].filter((file) => !skip.has(file));
+        continue;
+      }
+      if (this.sweepQueue.length === 0) {
+        return;
+      }
+      // Stop between batches when new changes arrive
+      await this.check(this.sweepQueue.splice(0, batchSize));
+    }
+  }
+
+  private async check(files: string[]): Promise<void> {
+    const closed: string[] = [];
+    for (const file of files) {
+      const absolutePath = join(this.root, file);
+      if (!existsSync(absolutePath)) {
+        this.files.delete(file);
+        this.checked.delete(file);
+        this.diagnostics.delete(file);
+        continue;
+      }
+      this.files.add(file);
+      const uri = pathToFileURL(absolutePath).toString();
+      if (!this.client.isDocumentOpen(uri)) {
+        closed.push(file);
+        continue;
+      }
+      // Open documents are kept current by the server, and closing them
+      // would pull them from under the session that opened them
+      let diagnostics = this.client.getDiagnostics(uri);
+      if (supportsPullDiagnostics(this.client)) {
+        diagnostics = await this.client
+          .pullDiagnostics(uri)
+          .catch(() => diagnostics);
+      }
+      this.record(file, diagnostics);
+    }
+    if (closed.length === 0) {
+      return;
+    }
+
+    const collected = await collectDiagnosticsForFiles(
+      this.root,
+      closed,
+      this.client,
+      {
+        concurrency: this.options.concurrency,
+        waitTimeout: this.options.waitTimeout ?? DEFAULT_WAIT_TIMEOUT_MS,
+      },
+    );
+    const found = new Map(
+      collected.files.map((file) => [file.filePath, file.diagnostics]),
+    );
+    for (const file of closed) {
+      const diagnostics = found.get(file);
+      if (diagnostics) {
+        this.diagnostics.set(file, diagnostics);
+      } else {
+        this.diagnostics.delete(file);
+      }
+      this.checked.add(file);
+    }
+    this.updatedAt = Date.now();
+  }
+}
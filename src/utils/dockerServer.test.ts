@@ -0,0 +1,98 @@
+import { describe, it, expect } from "vitest";
+import {
+  createPathRewriter,
+  dockerMounts,
+  dockerRunCommand,
+  translatePaths,
+} from "./dockerServer.ts";
+
+const mappings = dockerMounts(["/home/dev/app", "/home/dev/lib"]);
+
+describe("dockerMounts", () => {
+  it("should mount the first root at the mount path and number the rest", () => {
+    expect(mappings).toEqual([
+      { host: "/home/dev/app", container: "/workspace" },
+      { host: "/home/dev/lib", container: "/workspace-1" },
+    ]);
+  });
+});
+
+describe("translatePaths", () => {
+  it("should translate paths and URIs in both directions", () => {
+    const message =
+      '{"uri":"file:///home/dev/app/main.go","rootPath":"/home/dev/lib"}';
+
+    const inContainer = translatePaths(message, mappings, "toContainer");
+
+    expect(inContainer).toBe(
+      '{"uri":"file:///workspace/main.go","rootPath":"/workspace-1"}',
+    );
+    expect(translatePaths(inContainer, mappings, "toHost")).toBe(message);
+  });
+
+  it("should only match whole path segments", () => {
+    expect(
+      translatePaths("/home/dev/application/x.go", mappings, "toContainer"),
+    ).toBe("/home/dev/application/x.go");
+    expect(translatePaths("/workspace-10/x.go", mappings, "toHost")).toBe(
+      "/workspace-10/x.go",
+    );
+  });
+
+  it("should translate percent-encoded roots in URIs", () => {
+    const spaced = dockerMounts(["/home/dev/my app"]);
+
+    expect(
+      translatePaths("file:///home/dev/my%20app/main.c", spaced, "toContainer"),
+    ).toBe("file:///workspace/main.c");
+  });
+});
+
+describe("createPathRewriter", () => {
+  it("should clear the processId of the initialize request", () => {
+    const initialize = JSON.stringify({
+      jsonrpc: "2.0",
+      id: 1,
+      method: "initialize",
+      params: { processId: 1234, rootPath: "/home/dev/app" },
+    });
+
+    expect(
+      JSON.parse(createPathRewriter(mappings).toServer(initialize)).params,
+    ).toEqual({ processId: null, rootPath: "/workspace" });
+  });
+});
+
+describe("dockerRunCommand", () => {
+  it("should bind-mount the roots and translate server arguments", () => {
+    const command = dockerRunCommand(
+      { image: "lsmcp/clangd:18", args: ["--user", "1000:1000"] },
+      mappings,
+      "clangd",
+      ["--compile-commands-dir=/home/dev/app/build"],
+      { CLANGD_FLAGS: "--log=error", UNSET: undefined },
+    );
+
+    expect(command).toEqual({
+      command: "docker",
+      args: [
+        "run",
+        "-i",
+        "--rm",
+        "-v",
+        "/home/dev/app:/workspace",
+        "-v",
+        "/home/dev/lib:/workspace-1",
+        "-w",
+        "/workspace",
+        "-e",
+        "CLANGD_FLAGS=--log=error",
+        "--user",
+        "1000:1000",
+        "lsmcp/clangd:18",
+        "clangd",
+        "--compile-commands-dir=/workspace/build",
+      ],
+    });
+  });
+});
@@ -0,0 +1,132 @@
+/**
+ * Run a language server inside a container
+ *
+ * The server is started with `docker run -i` and the workspace roots are
+ * bind-mounted into the container. Paths and file URIs in LSP messages are
+ * translated between host and container, so tools keep working with host
+ * paths while the server only sees the container's file system.
+ */
+
+import { pathToFileURL } from "url";
+import type { MessageRewriter } from "@internal/lsp-client";
+import type { DockerOptions } from "../config/schema.ts";
+
+export interface PathMapping {
+  host: string;
+  container: string;
+}
+
+export const DEFAULT_CONTAINER_MOUNT = "/workspace";
+
+/**
+ * Container paths of the workspace roots: the first root is mounted at
+ * `mount`, further roots at `mount-1`, `mount-2`, ...
+ */
+export function dockerMounts(
+  roots: string[],
+  mount: string = DEFAULT_CONTAINER_MOUNT,
+): PathMapping[] {
+  const unique = [...new Set(roots.map((root) => root.replace(/\/+$/, "")))];
+  return unique.map((host, i) => ({
+    host,
+    container: i === 0 ? mount : `${mount}-${i}`,
+  }));
+}
+
+function escapeRegExp(text: string): string {
+  return text.replace(/[.*+?^${}()|[\]\\]/g, "\\$&");
+}
+
+/**
+ * Replace mapped path prefixes in a string (a path, a URI or a JSON message)
+ * Only whole path segments match, so /app does not rewrite /application.
+ */
+export function translatePaths(
+  text: string,
+  mappings: PathMapping[],
+  direction: "toContainer" | "toHost",
+): string {
+  const pairs = mappings.flatMap(({ host, container }) => {
+    if (direction === "toHost") {
+      return [[container, host]];
+    }
+    // URIs carry the percent-encoded form of paths with spaces etc.
+    const encoded = pathToFileURL(host).pathname;
+    return encoded === host
+      ? [[host, container]]
+      : [
+          [host, container],
+          [encoded, container],
+        ];
+  });
+  // Longest prefix first so nested mappings win
+  pairs.sort((a, b) => b[0].length - a[0].length);
+  for (const [from, to] of pairs) {
+    text = text.replace(
+      new RegExp(`${escapeRegExp(from)}(?=[/"?#\\\\]|$)`, "g"),
+      to,
+    );
+  }
+  return text;
+}
+
+export function createPathRewriter(mappings: PathMapping[]): MessageRewriter {
+  return {
+    toServer: (content) =>
+      translatePaths(withoutProcessId(content), mappings, "toContainer"),
+    fromServer: (content) => translatePaths(content, mappings, "toHost"),
+  };
+}
+
+/**
+ * Servers exit when the client's processId is not running, and lsmcp's pid
+ * does not exist inside the container
+ */
+function withoutProcessId(content: string): string {
+  if (!content.includes('"initialize"')) {
+    return content;
+  }
+  const message = JSON.parse(content);
+  if (message.method !== "initialize" || !message.params) {
+    return content;
+  }
+  return JSON.stringify({
+    ...message,
+    params: { ...message.params, processId: null },
+  });
+}
+
+/**
+ * `docker run` command line starting `command` in the configured image
+ */
+export function dockerRunCommand(
+  docker: DockerOptions,
+  mappings: PathMapping[],
+  command: string,
+  args: string[],
+  env: Record<string, string | undefined> = {},
+): { command: string; args: string[] } {
+  const volumes = mappings.flatMap(({ host, container }) => [
+    "-v",
+    `${host}:${container}`,
+  ]);
+  const variables = Object.entries(env)
+    .filter((entry): entry is [string, string] => entry[1] !== undefined)
+    .flatMap(([key, value]) => ["-e", `${key}=${value}`]);
+  return {
+    command: docker.command ?? "docker",
+    args: [
+      "run",
+      "-i",
+      "--rm",
+      ...volumes,
+      "-w",
+      mappings[0]?.container ?? DEFAULT_CONTAINER_MOUNT,
+      ...variables,
+      ...(docker.args ?? []),
+      docker.image,
+      command,
+      ...args.map((arg) => translatePaths(arg, mappings, "toContainer")),
+    ],
+  };
+}
@@ -0,0 +1,186 @@
+/**
+ * Summaries of oversized documentation
+ *
+ * Hover text and documentation from large libraries can exceed a whole token
+ * budget. With `summarizeDocumentation.enabled`, text over the threshold is
+ * summarized by the client's model (MCP sampling) and the full text is kept
+ * in memory, readable as lsmcp://documentation/{id}. Without sampling, or
+ * when the summary fails, tools return the full text as before. Secrets are
+ * redacted before the text reaches the model or the store.
+ */
+
+import { randomBytes } from "node:crypto";
+import { ResourceTemplate } from "@modelcontextprotocol/sdk/server/mcp.js";
+import type { McpContext } from "@internal/types";
+import { redact } from "./redaction.ts";
+import { estimateTokens } from "./tokenBudget.ts";
+import type { ResourceSetup } from "./mcpServerHelpers.ts";
+
+export const DOCUMENTATION_URI_TEMPLATE = "lsmcp://documentation/{id}";
+
+const DOCUMENTATION_PREFIX = "lsmcp://documentation/";
+
+/** Full texts kept for reading; the oldest are dropped first */
+const MAX_STORED_DOCUMENTS = 50;
+
+export interface SummaryPolicy {
+  enabled: boolean;
+  /** Text longer than this many tokens is summarized */
+  thresholdTokens: number;
+  maxSummaryTokens: number;
+}
+
+export const DEFAULT_SUMMARY_POLICY: SummaryPolicy = {
+  enabled: false,
+  thresholdTokens: 1000,
+  maxSummaryTokens: 300,
+};
+
+export function summaryPolicy(
+  config?: Record<string, unknown>,
+): SummaryPolicy {
+  const configured = config?.summarizeDocumentation as
+    | Partial<SummaryPolicy>
+    | undefined;
+  return { ...DEFAULT_SUMMARY_POLICY, ...configured };
+}
+
+interface StoredDocument {
+  title: string;
+  text: string;
+}
+
+/**
+ * Full texts of summarized documentation, by id
+ */
+export class DocumentationStore {
+  private documents = new Map<string, StoredDocument>();
+
+  constructor(private readonly capacity = MAX_STORED_DOCUMENTS) {}
+
+  /** Keep a text and return the URI to read it */
+  add(title: string, text: string): string {
+    const id = randomBytes(6).toString("hex");
+    this.documents.set(id, { title, text });
+    if (this.documents.size > this.capacity) {
+      const oldest = this.documents.keys().next().value;
+      if (oldest !== undefined) {
+        this.documents.delete(oldest);
+      }
+    }
+    return `${DOCUMENTATION_PREFIX}${id}`;
+  }
+
+  get(id: string): StoredDocument | undefined {
+    return this.documents.get(id);
+  }
+
+  entries(): [string, StoredDocument][] {
+    return [...this.documents];
+  }
+}
+
+/** Full texts of this process, shared by every session */
+export const documentationStore = new DocumentationStore();
+
+export interface DocumentationText {
+  text: string;
+  /** Set when text is a summary: where the full text can be read */
+  fullTextUri?: string;
+  /** Approximate tokens of the full text */
+  fullTokens?: number;
+}
+
+function summaryPrompt(title: string, text: string): string {
+  return [
+    `Summarize the following API documentation for ${title} for a developer working with the code.`,
+    "Keep the signature, what it does, the parameters, the return value and important caveats verbatim where possible; drop examples and boilerplate.",
+    "Answer with the summary only.",
+    "",
+    text,
+  ].join("\n");
+}
+
+/**
+ * Summarize documentation over the configured threshold
+ * @param title what the text documents, e.g. `"Marshal" at main.go:12:8`
+ */
+export async function summarizeDocumentation(
+  text: string,
+  title: string,
+  context: McpContext | undefined,
+  store: DocumentationStore = documentationStore,
+): Promise<DocumentationText> {
+  const policy = summaryPolicy(context?.config);
+  const fullTokens = estimateTokens(text);
+  if (
+    !policy.enabled ||
+    !context?.sample ||
+    fullTokens <= policy.thresholdTokens
+  ) {
+    return { text };
+  }
+  const [safeTitle, safeText] = [redact(title), redact(text)];
+  let summary: string;
+  try {
+    summary = await context.sample(
+      summaryPrompt(safeTitle, safeText),
+      policy.maxSummaryTokens,
+    );
+  } catch {
+    // Fall back to the full text; the token budget still applies
+    return { text };
+  }
+  return {
+    text: summary.trim(),
+    fullTextUri: store.add(safeTitle, safeText),
+    fullTokens,
+  };
+}
+
+/** Note telling where the full text of a summary is */
+export function summaryNote(documentation: DocumentationText): string {
+  return documentation.fullTextUri
+    ? `[Summarized from about ${documentation.fullTokens} tokens of documentation; read ${documentation.fullTextUri} for the full text]`
+    : "";
+}
+
+/**
+ * Register lsmcp://documentation/{id} on a server
+ */
+export function createDocumentationResources(
+  store: DocumentationStore = documentationStore,
+): ResourceSetup {
+  return (server) => {
+    server.resource(
+      "documentation",
+      new ResourceTemplate(DOCUMENTATION_URI_TEMPLATE, {
+        list: async () => ({
+          resources: store.entries().map(([id, document]) => ({
+            uri: `${DOCUMENTATION_PREFIX}${id}`,
+            name: document.title,
+            mimeType: "text/markdown",
+          })),
+        }),
+      }),
+      {
+        description:
+          "Full text of documentation a tool returned as a summary (the id is in the tool result)",
+        mimeType: "text/markdown",
+      },
+      async (uri, { id }) => {
+        const document = store.get(String(id));
+        if (!document) {
+          throw new Error(
+            `Documentation ${uri.href} is no longer available; call the tool again`,
+          );
+        }
+        return {
+          contents: [
+            { uri: uri.href, mimeType: "text/markdown", text: document.text },
+          ],
+        };
+      },
+    );
+  };
+}
@@ -0,0 +1,202 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import { mkdtemp, rm, writeFile } from "fs/promises";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  findingsByRelativePath,
+  findingToDiagnostic,
+  GoAnalyzerCache,
+  goAnalyzerPolicy,
+  mergeDiagnostics,
+  parseStaticcheckOutput,
+  parseVetOutput,
+  runGoAnalyzers,
+} from "./goAnalyzers.ts";
+
+const { runGoCommand } = vi.hoisted(() => ({ runGoCommand: vi.fn() }));
+
+vi.mock("./goCommand.ts", () => ({ runGoCommand }));
+
+const ROOT = "/work/app";
+
+const VET_OUTPUT = [
+  "# example.com/app/store",
+  "{",
+  '\t"example.com/app/store": {',
+  '\t\t"printf": [',
+  "\t\t\t{",
+  '\t\t\t\t"posn": "/work/app/store/store.go:12:3",',
+  '\t\t\t\t"message": "fmt.Printf format %d has arg name of wrong type string"',
+  "\t\t\t}",
+  "\t\t],",
+  '\t\t"copylocks": { "error": "analysis skipped" }',
+  "\t}",
+  "}",
+  "vet: store/broken.go:3:2: undefined: x",
+].join("\n");
+
+const STATICCHECK_OUTPUT = [
+  JSON.stringify({
+    code: "SA4006",
+    severity: "error",
+    location: { file: "/work/app/store/store.go", line: 20, column: 2 },
+    end: { file: "/work/app/store/store.go", line: 20, column: 5 },
+    message: "this value of err is never used",
+  }),
+  JSON.stringify({
+    code: "ST1000",
+    severity: "ignored",
+    location: { file: "/work/app/store/store.go", line: 1, column: 1 },
+    message: "at least one file in a package should have a package comment",
+  }),
+  JSON.stringify({
+    code: "compile",
+    severity: "error",
+    location: { file: "/work/app/store/broken.go", line: 3, column: 2 },
+    message: "undefined: x",
+  }),
+].join("\n");
+
+describe("parseVetOutput", () => {
+  it("should read findings per analyzer and skip build errors", () => {
+    expect(parseVetOutput(VET_OUTPUT, ROOT)).toEqual([
+      {
+        file: "/work/app/store/store.go",
+        line: 12,
+        column: 3,
+        message: "fmt.Printf format %d has arg name of wrong type string",
+        source: "go vet",
+        code: "printf",
+        severity: "warning",
+      },
+    ]);
+  });
+});
+
+describe("parseStaticcheckOutput", () => {
+  it("should skip ignored problems and compile errors", () => {
+    const findings = parseStaticcheckOutput(STATICCHECK_OUTPUT, ROOT);
+
+    expect(findings).toHaveLength(1);
+    expect(findingToDiagnostic(findings[0])).toEqual({
+      range: {
+        start: { line: 19, character: 1 },
+        end: { line: 19, character: 4 },
+      },
+      severity: 1,
+      source: "staticcheck",
+      code: "SA4006",
+      message: "this value of err is never used",
+    });
+  });
+});
+
+describe("mergeDiagnostics", () => {
+  it("should not repeat what the language server reported", () => {
+    const at = (line: number, message: string, source: string) => ({
+      range: {
+        start: { line, character: 0 },
+        end: { line, character: 1 },
+      },
+      message,
+      source,
+    });
+
+    expect(
+      mergeDiagnostics(
+        [at(3, "unused variable", "unusedvariable")],
+        [at(3, "unused variable", "go vet"), at(4, "bad format", "go vet")],
+      ).map((d) => d.source),
+    ).toEqual(["unusedvariable", "go vet"]);
+  });
+});
+
+describe("goAnalyzerPolicy", () => {
+  it("should apply to the gopls preset outside read-only mode", () => {
+    expect(
+      goAnalyzerPolicy({
+        languageId: "gopls",
+        config: { gopls: { buildFlags: ["-tags=integration"] } },
+      } as any),
+    ).toMatchObject({
+      vet: true,
+      staticcheck: false,
+      buildFlags: ["-tags=integration"],
+    });
+    expect(goAnalyzerPolicy({ languageId: "tsgo" } as any)).toBeUndefined();
+    expect(
+      goAnalyzerPolicy({ languageId: "gopls", readOnly: true } as any),
+    ).toBeUndefined();
+    expect(
+      goAnalyzerPolicy({
+        languageId: "gopls",
+        config: { goAnalyzers: { vet: false } },
+      } as any),
+    ).toBeUndefined();
+  });
+});
+
+describe("runGoAnalyzers", () => {
+  beforeEach(() => {
+    runGoCommand.mockReset();
+  });
+
+  it("should run both analyzers and report the one that failed", async () => {
+    runGoCommand.mockImplementation(async (_args: string[], options: any) => {
+      if (options.bin === "staticcheck") {
+        throw new Error("staticcheck not found. Install it or add it to PATH.");
+      }
+      return { stdout: "", stderr: VET_OUTPUT, exitCode: 1 };
+    });
+
+    const result = await runGoAnalyzers(ROOT, ["./..."], {
+      vet: true,
+      staticcheck: true,
+      timeout: 60,
+      buildFlags: ["-tags=integration", "-race"],
+    });
+
+    expect(runGoCommand).toHaveBeenCalledWith(
+      ["vet", "-json", "-tags=integration", "-race", "./..."],
+      expect.objectContaining({ cwd: ROOT, timeout: 60000 }),
+    );
+    expect(runGoCommand).toHaveBeenCalledWith(
+      ["-f", "json", "-tags=integration", "./..."],
+      expect.objectContaining({ bin: "staticcheck" }),
+    );
+    expect(result.analyzers).toEqual(["go vet"]);
+    expect(result.errors).toEqual([
+      "staticcheck: staticcheck not found. Install it or add it to PATH.",
+    ]);
+    expect(findingsByRelativePath(ROOT, result.diagnostics)).toEqual([
+      ["store/store.go", [expect.objectContaining({ code: "printf" })]],
+    ]);
+  });
+
+  it("should cache a package until one of its files changes", async () => {
+    const root = await mkdtemp(join(tmpdir(), "lsmcp-vet-"));
+    try {
+      const file = join(root, "main.go");
+      await writeFile(file, "package main\n");
+      runGoCommand.mockResolvedValue({ stdout: "", stderr: "", exitCode: 0 });
+      const cache = new GoAnalyzerCache();
+      const policy = {
+        vet: true,
+        staticcheck: false,
+        timeout: 60,
+        buildFlags: [],
+      };
+
+      await cache.forFile(root, file, policy);
+      await cache.forFile(root, file, policy);
+      expect(runGoCommand).toHaveBeenCalledTimes(1);
+      expect(runGoCommand.mock.calls[0][0]).toEqual(["vet", "-json", "."]);
+
+      await writeFile(join(root, "util.go"), "package main\n");
+      await cache.forFile(root, file, policy);
+      expect(runGoCommand).toHaveBeenCalledTimes(2);
+    } finally {
+      await rm(root, { recursive: true, force: true });
+    }
+  });
+});
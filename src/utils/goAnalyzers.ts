@@ -0,0 +1,415 @@
+/**
+ * Go analyzers run next to gopls
+ *
+ * In Go workspaces `go vet -json` and, when enabled, `staticcheck -f json`
+ * run on the packages being checked. Their findings become LSP diagnostics
+ * with source "go vet" or "staticcheck" and are merged into the diagnostics
+ * tools, so checks CI runs show up even when gopls does not report them.
+ * Results are cached per package until one of its Go files changes.
+ */
+
+import { readdir, stat } from "fs/promises";
+import { dirname, isAbsolute, join, relative, resolve, sep } from "path";
+import type { Diagnostic, McpContext } from "@internal/types";
+import { debug } from "@internal/lsp-client";
+import { runGoCommand } from "./goCommand.ts";
+
+export const GO_VET_SOURCE = "go vet";
+export const STATICCHECK_SOURCE = "staticcheck";
+
+export interface GoAnalyzerPolicy {
+  vet: boolean;
+  staticcheck: boolean;
+  /** Seconds each analyzer may run */
+  timeout: number;
+  buildFlags: string[];
+  env?: Record<string, string>;
+}
+
+export const DEFAULT_GO_ANALYZER_POLICY: GoAnalyzerPolicy = {
+  vet: true,
+  staticcheck: false,
+  timeout: 120,
+  buildFlags: [],
+};
+
+/**
+ * Analyzers to run for the current server, or undefined when none apply:
+ * outside Go workspaces and in read-only mode (the analyzers build packages)
+ */
+export function goAnalyzerPolicy(
+  context?: McpContext,
+): GoAnalyzerPolicy | undefined {
+  if (context?.languageId !== "gopls" || context.readOnly) {
+    return undefined;
+  }
+  const config = context.config ?? {};
+  const configured = config.goAnalyzers as
+    | Partial<GoAnalyzerPolicy>
+    | undefined;
+  const gopls = config.gopls as
+    | { buildFlags?: string[]; env?: Record<string, string> }
+    | undefined;
+  const policy: GoAnalyzerPolicy = {
+    ...DEFAULT_GO_ANALYZER_POLICY,
+    ...configured,
+    buildFlags: gopls?.buildFlags ?? [],
+    env: gopls?.env,
+  };
+  return policy.vet || policy.staticcheck ? policy : undefined;
+}
+
+/** A finding of one analyzer */
+export interface AnalyzerFinding {
+  /** Absolute path */
+  file: string;
+  /** 1-based */
+  line: number;
+  /** 1-based */
+  column: number;
+  endLine?: number;
+  endColumn?: number;
+  message: string;
+  source: string;
+  /** Analyzer (printf) or check (SA4006) */
+  code: string;
+  severity: "error" | "warning";
+}
+
+/** file:line:col as printed by go vet */
+function parsePosition(
+  position: string,
+  root: string,
+): { file: string; line: number; column: number } | undefined {
+  const match = position.match(/^(.*):(\d+):(\d+)$/);
+  if (!match) {
+    return undefined;
+  }
+  return {
+    file: resolve(root, match[1]),
+    line: Number(match[2]),
+    column: Number(match[3]),
+  };
+}
+
+interface VetDiagnostic {
+  posn?: string;
+  end?: string;
+  message?: string;
+}
+
+/**
+ * Parse `go vet -json` output: one indented JSON object per package, maybe
+ * preceded by `# package` lines and mixed with plain build errors, which are
+ * skipped (gopls reports those itself)
+ */
+export function parseVetOutput(
+  output: string,
+  root: string,
+): AnalyzerFinding[] {
+  const findings: AnalyzerFinding[] = [];
+  let block: string[] | undefined;
+  for (const line of output.split("\n")) {
+    if (line === "{") {
+      block = [line];
+      continue;
+    }
+    if (!block) {
+      continue;
+    }
+    block.push(line);
+    if (line !== "}") {
+      continue;
+    }
+    let parsed: Record<string, Record<string, unknown>>;
+    try {
+      parsed = JSON.parse(block.join("\n"));
+    } catch {
+      block = undefined;
+      continue;
+    }
+    block = undefined;
+    for (const analyzers of Object.values(parsed)) {
+      for (const [analyzer, diagnostics] of Object.entries(analyzers ?? {})) {
+        // An analyzer that failed reports { "error": "..." }
+        if (!Array.isArray(diagnostics)) {
+          continue;
+        }
+        for (const diagnostic of diagnostics as VetDiagnostic[]) {
+          const start = diagnostic.posn && parsePosition(diagnostic.posn, root);
+          if (!start || !diagnostic.message) {
+            continue;
+          }
+          const end = diagnostic.end && parsePosition(diagnostic.end, root);
+          findings.push({
+            ...start,
+            ...(end && { endLine: end.line, endColumn: end.column }),
+            message: diagnostic.message,
+            source: GO_VET_SOURCE,
+            code: analyzer,
+            severity: "warning",
+          });
+        }
+      }
+    }
+  }
+  return findings;
+}
+
+interface StaticcheckLocation {
+  file: string;
+  line: number;
+  column: number;
+}
+
+interface StaticcheckProblem {
+  code: string;
+  severity: "error" | "warning" | "ignored";
+  location: StaticcheckLocation;
+  end?: StaticcheckLocation;
+  message: string;
+}
+
+/**
+ * Parse `staticcheck -f json` output, one problem per line; ignored problems
+ * and compile errors are skipped
+ */
+export function parseStaticcheckOutput(
+  output: string,
+  root: string,
+): AnalyzerFinding[] {
+  const findings: AnalyzerFinding[] = [];
+  for (const line of output.split("\n")) {
+    if (!line.trim().startsWith("{")) {
+      continue;
+    }
+    let problem: StaticcheckProblem;
+    try {
+      problem = JSON.parse(line);
+    } catch {
+      continue;
+    }
+    if (
+      problem.severity === "ignored" ||
+      problem.code === "compile" ||
+      !problem.location?.file
+    ) {
+      continue;
+    }
+    findings.push({
+      file: resolve(root, problem.location.file),
+      line: problem.location.line,
+      column: problem.location.column,
+      ...(problem.end?.line && {
+        endLine: problem.end.line,
+        endColumn: problem.end.column,
+      }),
+      message: problem.message,
+      source: STATICCHECK_SOURCE,
+      code: problem.code,
+      severity: problem.severity === "error" ? "error" : "warning",
+    });
+  }
+  return findings;
+}
+
+export function findingToDiagnostic(finding: AnalyzerFinding): Diagnostic {
+  const start = {
+    line: Math.max(0, finding.line - 1),
+    character: Math.max(0, finding.column - 1),
+  };
+  const end =
+    finding.endLine !== undefined
+      ? {
+          line: Math.max(0, finding.endLine - 1),
+          character: Math.max(0, (finding.endColumn ?? 1) - 1),
+        }
+      : start;
+  return {
+    range: { start, end },
+    severity: finding.severity === "error" ? 1 : 2,
+    source: finding.source,
+    code: finding.code,
+    message: finding.message,
+  };
+}
+
+/**
+ * Add analyzer diagnostics the language server has not reported already
+ * (gopls runs some vet analyzers itself)
+ */
+export function mergeDiagnostics(
+  diagnostics: Diagnostic[],
+  extra: Diagnostic[],
+): Diagnostic[] {
+  const seen = new Set(
+    diagnostics.map((d) => `${d.range.start.line}\u0000${d.message}`),
+  );
+  return [
+    ...diagnostics,
+    ...extra.filter(
+      (d) => !seen.has(`${d.range.start.line}\u0000${d.message}`),
+    ),
+  ];
+}
+
+export interface GoAnalyzerResult {
+  /** Diagnostics by absolute file path */
+  diagnostics: Map<string, Diagnostic[]>;
+  /** Analyzers that ran */
+  analyzers: string[];
+  /** Analyzers that could not run, with the reason */
+  errors: string[];
+}
+
+/**
+ * Diagnostics by path relative to root (with "/"), skipping files outside it
+ */
+export function findingsByRelativePath(
+  root: string,
+  diagnostics: Map<string, Diagnostic[]>,
+): [string, Diagnostic[]][] {
+  return [...diagnostics].flatMap(([file, list]) => {
+    const path = relative(root, file);
+    return path.startsWith("..") || isAbsolute(path)
+      ? []
+      : [[path.split(sep).join("/"), list] as [string, Diagnostic[]]];
+  });
+}
+
+/** Package pattern of the directory, relative to root */
+function packagePattern(root: string, directory: string): string {
+  const path = relative(root, directory).split(sep).join("/");
+  return path ? `./${path}` : ".";
+}
+
+/**
+ * Run the enabled analyzers on package patterns (e.g. ./... or ./store)
+ */
+export async function runGoAnalyzers(
+  root: string,
+  packages: string[],
+  policy: GoAnalyzerPolicy,
+  signal?: AbortSignal,
+): Promise<GoAnalyzerResult> {
+  const options = {
+    cwd: root,
+    timeout: policy.timeout * 1000,
+    signal,
+    env: policy.env,
+  };
+  const runs: Promise<{ source: string; findings: AnalyzerFinding[] }>[] = [];
+  if (policy.vet) {
+    runs.push(
+      runGoCommand(
+        ["vet", "-json", ...policy.buildFlags, ...packages],
+        options,
+      ).then((result) => ({
+        source: GO_VET_SOURCE,
+        findings: parseVetOutput(`${result.stderr}\n${result.stdout}`, root),
+      })),
+    );
+  }
+  if (policy.staticcheck) {
+    // staticcheck understands -tags but not the other go build flags
+    const tags = policy.buildFlags.filter((flag) => flag.startsWith("-tags"));
+    runs.push(
+      runGoCommand(["-f", "json", ...tags, ...packages], {
+        ...options,
+        bin: "staticcheck",
+      }).then((result) => ({
+        source: STATICCHECK_SOURCE,
+        findings: parseStaticcheckOutput(result.stdout, root),
+      })),
+    );
+  }
+
+  const result: GoAnalyzerResult = {
+    diagnostics: new Map(),
+    analyzers: [],
+    errors: [],
+  };
+  const sources = [
+    ...(policy.vet ? [GO_VET_SOURCE] : []),
+    ...(policy.staticcheck ? [STATICCHECK_SOURCE] : []),
+  ];
+  const settled = await Promise.allSettled(runs);
+  settled.forEach((run, i) => {
+    if (run.status === "rejected") {
+      const reason =
+        run.reason instanceof Error ? run.reason.message : String(run.reason);
+      debug(`[goAnalyzers] ${sources[i]} failed: ${reason}`);
+      result.errors.push(`${sources[i]}: ${reason}`);
+      return;
+    }
+    result.analyzers.push(run.value.source);
+    for (const finding of run.value.findings) {
+      const list = result.diagnostics.get(finding.file) ?? [];
+      list.push(findingToDiagnostic(finding));
+      result.diagnostics.set(finding.file, list);
+    }
+  });
+  return result;
+}
+
+/** Newest modification time of the Go files in a directory */
+async function packageFingerprint(directory: string): Promise<string> {
+  const entries = await readdir(directory, { withFileTypes: true });
+  let newest = 0;
+  for (const entry of entries) {
+    if (entry.isFile() && entry.name.endsWith(".go")) {
+      const { mtimeMs } = await stat(join(directory, entry.name));
+      newest = Math.max(newest, mtimeMs);
+    }
+  }
+  return `${entries.length}:${newest}`;
+}
+
+export interface GoAnalyzerFileResult {
+  diagnostics: Diagnostic[];
+  analyzers: string[];
+  errors: string[];
+}
+
+interface CachedPackage {
+  fingerprint: string;
+  result: GoAnalyzerResult;
+}
+
+/**
+ * Analyzer results per package directory, rerun when a Go file of the
+ * package has changed
+ */
+export class GoAnalyzerCache {
+  private packages = new Map<string, CachedPackage>();
+
+  async forFile(
+    root: string,
+    filePath: string,
+    policy: GoAnalyzerPolicy,
+    signal?: AbortSignal,
+  ): Promise<GoAnalyzerFileResult> {
+    const directory = dirname(filePath);
+    const key = `${JSON.stringify(policy)}\u0000${directory}`;
+    const fingerprint = await packageFingerprint(directory);
+    let cached = this.packages.get(key);
+    if (!cached || cached.fingerprint !== fingerprint) {
+      const result = await runGoAnalyzers(
+        root,
+        [packagePattern(root, directory)],
+        policy,
+        signal,
+      );
+      cached = { fingerprint, result };
+      // Failed runs are retried on the next call
+      if (result.errors.length === 0) {
+        this.packages.set(key, cached);
+      }
+    }
+    const { diagnostics, analyzers, errors } = cached.result;
+    return { diagnostics: diagnostics.get(filePath) ?? [], analyzers, errors };
+  }
+}
+
+/** Cache shared by the diagnostics tools of this process */
+export const goAnalyzerCache = new GoAnalyzerCache();
@@ -0,0 +1,89 @@
+/**
+ * Run the go command (or a Go tool such as staticcheck) and collect its output
+ * Unlike the git helper in code-indexer, a non-zero exit code is not an error:
+ * go test and go vet exit with 1 when they find problems
+ */
+
+import { spawn } from "child_process";
+
+export interface GoCommandResult {
+  stdout: string;
+  stderr: string;
+  exitCode: number | null;
+}
+
+export interface GoCommandOptions {
+  cwd: string;
+  /** Kill the process after this many milliseconds */
+  timeout: number;
+  signal?: AbortSignal;
+  env?: Record<string, string | undefined>;
+  /** Program to run instead of go (e.g. staticcheck) */
+  bin?: string;
+}
+
+export function runGoCommand(
+  args: string[],
+  options: GoCommandOptions,
+): Promise<GoCommandResult> {
+  const bin = options.bin ?? "go";
+  const label = bin === "go" ? `go ${args[0]}` : bin;
+  return new Promise((resolve, reject) => {
+    const proc = spawn(bin, args, {
+      cwd: options.cwd,
+      env: { ...process.env, ...options.env },
+      stdio: ["ignore", "pipe", "pipe"],
+    });
+
+    let stdout = "";
+    let stderr = "";
+    let settled = false;
+
+    const finish = (error?: Error) => {
+      if (settled) return;
+      settled = true;
+      clearTimeout(timer);
+      options.signal?.removeEventListener("abort", onAbort);
+      if (error) {
+        proc.kill("SIGTERM");
+        reject(error);
+      }
+    };
+
+    const timer = setTimeout(() => {
+      finish(
+        new Error(
+          `${label} timed out after ${Math.round(options.timeout / 1000)}s`,
+        ),
+      );
+    }, options.timeout);
+
+    const onAbort = () => finish(new Error(`${label} was cancelled`));
+    options.signal?.addEventListener("abort", onAbort);
+
+    proc.stdout.on("data", (chunk) => {
+      stdout += chunk.toString();
+    });
+    proc.stderr.on("data", (chunk) => {
+      stderr += chunk.toString();
+    });
+
+    proc.on("close", (code) => {
+      if (settled) return;
+      finish();
+      resolve({ stdout, stderr, exitCode: code });
+    });
+
+    proc.on("error", (error: NodeJS.ErrnoException) => {
+      finish(
+        error.code === "ENOENT"
+          ? new Error(
+              bin === "go"
+                ? "go command not found. Install Go or add it to PATH."
+                : `${bin} not found. Install it or add it to PATH.`,
+            )
+          : error,
+      );
+    });
+  });
+}
@@ -0,0 +1,234 @@
+import { describe, it, expect, afterEach } from "vitest";
+import { request } from "node:http";
+import { z } from "zod";
+import { McpServer } from "@modelcontextprotocol/sdk/server/mcp.js";
+import { Client } from "@modelcontextprotocol/sdk/client/index.js";
+import { StreamableHTTPClientTransport } from "@modelcontextprotocol/sdk/client/streamableHttp.js";
+import {
+  defaultAllowedHosts,
+  parseHttpAddress,
+  startHttpTransport,
+  type HttpServerHandle,
+} from "./httpTransport.ts";
+
+describe("parseHttpAddress", () => {
+  it("should default to localhost for :port", () => {
+    expect(parseHttpAddress(":8080")).toEqual({
+      host: "127.0.0.1",
+      port: 8080,
+    });
+    expect(parseHttpAddress("8080")).toEqual({
+      host: "127.0.0.1",
+      port: 8080,
+    });
+  });
+
+  it("should accept explicit hosts", () => {
+    expect(parseHttpAddress("0.0.0.0:3000")).toEqual({
+      host: "0.0.0.0",
+      port: 3000,
+    });
+    expect(parseHttpAddress("[::1]:3000")).toEqual({ host: "::1", port: 3000 });
+  });
+
+  it("should reject invalid ports", () => {
+    expect(() => parseHttpAddress(":http")).toThrow("Invalid --http address");
+    expect(() => parseHttpAddress(":70000")).toThrow("Invalid --http address");
+  });
+});
+
+describe("defaultAllowedHosts", () => {
+  it("should allow localhost and a specific listen host", () => {
+    expect(defaultAllowedHosts({ host: "127.0.0.1", port: 8080 })).toEqual([
+      "127.0.0.1:8080",
+      "localhost:8080",
+      "[::1]:8080",
+    ]);
+    expect(defaultAllowedHosts({ host: "10.0.0.5", port: 80 })).toContain(
+      "10.0.0.5:80",
+    );
+    expect(defaultAllowedHosts({ host: "0.0.0.0", port: 80 })).toHaveLength(3);
+  });
+});
+
+/**
+ * POST to /mcp with the given headers; fetch does not let tests set Host
+ */
+function post(
+  port: number,
+  headers: Record<string, string>,
+  body: string,
+): Promise<number> {
+  return new Promise((resolve, reject) => {
+    const req = request(
+      {
+        host: "127.0.0.1",
+        port,
+        path: "/mcp",
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          Accept: "application/json, text/event-stream",
+          ...headers,
+        },
+      },
+      (res) => {
+        res.resume();
+        resolve(res.statusCode ?? 0);
+      },
+    );
+    req.on("error", reject);
+    req.end(body);
+  });
+}
+
+describe("startHttpTransport", () => {
+  let handle: HttpServerHandle | undefined;
+
+  afterEach(async () => {
+    await handle?.close();
+    handle = undefined;
+  });
+
+  function createEchoServer() {
+    const server = new McpServer({ name: "test", version: "0.0.0" });
+    server.tool("echo", { text: z.string() }, async ({ text }) => ({
+      content: [{ type: "text", text }],
+    }));
+    return server;
+  }
+
+  async function connectClient(port: number) {
+    const client = new Client({ name: "test-client", version: "0.0.0" });
+    await client.connect(
+      new StreamableHTTPClientTransport(
+        new URL(`http://127.0.0.1:${port}/mcp`),
+      ),
+    );
+    return client;
+  }
+
+  it("should serve several clients with separate sessions", async () => {
+    handle = await startHttpTransport(createEchoServer, {
+      host: "127.0.0.1",
+      port: 0,
+    });
+
+    const first = await connectClient(handle.address.port);
+    const second = await connectClient(handle.address.port);
+
+    const result = await first.callTool({
+      name: "echo",
+      arguments: { text: "hello" },
+    });
+    expect(result.content).toEqual([{ type: "text", text: "hello" }]);
+
+    const tools = await second.listTools();
+    expect(tools.tools.map((tool) => tool.name)).toEqual(["echo"]);
+    expect(handle.sessionCount()).toBe(2);
+
+    await first.close();
+    await second.close();
+  });
+
+  it("should reject requests without a session", async () => {
+    handle = await startHttpTransport(createEchoServer, {
+      host: "127.0.0.1",
+      port: 0,
+    });
+
+    const response = await fetch(
+      `http://127.0.0.1:${handle.address.port}/mcp`,
+      {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          Accept: "application/json, text/event-stream",
+        },
+        body: JSON.stringify({ jsonrpc: "2.0", id: 1, method: "tools/list" }),
+      },
+    );
+    expect(response.status).toBe(400);
+  });
+
+  it("should refuse foreign Host and Origin headers", async () => {
+    handle = await startHttpTransport(createEchoServer, {
+      host: "127.0.0.1",
+      port: 0,
+    });
+    const { port } = handle.address;
+    const body = JSON.stringify({
+      jsonrpc: "2.0",
+      id: 1,
+      method: "tools/list",
+    });
+
+    expect(await post(port, { Host: "attacker.example" }, body)).toBe(403);
+    expect(
+      await post(port, { Origin: "http://attacker.example" }, body),
+    ).toBe(403);
+    // Allowed, but still without a session
+    expect(
+      await post(port, { Origin: `http://localhost:${port}` }, body),
+    ).toBe(400);
+  });
+
+  it("should accept configured hosts and origins", async () => {
+    handle = await startHttpTransport(createEchoServer, {
+      host: "127.0.0.1",
+      port: 0,
+      allowedHosts: ["devbox.lan"],
+      allowedOrigins: ["https://app.example"],
+    });
+    const { port } = handle.address;
+    const body = JSON.stringify({
+      jsonrpc: "2.0",
+      id: 1,
+      method: "tools/list",
+    });
+
+    expect(await post(port, { Host: `devbox.lan:${port}` }, body)).toBe(400);
+    expect(
+      await post(
+        port,
+        { Host: "devbox.lan", Origin: "https://app.example" },
+        body,
+      ),
+    ).toBe(400);
+    expect(await post(port, { Host: `127.0.0.1:${port}` }, body)).toBe(403);
+  });
+
+  it("should refuse request bodies over the limit", async () => {
+    handle = await startHttpTransport(createEchoServer, {
+      host: "127.0.0.1",
+      port: 0,
+      maxBodyBytes: 1024,
+    });
+
+    const body = JSON.stringify({
+      jsonrpc: "2.0",
+      id: 1,
+      method: "tools/list",
+      params: { padding: "x".repeat(2048) },
+    });
+    expect(await post(handle.address.port, {}, body)).toBe(413);
+  });
+
+  it("should serve Prometheus metrics", async () => {
+    handle = await startHttpTransport(createEchoServer, {
+      host: "127.0.0.1",
+      port: 0,
+    });
+    const client = await connectClient(handle.address.port);
+
+    const response = await fetch(
+      `http://127.0.0.1:${handle.address.port}/metrics`,
+    );
+    const body = await response.text();
+
+    expect(response.headers.get("content-type")).toMatch(/^text\/plain/);
+    expect(body).toContain("# TYPE lsmcp_http_sessions gauge");
+    expect(body).toContain("lsmcp_http_sessions 1");
+    await client.close();
+  });
+});
@@ -0,0 +1,374 @@
+/**
+ * Streamable HTTP transport (with legacy SSE fallback) for running lsmcp
+ * as a long-lived daemon shared by several MCP clients
+ */
+
+import { randomUUID } from "node:crypto";
+import {
+  createServer,
+  type IncomingMessage,
+  type Server,
+  type ServerResponse,
+} from "node:http";
+import type { McpServer } from "@modelcontextprotocol/sdk/server/mcp.js";
+import { StreamableHTTPServerTransport } from "@modelcontextprotocol/sdk/server/streamableHttp.js";
+import { SSEServerTransport } from "@modelcontextprotocol/sdk/server/sse.js";
+import { isInitializeRequest } from "@modelcontextprotocol/sdk/types.js";
+import { debugLogWithPrefix } from "./debugLog.ts";
+import { metrics } from "./metrics.ts";
+
+export const MCP_HTTP_PATH = "/mcp";
+export const SSE_PATH = "/sse";
+export const SSE_MESSAGES_PATH = "/messages";
+export const METRICS_PATH = "/metrics";
+
+const DEFAULT_HOST = "127.0.0.1";
+const LOOPBACK_HOSTS = ["127.0.0.1", "localhost", "::1"];
+const WILDCARD_HOSTS = ["0.0.0.0", "::"];
+
+/** Largest request body accepted by default (4 MiB) */
+export const DEFAULT_MAX_BODY_BYTES = 4 * 1024 * 1024;
+
+export interface HttpAddress {
+  host: string;
+  port: number;
+}
+
+export interface HttpTransportOptions extends HttpAddress {
+  /** Serve the deprecated HTTP+SSE transport on /sse and /messages */
+  sse?: boolean;
+  /** Called after a client session ends (closed or disconnected) */
+  onSessionClosed?: (sessionId: string) => void;
+  /**
+   * Refuse requests whose Host or Origin header names another server, so a
+   * web page cannot reach the daemon through DNS rebinding (default: true)
+   */
+  enableDnsRebindingProtection?: boolean;
+  /**
+   * Host header values to accept, with or without the port. Defaults to
+   * localhost and, unless it is a wildcard address, the listen host.
+   */
+  allowedHosts?: string[];
+  /** Origins to accept besides those of the allowed hosts */
+  allowedOrigins?: string[];
+  /** Largest request body in bytes (default: 4 MiB) */
+  maxBodyBytes?: number;
+}
+
+export interface HttpServerHandle {
+  server: Server;
+  address: HttpAddress;
+  sessionCount: () => number;
+  close: () => Promise<void>;
+}
+
+/**
+ * Parse a listen address such as ":8080", "8080" or "0.0.0.0:8080".
+ * A missing host binds to localhost only.
+ */
+export function parseHttpAddress(value: string): HttpAddress {
+  const separator = value.lastIndexOf(":");
+  const host = separator > 0 ? value.slice(0, separator) : DEFAULT_HOST;
+  const portText = separator >= 0 ? value.slice(separator + 1) : value;
+  const port = Number(portText);
+  if (!/^\d+$/.test(portText) || port > 65535) {
+    throw new Error(
+      `Invalid --http address "${value}". Expected ":port" or "host:port".`,
+    );
+  }
+  return { host: host.replace(/^\[(.*)\]$/, "$1"), port };
+}
+
+/**
+ * Parse a comma-separated list of hosts or origins
+ */
+export function parseHostList(value: string): string[] {
+  return value
+    .split(",")
+    .map((entry) => entry.trim())
+    .filter((entry) => entry.length > 0);
+}
+
+/**
+ * Host header values accepted when no allowedHosts are configured
+ */
+export function defaultAllowedHosts(address: HttpAddress): string[] {
+  const hosts = [...LOOPBACK_HOSTS];
+  if (![...hosts, ...WILDCARD_HOSTS].includes(address.host)) {
+    hosts.push(address.host);
+  }
+  return hosts.map((host) =>
+    host.includes(":")
+      ? `[${host}]:${address.port}`
+      : `${host}:${address.port}`,
+  );
+}
+
+/**
+ * Whether a Host header value (or the host of an Origin) is allowed; an
+ * entry without a port allows the host on any port
+ */
+function isAllowedHost(host: string, allowedHosts: string[]): boolean {
+  const normalized = host.toLowerCase();
+  const hostname = normalized.replace(/:\d+$/, "");
+  return allowedHosts.some((entry) => {
+    const allowed = entry.toLowerCase();
+    return allowed === normalized || allowed === hostname;
+  });
+}
+
+/**
+ * Why a request must be refused for its Host or Origin header, if it must
+ */
+function rejectedHeader(
+  req: IncomingMessage,
+  allowedHosts: string[],
+  allowedOrigins: string[],
+): string | undefined {
+  const host = req.headers.host;
+  if (!host || !isAllowedHost(host, allowedHosts)) {
+    return `Invalid Host header: ${host ?? "(missing)"}`;
+  }
+
+  // Clients other than browsers send no Origin
+  const origin = req.headers.origin;
+  if (origin === undefined || allowedOrigins.includes(origin)) {
+    return undefined;
+  }
+  let originHost: string;
+  try {
+    originHost = new URL(origin).host;
+  } catch {
+    return `Invalid Origin header: ${origin}`;
+  }
+  return isAllowedHost(originHost, allowedHosts)
+    ? undefined
+    : `Invalid Origin header: ${origin}`;
+}
+
+/**
+ * Thrown when a request body exceeds the configured limit
+ */
+class PayloadTooLargeError extends Error {}
+
+async function readJsonBody(
+  req: IncomingMessage,
+  maxBytes: number,
+): Promise<unknown> {
+  const declared = Number(req.headers["content-length"]);
+  if (declared > maxBytes) {
+    throw new PayloadTooLargeError(`Request body exceeds ${maxBytes} bytes`);
+  }
+
+  const chunks: Buffer[] = [];
+  let size = 0;
+  for await (const chunk of req) {
+    size += (chunk as Buffer).length;
+    if (size > maxBytes) {
+      throw new PayloadTooLargeError(`Request body exceeds ${maxBytes} bytes`);
+    }
+    chunks.push(chunk as Buffer);
+  }
+  const body = Buffer.concat(chunks).toString("utf-8");
+  return body.length > 0 ? JSON.parse(body) : undefined;
+}
+
+function sendJsonRpcError(
+  res: ServerResponse,
+  status: number,
+  message: string,
+): void {
+  res.writeHead(status, { "Content-Type": "application/json" }).end(
+    JSON.stringify({
+      jsonrpc: "2.0",
+      error: { code: -32000, message },
+      id: null,
+    }),
+  );
+}
+
+function isInitialize(body: unknown): boolean {
+  return Array.isArray(body)
+    ? body.some((message) => isInitializeRequest(message))
+    : isInitializeRequest(body);
+}
+
+/**
+ * Start an HTTP server that creates one MCP server per client session.
+ * All sessions share whatever state the factory closes over (LSP client, index).
+ */
+export async function startHttpTransport(
+  createSessionServer: (sessionId: string) => McpServer,
+  options: HttpTransportOptions,
+): Promise<HttpServerHandle> {
+  const streamable = new Map<string, StreamableHTTPServerTransport>();
+  const sse = new Map<string, SSEServerTransport>();
+  metrics.gauge("lsmcp_http_sessions", "Connected MCP client sessions", () => [
+    { value: streamable.size + sse.size },
+  ]);
+  const maxBodyBytes = options.maxBodyBytes ?? DEFAULT_MAX_BODY_BYTES;
+  // Filled in once listening, when the port is known
+  let allowedHosts: string[] = [];
+  const allowedOrigins = options.allowedOrigins ?? [];
+
+  const handleStreamable = async (
+    req: IncomingMessage,
+    res: ServerResponse,
+  ) => {
+    const sessionId = req.headers["mcp-session-id"] as string | undefined;
+    const body =
+      req.method === "POST" ? await readJsonBody(req, maxBodyBytes) : undefined;
+
+    let transport = sessionId ? streamable.get(sessionId) : undefined;
+    if (!transport) {
+      if (sessionId || req.method !== "POST" || !isInitialize(body)) {
+        sendJsonRpcError(
+          res,
+          sessionId ? 404 : 400,
+          sessionId
+            ? `Unknown session: ${sessionId}`
+            : "Bad Request: No valid session ID provided",
+        );
+        return;
+      }
+
+      const newSessionId = randomUUID();
+      const created = new StreamableHTTPServerTransport({
+        sessionIdGenerator: () => newSessionId,
+        onsessioninitialized: (id) => {
+          streamable.set(id, created);
+          debugLogWithPrefix("HTTP", `Session started: ${id}`);
+        },
+      });
+      created.onclose = () => {
+        if (streamable.delete(newSessionId)) {
+          debugLogWithPrefix("HTTP", `Session closed: ${newSessionId}`);
+          options.onSessionClosed?.(newSessionId);
+        }
+      };
+      await createSessionServer(newSessionId).connect(created);
+      transport = created;
+    }
+
+    await transport.handleRequest(req, res, body);
+  };
+
+  const handleSse = async (res: ServerResponse) => {
+    const transport = new SSEServerTransport(SSE_MESSAGES_PATH, res);
+    sse.set(transport.sessionId, transport);
+    res.on("close", () => {
+      if (sse.delete(transport.sessionId)) {
+        options.onSessionClosed?.(transport.sessionId);
+      }
+    });
+    await createSessionServer(transport.sessionId).connect(transport);
+  };
+
+  const handleSseMessage = async (
+    req: IncomingMessage,
+    res: ServerResponse,
+    url: URL,
+  ) => {
+    const sessionId = url.searchParams.get("sessionId") ?? "";
+    const transport = sse.get(sessionId);
+    if (!transport) {
+      sendJsonRpcError(res, 404, `Unknown session: ${sessionId}`);
+      return;
+    }
+    await transport.handlePostMessage(
+      req,
+      res,
+      await readJsonBody(req, maxBodyBytes),
+    );
+  };
+
+  const server = createServer((req, res) => {
+    if (options.enableDnsRebindingProtection !== false) {
+      const rejected = rejectedHeader(req, allowedHosts, allowedOrigins);
+      if (rejected) {
+        debugLogWithPrefix("HTTP", `Refused request: ${rejected}`);
+        sendJsonRpcError(res, 403, rejected);
+        return;
+      }
+    }
+
+    const url = new URL(req.url ?? "/", `http://${req.headers.host}`);
+    let handler: Promise<void> | undefined;
+
+    if (url.pathname === MCP_HTTP_PATH) {
+      handler = handleStreamable(req, res);
+    } else if (url.pathname === METRICS_PATH && req.method === "GET") {
+      res
+        .writeHead(200, {
+          "Content-Type": "text/plain; version=0.0.4; charset=utf-8",
+        })
+        .end(metrics.render());
+      return;
+    } else if (
+      options.sse &&
+      url.pathname === SSE_PATH &&
+      req.method === "GET"
+    ) {
+      handler = handleSse(res);
+    } else if (
+      options.sse &&
+      url.pathname === SSE_MESSAGES_PATH &&
+      req.method === "POST"
+    ) {
+      handler = handleSseMessage(req, res, url);
+    }
+
+    if (!handler) {
+      res.writeHead(404).end();
+      return;
+    }
+
+    handler.catch((error) => {
+      debugLogWithPrefix("HTTP", `Request failed: ${error}`);
+      if (res.headersSent) {
+        return;
+      }
+      if (error instanceof PayloadTooLargeError) {
+        // Do not read the rest of the body
+        res.setHeader("Connection", "close");
+        sendJsonRpcError(res, 413, error.message);
+      } else {
+        sendJsonRpcError(
+          res,
+          error instanceof SyntaxError ? 400 : 500,
+          error instanceof SyntaxError
+            ? "Parse error"
+            : "Internal server error",
+        );
+      }
+    });
+  });
+
+  await new Promise<void>((resolve, reject) => {
+    server.once("error", reject);
+    server.listen(options.port, options.host, () => {
+      server.off("error", reject);
+      resolve();
+    });
+  });
+
+  const bound = server.address();
+  const address: HttpAddress = {
+    host: options.host,
+    port: typeof bound === "object" && bound ? bound.port : options.port,
+  };
+  allowedHosts = options.allowedHosts ?? defaultAllowedHosts(address);
+
+  return {
+    server,
+    address,
+    sessionCount: () => streamable.size + sse.size,
+    close: async () => {
+      const transports = [...streamable.values(), ...sse.values()];
+      await Promise.all(transports.map((transport) => transport.close()));
+      await new Promise<void>((resolve, reject) =>
+        server.close((error) => (error ? reject(error) : resolve())),
+      );
+    },
+  };
+}
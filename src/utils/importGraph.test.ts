@@ -0,0 +1,161 @@
+import { describe, it, expect } from "vitest";
+import {
+  addDependency,
+  aggregateByPackage,
+  buildImportGraph,
+  createDependencyGraph,
+  extractImports,
+  findCycles,
+  parseGoModulePath,
+  renderAdjacency,
+  renderDependencyMermaid,
+  resolveImport,
+} from "./importGraph.ts";
+
+describe("extractImports", () => {
+  it("should extract single and grouped Go imports", () => {
+    const content = `package main
+
+import "fmt"
+import (
+	"os"
+	cfg "example.com/app/internal/config"
+	_ "example.com/app/internal/db"
+)
+`;
+    expect(extractImports("main.go", content)).toEqual([
+      "fmt",
+      "os",
+      "example.com/app/internal/config",
+      "example.com/app/internal/db",
+    ]);
+  });
+
+  it("should extract TypeScript imports, re-exports and requires", () => {
+    const content = `import type { A } from "./a.ts";
+import {
+  B,
+} from "../b";
+export * from "./c.js";
+import "./side.ts";
+const lazy = await import("./lazy.ts");
+const legacy = require("./req");
+`;
+    expect(extractImports("src/index.ts", content).sort()).toEqual(
+      ["../b", "./a.ts", "./c.js", "./lazy.ts", "./req", "./side.ts"].sort(),
+    );
+  });
+
+  it("should extract Python and C imports", () => {
+    expect(
+      extractImports(
+        "app/views.py",
+        "import os, sys as s\nfrom .models import User\nimport app.utils\n",
+      ).sort(),
+    ).toEqual([".models", "app.utils", "os", "sys"]);
+    expect(
+      extractImports("src/main.c", '#include <stdio.h>\n#include "util.h"\n'),
+    ).toEqual(["util.h"]);
+  });
+});
+
+describe("resolveImport", () => {
+  const files = new Set([
+    "src/index.ts",
+    "src/a.ts",
+    "src/lib/index.ts",
+    "app/__init__.py",
+    "app/models.py",
+    "app/views.py",
+    "internal/config/config.go",
+    "internal/config/config_test.go",
+  ]);
+
+  it("should resolve relative script imports with extension fallbacks", () => {
+    const context = { files };
+    expect(resolveImport("./a.js", "src/index.ts", context)).toEqual([
+      "src/a.ts",
+    ]);
+    expect(resolveImport("./lib", "src/index.ts", context)).toEqual([
+      "src/lib/index.ts",
+    ]);
+    expect(resolveImport("zod", "src/index.ts", context)).toEqual([]);
+  });
+
+  it("should resolve Python relative and absolute imports", () => {
+    const context = { files };
+    expect(resolveImport(".models", "app/views.py", context)).toEqual([
+      "app/models.py",
+    ]);
+    expect(resolveImport("app", "app/views.py", context)).toEqual([
+      "app/__init__.py",
+    ]);
+  });
+
+  it("should map Go imports inside the module to package files", () => {
+    const context = { files, goModule: "example.com/app" };
+    expect(
+      resolveImport("example.com/app/internal/config", "main.go", context),
+    ).toEqual(["internal/config/config.go"]);
+    expect(resolveImport("fmt", "main.go", context)).toEqual([]);
+  });
+});
+
+describe("parseGoModulePath", () => {
+  it("should read the module line", () => {
+    expect(parseGoModulePath("module example.com/app\n\ngo 1.22\n")).toBe(
+      "example.com/app",
+    );
+  });
+});
+
+describe("dependency graph", () => {
+  const sources: Record<string, string> = {
+    "main.go": 'package main\n\nimport "example.com/app/store"\n',
+    "store/store.go": 'package store\n\nimport "example.com/app/model"\n',
+    "model/model.go": 'package model\n\nimport "example.com/app/store"\n',
+    "model/user.go": "package model\n",
+  };
+
+  function build() {
+    return buildImportGraph(Object.keys(sources), (file) => sources[file], {
+      goModule: "example.com/app",
+    });
+  }
+
+  it("should aggregate file edges by package", () => {
+    const packages = aggregateByPackage(build());
+    expect(packages.nodes).toEqual([".", "model", "store"]);
+    expect(renderAdjacency(packages)).toBe(
+      [". -> store", "model -> store", "store -> model"].join("\n"),
+    );
+  });
+
+  it("should detect cycles between packages", () => {
+    expect(findCycles(aggregateByPackage(build()))).toEqual([
+      ["model", "store"],
+    ]);
+  });
+
+  it("should report self-loops as cycles", () => {
+    const graph = createDependencyGraph(["a", "b"]);
+    addDependency(graph, "a", "a");
+    addDependency(graph, "a", "b");
+    expect(findCycles(graph)).toEqual([["a"]]);
+  });
+
+  it("should render Mermaid with cycle edges highlighted", () => {
+    expect(renderDependencyMermaid(aggregateByPackage(build()))).toBe(
+      [
+        "flowchart LR",
+        '  n0["."]',
+        '  n1["model"]',
+        '  n2["store"]',
+        "  n0 --> n2",
+        "  n1 --> n2",
+        "  n2 --> n1",
+        "  linkStyle 1,2 stroke:#d33",
+      ].join("\n"),
+    );
+  });
+});
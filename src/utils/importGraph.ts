@@ -0,0 +1,431 @@
+/**
+ * Import/include graph between workspace files and packages
+ * Extracts import specifiers with lightweight per-language patterns, resolves
+ * them to files inside the workspace and detects dependency cycles
+ */
+
+import { readFileSync } from "fs";
+import { dirname, extname, join, posix } from "path";
+
+export interface DependencyGraph {
+  /** Node ids (relative file paths or package directories) */
+  nodes: string[];
+  /** Adjacency list: node -> nodes it imports */
+  edges: Map<string, Set<string>>;
+}
+
+export type DependencyGranularity = "file" | "package";
+export type DependencyFormat = "adjacency" | "mermaid";
+
+export interface ImportResolutionContext {
+  /** All workspace files (relative, forward slashes) */
+  files: Set<string>;
+  /** Go module path from go.mod, used to map import paths to directories */
+  goModule?: string;
+  /** Non-test Go files by directory, built on first use */
+  goPackages?: Map<string, string[]>;
+}
+
+const TS_EXTENSIONS = [".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs"];
+const C_EXTENSIONS = [".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh"];
+
+function toPosix(path: string): string {
+  return path.replace(/\\/g, "/");
+}
+
+function extractGoImports(content: string): string[] {
+  const specs: string[] = [];
+  const single = /^\s*import\s+(?:[\w.]+\s+)?"([^"]+)"/gm;
+  for (const match of content.matchAll(single)) {
+    specs.push(match[1]);
+  }
+  const block = /^\s*import\s*\(([\s\S]*?)\)/gm;
+  for (const match of content.matchAll(block)) {
+    for (const line of match[1].split("\n")) {
+      const spec = /^\s*(?:[\w.]+\s+)?"([^"]+)"/.exec(line);
+      if (spec) {
+        specs.push(spec[1]);
+      }
+    }
+  }
+  return specs;
+}
+
+function extractScriptImports(content: string): string[] {
+  const specs: string[] = [];
+  const patterns = [
+    /\b(?:import|export)\s[^'"`;]*?\bfrom\s*["']([^"']+)["']/g,
+    /\bimport\s*["']([^"']+)["']/g,
+    /\b(?:require|import)\s*\(\s*["']([^"']+)["']\s*\)/g,
+  ];
+  for (const pattern of patterns) {
+    for (const match of content.matchAll(pattern)) {
+      specs.push(match[1]);
+    }
+  }
+  return specs;
+}
+
+function extractPythonImports(content: string): string[] {
+  const specs: string[] = [];
+  const fromImport = /^[ \t]*from[ \t]+([.\w]+)[ \t]+import\b/gm;
+  for (const match of content.matchAll(fromImport)) {
+    specs.push(match[1]);
+  }
+  const plainImport = /^[ \t]*import[ \t]+([\w., \t]+)$/gm;
+  for (const match of content.matchAll(plainImport)) {
+    for (const name of match[1].split(",")) {
+      const spec = name.trim().split(/\s+/)[0];
+      if (spec) {
+        specs.push(spec);
+      }
+    }
+  }
+  return specs;
+}
+
+function extractIncludes(content: string): string[] {
+  return Array.from(
+    content.matchAll(/^\s*#\s*include\s+"([^"]+)"/gm),
+    (match) => match[1],
+  );
+}
+
+/**
+ * Raw import specifiers of a file, chosen by its extension
+ * Returns an empty list for languages without a known import syntax
+ */
+export function extractImports(filePath: string, content: string): string[] {
+  const ext = extname(filePath).toLowerCase();
+  if (ext === ".go") {
+    return extractGoImports(content);
+  }
+  if (TS_EXTENSIONS.includes(ext)) {
+    return extractScriptImports(content);
+  }
+  if (ext === ".py") {
+    return extractPythonImports(content);
+  }
+  if (C_EXTENSIONS.includes(ext)) {
+    return extractIncludes(content);
+  }
+  return [];
+}
+
+/**
+ * Read the module path from go.mod content
+ */
+export function parseGoModulePath(content: string): string | undefined {
+  return /^\s*module\s+(\S+)/m.exec(content)?.[1];
+}
+
+/**
+ * Module path of the go.mod in root, if there is one
+ */
+export function readGoModulePath(root: string): string | undefined {
+  try {
+    return parseGoModulePath(readFileSync(join(root, "go.mod"), "utf-8"));
+  } catch {
+    return undefined;
+  }
+}
+
+function firstExisting(
+  candidates: string[],
+  files: Set<string>,
+): string | undefined {
+  return candidates.map(posix.normalize).find((file) => files.has(file));
+}
+
+function resolveScriptImport(
+  spec: string,
+  fromFile: string,
+  files: Set<string>,
+): string[] {
+  if (!spec.startsWith(".")) {
+    return [];
+  }
+  const base = posix.join(posix.dirname(fromFile), spec);
+  const stem = base.replace(/\.(m|c)?jsx?$/, "");
+  const candidates = [
+    base,
+    ...TS_EXTENSIONS.map((ext) => stem + ext),
+    ...TS_EXTENSIONS.map((ext) => posix.join(base, `index${ext}`)),
+  ];
+  const resolved = firstExisting(candidates, files);
+  return resolved ? [resolved] : [];
+}
+
+function resolvePythonImport(
+  spec: string,
+  fromFile: string,
+  files: Set<string>,
+): string[] {
+  let base: string;
+  if (spec.startsWith(".")) {
+    const dots = /^\.+/.exec(spec)![0].length;
+    let dir = posix.dirname(fromFile);
+    for (let i = 1; i < dots; i++) {
+      dir = posix.dirname(dir);
+    }
+    base = posix.join(dir, ...spec.slice(dots).split(".").filter(Boolean));
+  } else {
+    base = spec.split(".").join("/");
+  }
+  const resolved = firstExisting(
+    [`${base}.py`, posix.join(base, "__init__.py")],
+    files,
+  );
+  return resolved ? [resolved] : [];
+}
+
+function resolveGoImport(
+  spec: string,
+  context: ImportResolutionContext,
+): string[] {
+  const module = context.goModule;
+  if (!module || (spec !== module && !spec.startsWith(`${module}/`))) {
+    return [];
+  }
+  if (!context.goPackages) {
+    context.goPackages = new Map();
+    for (const file of context.files) {
+      if (file.endsWith(".go") && !file.endsWith("_test.go")) {
+        const dir = posix.dirname(file);
+        context.goPackages.set(dir, [
+          ...(context.goPackages.get(dir) ?? []),
+          file,
+        ]);
+      }
+    }
+  }
+  const dir = spec === module ? "." : spec.slice(module.length + 1);
+  return context.goPackages.get(dir) ?? [];
+}
+
+function resolveInclude(
+  spec: string,
+  fromFile: string,
+  files: Set<string>,
+): string[] {
+  const resolved = firstExisting(
+    [posix.join(posix.dirname(fromFile), spec), spec],
+    files,
+  );
+  return resolved ? [resolved] : [];
+}
+
+/**
+ * Resolve an import specifier to workspace files
+ * External packages (stdlib, node_modules, other Go modules) resolve to []
+ */
+export function resolveImport(
+  spec: string,
+  fromFile: string,
+  context: ImportResolutionContext,
+): string[] {
+  const ext = extname(fromFile).toLowerCase();
+  if (ext === ".go") {
+    return resolveGoImport(spec, context);
+  }
+  if (TS_EXTENSIONS.includes(ext)) {
+    return resolveScriptImport(spec, fromFile, context.files);
+  }
+  if (ext === ".py") {
+    return resolvePythonImport(spec, fromFile, context.files);
+  }
+  if (C_EXTENSIONS.includes(ext)) {
+    return resolveInclude(spec, fromFile, context.files);
+  }
+  return [];
+}
+
+export function createDependencyGraph(
+  nodes: Iterable<string>,
+): DependencyGraph {
+  const graph: DependencyGraph = { nodes: [], edges: new Map() };
+  for (const node of nodes) {
+    addDependencyNode(graph, node);
+  }
+  return graph;
+}
+
+function addDependencyNode(graph: DependencyGraph, node: string): void {
+  if (!graph.edges.has(node)) {
+    graph.edges.set(node, new Set());
+    graph.nodes.push(node);
+  }
+}
+
+export function addDependency(
+  graph: DependencyGraph,
+  from: string,
+  to: string,
+): void {
+  addDependencyNode(graph, from);
+  addDependencyNode(graph, to);
+  graph.edges.get(from)!.add(to);
+}
+
+/**
+ * File-level graph from file contents
+ * Files that import themselves (e.g. Go files of the same package) are ignored
+ */
+export function buildImportGraph(
+  files: string[],
+  readFile: (file: string) => string | undefined,
+  options: { goModule?: string } = {},
+): DependencyGraph {
+  const normalized = files.map(toPosix);
+  const context: ImportResolutionContext = {
+    files: new Set(normalized),
+    goModule: options.goModule,
+  };
+  const graph = createDependencyGraph(normalized);
+
+  for (const file of normalized) {
+    const content = readFile(file);
+    if (content === undefined) continue;
+    for (const spec of extractImports(file, content)) {
+      for (const target of resolveImport(spec, file, context)) {
+        if (target !== file) {
+          addDependency(graph, file, target);
+        }
+      }
+    }
+  }
+  graph.nodes.sort();
+  return graph;
+}
+
+/**
+ * Package (directory) of a file; files at the root belong to "."
+ */
+export function packageOf(file: string): string {
+  return toPosix(dirname(file));
+}
+
+/**
+ * Collapse a file graph into a graph between packages
+ */
+export function aggregateByPackage(graph: DependencyGraph): DependencyGraph {
+  const packages = createDependencyGraph(graph.nodes.map(packageOf));
+  for (const [from, targets] of graph.edges) {
+    for (const to of targets) {
+      const fromPackage = packageOf(from);
+      const toPackage = packageOf(to);
+      if (fromPackage !== toPackage) {
+        addDependency(packages, fromPackage, toPackage);
+      }
+    }
+  }
+  packages.nodes.sort();
+  return packages;
+}
+
+/**
+ * Strongly connected components with more than one node, or nodes that
+ * depend on themselves (Tarjan's algorithm)
+ */
+export function findCycles(graph: DependencyGraph): string[][] {
+  const index = new Map<string, number>();
+  const lowlink = new Map<string, number>();
+  const onStack = new Set<string>();
+  const stack: string[] = [];
+  const cycles: string[][] = [];
+  let counter = 0;
+
+  const visit = (node: string) => {
+    index.set(node, counter);
+    lowlink.set(node, counter);
+    counter++;
+    stack.push(node);
+    onStack.add(node);
+
+    for (const next of graph.edges.get(node) ?? []) {
+      if (!index.has(next)) {
+        visit(next);
+        lowlink.set(node, Math.min(lowlink.get(node)!, lowlink.get(next)!));
+      } else if (onStack.has(next)) {
+        lowlink.set(node, Math.min(lowlink.get(node)!, index.get(next)!));
+      }
+    }
+
+    if (lowlink.get(node) === index.get(node)) {
+      const component: string[] = [];
+      let member: string;
+      do {
+        member = stack.pop()!;
+        onStack.delete(member);
+        component.push(member);
+      } while (member !== node);
+
+      if (component.length > 1 || graph.edges.get(node)?.has(node)) {
+        cycles.push(component.sort());
+      }
+    }
+  };
+
+  for (const node of graph.nodes) {
+    if (!index.has(node)) {
+      visit(node);
+    }
+  }
+  return cycles.sort((a, b) => a[0].localeCompare(b[0]));
+}
+
+export function countDependencies(graph: DependencyGraph): number {
+  let count = 0;
+  for (const targets of graph.edges.values()) {
+    count += targets.size;
+  }
+  return count;
+}
+
+export function renderAdjacency(graph: DependencyGraph): string {
+  return graph.nodes
+    .map((node) => {
+      const targets = Array.from(graph.edges.get(node) ?? []).sort();
+      return targets.length > 0
+        ? `${node} -> ${targets.join(", ")}`
+        : `${node} (no dependencies)`;
+    })
+    .join("\n");
+}
+
+/**
+ * Mermaid flowchart; edges that are part of a cycle are drawn in red
+ */
+export function renderDependencyMermaid(
+  graph: DependencyGraph,
+  cycles: string[][] = findCycles(graph),
+): string {
+  const ids = new Map(graph.nodes.map((node, i) => [node, `n${i}`]));
+  const componentOf = new Map<string, number>();
+  cycles.forEach((cycle, i) => {
+    for (const node of cycle) {
+      componentOf.set(node, i);
+    }
+  });
+
+  const lines = ["flowchart LR"];
+  for (const node of graph.nodes) {
+    lines.push(`  ${ids.get(node)}["${node.replace(/"/g, "#quot;")}"]`);
+  }
+  const cycleEdges: number[] = [];
+  let edgeIndex = 0;
+  for (const node of graph.nodes) {
+    for (const target of Array.from(graph.edges.get(node) ?? []).sort()) {
+      lines.push(`  ${ids.get(node)} --> ${ids.get(target)}`);
+      const component = componentOf.get(node);
+      if (component !== undefined && componentOf.get(target) === component) {
+        cycleEdges.push(edgeIndex);
+      }
+      edgeIndex++;
+    }
+  }
+  if (cycleEdges.length > 0) {
+    lines.push(`  linkStyle ${cycleEdges.join(",")} stroke:#d33`);
+  }
+  return lines.join("\n");
+}
@@ -0,0 +1,196 @@
+/**
+ * Symbol index as MCP resources
+ *
+ * lsmcp://symbols/{query} lists the indexed symbols matching a query (JSON)
+ * and lsmcp://outline/{+path} is the symbol tree of one file, so
+ * resource-aware clients can pin an outline in context instead of calling a
+ * tool for it. Subscribed resources get notifications/resources/updated when
+ * the index changes: an outline when its file is re-indexed or removed, a
+ * symbol query on any change.
+ */
+
+import { ResourceTemplate } from "@modelcontextprotocol/sdk/server/mcp.js";
+import { isAbsolute, relative, sep } from "path";
+import { fileURLToPath } from "url";
+import type { McpContext } from "@internal/types";
+import {
+  getIndexedFiles,
+  getOrCreateIndex,
+  getSymbolKindName,
+  onIndexChange,
+  querySymbols,
+  type IndexChange,
+  type IndexedSymbol,
+} from "@internal/code-indexer";
+import {
+  decodeUriVariable,
+  pathResourceUri,
+  type ResourceSetup,
+} from "./mcpServerHelpers.ts";
+import { redact } from "./redaction.ts";
+
+export const SYMBOLS_URI_TEMPLATE = "lsmcp://symbols/{query}";
+export const OUTLINE_URI_TEMPLATE = "lsmcp://outline/{+path}";
+
+const SYMBOLS_PREFIX = "lsmcp://symbols/";
+const OUTLINE_PREFIX = "lsmcp://outline/";
+
+/** Symbols in one lsmcp://symbols read, best matches first */
+export const MAX_RESOURCE_SYMBOLS = 100;
+
+export function symbolsUri(query: string): string {
+  return SYMBOLS_PREFIX + encodeURIComponent(query);
+}
+
+export function outlineUri(relativePath: string): string {
+  return pathResourceUri(OUTLINE_PREFIX, relativePath);
+}
+
+function toRelativePath(root: string, uri: string): string {
+  return relative(root, fileURLToPath(uri)).split(sep).join("/");
+}
+
+/**
+ * One line per symbol, children indented: `name [Kind] line N`
+ */
+export function formatOutline(symbols: IndexedSymbol[], depth = 0): string[] {
+  return symbols.flatMap((symbol) => {
+    const kind = getSymbolKindName(symbol.kind) || `Unknown(${symbol.kind})`;
+    const line = symbol.location.range.start.line + 1;
+    const detail = symbol.detail ? `: ${symbol.detail}` : "";
+    return [
+      `${"  ".repeat(depth)}${symbol.name} [${kind}] line ${line}${detail}`,
+      ...formatOutline(symbol.children ?? [], depth + 1),
+    ];
+  });
+}
+
+export function readSymbolsResource(root: string, query: string): string {
+  const symbols = querySymbols(root, { name: query || undefined });
+  return redact(
+    JSON.stringify(
+      symbols.slice(0, MAX_RESOURCE_SYMBOLS).map((symbol) => ({
+        name: symbol.name,
+        kind: getSymbolKindName(symbol.kind),
+        containerName: symbol.containerName,
+        path: toRelativePath(root, symbol.location.uri),
+        line: symbol.location.range.start.line + 1,
+        column: symbol.location.range.start.character + 1,
+      })),
+      null,
+      2,
+    ),
+  );
+}
+
+/**
+ * Outline of a file in the index; files not indexed yet are indexed first
+ */
+export async function readOutlineResource(
+  root: string,
+  relativePath: string,
+  context?: McpContext,
+): Promise<string> {
+  if (
+    !relativePath ||
+    isAbsolute(relativePath) ||
+    relativePath.split("/").includes("..")
+  ) {
+    throw new Error(`Invalid outline path: ${relativePath}`);
+  }
+  const isIndexed = () =>
+    getIndexedFiles(root).some(
+      (file) => file.split(sep).join("/") === relativePath,
+    );
+  if (!isIndexed()) {
+    await getOrCreateIndex(root, context)?.indexFile(relativePath);
+  }
+  if (!isIndexed()) {
+    throw new Error(`${relativePath} has no symbols in the index`);
+  }
+  const symbols = querySymbols(root, {
+    file: relativePath,
+    includeChildren: false,
+  });
+  return redact([relativePath, ...formatOutline(symbols, 1)].join("\n"));
+}
+
+function affects(root: string, uri: string, change: IndexChange): boolean {
+  if (uri.startsWith(SYMBOLS_PREFIX)) {
+    return true;
+  }
+  if (!uri.startsWith(OUTLINE_PREFIX)) {
+    return false;
+  }
+  return (
+    !change.uri ||
+    decodeUriVariable(uri.slice(OUTLINE_PREFIX.length)) ===
+      toRelativePath(root, change.uri)
+  );
+}
+
+/**
+ * Register the index resources for `root` on a server
+ */
+export function createIndexResources(
+  root: string,
+  context?: McpContext,
+): ResourceSetup {
+  return (server, subscriptions) => {
+    server.resource(
+      "symbols",
+      new ResourceTemplate(SYMBOLS_URI_TEMPLATE, { list: undefined }),
+      {
+        description: `Indexed symbols matching a fuzzy name query (up to ${MAX_RESOURCE_SYMBOLS}), as JSON`,
+        mimeType: "application/json",
+      },
+      async (uri, { query }) => ({
+        contents: [
+          {
+            uri: uri.href,
+            mimeType: "application/json",
+            text: readSymbolsResource(root, decodeUriVariable(query)),
+          },
+        ],
+      }),
+    );
+    server.resource(
+      "outline",
+      new ResourceTemplate(OUTLINE_URI_TEMPLATE, {
+        list: async () => ({
+          resources: getIndexedFiles(root).map((file) => ({
+            uri: outlineUri(file),
+            name: file.split(sep).join("/"),
+            mimeType: "text/plain",
+          })),
+        }),
+      }),
+      {
+        description: "Symbol tree of a file (path relative to the root)",
+        mimeType: "text/plain",
+      },
+      async (uri, { path }) => ({
+        contents: [
+          {
+            uri: uri.href,
+            mimeType: "text/plain",
+            text: await readOutlineResource(
+              root,
+              decodeUriVariable(path),
+              context,
+            ),
+          },
+        ],
+      }),
+    );
+
+    const stop = onIndexChange((change) => {
+      if (change.root === root) {
+        subscriptions.updated(
+          [...subscriptions.uris].filter((uri) => affects(root, uri, change)),
+        );
+      }
+    });
+    subscriptions.onClose(stop);
+  };
+}
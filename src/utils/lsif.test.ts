@@ -0,0 +1,153 @@
+import { describe, it, expect, vi } from "vitest";
+import { mkdtempSync, rmSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import { collectCodeIntel, type IntelDocument } from "./codeIntel.ts";
+import { formatLsif, toLsif } from "./lsif.ts";
+
+const range = (line: number, start: number, end: number) => ({
+  start: { line, character: start },
+  end: { line, character: end },
+});
+
+const a = "file:///project/src/a.ts";
+const b = "file:///project/src/b.ts";
+
+const documents: IntelDocument[] = [
+  {
+    uri: a,
+    relativePath: "src/a.ts",
+    languageId: "typescript",
+    symbols: [
+      {
+        name: "add",
+        kind: 12,
+        parents: [],
+        range: range(0, 16, 19),
+        fullRange: range(0, 0, 40),
+        hover: "```ts\nfunction add(a: number, b: number): number\n```",
+        references: [
+          { uri: b, range: range(2, 9, 12) },
+          { uri: "file:///elsewhere/x.ts", range: range(0, 0, 3) },
+        ],
+      },
+    ],
+  },
+  { uri: b, relativePath: "src/b.ts", languageId: "typescript", symbols: [] },
+];
+
+describe("toLsif", () => {
+  const elements = toLsif(documents, { root: "/project" });
+  const byLabel = (label: string) => elements.filter((e) => e.label === label);
+
+  it("should start with metaData and a project", () => {
+    expect(elements[0]).toMatchObject({
+      id: 1,
+      type: "vertex",
+      label: "metaData",
+      version: "0.4.3",
+      projectRoot: "file:///project",
+      toolInfo: { name: "lsmcp" },
+    });
+    expect(byLabel("project")).toHaveLength(1);
+    expect(byLabel("document").map((d) => d.uri)).toEqual([a, b]);
+  });
+
+  it("should link definition, hover and references through a result set", () => {
+    // The reference outside the dump has no document to belong to
+    expect(byLabel("range")).toHaveLength(2);
+    const [definition, reference] = byLabel("range");
+    const [resultSet] = byLabel("resultSet");
+    expect(byLabel("next").map((e) => [e.outV, e.inV])).toEqual([
+      [definition.id, resultSet.id],
+      [reference.id, resultSet.id],
+    ]);
+    expect(byLabel("hoverResult")[0].result).toEqual({
+      contents: { kind: "markdown", value: documents[0].symbols[0].hover },
+    });
+
+    const [referenceResult] = byLabel("referenceResult");
+    const documentB = byLabel("document")[1];
+    expect(
+      byLabel("item").filter((e) => e.outV === referenceResult.id),
+    ).toEqual([
+      expect.objectContaining({
+        inVs: [definition.id],
+        property: "definitions",
+      }),
+      expect.objectContaining({
+        inVs: [reference.id],
+        document: documentB.id,
+        property: "references",
+      }),
+    ]);
+  });
+
+  it("should add vertices before the edges that use them", () => {
+    const seen = new Set<number>();
+    for (const element of elements) {
+      if (element.type === "edge") {
+        const ids = [
+          element.outV,
+          element.inV,
+          ...((element.inVs as number[]) ?? []),
+        ].filter((id) => id !== undefined);
+        expect(ids.every((id) => seen.has(id as number))).toBe(true);
+      }
+      seen.add(element.id);
+    }
+  });
+
+  it("should write one element per line", () => {
+    const lines = formatLsif(documents, { root: "/project" }).split("\n");
+    expect(lines).toHaveLength(elements.length);
+    expect(JSON.parse(lines[0]).label).toBe("metaData");
+  });
+});
+
+describe("collectCodeIntel", () => {
+  it("should ask for hover and references at the symbol name", async () => {
+    const root = mkdtempSync(join(tmpdir(), "lsmcp-intel-"));
+    try {
+      const file = join(root, "a.ts");
+      writeFileSync(file, "export function add() {}\n");
+      const uri = pathToFileURL(file).toString();
+      const client = {
+        openDocument: vi.fn(),
+        closeDocument: vi.fn(),
+        getHover: vi.fn().mockResolvedValue({
+          contents: { language: "ts", value: "function add(): void" },
+        }),
+        findReferences: vi.fn().mockRejectedValue(new Error("timeout")),
+      } as any;
+
+      const [document] = await collectCodeIntel(root, client, [
+        {
+          name: "add",
+          kind: 12,
+          location: { uri, range: range(0, 0, 24) },
+        },
+      ]);
+
+      expect(client.getHover).toHaveBeenCalledWith(uri, {
+        line: 0,
+        character: 16,
+      });
+      expect(document).toMatchObject({
+        relativePath: "a.ts",
+        languageId: "typescript",
+        symbols: [
+          {
+            name: "add",
+            range: range(0, 16, 19),
+            hover: "```ts\nfunction add(): void\n```",
+            references: [],
+          },
+        ],
+      });
+    } finally {
+      rmSync(root, { recursive: true, force: true });
+    }
+  });
+});
@@ -0,0 +1,181 @@
+/**
+ * LSIF export of the symbol index
+ *
+ * Writes collected code intelligence as an LSIF 0.4.3 dump (one JSON vertex
+ * or edge per line) that Sourcegraph (`src code-intel upload`) and other
+ * code-intel systems ingest. Each definition gets a result set with its
+ * definition, hover and references; references outside the exported
+ * documents are left out since LSIF ranges belong to a document of the dump.
+ */
+
+import { pathToFileURL } from "url";
+import type { IntelDocument, IntelRange } from "./codeIntel.ts";
+
+export const LSIF_VERSION = "0.4.3";
+
+type LsifElement = { id: number; type: "vertex" | "edge"; label: string } & {
+  [key: string]: unknown;
+};
+
+export interface LsifOptions {
+  root: string;
+  /** lsmcp version reported in toolInfo */
+  version?: string;
+}
+
+function rangeKey(uri: string, range: IntelRange): string {
+  const { start, end } = range;
+  return `${uri}:${start.line}:${start.character}:${end.line}:${end.character}`;
+}
+
+/**
+ * The dump as LSIF elements, vertices before the edges that use them
+ */
+export function toLsif(
+  documents: IntelDocument[],
+  options: LsifOptions,
+): LsifElement[] {
+  const elements: LsifElement[] = [];
+  let nextId = 1;
+  const vertex = (label: string, fields: Record<string, unknown> = {}) => {
+    const id = nextId++;
+    elements.push({ id, type: "vertex", label, ...fields });
+    return id;
+  };
+  const edge = (label: string, fields: Record<string, unknown>) => {
+    elements.push({ id: nextId++, type: "edge", label, ...fields });
+  };
+
+  const rootUri = pathToFileURL(options.root).href;
+  vertex("metaData", {
+    version: LSIF_VERSION,
+    projectRoot: rootUri,
+    positionEncoding: "utf-16",
+    toolInfo: {
+      name: "lsmcp",
+      ...(options.version && { version: options.version }),
+    },
+  });
+  const projectId = vertex("project", {
+    kind: documents[0]?.languageId ?? "plaintext",
+  });
+
+  const documentIds = new Map<string, number>();
+  for (const document of documents) {
+    documentIds.set(
+      document.uri,
+      vertex("document", {
+        uri: document.uri,
+        languageId: document.languageId,
+      }),
+    );
+  }
+
+  // Every range once; a reference that is also a definition keeps the
+  // definition's result set
+  const ranges = new Map<string, number>();
+  const rangesByDocument = new Map<string, number[]>();
+  const addRange = (uri: string, range: IntelRange): number | undefined => {
+    if (!documentIds.has(uri)) {
+      return undefined;
+    }
+    const key = rangeKey(uri, range);
+    if (ranges.has(key)) {
+      return undefined;
+    }
+    const id = vertex("range", { start: range.start, end: range.end });
+    ranges.set(key, id);
+    if (!rangesByDocument.has(uri)) {
+      rangesByDocument.set(uri, []);
+    }
+    rangesByDocument.get(uri)!.push(id);
+    return id;
+  };
+
+  const definitions = documents.flatMap((document) =>
+    document.symbols.flatMap((symbol) => {
+      const rangeId = addRange(document.uri, symbol.range);
+      return rangeId === undefined ? [] : [{ document, symbol, rangeId }];
+    }),
+  );
+  const references = definitions.map(({ symbol }) =>
+    symbol.references.flatMap((reference) => {
+      const rangeId = addRange(reference.uri, reference.range);
+      return rangeId === undefined ? [] : [{ uri: reference.uri, rangeId }];
+    }),
+  );
+
+  edge("contains", {
+    outV: projectId,
+    inVs: [...documentIds.values()],
+  });
+  for (const [uri, inVs] of rangesByDocument) {
+    edge("contains", { outV: documentIds.get(uri), inVs });
+  }
+
+  definitions.forEach(({ document, symbol, rangeId }, i) => {
+    const resultSetId = vertex("resultSet");
+    const documentId = documentIds.get(document.uri);
+    edge("next", { outV: rangeId, inV: resultSetId });
+
+    const definitionId = vertex("definitionResult");
+    edge("textDocument/definition", {
+      outV: resultSetId,
+      inV: definitionId,
+    });
+    edge("item", {
+      outV: definitionId,
+      inVs: [rangeId],
+      document: documentId,
+    });
+
+    if (symbol.hover) {
+      const hoverId = vertex("hoverResult", {
+        result: { contents: { kind: "markdown", value: symbol.hover } },
+      });
+      edge("textDocument/hover", { outV: resultSetId, inV: hoverId });
+    }
+
+    const referenceId = vertex("referenceResult");
+    edge("textDocument/references", {
+      outV: resultSetId,
+      inV: referenceId,
+    });
+    edge("item", {
+      outV: referenceId,
+      inVs: [rangeId],
+      document: documentId,
+      property: "definitions",
+    });
+    const byDocument = new Map<string, number[]>();
+    for (const reference of references[i]) {
+      edge("next", { outV: reference.rangeId, inV: resultSetId });
+      if (!byDocument.has(reference.uri)) {
+        byDocument.set(reference.uri, []);
+      }
+      byDocument.get(reference.uri)!.push(reference.rangeId);
+    }
+    for (const [uri, inVs] of byDocument) {
+      edge("item", {
+        outV: referenceId,
+        inVs,
+        document: documentIds.get(uri),
+        property: "references",
+      });
+    }
+  });
+
+  return elements;
+}
+
+/**
+ * The dump as JSON lines, the form LSIF tools read
+ */
+export function formatLsif(
+  documents: IntelDocument[],
+  options: LsifOptions,
+): string {
+  return toLsif(documents, options)
+    .map((element) => JSON.stringify(element))
+    .join("\n");
+}
@@ -1,24 +1,14 @@
 /**
- * MCP Server Logger - Independent logging system for MCP server
+ * MCP Server Logger - the `mcp` scope of the structured logger
  *
  * This logger is separate from the LSP logger and can be controlled independently.
- * Use MCP_DEBUG=1 or LSMCP_DEBUG=1 environment variable to enable MCP server debug logging.
+ * Use `--log-level mcp=debug` (or MCP_DEBUG=1 / LSMCP_DEBUG=1) to enable MCP server debug logging.
  *
- * IMPORTANT: For MCP servers, all debug output must go to stderr (console.error)
+ * IMPORTANT: For MCP servers, all debug output must go to stderr or the log file
  * since stdout is used for MCP protocol communication.
  */
 
-/**
- * Check if MCP debug logging is enabled
- */
-function isMcpDebugEnabled(): boolean {
-  return (
-    process.env.MCP_DEBUG === "1" ||
-    process.env.MCP_DEBUG === "true" ||
-    process.env.LSMCP_DEBUG === "1" ||
-    process.env.LSMCP_DEBUG === "true"
-  );
-}
+import { writeLog } from "./structuredLog.ts";
 
 /**
  * MCP server debug logging function
@@ -30,9 +20,7 @@ function isMcpDebugEnabled(): boolean {
  * mcpDebug("[MCPServer] Handling tool call");
  */
 export function mcpDebug(...args: unknown[]): void {
-  if (isMcpDebugEnabled()) {
-    console.error("[MCP]", ...args);
-  }
+  writeLog("debug", "mcp", undefined, args);
 }
 
 /**
@@ -46,9 +34,7 @@ export function mcpDebug(...args: unknown[]): void {
  * mcpDebugWithPrefix("Protocol", "Received request:", data);
  */
 export function mcpDebugWithPrefix(prefix: string, ...args: unknown[]): void {
-  if (isMcpDebugEnabled()) {
-    console.error(`[MCP:${prefix}]`, ...args);
-  }
+  writeLog("debug", "mcp", prefix, args);
 }
 
 /**
@@ -60,7 +46,7 @@ export function mcpDebugWithPrefix(prefix: string, ...args: unknown[]): void {
  * mcpError("Failed to execute tool:", error.message);
  */
 export function mcpError(...args: unknown[]): void {
-  console.error("[MCP:ERROR]", ...args);
+  writeLog("error", "mcp", undefined, args);
 }
 
 /**
@@ -76,8 +62,8 @@ export function mcpConditionalDebug(
   condition: boolean,
   ...args: unknown[]
 ): void {
-  if (condition && isMcpDebugEnabled()) {
-    console.error("[MCP]", ...args);
+  if (condition) {
+    writeLog("debug", "mcp", undefined, args);
   }
 }
 
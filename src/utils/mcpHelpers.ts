@@ -7,6 +7,7 @@ import { type ZodType } from "zod";
 import * as fs from "node:fs";
 import { LSMCPError } from "../domain/errors/index.ts";
 import type { McpToolDef } from "@internal/types";
+import { mcpDebug } from "./mcp-logger.ts";
 
 /**
  * Debug logging for MCP servers.
@@ -18,16 +19,15 @@ import type { McpToolDef } from "@internal/types";
  * This function provides a convenient way to output debug messages that won't
  * interfere with MCP communication.
  *
- * Debug output is only shown when LSMCP_DEBUG=1 environment variable is set.
+ * Debug output is shown when the `mcp` scope logs at debug level (e.g.
+ * LSMCP_DEBUG=1 or `--log-level mcp=debug`).
  *
  * @example
  * debug("Server started");
  * debug("Processing request:", requestData);
  */
 export function debug(...args: unknown[]): void {
-  if (process.env.LSMCP_DEBUG === "1") {
-    console.error(...args);
-  }
+  mcpDebug(...args);
 }
 
 // Re-export commonly used types
@@ -1,9 +1,54 @@
-import { McpServer } from "@modelcontextprotocol/sdk/server/mcp.js";
-import { z, ZodObject, type ZodType } from "zod";
+import {
+  McpServer,
+  type RegisteredTool,
+} from "@modelcontextprotocol/sdk/server/mcp.js";
+import {
+  RootsListChangedNotificationSchema,
+  SetLevelRequestSchema,
+} from "@modelcontextprotocol/sdk/types.js";
+import { sep } from "path";
+import { fileURLToPath } from "url";
+import { z, ZodObject, type ZodRawShape, type ZodType } from "zod";
 import { createCompatibleTransport } from "./compatibleTransport.ts";
+import {
+  startHttpTransport,
+  type HttpServerHandle,
+  type HttpTransportOptions,
+} from "./httpTransport.ts";
 import type { McpToolDef, McpContext } from "@internal/types";
 import type { FileSystemApi } from "@internal/types";
 import { debugLogWithPrefix } from "./debugLog.ts";
+import { createProgressReporter } from "./progress.ts";
+import { createConfirmer } from "./confirmation.ts";
+import { createSampler } from "./sampling.ts";
+import { createLogger } from "./structuredLog.ts";
+import { assertArgumentsInSandbox, sandboxOptions } from "./pathSandbox.ts";
+import { redact } from "./redaction.ts";
+import {
+  estimateTokens,
+  fitStructured,
+  runWithTokenBudget,
+  type TokenBudget,
+} from "./tokenBudget.ts";
+import { metrics } from "./metrics.ts";
+import { SpanKind, withSpan } from "./tracing.ts";
+import { declareOutputSchema, structuredResult } from "./structuredOutput.ts";
+import { isLogLevelEnabled, type McpLogLevel } from "./serverMessages.ts";
+import { hasSideEffects } from "../tools/filterTools.ts";
+import {
+  createResourceSubscriptions,
+  type ResourceSubscriptions,
+} from "./resourceSubscriptions.ts";
+
+const toolLog = createLogger("mcp", "tool");
+const toolCalls = metrics.counter(
+  "lsmcp_tool_calls_total",
+  "MCP tool invocations by tool and status",
+);
+const toolDuration = metrics.histogram(
+  "lsmcp_tool_duration_seconds",
+  "MCP tool execution time",
+);
 
 /**
  * MCP Server configuration options
@@ -25,22 +70,195 @@ export interface McpServerOptions {
  */
 export interface McpServerState {
   server: McpServer;
+  options: McpServerOptions;
   tools: Map<string, McpToolDef<ZodType>>;
   defaultRoot?: string;
   fileSystemApi?: FileSystemApi;
   context?: McpContext;
+  /** Registered tools per server: "" for the stdio server, else session id */
+  registered: Map<string, Map<string, RegisteredTool>>;
+  /** Tools hidden by this filter are registered but disabled */
+  toolFilter?: (name: string) => boolean;
+  /** Servers log messages go to: "" for the stdio server, else session id */
+  servers: Map<string, McpServer>;
+  /** Levels clients asked for with logging/setLevel */
+  clientLogLevels: WeakMap<McpServer, McpLogLevel>;
+  /** Resource registrations, run on the stdio server and every session */
+  resourceSetups: ResourceSetup[];
+  /** Subscriptions per server, shared by all of its resources */
+  subscriptions: WeakMap<McpServer, ResourceSubscriptions>;
+}
+
+/**
+ * Registers resources on one MCP server; changed resources are reported
+ * through the server's subscriptions
+ */
+export type ResourceSetup = (
+  server: McpServer,
+  subscriptions: ResourceSubscriptions,
+) => void;
+
+/**
+ * Resource URI for a path relative to the root, e.g. lsmcp://outline/src/a.ts
+ */
+export function pathResourceUri(prefix: string, relativePath: string): string {
+  const segments = relativePath.split(sep).join("/").split("/");
+  return prefix + segments.map(encodeURIComponent).join("/");
+}
+
+/**
+ * Value of a URI template variable, percent-decoded
+ */
+export function decodeUriVariable(
+  value: string | string[] | undefined,
+): string {
+  const text = Array.isArray(value) ? value.join("/") : (value ?? "");
+  try {
+    return decodeURIComponent(text);
+  } catch {
+    return text;
+  }
+}
+
+/**
+ * Per-call budget arguments added to every tool; the names are reserved
+ */
+const BUDGET_PARAMS = {
+  maxTokens: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .describe(
+      "Approximate token budget for the result; longer results drop context lines, then are paginated",
+    ),
+  page: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .describe("Page of a result that exceeded maxTokens (1-based)"),
+};
+
+/** Results over budget each handler keeps for serving their later pages */
+const RENDERED_RESULTS = 32;
+
+interface RenderedResult {
+  text: string;
+  structured?: Record<string, unknown>;
+}
+
+/**
+ * Reject path arguments outside the workspace roots (the working directory
+ * without roots) unless the config turns the sandbox off
+ */
+function checkSandbox(
+  args: unknown,
+  context?: McpContext,
+  pathArgs?: string[],
+): void {
+  const options = sandboxOptions(context);
+  if (options) {
+    assertArgumentsInSandbox(args, options, pathArgs);
+  }
 }
 
 /**
  * Convert a string-returning handler to MCP response format with error handling
+ * Secrets in the result or error text are redacted, and the result is fit
+ * into the call's `maxTokens` (or the config's) budget; later pages come from
+ * the result of the first call, so a tool is not run again for them, and a
+ * tool with side effects refuses a page it no longer has. Successful results
+ * carry structuredContent: what the handler set when the tool declares an
+ * `outputSchema`, else `{ text }`. Tools can ask the user through
+ * `context.confirm` when `clientCapabilities` include elicitation, and its
+ * model through `context.sample` when they include sampling. `pathArgs` are
+ * the tool's path arguments the sandbox checks besides the usual ones.
  */
 export function toMcpToolHandler<T>(
   handler: (args: T, context?: McpContext) => Promise<string> | string,
   context?: McpContext,
+  toolName: string = (handler as any).name || "unknown",
+  outputSchema?: ZodRawShape,
+  clientCapabilities?: () => Record<string, unknown> | undefined,
+  pathArgs?: string[],
 ): (args: T, extra?: any) => Promise<any> {
-  return async (args: T, _extra?: any) => {
+  const rendered = new Map<string, RenderedResult>();
+  const sideEffects = hasSideEffects(toolName);
+  return async (args: T, extra?: any) => {
+    const started = Date.now();
+    toolLog.trace(`${toolName} called`, { args });
     try {
-      const message = await handler(args, context);
+      // Give this call its own progress reporter, cancellation signal,
+      // confirmation prompt, sampler and structured result
+      const reportProgress = createProgressReporter(extra);
+      const signal: AbortSignal | undefined = extra?.signal;
+      const capabilities = clientCapabilities?.();
+      const confirm = createConfirmer(extra, capabilities);
+      const sample = createSampler(extra, capabilities);
+      let structured: Record<string, unknown> | undefined;
+      const callContext = context && {
+        ...context,
+        reportProgress,
+        signal,
+        confirm,
+        sample,
+        setStructuredContent: (content: Record<string, unknown>) => {
+          structured = content;
+        },
+      };
+      checkSandbox(args, context, pathArgs);
+      const { maxTokens, page, ...toolArgs } = (args ?? {}) as TokenBudget &
+        Record<string, unknown>;
+      const budget: TokenBudget = {
+        maxTokens:
+          maxTokens ?? (context?.config?.maxTokens as number | undefined),
+        page,
+      };
+      const run = async (overrides?: Record<string, unknown>) => {
+        const key = JSON.stringify([
+          extra?.sessionId,
+          { ...toolArgs, ...overrides },
+        ]);
+        if ((page ?? 1) > 1) {
+          const earlier = rendered.get(key);
+          if (earlier) {
+            structured = earlier.structured;
+            return earlier.text;
+          }
+          if (sideEffects) {
+            throw new Error(
+              `page ${page} of this result is no longer available, and calling ${toolName} again would repeat what it did. Call it with a larger maxTokens instead.`,
+            );
+          }
+        }
+        // Redact before paginating so no secret is split across pages
+        const text = redact(
+          await handler({ ...toolArgs, ...overrides } as T, callContext),
+        );
+        if (budget.maxTokens && estimateTokens(text) > budget.maxTokens) {
+          rendered.delete(key);
+          rendered.set(key, { text, structured });
+          if (rendered.size > RENDERED_RESULTS) {
+            rendered.delete(rendered.keys().next().value!);
+          }
+        }
+        return text;
+      };
+      const message = await withSpan(
+        `tool ${toolName}`,
+        { "mcp.tool.name": toolName },
+        () => runWithTokenBudget(run, toolArgs, budget),
+        SpanKind.SERVER,
+      );
+      toolLog.info(toolName, { duration: Date.now() - started, ok: true });
+      toolCalls.inc({ tool: toolName, status: "ok" });
+      toolDuration.observeSince({ tool: toolName }, started);
+      const structuredContent = structuredResult(
+        structured && fitStructured(structured, budget),
+        message,
+        outputSchema !== undefined,
+      );
       return {
         content: [
           {
@@ -48,23 +266,26 @@ export function toMcpToolHandler<T>(
             text: message,
           },
         ],
+        // A typed tool that produced no structured result answered with an
+        // error message instead
+        ...(structuredContent ? { structuredContent } : { isError: true }),
       };
     } catch (error) {
-      debugLogWithPrefix(
-        "MCP",
-        `Tool execution error in ${
-          (handler as any).name || "unknown"
-        }: ${error}`,
-      );
-
       const errorMessage =
         error instanceof Error ? error.message : String(error);
+      toolLog.info(toolName, {
+        duration: Date.now() - started,
+        ok: false,
+        error: errorMessage,
+      });
+      toolCalls.inc({ tool: toolName, status: "error" });
+      toolDuration.observeSince({ tool: toolName }, started);
 
       return {
         content: [
           {
             type: "text",
-            text: `Error: ${errorMessage}`,
+            text: redact(`Error: ${errorMessage}`),
           },
         ],
         isError: true,
@@ -73,20 +294,43 @@ export function toMcpToolHandler<T>(
   };
 }
 
+/**
+ * Create an McpServer with the logging capability, remembering the level its
+ * client asks for
+ */
+function newServer(
+  options: McpServerOptions,
+  clientLogLevels: WeakMap<McpServer, McpLogLevel>,
+): McpServer {
+  const server = new McpServer(
+    { name: options.name, version: options.version },
+    { capabilities: { logging: {} } },
+  );
+  server.server.setRequestHandler(SetLevelRequestSchema, async (request) => {
+    clientLogLevels.set(server, request.params.level);
+    return {};
+  });
+  return server;
+}
+
 /**
  * Create an MCP server instance
  */
 export function createMcpServer(options: McpServerOptions): McpServerState {
-  const server = new McpServer({
-    name: options.name,
-    version: options.version,
-  });
+  const clientLogLevels = new WeakMap<McpServer, McpLogLevel>();
+  const server = newServer(options, clientLogLevels);
 
   return {
     server,
+    options,
     tools: new Map(),
     defaultRoot: undefined,
     fileSystemApi: options.fileSystemApi,
+    registered: new Map(),
+    servers: new Map([["", server]]),
+    clientLogLevels,
+    resourceSetups: [],
+    subscriptions: new WeakMap(),
   };
 }
 
@@ -105,7 +349,47 @@ export function registerTool<S extends ZodType>(
   tool: McpToolDef<S>,
 ): void {
   state.tools.set(tool.name, tool as unknown as McpToolDef<ZodType>);
-  _registerToolWithServer(state, tool);
+  trackTool(state, "", tool.name, _registerToolWithServer(state, tool));
+}
+
+function trackTool(
+  state: McpServerState,
+  serverKey: string,
+  name: string,
+  handle: RegisteredTool,
+): void {
+  let handles = state.registered.get(serverKey);
+  if (!handles) {
+    handles = new Map();
+    state.registered.set(serverKey, handles);
+  }
+  handles.set(name, handle);
+  if (state.toolFilter && !state.toolFilter(name)) {
+    handle.disable();
+  }
+}
+
+/**
+ * Show only the tools `filter` accepts, on every server and session
+ * Connected clients get notifications/tools/list_changed.
+ */
+export function setToolFilter(
+  state: McpServerState,
+  filter: ((name: string) => boolean) | undefined,
+): void {
+  state.toolFilter = filter;
+  for (const handles of state.registered.values()) {
+    for (const [name, handle] of handles) {
+      const enabled = !filter || filter(name);
+      if (handle.enabled !== enabled) {
+        if (enabled) {
+          handle.enable();
+        } else {
+          handle.disable();
+        }
+      }
+    }
+  }
 }
 
 /**
@@ -120,6 +404,30 @@ export function registerTools(
   }
 }
 
+/**
+ * Register resources on the server and on every session created later
+ */
+export function registerResources(
+  state: McpServerState,
+  setup: ResourceSetup,
+): void {
+  state.resourceSetups.push(setup);
+  setupResources(state, state.server, setup);
+}
+
+function setupResources(
+  state: McpServerState,
+  server: McpServer,
+  setup: ResourceSetup,
+): void {
+  let subscriptions = state.subscriptions.get(server);
+  if (!subscriptions) {
+    subscriptions = createResourceSubscriptions(server);
+    state.subscriptions.set(server, subscriptions);
+  }
+  setup(server, subscriptions);
+}
+
 /**
  * Start the server with stdio transport
  */
@@ -129,6 +437,127 @@ export async function startServer(state: McpServerState): Promise<void> {
   await state.server.connect(transport);
 }
 
+/**
+ * Per-session setup for transports that serve several clients
+ */
+export interface McpSessionHooks {
+  /** Build the tools and context for a new session */
+  createSession: (sessionId: string) => {
+    tools: McpToolDef<ZodType>[];
+    context?: McpContext;
+  };
+  /** Release resources held by a session */
+  closeSession?: (sessionId: string) => void;
+}
+
+/**
+ * Create a fresh MCP server for one session.
+ * Uses the registered tools unless the session provides its own.
+ */
+export function createSessionServer(
+  state: McpServerState,
+  session?: { tools: McpToolDef<ZodType>[]; context?: McpContext },
+  sessionId?: string,
+): McpServer {
+  const server = newServer(state.options, state.clientLogLevels);
+  const sessionState: McpServerState = session
+    ? { ...state, server, context: session.context ?? state.context }
+    : { ...state, server };
+  for (const tool of session?.tools ?? state.tools.values()) {
+    const handle = _registerToolWithServer(sessionState, tool);
+    if (sessionId !== undefined) {
+      trackTool(state, sessionId, tool.name, handle);
+    }
+  }
+  for (const setup of state.resourceSetups) {
+    setupResources(state, server, setup);
+  }
+  if (sessionId !== undefined) {
+    state.servers.set(sessionId, server);
+  }
+  return server;
+}
+
+/**
+ * Start the server with streamable HTTP transport
+ */
+export async function startHttpServer(
+  state: McpServerState,
+  options: HttpTransportOptions,
+  hooks?: McpSessionHooks,
+): Promise<HttpServerHandle> {
+  return startHttpTransport(
+    (sessionId) =>
+      createSessionServer(state, hooks?.createSession(sessionId), sessionId),
+    {
+      ...options,
+      onSessionClosed: (sessionId) => {
+        state.registered.delete(sessionId);
+        state.servers.delete(sessionId);
+        hooks?.closeSession?.(sessionId);
+        options.onSessionClosed?.(sessionId);
+      },
+    },
+  );
+}
+
+/**
+ * Follow the MCP client's workspace roots. The listener gets the file roots
+ * after initialization and whenever the client reports a change; clients
+ * without the roots capability are not asked.
+ */
+export function watchClientRoots(
+  state: McpServerState,
+  listener: (roots: string[]) => void | Promise<void>,
+): void {
+  const server = state.server.server;
+  const refresh = async () => {
+    if (!server.getClientCapabilities()?.roots) {
+      return;
+    }
+    try {
+      const { roots } = await server.listRoots();
+      await listener(
+        roots
+          .filter((root) => root.uri.startsWith("file:"))
+          .map((root) => fileURLToPath(root.uri)),
+      );
+    } catch (error) {
+      debugLogWithPrefix("MCP", `roots/list failed: ${error}`);
+    }
+  };
+
+  const previous = server.oninitialized;
+  server.oninitialized = () => {
+    previous?.();
+    void refresh();
+  };
+  server.setNotificationHandler(RootsListChangedNotificationSchema, refresh);
+}
+
+/**
+ * Send a log notification to every connected client at or above the level it
+ * asked for with logging/setLevel, or `minimum` if it did not ask; secrets in
+ * the text are redacted
+ */
+export function sendLogMessage(
+  state: McpServerState,
+  message: { level: McpLogLevel; logger: string; data: string },
+  minimum: McpLogLevel,
+): void {
+  for (const server of state.servers.values()) {
+    const threshold = state.clientLogLevels.get(server) ?? minimum;
+    if (!isLogLevelEnabled(message.level, threshold)) {
+      continue;
+    }
+    server.server
+      .sendLoggingMessage({ ...message, data: redact(message.data) })
+      .catch((error) => {
+        debugLogWithPrefix("MCP", `Failed to send log message: ${error}`);
+      });
+  }
+}
+
 /**
  * Get the underlying MCP server instance
  */
@@ -142,15 +571,16 @@ export function getServer(state: McpServerState): McpServer {
 function _registerToolWithServer<S extends ZodType>(
   state: McpServerState,
   tool: McpToolDef<S>,
-): void {
+): RegisteredTool {
+  const { server } = state;
   // Check if the schema is a ZodObject to extract shape
   if (tool.schema instanceof ZodObject) {
-    const schemaShape = tool.schema.shape;
+    const schemaShape = { ...tool.schema.shape, ...BUDGET_PARAMS };
 
     // Create a wrapper handler that adds default root if not provided
     const wrappedHandler =
       state.defaultRoot && "root" in schemaShape
-        ? (args: z.infer<S>) => {
+        ? (args: z.infer<S>, context?: McpContext) => {
             // If root is not provided in args, use the default
             const argsWithRoot = {
               ...args,
@@ -159,36 +589,42 @@ function _registerToolWithServer<S extends ZodType>(
                   ? (args as Record<string, unknown>).root
                   : undefined) || state.defaultRoot,
             } as z.infer<S>;
-            return tool.execute(argsWithRoot, state.context);
+            return tool.execute(argsWithRoot, context);
           }
-        : (args: z.infer<S>) => tool.execute(args, state.context);
+        : (args: z.infer<S>, context?: McpContext) =>
+            tool.execute(args, context);
 
     // Register tool with McpServer using the correct overload
-    if (tool.description) {
-      state.server.tool(
-        tool.name,
-        tool.description,
-        schemaShape,
-        toMcpToolHandler(wrappedHandler, state.context),
-      );
-    } else {
-      state.server.tool(
-        tool.name,
-        schemaShape,
-        toMcpToolHandler(wrappedHandler, state.context),
-      );
-    }
+    const handler = toMcpToolHandler(
+      wrappedHandler,
+      state.context,
+      tool.name,
+      tool.outputSchema,
+      () => server.server.getClientCapabilities(),
+      tool.pathArgs,
+    );
+    return declareOutputSchema(
+      tool.description
+        ? server.tool(tool.name, tool.description, schemaShape, handler)
+        : server.tool(tool.name, schemaShape, handler),
+      tool.outputSchema,
+    );
   } else {
     // For non-ZodObject schemas, register without shape
-    if (tool.description) {
-      state.server.tool(
-        tool.name,
-        tool.description,
-        toMcpToolHandler(tool.execute),
-      );
-    } else {
-      state.server.tool(tool.name, toMcpToolHandler(tool.execute));
-    }
+    const handler = toMcpToolHandler(
+      tool.execute,
+      state.context,
+      tool.name,
+      tool.outputSchema,
+      () => server.server.getClientCapabilities(),
+      tool.pathArgs,
+    );
+    return declareOutputSchema(
+      tool.description
+        ? server.tool(tool.name, tool.description, handler)
+        : server.tool(tool.name, handler),
+      tool.outputSchema,
+    );
   }
 }
 
@@ -201,7 +637,18 @@ export interface McpServerManager {
   setContext: (context: McpContext) => void;
   registerTool: <S extends ZodType>(tool: McpToolDef<S>) => void;
   registerTools: (tools: McpToolDef<ZodType>[]) => void;
+  registerResources: (setup: ResourceSetup) => void;
   start: () => Promise<void>;
+  startHttp: (
+    options: HttpTransportOptions,
+    hooks?: McpSessionHooks,
+  ) => Promise<HttpServerHandle>;
+  watchRoots: (listener: (roots: string[]) => void | Promise<void>) => void;
+  setToolFilter: (filter: ((name: string) => boolean) | undefined) => void;
+  log: (
+    message: { level: McpLogLevel; logger: string; data: string },
+    minimum: McpLogLevel,
+  ) => void;
   getServer: () => McpServer;
 }
 
@@ -223,7 +670,14 @@ export function createMcpServerManager(
       registerTool(state, tool),
     registerTools: (tools: McpToolDef<ZodType>[]) =>
       registerTools(state, tools),
+    registerResources: (setup: ResourceSetup) =>
+      registerResources(state, setup),
     start: () => startServer(state),
+    startHttp: (options: HttpTransportOptions, hooks?: McpSessionHooks) =>
+      startHttpServer(state, options, hooks),
+    watchRoots: (listener) => watchClientRoots(state, listener),
+    setToolFilter: (filter) => setToolFilter(state, filter),
+    log: (message, minimum) => sendLogMessage(state, message, minimum),
     getServer: () => getServer(state),
   };
 }
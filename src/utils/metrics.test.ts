@@ -0,0 +1,55 @@
+import { describe, it, expect } from "vitest";
+import { MetricsRegistry } from "./metrics.ts";
+
+describe("MetricsRegistry", () => {
+  it("should render counters with escaped labels", () => {
+    const registry = new MetricsRegistry();
+    const calls = registry.counter("tool_calls_total", "Tool calls");
+
+    calls.inc({ tool: "lsp_get_hover", status: "ok" });
+    calls.inc({ status: "ok", tool: "lsp_get_hover" });
+    calls.inc({ tool: 'say "hi"', status: "error" });
+
+    expect(registry.counter("tool_calls_total", "Tool calls")).toBe(calls);
+    expect(registry.render()).toBe(
+      [
+        "# HELP tool_calls_total Tool calls",
+        "# TYPE tool_calls_total counter",
+        'tool_calls_total{tool="lsp_get_hover",status="ok"} 2',
+        'tool_calls_total{tool="say \\"hi\\"",status="error"} 1',
+        "",
+      ].join("\n"),
+    );
+  });
+
+  it("should render cumulative histogram buckets", () => {
+    const registry = new MetricsRegistry();
+    const latency = registry.histogram("latency_seconds", "Latency", [0.1, 1]);
+
+    latency.observe({ method: "hover" }, 0.05);
+    latency.observe({ method: "hover" }, 0.5);
+    latency.observe({ method: "hover" }, 3);
+
+    expect(registry.render().split("\n").slice(2, 7)).toEqual([
+      'latency_seconds_bucket{method="hover",le="0.1"} 1',
+      'latency_seconds_bucket{method="hover",le="1"} 2',
+      'latency_seconds_bucket{method="hover",le="+Inf"} 3',
+      'latency_seconds_sum{method="hover"} 3.55',
+      'latency_seconds_count{method="hover"} 3',
+    ]);
+  });
+
+  it("should collect gauges at render time and reject type clashes", () => {
+    const registry = new MetricsRegistry();
+    let files = 1;
+    registry.gauge("index_files", "Indexed files", () => [
+      { labels: { root: "/repo" }, value: files },
+    ]);
+    files = 42;
+
+    expect(registry.render()).toContain('index_files{root="/repo"} 42');
+    expect(() => registry.counter("index_files", "Files")).toThrow(
+      "already a gauge",
+    );
+  });
+});
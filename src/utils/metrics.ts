@@ -0,0 +1,209 @@
+/**
+ * Prometheus metrics for the HTTP daemon
+ *
+ * A small registry of counters, histograms and gauges rendered in the
+ * Prometheus text exposition format on `/metrics`. Counters and histograms
+ * are updated where the work happens (tool calls, LSP requests, the result
+ * cache, server restarts); gauges are collected when the endpoint is scraped.
+ */
+
+export type Labels = Record<string, string>;
+
+export interface GaugeSample {
+  labels?: Labels;
+  value: number;
+}
+
+/** Latency buckets in seconds, from fast hovers to cold workspace scans */
+export const DEFAULT_BUCKETS = [
+  0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60,
+];
+
+interface Metric {
+  readonly type: "counter" | "histogram" | "gauge";
+  readonly help: string;
+  samples(name: string): string[];
+}
+
+function escapeLabel(value: string): string {
+  return value
+    .replace(/\\/g, "\\\\")
+    .replace(/"/g, '\\"')
+    .replace(/\n/g, "\\n");
+}
+
+function formatLabels(labels: Labels): string {
+  const entries = Object.entries(labels);
+  if (entries.length === 0) {
+    return "";
+  }
+  return `{${entries
+    .map(([key, value]) => `${key}="${escapeLabel(value)}"`)
+    .join(",")}}`;
+}
+
+function labelKey(labels: Labels): string {
+  return JSON.stringify(
+    Object.entries(labels).sort(([a], [b]) => a.localeCompare(b)),
+  );
+}
+
+export class Counter implements Metric {
+  readonly type = "counter";
+  private values = new Map<string, { labels: Labels; value: number }>();
+
+  constructor(readonly help: string) {}
+
+  inc(labels: Labels = {}, value = 1): void {
+    const key = labelKey(labels);
+    const entry = this.values.get(key) ?? { labels, value: 0 };
+    entry.value += value;
+    this.values.set(key, entry);
+  }
+
+  get(labels: Labels = {}): number {
+    return this.values.get(labelKey(labels))?.value ?? 0;
+  }
+
+  samples(name: string): string[] {
+    return [...this.values.values()].map(
+      ({ labels, value }) => `${name}${formatLabels(labels)} ${value}`,
+    );
+  }
+}
+
+interface HistogramSeries {
+  labels: Labels;
+  counts: number[];
+  sum: number;
+  count: number;
+}
+
+export class Histogram implements Metric {
+  readonly type = "histogram";
+  private series = new Map<string, HistogramSeries>();
+
+  constructor(
+    readonly help: string,
+    private buckets: number[] = DEFAULT_BUCKETS,
+  ) {}
+
+  observe(labels: Labels, value: number): void {
+    const key = labelKey(labels);
+    const series = this.series.get(key) ?? {
+      labels,
+      counts: this.buckets.map(() => 0),
+      sum: 0,
+      count: 0,
+    };
+    this.series.set(key, series);
+    this.buckets.forEach((bound, i) => {
+      if (value <= bound) {
+        series.counts[i]++;
+      }
+    });
+    series.sum += value;
+    series.count++;
+  }
+
+  /**
+   * Observe the seconds elapsed since `started` (a Date.now() value)
+   */
+  observeSince(labels: Labels, started: number): void {
+    this.observe(labels, (Date.now() - started) / 1000);
+  }
+
+  samples(name: string): string[] {
+    const lines: string[] = [];
+    for (const { labels, counts, sum, count } of this.series.values()) {
+      this.buckets.forEach((bound, i) => {
+        lines.push(
+          `${name}_bucket${formatLabels({ ...labels, le: String(bound) })} ${counts[i]}`,
+        );
+      });
+      lines.push(
+        `${name}_bucket${formatLabels({ ...labels, le: "+Inf" })} ${count}`,
+        `${name}_sum${formatLabels(labels)} ${sum}`,
+        `${name}_count${formatLabels(labels)} ${count}`,
+      );
+    }
+    return lines;
+  }
+}
+
+class Gauge implements Metric {
+  readonly type = "gauge";
+
+  constructor(
+    readonly help: string,
+    private collect: () => GaugeSample[],
+  ) {}
+
+  samples(name: string): string[] {
+    let samples: GaugeSample[];
+    try {
+      samples = this.collect();
+    } catch {
+      // A failing collector must not break the whole scrape
+      return [];
+    }
+    return samples.map(
+      ({ labels = {}, value }) => `${name}${formatLabels(labels)} ${value}`,
+    );
+  }
+}
+
+export class MetricsRegistry {
+  private metrics = new Map<string, Metric>();
+
+  /** Counter by name; registering a name again returns the same counter */
+  counter(name: string, help: string): Counter {
+    return this.register(name, () => new Counter(help), "counter");
+  }
+
+  histogram(name: string, help: string, buckets?: number[]): Histogram {
+    return this.register(
+      name,
+      () => new Histogram(help, buckets),
+      "histogram",
+    );
+  }
+
+  /**
+   * Gauge read at scrape time; registering a name again replaces it
+   */
+  gauge(name: string, help: string, collect: () => GaugeSample[]): void {
+    this.metrics.set(name, new Gauge(help, collect));
+  }
+
+  /** Prometheus text exposition format */
+  render(): string {
+    const lines: string[] = [];
+    for (const [name, metric] of this.metrics) {
+      lines.push(`# HELP ${name} ${metric.help}`);
+      lines.push(`# TYPE ${name} ${metric.type}`);
+      lines.push(...metric.samples(name));
+    }
+    return lines.join("\n") + "\n";
+  }
+
+  private register<T extends Metric>(
+    name: string,
+    create: () => T,
+    type: Metric["type"],
+  ): T {
+    const existing = this.metrics.get(name);
+    if (existing) {
+      if (existing.type !== type) {
+        throw new Error(`Metric ${name} is already a ${existing.type}`);
+      }
+      return existing as T;
+    }
+    const metric = create();
+    this.metrics.set(name, metric);
+    return metric;
+  }
+}
+
+/** Registry served on the HTTP daemon's /metrics endpoint */
+export const metrics = new MetricsRegistry();
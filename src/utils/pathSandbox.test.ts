@@ -0,0 +1,175 @@
+import { describe, it, expect } from "vitest";
+import { mkdirSync, mkdtempSync, symlinkSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import {
+  assertArgumentsInSandbox,
+  assertNotLsmcpFiles,
+  assertPathsInSandbox,
+  sandboxOptions,
+} from "./pathSandbox.ts";
+
+function workspace() {
+  const base = mkdtempSync(join(tmpdir(), "lsmcp-sandbox-"));
+  const root = join(base, "project");
+  const outside = join(base, "outside");
+  mkdirSync(join(root, "src"), { recursive: true });
+  mkdirSync(outside);
+  writeFileSync(join(root, "src", "main.go"), "package main\n");
+  writeFileSync(join(outside, "secret.txt"), "secret");
+  return { root, outside };
+}
+
+describe("assertArgumentsInSandbox", () => {
+  it("should accept paths inside the root, including new files", () => {
+    const { root } = workspace();
+
+    expect(() =>
+      assertArgumentsInSandbox(
+        { root, relativePath: "src/main.go", filePath: "src/new.go" },
+        { roots: [root] },
+      ),
+    ).not.toThrow();
+  });
+
+  it("should reject traversal and absolute paths outside the roots", () => {
+    const { root } = workspace();
+
+    expect(() =>
+      assertArgumentsInSandbox(
+        { root, relativePath: "../../etc/passwd" },
+        { roots: [root] },
+      ),
+    ).toThrow(/outside the workspace/);
+    expect(() =>
+      assertArgumentsInSandbox({ root: "/etc" }, { roots: [root] }),
+    ).toThrow(/outside the workspace/);
+  });
+
+  it("should follow symlinks out of the workspace", () => {
+    const { root, outside } = workspace();
+    symlinkSync(outside, join(root, "link"));
+
+    expect(() =>
+      assertArgumentsInSandbox(
+        { root, relativePath: "link/secret.txt" },
+        { roots: [root] },
+      ),
+    ).toThrow(/outside the workspace/);
+    expect(() =>
+      assertArgumentsInSandbox(
+        { root, relativePath: "link/secret.txt" },
+        { roots: [root], paths: [outside] },
+      ),
+    ).not.toThrow();
+  });
+
+  it("should check nested requests and workspace edit URIs", () => {
+    const { root, outside } = workspace();
+    const uri = pathToFileURL(join(outside, "secret.txt")).toString();
+
+    expect(() =>
+      assertArgumentsInSandbox(
+        { root, requests: [{ relativePath: "../outside/secret.txt" }] },
+        { roots: [root] },
+      ),
+    ).toThrow(/outside the workspace/);
+    expect(() =>
+      assertArgumentsInSandbox(
+        { root, edit: { changes: { [uri]: [] } } },
+        { roots: [root] },
+      ),
+    ).toThrow(/outside the workspace/);
+  });
+
+  it("should reject memory names that leave the memories directory", () => {
+    const { root } = workspace();
+
+    expect(() =>
+      assertArgumentsInSandbox(
+        { root, memoryName: "../../config" },
+        { roots: [root] },
+      ),
+    ).toThrow(/invalid memoryName/);
+  });
+
+  it("should check the path arguments a tool declares", () => {
+    const { root } = workspace();
+    const args = { root, relativePath: "src/main.go", newPath: "../x" };
+
+    expect(() =>
+      assertArgumentsInSandbox(args, { roots: [root] }),
+    ).not.toThrow();
+    expect(() =>
+      assertArgumentsInSandbox(args, { roots: [root] }, ["newPath"]),
+    ).toThrow(/outside the workspace/);
+  });
+});
+
+describe("assertPathsInSandbox", () => {
+  it("should check resolved paths and URIs a tool computed", () => {
+    const { root, outside } = workspace();
+    const options = sandboxOptions({ roots: [root] });
+
+    expect(() =>
+      assertPathsInSandbox(
+        [join(root, "src/main.go"), pathToFileURL(join(root, "new.go")).href],
+        options,
+      ),
+    ).not.toThrow();
+    expect(() =>
+      assertPathsInSandbox([join(outside, "secret.txt")], options),
+    ).toThrow(/outside the workspace/);
+    expect(() => assertPathsInSandbox(["src/main.go"], options)).toThrow(
+      /not an absolute path/,
+    );
+  });
+
+  it("should fall back to one root when the context has none", () => {
+    const { root, outside } = workspace();
+
+    expect(sandboxOptions(undefined, root)).toEqual({
+      roots: [root],
+      paths: undefined,
+    });
+    expect(sandboxOptions({ roots: [] })?.roots).toEqual([process.cwd()]);
+    expect(() =>
+      assertPathsInSandbox(
+        [join(outside, "secret.txt")],
+        sandboxOptions({}, root),
+      ),
+    ).toThrow(/outside the workspace/);
+  });
+
+  it("should let everything through when the sandbox is off", () => {
+    const { root, outside } = workspace();
+    const options = sandboxOptions({
+      roots: [root],
+      config: { sandbox: { enabled: false } },
+    });
+
+    expect(options).toBeUndefined();
+    expect(() =>
+      assertPathsInSandbox([join(outside, "secret.txt")], options),
+    ).not.toThrow();
+  });
+});
+
+describe("assertNotLsmcpFiles", () => {
+  it("should refuse lsmcp's config and caches", () => {
+    const { root } = workspace();
+
+    expect(() =>
+      assertNotLsmcpFiles([join(root, ".lsmcp", "config.json")]),
+    ).toThrow(/inside \.lsmcp/);
+    expect(() =>
+      assertNotLsmcpFiles([
+        pathToFileURL(join(root, ".lsmcp", "cache", "symbols.db")).toString(),
+      ]),
+    ).toThrow(/inside \.lsmcp/);
+    expect(() =>
+      assertNotLsmcpFiles([join(root, "src", "lsmcp.go")]),
+    ).not.toThrow();
+  });
+});
@@ -0,0 +1,202 @@
+/**
+ * Keep tool arguments inside the workspace
+ *
+ * Every path-like argument (root, relativePath, filePath, file, file:// URIs)
+ * must resolve inside a workspace root or an extra allowed directory after
+ * symlinks are resolved, so `../../etc/passwd` or a symlink pointing out of
+ * the project cannot be read or written through the tools. Paths a tool
+ * resolves itself are checked with assertPathsInSandbox, which every
+ * WorkspaceEdit goes through before it is written, including edits the
+ * language server computed. Edit tools never write under .lsmcp, whose config
+ * is applied on reload.
+ */
+
+import { realpathSync } from "fs";
+import { homedir } from "os";
+import { basename, dirname, isAbsolute, join, resolve, sep } from "path";
+import { fileURLToPath } from "url";
+
+/** Argument names holding a file or directory path */
+const PATH_KEYS = ["root", "relativePath", "filePath", "file", "path"];
+
+/** Argument names holding a file name inside an lsmcp directory */
+const NAME_KEYS = ["memoryName"];
+
+/** Directory of lsmcp's config, caches and memories in a project */
+const LSMCP_DIR = ".lsmcp";
+
+export interface SandboxOptions {
+  /** Workspace roots, primary root first */
+  roots: string[];
+  /** Additional directories tools may access (`~/` is the home directory) */
+  paths?: string[];
+}
+
+/**
+ * Resolve symlinks, including for paths that do not exist yet (files a tool
+ * is about to create): the nearest existing ancestor is resolved instead
+ */
+function realPath(path: string): string {
+  try {
+    return realpathSync.native(path);
+  } catch {
+    const parent = dirname(path);
+    if (parent === path) {
+      return path;
+    }
+    return resolve(realPath(parent), basename(path));
+  }
+}
+
+function isInside(path: string, directory: string): boolean {
+  return (
+    path === directory ||
+    path.startsWith(directory.endsWith(sep) ? directory : directory + sep)
+  );
+}
+
+interface Allowed {
+  roots: string[];
+  /** Roots and extra paths with symlinks resolved */
+  directories: string[];
+}
+
+function allowedDirectories(options: SandboxOptions): Allowed {
+  const directories = [...options.roots, ...(options.paths ?? [])].map((dir) =>
+    realPath(
+      dir.startsWith("~/")
+        ? join(homedir(), dir.slice(2))
+        : resolve(options.roots[0], dir),
+    ),
+  );
+  return { roots: options.roots, directories };
+}
+
+function assertInside(value: string, target: string, allowed: Allowed) {
+  const path = realPath(target);
+  if (!allowed.directories.some((dir) => isInside(path, dir))) {
+    throw new Error(
+      `Access denied: '${value}' is outside the workspace (${allowed.roots.join(", ")})`,
+    );
+  }
+}
+
+/**
+ * Sandbox of a tool call: the context's workspace roots and the `sandbox`
+ * config, or undefined when the sandbox is off
+ * @param fallbackRoot the only root when the context has none
+ */
+export function sandboxOptions(
+  context?: {
+    roots?: string[];
+    config?: Record<string, unknown>;
+  },
+  fallbackRoot: string = process.cwd(),
+): SandboxOptions | undefined {
+  const sandbox = context?.config?.sandbox as
+    | { enabled?: boolean; paths?: string[] }
+    | undefined;
+  if (sandbox?.enabled === false) {
+    return undefined;
+  }
+  const roots = context?.roots?.length ? context.roots : [fallbackRoot];
+  return { roots, paths: sandbox?.paths };
+}
+
+/**
+ * Throw when a path a tool resolved itself (absolute, or a file:// URI)
+ * points outside the allowed directories; anything else is rejected
+ */
+export function assertPathsInSandbox(
+  paths: string[],
+  options: SandboxOptions | undefined,
+): void {
+  if (!options || options.roots.length === 0) {
+    return;
+  }
+  const allowed = allowedDirectories(options);
+  for (const path of paths) {
+    const target = path.startsWith("file://") ? fileURLToPath(path) : path;
+    if (!isAbsolute(target)) {
+      throw new Error(`Access denied: '${path}' is not an absolute path`);
+    }
+    assertInside(path, target, allowed);
+  }
+}
+
+/**
+ * Throw for files inside a .lsmcp directory, which edit tools must not change
+ */
+export function assertNotLsmcpFiles(paths: string[]): void {
+  for (const path of paths) {
+    const target = path.startsWith("file://") ? fileURLToPath(path) : path;
+    if (resolve(target).split(sep).includes(LSMCP_DIR)) {
+      throw new Error(
+        `Access denied: '${path}' is inside ${LSMCP_DIR}; edit tools cannot change lsmcp's own files`,
+      );
+    }
+  }
+}
+
+/**
+ * Throw when an argument points outside the allowed directories; `pathKeys`
+ * names the tool's own path arguments
+ */
+export function assertArgumentsInSandbox(
+  args: unknown,
+  options: SandboxOptions,
+  pathKeys: string[] = [],
+): void {
+  if (options.roots.length === 0) {
+    return;
+  }
+  const allowed = allowedDirectories(options);
+  const keys = [...PATH_KEYS, ...pathKeys];
+
+  const check = (value: string, base: string) =>
+    assertInside(
+      value,
+      isAbsolute(value) ? value : resolve(base, value),
+      allowed,
+    );
+  const checkUri = (value: string) => {
+    if (value.startsWith("file://")) {
+      check(fileURLToPath(value), options.roots[0]);
+    }
+  };
+
+  const visit = (value: unknown, base: string, key?: string) => {
+    if (typeof value === "string") {
+      if (key && keys.includes(key)) {
+        check(value, base);
+      } else if (key && NAME_KEYS.includes(key)) {
+        if (/[\\/]/.test(value) || value.includes("..")) {
+          throw new Error(`Access denied: invalid ${key} '${value}'`);
+        }
+      } else {
+        checkUri(value);
+      }
+      return;
+    }
+    if (Array.isArray(value)) {
+      value.forEach((item) => visit(item, base, key));
+      return;
+    }
+    if (value && typeof value === "object") {
+      const record = value as Record<string, unknown>;
+      // Paths are relative to the root given next to them
+      if (typeof record.root === "string") {
+        check(record.root, base);
+        base = resolve(base, record.root);
+      }
+      for (const [childKey, child] of Object.entries(record)) {
+        // WorkspaceEdit.changes is keyed by document URI
+        checkUri(childKey);
+        if (childKey !== "root") {
+          visit(child, base, childKey);
+        }
+      }
+    }
+  };
+  visit(args, options.roots[0]);
+}
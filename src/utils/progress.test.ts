@@ -0,0 +1,94 @@
+import { describe, it, expect, vi } from "vitest";
+import type { McpContext } from "@internal/types";
+import {
+  createIndexProgressHandler,
+  createProgressReporter,
+  formatFileProgress,
+} from "./progress.ts";
+
+describe("createProgressReporter", () => {
+  it("should return undefined without a progressToken", () => {
+    expect(createProgressReporter(undefined)).toBeUndefined();
+    expect(
+      createProgressReporter({ sendNotification: vi.fn(), _meta: {} }),
+    ).toBeUndefined();
+  });
+
+  it("should send notifications with the client's token", () => {
+    const sendNotification = vi.fn().mockResolvedValue(undefined);
+    const report = createProgressReporter({
+      _meta: { progressToken: "abc" },
+      sendNotification,
+    })!;
+
+    report({ progress: 1, total: 10, message: "a.ts" });
+
+    expect(sendNotification).toHaveBeenCalledWith({
+      method: "notifications/progress",
+      params: { progressToken: "abc", progress: 1, total: 10, message: "a.ts" },
+    });
+  });
+
+  it("should throttle intermediate updates but always send the final one", () => {
+    const sendNotification = vi.fn().mockResolvedValue(undefined);
+    let time = 0;
+    const report = createProgressReporter(
+      { _meta: { progressToken: 1 }, sendNotification },
+      () => time,
+    )!;
+
+    report({ progress: 1, total: 3 });
+    time = 10;
+    report({ progress: 2, total: 3 });
+    time = 20;
+    report({ progress: 3, total: 3 });
+
+    expect(sendNotification).toHaveBeenCalledTimes(2);
+    expect(sendNotification.mock.calls[1][0].params.progress).toBe(3);
+  });
+
+  it("should drop updates that do not increase progress", () => {
+    const sendNotification = vi.fn().mockResolvedValue(undefined);
+    let time = 0;
+    const report = createProgressReporter(
+      { _meta: { progressToken: 1 }, sendNotification },
+      () => time,
+    )!;
+
+    report({ progress: 5 });
+    time = 1000;
+    report({ progress: 5 });
+
+    expect(sendNotification).toHaveBeenCalledTimes(1);
+  });
+});
+
+describe("formatFileProgress", () => {
+  it("should include percentage and current file", () => {
+    expect(formatFileProgress(5, 20, "src/a.ts")).toBe(
+      "Indexed 5/20 files (25%): src/a.ts",
+    );
+    expect(formatFileProgress(0, 0)).toBe("Indexed 0/0 files (100%)");
+  });
+});
+
+describe("createIndexProgressHandler", () => {
+  it("should map indexer progress to MCP progress", () => {
+    const reportProgress = vi.fn();
+    const handler = createIndexProgressHandler({
+      reportProgress,
+    } as unknown as McpContext)!;
+
+    handler({ current: 2, total: 4, file: "src/b.ts" });
+
+    expect(reportProgress).toHaveBeenCalledWith({
+      progress: 2,
+      total: 4,
+      message: "Indexed 2/4 files (50%): src/b.ts",
+    });
+  });
+
+  it("should be undefined when the call has no progress reporter", () => {
+    expect(createIndexProgressHandler(undefined)).toBeUndefined();
+  });
+});
@@ -0,0 +1,94 @@
+/**
+ * MCP progress notifications
+ */
+
+import type { McpContext, McpProgress } from "@internal/types";
+import type { IndexProgressCallback } from "@internal/code-indexer";
+import { debugLogWithPrefix } from "./debugLog.ts";
+
+/** Minimum interval between notifications, except for the final one */
+export const PROGRESS_THROTTLE_MS = 100;
+
+type ProgressToken = string | number;
+
+export interface ProgressNotification {
+  method: "notifications/progress";
+  params: {
+    progressToken: ProgressToken;
+    progress: number;
+    total?: number;
+    message?: string;
+  };
+}
+
+/**
+ * Subset of the MCP SDK request handler extra that carries progress info
+ */
+export interface ProgressRequestExtra {
+  _meta?: { progressToken?: ProgressToken };
+  sendNotification?: (notification: ProgressNotification) => Promise<void>;
+}
+
+/**
+ * Create a progress reporter for a tool call.
+ * Returns undefined when the client did not ask for progress.
+ */
+export function createProgressReporter(
+  extra: ProgressRequestExtra | undefined,
+  now: () => number = Date.now,
+): ((update: McpProgress) => void) | undefined {
+  const progressToken = extra?._meta?.progressToken;
+  const sendNotification = extra?.sendNotification;
+  if (progressToken === undefined || !sendNotification) {
+    return undefined;
+  }
+
+  let lastSent = -Infinity;
+  let lastProgress = -Infinity;
+  return (update: McpProgress) => {
+    const done = update.total !== undefined && update.progress >= update.total;
+    const time = now();
+    // Progress must increase, and intermediate updates are throttled
+    if (update.progress <= lastProgress) return;
+    if (!done && time - lastSent < PROGRESS_THROTTLE_MS) return;
+
+    lastSent = time;
+    lastProgress = update.progress;
+    sendNotification({
+      method: "notifications/progress",
+      params: { progressToken, ...update },
+    }).catch((error) => {
+      debugLogWithPrefix("MCP", `Failed to send progress: ${error}`);
+    });
+  };
+}
+
+/**
+ * Format indexing progress as "n/total path"
+ */
+export function formatFileProgress(
+  current: number,
+  total: number,
+  file?: string,
+): string {
+  const percentage = total > 0 ? Math.round((current / total) * 100) : 100;
+  return `Indexed ${current}/${total} files (${percentage}%)${file ? `: ${file}` : ""}`;
+}
+
+/**
+ * Forward symbol indexer progress to the MCP client, if it asked for progress
+ */
+export function createIndexProgressHandler(
+  context?: McpContext,
+): IndexProgressCallback | undefined {
+  const reportProgress = context?.reportProgress;
+  if (!reportProgress) {
+    return undefined;
+  }
+  return ({ current, total, file }) =>
+    reportProgress({
+      progress: current,
+      total,
+      message: formatFileProgress(current, total, file),
+    });
+}
@@ -0,0 +1,130 @@
+import { describe, it, expect, beforeEach } from "vitest";
+import { mkdirSync, mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  countFilesByExtension,
+  detectProjectType,
+  formatDetectionReport,
+  selectProjectPreset,
+} from "./projectDetector.ts";
+
+function writeFiles(root: string, files: Record<string, string>): void {
+  for (const [path, content] of Object.entries(files)) {
+    mkdirSync(join(root, path, ".."), { recursive: true });
+    writeFileSync(join(root, path), content);
+  }
+}
+
+describe("detectProjectType", () => {
+  let root: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-detect-"));
+  });
+
+  it("should detect marker files", async () => {
+    writeFiles(root, {
+      "go.mod": "module example.com/app\n",
+      "Cargo.toml": "[package]\n",
+      "pyproject.toml": '[project]\nname = "app"\n',
+      "CMakeLists.txt": "project(app)\n",
+    });
+
+    expect((await detectProjectType(root)).map((d) => d.preset)).toEqual([
+      "rust-analyzer",
+      "pyright",
+      "gopls",
+      "clangd",
+    ]);
+  });
+
+  it("should treat a package.json without tsconfig as typescript", async () => {
+    writeFiles(root, { "package.json": '{ "name": "app" }' });
+
+    expect(await detectProjectType(root)).toEqual([
+      { preset: "typescript", reason: "Found package.json" },
+    ]);
+  });
+
+  it("should pick basedpyright when pyproject.toml configures it", async () => {
+    writeFiles(root, {
+      "pyproject.toml": '[tool.basedpyright]\ntypeCheckingMode = "all"\n',
+    });
+
+    expect((await detectProjectType(root))[0].preset).toBe("basedpyright");
+  });
+});
+
+describe("selectProjectPreset", () => {
+  let root: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-detect-"));
+  });
+
+  it("should return null without markers", async () => {
+    expect(await selectProjectPreset(root)).toBeNull();
+  });
+
+  it("should prefer the language with the most source files", async () => {
+    writeFiles(root, {
+      "go.mod": "module example.com/app\n",
+      "package.json": '{ "name": "tools" }',
+      "main.go": "package main\n",
+      "internal/server/server.go": "package server\n",
+      "scripts/release.js": "",
+      "node_modules/dep/index.js": "",
+      "node_modules/dep/lib.js": "",
+    });
+
+    const selection = await selectProjectPreset(root);
+
+    expect(selection?.preset).toBe("gopls");
+    expect(selection?.detected.map((d) => d.sourceFiles)).toEqual([1, 2]);
+  });
+
+  it("should keep detection order on ties", async () => {
+    writeFiles(root, {
+      "Cargo.toml": "[package]\n",
+      "go.mod": "module example.com/app\n",
+    });
+
+    expect((await selectProjectPreset(root))?.preset).toBe("rust-analyzer");
+  });
+});
+
+describe("countFilesByExtension", () => {
+  it("should skip dot and dependency directories", () => {
+    const root = mkdtempSync(join(tmpdir(), "lsmcp-detect-"));
+    writeFiles(root, {
+      "src/lib.rs": "",
+      "target/debug/build.rs": "",
+      ".git/hooks/pre-commit.rs": "",
+      "README.md": "",
+    });
+
+    const counts = countFilesByExtension(root);
+
+    expect(counts.get(".rs")).toBe(1);
+    expect(counts.get(".md")).toBe(1);
+  });
+});
+
+describe("formatDetectionReport", () => {
+  it("should mark the selected preset", () => {
+    const report = formatDetectionReport({
+      preset: "gopls",
+      detected: [
+        { preset: "typescript", reason: "Found package.json", sourceFiles: 1 },
+        { preset: "gopls", reason: "Found go.mod", sourceFiles: 12 },
+      ],
+    });
+
+    expect(report).toContain(
+      "    typescript: Found package.json (1 source files)",
+    );
+    expect(report).toContain("  * gopls: Found go.mod (12 source files)");
+    expect(report).toContain("Using preset 'gopls'");
+  });
+});
@@ -11,6 +11,13 @@ import { LSMCPError, ErrorCode } from "../domain/errors/index.ts";
 interface DetectedProject {
   preset: string;
   reason: string;
+  /** Source files of the preset's language (set by selectProjectPreset) */
+  sourceFiles?: number;
+}
+
+export interface ProjectSelection {
+  preset: string;
+  detected: DetectedProject[];
 }
 
 /**
@@ -59,6 +66,16 @@ export async function detectProjectType(
           reason: "Found package.json and tsconfig.json",
         });
       }
+      // Plain Node.js project (typescript-language-server handles JS too)
+      else if (
+        !existsSync(join(projectRoot, "deno.json")) &&
+        !existsSync(join(projectRoot, "deno.jsonc"))
+      ) {
+        detected.push({
+          preset: "typescript",
+          reason: "Found package.json",
+        });
+      }
     } catch (error) {
       // Log parse errors but don't fail
       debug(`Failed to parse package.json: ${error}`);
@@ -117,7 +134,9 @@ export async function detectProjectType(
   for (const file of pythonFiles) {
     if (existsSync(join(projectRoot, file))) {
       detected.push({
-        preset: "pyright",
+        preset: (await usesBasedpyright(projectRoot))
+          ? "basedpyright"
+          : "pyright",
         reason: `Found ${file}`,
       });
       break;
@@ -132,9 +151,147 @@ export async function detectProjectType(
     });
   }
 
+  // Check for C/C++
+  const cFiles = [
+    "compile_commands.json",
+    "CMakeLists.txt",
+    "meson.build",
+    "compile_flags.txt",
+  ];
+  const cFile = cFiles.find((file) => existsSync(join(projectRoot, file)));
+  if (cFile) {
+    detected.push({
+      preset: "clangd",
+      reason: `Found ${cFile}`,
+    });
+  }
+
   return detected;
 }
 
+async function usesBasedpyright(projectRoot: string): Promise<boolean> {
+  try {
+    const pyproject = await readFile(
+      join(projectRoot, "pyproject.toml"),
+      "utf-8",
+    );
+    return /^\[tool\.basedpyright\]|["']basedpyright\b/m.test(pyproject);
+  } catch {
+    return false;
+  }
+}
+
+// Source extensions per preset, used to rank multiple matches
+const PRESET_EXTENSIONS: Record<string, string[]> = {
+  tsgo: [".ts", ".tsx", ".mts", ".cts"],
+  typescript: [".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"],
+  deno: [".ts", ".tsx", ".js", ".jsx"],
+  fsharp: [".fs", ".fsi", ".fsx"],
+  moonbit: [".mbt"],
+  "rust-analyzer": [".rs"],
+  pyright: [".py", ".pyi"],
+  basedpyright: [".py", ".pyi"],
+  gopls: [".go"],
+  clangd: [".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp"],
+};
+
+export const SKIPPED_DIRS = new Set([
+  "node_modules",
+  "vendor",
+  "target",
+  "dist",
+  "build",
+  "out",
+  "__pycache__",
+]);
+
+/**
+ * Count files by extension under root, skipping dependency/build directories.
+ * Stops after maxFiles files so huge trees stay fast.
+ */
+export function countFilesByExtension(
+  root: string,
+  maxFiles: number = 20000,
+): Map<string, number> {
+  const counts = new Map<string, number>();
+  const pending = [root];
+  let seen = 0;
+
+  while (pending.length > 0 && seen < maxFiles) {
+    const dir = pending.pop()!;
+    let entries;
+    try {
+      entries = readdirSync(dir, { withFileTypes: true });
+    } catch {
+      continue;
+    }
+    for (const entry of entries) {
+      if (entry.name.startsWith(".")) continue;
+      if (entry.isDirectory()) {
+        if (!SKIPPED_DIRS.has(entry.name)) {
+          pending.push(join(dir, entry.name));
+        }
+      } else if (entry.isFile()) {
+        seen++;
+        const dot = entry.name.lastIndexOf(".");
+        if (dot > 0) {
+          const ext = entry.name.slice(dot).toLowerCase();
+          counts.set(ext, (counts.get(ext) ?? 0) + 1);
+        }
+      }
+    }
+  }
+  return counts;
+}
+
+/**
+ * Detect project types and pick one. With several matches (e.g. a Go module
+ * with a package.json for tooling) the preset whose language has the most
+ * source files wins; ties keep detection order.
+ */
+export async function selectProjectPreset(
+  projectRoot: string,
+): Promise<ProjectSelection | null> {
+  const detected = await detectProjectType(projectRoot);
+  if (detected.length === 0) {
+    return null;
+  }
+  if (detected.length === 1) {
+    return { preset: detected[0].preset, detected };
+  }
+
+  const counts = countFilesByExtension(projectRoot);
+  for (const project of detected) {
+    project.sourceFiles = (PRESET_EXTENSIONS[project.preset] ?? []).reduce(
+      (sum, ext) => sum + (counts.get(ext) ?? 0),
+      0,
+    );
+  }
+  const best = detected.reduce((a, b) =>
+    (b.sourceFiles ?? 0) > (a.sourceFiles ?? 0) ? b : a,
+  );
+  return { preset: best.preset, detected };
+}
+
+/**
+ * Describe what was detected and which preset was chosen
+ */
+export function formatDetectionReport(selection: ProjectSelection): string {
+  const lines = ["[lsmcp] Detected project types:"];
+  for (const project of selection.detected) {
+    const mark = project.preset === selection.preset ? "*" : " ";
+    const files =
+      project.sourceFiles !== undefined
+        ? ` (${project.sourceFiles} source files)`
+        : "";
+    lines.push(`  ${mark} ${project.preset}: ${project.reason}${files}`);
+  }
+  lines.push(
+    `[lsmcp] Using preset '${selection.preset}'. Pass -p <preset> or run 'lsmcp init' to choose another.`,
+  );
+  return lines.join("\n");
+}
+
 /**
  * Generate boilerplate config for manual setup
  */
@@ -0,0 +1,84 @@
+/**
+ * Minimal protocol buffers encoder
+ *
+ * Enough of the wire format to write SCIP indexes without a protobuf
+ * runtime: varint and length-delimited fields, nested messages and packed
+ * repeated integers. Default values (0, "") are left out like proto3 does.
+ */
+
+const WIRE_VARINT = 0;
+const WIRE_LENGTH_DELIMITED = 2;
+
+export class ProtoWriter {
+  private chunks: Uint8Array[] = [];
+
+  /** Non-negative integer (uint32, int32 >= 0 or enum) */
+  uint32(field: number, value: number | undefined): this {
+    if (value) {
+      this.tag(field, WIRE_VARINT);
+      this.chunks.push(encodeVarint(value));
+    }
+    return this;
+  }
+
+  string(field: number, value: string | undefined): this {
+    if (value) {
+      this.bytes(field, Buffer.from(value, "utf-8"));
+    }
+    return this;
+  }
+
+  /** One string field per value */
+  strings(field: number, values: string[] | undefined): this {
+    for (const value of values ?? []) {
+      this.bytes(field, Buffer.from(value, "utf-8"));
+    }
+    return this;
+  }
+
+  /** Packed repeated non-negative integers */
+  packed(field: number, values: number[] | undefined): this {
+    if (values?.length) {
+      this.bytes(field, Buffer.concat(values.map(encodeVarint)));
+    }
+    return this;
+  }
+
+  message(field: number, write: (writer: ProtoWriter) => void): this {
+    const nested = new ProtoWriter();
+    write(nested);
+    this.tag(field, WIRE_LENGTH_DELIMITED);
+    this.delimited(nested.finish());
+    return this;
+  }
+
+  finish(): Buffer {
+    return Buffer.concat(this.chunks);
+  }
+
+  private bytes(field: number, value: Uint8Array): void {
+    this.tag(field, WIRE_LENGTH_DELIMITED);
+    this.delimited(value);
+  }
+
+  private delimited(value: Uint8Array): void {
+    this.chunks.push(encodeVarint(value.length), value);
+  }
+
+  private tag(field: number, wireType: number): void {
+    this.chunks.push(encodeVarint(field * 8 + wireType));
+  }
+}
+
+export function encodeVarint(value: number): Uint8Array {
+  if (!Number.isSafeInteger(value) || value < 0) {
+    throw new RangeError(`Cannot encode ${value} as a varint`);
+  }
+  const bytes: number[] = [];
+  while (value > 0x7f) {
+    bytes.push((value % 0x80) | 0x80);
+    value = Math.floor(value / 0x80);
+  }
+  bytes.push(value);
+  return Uint8Array.from(bytes);
+}
@@ -0,0 +1,116 @@
+import { describe, it, expect, beforeEach } from "vitest";
+import { mkdirSync, mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  applyPythonEnvironment,
+  detectPythonEnvironment,
+  resolvePythonInitializationOptions,
+} from "./pythonEnvironment.ts";
+
+function createVenv(dir: string): string {
+  mkdirSync(join(dir, "bin"), { recursive: true });
+  const python = join(dir, "bin", "python");
+  writeFileSync(python, "");
+  return python;
+}
+
+describe("detectPythonEnvironment", () => {
+  let root: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-python-"));
+  });
+
+  it("should use venvPath/venv from pyrightconfig.json first", () => {
+    const python = createVenv(join(root, "envs", "app"));
+    createVenv(join(root, ".venv"));
+    writeFileSync(
+      join(root, "pyrightconfig.json"),
+      '{\n  // local envs\n  "venvPath": "envs",\n  "venv": "app"\n}',
+    );
+
+    expect(detectPythonEnvironment(root, {}, "linux")).toEqual({
+      pythonPath: python,
+      source: "pyrightconfig.json",
+    });
+  });
+
+  it("should read [tool.pyright] from pyproject.toml", () => {
+    const python = createVenv(join(root, "custom"));
+    writeFileSync(
+      join(root, "pyproject.toml"),
+      '[project]\nname = "app"\n\n[tool.pyright]\nvenvPath = "."\nvenv = "custom"\n',
+    );
+
+    expect(detectPythonEnvironment(root, {}, "linux")?.pythonPath).toBe(python);
+  });
+
+  it("should prefer the active virtualenv over conda and local dirs", () => {
+    const active = createVenv(join(root, "active"));
+    const conda = createVenv(join(root, "conda"));
+    createVenv(join(root, ".venv"));
+
+    expect(
+      detectPythonEnvironment(
+        root,
+        {
+          VIRTUAL_ENV: join(root, "active"),
+          CONDA_PREFIX: join(root, "conda"),
+        },
+        "linux",
+      ),
+    ).toEqual({ pythonPath: active, source: "VIRTUAL_ENV" });
+    expect(
+      detectPythonEnvironment(
+        root,
+        { CONDA_PREFIX: join(root, "conda") },
+        "linux",
+      ),
+    ).toEqual({ pythonPath: conda, source: "CONDA_PREFIX" });
+  });
+
+  it("should fall back to a project virtualenv directory", () => {
+    const python = createVenv(join(root, "venv"));
+
+    expect(detectPythonEnvironment(root, {}, "linux")).toEqual({
+      pythonPath: python,
+      source: "venv",
+    });
+    const empty = mkdtempSync(join(tmpdir(), "lsmcp-python-"));
+    expect(detectPythonEnvironment(empty, {}, "linux")).toBeUndefined();
+  });
+});
+
+describe("applyPythonEnvironment", () => {
+  const environment = { pythonPath: "/p/.venv/bin/python", source: ".venv" };
+
+  it("should set python.pythonPath and keep analysis settings", () => {
+    const options = { python: { analysis: { diagnosticMode: "workspace" } } };
+    const result = applyPythonEnvironment(options, environment);
+
+    expect(result).toEqual({
+      python: {
+        analysis: { diagnosticMode: "workspace" },
+        pythonPath: "/p/.venv/bin/python",
+      },
+    });
+    expect(options.python).not.toHaveProperty("pythonPath");
+  });
+
+  it("should not override a configured interpreter", () => {
+    const options = { python: { pythonPath: "/usr/bin/python3.12" } };
+
+    expect(applyPythonEnvironment(options, environment)).toBe(options);
+  });
+});
+
+describe("resolvePythonInitializationOptions", () => {
+  it("should leave other presets unchanged", () => {
+    const options = { analyses: {} };
+
+    expect(resolvePythonInitializationOptions("gopls", options, "/")).toBe(
+      options,
+    );
+  });
+});
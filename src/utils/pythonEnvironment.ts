@@ -0,0 +1,161 @@
+/**
+ * Python interpreter detection for pyright-based servers
+ * Without python.pythonPath pyright resolves imports against whatever python
+ * is on PATH and reports installed packages as missing.
+ */
+
+import { existsSync, readFileSync } from "fs";
+import { isAbsolute, join, resolve } from "path";
+import { parseTomlSections } from "./dependencyManifests.ts";
+import { debugLogWithPrefix } from "./debugLog.ts";
+
+export interface PythonEnvironment {
+  pythonPath: string;
+  /** Where the interpreter came from, for logging */
+  source: string;
+}
+
+// Project-local virtualenv directory names, checked in order
+const VENV_DIRS = [".venv", "venv", ".env", "env"];
+
+function interpreterIn(envDir: string, platform: string): string | undefined {
+  const candidates =
+    platform === "win32"
+      ? [join(envDir, "Scripts", "python.exe"), join(envDir, "python.exe")]
+      : [join(envDir, "bin", "python3"), join(envDir, "bin", "python")];
+  return candidates.find((candidate) => existsSync(candidate));
+}
+
+/**
+ * venvPath/venv from pyrightconfig.json or [tool.pyright] in pyproject.toml
+ */
+export function readPyrightVenv(
+  root: string,
+): { venvPath?: string; venv?: string; file: string } | undefined {
+  const configPath = join(root, "pyrightconfig.json");
+  if (existsSync(configPath)) {
+    try {
+      // pyrightconfig.json allows comments
+      const content = readFileSync(configPath, "utf-8").replace(
+        /^\s*\/\/.*$/gm,
+        "",
+      );
+      const config = JSON.parse(content);
+      return {
+        venvPath: config.venvPath,
+        venv: config.venv,
+        file: "pyrightconfig.json",
+      };
+    } catch {
+      return undefined;
+    }
+  }
+
+  const pyprojectPath = join(root, "pyproject.toml");
+  if (existsSync(pyprojectPath)) {
+    const table = parseTomlSections(readFileSync(pyprojectPath, "utf-8")).get(
+      "tool.pyright",
+    );
+    if (table) {
+      const value = (key: string) =>
+        typeof table[key] === "string" ? (table[key] as string) : undefined;
+      return {
+        venvPath: value("venvPath"),
+        venv: value("venv"),
+        file: "pyproject.toml",
+      };
+    }
+  }
+  return undefined;
+}
+
+/**
+ * Find the interpreter for a project: pyright config venv, then the active
+ * virtualenv or conda env, then a virtualenv directory in the project
+ */
+export function detectPythonEnvironment(
+  root: string,
+  env: Record<string, string | undefined> = process.env,
+  platform: string = process.platform,
+): PythonEnvironment | undefined {
+  const pyright = readPyrightVenv(root);
+  if (pyright?.venv) {
+    const venvPath = pyright.venvPath ?? ".";
+    const envDir = join(
+      isAbsolute(venvPath) ? venvPath : resolve(root, venvPath),
+      pyright.venv,
+    );
+    const pythonPath = interpreterIn(envDir, platform);
+    if (pythonPath) {
+      return { pythonPath, source: pyright.file };
+    }
+  }
+
+  const active: [string | undefined, string][] = [
+    [env.VIRTUAL_ENV, "VIRTUAL_ENV"],
+    [env.CONDA_PREFIX, "CONDA_PREFIX"],
+  ];
+  for (const [envDir, source] of active) {
+    const pythonPath = envDir ? interpreterIn(envDir, platform) : undefined;
+    if (pythonPath) {
+      return { pythonPath, source };
+    }
+  }
+
+  for (const name of VENV_DIRS) {
+    const pythonPath = interpreterIn(join(root, name), platform);
+    if (pythonPath) {
+      return { pythonPath, source: name };
+    }
+  }
+  return undefined;
+}
+
+/**
+ * Set python.pythonPath in initializationOptions unless already configured
+ */
+export function applyPythonEnvironment(
+  initializationOptions: unknown,
+  environment: PythonEnvironment | undefined,
+): Record<string, unknown> | undefined {
+  const base =
+    initializationOptions && typeof initializationOptions === "object"
+      ? { ...(initializationOptions as Record<string, unknown>) }
+      : {};
+  const python =
+    base.python && typeof base.python === "object"
+      ? { ...(base.python as Record<string, unknown>) }
+      : {};
+
+  if (!environment || python.pythonPath) {
+    return initializationOptions as Record<string, unknown> | undefined;
+  }
+  python.pythonPath = environment.pythonPath;
+  base.python = python;
+  return base;
+}
+
+/** Presets that take python.pythonPath */
+export const PYRIGHT_PRESETS = ["pyright", "basedpyright"];
+
+/**
+ * initializationOptions with the project's interpreter for pyright presets;
+ * other presets are returned unchanged
+ */
+export function resolvePythonInitializationOptions(
+  presetId: string | undefined,
+  initializationOptions: unknown,
+  root: string,
+): unknown {
+  if (!presetId || !PYRIGHT_PRESETS.includes(presetId)) {
+    return initializationOptions;
+  }
+  const environment = detectPythonEnvironment(root);
+  if (environment) {
+    debugLogWithPrefix(
+      "pythonEnvironment",
+      `Using ${environment.pythonPath} (from ${environment.source})`,
+    );
+  }
+  return applyPythonEnvironment(initializationOptions, environment);
+}
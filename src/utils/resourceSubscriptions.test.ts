@@ -0,0 +1,81 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import {
+  SubscribeRequestSchema,
+  UnsubscribeRequestSchema,
+} from "@modelcontextprotocol/sdk/types.js";
+import { createResourceSubscriptions } from "./resourceSubscriptions.ts";
+
+describe("createResourceSubscriptions", () => {
+  beforeEach(() => {
+    vi.useFakeTimers();
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  function createServer() {
+    const handlers = new Map<unknown, (request: any) => Promise<unknown>>();
+    return {
+      handlers,
+      server: {
+        registerCapabilities: vi.fn(),
+        setRequestHandler: vi.fn((schema, handler) =>
+          handlers.set(schema, handler),
+        ),
+        sendResourceUpdated: vi.fn(async () => {}),
+        onclose: undefined as (() => void) | undefined,
+      },
+    };
+  }
+
+  it("should declare the subscribe capability", () => {
+    const server = createServer();
+    createResourceSubscriptions(server as any);
+
+    expect(server.server.registerCapabilities).toHaveBeenCalledWith({
+      resources: { subscribe: true },
+    });
+  });
+
+  it("should send one update per subscribed URI for a burst of changes", async () => {
+    const server = createServer();
+    const subscriptions = createResourceSubscriptions(server as any);
+    const subscribe = server.handlers.get(SubscribeRequestSchema)!;
+    await subscribe({ params: { uri: "lsmcp://outline/a.ts" } });
+
+    subscriptions.updated(["lsmcp://outline/a.ts", "lsmcp://outline/b.ts"]);
+    subscriptions.updated(["lsmcp://outline/a.ts"]);
+    await vi.runAllTimersAsync();
+
+    expect(server.server.sendResourceUpdated.mock.calls).toEqual([
+      [{ uri: "lsmcp://outline/a.ts" }],
+    ]);
+  });
+
+  it("should drop pending updates on unsubscribe", async () => {
+    const server = createServer();
+    const subscriptions = createResourceSubscriptions(server as any);
+    const params = { uri: "lsmcp://diagnostics" };
+    await server.handlers.get(SubscribeRequestSchema)!({ params });
+
+    subscriptions.updated([params.uri]);
+    await server.handlers.get(UnsubscribeRequestSchema)!({ params });
+    await vi.runAllTimersAsync();
+
+    expect(server.server.sendResourceUpdated).not.toHaveBeenCalled();
+    expect(subscriptions.uris.size).toBe(0);
+  });
+
+  it("should run close listeners after the previous onclose", () => {
+    const server = createServer();
+    const calls: string[] = [];
+    server.server.onclose = () => calls.push("previous");
+    const subscriptions = createResourceSubscriptions(server as any);
+    subscriptions.onClose(() => calls.push("listener"));
+
+    server.server.onclose?.();
+
+    expect(calls).toEqual(["previous", "listener"]);
+  });
+});
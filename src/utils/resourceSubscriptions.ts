@@ -0,0 +1,84 @@
+/**
+ * Resource subscriptions of one MCP server
+ *
+ * One handler pair answers resources/subscribe and resources/unsubscribe for
+ * every resource a server offers. Resource sources report the URIs that
+ * changed; subscribed ones are sent notifications/resources/updated, once
+ * per URI for a burst of changes.
+ */
+
+import type { McpServer } from "@modelcontextprotocol/sdk/server/mcp.js";
+import {
+  SubscribeRequestSchema,
+  UnsubscribeRequestSchema,
+} from "@modelcontextprotocol/sdk/types.js";
+import { debugLogWithPrefix } from "./debugLog.ts";
+
+/** Changes within this interval are sent as one notification per URI */
+export const UPDATE_DELAY_MS = 200;
+
+export interface ResourceSubscriptions {
+  /** URIs the client subscribed to */
+  readonly uris: ReadonlySet<string>;
+  /** Notify the client of the subscribed URIs among these */
+  updated(uris: Iterable<string>): void;
+  /** Run when the server closes, e.g. to stop listening for changes */
+  onClose(listener: () => void): void;
+}
+
+export function createResourceSubscriptions(
+  server: McpServer,
+  delay: number = UPDATE_DELAY_MS,
+): ResourceSubscriptions {
+  const uris = new Set<string>();
+  const pending = new Set<string>();
+  const closeListeners: (() => void)[] = [];
+  let timer: ReturnType<typeof setTimeout> | undefined;
+
+  const flush = () => {
+    timer = undefined;
+    for (const uri of pending) {
+      server.server.sendResourceUpdated({ uri }).catch((error) => {
+        debugLogWithPrefix("MCP", `Failed to send resource update: ${error}`);
+      });
+    }
+    pending.clear();
+  };
+
+  server.server.registerCapabilities({ resources: { subscribe: true } });
+  server.server.setRequestHandler(SubscribeRequestSchema, async (request) => {
+    uris.add(request.params.uri);
+    return {};
+  });
+  server.server.setRequestHandler(UnsubscribeRequestSchema, async (request) => {
+    uris.delete(request.params.uri);
+    pending.delete(request.params.uri);
+    return {};
+  });
+
+  const previous = server.server.onclose;
+  server.server.onclose = () => {
+    previous?.();
+    clearTimeout(timer);
+    for (const listener of closeListeners) {
+      listener();
+    }
+  };
+
+  return {
+    uris,
+    updated(changed) {
+      for (const uri of changed) {
+        if (uris.has(uri)) {
+          pending.add(uri);
+        }
+      }
+      if (pending.size > 0 && !timer) {
+        timer = setTimeout(flush, delay);
+      }
+    },
+    onClose(listener) {
+      closeListeners.push(listener);
+    },
+  };
+}
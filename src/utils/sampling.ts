@@ -0,0 +1,66 @@
+/**
+ * Completions from the client's model through MCP sampling
+ *
+ * Tools get context.sample when the client declares the sampling capability;
+ * it sends sampling/createMessage with a single user message and returns the
+ * text of the answer.
+ */
+
+import { z, type ZodType } from "zod";
+
+/** How long the client's model has to answer */
+export const SAMPLING_TIMEOUT_MS = 60 * 1000;
+
+/**
+ * Subset of the MCP SDK request handler extra used to send requests
+ */
+export interface SamplingRequestExtra {
+  sendRequest?: (
+    request: { method: "sampling/createMessage"; params: unknown },
+    resultSchema: ZodType,
+    options?: { timeout?: number },
+  ) => Promise<unknown>;
+}
+
+const CreateMessageResultSchema = z
+  .object({
+    role: z.string(),
+    content: z
+      .object({ type: z.string(), text: z.string().optional() })
+      .passthrough(),
+  })
+  .passthrough();
+
+/**
+ * Create the sample function of a tool call.
+ * Returns undefined when the client does not support sampling.
+ */
+export function createSampler(
+  extra: SamplingRequestExtra | undefined,
+  clientCapabilities: Record<string, unknown> | undefined,
+): ((prompt: string, maxTokens: number) => Promise<string>) | undefined {
+  const sendRequest = extra?.sendRequest;
+  if (!clientCapabilities?.sampling || !sendRequest) {
+    return undefined;
+  }
+  return async (prompt, maxTokens) => {
+    const result = (await sendRequest(
+      {
+        method: "sampling/createMessage",
+        params: {
+          messages: [{ role: "user", content: { type: "text", text: prompt } }],
+          maxTokens,
+          includeContext: "none",
+        },
+      },
+      CreateMessageResultSchema,
+      { timeout: SAMPLING_TIMEOUT_MS },
+    )) as z.infer<typeof CreateMessageResultSchema>;
+    if (result.content.type !== "text" || !result.content.text) {
+      throw new Error(
+        `sampling returned ${result.content.type} content instead of text`,
+      );
+    }
+    return result.content.text;
+  };
+}
@@ -0,0 +1,86 @@
+import { describe, it, expect } from "vitest";
+import { sarifRuleId, toSarif } from "./sarif.ts";
+import type { FileDiagnostic } from "../tools/lsp/allDiagnostics.ts";
+
+const at = (
+  line: number,
+  message: string,
+  extra: Partial<FileDiagnostic["diagnostics"][number]> = {},
+): FileDiagnostic["diagnostics"][number] => ({
+  severity: "error",
+  line,
+  column: 5,
+  endLine: line,
+  endColumn: 9,
+  message,
+  ...extra,
+});
+
+describe("sarifRuleId", () => {
+  it("should combine source and code", () => {
+    expect(sarifRuleId(at(1, "x", { source: "ts", code: 2322 }))).toBe(
+      "ts/2322",
+    );
+    expect(sarifRuleId(at(1, "x", { source: "go vet" }))).toBe("go vet");
+    expect(sarifRuleId(at(1, "x", { code: "" }))).toBe("lsmcp");
+  });
+});
+
+describe("toSarif", () => {
+  const files: FileDiagnostic[] = [
+    {
+      filePath: "src/my file.ts",
+      diagnostics: [
+        at(3, "Type 'string' is not assignable", { source: "ts", code: 2322 }),
+        at(7, "Unused variable", { severity: "hint", source: "ts" }),
+      ],
+    },
+    {
+      filePath: "src\\lib.ts",
+      diagnostics: [
+        at(2, "Another mismatch", {
+          severity: "warning",
+          source: "ts",
+          code: 2322,
+          column: 10,
+          endColumn: 1,
+        }),
+      ],
+    },
+  ];
+
+  it("should share rules between results", () => {
+    const run = toSarif(files, { root: "/work/app" }).runs[0];
+
+    expect(run.tool.driver.rules.map((r) => r.id)).toEqual(["ts/2322", "ts"]);
+    expect(run.results.map((r) => [r.ruleId, r.ruleIndex, r.level])).toEqual([
+      ["ts/2322", 0, "error"],
+      ["ts", 1, "note"],
+      ["ts/2322", 0, "warning"],
+    ]);
+  });
+
+  it("should use paths relative to SRCROOT", () => {
+    const log = toSarif(files, { root: "/work/app" });
+    const run = log.runs[0];
+
+    expect(log.version).toBe("2.1.0");
+    expect(run.originalUriBaseIds.SRCROOT.uri).toBe("file:///work/app/");
+    expect(
+      run.results.map(
+        (r) => r.locations[0].physicalLocation.artifactLocation.uri,
+      ),
+    ).toEqual(["src/my%20file.ts", "src/my%20file.ts", "src/lib.ts"]);
+  });
+
+  it("should not emit regions that end before they start", () => {
+    const run = toSarif(files, { root: "/work/app" }).runs[0];
+
+    expect(run.results[2].locations[0].physicalLocation.region).toEqual({
+      startLine: 2,
+      startColumn: 10,
+      endLine: 2,
+      endColumn: 10,
+    });
+  });
+});
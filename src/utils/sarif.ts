@@ -0,0 +1,159 @@
+/**
+ * SARIF 2.1.0 export of diagnostics
+ *
+ * Converts collected workspace diagnostics into a SARIF log that GitHub code
+ * scanning (github/codeql-action/upload-sarif) accepts. Paths are relative
+ * to the SRCROOT base, so the log stays valid wherever CI checks out the
+ * repository. Rules are named after the diagnostic source and code, e.g.
+ * "staticcheck/SA4006" or "typescript/2322".
+ */
+
+import { pathToFileURL } from "url";
+import type { FileDiagnostic } from "../tools/lsp/allDiagnostics.ts";
+
+export const SARIF_SCHEMA = "https://json.schemastore.org/sarif-2.1.0.json";
+
+export const SARIF_VERSION = "2.1.0";
+
+type SarifLevel = "error" | "warning" | "note";
+
+export interface SarifRule {
+  id: string;
+  shortDescription: { text: string };
+  properties?: { category?: string };
+}
+
+export interface SarifResult {
+  ruleId: string;
+  ruleIndex: number;
+  level: SarifLevel;
+  message: { text: string };
+  locations: {
+    physicalLocation: {
+      artifactLocation: { uri: string; uriBaseId: "SRCROOT" };
+      region: {
+        startLine: number;
+        startColumn: number;
+        endLine: number;
+        endColumn: number;
+      };
+    };
+  }[];
+}
+
+export interface SarifLog {
+  $schema: string;
+  version: typeof SARIF_VERSION;
+  runs: {
+    tool: {
+      driver: {
+        name: string;
+        informationUri: string;
+        version?: string;
+        rules: SarifRule[];
+      };
+    };
+    originalUriBaseIds: { SRCROOT: { uri: string } };
+    columnKind: "utf16CodeUnits";
+    results: SarifResult[];
+  }[];
+}
+
+export interface SarifOptions {
+  /** Project root; result paths are relative to it */
+  root: string;
+  /** Version of the tool that produced the diagnostics */
+  version?: string;
+}
+
+type ExportedDiagnostic = FileDiagnostic["diagnostics"][number];
+
+const LEVELS: Record<ExportedDiagnostic["severity"], SarifLevel> = {
+  error: "error",
+  warning: "warning",
+  information: "note",
+  hint: "note",
+};
+
+/** Rule id of a diagnostic: source and code when the server sent them */
+export function sarifRuleId(diagnostic: ExportedDiagnostic): string {
+  const source = diagnostic.source?.trim() || "lsmcp";
+  return diagnostic.code !== undefined && diagnostic.code !== ""
+    ? `${source}/${diagnostic.code}`
+    : source;
+}
+
+export function toSarif(
+  files: FileDiagnostic[],
+  options: SarifOptions,
+): SarifLog {
+  const rules: SarifRule[] = [];
+  const ruleIndexes = new Map<string, number>();
+  const results: SarifResult[] = [];
+
+  for (const file of files) {
+    // SARIF URIs use "/" and percent-encoding; the path stays relative
+    const uri = file.filePath.split(/[\\/]/).map(encodeURIComponent).join("/");
+    for (const diagnostic of file.diagnostics) {
+      const ruleId = sarifRuleId(diagnostic);
+      let ruleIndex = ruleIndexes.get(ruleId);
+      if (ruleIndex === undefined) {
+        ruleIndex = rules.length;
+        ruleIndexes.set(ruleId, ruleIndex);
+        rules.push({
+          id: ruleId,
+          shortDescription: { text: ruleId },
+          ...(diagnostic.source && {
+            properties: { category: diagnostic.source },
+          }),
+        });
+      }
+      results.push({
+        ruleId,
+        ruleIndex,
+        level: LEVELS[diagnostic.severity] ?? "warning",
+        message: { text: diagnostic.message },
+        locations: [
+          {
+            physicalLocation: {
+              artifactLocation: { uri, uriBaseId: "SRCROOT" },
+              region: {
+                startLine: diagnostic.line,
+                startColumn: diagnostic.column,
+                // SARIF regions may not end before they start
+                endLine: Math.max(diagnostic.endLine, diagnostic.line),
+                endColumn:
+                  diagnostic.endLine > diagnostic.line
+                    ? diagnostic.endColumn
+                    : Math.max(diagnostic.endColumn, diagnostic.column),
+              },
+            },
+          },
+        ],
+      });
+    }
+  }
+
+  const rootUri = pathToFileURL(options.root).href;
+  return {
+    $schema: SARIF_SCHEMA,
+    version: SARIF_VERSION,
+    runs: [
+      {
+        tool: {
+          driver: {
+            name: "lsmcp",
+            informationUri: "https://github.com/mizchi/lsmcp",
+            ...(options.version && { version: options.version }),
+            rules,
+          },
+        },
+        originalUriBaseIds: {
+          SRCROOT: { uri: rootUri.endsWith("/") ? rootUri : `${rootUri}/` },
+        },
+        columnKind: "utf16CodeUnits",
+        results,
+      },
+    ],
+  };
+}
@@ -0,0 +1,118 @@
+import { describe, it, expect } from "vitest";
+import type { IntelDocument, IntelSymbol } from "./codeIntel.ts";
+import { encodeVarint, ProtoWriter } from "./protobuf.ts";
+import { encodeScip, scipSymbol, toScip } from "./scip.ts";
+
+const range = (line: number, start: number, end: number) => ({
+  start: { line, character: start },
+  end: { line, character: end },
+});
+
+const save: IntelSymbol = {
+  name: "save",
+  kind: 6,
+  parents: [{ name: "User", kind: 5 }],
+  range: range(3, 2, 6),
+  fullRange: {
+    start: { line: 3, character: 2 },
+    end: { line: 5, character: 3 },
+  },
+  hover: "(method) User.save(): void",
+  references: [
+    { uri: "file:///project/src/main.ts", range: range(7, 5, 9) },
+    { uri: "file:///elsewhere/x.ts", range: range(0, 0, 4) },
+  ],
+};
+
+const documents: IntelDocument[] = [
+  {
+    uri: "file:///project/src/user.ts",
+    relativePath: "src/user.ts",
+    languageId: "typescript",
+    symbols: [save],
+  },
+];
+
+describe("scipSymbol", () => {
+  it("should name symbols after their file and enclosing symbols", () => {
+    expect(scipSymbol("src/user.ts", save)).toBe(
+      "lsmcp . . . src/`user.ts`/User#save().",
+    );
+  });
+
+  it("should prefer monikers unique beyond the project", () => {
+    expect(
+      scipSymbol("src/user.ts", {
+        ...save,
+        monikers: [
+          { scheme: "tsc", identifier: "src/user:save", unique: "project" },
+        ],
+      }),
+    ).toBe("lsmcp . . . src/`user.ts`/User#save().");
+    expect(
+      scipSymbol("src/lib.rs", {
+        ...save,
+        monikers: [
+          {
+            scheme: "rust-analyzer",
+            identifier: "mycrate::User::save",
+            unique: "scheme",
+            kind: "export",
+          },
+        ],
+      }),
+    ).toBe("rust-analyzer . . . `mycrate::User::save`.");
+  });
+});
+
+describe("toScip", () => {
+  it("should record definitions and references within the project", () => {
+    const index = toScip(documents, { root: "/project" });
+
+    expect(index.metadata.projectRoot).toBe("file:///project");
+    expect(index.documents.map((d) => d.relativePath)).toEqual([
+      "src/main.ts",
+      "src/user.ts",
+    ]);
+    const [main, user] = index.documents;
+    const symbol = "lsmcp . . . src/`user.ts`/User#save().";
+    expect(main.occurrences).toEqual([
+      { range: [7, 5, 9], symbol, symbolRoles: 0 },
+    ]);
+    expect(user.occurrences).toEqual([
+      {
+        range: [3, 2, 6],
+        symbol,
+        symbolRoles: 1,
+        enclosingRange: [3, 2, 5, 3],
+      },
+    ]);
+    expect(user.symbols).toEqual([
+      { symbol, documentation: [save.hover], displayName: "save" },
+    ]);
+  });
+});
+
+describe("encodeScip", () => {
+  it("should use the protobuf wire format", () => {
+    expect([...encodeVarint(300)]).toEqual([0xac, 0x02]);
+    expect([
+      ...new ProtoWriter()
+        .string(1, "a")
+        .uint32(2, 0)
+        .packed(3, [1, 2])
+        .finish(),
+    ]).toEqual([0x0a, 0x01, 0x61, 0x1a, 0x02, 0x01, 0x02]);
+  });
+
+  it("should start with the metadata", () => {
+    const encoded = encodeScip(toScip(documents, { root: "/project" }));
+
+    // Field 1 (metadata), length-delimited
+    expect(encoded[0]).toBe(0x0a);
+    expect(encoded.includes(Buffer.from("file:///project"))).toBe(true);
+    expect(encoded.includes(Buffer.from("src/`user.ts`/User#save()."))).toBe(
+      true,
+    );
+  });
+});
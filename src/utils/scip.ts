@@ -0,0 +1,238 @@
+/**
+ * SCIP export of the symbol index
+ *
+ * Writes collected code intelligence as a SCIP index (index.scip, the
+ * protobuf format of github.com/sourcegraph/scip) for code-search pipelines
+ * that consume SCIP rather than LSIF. Symbols are named after their file and
+ * enclosing symbols, e.g. "lsmcp . . . src/`user.ts`/User#save().". When the
+ * language server gives a symbol a moniker that is unique beyond the
+ * project, the moniker names it instead, so references from other
+ * repositories indexed the same way resolve to it.
+ */
+
+import { isAbsolute, relative } from "path";
+import { fileURLToPath, pathToFileURL } from "url";
+import { SymbolKind } from "@internal/types";
+import { getLanguageIdFromPath } from "@internal/lsp-client";
+import type { IntelDocument, IntelRange, IntelSymbol } from "./codeIntel.ts";
+import { ProtoWriter } from "./protobuf.ts";
+
+/** SymbolRole.Definition */
+const ROLE_DEFINITION = 1;
+
+/** TextEncoding.UTF8 */
+const TEXT_ENCODING_UTF8 = 1;
+
+/** PositionEncoding.UTF16CodeUnitOffsetFromLineStart, as LSP positions are */
+const POSITION_ENCODING_UTF16 = 2;
+
+const SCHEME = "lsmcp";
+
+export interface ScipOccurrence {
+  /** [startLine, startCharacter, (endLine,) endCharacter] */
+  range: number[];
+  symbol: string;
+  symbolRoles: number;
+  enclosingRange?: number[];
+}
+
+export interface ScipSymbolInformation {
+  symbol: string;
+  documentation: string[];
+  displayName: string;
+}
+
+export interface ScipDocument {
+  relativePath: string;
+  language: string;
+  occurrences: ScipOccurrence[];
+  symbols: ScipSymbolInformation[];
+}
+
+export interface ScipIndex {
+  metadata: {
+    toolInfo: { name: string; version?: string };
+    projectRoot: string;
+  };
+  documents: ScipDocument[];
+}
+
+export interface ScipOptions {
+  root: string;
+  /** lsmcp version reported in toolInfo */
+  version?: string;
+}
+
+function scipRange({ start, end }: IntelRange): number[] {
+  return start.line === end.line
+    ? [start.line, start.character, end.character]
+    : [start.line, start.character, end.line, end.character];
+}
+
+/** Names outside the simple identifier set are backquoted */
+function escapeName(name: string): string {
+  return /^[\w+$-]+$/.test(name) ? name : `\`${name.replace(/`/g, "``")}\``;
+}
+
+function descriptor(name: string, kind: SymbolKind): string {
+  const escaped = escapeName(name);
+  switch (kind) {
+    case SymbolKind.File:
+    case SymbolKind.Module:
+    case SymbolKind.Namespace:
+    case SymbolKind.Package:
+      return `${escaped}/`;
+    case SymbolKind.Class:
+    case SymbolKind.Interface:
+    case SymbolKind.Enum:
+    case SymbolKind.Struct:
+      return `${escaped}#`;
+    case SymbolKind.Method:
+    case SymbolKind.Function:
+    case SymbolKind.Constructor:
+      return `${escaped}().`;
+    case SymbolKind.TypeParameter:
+      return `[${escaped}]`;
+    default:
+      return `${escaped}.`;
+  }
+}
+
+/**
+ * SCIP symbol string of a definition: its moniker when that is unique
+ * beyond the project, otherwise its path and enclosing symbols
+ */
+export function scipSymbol(relativePath: string, symbol: IntelSymbol): string {
+  const moniker = symbol.monikers?.find(
+    (m) =>
+      (m.unique === "scheme" || m.unique === "global") && m.kind !== "local",
+  );
+  if (moniker) {
+    // Spaces in the scheme are doubled; the identifier is one term
+    const scheme = moniker.scheme.replace(/ /g, "  ");
+    return `${scheme} . . . ${escapeName(moniker.identifier)}.`;
+  }
+  const path = relativePath
+    .split("/")
+    .map((segment) => `${escapeName(segment)}/`)
+    .join("");
+  const parents = symbol.parents
+    .map((parent) => descriptor(parent.name, parent.kind))
+    .join("");
+  return `${SCHEME} . . . ${path}${parents}${descriptor(symbol.name, symbol.kind)}`;
+}
+
+function compareRanges(a: number[], b: number[]): number {
+  return a[0] - b[0] || a[1] - b[1];
+}
+
+export function toScip(
+  documents: IntelDocument[],
+  options: ScipOptions,
+): ScipIndex {
+  const byPath = new Map<string, ScipDocument>();
+  const documentAt = (relativePath: string, language: string) => {
+    let document = byPath.get(relativePath);
+    if (!document) {
+      document = { relativePath, language, occurrences: [], symbols: [] };
+      byPath.set(relativePath, document);
+    }
+    return document;
+  };
+
+  for (const intel of documents) {
+    const document = documentAt(intel.relativePath, intel.languageId);
+    for (const symbol of intel.symbols) {
+      const name = scipSymbol(intel.relativePath, symbol);
+      document.occurrences.push({
+        range: scipRange(symbol.range),
+        symbol: name,
+        symbolRoles: ROLE_DEFINITION,
+        enclosingRange: scipRange(symbol.fullRange),
+      });
+      document.symbols.push({
+        symbol: name,
+        documentation: symbol.hover ? [symbol.hover] : [],
+        displayName: symbol.name,
+      });
+
+      // References in files of the project that were not indexed still
+      // get a document
+      for (const reference of symbol.references) {
+        const filePath = fileURLToPath(reference.uri);
+        const relativePath = relative(options.root, filePath)
+          .split("\\")
+          .join("/");
+        if (relativePath.startsWith("..") || isAbsolute(relativePath)) {
+          continue;
+        }
+        documentAt(
+          relativePath,
+          getLanguageIdFromPath(filePath) || "plaintext",
+        ).occurrences.push({
+          range: scipRange(reference.range),
+          symbol: name,
+          symbolRoles: 0,
+        });
+      }
+    }
+  }
+
+  const sorted = [...byPath.values()].sort((a, b) =>
+    a.relativePath.localeCompare(b.relativePath),
+  );
+  for (const document of sorted) {
+    document.occurrences.sort((a, b) => compareRanges(a.range, b.range));
+  }
+  return {
+    metadata: {
+      toolInfo: {
+        name: "lsmcp",
+        ...(options.version && { version: options.version }),
+      },
+      projectRoot: pathToFileURL(options.root).href,
+    },
+    documents: sorted,
+  };
+}
+
+/**
+ * The index in the SCIP protobuf wire format
+ */
+export function encodeScip(index: ScipIndex): Buffer {
+  const writer = new ProtoWriter();
+  writer.message(1, (metadata) =>
+    metadata
+      .message(2, (toolInfo) =>
+        toolInfo
+          .string(1, index.metadata.toolInfo.name)
+          .string(2, index.metadata.toolInfo.version),
+      )
+      .string(3, index.metadata.projectRoot)
+      .uint32(4, TEXT_ENCODING_UTF8),
+  );
+  for (const document of index.documents) {
+    writer.message(2, (doc) => {
+      doc.string(1, document.relativePath);
+      for (const occurrence of document.occurrences) {
+        doc.message(2, (occ) =>
+          occ
+            .packed(1, occurrence.range)
+            .string(2, occurrence.symbol)
+            .uint32(3, occurrence.symbolRoles)
+            .packed(7, occurrence.enclosingRange),
+        );
+      }
+      for (const symbol of document.symbols) {
+        doc.message(3, (info) =>
+          info
+            .string(1, symbol.symbol)
+            .strings(3, symbol.documentation)
+            .string(6, symbol.displayName),
+        );
+      }
+      doc.string(4, document.language).uint32(6, POSITION_ENCODING_UTF16);
+    });
+  }
+  return writer.finish();
+}
@@ -0,0 +1,128 @@
+import { describe, it, expect, beforeEach, vi } from "vitest";
+import { mkdirSync, mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  INSTALL_RECIPES,
+  ensureLanguageServer,
+  installCommand,
+  installedBinary,
+} from "./serverInstaller.ts";
+
+describe("installCommand", () => {
+  it("should go install into the lsmcp bin directory", () => {
+    expect(installCommand(INSTALL_RECIPES.gopls, "/cache/gopls")).toEqual({
+      command: "go",
+      args: ["install", "golang.org/x/tools/gopls@latest"],
+      env: { GOBIN: "/cache/gopls/bin" },
+    });
+  });
+
+  it("should pin npm packages and install peers", () => {
+    expect(
+      installCommand(INSTALL_RECIPES.typescript, "/cache/ts", "4.3.3").args,
+    ).toEqual([
+      "install",
+      "--prefix",
+      "/cache/ts",
+      "--no-audit",
+      "--no-fund",
+      "typescript-language-server@4.3.3",
+      "typescript",
+    ]);
+  });
+
+  it("should pass a pinned toolchain to rustup", () => {
+    expect(
+      installCommand(INSTALL_RECIPES["rust-analyzer"], "/cache", "1.80.0")
+        .args,
+    ).toEqual(["component", "add", "rust-analyzer", "--toolchain", "1.80.0"]);
+  });
+});
+
+describe("installedBinary", () => {
+  let dir: string;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), "lsmcp-install-"));
+    mkdirSync(join(dir, "bin"));
+    writeFileSync(join(dir, "bin", "gopls"), "");
+  });
+
+  it("should only reuse a binary installed for the same pin", () => {
+    writeFileSync(
+      join(dir, "lsmcp-install.json"),
+      JSON.stringify({ version: "v0.16.2" }),
+    );
+
+    expect(
+      installedBinary(INSTALL_RECIPES.gopls, dir, "v0.16.2", "linux"),
+    ).toBe(join(dir, "bin", "gopls"));
+    expect(
+      installedBinary(INSTALL_RECIPES.gopls, dir, "v0.17.0", "linux"),
+    ).toBeUndefined();
+  });
+});
+
+describe("ensureLanguageServer", () => {
+  const resolved = { command: "gopls", args: ["serve"] };
+  let dir: string;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), "lsmcp-install-"));
+  });
+
+  it("should keep a command that is already available", async () => {
+    const runner = vi.fn();
+
+    expect(
+      await ensureLanguageServer("gopls", resolved, {
+        runner,
+        exists: () => true,
+      }),
+    ).toBe(resolved);
+    expect(runner).not.toHaveBeenCalled();
+  });
+
+  it("should install a missing server and run the installed binary", async () => {
+    const runner = vi.fn(async () => {
+      mkdirSync(join(dir, "gopls", "bin"), { recursive: true });
+      writeFileSync(join(dir, "gopls", "bin", "gopls"), "");
+    });
+
+    const result = await ensureLanguageServer("gopls", resolved, {
+      install: { dir, versions: { gopls: "v0.16.2" } },
+      runner,
+      exists: (command) => command === "go",
+    });
+
+    expect(runner).toHaveBeenCalledWith({
+      command: "go",
+      args: ["install", "golang.org/x/tools/gopls@v0.16.2"],
+      env: { GOBIN: join(dir, "gopls", "bin") },
+    });
+    expect(result).toEqual({
+      command: join(dir, "gopls", "bin", "gopls"),
+      args: ["serve"],
+    });
+  });
+
+  it("should explain how to install when installing is disabled", async () => {
+    await expect(
+      ensureLanguageServer("gopls", resolved, {
+        install: { dir, enabled: false },
+        exists: () => false,
+      }),
+    ).rejects.toThrow(
+      "gopls not found. Install it with: go install golang.org/x/tools/gopls@latest",
+    );
+  });
+
+  it("should leave presets without a recipe alone", async () => {
+    const custom = { command: "jdtls", args: [] };
+
+    expect(
+      await ensureLanguageServer("java", custom, { exists: () => false }),
+    ).toBe(custom);
+  });
+});
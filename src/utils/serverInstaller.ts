@@ -0,0 +1,273 @@
+/**
+ * Language server installer
+ *
+ * When a preset's server is missing, install it with the toolchain that ships
+ * it (go install, npm install or rustup component add) into an lsmcp-owned
+ * directory. Versions can be pinned per preset in the `install` config.
+ */
+
+import { spawn, execSync } from "child_process";
+import { existsSync, mkdirSync, readFileSync, writeFileSync } from "fs";
+import { homedir } from "os";
+import { isAbsolute, join } from "path";
+import type { InstallOptions } from "../config/schema.ts";
+import { debugLogWithPrefix, errorLog } from "./debugLog.ts";
+
+export interface InstallRecipe {
+  manager: "go" | "npm" | "rustup";
+  /** Go module, npm package or rustup component */
+  package: string;
+  /** Executable the preset runs */
+  bin: string;
+  /** npm packages installed next to the server (e.g. typescript) */
+  peers?: string[];
+}
+
+export const INSTALL_RECIPES: Record<string, InstallRecipe> = {
+  gopls: { manager: "go", package: "golang.org/x/tools/gopls", bin: "gopls" },
+  "rust-analyzer": {
+    manager: "rustup",
+    package: "rust-analyzer",
+    bin: "rust-analyzer",
+  },
+  typescript: {
+    manager: "npm",
+    package: "typescript-language-server",
+    bin: "typescript-language-server",
+    peers: ["typescript"],
+  },
+  tsgo: { manager: "npm", package: "@typescript/native-preview", bin: "tsgo" },
+  pyright: { manager: "npm", package: "pyright", bin: "pyright-langserver" },
+  basedpyright: {
+    manager: "npm",
+    package: "basedpyright",
+    bin: "basedpyright-langserver",
+  },
+};
+
+export interface InstallCommand {
+  command: string;
+  args: string[];
+  env?: Record<string, string>;
+}
+
+// Written next to the install so a changed pin triggers a reinstall
+const INSTALL_RECORD = "lsmcp-install.json";
+
+export function defaultInstallDir(
+  env: Record<string, string | undefined> = process.env,
+): string {
+  const cacheHome = env.XDG_CACHE_HOME || join(homedir(), ".cache");
+  return join(cacheHome, "lsmcp", "servers");
+}
+
+/**
+ * Command that installs a recipe into `dir`
+ */
+export function installCommand(
+  recipe: InstallRecipe,
+  dir: string,
+  version?: string,
+): InstallCommand {
+  switch (recipe.manager) {
+    case "go":
+      return {
+        command: "go",
+        args: ["install", `${recipe.package}@${version ?? "latest"}`],
+        env: { GOBIN: join(dir, "bin") },
+      };
+    case "npm":
+      return {
+        command: "npm",
+        args: [
+          "install",
+          "--prefix",
+          dir,
+          "--no-audit",
+          "--no-fund",
+          version ? `${recipe.package}@${version}` : recipe.package,
+          ...(recipe.peers ?? []),
+        ],
+      };
+    case "rustup":
+      // rustup installs components per toolchain; a pin names the toolchain
+      return {
+        command: "rustup",
+        args: [
+          "component",
+          "add",
+          recipe.package,
+          ...(version ? ["--toolchain", version] : []),
+        ],
+      };
+  }
+}
+
+/**
+ * Format an install command for messages
+ */
+export function describeInstall(
+  recipe: InstallRecipe,
+  version?: string,
+): string {
+  switch (recipe.manager) {
+    case "go":
+      return `go install ${recipe.package}@${version ?? "latest"}`;
+    case "npm":
+      return `npm install -g ${version ? `${recipe.package}@${version}` : recipe.package}`;
+    case "rustup":
+      return `rustup component add ${recipe.package}${version ? ` --toolchain ${version}` : ""}`;
+  }
+}
+
+/**
+ * Path of the installed executable, or undefined when it is not installed
+ */
+export function installedBinary(
+  recipe: InstallRecipe,
+  dir: string,
+  version?: string,
+  platform: string = process.platform,
+): string | undefined {
+  if (recipe.manager === "rustup") {
+    // rustup keeps components in its own toolchain directories
+    try {
+      const path = execSync(
+        `rustup which ${recipe.bin}${version ? ` --toolchain ${version}` : ""}`,
+        { encoding: "utf-8", stdio: ["pipe", "pipe", "ignore"] },
+      ).trim();
+      return path && existsSync(path) ? path : undefined;
+    } catch {
+      return undefined;
+    }
+  }
+
+  const exe = platform === "win32" ? ".exe" : "";
+  const path =
+    recipe.manager === "go"
+      ? join(dir, "bin", recipe.bin + exe)
+      : join(
+          dir,
+          "node_modules",
+          ".bin",
+          recipe.bin + (platform === "win32" ? ".cmd" : ""),
+        );
+  if (!existsSync(path)) {
+    return undefined;
+  }
+
+  // A binary installed for another pin does not count
+  try {
+    const record = JSON.parse(readFileSync(join(dir, INSTALL_RECORD), "utf-8"));
+    return record.version === (version ?? "latest") ? path : undefined;
+  } catch {
+    return version ? undefined : path;
+  }
+}
+
+/**
+ * Whether a command can be run: an existing path or a name found on PATH
+ */
+export function commandExists(command: string): boolean {
+  if (isAbsolute(command) || command.includes("/")) {
+    return existsSync(command);
+  }
+  try {
+    execSync(
+      process.platform === "win32" ? `where ${command}` : `which ${command}`,
+      { stdio: ["pipe", "pipe", "ignore"] },
+    );
+    return true;
+  } catch {
+    return false;
+  }
+}
+
+export type CommandRunner = (command: InstallCommand) => Promise<void>;
+
+/**
+ * Run an install command, forwarding its output to stderr (stdout is MCP)
+ */
+export const runInstallCommand: CommandRunner = (command) =>
+  new Promise((resolve, reject) => {
+    const child = spawn(command.command, command.args, {
+      env: { ...process.env, ...command.env },
+      stdio: ["ignore", "pipe", "pipe"],
+    });
+    child.stdout?.on("data", (data) => process.stderr.write(data));
+    child.stderr?.on("data", (data) => process.stderr.write(data));
+    child.on("error", reject);
+    child.on("exit", (code) =>
+      code === 0
+        ? resolve()
+        : reject(new Error(`${command.command} exited with code ${code}`)),
+    );
+  });
+
+export interface EnsureServerOptions {
+  install?: InstallOptions;
+  runner?: CommandRunner;
+  exists?: (command: string) => boolean;
+}
+
+/**
+ * Make sure the resolved command of a preset can run, installing the server
+ * when it is missing (or when a version is pinned). Commands of presets
+ * without a recipe are returned unchanged.
+ */
+export async function ensureLanguageServer(
+  presetId: string | undefined,
+  resolved: { command: string; args: string[] },
+  options: EnsureServerOptions = {},
+): Promise<{ command: string; args: string[] }> {
+  const recipe = presetId ? INSTALL_RECIPES[presetId] : undefined;
+  if (!presetId || !recipe) {
+    return resolved;
+  }
+  const exists = options.exists ?? commandExists;
+  const version = options.install?.versions?.[presetId];
+  if (!version && exists(resolved.command)) {
+    return resolved;
+  }
+
+  const dir = join(options.install?.dir ?? defaultInstallDir(), presetId);
+  const installed = installedBinary(recipe, dir, version);
+  if (installed) {
+    debugLogWithPrefix(
+      "Installer",
+      `Using installed ${presetId}: ${installed}`,
+    );
+    return { command: installed, args: resolved.args };
+  }
+
+  if (options.install?.enabled === false) {
+    throw new Error(
+      `${recipe.bin} not found. Install it with: ${describeInstall(recipe, version)}`,
+    );
+  }
+  if (!exists(recipe.manager)) {
+    throw new Error(
+      `${recipe.bin} not found and '${recipe.manager}' is not available to install it. Install it with: ${describeInstall(recipe, version)}`,
+    );
+  }
+
+  const command = installCommand(recipe, dir, version);
+  errorLog(
+    `[lsmcp] ${recipe.bin} not found, installing: ${command.command} ${command.args.join(" ")}`,
+  );
+  mkdirSync(dir, { recursive: true });
+  await (options.runner ?? runInstallCommand)(command);
+  if (recipe.manager !== "rustup") {
+    writeFileSync(
+      join(dir, INSTALL_RECORD),
+      JSON.stringify({ package: recipe.package, version: version ?? "latest" }),
+    );
+  }
+
+  const path = installedBinary(recipe, dir, version);
+  if (!path) {
+    throw new Error(`Installed ${recipe.package} but ${recipe.bin} is missing`);
+  }
+  errorLog(`[lsmcp] Installed ${recipe.bin} to ${path}`);
+  return { command: path, args: resolved.args };
+}
@@ -0,0 +1,140 @@
+/**
+ * Language server messages as MCP log notifications
+ *
+ * window/logMessage, window/showMessage and $/progress from the language
+ * server are sent to the MCP client through the logging capability, so
+ * server-side failures (a module that does not load, a crashed build script)
+ * explain empty results instead of disappearing into stderr. Their text is
+ * redacted like tool results, since servers log file contents and
+ * environments.
+ */
+
+import type { LSPClient } from "@internal/lsp-client";
+import { redact } from "./redaction.ts";
+
+/** MCP log levels (RFC 5424 severities), least severe first */
+export const MCP_LOG_LEVELS = [
+  "debug",
+  "info",
+  "notice",
+  "warning",
+  "error",
+  "critical",
+  "alert",
+  "emergency",
+] as const;
+
+export type McpLogLevel = (typeof MCP_LOG_LEVELS)[number];
+
+/** Minimum level of forwarded server messages unless configured */
+export const DEFAULT_SERVER_LOG_LEVEL: McpLogLevel = "warning";
+
+/** Minimum level from the config; "off" forwards nothing */
+export type ServerLogLevel = McpLogLevel | "off";
+
+/** Send one log notification to the MCP client */
+export type McpLogSender = (
+  level: McpLogLevel,
+  logger: string,
+  data: string,
+) => void;
+
+interface ServerMessage {
+  /** LSP MessageType: 1 error, 2 warning, 3 info, 4 log, 5 debug */
+  type: number;
+  message: string;
+}
+
+interface ProgressParams {
+  token: string | number;
+  value?: {
+    kind?: "begin" | "report" | "end";
+    title?: string;
+    message?: string;
+    percentage?: number;
+  };
+}
+
+export function isLogLevelEnabled(
+  level: McpLogLevel,
+  minimum: McpLogLevel,
+): boolean {
+  return MCP_LOG_LEVELS.indexOf(level) >= MCP_LOG_LEVELS.indexOf(minimum);
+}
+
+/**
+ * MCP level of an LSP message; messages shown to the user rank one step above
+ * logged ones of the same type
+ */
+export function serverMessageLevel(type: number, shown: boolean): McpLogLevel {
+  switch (type) {
+    case 1:
+      return "error";
+    case 2:
+      return "warning";
+    case 3:
+      return shown ? "notice" : "info";
+    default:
+      return shown ? "info" : "debug";
+  }
+}
+
+function formatProgress(
+  title: string | undefined,
+  value: ProgressParams["value"],
+): string {
+  const parts = [title, value?.message].filter(Boolean).join(": ");
+  const percentage =
+    value?.percentage !== undefined ? ` (${value.percentage}%)` : "";
+  const text = `${parts || "Working"}${percentage}`;
+  return value?.kind === "end" ? `Done: ${text}` : text;
+}
+
+/**
+ * Send the client's server messages with their MCP level; `send` decides
+ * what reaches the MCP client. Returns a function that stops forwarding.
+ */
+export function forwardServerMessages(
+  client: Pick<LSPClient, "on" | "off">,
+  logger: string,
+  send: McpLogSender,
+): () => void {
+  const forward = (level: McpLogLevel, data: string) =>
+    send(level, logger, redact(data));
+
+  const onLogMessage = (params: ServerMessage) =>
+    forward(serverMessageLevel(params.type, false), params.message);
+  const onShowMessage = (params: ServerMessage) =>
+    forward(serverMessageLevel(params.type, true), params.message);
+
+  // Titles only come with "begin"; later reports are named after it
+  const titles = new Map<string | number, string | undefined>();
+  const onProgress = (params: ProgressParams) => {
+    // Progress of lsmcp's own requests is reported on the tool call
+    const token = params.token;
+    if (typeof token === "string" && token.startsWith("lsmcp-")) {
+      return;
+    }
+    const value = params.value;
+    if (value?.kind === "begin") {
+      titles.set(token, value.title);
+    }
+    const title = titles.get(token);
+    if (value?.kind === "end") {
+      titles.delete(token);
+    }
+    forward(
+      value?.kind === "report" ? "debug" : "info",
+      formatProgress(title, value),
+    );
+  };
+
+  client.on("logMessage", onLogMessage);
+  client.on("showMessage", onShowMessage);
+  client.on("progress", onProgress);
+  return () => {
+    client.off("logMessage", onLogMessage);
+    client.off("showMessage", onShowMessage);
+    client.off("progress", onProgress);
+  };
+}
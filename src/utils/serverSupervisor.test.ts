@@ -0,0 +1,121 @@
+import { describe, it, expect, vi } from "vitest";
+import { EventEmitter } from "events";
+import type { LSPClient } from "@internal/lsp-client";
+import { ServerSupervisor } from "./serverSupervisor.ts";
+
+function createFakeClient(documents: { uri: string; text: string }[] = []) {
+  const events = new EventEmitter();
+  return {
+    on: (event: string, listener: (...args: any[]) => void) =>
+      events.on(event, listener),
+    off: (event: string, listener: (...args: any[]) => void) =>
+      events.off(event, listener),
+    emit: (event: string, ...args: unknown[]) => events.emit(event, ...args),
+    stop: vi.fn().mockResolvedValue(undefined),
+    openDocument: vi.fn(),
+    getOpenDocuments: () =>
+      documents.map((d) => ({ ...d, languageId: "go", version: 3 })),
+    getServerStatus: () => undefined,
+  } as unknown as LSPClient;
+}
+
+function flush() {
+  return new Promise((resolve) => setTimeout(resolve, 5));
+}
+
+describe("ServerSupervisor", () => {
+  it("should restart a crashed server and re-open its documents", async () => {
+    const crashed = createFakeClient([
+      { uri: "file:///app/main.go", text: "package main" },
+    ]);
+    const restarted = createFakeClient();
+    const onReplace = vi.fn();
+    const supervisor = new ServerSupervisor({ onReplace, backoff: 0 });
+    supervisor.watch({
+      id: "gopls",
+      client: crashed,
+      pid: 10,
+      restart: async () => ({ client: restarted, pid: 11 }),
+    });
+
+    crashed.emit("serverExit", { code: 2, signal: null });
+    await flush();
+
+    expect(restarted.openDocument).toHaveBeenCalledWith(
+      "file:///app/main.go",
+      "package main",
+      "go",
+    );
+    expect(onReplace).toHaveBeenCalledWith(crashed, restarted);
+    expect(supervisor.status()[0]).toMatchObject({
+      id: "gopls",
+      state: "running",
+      pid: 11,
+      restarts: 1,
+      lastExit: { code: 2, signal: null },
+    });
+    expect(supervisor.events.map((e) => e.kind)).toEqual([
+      "started",
+      "exited",
+      "restarting",
+      "restarted",
+    ]);
+  });
+
+  it("should restart a server after repeated timeouts", async () => {
+    const hung = createFakeClient();
+    const kill = vi.fn();
+    const restart = vi.fn(async () => ({ client: createFakeClient() }));
+    const supervisor = new ServerSupervisor({
+      onReplace: () => {},
+      backoff: 0,
+      timeoutThreshold: 2,
+    });
+    supervisor.watch({ id: "gopls", client: hung, kill, restart });
+
+    hung.emit("requestTimeout", { method: "textDocument/references" });
+    hung.emit("response", 1);
+    hung.emit("requestTimeout", { method: "textDocument/references" });
+    await flush();
+    expect(restart).not.toHaveBeenCalled();
+
+    hung.emit("requestTimeout", { method: "textDocument/hover" });
+    await flush();
+
+    expect(kill).toHaveBeenCalled();
+    expect(restart).toHaveBeenCalledTimes(1);
+  });
+
+  it("should give up after too many restarts", async () => {
+    const supervisor = new ServerSupervisor({
+      onReplace: () => {},
+      backoff: 0,
+      maxRestarts: 2,
+    });
+    const restart = vi.fn(async () => {
+      throw new Error("gopls: command not found");
+    });
+    supervisor.watch({ id: "gopls", client: createFakeClient(), restart });
+
+    supervisor.status()[0].client.emit("serverExit", { code: 1, signal: null });
+    await flush();
+
+    expect(restart).toHaveBeenCalledTimes(2);
+    expect(supervisor.status()[0].state).toBe("failed");
+    expect(supervisor.events.at(-1)?.kind).toBe("gave-up");
+  });
+
+  it("should ignore servers that are no longer watched", async () => {
+    const client = createFakeClient();
+    const restart = vi.fn();
+    const supervisor = new ServerSupervisor({ onReplace: () => {} });
+    supervisor.watch({ id: "gopls (/work/lib)", client, restart });
+
+    supervisor.unwatch(client);
+    client.emit("serverExit", { code: 1, signal: null });
+    await flush();
+
+    expect(restart).not.toHaveBeenCalled();
+    expect(supervisor.status()).toEqual([]);
+  });
+});
@@ -0,0 +1,279 @@
+/**
+ * Language server health monitoring and automatic restart
+ *
+ * A crashed or hung server is replaced by a fresh process: its open documents
+ * are re-opened on the new server and the routing client is pointed at it, so
+ * tools keep working without restarting lsmcp. Restarts are rate limited to
+ * avoid spinning on a server that crashes on startup.
+ */
+
+import type { LSPClient } from "@internal/lsp-client";
+import { debugLogWithPrefix, errorLog } from "./debugLog.ts";
+import { metrics } from "./metrics.ts";
+
+const serverRestarts = metrics.counter(
+  "lsmcp_language_server_restarts_total",
+  "Automatic and manual language server restarts",
+);
+
+export interface ServerProcess {
+  client: LSPClient;
+  pid?: number;
+  /** Terminate the process without a shutdown handshake */
+  kill?: () => void;
+}
+
+export interface SupervisedServer extends ServerProcess {
+  /** Preset id (plus root for per-root servers), for status and logs */
+  id: string;
+  /** Spawn and initialize a replacement */
+  restart: () => Promise<ServerProcess>;
+}
+
+export type ServerEventKind =
+  | "started"
+  | "exited"
+  | "timeout"
+  | "restarting"
+  | "restarted"
+  | "restart-failed"
+  | "gave-up";
+
+export interface ServerEvent {
+  time: Date;
+  server: string;
+  kind: ServerEventKind;
+  message: string;
+}
+
+export type ServerState = "running" | "restarting" | "failed";
+
+export interface ServerHealth {
+  id: string;
+  state: ServerState;
+  pid?: number;
+  restarts: number;
+  consecutiveTimeouts: number;
+  lastExit?: { code: number | null; signal: string | null };
+  client: LSPClient;
+}
+
+export interface SupervisorOptions {
+  /** Called with the old and new client after a restart */
+  onReplace: (previous: LSPClient, next: LSPClient) => void;
+  /** Restarts allowed within restartWindow before giving up (default 5) */
+  maxRestarts?: number;
+  restartWindow?: number;
+  /** Consecutive request timeouts that mark a server as hung (default 3) */
+  timeoutThreshold?: number;
+  /** Delay before a restart, multiplied by the attempt number (default 1s) */
+  backoff?: number;
+}
+
+// Events kept for server_status
+const MAX_EVENTS = 100;
+
+interface Entry {
+  server: SupervisedServer;
+  state: ServerState;
+  restarts: number;
+  restartTimes: number[];
+  consecutiveTimeouts: number;
+  lastExit?: { code: number | null; signal: string | null };
+  detach?: () => void;
+}
+
+export class ServerSupervisor {
+  readonly events: ServerEvent[] = [];
+  private entries: Entry[] = [];
+  private disposed = false;
+  private maxRestarts: number;
+  private restartWindow: number;
+  private timeoutThreshold: number;
+  private backoff: number;
+
+  constructor(private options: SupervisorOptions) {
+    this.maxRestarts = options.maxRestarts ?? 5;
+    this.restartWindow = options.restartWindow ?? 5 * 60_000;
+    this.timeoutThreshold = options.timeoutThreshold ?? 3;
+    this.backoff = options.backoff ?? 1000;
+  }
+
+  watch(server: SupervisedServer): void {
+    const entry: Entry = {
+      server,
+      state: "running",
+      restarts: 0,
+      restartTimes: [],
+      consecutiveTimeouts: 0,
+    };
+    this.entries.push(entry);
+    this.attach(entry);
+    this.record(
+      server.id,
+      "started",
+      server.pid ? `pid ${server.pid}` : "started",
+    );
+  }
+
+  /** Stop watching a server that is shut down on purpose */
+  unwatch(client: LSPClient): void {
+    const index = this.entries.findIndex((e) => e.server.client === client);
+    if (index !== -1) {
+      this.entries[index].detach?.();
+      this.entries.splice(index, 1);
+    }
+  }
+
+  status(): ServerHealth[] {
+    return this.entries.map((entry) => ({
+      id: entry.server.id,
+      state: entry.state,
+      pid: entry.server.pid,
+      restarts: entry.restarts,
+      consecutiveTimeouts: entry.consecutiveTimeouts,
+      lastExit: entry.lastExit,
+      client: entry.server.client,
+    }));
+  }
+
+  /**
+   * Restart a server on request; also revives one that was given up on
+   */
+  async restartServer(id: string): Promise<boolean> {
+    const entry = this.entries.find((e) => e.server.id === id);
+    if (!entry) {
+      return false;
+    }
+    if (entry.state === "failed") {
+      entry.state = "running";
+      entry.restartTimes = [];
+    }
+    await this.restart(entry);
+    return true;
+  }
+
+  dispose(): void {
+    this.disposed = true;
+    for (const entry of this.entries) {
+      entry.detach?.();
+    }
+  }
+
+  private attach(entry: Entry): void {
+    const { client } = entry.server;
+    const onExit = (exit: { code: number | null; signal: string | null }) => {
+      entry.lastExit = exit;
+      this.record(
+        entry.server.id,
+        "exited",
+        `exited with ${exit.signal ?? `code ${exit.code}`}`,
+      );
+      void this.restart(entry);
+    };
+    const onTimeout = ({ method }: { method: string }) => {
+      entry.consecutiveTimeouts++;
+      this.record(
+        entry.server.id,
+        "timeout",
+        `${method} timed out (${entry.consecutiveTimeouts}/${this.timeoutThreshold})`,
+      );
+      if (entry.consecutiveTimeouts >= this.timeoutThreshold) {
+        void this.restart(entry);
+      }
+    };
+    const onResponse = () => {
+      entry.consecutiveTimeouts = 0;
+    };
+    client.on("serverExit", onExit);
+    client.on("requestTimeout", onTimeout);
+    client.on("response", onResponse);
+    entry.detach = () => {
+      client.off("serverExit", onExit);
+      client.off("requestTimeout", onTimeout);
+      client.off("response", onResponse);
+    };
+  }
+
+  private async restart(entry: Entry): Promise<void> {
+    if (this.disposed || entry.state !== "running") {
+      return;
+    }
+    const now = Date.now();
+    entry.restartTimes = entry.restartTimes.filter(
+      (time) => now - time < this.restartWindow,
+    );
+    if (entry.restartTimes.length >= this.maxRestarts) {
+      entry.state = "failed";
+      this.record(
+        entry.server.id,
+        "gave-up",
+        `${this.maxRestarts} restarts within ${Math.round(this.restartWindow / 1000)}s, not restarting again`,
+      );
+      errorLog(
+        `[lsmcp] ${entry.server.id} keeps failing; restart lsmcp after fixing the server`,
+      );
+      return;
+    }
+    entry.state = "restarting";
+    entry.restartTimes.push(now);
+
+    const previous = entry.server.client;
+    const documents = previous.getOpenDocuments();
+    entry.detach?.();
+    this.record(
+      entry.server.id,
+      "restarting",
+      `attempt ${entry.restartTimes.length}, ${documents.length} open document(s)`,
+    );
+    // A hung server would not answer shutdown; kill it instead
+    if (entry.server.kill) {
+      entry.server.kill();
+    } else {
+      await previous.stop().catch(() => {});
+    }
+    await new Promise((resolve) =>
+      setTimeout(resolve, this.backoff * entry.restartTimes.length),
+    );
+    if (this.disposed) {
+      return;
+    }
+
+    try {
+      const next = await entry.server.restart();
+      const { client, pid } = next;
+      for (const document of documents) {
+        client.openDocument(document.uri, document.text, document.languageId);
+      }
+      entry.server = { ...entry.server, ...next };
+      entry.restarts++;
+      serverRestarts.inc({ server: entry.server.id });
+      entry.consecutiveTimeouts = 0;
+      entry.state = "running";
+      this.attach(entry);
+      this.options.onReplace(previous, client);
+      this.record(
+        entry.server.id,
+        "restarted",
+        `${pid ? `pid ${pid}, ` : ""}re-opened ${documents.length} document(s)`,
+      );
+      errorLog(`[lsmcp] Restarted ${entry.server.id}`);
+    } catch (error) {
+      this.record(
+        entry.server.id,
+        "restart-failed",
+        error instanceof Error ? error.message : String(error),
+      );
+      entry.state = "running";
+      await this.restart(entry);
+    }
+  }
+
+  private record(server: string, kind: ServerEventKind, message: string) {
+    this.events.push({ time: new Date(), server, kind, message });
+    if (this.events.length > MAX_EVENTS) {
+      this.events.splice(0, this.events.length - MAX_EVENTS);
+    }
+    debugLogWithPrefix("ServerSupervisor", `${server}: ${kind} ${message}`);
+  }
+}
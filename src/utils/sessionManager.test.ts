@@ -0,0 +1,50 @@
+import { describe, it, expect, vi } from "vitest";
+import type { LSPClient } from "@internal/lsp-client";
+import type { McpContext } from "@internal/types";
+import { SessionManager } from "./sessionManager.ts";
+
+function createFakeClient() {
+  return {
+    openDocument: vi.fn(),
+    updateDocument: vi.fn(),
+    closeDocument: vi.fn(),
+    isDocumentOpen: vi.fn(),
+  } as unknown as LSPClient;
+}
+
+describe("SessionManager", () => {
+  it("should give each session a context bound to its own client", () => {
+    const client = createFakeClient();
+    const context = { lspClient: client, fs: {} } as McpContext;
+    const manager = new SessionManager(client, context);
+
+    const first = manager.open("a");
+    const second = manager.open("b");
+
+    expect(manager.open("a")).toBe(first);
+    expect(first.client).not.toBe(second.client);
+    expect(first.context.lspClient).toBe(first.client);
+    expect(first.context.fs).toBe(context.fs);
+    expect(first.context.sessionId).toBe("a");
+    expect(manager.list().map((s) => s.id)).toEqual(["a", "b"]);
+  });
+
+  it("should release documents held by a closed session", () => {
+    const client = createFakeClient();
+    const manager = new SessionManager(client, {
+      lspClient: client,
+    } as McpContext);
+
+    manager.open("a").client.openDocument("file:///main.go", "package main");
+    manager.open("b").client.openDocument("file:///main.go", "package main");
+    expect(client.openDocument).toHaveBeenCalledTimes(1);
+
+    manager.close("a");
+    expect(client.closeDocument).not.toHaveBeenCalled();
+    expect(manager.getOpenDocuments("b")).toEqual(["file:///main.go"]);
+
+    manager.close("b");
+    expect(client.closeDocument).toHaveBeenCalledWith("file:///main.go");
+    expect(manager.get("b")).toBeUndefined();
+  });
+});
@@ -0,0 +1,81 @@
+/**
+ * MCP session management
+ *
+ * Multiplexes several MCP sessions (e.g. over the HTTP transport) onto one
+ * language server. Each session gets its own view of the LSP client so that
+ * open documents are tracked per session, and its own previewed edits.
+ */
+
+import type { McpContext } from "@internal/types";
+import {
+  SharedDocumentTracker,
+  createSessionClient,
+  type LSPClient,
+} from "@internal/lsp-client";
+import { debugLogWithPrefix } from "./debugLog.ts";
+import { dropPendingEdits } from "../tools/editor/pendingEdits.ts";
+
+export interface McpSession {
+  id: string;
+  client: LSPClient;
+  context: McpContext;
+  startedAt: Date;
+}
+
+export class SessionManager {
+  private sessions = new Map<string, McpSession>();
+  private tracker: SharedDocumentTracker;
+
+  constructor(
+    private client: LSPClient,
+    private context: McpContext,
+  ) {
+    this.tracker = new SharedDocumentTracker(client);
+  }
+
+  /**
+   * Create (or return) the session with the given id
+   */
+  open(id: string): McpSession {
+    const existing = this.sessions.get(id);
+    if (existing) {
+      return existing;
+    }
+
+    const client = createSessionClient(this.client, this.tracker, id);
+    const session: McpSession = {
+      id,
+      client,
+      context: { ...this.context, lspClient: client, sessionId: id },
+      startedAt: new Date(),
+    };
+    this.sessions.set(id, session);
+    debugLogWithPrefix("Session", `Opened ${id} (${this.sessions.size} active)`);
+    return session;
+  }
+
+  /**
+   * Close a session, release the documents it still holds open and forget
+   * its previewed edits
+   */
+  close(id: string): void {
+    if (!this.sessions.delete(id)) {
+      return;
+    }
+    this.tracker.closeSession(id);
+    dropPendingEdits(id);
+    debugLogWithPrefix("Session", `Closed ${id} (${this.sessions.size} active)`);
+  }
+
+  get(id: string): McpSession | undefined {
+    return this.sessions.get(id);
+  }
+
+  list(): McpSession[] {
+    return Array.from(this.sessions.values());
+  }
+
+  getOpenDocuments(id?: string): string[] {
+    return this.tracker.getOpenDocuments(id);
+  }
+}
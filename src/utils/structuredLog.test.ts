@@ -0,0 +1,105 @@
+import { afterEach, describe, it, expect, vi } from "vitest";
+import { mkdtempSync, readFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  configureLogging,
+  formatRecord,
+  levelFor,
+  parseLogLevels,
+  resetLogging,
+  writeLog,
+} from "./structuredLog.ts";
+
+afterEach(() => {
+  vi.unstubAllEnvs();
+  resetLogging();
+});
+
+describe("parseLogLevels", () => {
+  it("should read a default level and scope overrides", () => {
+    const spec = parseLogLevels("info, lsp=debug ,indexer:SymbolIndex=TRACE");
+
+    expect(spec.level).toBe("info");
+    expect(Object.fromEntries(spec.scopes)).toEqual({
+      lsp: "debug",
+      "indexer:symbolindex": "trace",
+    });
+    expect(() => parseLogLevels("verbose")).toThrow(/Unknown log level/);
+  });
+});
+
+describe("levelFor", () => {
+  it("should use the most specific matching scope", () => {
+    configureLogging({ level: "error,lsp=info,lsp:request=trace" });
+
+    expect(levelFor("mcp", "tool")).toBe("error");
+    expect(levelFor("lsp", "Client")).toBe("info");
+    expect(levelFor("lsp", "request")).toBe("trace");
+  });
+
+  it("should honor the legacy debug switches without a level", () => {
+    vi.stubEnv("LSMCP_LOG_LEVEL", "");
+    vi.stubEnv("MCP_DEBUG", "");
+    vi.stubEnv("LSMCP_DEBUG", "");
+    vi.stubEnv("LSP_DEBUG", "1");
+
+    expect(levelFor("lsp", "Client")).toBe("debug");
+    expect(levelFor("mcp")).toBe("warn");
+
+    configureLogging({ level: "error" });
+    expect(levelFor("lsp", "Client")).toBe("error");
+  });
+});
+
+describe("formatRecord", () => {
+  const time = new Date("2025-01-01T00:00:00.000Z");
+
+  it("should keep the bracketed tags in text format", () => {
+    expect(formatRecord("text", "debug", "mcp", "Tool", ["run", 2])).toBe(
+      "[MCP:Tool] run 2",
+    );
+    expect(
+      formatRecord("text", "warn", "lsp", undefined, ["slow"], { ms: 10 }),
+    ).toBe("[LSP:WARN] slow ms=10");
+  });
+
+  it("should emit one JSON object per record", () => {
+    const line = formatRecord(
+      "json",
+      "info",
+      "mcp",
+      "tool",
+      ["lsp_get_hover"],
+      { duration: 12, ok: true },
+      time,
+    );
+
+    expect(JSON.parse(line)).toEqual({
+      time: "2025-01-01T00:00:00.000Z",
+      level: "info",
+      scope: "mcp:tool",
+      msg: "lsp_get_hover",
+      duration: 12,
+      ok: true,
+    });
+  });
+});
+
+describe("writeLog", () => {
+  it("should append enabled records to the log file", () => {
+    const file = join(mkdtempSync(join(tmpdir(), "lsmcp-log-")), "lsmcp.log");
+    configureLogging({ level: "info", format: "json", file });
+
+    writeLog("info", "indexer", "SymbolIndex", ["Indexed 3/3 files"]);
+    writeLog("debug", "indexer", "SymbolIndex", ["hidden"]);
+
+    const lines = readFileSync(file, "utf-8").trim().split("\n");
+    expect(lines).toHaveLength(1);
+    expect(JSON.parse(lines[0])).toMatchObject({
+      level: "info",
+      scope: "indexer:SymbolIndex",
+      msg: "Indexed 3/3 files",
+    });
+  });
+});
@@ -0,0 +1,305 @@
+/**
+ * Leveled, structured logger shared by the MCP server, LSP client and indexer
+ *
+ * Every record has a level, a scope (`mcp`, `lsp` or `indexer`, optionally
+ * followed by a component such as `lsp:Client`) and optional fields. The
+ * level can be set per scope, e.g. `warn,lsp=debug,indexer:SymbolIndex=trace`.
+ *
+ * Configuration comes from `configureLogging()` (the `--log-*` CLI flags) or
+ * LSMCP_LOG_LEVEL, LSMCP_LOG_FORMAT and LSMCP_LOG_FILE. The older MCP_DEBUG /
+ * LSMCP_DEBUG and LSP_DEBUG switches still enable debug output for their
+ * subsystems.
+ *
+ * Records are passed through the secret redaction filter (redaction.ts).
+ *
+ * IMPORTANT: stdout carries the MCP protocol, so records go to stderr or to
+ * the log file, never to stdout.
+ */
+
+import { appendFileSync } from "fs";
+import { format as formatArgs } from "util";
+import { redact } from "./redaction.ts";
+
+export const LOG_LEVELS = ["error", "warn", "info", "debug", "trace"] as const;
+export type LogLevel = (typeof LOG_LEVELS)[number];
+export type LogFormat = "text" | "json";
+export type LogSubsystem = "mcp" | "lsp" | "indexer";
+export type LogFields = Record<string, unknown>;
+
+export interface LoggingOptions {
+  /** Level spec: a default level plus `scope=level` overrides */
+  level?: string;
+  format?: LogFormat;
+  /** Append records to this file instead of stderr */
+  file?: string;
+}
+
+interface LevelSpec {
+  level: LogLevel;
+  scopes: Map<string, LogLevel>;
+}
+
+const DEFAULT_LEVEL: LogLevel = "warn";
+
+let options: LoggingOptions | undefined;
+let spec: LevelSpec | undefined;
+
+function isTruthy(value: string | undefined): boolean {
+  return value === "1" || value === "true";
+}
+
+function isLogLevel(value: string): value is LogLevel {
+  return (LOG_LEVELS as readonly string[]).includes(value);
+}
+
+/**
+ * Parse `info,lsp=debug,indexer:SymbolIndex=trace`
+ * @throws on unknown levels
+ */
+export function parseLogLevels(text: string): LevelSpec {
+  const parsed: LevelSpec = { level: DEFAULT_LEVEL, scopes: new Map() };
+  for (const part of text.split(",")) {
+    const item = part.trim();
+    if (!item) {
+      continue;
+    }
+    const [scope, level] = item.includes("=")
+      ? item.split("=", 2).map((s) => s.trim().toLowerCase())
+      : [undefined, item.toLowerCase()];
+    if (!isLogLevel(level)) {
+      throw new Error(
+        `Unknown log level "${level}" (expected ${LOG_LEVELS.join(", ")})`,
+      );
+    }
+    if (scope) {
+      parsed.scopes.set(scope, level);
+    } else {
+      parsed.level = level;
+    }
+  }
+  return parsed;
+}
+
+function envOptions(): LoggingOptions {
+  const format = process.env.LSMCP_LOG_FORMAT;
+  return {
+    level: process.env.LSMCP_LOG_LEVEL || undefined,
+    format: format === "json" || format === "text" ? format : undefined,
+    file: process.env.LSMCP_LOG_FILE || undefined,
+  };
+}
+
+function currentOptions(): LoggingOptions {
+  return (options ??= envOptions());
+}
+
+function currentSpec(): LevelSpec {
+  if (spec) {
+    return spec;
+  }
+  const level = currentOptions().level;
+  let parsed: LevelSpec;
+  try {
+    parsed = parseLogLevels(level ?? "");
+  } catch (error) {
+    parsed = parseLogLevels("");
+    reportInvalidLevel(error);
+  }
+  // Legacy switches only apply when no level was configured
+  const legacy: [boolean, LogSubsystem[]][] = [
+    [
+      isTruthy(process.env.MCP_DEBUG) || isTruthy(process.env.LSMCP_DEBUG),
+      ["mcp", "indexer"],
+    ],
+    [isTruthy(process.env.LSP_DEBUG), ["lsp"]],
+  ];
+  for (const [enabled, subsystems] of legacy) {
+    for (const subsystem of subsystems) {
+      if (enabled && level === undefined) {
+        parsed.scopes.set(subsystem, "debug");
+      }
+    }
+  }
+  return (spec = parsed);
+}
+
+function reportInvalidLevel(error: unknown): void {
+  process.stderr.write(
+    `[MCP:ERROR] ${error instanceof Error ? error.message : String(error)}\n`,
+  );
+}
+
+/**
+ * Apply logging options; unset options keep their environment defaults
+ * @throws when the level spec is invalid
+ */
+export function configureLogging(next: LoggingOptions): void {
+  const merged = { ...envOptions() };
+  for (const [key, value] of Object.entries(next)) {
+    if (value !== undefined) {
+      (merged as Record<string, unknown>)[key] = value;
+    }
+  }
+  if (merged.level !== undefined) {
+    parseLogLevels(merged.level);
+  }
+  options = merged;
+  spec = undefined;
+}
+
+/**
+ * Forget configured options and re-read the environment on next use
+ */
+export function resetLogging(): void {
+  options = undefined;
+  spec = undefined;
+}
+
+function scopeName(subsystem: LogSubsystem, component?: string): string {
+  return component ? `${subsystem}:${component}` : subsystem;
+}
+
+/**
+ * Effective level for a scope: the most specific matching override wins
+ */
+export function levelFor(
+  subsystem: LogSubsystem,
+  component?: string,
+): LogLevel {
+  const { level, scopes } = currentSpec();
+  let scope = scopeName(subsystem, component).toLowerCase();
+  for (;;) {
+    const override = scopes.get(scope);
+    if (override) {
+      return override;
+    }
+    const colon = scope.lastIndexOf(":");
+    if (colon < 0) {
+      return level;
+    }
+    scope = scope.slice(0, colon);
+  }
+}
+
+export function isLogEnabled(
+  level: LogLevel,
+  subsystem: LogSubsystem,
+  component?: string,
+): boolean {
+  return (
+    LOG_LEVELS.indexOf(level) <=
+    LOG_LEVELS.indexOf(levelFor(subsystem, component))
+  );
+}
+
+function textTag(
+  level: LogLevel,
+  subsystem: LogSubsystem,
+  component?: string,
+): string {
+  const parts = [subsystem.toUpperCase()];
+  if (component) {
+    parts.push(component);
+  }
+  if (level === "error" || level === "warn") {
+    parts.push(level.toUpperCase());
+  }
+  return `[${parts.join(":")}]`;
+}
+
+function fieldValue(value: unknown): unknown {
+  if (value instanceof Error) {
+    return { name: value.name, message: value.message, stack: value.stack };
+  }
+  return value;
+}
+
+export function formatRecord(
+  logFormat: LogFormat,
+  level: LogLevel,
+  subsystem: LogSubsystem,
+  component: string | undefined,
+  args: unknown[],
+  fields: LogFields = {},
+  time: Date = new Date(),
+): string {
+  const msg = formatArgs(...args);
+  if (logFormat === "json") {
+    const record: LogFields = {
+      time: time.toISOString(),
+      level,
+      scope: scopeName(subsystem, component),
+      msg,
+    };
+    for (const [key, value] of Object.entries(fields)) {
+      record[key] = fieldValue(value);
+    }
+    return JSON.stringify(record);
+  }
+  const extra = Object.entries(fields).map(([key, value]) => {
+    const shown = fieldValue(value);
+    const text = typeof shown === "string" ? shown : JSON.stringify(shown);
+    return `${key}=${text}`;
+  });
+  return [textTag(level, subsystem, component), msg, ...extra]
+    .filter(Boolean)
+    .join(" ");
+}
+
+/**
+ * Write one record if its scope logs at `level`
+ */
+export function writeLog(
+  level: LogLevel,
+  subsystem: LogSubsystem,
+  component: string | undefined,
+  args: unknown[],
+  fields?: LogFields,
+): void {
+  if (!isLogEnabled(level, subsystem, component)) {
+    return;
+  }
+  const { format: logFormat = "text", file } = currentOptions();
+  const line = redact(
+    formatRecord(logFormat, level, subsystem, component, args, fields),
+  );
+  if (file) {
+    try {
+      appendFileSync(file, line + "\n");
+      return;
+    } catch {
+      // Fall back to stderr when the log file is not writable
+    }
+  }
+  process.stderr.write(line + "\n");
+}
+
+export interface Logger {
+  error(msg: string, fields?: LogFields): void;
+  warn(msg: string, fields?: LogFields): void;
+  info(msg: string, fields?: LogFields): void;
+  debug(msg: string, fields?: LogFields): void;
+  trace(msg: string, fields?: LogFields): void;
+  enabled(level: LogLevel): boolean;
+}
+
+/**
+ * Logger for one scope, e.g. `createLogger("lsp", "Connection")`
+ */
+export function createLogger(
+  subsystem: LogSubsystem,
+  component?: string,
+): Logger {
+  const at =
+    (level: LogLevel) =>
+    (msg: string, fields?: LogFields): void =>
+      writeLog(level, subsystem, component, [msg], fields);
+  return {
+    error: at("error"),
+    warn: at("warn"),
+    info: at("info"),
+    debug: at("debug"),
+    trace: at("trace"),
+    enabled: (level) => isLogEnabled(level, subsystem, component),
+  };
+}
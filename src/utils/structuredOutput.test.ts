@@ -0,0 +1,72 @@
+import { describe, it, expect } from "vitest";
+import { z } from "zod";
+import { REDACTED } from "./redaction.ts";
+import { redactStructured, structuredResult } from "./structuredOutput.ts";
+import { toMcpToolHandler } from "./mcpServerHelpers.ts";
+
+const context = { lspClient: {}, fs: {} } as any;
+
+describe("structuredResult", () => {
+  it("should fall back to the text for tools without an output schema", () => {
+    expect(structuredResult(undefined, "Done", false)).toEqual({
+      text: "Done",
+    });
+    expect(structuredResult(undefined, "Done", true)).toBeUndefined();
+  });
+
+  it("should redact secrets anywhere in the result", () => {
+    const token = `ghp_${"a".repeat(36)}`;
+
+    expect(
+      redactStructured({
+        files: [{ preview: `token ${token}`, line: 3 }],
+        ok: true,
+      }),
+    ).toEqual({
+      files: [{ preview: `token ${REDACTED}`, line: 3 }],
+      ok: true,
+    });
+  });
+});
+
+describe("toMcpToolHandler structured content", () => {
+  it("should return what the handler set next to the text", async () => {
+    const handler = toMcpToolHandler(
+      async (_args: unknown, callContext) => {
+        callContext?.setStructuredContent?.({ total: 2 });
+        return "Found 2 references";
+      },
+      context,
+      "lsp_find_references",
+      { total: z.number() },
+    );
+
+    const result = await handler({});
+
+    expect(result.content).toEqual([
+      { type: "text", text: "Found 2 references" },
+    ]);
+    expect(result.structuredContent).toEqual({ total: 2 });
+    expect(result.isError).toBeUndefined();
+  });
+
+  it("should wrap the text of tools without an output schema", async () => {
+    const handler = toMcpToolHandler(async () => "Saved", context, "save");
+
+    expect((await handler({})).structuredContent).toEqual({ text: "Saved" });
+  });
+
+  it("should report typed tools that set no result as failed", async () => {
+    const handler = toMcpToolHandler(
+      async () => "Error: index is not ready",
+      context,
+      "search_symbols",
+      { total: z.number() },
+    );
+
+    const result = await handler({});
+
+    expect(result.isError).toBe(true);
+    expect(result.structuredContent).toBeUndefined();
+  });
+});
@@ -0,0 +1,59 @@
+/**
+ * Structured tool results (MCP structuredContent)
+ *
+ * Every tool declares an output schema. Tools with a typed result pass it to
+ * context.setStructuredContent; the others return { text } holding the text
+ * content, so automation can read structuredContent from any successful call
+ * instead of parsing prose.
+ */
+
+import type { RegisteredTool } from "@modelcontextprotocol/sdk/server/mcp.js";
+import { z, type ZodRawShape } from "zod";
+import { redact } from "./redaction.ts";
+
+/** Output schema of tools without a typed result */
+export const TEXT_OUTPUT_SHAPE = {
+  text: z.string().describe("The result as shown in the text content"),
+};
+
+/**
+ * Declare the output schema on a registered tool; McpServer lists it in
+ * tools/list and checks structuredContent of successful calls against it
+ */
+export function declareOutputSchema(
+  handle: RegisteredTool,
+  shape: ZodRawShape = TEXT_OUTPUT_SHAPE,
+): RegisteredTool {
+  return Object.assign(handle, { outputSchema: z.object(shape) });
+}
+
+/** Redact secrets in every string of a structured result */
+export function redactStructured<T>(value: T): T {
+  if (typeof value === "string") {
+    return redact(value) as T;
+  }
+  if (Array.isArray(value)) {
+    return value.map(redactStructured) as T;
+  }
+  if (value && typeof value === "object") {
+    return Object.fromEntries(
+      Object.entries(value).map(([key, item]) => [key, redactStructured(item)]),
+    ) as T;
+  }
+  return value;
+}
+
+/**
+ * structuredContent of a successful call: what the tool set, else the text
+ * for tools without an output schema of their own
+ */
+export function structuredResult(
+  content: Record<string, unknown> | undefined,
+  text: string,
+  typed: boolean,
+): Record<string, unknown> | undefined {
+  if (content) {
+    return redactStructured(content);
+  }
+  return typed ? undefined : { text };
+}
@@ -0,0 +1,100 @@
+import { describe, it, expect } from "vitest";
+import { SymbolKind } from "vscode-languageserver-types";
+import type { SymbolInformation } from "@internal/types";
+import {
+  filterWorkspaceSymbols,
+  mergeSymbolResults,
+  symbolLocationKey,
+} from "./symbolMerge.ts";
+
+function symbol(
+  name: string,
+  uri: string,
+  line: number,
+  kind: SymbolKind = SymbolKind.Function,
+  character = 0,
+): SymbolInformation {
+  return {
+    name,
+    kind,
+    location: {
+      uri,
+      range: {
+        start: { line, character },
+        end: { line, character: character + name.length },
+      },
+    },
+  };
+}
+
+describe("symbolLocationKey", () => {
+  it("should ignore the column", () => {
+    expect(symbolLocationKey(symbol("Run", "file:///a.go", 3, 12, 0))).toBe(
+      symbolLocationKey(symbol("Run", "file:///a.go", 3, 12, 5)),
+    );
+  });
+});
+
+describe("mergeSymbolResults", () => {
+  it("should deduplicate by location and keep index entries first", () => {
+    const indexed = [symbol("Run", "file:///root/a.go", 3)];
+    const lsp = [
+      symbol("Generated", "file:///root/gen.go", 10),
+      symbol("Run", "file:///root/a.go", 3, SymbolKind.Function, 5),
+    ];
+
+    const merged = mergeSymbolResults(indexed, lsp);
+
+    expect(merged.map((s) => [s.name, s.source])).toEqual([
+      ["Run", "index"],
+      ["Generated", "lsp"],
+    ]);
+  });
+
+  it("should keep different symbols on the same line", () => {
+    const merged = mergeSymbolResults(
+      [symbol("a", "file:///root/a.ts", 1)],
+      [symbol("b", "file:///root/a.ts", 1)],
+    );
+    expect(merged).toHaveLength(2);
+  });
+});
+
+describe("filterWorkspaceSymbols", () => {
+  const symbols = [
+    symbol("User", "file:///root/model/user.go", 5, SymbolKind.Struct),
+    symbol("NewUser", "file:///root/model/user.go", 12),
+    symbol("Handler", "file:///root/http/handler.go", 3, SymbolKind.Struct),
+  ];
+
+  it("should filter by kind", () => {
+    const result = filterWorkspaceSymbols("/root", symbols, {
+      kind: [SymbolKind.Struct],
+    });
+    expect(result.map((s) => s.name)).toEqual(["User", "Handler"]);
+  });
+
+  it("should filter by file relative to root", () => {
+    const result = filterWorkspaceSymbols("/root", symbols, {
+      file: "model/",
+    });
+    expect(result.map((s) => s.name)).toEqual(["User", "NewUser"]);
+  });
+
+  it("should drop symbols outside root unless external is included", () => {
+    const external = [symbol("Println", "file:///usr/lib/go/fmt/print.go", 1)];
+    expect(filterWorkspaceSymbols("/root", external, {})).toEqual([]);
+    expect(
+      filterWorkspaceSymbols("/root", external, { includeExternal: true }),
+    ).toHaveLength(1);
+  });
+
+  it("should drop symbols without a range", () => {
+    const unresolved = {
+      name: "Lazy",
+      kind: SymbolKind.Function,
+      location: { uri: "file:///root/lazy.go" },
+    } as unknown as SymbolInformation;
+    expect(filterWorkspaceSymbols("/root", [unresolved], {})).toEqual([]);
+  });
+});
@@ -0,0 +1,104 @@
+/**
+ * Merge symbol index results with workspace/symbol results from the language server
+ */
+
+import { relative } from "path";
+import { fileURLToPath } from "url";
+import type { IndexedSymbol, SymbolQuery } from "@internal/code-indexer";
+import type { SymbolInformation } from "@internal/types";
+
+export interface MergedSymbol extends IndexedSymbol {
+  /** Where the symbol came from */
+  source: "index" | "lsp";
+}
+
+/**
+ * Symbols are considered the same when name, file and start line match.
+ * Columns are ignored because servers disagree on whether the range starts
+ * at the declaration keyword or at the identifier.
+ */
+export function symbolLocationKey(symbol: IndexedSymbol): string {
+  return `${symbol.location.uri}#${symbol.location.range.start.line}#${symbol.name}`;
+}
+
+function toRelativePath(rootPath: string, uri: string): string | undefined {
+  try {
+    return relative(rootPath, fileURLToPath(uri));
+  } catch {
+    return undefined;
+  }
+}
+
+/**
+ * Apply the same filters the index uses to workspace/symbol results
+ */
+export function filterWorkspaceSymbols(
+  rootPath: string,
+  symbols: SymbolInformation[],
+  query: Pick<SymbolQuery, "kind" | "file" | "containerName"> & {
+    includeExternal?: boolean;
+  },
+): SymbolInformation[] {
+  const kinds =
+    query.kind === undefined
+      ? undefined
+      : Array.isArray(query.kind)
+        ? query.kind
+        : [query.kind];
+
+  return symbols.filter((symbol) => {
+    // WorkspaceSymbol results may omit the range until resolved
+    if (!symbol.location?.range) {
+      return false;
+    }
+    if (kinds && !kinds.includes(symbol.kind)) {
+      return false;
+    }
+    if (query.containerName && symbol.containerName !== query.containerName) {
+      return false;
+    }
+    const relativePath = toRelativePath(rootPath, symbol.location.uri);
+    const isExternal = !relativePath || relativePath.startsWith("..");
+    if (isExternal && !query.includeExternal) {
+      return false;
+    }
+    if (query.file && !(relativePath ?? "").includes(query.file)) {
+      return false;
+    }
+    return true;
+  });
+}
+
+/**
+ * Merge index results with LSP results, keeping index entries first and
+ * adding only LSP symbols the index does not know about
+ */
+export function mergeSymbolResults(
+  indexed: IndexedSymbol[],
+  lspSymbols: SymbolInformation[],
+): MergedSymbol[] {
+  const seen = new Set<string>();
+  const merged: MergedSymbol[] = [];
+
+  for (const symbol of indexed) {
+    seen.add(symbolLocationKey(symbol));
+    merged.push({ ...symbol, source: "index" });
+  }
+
+  for (const symbol of lspSymbols) {
+    const entry: IndexedSymbol = {
+      name: symbol.name,
+      kind: symbol.kind,
+      location: symbol.location,
+      containerName: symbol.containerName,
+      deprecated: symbol.deprecated,
+    };
+    const key = symbolLocationKey(entry);
+    if (!seen.has(key)) {
+      seen.add(key);
+      merged.push({ ...entry, source: "lsp" });
+    }
+  }
+
+  return merged;
+}
@@ -0,0 +1,72 @@
+import { describe, it, expect } from "vitest";
+import {
+  hasSymbolQueryFilters,
+  matchesFileFilter,
+  parseSymbolQuery,
+} from "./symbolQueryParser.ts";
+
+describe("parseSymbolQuery", () => {
+  it("should parse filters and free text", () => {
+    expect(
+      parseSymbolQuery(
+        "kind:function container:User file:**/handlers/*.go exported:true Save",
+      ),
+    ).toEqual({
+      text: "Save",
+      kind: ["function"],
+      containerName: "User",
+      file: "**/handlers/*.go",
+      exported: true,
+    });
+  });
+
+  it("should treat plain text as a name query", () => {
+    const parsed = parseSymbolQuery("processUsers");
+    expect(parsed).toEqual({ text: "processUsers" });
+    expect(hasSymbolQueryFilters(parsed)).toBe(false);
+  });
+
+  it("should accept repeated and comma-separated kinds", () => {
+    expect(parseSymbolQuery("kind:class,interface kind:Struct").kind).toEqual(
+      ["class", "interface", "Struct"],
+    );
+  });
+
+  it("should support aliases and quoted values", () => {
+    expect(parseSymbolQuery('in:"User Service" path:src/api')).toEqual({
+      containerName: "User Service",
+      file: "src/api",
+    });
+  });
+
+  it("should keep unknown keys and paths as free text", () => {
+    expect(parseSymbolQuery("std::fmt")).toEqual({ text: "std::fmt" });
+    expect(parseSymbolQuery("foo:bar")).toEqual({ text: "foo:bar" });
+  });
+
+  it("should reject invalid filter values", () => {
+    expect(() => parseSymbolQuery("exported:maybe")).toThrow(
+      /expected true or false/,
+    );
+    expect(() => parseSymbolQuery("kind:")).toThrow(/Missing value/);
+  });
+});
+
+describe("matchesFileFilter", () => {
+  it("should match globs against the relative path", () => {
+    expect(matchesFileFilter("api/handlers/user.go", "**/handlers/*.go")).toBe(
+      true,
+    );
+    expect(matchesFileFilter("handlers/user.go", "**/handlers/*.go")).toBe(
+      true,
+    );
+    expect(matchesFileFilter("api/models/user.go", "**/handlers/*.go")).toBe(
+      false,
+    );
+  });
+
+  it("should match plain values as a substring", () => {
+    expect(matchesFileFilter("src/api/user.ts", "api/")).toBe(true);
+    expect(matchesFileFilter("src/web/user.ts", "api/")).toBe(false);
+  });
+});
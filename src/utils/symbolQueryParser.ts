@@ -0,0 +1,141 @@
+/**
+ * Parse structured symbol search queries such as
+ * `kind:function container:User file:handlers/*.go exported:true Save`
+ */
+
+import { minimatch } from "minimatch";
+
+export interface ParsedSymbolQuery {
+  /** Free text left after removing filters, matched against the symbol name */
+  text?: string;
+  kind?: string[];
+  containerName?: string;
+  file?: string;
+  exported?: boolean;
+}
+
+const FILTER_KEYS: Record<string, keyof Omit<ParsedSymbolQuery, "text">> = {
+  kind: "kind",
+  container: "containerName",
+  in: "containerName",
+  file: "file",
+  path: "file",
+  exported: "exported",
+};
+
+export const SYMBOL_QUERY_FILTERS = Object.keys(FILTER_KEYS);
+
+/**
+ * Split on whitespace, keeping double-quoted sections together
+ */
+function tokenize(input: string): string[] {
+  const tokens: string[] = [];
+  let current = "";
+  let quoted = false;
+
+  for (const char of input) {
+    if (char === '"') {
+      quoted = !quoted;
+    } else if (/\s/.test(char) && !quoted) {
+      if (current) {
+        tokens.push(current);
+        current = "";
+      }
+    } else {
+      current += char;
+    }
+  }
+  if (current) {
+    tokens.push(current);
+  }
+  return tokens;
+}
+
+function parseBoolean(key: string, value: string): boolean {
+  const normalized = value.toLowerCase();
+  if (normalized === "true" || normalized === "yes") {
+    return true;
+  }
+  if (normalized === "false" || normalized === "no") {
+    return false;
+  }
+  throw new Error(
+    `Invalid value for ${key}: "${value}" (expected true or false)`,
+  );
+}
+
+/**
+ * Parse a query string into free text and filters. Tokens of the form
+ * `key:value` with a known key become filters; anything else (including
+ * `std::fmt` style paths) is kept as free text. `kind` may be repeated or
+ * comma-separated.
+ */
+export function parseSymbolQuery(input: string): ParsedSymbolQuery {
+  const result: ParsedSymbolQuery = {};
+  const text: string[] = [];
+
+  for (const token of tokenize(input)) {
+    const separator = token.indexOf(":");
+    const key = separator > 0 ? token.slice(0, separator).toLowerCase() : "";
+    const value = token.slice(separator + 1);
+    const field = FILTER_KEYS[key];
+
+    if (!field || value.startsWith(":")) {
+      text.push(token);
+      continue;
+    }
+    if (!value) {
+      throw new Error(`Missing value for ${key}: filter`);
+    }
+
+    switch (field) {
+      case "kind":
+        result.kind = [
+          ...(result.kind ?? []),
+          ...value.split(",").filter(Boolean),
+        ];
+        break;
+      case "exported":
+        result.exported = parseBoolean(key, value);
+        break;
+      default:
+        result[field] = value;
+    }
+  }
+
+  if (text.length > 0) {
+    result.text = text.join(" ");
+  }
+  return result;
+}
+
+/**
+ * Whether the query contains any structured filters
+ */
+export function hasSymbolQueryFilters(parsed: ParsedSymbolQuery): boolean {
+  return (
+    parsed.kind !== undefined ||
+    parsed.containerName !== undefined ||
+    parsed.file !== undefined ||
+    parsed.exported !== undefined
+  );
+}
+
+export function isGlobPattern(pattern: string): boolean {
+  return /[*?[\]{}]/.test(pattern);
+}
+
+/**
+ * Match a root-relative path against a file filter. Glob patterns use
+ * minimatch; plain values match as a substring like the index's file filter.
+ */
+export function matchesFileFilter(
+  relativePath: string,
+  pattern: string,
+): boolean {
+  const normalized = relativePath.replace(/\\/g, "/");
+  if (isGlobPattern(pattern)) {
+    return minimatch(normalized, pattern, { dot: true });
+  }
+  return normalized.includes(pattern);
+}
@@ -0,0 +1,102 @@
+import { describe, it, expect } from "vitest";
+import type { IndexedSymbol } from "@internal/code-indexer";
+import { fileTags, formatTags, mergeTags, parseTags } from "./tags.ts";
+
+const content = [
+  "package user",
+  "",
+  "type User struct {",
+  "\tName string",
+  "}",
+  "",
+  "func (u *User) Save() error { return nil }",
+].join("\n");
+
+const at = (start: number, end: number) => ({
+  uri: "file:///project/user/user.go",
+  range: {
+    start: { line: start, character: 0 },
+    end: { line: end, character: 1 },
+  },
+});
+
+const symbols: IndexedSymbol[] = [
+  {
+    name: "User",
+    kind: 23,
+    location: at(2, 4),
+    children: [{ name: "Name", kind: 8, location: at(3, 3) }],
+  },
+  { name: "Save", kind: 6, location: at(6, 6), containerName: "User" },
+];
+
+describe("fileTags", () => {
+  it("should write ctags lines with kind, line and scope", () => {
+    expect(fileTags("ctags", "user/user.go", symbols, content)).toEqual([
+      'User\tuser/user.go\t3;"\ts\tline:3',
+      'Name\tuser/user.go\t4;"\tm\tline:4\tscope:User',
+      'Save\tuser/user.go\t7;"\tm\tline:7',
+    ]);
+  });
+
+  it("should write etags lines with the pattern and byte offset", () => {
+    expect(fileTags("etags", "user/user.go", symbols, content)).toEqual([
+      "type User\x7fUser\x013,14",
+      "\tName\x7fName\x014,33",
+      "func (u *User) Save\x7fSave\x017,49",
+    ]);
+  });
+});
+
+describe("formatTags", () => {
+  it("should sort ctags lines after the pseudo tags", () => {
+    const text = formatTags(
+      "ctags",
+      new Map([
+        ["b.go", ['b\tb.go\t1;"\tf\tline:1']],
+        ["a.go", ['a\ta.go\t1;"\tf\tline:1', 'Z\ta.go\t2;"\tf\tline:2']],
+      ]),
+    );
+    const lines = text.trimEnd().split("\n");
+
+    expect(lines[0]).toMatch(/^!_TAG_FILE_FORMAT\t2/);
+    expect(lines.filter((l) => !l.startsWith("!_TAG_"))).toEqual([
+      'Z\ta.go\t2;"\tf\tline:2',
+      'a\ta.go\t1;"\tf\tline:1',
+      'b\tb.go\t1;"\tf\tline:1',
+    ]);
+  });
+
+  it("should size etags sections in bytes", () => {
+    expect(
+      formatTags("etags", new Map([["é.go", ["func é\x7fé\x011,0"]]])),
+    ).toBe("\f\né.go,15\nfunc é\x7fé\x011,0\n");
+  });
+});
+
+describe("mergeTags", () => {
+  for (const format of ["ctags", "etags"] as const) {
+    it(`should replace changed files and drop removed ones (${format})`, () => {
+      const existing = parseTags(
+        format,
+        formatTags(
+          format,
+          new Map([
+            ["a.go", [fileTags(format, "a.go", symbols, content)[0]]],
+            ["b.go", [fileTags(format, "b.go", symbols, content)[0]]],
+            ["c.go", [fileTags(format, "c.go", symbols, content)[0]]],
+          ]),
+        ),
+      );
+      const fresh = new Map([
+        ["b.go", fileTags(format, "b.go", symbols, content)],
+      ]);
+
+      const merged = mergeTags(existing, fresh, ["c.go"]);
+
+      expect([...merged.keys()].sort()).toEqual(["a.go", "b.go"]);
+      expect(merged.get("a.go")).toEqual(existing.get("a.go"));
+      expect(merged.get("b.go")).toHaveLength(3);
+    });
+  }
+});
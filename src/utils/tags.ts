@@ -0,0 +1,214 @@
+/**
+ * ctags/etags export of the symbol index
+ *
+ * Writes the definitions of the index as a vi-style `tags` file (extended
+ * ctags format) or an Emacs `TAGS` file, so editors jump to definitions
+ * found by the language server without running one. Both formats are
+ * grouped by file here: regenerating a tags file replaces the entries of
+ * the files that changed and keeps the rest as they were.
+ */
+
+import type { IndexedSymbol } from "@internal/code-indexer";
+import { SymbolKind } from "@internal/types";
+import { findNamePosition } from "../tools/highlevel/unusedSymbols.ts";
+
+export type TagsFormat = "ctags" | "etags";
+
+export interface TagEntry {
+  name: string;
+  /** Relative to the project root, with "/" */
+  file: string;
+  /** 1-based */
+  line: number;
+  kind: SymbolKind;
+  /** Enclosing symbol */
+  scope?: string;
+}
+
+/** Tags of a file: ctags lines, or the body of an etags section */
+export type TagsByFile = Map<string, string[]>;
+
+const KIND_LETTERS: Partial<Record<SymbolKind, string>> = {
+  [SymbolKind.Module]: "n",
+  [SymbolKind.Namespace]: "n",
+  [SymbolKind.Package]: "p",
+  [SymbolKind.Class]: "c",
+  [SymbolKind.Method]: "m",
+  [SymbolKind.Property]: "m",
+  [SymbolKind.Field]: "m",
+  [SymbolKind.Constructor]: "m",
+  [SymbolKind.Enum]: "g",
+  [SymbolKind.Interface]: "i",
+  [SymbolKind.Function]: "f",
+  [SymbolKind.Variable]: "v",
+  [SymbolKind.Constant]: "C",
+  [SymbolKind.EnumMember]: "e",
+  [SymbolKind.Struct]: "s",
+  [SymbolKind.TypeParameter]: "t",
+};
+
+const CTAGS_HEADER = [
+  "!_TAG_FILE_FORMAT\t2\t/extended format; --format=1 will not append ;\" to lines/",
+  "!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted, 2=foldcase/",
+  "!_TAG_PROGRAM_NAME\tlsmcp\t//",
+  "!_TAG_PROGRAM_URL\thttps://github.com/mizchi/lsmcp\t//",
+];
+
+/**
+ * ctags lines of one file's entries, with line numbers as addresses
+ */
+export function ctagsLines(entries: TagEntry[]): string[] {
+  return entries
+    .filter((entry) => !/[\t\n]/.test(entry.name))
+    .map((entry) => {
+      const fields = [
+        entry.name,
+        entry.file,
+        `${entry.line};"`,
+        KIND_LETTERS[entry.kind] ?? "v",
+        `line:${entry.line}`,
+      ];
+      if (entry.scope) {
+        fields.push(`scope:${entry.scope}`);
+      }
+      return fields.join("\t");
+    });
+}
+
+/**
+ * Body of one file's etags section; `lines` is the file content by line
+ */
+export function etagsLines(entries: TagEntry[], lines: string[]): string[] {
+  // Byte offsets of each line start
+  const offsets = [0];
+  for (const text of lines) {
+    offsets.push(offsets[offsets.length - 1] + Buffer.byteLength(text) + 1);
+  }
+  return entries.flatMap((entry) => {
+    const text = lines[entry.line - 1];
+    if (text === undefined) {
+      return [];
+    }
+    // The pattern is the line up to and including the name
+    const end = text.indexOf(entry.name);
+    const pattern = (
+      end >= 0 ? text.slice(0, end + entry.name.length) : text
+    ).replace(/[\x7f\x01]/g, "");
+    return [
+      `${pattern}\x7f${entry.name}\x01${entry.line},${offsets[entry.line - 1]}`,
+    ];
+  });
+}
+
+/**
+ * Entries of the indexed symbols of one file, nested ones included, at the
+ * line of their name
+ */
+export function tagEntries(
+  file: string,
+  symbols: IndexedSymbol[],
+  lines: string[],
+  scope?: string,
+): TagEntry[] {
+  return symbols.flatMap((symbol) => {
+    const position = findNamePosition(lines, symbol);
+    const entry: TagEntry = {
+      name: symbol.name,
+      file,
+      line: (position?.line ?? symbol.location.range.start.line) + 1,
+      kind: symbol.kind,
+      ...(scope && { scope }),
+    };
+    return [
+      entry,
+      ...tagEntries(file, symbol.children ?? [], lines, symbol.name),
+    ];
+  });
+}
+
+/**
+ * Tags of one file in the given format
+ */
+export function fileTags(
+  format: TagsFormat,
+  file: string,
+  symbols: IndexedSymbol[],
+  content: string,
+): string[] {
+  const lines = content.split("\n");
+  const entries = tagEntries(file, symbols, lines).sort(
+    (a, b) => a.line - b.line,
+  );
+  return format === "ctags" ? ctagsLines(entries) : etagsLines(entries, lines);
+}
+
+export function parseTags(format: TagsFormat, text: string): TagsByFile {
+  const byFile: TagsByFile = new Map();
+  const add = (file: string, line: string) => {
+    if (!byFile.has(file)) {
+      byFile.set(file, []);
+    }
+    byFile.get(file)!.push(line);
+  };
+  if (format === "ctags") {
+    for (const line of text.split("\n")) {
+      if (!line || line.startsWith("!_TAG_")) {
+        continue;
+      }
+      const file = line.split("\t")[1];
+      if (file) {
+        add(file, line);
+      }
+    }
+    return byFile;
+  }
+  for (const section of text.split("\f\n").slice(1)) {
+    const [header, ...lines] = section.split("\n");
+    const file = header.slice(0, header.lastIndexOf(","));
+    byFile.set(file, []);
+    for (const line of lines) {
+      if (line) {
+        add(file, line);
+      }
+    }
+  }
+  return byFile;
+}
+
+function compareCodeUnits(a: string, b: string): number {
+  return a < b ? -1 : a > b ? 1 : 0;
+}
+
+export function formatTags(format: TagsFormat, byFile: TagsByFile): string {
+  if (format === "ctags") {
+    // Sorted by byte value, as !_TAG_FILE_SORTED 1 promises
+    const lines = [...byFile.values()].flat().sort(compareCodeUnits);
+    return [...CTAGS_HEADER, ...lines].map((line) => `${line}\n`).join("");
+  }
+  return [...byFile]
+    .sort(([a], [b]) => compareCodeUnits(a, b))
+    .map(([file, lines]) => {
+      const body = lines.map((line) => `${line}\n`).join("");
+      return `\f\n${file},${Buffer.byteLength(body)}\n${body}`;
+    })
+    .join("");
+}
+
+/**
+ * The tags of `existing` with the files in `fresh` replaced and the files
+ * in `removed` dropped
+ */
+export function mergeTags(
+  existing: TagsByFile,
+  fresh: TagsByFile,
+  removed: Iterable<string> = [],
+): TagsByFile {
+  const merged: TagsByFile = new Map(existing);
+  for (const file of removed) {
+    merged.delete(file);
+  }
+  for (const [file, lines] of fresh) {
+    merged.set(file, lines);
+  }
+  return merged;
+}
@@ -0,0 +1,154 @@
+import { describe, it, expect, vi } from "vitest";
+import { z } from "zod";
+import {
+  estimateTokens,
+  fitStructured,
+  paginate,
+  runWithTokenBudget,
+} from "./tokenBudget.ts";
+import { toMcpToolHandler } from "./mcpServerHelpers.ts";
+
+// 40 characters (10 tokens) per line, newline included
+function lines(count: number): string {
+  return Array.from({ length: count }, (_, i) =>
+    `line ${i}`.padEnd(39, "."),
+  ).join("\n");
+}
+
+describe("paginate", () => {
+  it("should cut between lines within the budget", () => {
+    const pages = paginate(lines(10), 25);
+
+    expect(pages).toHaveLength(5);
+    expect(pages.every((page) => estimateTokens(page) <= 25)).toBe(true);
+    expect(pages.join("\n")).toBe(lines(10));
+  });
+
+  it("should cut lines longer than a page", () => {
+    expect(paginate("x".repeat(10), 1)).toEqual(["xxxx", "xxxx", "xx"]);
+  });
+});
+
+describe("runWithTokenBudget", () => {
+  it("should return results within the budget unchanged", async () => {
+    const run = vi.fn(async () => "short");
+
+    expect(await runWithTokenBudget(run, {}, { maxTokens: 100 })).toBe(
+      "short",
+    );
+    expect(await runWithTokenBudget(run, {}, {})).toBe("short");
+  });
+
+  it("should paginate and say how to get the next page", async () => {
+    const run = async () => lines(100);
+
+    const first = await runWithTokenBudget(run, {}, { maxTokens: 200 });
+    expect(first).toContain("line 0");
+    expect(first).toMatch(/page 1 of \d+\. Call again .* page: 2/);
+    expect(estimateTokens(first)).toBeLessThanOrEqual(200);
+
+    const second = await runWithTokenBudget(
+      run,
+      {},
+      { maxTokens: 200, page: 2 },
+    );
+    expect(second).not.toContain("line 0.");
+    expect(second).toContain("page 2 of");
+
+    await expect(
+      runWithTokenBudget(run, {}, { maxTokens: 200, page: 50 }),
+    ).rejects.toThrow(/past the end/);
+  });
+
+  it("should drop context lines before paginating", async () => {
+    const run = vi.fn(async (overrides?: Record<string, unknown>) =>
+      overrides?.contextLines === 0 ? lines(5) : lines(100),
+    );
+
+    const result = await runWithTokenBudget(
+      run,
+      { contextLines: 2 },
+      { maxTokens: 200 },
+    );
+
+    expect(run).toHaveBeenLastCalledWith({ contextLines: 0 });
+    expect(result).toContain(lines(5));
+    expect(result).toContain("context lines dropped");
+    expect(result).not.toContain("page 1 of");
+  });
+});
+
+describe("fitStructured", () => {
+  const content = {
+    total: 100,
+    references: Array.from({ length: 100 }, (_, i) => ({ line: i })),
+  };
+
+  it("should keep results within the budget unchanged", () => {
+    expect(fitStructured(content, { maxTokens: 10_000 })).toBe(content);
+    expect(fitStructured(content, {})).toBe(content);
+  });
+
+  it("should cut the longest list to one page", () => {
+    const first = fitStructured(content, { maxTokens: 100 });
+    const second = fitStructured(content, { maxTokens: 100, page: 2 });
+
+    expect(first.total).toBe(100);
+    expect(estimateTokens(JSON.stringify(first))).toBeLessThanOrEqual(100);
+    expect((first.references as unknown[])[0]).toEqual({ line: 0 });
+    expect((second.references as unknown[])[0]).not.toEqual({ line: 0 });
+    expect(fitStructured(content, { maxTokens: 100, page: 99 })).toEqual({
+      total: 100,
+      references: [],
+    });
+  });
+});
+
+describe("toMcpToolHandler pages", () => {
+  const context = { lspClient: {}, fs: {} } as any;
+
+  it("should serve later pages without running the tool again", async () => {
+    const edit = vi.fn(async () => lines(100));
+    const handler = toMcpToolHandler(edit, context, "apply_workspace_edit");
+
+    await handler({ edit: "{}", maxTokens: 200 });
+    const second = await handler({ edit: "{}", maxTokens: 200, page: 2 });
+
+    expect(edit).toHaveBeenCalledTimes(1);
+    expect(second.content[0].text).toContain("page 2 of");
+    expect(second.structuredContent.text).toBe(second.content[0].text);
+  });
+
+  it("should not repeat a tool with side effects for a page it lost", async () => {
+    const edit = vi.fn(async () => lines(100));
+    const handler = toMcpToolHandler(edit, context, "apply_workspace_edit");
+
+    const result = await handler({ edit: "{}", maxTokens: 200, page: 2 });
+
+    expect(edit).not.toHaveBeenCalled();
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toMatch(/no longer available/);
+  });
+
+  it("should fit structured results into the budget", async () => {
+    const handler = toMcpToolHandler(
+      async (_args: unknown, callContext) => {
+        callContext?.setStructuredContent?.({
+          total: 100,
+          references: Array.from({ length: 100 }, (_, i) => ({ line: i })),
+        });
+        return lines(100);
+      },
+      context,
+      "lsp_find_references",
+      { total: z.number(), references: z.array(z.object({})) },
+    );
+
+    const result = await handler({ maxTokens: 100 });
+
+    expect(
+      estimateTokens(JSON.stringify(result.structuredContent)),
+    ).toBeLessThanOrEqual(100);
+    expect(result.structuredContent.total).toBe(100);
+  });
+});
@@ -0,0 +1,156 @@
+/**
+ * Keep tool results within a token budget
+ *
+ * Tokens are estimated at four characters each. A result over the budget is
+ * first produced again without context lines (tools with `contextLines`),
+ * then split into pages at line boundaries. A note at the end says what was
+ * dropped and how to ask for the next page. Structured results are cut to a
+ * page of their longest list.
+ */
+
+export const CHARS_PER_TOKEN = 4;
+
+/** Tokens kept free for the truncation note */
+const NOTE_TOKENS = 60;
+
+export interface TokenBudget {
+  /** Approximate tokens the result may use; unlimited when unset */
+  maxTokens?: number;
+  /** 1-based page of a result that exceeds the budget */
+  page?: number;
+}
+
+export function estimateTokens(text: string): number {
+  return Math.ceil(text.length / CHARS_PER_TOKEN);
+}
+
+/**
+ * Split text into pages of at most maxTokens, cutting between lines; lines
+ * longer than a page are cut as well
+ */
+export function paginate(text: string, maxTokens: number): string[] {
+  const limit = Math.max(1, maxTokens) * CHARS_PER_TOKEN;
+  const pages: string[] = [];
+  let current: string | undefined;
+  for (const line of text.split("\n")) {
+    let rest = line;
+    while (rest.length > limit) {
+      if (current !== undefined) {
+        pages.push(current);
+        current = undefined;
+      }
+      pages.push(rest.slice(0, limit));
+      rest = rest.slice(limit);
+    }
+    if (current === undefined) {
+      current = rest;
+    } else if (current.length + 1 + rest.length > limit) {
+      pages.push(current);
+      current = rest;
+    } else {
+      current += `\n${rest}`;
+    }
+  }
+  if (current !== undefined) {
+    pages.push(current);
+  }
+  return pages;
+}
+
+/**
+ * Run a tool and fit its result into the budget
+ * @param run produces the result, with argument overrides for a second try
+ * @param args arguments of the call, checked for `contextLines`
+ * @throws when the requested page does not exist
+ */
+export async function runWithTokenBudget(
+  run: (overrides?: Record<string, unknown>) => Promise<string>,
+  args: unknown,
+  budget: TokenBudget,
+): Promise<string> {
+  const { maxTokens, page = 1 } = budget;
+  let text = await run();
+  if (!maxTokens || (page === 1 && estimateTokens(text) <= maxTokens)) {
+    return text;
+  }
+
+  const fullTokens = estimateTokens(text);
+  const decisions: string[] = [];
+  const contextLines = (args as { contextLines?: unknown } | null)
+    ?.contextLines;
+  if (typeof contextLines === "number" && contextLines > 0) {
+    text = await run({ contextLines: 0 });
+    decisions.push("context lines dropped (contextLines: 0)");
+  }
+
+  const pages =
+    decisions.length > 0 && estimateTokens(text) <= maxTokens
+      ? [text]
+      : paginate(
+          text,
+          Math.max(maxTokens - NOTE_TOKENS, Math.ceil(maxTokens / 2)),
+        );
+  if (page > pages.length) {
+    throw new Error(
+      `page ${page} is past the end of this result (${pages.length} page${
+        pages.length === 1 ? "" : "s"
+      } at maxTokens ${maxTokens})`,
+    );
+  }
+  if (pages.length > 1) {
+    decisions.push(`page ${page} of ${pages.length}`);
+  }
+
+  let note = `[Result truncated to fit maxTokens ${maxTokens} (about ${fullTokens} tokens in full): ${decisions.join("; ")}.`;
+  if (page < pages.length) {
+    note += ` Call again with the same arguments and page: ${page + 1} for more, or raise maxTokens.`;
+  } else if (decisions.length > 0 && pages.length === 1) {
+    note += " Raise maxTokens to include them.";
+  }
+  return `${pages[page - 1]}\n\n${note}]`;
+}
+
+/**
+ * Cut the longest list of a structured result to the items of one page
+ * The other fields are kept, so the result still matches the tool's output
+ * schema; pages past the end hold an empty list.
+ */
+export function fitStructured(
+  content: Record<string, unknown>,
+  budget: TokenBudget,
+): Record<string, unknown> {
+  const { maxTokens, page = 1 } = budget;
+  if (
+    !maxTokens ||
+    (page === 1 && estimateTokens(JSON.stringify(content)) <= maxTokens)
+  ) {
+    return content;
+  }
+  const lists = Object.entries(content).filter(
+    (entry): entry is [string, unknown[]] => Array.isArray(entry[1]),
+  );
+  if (lists.length === 0) {
+    return content;
+  }
+  const [key, items] = lists.reduce((longest, list) =>
+    list[1].length > longest[1].length ? list : longest,
+  );
+  const limit = Math.max(
+    1,
+    maxTokens - estimateTokens(JSON.stringify({ ...content, [key]: [] })),
+  );
+  const pages: unknown[][] = [[]];
+  let used = 0;
+  for (const item of items) {
+    const tokens = estimateTokens(JSON.stringify(item));
+    const current = pages[pages.length - 1];
+    if (current.length > 0 && used + tokens > limit) {
+      pages.push([item]);
+      used = tokens;
+    } else {
+      current.push(item);
+      used += tokens;
+    }
+  }
+  return { ...content, [key]: pages[page - 1] ?? [] };
+}
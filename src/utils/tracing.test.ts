@@ -0,0 +1,132 @@
+import { afterEach, describe, it, expect } from "vitest";
+import {
+  SpanKind,
+  exporterFromEnv,
+  flushTracing,
+  parseKeyValueList,
+  setTracingExporter,
+  toOtlpRequest,
+  withSpan,
+  type FinishedSpan,
+} from "./tracing.ts";
+
+function collect() {
+  const spans: FinishedSpan[] = [];
+  setTracingExporter({
+    export: async (batch) => {
+      spans.push(...batch);
+    },
+  });
+  return spans;
+}
+
+afterEach(() => {
+  setTracingExporter(undefined);
+});
+
+describe("withSpan", () => {
+  it("should nest spans across awaits in one trace", async () => {
+    const spans = collect();
+
+    await withSpan(
+      "tool lsp_get_hover",
+      { "mcp.tool.name": "lsp_get_hover" },
+      async () => {
+        withSpan("lsp openDocument", {}, () => undefined);
+        await new Promise((resolve) => setTimeout(resolve, 1));
+        await withSpan(
+          "lsp textDocument/hover",
+          {},
+          async () => "ok",
+          SpanKind.CLIENT,
+        );
+      },
+      SpanKind.SERVER,
+    );
+    await flushTracing();
+
+    const [open, hover, tool] = spans;
+    expect(spans.map((s) => s.name)).toEqual([
+      "lsp openDocument",
+      "lsp textDocument/hover",
+      "tool lsp_get_hover",
+    ]);
+    expect(tool.parentSpanId).toBeUndefined();
+    expect(open.parentSpanId).toBe(tool.spanId);
+    expect(hover.parentSpanId).toBe(tool.spanId);
+    expect(new Set(spans.map((s) => s.traceId)).size).toBe(1);
+    expect(hover.kind).toBe(SpanKind.CLIENT);
+  });
+
+  it("should mark rejected spans as errors", async () => {
+    const spans = collect();
+
+    await expect(
+      withSpan("lsp textDocument/references", {}, async () => {
+        throw new Error("LSP request timeout");
+      }),
+    ).rejects.toThrow();
+    await flushTracing();
+
+    expect(spans[0].status).toEqual({
+      code: 2,
+      message: "LSP request timeout",
+    });
+  });
+
+  it("should only run the function when tracing is off", () => {
+    setTracingExporter(undefined);
+
+    expect(withSpan("noop", {}, (span) => span)).toBeUndefined();
+  });
+});
+
+describe("exporterFromEnv", () => {
+  it("should need an endpoint and respect the off switches", () => {
+    expect(exporterFromEnv({})).toBeUndefined();
+    expect(
+      exporterFromEnv({
+        OTEL_EXPORTER_OTLP_ENDPOINT: "http://collector:4318",
+        OTEL_TRACES_EXPORTER: "none",
+      }),
+    ).toBeUndefined();
+    expect(
+      exporterFromEnv({ OTEL_EXPORTER_OTLP_ENDPOINT: "http://collector:4318" }),
+    ).toMatchObject({ url: "http://collector:4318/v1/traces" });
+  });
+
+  it("should parse header and resource lists", () => {
+    expect(parseKeyValueList("api-key=abc%3D,x-team = tools")).toEqual({
+      "api-key": "abc=",
+      "x-team": "tools",
+    });
+  });
+});
+
+describe("toOtlpRequest", () => {
+  it("should encode attributes as OTLP any-values", () => {
+    const span: FinishedSpan = {
+      traceId: "a".repeat(32),
+      spanId: "b".repeat(16),
+      name: "index querySymbols",
+      kind: SpanKind.INTERNAL,
+      startTimeUnixNano: "1",
+      endTimeUnixNano: "2",
+      attributes: { "index.results": 3, ratio: 0.5, hit: true, q: "foo" },
+      status: { code: 1 },
+    };
+
+    const request = toOtlpRequest([span], { "service.name": "lsmcp" });
+    const [encoded] = request.resourceSpans[0].scopeSpans[0].spans;
+
+    expect(request.resourceSpans[0].resource.attributes).toEqual([
+      { key: "service.name", value: { stringValue: "lsmcp" } },
+    ]);
+    expect(encoded.attributes).toEqual([
+      { key: "index.results", value: { intValue: "3" } },
+      { key: "ratio", value: { doubleValue: 0.5 } },
+      { key: "hit", value: { boolValue: true } },
+      { key: "q", value: { stringValue: "foo" } },
+    ]);
+  });
+});
@@ -0,0 +1,345 @@
+/**
+ * OpenTelemetry tracing for MCP tool calls and the LSP requests they cause
+ *
+ * Tool handlers, index lookups, document opens and LSP requests run inside
+ * spans; the active span is carried through async calls with
+ * AsyncLocalStorage, so one tool call becomes one trace. Finished spans are
+ * batched and exported as OTLP/HTTP JSON, configured by the standard
+ * variables:
+ *
+ * - OTEL_EXPORTER_OTLP_TRACES_ENDPOINT / OTEL_EXPORTER_OTLP_ENDPOINT
+ * - OTEL_EXPORTER_OTLP_HEADERS / OTEL_EXPORTER_OTLP_TRACES_HEADERS
+ * - OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES
+ * - OTEL_TRACES_EXPORTER=none or OTEL_SDK_DISABLED=true to turn it off
+ *
+ * Without an endpoint nothing is recorded and spans cost one function call.
+ */
+
+import { AsyncLocalStorage } from "async_hooks";
+import { randomBytes } from "crypto";
+import { errorLog } from "./debugLog.ts";
+
+export type AttributeValue = string | number | boolean;
+export type Attributes = Record<string, AttributeValue | undefined>;
+
+/** OTLP span kinds */
+export const SpanKind = {
+  INTERNAL: 1,
+  SERVER: 2,
+  CLIENT: 3,
+} as const;
+export type SpanKindValue = (typeof SpanKind)[keyof typeof SpanKind];
+
+const STATUS_OK = 1;
+const STATUS_ERROR = 2;
+
+export interface Span {
+  readonly traceId: string;
+  readonly spanId: string;
+  setAttribute(key: string, value: AttributeValue | undefined): void;
+}
+
+export interface FinishedSpan {
+  traceId: string;
+  spanId: string;
+  parentSpanId?: string;
+  name: string;
+  kind: SpanKindValue;
+  startTimeUnixNano: string;
+  endTimeUnixNano: string;
+  attributes: Attributes;
+  status: { code: number; message?: string };
+}
+
+export interface SpanExporter {
+  export(spans: FinishedSpan[]): Promise<void>;
+}
+
+class RecordingSpan implements Span {
+  readonly spanId = randomBytes(8).toString("hex");
+  readonly traceId: string;
+  private readonly start = nowNanos();
+
+  constructor(
+    private readonly name: string,
+    private readonly kind: SpanKindValue,
+    private readonly attributes: Attributes,
+    private readonly parent?: RecordingSpan,
+  ) {
+    this.traceId = parent?.traceId ?? randomBytes(16).toString("hex");
+  }
+
+  setAttribute(key: string, value: AttributeValue | undefined): void {
+    this.attributes[key] = value;
+  }
+
+  finish(error?: unknown): FinishedSpan {
+    return {
+      traceId: this.traceId,
+      spanId: this.spanId,
+      parentSpanId: this.parent?.spanId,
+      name: this.name,
+      kind: this.kind,
+      startTimeUnixNano: this.start.toString(),
+      endTimeUnixNano: nowNanos().toString(),
+      attributes: this.attributes,
+      status:
+        error === undefined
+          ? { code: STATUS_OK }
+          : {
+              code: STATUS_ERROR,
+              message: error instanceof Error ? error.message : String(error),
+            },
+    };
+  }
+}
+
+// hrtime has no epoch; anchor it to the wall clock once
+const epochOffset = BigInt(Date.now()) * 1_000_000n - process.hrtime.bigint();
+
+function nowNanos(): bigint {
+  return process.hrtime.bigint() + epochOffset;
+}
+
+const BATCH_SIZE = 512;
+const FLUSH_INTERVAL = 5000;
+
+class BatchProcessor {
+  private queue: FinishedSpan[] = [];
+  private timer?: ReturnType<typeof setInterval>;
+
+  constructor(private exporter: SpanExporter) {
+    this.timer = setInterval(() => void this.flush(), FLUSH_INTERVAL);
+    this.timer.unref?.();
+  }
+
+  add(span: FinishedSpan): void {
+    this.queue.push(span);
+    if (this.queue.length >= BATCH_SIZE) {
+      void this.flush();
+    }
+  }
+
+  async flush(): Promise<void> {
+    const spans = this.queue.splice(0);
+    if (spans.length === 0) {
+      return;
+    }
+    try {
+      await this.exporter.export(spans);
+    } catch (error) {
+      // Losing telemetry must not affect tool calls
+      errorLog(
+        `Failed to export ${spans.length} span(s): ${
+          error instanceof Error ? error.message : String(error)
+        }`,
+      );
+    }
+  }
+
+  stop(): void {
+    clearInterval(this.timer);
+  }
+}
+
+/**
+ * Parse `key1=value1,key2=value2` (OTEL_*_HEADERS, OTEL_RESOURCE_ATTRIBUTES)
+ */
+export function parseKeyValueList(
+  text: string | undefined,
+): Record<string, string> {
+  const result: Record<string, string> = {};
+  for (const pair of (text ?? "").split(",")) {
+    const separator = pair.indexOf("=");
+    if (separator > 0) {
+      result[decodeURIComponent(pair.slice(0, separator).trim())] =
+        decodeURIComponent(pair.slice(separator + 1).trim());
+    }
+  }
+  return result;
+}
+
+function otlpValue(value: AttributeValue) {
+  if (typeof value === "string") {
+    return { stringValue: value };
+  }
+  if (typeof value === "boolean") {
+    return { boolValue: value };
+  }
+  return Number.isInteger(value)
+    ? { intValue: String(value) }
+    : { doubleValue: value };
+}
+
+function otlpAttributes(attributes: Attributes) {
+  return Object.entries(attributes)
+    .filter(([, value]) => value !== undefined)
+    .map(([key, value]) => ({
+      key,
+      value: otlpValue(value as AttributeValue),
+    }));
+}
+
+/**
+ * OTLP/HTTP JSON request body (ExportTraceServiceRequest)
+ */
+export function toOtlpRequest(spans: FinishedSpan[], resource: Attributes) {
+  return {
+    resourceSpans: [
+      {
+        resource: { attributes: otlpAttributes(resource) },
+        scopeSpans: [
+          {
+            scope: { name: "lsmcp" },
+            spans: spans.map((span) => ({
+              ...span,
+              attributes: otlpAttributes(span.attributes),
+            })),
+          },
+        ],
+      },
+    ],
+  };
+}
+
+export class OtlpHttpExporter implements SpanExporter {
+  constructor(
+    private url: string,
+    private headers: Record<string, string>,
+    private resource: Attributes,
+  ) {}
+
+  async export(spans: FinishedSpan[]): Promise<void> {
+    const response = await fetch(this.url, {
+      method: "POST",
+      headers: { "Content-Type": "application/json", ...this.headers },
+      body: JSON.stringify(toOtlpRequest(spans, this.resource)),
+    });
+    if (!response.ok) {
+      throw new Error(`OTLP endpoint ${this.url} returned ${response.status}`);
+    }
+  }
+}
+
+/**
+ * Exporter described by the OTEL_* environment, or undefined when disabled
+ */
+export function exporterFromEnv(
+  env: NodeJS.ProcessEnv = process.env,
+): SpanExporter | undefined {
+  if (
+    env.OTEL_SDK_DISABLED === "true" ||
+    env.OTEL_TRACES_EXPORTER === "none"
+  ) {
+    return undefined;
+  }
+  const base = env.OTEL_EXPORTER_OTLP_ENDPOINT;
+  const url =
+    env.OTEL_EXPORTER_OTLP_TRACES_ENDPOINT ||
+    (base ? `${base.replace(/\/$/, "")}/v1/traces` : undefined);
+  if (!url) {
+    return undefined;
+  }
+  const protocol =
+    env.OTEL_EXPORTER_OTLP_TRACES_PROTOCOL || env.OTEL_EXPORTER_OTLP_PROTOCOL;
+  if (protocol && protocol !== "http/json") {
+    errorLog(
+      `OTLP protocol '${protocol}' is not supported; exporting traces as http/json`,
+    );
+  }
+  return new OtlpHttpExporter(
+    url,
+    {
+      ...parseKeyValueList(env.OTEL_EXPORTER_OTLP_HEADERS),
+      ...parseKeyValueList(env.OTEL_EXPORTER_OTLP_TRACES_HEADERS),
+    },
+    {
+      ...parseKeyValueList(env.OTEL_RESOURCE_ATTRIBUTES),
+      "service.name": env.OTEL_SERVICE_NAME || "lsmcp",
+    },
+  );
+}
+
+const activeSpans = new AsyncLocalStorage<RecordingSpan>();
+let processor: BatchProcessor | undefined;
+let initialized = false;
+
+function currentProcessor(): BatchProcessor | undefined {
+  if (!initialized) {
+    initialized = true;
+    const exporter = exporterFromEnv();
+    if (exporter) {
+      setTracingExporter(exporter);
+    }
+  }
+  return processor;
+}
+
+/**
+ * Export spans to `exporter` (or stop tracing with undefined)
+ */
+export function setTracingExporter(exporter: SpanExporter | undefined): void {
+  initialized = true;
+  processor?.stop();
+  processor = exporter ? new BatchProcessor(exporter) : undefined;
+  if (processor) {
+    process.once("beforeExit", () => void processor?.flush());
+  }
+}
+
+export function isTracingEnabled(): boolean {
+  return currentProcessor() !== undefined;
+}
+
+/**
+ * Export finished spans now
+ */
+export async function flushTracing(): Promise<void> {
+  await processor?.flush();
+}
+
+/**
+ * Run `fn` in a child span of the active one
+ * The span ends when `fn` returns or its promise settles; a throw or
+ * rejection marks it as failed.
+ */
+export function withSpan<T>(
+  name: string,
+  attributes: Attributes,
+  fn: (span?: Span) => T,
+  kind: SpanKindValue = SpanKind.INTERNAL,
+): T {
+  const target = currentProcessor();
+  if (!target) {
+    return fn();
+  }
+  const span = new RecordingSpan(
+    name,
+    kind,
+    { ...attributes },
+    activeSpans.getStore(),
+  );
+  return activeSpans.run(span, () => {
+    let result: T;
+    try {
+      result = fn(span);
+    } catch (error) {
+      target.add(span.finish(error));
+      throw error;
+    }
+    if (result instanceof Promise) {
+      return result.then(
+        (value) => {
+          target.add(span.finish());
+          return value;
+        },
+        (error) => {
+          target.add(span.finish(error));
+          throw error;
+        },
+      ) as T;
+    }
+    target.add(span.finish());
+    return result;
+  });
+}
@@ -0,0 +1,50 @@
+import { describe, it, expect } from "vitest";
+import { createUnifiedDiff } from "./unifiedDiff.ts";
+
+describe("createUnifiedDiff", () => {
+  it("should return empty string for identical content", () => {
+    expect(createUnifiedDiff("a.ts", "same\n", "same\n")).toBe("");
+  });
+
+  it("should produce separate hunks with context", () => {
+    const lines = Array.from({ length: 20 }, (_, i) => `line${i}`);
+    const before = lines.join("\n") + "\n";
+    const after =
+      lines
+        .map((l) => (l === "line2" ? "LINE2" : l))
+        .filter((l) => l !== "line15")
+        .join("\n") + "\n";
+
+    expect(createUnifiedDiff("a.ts", before, after)).toBe(
+      [
+        "--- a/a.ts",
+        "+++ b/a.ts",
+        "@@ -1,6 +1,6 @@",
+        " line0",
+        " line1",
+        "-line2",
+        "+LINE2",
+        " line3",
+        " line4",
+        " line5",
+        "@@ -13,7 +13,6 @@",
+        " line12",
+        " line13",
+        " line14",
+        "-line15",
+        " line16",
+        " line17",
+        " line18",
+      ].join("\n"),
+    );
+  });
+
+  it("should handle new and deleted files", () => {
+    expect(createUnifiedDiff("n.ts", "", "hello\n")).toBe(
+      "--- a/n.ts\n+++ b/n.ts\n@@ -0,0 +1,1 @@\n+hello",
+    );
+    expect(createUnifiedDiff("d.ts", "a\nb\n", "")).toBe(
+      "--- a/d.ts\n+++ b/d.ts\n@@ -1,2 +0,0 @@\n-a\n-b",
+    );
+  });
+});
@@ -0,0 +1,161 @@
+/**
+ * Minimal line-based unified diff generator
+ */
+
+interface DiffLine {
+  type: " " | "-" | "+";
+  text: string;
+}
+
+// Guard against quadratic blow-up on very large rewrites
+const MAX_LCS_CELLS = 4_000_000;
+
+/**
+ * Compute line operations between two texts.
+ * Common prefix/suffix are stripped before running LCS on the middle part.
+ */
+function diffLines(oldLines: string[], newLines: string[]): DiffLine[] {
+  let prefix = 0;
+  while (
+    prefix < oldLines.length &&
+    prefix < newLines.length &&
+    oldLines[prefix] === newLines[prefix]
+  ) {
+    prefix++;
+  }
+
+  let suffix = 0;
+  while (
+    suffix < oldLines.length - prefix &&
+    suffix < newLines.length - prefix &&
+    oldLines[oldLines.length - 1 - suffix] ===
+      newLines[newLines.length - 1 - suffix]
+  ) {
+    suffix++;
+  }
+
+  const oldMid = oldLines.slice(prefix, oldLines.length - suffix);
+  const newMid = newLines.slice(prefix, newLines.length - suffix);
+
+  const result: DiffLine[] = oldLines
+    .slice(0, prefix)
+    .map((text) => ({ type: " " as const, text }));
+
+  if (oldMid.length * newMid.length > MAX_LCS_CELLS) {
+    result.push(...oldMid.map((text) => ({ type: "-" as const, text })));
+    result.push(...newMid.map((text) => ({ type: "+" as const, text })));
+  } else {
+    // lcs[i][j] = length of LCS of oldMid[i..] and newMid[j..]
+    const rows = oldMid.length + 1;
+    const cols = newMid.length + 1;
+    const lcs = new Uint32Array(rows * cols);
+    for (let i = oldMid.length - 1; i >= 0; i--) {
+      for (let j = newMid.length - 1; j >= 0; j--) {
+        lcs[i * cols + j] =
+          oldMid[i] === newMid[j]
+            ? lcs[(i + 1) * cols + j + 1] + 1
+            : Math.max(lcs[(i + 1) * cols + j], lcs[i * cols + j + 1]);
+      }
+    }
+
+    let i = 0;
+    let j = 0;
+    while (i < oldMid.length && j < newMid.length) {
+      if (oldMid[i] === newMid[j]) {
+        result.push({ type: " ", text: oldMid[i] });
+        i++;
+        j++;
+      } else if (lcs[(i + 1) * cols + j] >= lcs[i * cols + j + 1]) {
+        result.push({ type: "-", text: oldMid[i++] });
+      } else {
+        result.push({ type: "+", text: newMid[j++] });
+      }
+    }
+    while (i < oldMid.length) result.push({ type: "-", text: oldMid[i++] });
+    while (j < newMid.length) result.push({ type: "+", text: newMid[j++] });
+  }
+
+  result.push(
+    ...oldLines
+      .slice(oldLines.length - suffix)
+      .map((text) => ({ type: " " as const, text })),
+  );
+  return result;
+}
+
+function splitLines(text: string): string[] {
+  const lines = text.split("\n");
+  // A trailing newline does not start another line
+  if (lines[lines.length - 1] === "") {
+    lines.pop();
+  }
+  return lines;
+}
+
+/**
+ * Create a unified diff between two versions of a file.
+ * Returns an empty string when the contents are identical.
+ */
+export function createUnifiedDiff(
+  filePath: string,
+  oldText: string,
+  newText: string,
+  context = 3,
+): string {
+  if (oldText === newText) {
+    return "";
+  }
+
+  const oldLines = splitLines(oldText);
+  const newLines = splitLines(newText);
+  const ops = diffLines(oldLines, newLines);
+
+  // Group changed lines into hunks with surrounding context
+  const hunks: string[] = [];
+  let index = 0;
+  while (index < ops.length) {
+    if (ops[index].type === " ") {
+      index++;
+      continue;
+    }
+
+    const start = Math.max(0, index - context);
+    let end = index;
+    let lastChange = index;
+    while (end < ops.length) {
+      if (ops[end].type !== " ") {
+        lastChange = end;
+      } else if (end - lastChange > context * 2) {
+        break;
+      }
+      end++;
+    }
+    end = Math.min(ops.length, lastChange + context + 1);
+
+    // Line numbers (1-based) at the start of the hunk
+    let oldStart = 1;
+    let newStart = 1;
+    for (let k = 0; k < start; k++) {
+      if (ops[k].type !== "+") oldStart++;
+      if (ops[k].type !== "-") newStart++;
+    }
+
+    const body = ops.slice(start, end);
+    const oldCount = body.filter((op) => op.type !== "+").length;
+    const newCount = body.filter((op) => op.type !== "-").length;
+    hunks.push(
+      `@@ -${oldCount === 0 ? oldStart - 1 : oldStart},${oldCount} +${
+        newCount === 0 ? newStart - 1 : newStart
+      },${newCount} @@`,
+      ...body.map((op) => `${op.type}${op.text}`),
+    );
+    index = end;
+  }
+
+  if (hunks.length === 0) {
+    // Only the trailing newline differs
+    hunks.push("\\ Newline at end of file changed");
+  }
+
+  return [`--- a/${filePath}`, `+++ b/${filePath}`, ...hunks].join("\n");
+}
@@ -0,0 +1,100 @@
+import { describe, it, expect, vi } from "vitest";
+import { WarmPool, withWarmPool } from "./warmPool.ts";
+
+function createPool(options: { maxProjects?: number } = {}) {
+  let now = 0;
+  const workspace = { setRoots: vi.fn().mockResolvedValue(undefined) };
+  const pool = new WarmPool(
+    workspace,
+    ["/srv/main"],
+    ["/srv/api", "/srv/web", "../shared"],
+    { idleTimeout: 1000, now: () => now, ...options },
+  );
+  return {
+    pool,
+    workspace,
+    advance: (ms: number) => {
+      now += ms;
+    },
+  };
+}
+
+describe("WarmPool", () => {
+  it("should start all projects as roots on start", async () => {
+    const { pool, workspace } = createPool();
+
+    await pool.start();
+    pool.stop();
+
+    expect(workspace.setRoots).toHaveBeenCalledWith([
+      "/srv/main",
+      "/srv/api",
+      "/srv/web",
+      "/srv/shared",
+    ]);
+  });
+
+  it("should evict idle projects and restart them on use", async () => {
+    const { pool, workspace, advance } = createPool();
+    await pool.start();
+    pool.stop();
+
+    advance(600);
+    await pool.acquire("/srv/api/src/main.rs");
+    advance(600);
+
+    expect(await pool.evictIdle()).toEqual(["/srv/web", "/srv/shared"]);
+    expect(workspace.setRoots).toHaveBeenLastCalledWith([
+      "/srv/main",
+      "/srv/api",
+    ]);
+
+    await pool.acquire("/srv/web");
+    expect(pool.isWarm("/srv/web")).toBe(true);
+    expect(workspace.setRoots).toHaveBeenLastCalledWith([
+      "/srv/main",
+      "/srv/api",
+      "/srv/web",
+    ]);
+  });
+
+  it("should keep at most maxProjects warm", async () => {
+    const { pool, advance } = createPool({ maxProjects: 1 });
+    await pool.start();
+    pool.stop();
+
+    advance(10);
+    await pool.acquire("/srv/web");
+
+    expect(await pool.evictIdle()).toEqual(["/srv/api", "/srv/shared"]);
+  });
+
+  it("should ignore paths outside the pool", async () => {
+    const { pool, workspace } = createPool();
+
+    await pool.acquire("/srv/apiary/x.go");
+    await pool.acquire("/srv/main/x.go");
+
+    expect(workspace.setRoots).not.toHaveBeenCalled();
+  });
+});
+
+describe("withWarmPool", () => {
+  it("should acquire the root before running the tool", async () => {
+    const { pool, workspace } = createPool();
+    const [tool] = withWarmPool(
+      [
+        {
+          name: "lsp_get_hover",
+          description: "",
+          schema: {} as any,
+          execute: async () => "ok",
+        },
+      ],
+      pool,
+    );
+
+    await expect(tool.execute({ root: "/srv/web" })).resolves.toBe("ok");
+    expect(workspace.setRoots).toHaveBeenCalledTimes(1);
+  });
+});
@@ -0,0 +1,176 @@
+/**
+ * Warm pool of pre-initialized language servers for the HTTP daemon
+ *
+ * Projects listed in `warmPool.projects` are added as roots when the daemon
+ * starts, so their servers are spawned and initialized (including the wait
+ * for rust-analyzer to finish loading) before the first tool call. Projects
+ * no tool has used for `idleTimeout` are dropped from the roots, which stops
+ * their per-root servers, and are added back by the next call naming them.
+ */
+
+import { isAbsolute, resolve, sep } from "path";
+import type { McpToolDef } from "@internal/types";
+import { debugLogWithPrefix, errorLog } from "./debugLog.ts";
+
+/** The part of WorkspaceRoots the pool drives */
+export interface RootSet {
+  setRoots(roots: string[]): Promise<void>;
+}
+
+export interface WarmPoolOptions {
+  /** Stop projects unused for this long (ms, default 30 minutes) */
+  idleTimeout?: number;
+  /** Most projects kept warm; the least recently used are stopped first */
+  maxProjects?: number;
+  /** How often idle projects are checked (ms, default 1 minute) */
+  checkInterval?: number;
+  now?: () => number;
+}
+
+export const DEFAULT_IDLE_TIMEOUT = 30 * 60 * 1000;
+
+export class WarmPool {
+  private readonly projects: string[];
+  // Warm projects and when a tool last used them
+  private lastUsed = new Map<string, number>();
+  private updates: Promise<void> = Promise.resolve();
+  private timer?: ReturnType<typeof setInterval>;
+
+  constructor(
+    private workspace: RootSet,
+    /** Roots that are always kept (configured roots, primary first) */
+    private baseRoots: string[],
+    projects: string[],
+    private options: WarmPoolOptions = {},
+  ) {
+    const [primaryRoot] = baseRoots;
+    this.projects = Array.from(
+      new Set(
+        projects.map((project) =>
+          isAbsolute(project) ? project : resolve(primaryRoot, project),
+        ),
+      ),
+    ).filter((project) => !baseRoots.includes(project));
+  }
+
+  /**
+   * Start every project's servers and begin evicting idle ones
+   */
+  async start(): Promise<void> {
+    const now = this.now();
+    for (const project of this.projects) {
+      this.lastUsed.set(project, now);
+    }
+    await this.apply();
+    this.timer = setInterval(
+      () => void this.evictIdle(),
+      this.options.checkInterval ?? 60 * 1000,
+    );
+    this.timer.unref?.();
+  }
+
+  stop(): void {
+    clearInterval(this.timer);
+  }
+
+  /**
+   * Pool project containing a path, if any
+   */
+  projectFor(path: string): string | undefined {
+    return this.projects
+      .filter((project) => path === project || path.startsWith(project + sep))
+      .sort((a, b) => b.length - a.length)[0];
+  }
+
+  isWarm(project: string): boolean {
+    return this.lastUsed.has(project);
+  }
+
+  /**
+   * Mark the project containing `path` as used, restarting it if evicted
+   */
+  async acquire(path: string): Promise<void> {
+    const project = this.projectFor(path);
+    if (!project) {
+      return;
+    }
+    const wasWarm = this.lastUsed.has(project);
+    this.lastUsed.set(project, this.now());
+    if (!wasWarm) {
+      debugLogWithPrefix("WarmPool", `Restarting ${project}`);
+      await this.apply();
+    } else {
+      // A restart that is still running has to finish first
+      await this.updates;
+    }
+  }
+
+  /**
+   * Stop projects idle past the timeout or beyond maxProjects
+   * @returns the evicted projects
+   */
+  async evictIdle(): Promise<string[]> {
+    const now = this.now();
+    const idleTimeout = this.options.idleTimeout ?? DEFAULT_IDLE_TIMEOUT;
+    const byRecency = Array.from(this.lastUsed).sort((a, b) => b[1] - a[1]);
+    const evicted = byRecency
+      .filter(
+        ([, used], i) =>
+          now - used >= idleTimeout ||
+          (this.options.maxProjects !== undefined &&
+            i >= this.options.maxProjects),
+      )
+      .map(([project]) => project);
+    if (evicted.length === 0) {
+      return [];
+    }
+    for (const project of evicted) {
+      this.lastUsed.delete(project);
+    }
+    debugLogWithPrefix("WarmPool", `Stopping idle ${evicted.join(", ")}`);
+    await this.apply();
+    return evicted;
+  }
+
+  private apply(): Promise<void> {
+    // Root changes start and stop processes, so they run one at a time
+    this.updates = this.updates
+      .then(() =>
+        this.workspace.setRoots([
+          ...this.baseRoots,
+          ...this.projects.filter((project) => this.lastUsed.has(project)),
+        ]),
+      )
+      .catch((error) =>
+        errorLog(
+          `Failed to update warm pool roots: ${
+            error instanceof Error ? error.message : String(error)
+          }`,
+        ),
+      );
+    return this.updates;
+  }
+
+  private now(): number {
+    return this.options.now?.() ?? Date.now();
+  }
+}
+
+/**
+ * Make tools restart an evicted pool project before they run
+ */
+export function withWarmPool(
+  tools: McpToolDef<any>[],
+  pool: WarmPool,
+): McpToolDef<any>[] {
+  return tools.map((tool) => ({
+    ...tool,
+    execute: async (args, context) => {
+      const root = (args as { root?: unknown } | undefined)?.root;
+      if (typeof root === "string") {
+        await pool.acquire(resolve(root));
+      }
+      return tool.execute(args, context);
+    },
+  }));
+}
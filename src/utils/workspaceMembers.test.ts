@@ -0,0 +1,116 @@
+import { describe, it, expect, beforeEach } from "vitest";
+import { mkdirSync, mkdtempSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import {
+  detectWorkspace,
+  parseCargoWorkspace,
+  parseGoWork,
+  parsePnpmWorkspace,
+  workspaceMemberRoots,
+} from "./workspaceMembers.ts";
+
+function writeFiles(root: string, files: Record<string, string>): void {
+  for (const [path, content] of Object.entries(files)) {
+    mkdirSync(join(root, path, ".."), { recursive: true });
+    writeFileSync(join(root, path), content);
+  }
+}
+
+describe("workspace manifest parsers", () => {
+  it("should read single and block use directives from go.work", () => {
+    expect(
+      parseGoWork(
+        "go 1.22\n\nuse ./tools\n\nuse (\n\t./api // service\n\t./worker\n)\n",
+      ),
+    ).toEqual(["./tools", "./api", "./worker"]);
+  });
+
+  it("should read the packages list from pnpm-workspace.yaml", () => {
+    expect(
+      parsePnpmWorkspace(
+        "packages:\n  - 'packages/*'\n  - apps/web # site\n  - '!**/test/**'\ncatalog:\n  - react\n",
+      ),
+    ).toEqual(["packages/*", "apps/web", "!**/test/**"]);
+  });
+
+  it("should read multi-line Cargo workspace members", () => {
+    expect(
+      parseCargoWorkspace(
+        '[workspace]\nmembers = [\n  "crates/*",\n  "cli",\n]\n\n[workspace.dependencies]\nserde = "1"\n',
+      ),
+    ).toEqual(["crates/*", "cli"]);
+    expect(parseCargoWorkspace('[package]\nname = "app"\n')).toBeUndefined();
+  });
+});
+
+describe("detectWorkspace", () => {
+  let root: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-workspace-"));
+  });
+
+  it("should expand pnpm globs to directories with a package.json", () => {
+    writeFiles(root, {
+      "pnpm-workspace.yaml": "packages:\n  - packages/*\n",
+      "packages/core/package.json": "{}",
+      "packages/ui/package.json": "{}",
+      "packages/docs/README.md": "",
+    });
+
+    expect(detectWorkspace(root)).toEqual({
+      kind: "pnpm",
+      root,
+      members: [join(root, "packages/core"), join(root, "packages/ui")],
+    });
+  });
+
+  it("should read yarn workspaces and exclusions from package.json", () => {
+    writeFiles(root, {
+      "package.json": JSON.stringify({
+        workspaces: { packages: ["apps/**", "!apps/legacy"] },
+      }),
+      "apps/web/package.json": "{}",
+      "apps/legacy/package.json": "{}",
+    });
+
+    expect(detectWorkspace(root)?.members).toEqual([join(root, "apps/web")]);
+  });
+});
+
+describe("workspaceMemberRoots", () => {
+  let root: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lsmcp-workspace-"));
+    writeFiles(root, {
+      ".git/HEAD": "",
+      "go.work": "go 1.22\n\nuse (\n\t./api\n\t./worker\n\t./missing\n)\n",
+      "api/go.mod": "module example.com/api\n",
+      "worker/go.mod": "module example.com/worker\n",
+    });
+  });
+
+  it("should add sibling go.work modules when run inside one", () => {
+    expect(workspaceMemberRoots(join(root, "api"))).toEqual({
+      workspace: {
+        kind: "go.work",
+        root,
+        members: [join(root, "api"), join(root, "worker")],
+      },
+      roots: [join(root, "worker")],
+    });
+  });
+
+  it("should add nothing at the workspace root", () => {
+    expect(workspaceMemberRoots(root).roots).toEqual([]);
+  });
+
+  it("should not look past the repository root", () => {
+    const nested = join(root, "api", "sub");
+    writeFiles(nested, { ".git/HEAD": "", "go.mod": "module sub\n" });
+
+    expect(workspaceMemberRoots(nested).workspace).toBeUndefined();
+  });
+});
@@ -0,0 +1,277 @@
+/**
+ * Monorepo workspace detection (go.work, pnpm/yarn/npm workspaces, Cargo)
+ *
+ * When lsmcp runs inside one member of a workspace, the sibling members are
+ * added as workspace roots so references across modules are found.
+ */
+
+import { existsSync, readdirSync, readFileSync, statSync } from "fs";
+import { dirname, join, relative, resolve, sep } from "path";
+import { SKIPPED_DIRS } from "./projectDetector.ts";
+
+export type WorkspaceKind = "go.work" | "pnpm" | "npm" | "cargo";
+
+export interface WorkspaceInfo {
+  kind: WorkspaceKind;
+  /** Directory holding the workspace manifest */
+  root: string;
+  /** Absolute member directories */
+  members: string[];
+}
+
+export interface MemberRoots {
+  workspace?: WorkspaceInfo;
+  /** Member directories outside the project root */
+  roots: string[];
+}
+
+// Manifest a directory must contain to count as a member for each kind
+const MEMBER_MANIFESTS: Record<WorkspaceKind, string> = {
+  "go.work": "go.mod",
+  pnpm: "package.json",
+  npm: "package.json",
+  cargo: "Cargo.toml",
+};
+
+/**
+ * Module directories from the `use` directives of go.work
+ */
+export function parseGoWork(content: string): string[] {
+  const dirs: string[] = [];
+  let inUse = false;
+  for (const rawLine of content.split("\n")) {
+    const line = rawLine.replace(/\/\/.*$/, "").trim();
+    if (!line) continue;
+    if (inUse) {
+      if (line === ")") {
+        inUse = false;
+      } else {
+        dirs.push(line.replace(/^"(.*)"$/, "$1"));
+      }
+      continue;
+    }
+    const match = /^use\s+(.+)$/.exec(line);
+    if (!match) continue;
+    if (match[1] === "(") {
+      inUse = true;
+    } else {
+      dirs.push(match[1].replace(/^"(.*)"$/, "$1"));
+    }
+  }
+  return dirs;
+}
+
+/**
+ * Entries of the `packages:` list in pnpm-workspace.yaml
+ */
+export function parsePnpmWorkspace(content: string): string[] {
+  const patterns: string[] = [];
+  let inPackages = false;
+  for (const rawLine of content.split("\n")) {
+    const line = rawLine.replace(/\s+#.*$/, "");
+    if (/^packages:\s*$/.test(line)) {
+      inPackages = true;
+      continue;
+    }
+    if (!inPackages || !line.trim()) continue;
+    const item = /^\s*-\s*(.+)$/.exec(line);
+    if (!item) {
+      // Next top-level key
+      inPackages = /^\s/.test(line);
+      continue;
+    }
+    patterns.push(item[1].trim().replace(/^["'](.*)["']$/, "$1"));
+  }
+  return patterns;
+}
+
+/**
+ * `members` of the [workspace] table in Cargo.toml (single or multi-line)
+ */
+export function parseCargoWorkspace(content: string): string[] | undefined {
+  const section = /^\[workspace\]\s*$([\s\S]*?)(?=^\[|(?![\s\S]))/m.exec(
+    content,
+  );
+  if (!section) return undefined;
+  const members = /^\s*members\s*=\s*\[([\s\S]*?)\]/m.exec(section[1]);
+  if (!members) return [];
+  return Array.from(members[1].matchAll(/["']([^"']+)["']/g), (m) => m[1]);
+}
+
+function isDirectory(path: string): boolean {
+  try {
+    return statSync(path).isDirectory();
+  } catch {
+    return false;
+  }
+}
+
+function subdirectories(dir: string): string[] {
+  try {
+    return readdirSync(dir, { withFileTypes: true })
+      .filter(
+        (entry) =>
+          entry.isDirectory() &&
+          !entry.name.startsWith(".") &&
+          !SKIPPED_DIRS.has(entry.name),
+      )
+      .map((entry) => join(dir, entry.name));
+  } catch {
+    return [];
+  }
+}
+
+/**
+ * Expand a member pattern. Supports literal paths, `*` for one directory
+ * level and `**` for any depth; `!pattern` entries are exclusions.
+ */
+export function expandMemberPatterns(
+  root: string,
+  patterns: string[],
+  manifest: string,
+): string[] {
+  const expand = (pattern: string): string[] => {
+    let dirs = [root];
+    for (const segment of pattern.replace(/^\.\//, "").split("/")) {
+      if (!segment || segment === ".") continue;
+      if (segment === "**") {
+        const all: string[] = [];
+        const walk = (dir: string, depth: number) => {
+          all.push(dir);
+          if (depth < 5) {
+            for (const child of subdirectories(dir)) walk(child, depth + 1);
+          }
+        };
+        dirs.forEach((dir) => walk(dir, 0));
+        dirs = all;
+      } else if (segment.includes("*")) {
+        const escaped = segment.replace(/[.+?^${}()|[\]\\]/g, "\\$&");
+        const regex = new RegExp(`^${escaped.replace(/\*/g, ".*")}$`);
+        dirs = dirs.flatMap((dir) =>
+          subdirectories(dir).filter((child) =>
+            regex.test(child.slice(dir.length + 1)),
+          ),
+        );
+      } else {
+        dirs = dirs.map((dir) => join(dir, segment)).filter(isDirectory);
+      }
+    }
+    return dirs;
+  };
+
+  const included = new Set<string>();
+  const excluded = new Set<string>();
+  for (const pattern of patterns) {
+    if (pattern.startsWith("!")) {
+      expand(pattern.slice(1)).forEach((dir) => excluded.add(dir));
+    } else {
+      expand(pattern).forEach((dir) => included.add(dir));
+    }
+  }
+  return Array.from(included)
+    .filter((dir) => !excluded.has(dir) && existsSync(join(dir, manifest)))
+    .sort();
+}
+
+function readJson(path: string): any {
+  try {
+    return JSON.parse(readFileSync(path, "utf-8"));
+  } catch {
+    return undefined;
+  }
+}
+
+/**
+ * Workspace declared in `dir` itself, if any
+ */
+export function detectWorkspace(dir: string): WorkspaceInfo | undefined {
+  const members = (kind: WorkspaceKind, patterns: string[]) => ({
+    kind,
+    root: dir,
+    members: expandMemberPatterns(dir, patterns, MEMBER_MANIFESTS[kind]),
+  });
+
+  const goWork = join(dir, "go.work");
+  if (existsSync(goWork)) {
+    // `use` lists directories, not patterns; keep the ones with a go.mod
+    const dirs = parseGoWork(readFileSync(goWork, "utf-8"))
+      .map((use) => resolve(dir, use))
+      .filter((use) => existsSync(join(use, "go.mod")));
+    return { kind: "go.work", root: dir, members: dirs };
+  }
+
+  const pnpmWorkspace = join(dir, "pnpm-workspace.yaml");
+  if (existsSync(pnpmWorkspace)) {
+    return members(
+      "pnpm",
+      parsePnpmWorkspace(readFileSync(pnpmWorkspace, "utf-8")),
+    );
+  }
+
+  const packageJson = readJson(join(dir, "package.json"));
+  const workspaces = Array.isArray(packageJson?.workspaces)
+    ? packageJson.workspaces
+    : packageJson?.workspaces?.packages;
+  if (Array.isArray(workspaces)) {
+    // yarn and npm share the package.json "workspaces" field
+    return members(
+      "npm",
+      workspaces.filter((w: unknown) => typeof w === "string"),
+    );
+  }
+
+  const cargoToml = join(dir, "Cargo.toml");
+  if (existsSync(cargoToml)) {
+    const patterns = parseCargoWorkspace(readFileSync(cargoToml, "utf-8"));
+    if (patterns) {
+      return members("cargo", patterns);
+    }
+  }
+  return undefined;
+}
+
+/**
+ * Find the workspace that contains `projectRoot`: declared in the project
+ * root itself or in a parent directory that lists it as a member. The search
+ * stops at the repository root (the first directory with .git).
+ */
+export function findEnclosingWorkspace(
+  projectRoot: string,
+): WorkspaceInfo | undefined {
+  const root = resolve(projectRoot);
+  let dir = root;
+  while (true) {
+    const workspace = detectWorkspace(dir);
+    if (
+      workspace &&
+      (dir === root ||
+        workspace.members.some(
+          (member) => root === member || root.startsWith(member + sep),
+        ))
+    ) {
+      return workspace;
+    }
+    const parent = dirname(dir);
+    if (parent === dir || existsSync(join(dir, ".git"))) {
+      return undefined;
+    }
+    dir = parent;
+  }
+}
+
+/**
+ * Members to add as extra roots: those outside the project root. Members
+ * inside it are already covered by the servers and the index.
+ */
+export function workspaceMemberRoots(projectRoot: string): MemberRoots {
+  const workspace = findEnclosingWorkspace(projectRoot);
+  if (!workspace) {
+    return { roots: [] };
+  }
+  const root = resolve(projectRoot);
+  const roots = workspace.members.filter((member) => {
+    const path = relative(root, member);
+    return path.startsWith("..") && !root.startsWith(member + sep);
+  });
+  return { workspace, roots };
+}
@@ -0,0 +1,102 @@
+import { describe, it, expect, vi } from "vitest";
+import type { LSPClient } from "@internal/lsp-client";
+import { WorkspaceRoots, resolveRoots } from "./workspaceRoots.ts";
+
+function createFakeClient(workspaceFolders: boolean): LSPClient {
+  return {
+    supportsFeature: vi.fn((feature: string) =>
+      feature === "workspaceFolders" ? workspaceFolders : false,
+    ),
+    changeWorkspaceFolders: vi.fn(),
+    stop: vi.fn().mockResolvedValue(undefined),
+  } as unknown as LSPClient;
+}
+
+function createFakeRouter() {
+  return {
+    addRoute: vi.fn(),
+    removeRoute: vi.fn(),
+  } as unknown as LSPClient & {
+    addRoute: ReturnType<typeof vi.fn>;
+    removeRoute: ReturnType<typeof vi.fn>;
+  };
+}
+
+describe("resolveRoots", () => {
+  it("should resolve relative roots and keep the project root first", () => {
+    expect(
+      resolveRoots("/work/api", ["../worker", "/work/api", "/lib"]),
+    ).toEqual(["/work/api", "/work/worker", "/lib"]);
+  });
+});
+
+describe("WorkspaceRoots", () => {
+  it("should send workspace folder changes to servers that support them", async () => {
+    const client = createFakeClient(true);
+    const startForRoot = vi.fn();
+    const workspace = new WorkspaceRoots(
+      createFakeRouter(),
+      [{ id: "gopls", client, extensions: [".go"], startForRoot }],
+      "/work/api",
+    );
+
+    await workspace.setRoots(["/work/worker"]);
+    await workspace.setRoots([]);
+
+    expect(client.changeWorkspaceFolders).toHaveBeenNthCalledWith(
+      1,
+      ["/work/worker"],
+      [],
+    );
+    expect(client.changeWorkspaceFolders).toHaveBeenNthCalledWith(
+      2,
+      [],
+      ["/work/worker"],
+    );
+    expect(startForRoot).not.toHaveBeenCalled();
+    expect(workspace.roots).toEqual(["/work/api"]);
+  });
+
+  it("should start and stop a server per root otherwise", async () => {
+    const router = createFakeRouter();
+    const perRoot = createFakeClient(false);
+    const startForRoot = vi.fn().mockResolvedValue(perRoot);
+    const workspace = new WorkspaceRoots(
+      router,
+      [
+        {
+          id: "clangd",
+          client: createFakeClient(false),
+          extensions: [".c"],
+          startForRoot,
+        },
+      ],
+      "/work/api",
+    );
+
+    await workspace.setRoots(["/work/lib"]);
+
+    expect(startForRoot).toHaveBeenCalledWith("/work/lib");
+    expect(router.addRoute).toHaveBeenCalledWith({
+      id: "clangd",
+      client: perRoot,
+      extensions: [".c"],
+      root: "/work/lib",
+    });
+    expect(workspace.roots).toEqual(["/work/api", "/work/lib"]);
+
+    await workspace.setRoots([]);
+
+    expect(router.removeRoute).toHaveBeenCalledWith(perRoot);
+    expect(perRoot.stop).toHaveBeenCalled();
+  });
+
+  it("should keep the shared roots array in place", async () => {
+    const workspace = new WorkspaceRoots(createFakeRouter(), [], "/a");
+    const roots = workspace.roots;
+
+    await workspace.setRoots(["/b"]);
+
+    expect(roots).toEqual(["/a", "/b"]);
+  });
+});
@@ -0,0 +1,151 @@
+/**
+ * Multi-root workspace management
+ *
+ * Roots come from `--root`/`roots` in config and from the MCP client's roots
+ * list. Servers that support workspaceFolders get didChangeWorkspaceFolders;
+ * for the others a separate process is started per root and added to the
+ * routing client.
+ */
+
+import { isAbsolute, resolve } from "path";
+import type { ClientRoute, LSPClient } from "@internal/lsp-client";
+import { debugLogWithPrefix, errorLog } from "./debugLog.ts";
+
+type RoutingClient = LSPClient & {
+  addRoute(route: ClientRoute<LSPClient>): void;
+  removeRoute(client: LSPClient): void;
+};
+
+export interface RootServerGroup {
+  /** Preset id of the server */
+  id: string;
+  /** Server started for the primary root */
+  client: LSPClient;
+  extensions: string[];
+  /** Start another process of this server for a root */
+  startForRoot: (root: string) => Promise<LSPClient>;
+  /** Called before a per-root server is stopped */
+  onStop?: (client: LSPClient) => void;
+}
+
+/**
+ * Resolve configured roots against the project root, dropping duplicates
+ */
+export function resolveRoots(projectRoot: string, roots: string[]): string[] {
+  const resolved = roots.map((root) =>
+    isAbsolute(root) ? root : resolve(projectRoot, root),
+  );
+  return Array.from(new Set([projectRoot, ...resolved]));
+}
+
+export class WorkspaceRoots {
+  /** Current roots, primary root first (shared with McpContext.roots) */
+  readonly roots: string[];
+  private perRootClients = new Map<string, Map<string, LSPClient>>();
+
+  constructor(
+    private router: RoutingClient,
+    private groups: RootServerGroup[],
+    primaryRoot: string,
+  ) {
+    this.roots = [primaryRoot];
+  }
+
+  /**
+   * Replace the set of additional roots. The primary root is always kept.
+   */
+  async setRoots(roots: string[]): Promise<void> {
+    const [primaryRoot] = this.roots;
+    const next = Array.from(new Set([primaryRoot, ...roots]));
+    const added = next.filter((root) => !this.roots.includes(root));
+    const removed = this.roots.filter((root) => !next.includes(root));
+    if (added.length === 0 && removed.length === 0) {
+      return;
+    }
+    this.roots.splice(0, this.roots.length, ...next);
+    debugLogWithPrefix(
+      "WorkspaceRoots",
+      `Roots: ${next.join(", ")} (added ${added.length}, removed ${removed.length})`,
+    );
+
+    for (const group of this.groups) {
+      if (group.client.supportsFeature("workspaceFolders")) {
+        group.client.changeWorkspaceFolders(added, removed);
+        continue;
+      }
+      await this.updatePerRootServers(group, added, removed);
+    }
+  }
+
+  private async updatePerRootServers(
+    group: RootServerGroup,
+    added: string[],
+    removed: string[],
+  ): Promise<void> {
+    let clients = this.perRootClients.get(group.id);
+    if (!clients) {
+      clients = new Map();
+      this.perRootClients.set(group.id, clients);
+    }
+
+    for (const root of removed) {
+      const client = clients.get(root);
+      if (!client) continue;
+      clients.delete(root);
+      this.router.removeRoute(client);
+      group.onStop?.(client);
+      await client.stop().catch(() => {});
+    }
+
+    for (const root of added) {
+      try {
+        const client = await group.startForRoot(root);
+        clients.set(root, client);
+        this.router.addRoute({
+          id: group.id,
+          client,
+          extensions: group.extensions,
+          root,
+        });
+      } catch (error) {
+        errorLog(
+          `Failed to start ${group.id} for ${root}: ${
+            error instanceof Error ? error.message : String(error)
+          }`,
+        );
+      }
+    }
+  }
+
+  /**
+   * Track a restarted server in place of the old one
+   */
+  replaceClient(previous: LSPClient, next: LSPClient): void {
+    for (const group of this.groups) {
+      if (group.client === previous) {
+        group.client = next;
+      }
+    }
+    for (const clients of this.perRootClients.values()) {
+      for (const [root, client] of clients) {
+        if (client === previous) {
+          clients.set(root, next);
+        }
+      }
+    }
+  }
+
+  /**
+   * Stop the servers started for additional roots
+   */
+  async dispose(): Promise<void> {
+    for (const clients of this.perRootClients.values()) {
+      await Promise.all(
+        Array.from(clients.values()).map((client) =>
+          client.stop().catch(() => {}),
+        ),
+      );
+    }
+    this.perRootClients.clear();
+  }
+}
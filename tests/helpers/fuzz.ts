@@ -0,0 +1,121 @@
+/**
+ * Seeded fuzzing on top of Vitest
+ *
+ * `fuzz` registers a test that checks a property against many generated
+ * inputs. Every run gets its own seed, and a failure names the seed that
+ * reproduces it:
+ *
+ *   FUZZ_SEED=123456 FUZZ_RUNS=1 pnpm test:fuzz
+ *
+ * FUZZ_RUNS sets the number of runs per target (default 200); a fresh base
+ * seed is drawn for every test run unless FUZZ_SEED is set.
+ */
+
+import { it } from "vitest";
+
+const DEFAULT_RUNS = 200;
+
+/**
+ * Characters that tend to break text handling: multi-byte UTF-8, surrogate
+ * pairs, lone surrogates, line breaks of every kind, NUL and BOM
+ */
+const TRICKY_CHARACTERS = [
+  " ",
+  "\t",
+  "\n",
+  "\r",
+  "\r\n",
+  "\u0000",
+  "\u00e9",
+  "e\u0301",
+  "\u00a0",
+  "\ufeff",
+  "漢",
+  "😀",
+  "\ud800",
+  "\udc00",
+];
+
+export interface Random {
+  readonly seed: number;
+  /** Uniform in [0, 1) */
+  next(): number;
+  /** Integer in [min, max] */
+  int(min: number, max: number): number;
+  bool(probability?: number): boolean;
+  pick<T>(items: readonly T[]): T;
+  bytes(length: number): Buffer;
+  /** Up to maxLength pieces of ASCII and tricky characters */
+  text(maxLength: number): string;
+  /** The data cut at random points, empty chunks included */
+  chunks(data: Buffer): Buffer[];
+}
+
+/** mulberry32: small, fast and good enough for input generation */
+export function createRandom(seed: number): Random {
+  let state = seed >>> 0;
+  const next = () => {
+    state = (state + 0x6d2b79f5) >>> 0;
+    let t = state;
+    t = Math.imul(t ^ (t >>> 15), t | 1);
+    t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+    return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+  };
+  const int = (min: number, max: number) =>
+    min + Math.floor(next() * (max - min + 1));
+  const pick = <T>(items: readonly T[]) => items[int(0, items.length - 1)];
+
+  return {
+    seed,
+    next,
+    int,
+    pick,
+    bool: (probability = 0.5) => next() < probability,
+    bytes: (length) => Buffer.from(Array.from({ length }, () => int(0, 255))),
+    text: (maxLength) =>
+      Array.from({ length: int(0, maxLength) }, () =>
+        next() < 0.5
+          ? String.fromCharCode(int(0x20, 0x7e))
+          : pick(TRICKY_CHARACTERS),
+      ).join(""),
+    chunks: (data) => {
+      const chunks: Buffer[] = [];
+      let start = 0;
+      while (start < data.length) {
+        const end = Math.min(data.length, start + int(0, 16));
+        chunks.push(data.subarray(start, end));
+        start = end;
+      }
+      return chunks;
+    },
+  };
+}
+
+/**
+ * Register a test checking the property for FUZZ_RUNS generated inputs
+ */
+export function fuzz(
+  name: string,
+  property: (random: Random) => void | Promise<void>,
+  options: { runs?: number } = {},
+): void {
+  it(name, async () => {
+    const runs = Number(process.env.FUZZ_RUNS) || options.runs || DEFAULT_RUNS;
+    const base = process.env.FUZZ_SEED
+      ? Number(process.env.FUZZ_SEED)
+      : Math.floor(Math.random() * 4294967296);
+    for (let run = 0; run < runs; run++) {
+      const seed = (base + run) >>> 0;
+      try {
+        await property(createRandom(seed));
+      } catch (error) {
+        throw new Error(
+          `Failed on run ${run + 1} of ${runs}; reproduce with FUZZ_SEED=${seed} FUZZ_RUNS=1\n${
+            error instanceof Error ? error.message : String(error)
+          }`,
+          { cause: error },
+        );
+      }
+    }
+  });
+}
@@ -0,0 +1,91 @@
+/**
+ * Golden-file snapshots of tool responses
+ *
+ * A response is recorded as `<name>.txt` (its text content) and, unless it
+ * is a plain string, `<name>.json` (isError and structuredContent) under
+ * tests/golden/. Later runs must produce the same files byte for byte, so a
+ * change to how results are formatted, truncated or paginated shows up as a
+ * diff to review. Run with UPDATE_GOLDEN=1 (`pnpm test:golden:update`) to
+ * rewrite them after an intended change; missing files are written on the
+ * first run outside CI.
+ */
+
+import { existsSync, mkdirSync, readFileSync, writeFileSync } from "fs";
+import { dirname, join, relative } from "path";
+import { expect } from "vitest";
+
+export const GOLDEN_DIR = join(import.meta.dirname, "../golden");
+
+export interface ToolResponse {
+  content: Array<{ type: string; text?: string }>;
+  structuredContent?: unknown;
+  isError?: boolean;
+}
+
+export interface GoldenOptions {
+  /** Replace machine-specific text, e.g. a temp directory, before comparing */
+  replacements?: Record<string, string>;
+  /** Directory of the golden files (default: tests/golden) */
+  dir?: string;
+}
+
+function normalize(text: string, options: GoldenOptions): string {
+  let result = text.replace(/\r\n/g, "\n");
+  for (const [from, to] of Object.entries(options.replacements ?? {})) {
+    result = result.split(from).join(to);
+  }
+  return result.endsWith("\n") ? result : `${result}\n`;
+}
+
+function goldenFiles(output: ToolResponse | string): Record<string, string> {
+  if (typeof output === "string") {
+    return { ".txt": output };
+  }
+  const text = output.content
+    .map((item) => (item.type === "text" ? item.text : `[${item.type}]`))
+    .join("\n\n");
+  const meta = {
+    isError: output.isError ?? false,
+    structuredContent: output.structuredContent ?? null,
+  };
+  return {
+    ".txt": text,
+    ".json": JSON.stringify(meta, null, 2),
+  };
+}
+
+function shouldUpdate(): boolean {
+  return !!process.env.UPDATE_GOLDEN && process.env.UPDATE_GOLDEN !== "0";
+}
+
+/**
+ * Compare a tool response, or any formatted text, with its golden files
+ * @param name path of the golden files without extension, e.g.
+ *   "lsp_request/raw-result"
+ */
+export function expectGolden(
+  name: string,
+  output: ToolResponse | string,
+  options: GoldenOptions = {},
+): void {
+  const dir = options.dir ?? GOLDEN_DIR;
+  for (const [extension, content] of Object.entries(goldenFiles(output))) {
+    const file = join(dir, `${name}${extension}`);
+    const actual = normalize(content, options);
+    if (shouldUpdate() || (!existsSync(file) && !process.env.CI)) {
+      mkdirSync(dirname(file), { recursive: true });
+      writeFileSync(file, actual);
+      continue;
+    }
+    const label = relative(process.cwd(), file);
+    if (!existsSync(file)) {
+      throw new Error(
+        `Golden file ${label} is missing; run with UPDATE_GOLDEN=1 to record it`,
+      );
+    }
+    expect(
+      actual,
+      `${label} differs; run with UPDATE_GOLDEN=1 if the change is intended`,
+    ).toBe(readFileSync(file, "utf-8").replace(/\r\n/g, "\n"));
+  }
+}
@@ -0,0 +1,406 @@
+/**
+ * Adapter conformance harness
+ *
+ * Each fixture project with a conformance.json (tests/fixtures/<name>/) is
+ * run against the presets it names, checking the same feature matrix for
+ * every adapter: definitions, references, hover, rename, diagnostics and
+ * document symbols. A new adapter plugs in with a fixture and an
+ * expectations file; features the file leaves out are not checked.
+ */
+
+import { spawn, spawnSync, type ChildProcess } from "child_process";
+import { existsSync, readdirSync, readFileSync } from "fs";
+import { isAbsolute, join, relative } from "path";
+import { fileURLToPath, pathToFileURL } from "url";
+import { createLSPClient, type LSPClient } from "@internal/lsp-client";
+import type {
+  Diagnostic,
+  DocumentSymbol,
+  Location,
+  LocationLink,
+  SymbolInformation,
+  WorkspaceEdit,
+} from "@internal/types";
+import type { LspClientConfig } from "../../src/config/schema.ts";
+import { resolveAdapterCommand } from "../../src/presets/utils.ts";
+
+/** 1-based line number, or text contained in the line */
+export type LineSpec = number | string;
+
+interface SymbolAt {
+  /** Relative to the fixture root */
+  file: string;
+  line: LineSpec;
+  /** The position is the start of its first occurrence on the line */
+  symbol: string;
+}
+
+export interface ConformanceExpectations {
+  /** Ids of the presets that must pass with this fixture */
+  presets: string[];
+  /** Milliseconds to wait for the server to analyze the opened files */
+  readyTimeout?: number;
+  definitions?: Array<SymbolAt & { target: { file: string; line: number } }>;
+  /** Reference counts include the declaration */
+  references?: Array<SymbolAt & { count: number }>;
+  hover?: Array<SymbolAt & { contains: string }>;
+  /** Edits the rename returns; nothing is written */
+  rename?: Array<SymbolAt & { newName: string; edits: number }>;
+  diagnostics?: Array<{
+    file: string;
+    line: LineSpec;
+    severity?: number;
+    contains?: string;
+  }>;
+  /** Names that must be among the symbols, nested ones included */
+  documentSymbols?: Array<{ file: string; symbols: string[] }>;
+}
+
+export const CONFORMANCE_FEATURES = [
+  "definitions",
+  "references",
+  "hover",
+  "rename",
+  "diagnostics",
+  "documentSymbols",
+] as const;
+
+export type ConformanceFeature = (typeof CONFORMANCE_FEATURES)[number];
+
+export interface ConformanceCase {
+  /** Fixture directory name */
+  name: string;
+  root: string;
+  expectations: ConformanceExpectations;
+}
+
+export interface FixtureSession {
+  client: LSPClient;
+  root: string;
+  expectations: ConformanceExpectations;
+  /** Latest published diagnostics by URI */
+  diagnostics: Map<string, Diagnostic[]>;
+}
+
+export const EXPECTATIONS_FILE = "conformance.json";
+
+/**
+ * Fixture projects that have an expectations file
+ */
+export function loadConformanceCases(fixturesDir: string): ConformanceCase[] {
+  return readdirSync(fixturesDir, { withFileTypes: true })
+    .filter((entry) => entry.isDirectory())
+    .map((entry) => join(fixturesDir, entry.name, EXPECTATIONS_FILE))
+    .filter((file) => existsSync(file))
+    .map((file) => {
+      const root = join(file, "..");
+      return {
+        name: relative(fixturesDir, root),
+        root,
+        expectations: JSON.parse(readFileSync(file, "utf-8")),
+      };
+    })
+    .sort((a, b) => a.name.localeCompare(b.name));
+}
+
+/**
+ * Whether the adapter's server command can be run here
+ */
+export function isAdapterAvailable(
+  adapter: LspClientConfig,
+  root: string,
+): boolean {
+  try {
+    const { command } = resolveAdapterCommand(adapter, root);
+    if (isAbsolute(command) || command.includes("/")) {
+      return existsSync(command);
+    }
+    const which = process.platform === "win32" ? "where" : "which";
+    return spawnSync(which, [command], { stdio: "ignore" }).status === 0;
+  } catch {
+    return false;
+  }
+}
+
+export async function startAdapter(
+  adapter: LspClientConfig,
+  root: string,
+): Promise<{ client: LSPClient; process: ChildProcess }> {
+  const { command, args } = resolveAdapterCommand(adapter, root);
+  const lspProcess = spawn(command, args, {
+    cwd: root,
+    stdio: ["pipe", "pipe", "pipe"],
+  });
+  const client = createLSPClient({
+    rootPath: root,
+    process: lspProcess,
+    languageId: adapter.baseLanguage || (adapter as any).presetId || "unknown",
+    initializationOptions: adapter.initializationOptions as
+      | Record<string, unknown>
+      | undefined,
+    serverCharacteristics: adapter.serverCharacteristics,
+  });
+  await client.start();
+  return { client, process: lspProcess };
+}
+
+function fileUri(root: string, file: string): string {
+  return pathToFileURL(join(root, file)).href;
+}
+
+function relativeFile(root: string, uri: string): string {
+  return relative(root, fileURLToPath(uri)).split("\\").join("/");
+}
+
+/** Every file the expectations refer to */
+function expectedFiles(expectations: ConformanceExpectations): string[] {
+  const files = new Set<string>();
+  for (const feature of CONFORMANCE_FEATURES) {
+    for (const entry of expectations[feature] ?? []) {
+      files.add(entry.file);
+    }
+  }
+  return [...files];
+}
+
+/**
+ * Open the files of the expectations and wait until the server published
+ * diagnostics for all of them, or readyTimeout passed
+ */
+export async function openFixture(
+  client: LSPClient,
+  root: string,
+  expectations: ConformanceExpectations,
+): Promise<FixtureSession> {
+  const session: FixtureSession = {
+    client,
+    root,
+    expectations,
+    diagnostics: new Map(),
+  };
+  const files = expectedFiles(expectations);
+  const uris = new Set(files.map((file) => fileUri(root, file)));
+
+  let onReady!: () => void;
+  const ready = new Promise<void>((resolve) => (onReady = resolve));
+  client.on("diagnostics", (params) => {
+    session.diagnostics.set(params.uri, params.diagnostics);
+    if ([...uris].every((uri) => session.diagnostics.has(uri))) {
+      onReady();
+    }
+  });
+  for (const file of files) {
+    client.openDocument(
+      fileUri(root, file),
+      readFileSync(join(root, file), "utf-8"),
+    );
+  }
+
+  let timer: NodeJS.Timeout | undefined;
+  await Promise.race([
+    ready,
+    new Promise<void>((resolve) => {
+      timer = setTimeout(resolve, expectations.readyTimeout ?? 5000);
+    }),
+  ]);
+  clearTimeout(timer);
+  return session;
+}
+
+export function closeFixture(session: FixtureSession): void {
+  for (const file of expectedFiles(session.expectations)) {
+    session.client.closeDocument(fileUri(session.root, file));
+  }
+}
+
+function lineIndex(lines: string[], line: LineSpec): number {
+  if (typeof line === "number") {
+    return line - 1;
+  }
+  return lines.findIndex((text) => text.includes(line));
+}
+
+function locate(session: FixtureSession, at: SymbolAt) {
+  const lines = readFileSync(join(session.root, at.file), "utf-8").split("\n");
+  const line = lineIndex(lines, at.line);
+  const character = line >= 0 ? (lines[line] ?? "").indexOf(at.symbol) : -1;
+  if (character === -1) {
+    throw new Error(`"${at.symbol}" is not on line ${JSON.stringify(at.line)}`);
+  }
+  return { uri: fileUri(session.root, at.file), position: { line, character } };
+}
+
+function toLocations(
+  result: Location | Location[] | LocationLink[] | null,
+): Location[] {
+  if (!result) {
+    return [];
+  }
+  return (Array.isArray(result) ? result : [result]).map((item) =>
+    "targetUri" in item
+      ? {
+          uri: item.targetUri,
+          range: item.targetSelectionRange ?? item.targetRange,
+        }
+      : item,
+  );
+}
+
+function countEdits(edit: WorkspaceEdit): number {
+  let count = 0;
+  for (const edits of Object.values(edit.changes ?? {})) {
+    count += edits.length;
+  }
+  for (const change of edit.documentChanges ?? []) {
+    if ("edits" in change) {
+      count += change.edits.length;
+    }
+  }
+  return count;
+}
+
+function symbolNames(
+  symbols: Array<DocumentSymbol | SymbolInformation>,
+): string[] {
+  return symbols.flatMap((symbol) => [
+    symbol.name,
+    ...("children" in symbol ? symbolNames(symbol.children ?? []) : []),
+  ]);
+}
+
+function hoverText(contents: unknown): string {
+  if (typeof contents === "string") {
+    return contents;
+  }
+  if (Array.isArray(contents)) {
+    return contents.map(hoverText).join("\n");
+  }
+  return (contents as { value?: string })?.value ?? "";
+}
+
+/**
+ * Failures of one feature's expectations; empty when all of them hold
+ */
+export async function checkFeature(
+  session: FixtureSession,
+  feature: ConformanceFeature,
+): Promise<string[]> {
+  const { client, root, expectations } = session;
+  const failures: string[] = [];
+  const check = async (label: string, run: () => Promise<string | void>) => {
+    try {
+      const failure = await run();
+      if (failure) {
+        failures.push(`${label}: ${failure}`);
+      }
+    } catch (error) {
+      failures.push(
+        `${label}: ${error instanceof Error ? error.message : String(error)}`,
+      );
+    }
+  };
+
+  switch (feature) {
+    case "definitions":
+      for (const entry of expectations.definitions ?? []) {
+        await check(`${entry.file} ${entry.symbol}`, async () => {
+          const { uri, position } = locate(session, entry);
+          const locations = toLocations(
+            await client.getDefinition(uri, position),
+          );
+          const found = locations.some(
+            (location) =>
+              relativeFile(root, location.uri) === entry.target.file &&
+              location.range.start.line + 1 === entry.target.line,
+          );
+          if (!found) {
+            const got = locations.map(
+              (l) => `${relativeFile(root, l.uri)}:${l.range.start.line + 1}`,
+            );
+            return `expected ${entry.target.file}:${entry.target.line}, got ${got.join(", ") || "nothing"}`;
+          }
+        });
+      }
+      break;
+    case "references":
+      for (const entry of expectations.references ?? []) {
+        await check(`${entry.file} ${entry.symbol}`, async () => {
+          const { uri, position } = locate(session, entry);
+          const references = await client.findReferences(uri, position, {
+            includeDeclaration: true,
+          });
+          if (references.length !== entry.count) {
+            return `expected ${entry.count} references, got ${references.length}`;
+          }
+        });
+      }
+      break;
+    case "hover":
+      for (const entry of expectations.hover ?? []) {
+        await check(`${entry.file} ${entry.symbol}`, async () => {
+          const { uri, position } = locate(session, entry);
+          const hover = await client.getHover(uri, position);
+          const text = hoverText(hover?.contents);
+          if (!text.includes(entry.contains)) {
+            return `expected hover containing ${JSON.stringify(entry.contains)}, got ${JSON.stringify(text)}`;
+          }
+        });
+      }
+      break;
+    case "rename":
+      for (const entry of expectations.rename ?? []) {
+        await check(`${entry.file} ${entry.symbol}`, async () => {
+          const { uri, position } = locate(session, entry);
+          const edit = await client.rename(uri, position, entry.newName);
+          const edits = edit ? countEdits(edit) : 0;
+          if (edits !== entry.edits) {
+            return `expected ${entry.edits} edits, got ${edits}`;
+          }
+        });
+      }
+      break;
+    case "diagnostics":
+      for (const entry of expectations.diagnostics ?? []) {
+        await check(`${entry.file}:${entry.line}`, async () => {
+          const uri = fileUri(root, entry.file);
+          const lines = readFileSync(join(root, entry.file), "utf-8").split(
+            "\n",
+          );
+          const line = lineIndex(lines, entry.line);
+          const diagnostics = client.getDiagnosticSupport().pullDiagnostics
+            ? await client.pullDiagnostics(uri)
+            : (session.diagnostics.get(uri) ?? client.getDiagnostics(uri));
+          const found = diagnostics.some(
+            (d) =>
+              d.range.start.line === line &&
+              (entry.severity === undefined || d.severity === entry.severity) &&
+              (entry.contains === undefined ||
+                d.message.includes(entry.contains)),
+          );
+          if (!found) {
+            const got = diagnostics.map(
+              (d) => `${d.range.start.line + 1}: ${d.message}`,
+            );
+            return `no matching diagnostic among [${got.join("; ")}]`;
+          }
+        });
+      }
+      break;
+    case "documentSymbols":
+      for (const entry of expectations.documentSymbols ?? []) {
+        await check(entry.file, async () => {
+          const names = new Set(
+            symbolNames(
+              await client.getDocumentSymbols(fileUri(root, entry.file)),
+            ),
+          );
+          const missing = entry.symbols.filter((name) => !names.has(name));
+          if (missing.length > 0) {
+            return `missing symbols ${missing.join(", ")}`;
+          }
+        });
+      }
+      break;
+  }
+  return failures;
+}
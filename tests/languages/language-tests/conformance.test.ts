@@ -0,0 +1,48 @@
+import { describe, expect, it, beforeAll, afterAll } from "vitest";
+import { join } from "path";
+import type { ChildProcess } from "child_process";
+import { globalPresetRegistry } from "../../../src/config/loader.ts";
+import {
+  CONFORMANCE_FEATURES,
+  checkFeature,
+  closeFixture,
+  isAdapterAvailable,
+  loadConformanceCases,
+  openFixture,
+  startAdapter,
+  type FixtureSession,
+} from "../conformance.ts";
+
+const fixturesDir = join(import.meta.dirname, "../../fixtures");
+
+for (const { name, root, expectations } of loadConformanceCases(fixturesDir)) {
+  for (const presetId of expectations.presets) {
+    const adapter = globalPresetRegistry.get(presetId);
+    const available = !!adapter && isAdapterAvailable(adapter, root);
+
+    describe.skipIf(!available)(`${presetId} conformance (${name})`, () => {
+      let session: FixtureSession;
+      let lspProcess: ChildProcess;
+
+      beforeAll(async () => {
+        const started = await startAdapter(adapter!, root);
+        lspProcess = started.process;
+        session = await openFixture(started.client, root, expectations);
+      }, 60000);
+
+      afterAll(async () => {
+        if (session) {
+          closeFixture(session);
+          await session.client.stop();
+        }
+        lspProcess?.kill();
+      });
+
+      for (const feature of CONFORMANCE_FEATURES) {
+        it.skipIf(!expectations[feature])(feature, async () => {
+          expect(await checkFeature(session, feature)).toEqual([]);
+        });
+      }
+    });
+  }
+}
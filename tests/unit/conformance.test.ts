@@ -0,0 +1,143 @@
+import { describe, it, expect, beforeAll, afterAll } from "vitest";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "fs";
+import { tmpdir } from "os";
+import { join } from "path";
+import { pathToFileURL } from "url";
+import { MockLanguageServer } from "@internal/mock-lsp-server";
+import { createLSPClient, type LSPClient } from "@internal/lsp-client";
+import {
+  checkFeature,
+  loadConformanceCases,
+  openFixture,
+  type ConformanceExpectations,
+} from "../languages/conformance.ts";
+
+const source = `package main
+
+func helper() int {
+	return 1
+}
+
+func main() {
+	helper()
+}
+`;
+
+const range = (line: number, start: number, end: number) => ({
+  start: { line, character: start },
+  end: { line, character: end },
+});
+
+describe("adapter conformance harness", () => {
+  let fixturesDir: string;
+  let root: string;
+  let uri: string;
+  let client: LSPClient;
+
+  const expectations: ConformanceExpectations = {
+    presets: ["mock"],
+    readyTimeout: 1000,
+    definitions: [
+      {
+        file: "main.go",
+        line: "\thelper()",
+        symbol: "helper",
+        target: { file: "main.go", line: 3 },
+      },
+    ],
+    references: [{ file: "main.go", line: 3, symbol: "helper", count: 2 }],
+    hover: [
+      {
+        file: "main.go",
+        line: 3,
+        symbol: "helper",
+        contains: "func helper() int",
+      },
+    ],
+    rename: [
+      { file: "main.go", line: 3, symbol: "helper", newName: "one", edits: 2 },
+    ],
+    diagnostics: [
+      { file: "main.go", line: "return 1", severity: 1, contains: "unused" },
+    ],
+    documentSymbols: [{ file: "main.go", symbols: ["helper", "main", "x"] }],
+  };
+
+  beforeAll(async () => {
+    fixturesDir = mkdtempSync(join(tmpdir(), "lsmcp-conformance-"));
+    root = join(fixturesDir, "mock");
+    uri = pathToFileURL(join(root, "main.go")).href;
+    mkdirSync(root);
+    writeFileSync(join(root, "main.go"), source);
+    writeFileSync(join(root, "conformance.json"), JSON.stringify(expectations));
+
+    const server = new MockLanguageServer({
+      responses: {
+        "textDocument/definition": () => [
+          {
+            targetUri: uri,
+            targetRange: range(2, 0, 4),
+            targetSelectionRange: range(2, 5, 11),
+          },
+        ],
+        "textDocument/references": () => [
+          { uri, range: range(2, 5, 11) },
+          { uri, range: range(7, 1, 7) },
+        ],
+        "textDocument/hover": { contents: "func helper() int" },
+        "textDocument/rename": () => ({
+          changes: {
+            [uri]: [
+              { range: range(2, 5, 11), newText: "one" },
+              { range: range(7, 1, 7), newText: "one" },
+            ],
+          },
+        }),
+        "textDocument/documentSymbol": [
+          { name: "helper", kind: 12, range: range(2, 0, 4) },
+          { name: "main", kind: 12, range: range(6, 0, 8) },
+        ],
+      },
+    }).respond("textDocument/didOpen", (params, mock) => {
+      mock.publishDiagnostics(params.textDocument.uri, [
+        { range: range(3, 1, 9), severity: 1, message: "unused result" },
+      ]);
+    });
+    client = createLSPClient({
+      process: server.process,
+      rootPath: root,
+      languageId: "go",
+      serverCharacteristics: { readinessCheckTimeout: 1 },
+    });
+    await client.start();
+  });
+
+  afterAll(async () => {
+    await client?.stop();
+    rmSync(fixturesDir, { recursive: true, force: true });
+  });
+
+  it("should load fixtures that have an expectations file", () => {
+    expect(loadConformanceCases(fixturesDir)).toEqual([
+      { name: "mock", root, expectations },
+    ]);
+  });
+
+  it("should pass the features the server gets right and report the rest", async () => {
+    const session = await openFixture(client, root, expectations);
+    expect(session.diagnostics.get(uri)).toHaveLength(1);
+
+    for (const feature of [
+      "definitions",
+      "references",
+      "hover",
+      "rename",
+      "diagnostics",
+    ] as const) {
+      expect(await checkFeature(session, feature)).toEqual([]);
+    }
+    expect(await checkFeature(session, "documentSymbols")).toEqual([
+      "main.go: missing symbols x",
+    ]);
+  });
+});
@@ -29,6 +29,13 @@ export default defineConfig({
           "packages/lsp-client/src/index.ts",
         ),
       },
+      {
+        find: "@internal/mock-lsp-server",
+        replacement: path.resolve(
+          __dirname,
+          "packages/mock-lsp-server/src/index.ts",
+        ),
+      },
       {
         find: "@internal/types",
         replacement: path.resolve(__dirname, "packages/types/src/index.ts"),
@@ -105,6 +112,16 @@ export default defineConfig({
           },
         },
       },
+      {
+        extends: true,
+        test: {
+          name: "fuzz",
+          include: ["src/**/*.fuzz.ts", "packages/**/*.fuzz.ts"],
+          exclude: [...GLOBAL_IGNORED_FILES],
+          // FUZZ_RUNS raises the number of inputs per target
+          testTimeout: 60000,
+        },
+      },
       {
         extends: true,
         test: {